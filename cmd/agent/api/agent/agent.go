@@ -20,16 +20,20 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
 	"github.com/DataDog/datadog-agent/cmd/agent/gui"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/flare"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/status"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/buffer"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -50,8 +54,14 @@ func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/gui/csrf-token", getCSRFToken).Methods("GET")
 	r.HandleFunc("/config-check", getConfigCheck).Methods("GET")
 	r.HandleFunc("/config", getRuntimeConfig).Methods("GET")
+	r.HandleFunc("/config/origin", config.ValueWithOriginHandler(config.Datadog)).Methods("GET")
 	r.HandleFunc("/tagger-list", getTaggerList).Methods("GET")
 	r.HandleFunc("/secrets", secretInfo).Methods("GET")
+	r.HandleFunc("/settings/buffers", getBuffers).Methods("GET")
+	r.HandleFunc("/top/metrics", getTopMetrics).Methods("GET")
+	r.HandleFunc("/forwarder/acks", getForwarderAcks).Methods("GET")
+	r.HandleFunc("/settings/buffers/{name}", setBufferSize).Methods("POST")
+	r.HandleFunc("/kubernetes/purge-deleted-pods", purgeDeletedPods).Methods("POST")
 }
 
 func stopAgent(w http.ResponseWriter, r *http.Request) {
@@ -311,3 +321,97 @@ func max(a, b int) int {
 	}
 	return b
 }
+
+// purgeDeletedPods deletes the entities of the deleted pods from the tagger
+// right away, autodiscovery unschedules their checks at its next poll
+func purgeDeletedPods(w http.ResponseWriter, r *http.Request) {
+	log.Infof("Purging the deleted pods, as requested by the API")
+	kubelet.PurgeDeletedPods()
+	tagger.Purge()
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal("")
+	w.Write(j)
+}
+
+func getBuffers(w http.ResponseWriter, r *http.Request) {
+	jsonStats, err := json.Marshal(buffer.GetStats())
+	if err != nil {
+		log.Errorf("Unable to marshal buffers stats: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+	w.Write(jsonStats)
+}
+
+func setBufferSize(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	b := buffer.Get(name)
+	if b == nil {
+		body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("unknown buffer %q", name)})
+		http.Error(w, string(body), 404)
+		return
+	}
+
+	var payload struct {
+		Size int `json:"size"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 400)
+		return
+	}
+	if err := b.SetSize(payload.Size); err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 400)
+		return
+	}
+	log.Infof("Buffer %s resized to %d", name, payload.Size)
+
+	jsonStats, _ := json.Marshal(b.Stats())
+	w.Write(jsonStats)
+}
+
+func getTopMetrics(w http.ResponseWriter, r *http.Request) {
+	filter, err := aggregator.ParseRecentSeriesFilter(r.URL.Query()["filter"])
+	if err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 400)
+		return
+	}
+
+	series, err := aggregator.GetRecentSeries(filter)
+	if err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	jsonSeries, err := json.Marshal(series)
+	if err != nil {
+		log.Errorf("Unable to marshal the recent series: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+	w.Write(jsonSeries)
+}
+
+func getForwarderAcks(w http.ResponseWriter, r *http.Request) {
+	acks, err := forwarder.GetAcknowledgments()
+	if err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	jsonAcks, err := json.Marshal(acks)
+	if err != nil {
+		log.Errorf("Unable to marshal the forwarder acknowledgments: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+	w.Write(jsonAcks)
+}
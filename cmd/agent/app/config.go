@@ -8,6 +8,13 @@ package app
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
@@ -20,7 +27,14 @@ var (
 	configJSON bool
 )
 
+const (
+	// the default ports of the other agents, when they're not set in the configuration
+	defaultProcessExpVarPort = 6062
+	defaultTraceReceiverPort = 8126
+)
+
 func init() {
+	configCommand.AddCommand(configCheckOriginCommand)
 	AgentCmd.AddCommand(configCommand)
 }
 
@@ -82,3 +96,147 @@ func requestConfig() (string, error) {
 
 	return string(r), nil
 }
+
+var configCheckOriginCommand = &cobra.Command{
+	Use:   "check-origin <key>",
+	Short: "Print the value and the origin of a configuration key in each running agent",
+	Long: `Query the core, process and trace agents for the effective value of a configuration
+key and where it comes from, as each agent resolves its configuration independently.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		err = util.SetAuthToken()
+		if err != nil {
+			return err
+		}
+
+		renderValueOrigins(color.Output, args[0], requestValueOrigins(args[0]))
+		return nil
+	},
+}
+
+// agentValueOrigin is the value and origin of a key in an agent, or the error
+// querying it
+type agentValueOrigin struct {
+	agent string
+	value config.ValueWithOrigin
+	err   error
+}
+
+func requestValueOrigins(key string) []agentValueOrigin {
+	query := "?key=" + url.QueryEscape(key)
+	results := []agentValueOrigin{}
+
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		results = append(results, agentValueOrigin{agent: "core", err: err})
+	} else {
+		coreURL := fmt.Sprintf("https://%v:%v/agent/config/origin", ipcAddress, config.Datadog.GetInt("cmd_port"))
+		c := util.GetClient(false)
+		results = append(results, requestValueOrigin("core", func() ([]byte, error) { return util.DoGet(c, coreURL+query) }))
+	}
+
+	// the process and trace agents only listen on localhost, without
+	// authentication: don't send them the auth token
+	processPort := defaultProcessExpVarPort
+	if config.Datadog.IsSet("process_config.expvar_port") {
+		processPort = config.Datadog.GetInt("process_config.expvar_port")
+	}
+	processURL := fmt.Sprintf("http://localhost:%d/config/origin", processPort)
+	results = append(results, requestValueOrigin("process", func() ([]byte, error) { return doGetWithoutAuth(processURL + query) }))
+
+	tracePort := defaultTraceReceiverPort
+	if config.Datadog.IsSet("apm_config.receiver_port") {
+		tracePort = config.Datadog.GetInt("apm_config.receiver_port")
+	}
+	traceURL := fmt.Sprintf("http://localhost:%d/debug/config/origin", tracePort)
+	results = append(results, requestValueOrigin("trace", func() ([]byte, error) { return doGetWithoutAuth(traceURL + query) }))
+
+	return results
+}
+
+func doGetWithoutAuth(apiURL string) ([]byte, error) {
+	c := &http.Client{Timeout: 5 * time.Second}
+	r, err := c.Get(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return body, err
+	}
+	if r.StatusCode != http.StatusOK {
+		return body, fmt.Errorf("%s", body)
+	}
+	return body, nil
+}
+
+func requestValueOrigin(agent string, get func() ([]byte, error)) agentValueOrigin {
+	result := agentValueOrigin{agent: agent}
+
+	r, err := get()
+	if err != nil {
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap)
+		if e, found := errMap["error"]; found {
+			result.err = fmt.Errorf(e)
+		} else {
+			result.err = fmt.Errorf("not reachable, is the agent running?")
+		}
+		return result
+	}
+
+	result.err = json.Unmarshal(r, &result.value)
+	return result
+}
+
+// renderValueOrigins prints the value and origin of the key in each agent,
+// flagging the values that differ from the one of the first agent answering
+func renderValueOrigins(w io.Writer, key string, results []agentValueOrigin) {
+	var reference *config.ValueWithOrigin
+	mismatch := false
+
+	table := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(table, "Agent\tValue\tOrigin\t")
+	for i, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(table, "%s\t\t%s\t\n", result.agent, color.YellowString(result.err.Error()))
+			continue
+		}
+
+		origin := result.value.Origin
+		if result.value.EnvVar != "" {
+			origin = fmt.Sprintf("%s (%s)", origin, result.value.EnvVar)
+		}
+		flag := ""
+		if reference == nil {
+			reference = &results[i].value
+		} else if result.value.Value != reference.Value {
+			mismatch = true
+			flag = color.RedString("mismatch")
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", result.agent, strings.Replace(result.value.Value, "\n", " ", -1), origin, flag)
+	}
+	table.Flush()
+
+	if mismatch {
+		fmt.Fprintln(w, color.RedString("\nThe value of %s differs between the agents", key))
+	}
+}
@@ -7,10 +7,12 @@ package app
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/diagnose"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -18,8 +20,20 @@ import (
 
 func init() {
 	AgentCmd.AddCommand(diagnoseCommand)
+	diagnoseCommand.AddCommand(diagnoseKubeRBACCommand)
+	diagnoseCommand.AddCommand(diagnoseContainerFilterCommand)
+
+	diagnoseContainerFilterCommand.Flags().StringVar(&filterContainer.Name, "name", "", "name of the container")
+	diagnoseContainerFilterCommand.Flags().StringVar(&filterContainer.Image, "image", "", "image of the container")
+	diagnoseContainerFilterCommand.Flags().StringVar(&filterContainer.Namespace, "namespace", "", "kubernetes namespace of the container")
+	diagnoseContainerFilterCommand.Flags().StringSliceVar(&filterContainerLabels, "label", nil, "label of the container, as key=value, can be repeated")
 }
 
+var (
+	filterContainer       containers.FilterableContainer
+	filterContainerLabels []string
+)
+
 var diagnoseCommand = &cobra.Command{
 	Use:   "diagnose",
 	Short: "Execute some connectivity diagnosis on your system",
@@ -27,7 +41,57 @@ var diagnoseCommand = &cobra.Command{
 	RunE:  doDiagnose,
 }
 
+var diagnoseKubeRBACCommand = &cobra.Command{
+	Use:   "kube-rbac",
+	Short: "Review the permissions needed on the Kubernetes API server",
+	Long: `Review with SelfSubjectAccessReviews the permissions the features enabled in the
+configuration need on the Kubernetes API server, and print the granted and denied ones.`,
+	RunE: doDiagnoseKubeRBAC,
+}
+
+var diagnoseContainerFilterCommand = &cobra.Command{
+	Use:   "container-filter",
+	Short: "Show which container filter rules exclude a container",
+	Long: `Show, for the global container filter and for the metrics, logs and autodiscovery
+scopes, whether a container is excluded and the include or exclude rule that decided it.`,
+	RunE: doDiagnoseContainerFilter,
+}
+
 func doDiagnose(cmd *cobra.Command, args []string) error {
+	if err := setupDiagnose(); err != nil {
+		return err
+	}
+	return diagnose.RunAll(color.Output)
+}
+
+func doDiagnoseKubeRBAC(cmd *cobra.Command, args []string) error {
+	if err := setupDiagnose(); err != nil {
+		return err
+	}
+	return diagnose.RunKubeRBAC(color.Output, false)
+}
+
+func doDiagnoseContainerFilter(cmd *cobra.Command, args []string) error {
+	if filterContainer.Name == "" && filterContainer.Image == "" {
+		return fmt.Errorf("please provide the name or the image of the container")
+	}
+	filterContainer.Labels = make(map[string]string, len(filterContainerLabels))
+	for _, label := range filterContainerLabels {
+		kv := strings.SplitN(label, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid label %q, the format is key=value", label)
+		}
+		filterContainer.Labels[kv[0]] = kv[1]
+	}
+
+	if err := setupDiagnose(); err != nil {
+		return err
+	}
+	return diagnose.RunContainerFilter(color.Output, filterContainer)
+}
+
+// setupDiagnose loads the configuration and sets up the logger of the diagnosis
+func setupDiagnose() error {
 	// Global config setup
 	err := common.SetupConfig(confFilePath)
 	if err != nil {
@@ -50,6 +114,5 @@ func doDiagnose(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("Error while setting up logging, exiting: %v", err)
 	}
-
-	return diagnose.RunAll(color.Output)
+	return nil
 }
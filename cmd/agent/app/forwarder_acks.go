@@ -0,0 +1,152 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	forwarderAcksJSON     bool
+	forwarderAcksChecksum string
+	forwarderAcksFailed   bool
+)
+
+func init() {
+	AgentCmd.AddCommand(forwarderCmd)
+	forwarderCmd.AddCommand(forwarderAcksCmd)
+	forwarderAcksCmd.Flags().BoolVarP(&forwarderAcksJSON, "json", "j", false, "print out raw json")
+	forwarderAcksCmd.Flags().StringVarP(&forwarderAcksChecksum, "checksum", "c", "", "only print the attempts to deliver the payload of this checksum")
+	forwarderAcksCmd.Flags().BoolVarP(&forwarderAcksFailed, "failed", "f", false, "only print the attempts which didn't get a 2xx response")
+}
+
+var forwarderCmd = &cobra.Command{
+	Use:   "forwarder",
+	Short: "Print what the forwarder of a running agent sent",
+	Long:  ``,
+}
+
+var forwarderAcksCmd = &cobra.Command{
+	Use:   "acks",
+	Short: "Print the attempts to deliver the payloads in the last forwarder_ack_log.retention seconds",
+	Long: `Print the attempts of a running agent to deliver the payloads in the last forwarder_ack_log.retention
+seconds, the most recent first, with their transaction id, endpoint, HTTP status and latency, to prove
+whether a payload left the host. The payload checksums are printed when forwarder_payload_checksum is set.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return requestForwarderAcks()
+	},
+}
+
+func requestForwarderAcks() error {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return err
+	}
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+
+	urlstr := fmt.Sprintf("https://%v:%v/agent/forwarder/acks", ipcAddress, config.Datadog.GetInt("cmd_port"))
+
+	r, err := util.DoGet(c, urlstr)
+	if err != nil {
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap)
+		// If the error has been marshalled into a json object, check it and return it properly
+		if e, found := errMap["error"]; found {
+			err = fmt.Errorf(e)
+		}
+		fmt.Printf("Could not get the acknowledgments from the agent: %v\n", err)
+		return err
+	}
+
+	var acks []forwarder.Acknowledgment
+	if err := json.Unmarshal(r, &acks); err != nil {
+		return err
+	}
+	acks = filterForwarderAcks(acks, forwarderAcksChecksum, forwarderAcksFailed)
+
+	if forwarderAcksJSON {
+		jsonAcks, err := json.Marshal(acks)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonAcks))
+		return nil
+	}
+
+	printForwarderAcks(acks)
+	return nil
+}
+
+func filterForwarderAcks(acks []forwarder.Acknowledgment, checksum string, failed bool) []forwarder.Acknowledgment {
+	filtered := []forwarder.Acknowledgment{}
+	for _, ack := range acks {
+		if checksum != "" && !strings.HasPrefix(ack.Checksum, checksum) {
+			continue
+		}
+		if failed && ack.StatusCode >= 200 && ack.StatusCode < 300 {
+			continue
+		}
+		filtered = append(filtered, ack)
+	}
+	return filtered
+}
+
+func printForwarderAcks(acks []forwarder.Acknowledgment) {
+	if len(acks) == 0 {
+		fmt.Fprintln(color.Output, "No attempt to deliver a payload recently matches the filters")
+		return
+	}
+
+	w := tabwriter.NewWriter(color.Output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SENT\tTRANSACTION\tATTEMPT\tENDPOINT\tSTATUS\tLATENCY\tSIZE\tCHECKSUM")
+	for _, ack := range acks {
+		status := fmt.Sprintf("%d", ack.StatusCode)
+		if ack.Error != "" {
+			status = color.RedString(ack.Error)
+		} else if ack.StatusCode >= 300 {
+			status = color.RedString(status)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%d\t%s\n",
+			time.Unix(ack.Sent, 0).Format(time.RFC3339), ack.TransactionID, ack.Attempt, ack.Endpoint,
+			status, time.Duration(ack.LatencyMs)*time.Millisecond, ack.PayloadSize, ack.Checksum)
+	}
+	w.Flush()
+	fmt.Fprintf(color.Output, "\n%d attempts\n", len(acks))
+}
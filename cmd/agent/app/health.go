@@ -97,6 +97,25 @@ func requestHealth() error {
 		fmt.Fprintln(color.Output, fmt.Sprintf("=== %s healthy components ===", color.GreenString(strconv.Itoa(len(s.Healthy)))))
 		fmt.Fprintln(color.Output, strings.Join(s.Healthy, ", "))
 	}
+	if len(s.Goroutines) > 0 {
+		components := make([]string, 0, len(s.Goroutines))
+		for component := range s.Goroutines {
+			components = append(components, component)
+		}
+		sort.Strings(components)
+		fmt.Fprintln(color.Output, "=== goroutines per component ===")
+		for _, component := range components {
+			fmt.Fprintln(color.Output, fmt.Sprintf("%s: %d", component, s.Goroutines[component]))
+		}
+	}
+	if len(s.Leaking) > 0 {
+		fmt.Fprintln(color.Output, fmt.Sprintf("=== %s components with growing goroutines ===", color.YellowString(strconv.Itoa(len(s.Leaking)))))
+		fmt.Fprintln(color.Output, strings.Join(s.Leaking, ", "))
+	}
+	if len(s.Limited) > 0 {
+		fmt.Fprintln(color.Output, fmt.Sprintf("=== %s components over their goroutine limit ===", color.YellowString(strconv.Itoa(len(s.Limited)))))
+		fmt.Fprintln(color.Output, strings.Join(s.Limited, ", "))
+	}
 	if len(s.Unhealthy) > 0 {
 		fmt.Fprintln(color.Output, fmt.Sprintf("=== %s unhealthy components ===", color.RedString(strconv.Itoa(len(s.Unhealthy)))))
 		fmt.Fprintln(color.Output, strings.Join(s.Unhealthy, ", "))
@@ -9,7 +9,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config/legacy"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -17,14 +17,16 @@ import (
 
 var (
 	importCmd = &cobra.Command{
-		Use:          "import <old_configuration_dir> <destination_dir>",
+		Use:          "import-config <old_configuration_dir> <destination_dir>",
+		Aliases:      []string{"import"},
 		Short:        "Import and convert configuration files from previous versions of the Agent",
 		Long:         ``,
 		RunE:         doImport,
 		SilenceUsage: true,
 	}
 
-	force = false
+	force  = false
+	dryRun = false
 )
 
 func init() {
@@ -33,6 +35,7 @@ func init() {
 
 	// local flags
 	importCmd.Flags().BoolVarP(&force, "force", "f", force, "overwrite existing files")
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", dryRun, "print the diff of the converted files instead of writing them")
 }
 
 func doImport(cmd *cobra.Command, args []string) error {
@@ -50,5 +53,8 @@ func doImport(cmd *cobra.Command, args []string) error {
 		color.NoColor = true
 	}
 
-	return common.ImportConfig(oldConfigDir, newConfigDir, force)
+	return legacy.ImportConfig(oldConfigDir, newConfigDir, legacy.ImportOptions{
+		Force:  force,
+		DryRun: dryRun,
+	})
 }
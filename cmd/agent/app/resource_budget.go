@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package app
+
+import (
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/collector/runner"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/watchdog"
+)
+
+// startResourceWatchdog starts shedding the load of the agent while it's over
+// its resource budget, the container stats first and the verbose telemetry
+// last
+func startResourceWatchdog() {
+	var actions []watchdog.Action
+
+	containerChecks := config.Datadog.GetStringSlice("resource_budget.container_checks")
+	throttle := config.Datadog.GetInt("resource_budget.container_checks_throttle")
+	if len(containerChecks) > 0 && throttle > 1 {
+		actions = append(actions, watchdog.Action{
+			Name:        "reduce_container_stats_frequency",
+			Description: "reduced the frequency of the container checks",
+			Shed:        func() { runner.ThrottleChecks(containerChecks, throttle) },
+			Restore:     func() { runner.ThrottleChecks(containerChecks, 1) },
+		})
+	}
+
+	lowPriorityChecks := config.Datadog.GetStringSlice("resource_budget.low_priority_checks")
+	if len(lowPriorityChecks) > 0 {
+		actions = append(actions, watchdog.Action{
+			Name:        "pause_low_priority_checks",
+			Description: "paused the low priority checks",
+			Shed:        func() { runner.PauseChecks(lowPriorityChecks, true) },
+			Restore:     func() { runner.PauseChecks(lowPriorityChecks, false) },
+		})
+	}
+
+	if common.DSD != nil && config.Datadog.GetBool("dogstatsd_metrics_stats_enable") {
+		dsd := common.DSD
+		actions = append(actions, watchdog.Action{
+			Name:        "drop_verbose_telemetry",
+			Description: "stopped storing the dogstatsd metrics statistics",
+			Shed:        func() { dsd.PauseMetricsStats(true) },
+			Restore:     func() { dsd.PauseMetricsStats(false) },
+		})
+	}
+
+	watchdog.NewWatchdog(actions).Start(common.MainCtx)
+}
@@ -15,6 +15,8 @@ import (
 	_ "net/http/pprof" // Blank import used because this isn't directly used in this file
 
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -25,29 +27,38 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/api/healthprobe"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed/jmx"
+	collectormetadata "github.com/DataDog/datadog-agent/pkg/collector/metadata"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/logs"
 	"github.com/DataDog/datadog-agent/pkg/metadata"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/metadata/identity"
 	"github.com/DataDog/datadog-agent/pkg/pidfile"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
+	"github.com/DataDog/datadog-agent/pkg/seriesintake"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/audit"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/blocklist"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/nodehealth"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/supervisor"
 	"github.com/DataDog/datadog-agent/pkg/version"
 
 	// register core checks
+	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/canary"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/cluster"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/containers"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed"
+	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/httpjson"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/net"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/system"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/systemd"
+	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/vsphere"
 
 	// register metadata providers
-	_ "github.com/DataDog/datadog-agent/pkg/collector/metadata"
 	_ "github.com/DataDog/datadog-agent/pkg/metadata"
 )
 
@@ -123,6 +134,12 @@ func StartAgent() error {
 
 	log.Infof("Starting Datadog Agent v%v", version.AgentVersion)
 
+	setupAuditLog()
+	audit.Record(audit.AgentStarted, "Agent started, configuration loaded", map[string]string{
+		"version":     version.AgentVersion,
+		"config_file": config.Datadog.ConfigFileUsed(),
+	})
+
 	// Setup expvar server
 	var port = config.Datadog.GetString("expvar_port")
 	go http.ListenAndServe("127.0.0.1:"+port, http.DefaultServeMux)
@@ -173,6 +190,13 @@ func StartAgent() error {
 		}
 	}
 
+	// start fetching the workload blocklist from the cluster agent, before the
+	// checks and the logs are scheduled
+	blocklist.Start()
+
+	// publish the agent health on the node for the cluster agent
+	nodehealth.Start(common.MainCtx)
+
 	// start the GUI server
 	guiPort := config.Datadog.GetString("GUI_port")
 	if guiPort == "-1" {
@@ -206,6 +230,16 @@ func StartAgent() error {
 	}
 	log.Debugf("statsd started")
 
+	// start the series intake
+	if config.Datadog.GetBool("series_intake.enabled") {
+		var err error
+		metricOut, _, _ := agg.GetBufferedChannels()
+		common.SeriesIntake, err = seriesintake.NewServer(metricOut)
+		if err != nil {
+			log.Errorf("Could not start the series intake: %s", err)
+		}
+	}
+
 	// start logs-agent
 	if config.Datadog.GetBool("logs_enabled") || config.Datadog.GetBool("log_enabled") {
 		if config.Datadog.GetBool("log_enabled") {
@@ -230,6 +264,17 @@ func StartAgent() error {
 		return err
 	}
 
+	// report the agent identity and inventories
+	identity.SetupInventories()
+	if config.Datadog.GetBool("inventories_enabled") {
+		if err := collectormetadata.SetupInventories(common.MetadataScheduler, common.AC, common.Coll); err != nil {
+			log.Errorf("Could not schedule the inventories metadata: %v", err)
+		}
+	}
+
+	// shed load while the agent is over its resource budget
+	startResourceWatchdog()
+
 	// start dependent services
 	startDependentServices()
 	return nil
@@ -246,12 +291,21 @@ func StopAgent() {
 		log.Warnf("Some components were unhealthy: %v", health.Unhealthy)
 	}
 
+	// let the backend know right away that an ephemeral host is going away
+	if host.IsEphemeral() {
+		sendEphemeralGoodbye()
+	}
+
 	// gracefully shut down any component
+	supervisor.Stop()
 	common.MainCtxCancel()
 
 	if common.DSD != nil {
 		common.DSD.Stop()
 	}
+	if common.SeriesIntake != nil {
+		common.SeriesIntake.Stop()
+	}
 	if common.AC != nil {
 		common.AC.Stop()
 	}
@@ -261,12 +315,65 @@ func StopAgent() {
 	api.StopServer()
 	clcrunnerapi.StopCLCRunnerServer()
 	jmx.StopJmxfetch()
+	aggregator.StopDefaultAggregator()
 	if common.Forwarder != nil {
 		common.Forwarder.Stop()
 	}
 	logs.Stop()
 	gui.StopGUIServer()
 	os.Remove(pidfilePath)
+	audit.Record(audit.AgentStopped, "Agent stopped", nil)
+	audit.Close()
 	log.Info("See ya!")
 	log.Flush()
 }
+
+// setupAuditLog opens the audit log, next to the agent log file by default
+func setupAuditLog() {
+	if !config.Datadog.GetBool("audit_log.enabled") {
+		return
+	}
+
+	auditFile := config.Datadog.GetString("audit_log.file")
+	if auditFile == "" {
+		logFile := config.Datadog.GetString("log_file")
+		if logFile == "" {
+			logFile = common.DefaultLogFile
+		}
+		auditFile = filepath.Join(filepath.Dir(logFile), "audit.log")
+	}
+
+	err := audit.Init(
+		auditFile,
+		int64(config.Datadog.GetSizeInBytes("audit_log.max_size")),
+		config.Datadog.GetInt("audit_log.max_rolls"),
+	)
+	if err != nil {
+		log.Warnf("Could not open the audit log %s: %v", auditFile, err)
+	}
+}
+
+// sendEphemeralGoodbye sends a last host metadata payload and a shutdown event,
+// with the cloud termination notice if any, so that the backend can expire the
+// host without waiting for it to stop reporting
+func sendEphemeralGoodbye() {
+	host.SetShuttingDown()
+	if common.MetadataScheduler != nil {
+		if err := common.MetadataScheduler.SendFinal("host"); err != nil {
+			log.Warnf("Could not send the final host metadata: %v", err)
+		}
+	}
+
+	text := "The Agent is shutting down gracefully"
+	tags := []string{"host_lifecycle:" + host.LifecycleEphemeral}
+	if notice := host.GetTerminationNotice(); notice != nil {
+		text = fmt.Sprintf("The Agent is shutting down after a %s termination notice (%s)", notice.Provider, notice.Action)
+		tags = append(tags, "termination_provider:"+notice.Provider)
+	}
+	if err := aggregator.SendAgentShutdownEvent(text, tags); err != nil {
+		log.Warnf("Could not send the shutdown event: %v", err)
+	}
+
+	// give the forwarder some time to send the payloads before it's stopped
+	time.Sleep(time.Duration(config.Datadog.GetInt("ephemeral_shutdown_grace_period")) * time.Second)
+}
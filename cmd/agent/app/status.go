@@ -24,6 +24,9 @@ var (
 	jsonStatus      bool
 	prettyPrintJSON bool
 	statusFilePath  string
+	statusSchema    string
+	printSchema     bool
+	verboseStatus   bool
 )
 
 func init() {
@@ -31,6 +34,9 @@ func init() {
 	statusCmd.Flags().BoolVarP(&jsonStatus, "json", "j", false, "print out raw json")
 	statusCmd.Flags().BoolVarP(&prettyPrintJSON, "pretty-json", "p", false, "pretty print JSON")
 	statusCmd.Flags().StringVarP(&statusFilePath, "file", "o", "", "Output the status command to a file")
+	statusCmd.Flags().StringVarP(&statusSchema, "schema", "", "", fmt.Sprintf("print out the JSON document of a stable schema version (%s) instead of the raw JSON, implies --json", status.SchemaV1))
+	statusCmd.Flags().BoolVarP(&printSchema, "print-schema", "", false, "print out the JSON Schema of the --schema version, defaults to the latest one")
+	statusCmd.Flags().BoolVarP(&verboseStatus, "verbose", "v", false, "print out the recent failures of the checks")
 	statusCmd.AddCommand(componentCmd)
 	componentCmd.Flags().BoolVarP(&prettyPrintJSON, "pretty-json", "p", false, "pretty print JSON")
 	componentCmd.Flags().StringVarP(&statusFilePath, "file", "o", "", "Output the status command to a file")
@@ -46,6 +52,10 @@ var statusCmd = &cobra.Command{
 			color.NoColor = true
 		}
 
+		if printSchema {
+			return printStatusSchema()
+		}
+
 		err := common.SetupConfigWithoutSecrets(confFilePath)
 		if err != nil {
 			return fmt.Errorf("unable to set up global agent configuration: %v", err)
@@ -84,7 +94,7 @@ var componentCmd = &cobra.Command{
 func requestStatus() error {
 	var s string
 
-	if !prettyPrintJSON && !jsonStatus {
+	if !prettyPrintJSON && !jsonStatus && statusSchema == "" {
 		fmt.Printf("Getting the status from the agent.\n\n")
 	}
 	ipcAddress, err := config.GetIPCAddress()
@@ -97,13 +107,28 @@ func requestStatus() error {
 		return err
 	}
 
+	// The versioned documents are built from the raw status, so that they
+	// stay stable whatever the version of the agent answering
+	if statusSchema != "" {
+		r, err = status.FormatStatusJSON(r, statusSchema)
+		if err != nil {
+			return err
+		}
+	}
+
 	// The rendering is done in the client so that the agent has less work to do
 	if prettyPrintJSON {
 		var prettyJSON bytes.Buffer
 		json.Indent(&prettyJSON, r, "", "  ")
 		s = prettyJSON.String()
-	} else if jsonStatus {
+	} else if jsonStatus || statusSchema != "" {
 		s = string(r)
+	} else if verboseStatus {
+		formattedStatus, err := status.FormatVerboseStatus(r)
+		if err != nil {
+			return err
+		}
+		s = formattedStatus
 	} else {
 		formattedStatus, err := status.FormatStatus(r)
 		if err != nil {
@@ -121,6 +146,22 @@ func requestStatus() error {
 	return nil
 }
 
+func printStatusSchema() error {
+	schema := statusSchema
+	if schema == "" {
+		schema = status.SchemaV1
+	}
+	s, err := status.GetJSONSchema(schema)
+	if err != nil {
+		return err
+	}
+	if statusFilePath != "" {
+		return ioutil.WriteFile(statusFilePath, []byte(s), 0644)
+	}
+	fmt.Println(s)
+	return nil
+}
+
 func componentStatus(component string) error {
 	var s string
 
@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	topMetricsFilters []string
+	topMetricsJSON    bool
+)
+
+func init() {
+	AgentCmd.AddCommand(topCmd)
+	topCmd.AddCommand(topMetricsCmd)
+	topMetricsCmd.Flags().StringSliceVarP(&topMetricsFilters, "filter", "f", nil, "only print the series matching <name|host|tag>:<glob>, e.g. name:docker.*, can be repeated")
+	topMetricsCmd.Flags().BoolVarP(&topMetricsJSON, "json", "j", false, "print out raw json")
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Print what a running agent is producing",
+	Long:  ``,
+}
+
+var topMetricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Print the series flushed by a running agent in the last minutes",
+	Long: `Print the series flushed by a running agent in the last recent_series.retention seconds,
+the most recent first, to check what is produced locally without waiting for the backend.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return requestTopMetrics()
+	},
+}
+
+func requestTopMetrics() error {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return err
+	}
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	for _, filter := range topMetricsFilters {
+		query.Add("filter", filter)
+	}
+	urlstr := fmt.Sprintf("https://%v:%v/agent/top/metrics?%s", ipcAddress, config.Datadog.GetInt("cmd_port"), query.Encode())
+
+	r, err := util.DoGet(c, urlstr)
+	if err != nil {
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap)
+		// If the error has been marshalled into a json object, check it and return it properly
+		if e, found := errMap["error"]; found {
+			err = fmt.Errorf(e)
+		}
+		fmt.Printf("Could not get the recent series from the agent: %v\n", err)
+		return err
+	}
+
+	if topMetricsJSON {
+		fmt.Println(string(r))
+		return nil
+	}
+
+	var series []aggregator.RecentSerie
+	if err := json.Unmarshal(r, &series); err != nil {
+		return err
+	}
+	printTopMetrics(series, time.Now())
+	return nil
+}
+
+func printTopMetrics(series []aggregator.RecentSerie, now time.Time) {
+	if len(series) == 0 {
+		fmt.Fprintln(color.Output, "No series flushed recently matches the filters")
+		return
+	}
+
+	w := tabwriter.NewWriter(color.Output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METRIC\tTYPE\tVALUE\tAGE\tHOST\tTAGS")
+	for _, serie := range series {
+		value := ""
+		if len(serie.Points) > 0 {
+			value = fmt.Sprintf("%g", serie.Points[len(serie.Points)-1].Value)
+		}
+		age := now.Sub(time.Unix(serie.Flushed, 0)).Truncate(time.Second)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", serie.Name, serie.Type, value, age, serie.Host, strings.Join(serie.Tags, ","))
+	}
+	w.Flush()
+	fmt.Fprintf(color.Output, "\n%d series\n", len(series))
+}
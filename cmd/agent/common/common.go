@@ -19,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/metadata"
+	"github.com/DataDog/datadog-agent/pkg/seriesintake"
 	"github.com/DataDog/datadog-agent/pkg/util/executable"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
@@ -33,6 +34,9 @@ var (
 	// DSD is the global dogstastd instance
 	DSD *dogstatsd.Server
 
+	// SeriesIntake is the global series intake instance
+	SeriesIntake *seriesintake.Server
+
 	// MetadataScheduler is responsible to orchestrate metadata collection
 	MetadataScheduler *metadata.Scheduler
 
@@ -14,6 +14,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/flare"
 	"github.com/DataDog/datadog-agent/pkg/status"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/audit"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/version"
 	"github.com/gorilla/mux"
@@ -204,5 +205,6 @@ func setConfigFile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Infof("Successfully wrote new config file.")
+	audit.Record(audit.ConfigChanged, "Agent configuration file written from the GUI", map[string]string{"path": path})
 	w.Write([]byte("Success"))
 }
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/audit"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/gorilla/mux"
 	yaml "gopkg.in/yaml.v2"
@@ -150,6 +152,11 @@ func reloadCheck(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Infof("Removed %v old instance(s) and started %v new instance(s) of %s", len(killed), len(instances), name)
+	audit.Record(audit.CheckReloaded, fmt.Sprintf("Check %s reloaded from the GUI", name), map[string]string{
+		"check":   name,
+		"stopped": strconv.Itoa(len(killed)),
+		"started": strconv.Itoa(len(instances)),
+	})
 	w.Write([]byte(fmt.Sprintf("Removed %v old instance(s) and started %v new instance(s) of %s", len(killed), len(instances), name)))
 }
 
@@ -240,6 +247,7 @@ func setCheckConfigFile(w http.ResponseWriter, r *http.Request) {
 		}
 
 		log.Infof("Successfully wrote new " + fileName + " config file.")
+		audit.Record(audit.CheckConfigChanged, "Check configuration file written from the GUI", map[string]string{"path": path})
 		w.Write([]byte("Success"))
 	} else if r.Method == "DELETE" {
 		// Attempt to write new configs to custom checks directory
@@ -259,6 +267,7 @@ func setCheckConfigFile(w http.ResponseWriter, r *http.Request) {
 		}
 
 		log.Infof("Successfully disabled integration " + fileName + " config file.")
+		audit.Record(audit.CheckConfigDisabled, "Check configuration file disabled from the GUI", map[string]string{"path": path})
 		w.Write([]byte("Success"))
 	}
 }
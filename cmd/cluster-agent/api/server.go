@@ -27,6 +27,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 )
 
 var (
@@ -58,6 +59,15 @@ func StartServer(sc clusteragent.ServerContext) error {
 	// DCA client token
 	util.SetDCAAuthToken()
 
+	// Service account tokens of the node agents and the cluster check runners
+	if config.Datadog.GetBool("cluster_agent.token_review.enabled") {
+		validator, err := apiserver.NewServiceAccountTokenValidator()
+		if err != nil {
+			return fmt.Errorf("unable to validate the service account tokens: %v", err)
+		}
+		util.SetDCATokenValidator(validator)
+	}
+
 	// create cert
 	hosts := []string{"127.0.0.1", "localhost"}
 	_, rootCertPEM, rootKey, err := security.GenerateRootCert(hosts, 2048)
@@ -128,6 +138,7 @@ func isExternalPath(path string) bool {
 		path == "/version" ||
 		strings.HasPrefix(path, "/api/v1/tags/pod/") && (len(strings.Split(path, "/")) == 6 || len(strings.Split(path, "/")) == 8) ||
 		strings.HasPrefix(path, "/api/v1/tags/node/") && len(strings.Split(path, "/")) == 6 ||
+		strings.HasPrefix(path, "/api/v1/pods/by-ip/") && len(strings.Split(path, "/")) == 6 ||
 		strings.HasPrefix(path, "/api/v1/clusterchecks/") && len(strings.Split(path, "/")) == 6 ||
 		strings.HasPrefix(path, "/api/v1/endpointschecks/") && len(strings.Split(path, "/")) == 6
 }
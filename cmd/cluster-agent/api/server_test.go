@@ -41,6 +41,16 @@ func TestValidateTokenMiddleware(t *testing.T) {
 			"imposter",
 			http.StatusForbidden,
 		},
+		{
+			"/api/v1/pods/by-ip/10.4.1.12",
+			"abc123",
+			http.StatusOK,
+		},
+		{
+			"/api/v1/pods/by-ip/10.4.1.12",
+			"imposter",
+			http.StatusForbidden,
+		},
 		{
 			"/version",
 			"abc123",
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// installBlocklistEndpoints registers the v1 API endpoint of the workload blocklist
+func installBlocklistEndpoints(r *mux.Router) {
+	r.HandleFunc("/blocklist", getWorkloadBlocklist).Methods("GET")
+}
+
+// getWorkloadBlocklist is used by the node agents to skip the logs and metrics
+// of the workloads matching the `workload_blocklist` rules
+func getWorkloadBlocklist(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5001/api/v1/blocklist
+		Outputs
+			Status: 200
+			Returns: apiv1.BlocklistResponse
+			Example: {"rules": [{"namespace": "kube-system", "logs": true, "metrics": false}]}
+
+			Status: 500
+			Returns: string
+			Example: "invalid workload_blocklist configuration"
+	*/
+	response := apiv1.BlocklistResponse{Rules: []apiv1.BlocklistRule{}}
+	if err := config.Datadog.UnmarshalKey("workload_blocklist", &response.Rules); err != nil {
+		log.Errorf("Could not parse the workload_blocklist configuration: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("getWorkloadBlocklist", http.StatusInternalServerError)
+		return
+	}
+
+	b, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("getWorkloadBlocklist", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+	incrementRequestMetric("getWorkloadBlocklist", http.StatusOK)
+}
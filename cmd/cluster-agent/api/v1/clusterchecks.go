@@ -24,6 +24,7 @@ import (
 func installClusterCheckEndpoints(r *mux.Router, sc clusteragent.ServerContext) {
 	r.HandleFunc("/clusterchecks/status/{nodeName}", postCheckStatus(sc)).Methods("POST")
 	r.HandleFunc("/clusterchecks/configs/{nodeName}", getCheckConfigs(sc)).Methods("GET")
+	r.HandleFunc("/clusterchecks/rebalance", postRebalanceChecks(sc)).Methods("POST")
 	r.HandleFunc("/clusterchecks", getState(sc)).Methods("GET")
 }
 
@@ -111,6 +112,29 @@ func getState(sc clusteragent.ServerContext) func(w http.ResponseWriter, r *http
 	}
 }
 
+// postRebalanceChecks triggers a rebalancing of the cluster checks, for the
+// clusterchecks rebalance cmd
+func postRebalanceChecks(sc clusteragent.ServerContext) func(w http.ResponseWriter, r *http.Request) {
+	if sc.ClusterCheckHandler == nil {
+		return clusterChecksDisabledHandler
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !shouldHandle(w, r, sc.ClusterCheckHandler, "postRebalanceChecks") {
+			return
+		}
+
+		response, err := sc.ClusterCheckHandler.Rebalance()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			incrementRequestMetric("postRebalanceChecks", http.StatusPreconditionFailed)
+			return
+		}
+
+		writeJSONResponse(w, response, "postRebalanceChecks")
+	}
+}
+
 // writeJSONResponse serialises and writes data to the response
 func writeJSONResponse(w http.ResponseWriter, data interface{}, handler string) {
 	slcB, err := json.Marshal(data)
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	as "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// installDiscoveryEndpoints registers the v1 API endpoint of the API discovery cache
+func installDiscoveryEndpoints(r *mux.Router) {
+	r.HandleFunc("/discovery/refresh", postRefreshDiscovery).Methods("POST")
+}
+
+// postRefreshDiscovery drops the cached discovery of the API groups and
+// resources, used by the `discovery refresh` command
+func postRefreshDiscovery(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5001/api/v1/discovery/refresh
+		Outputs
+			Status: 200
+			Returns: apiv1.DiscoveryRefreshResponse
+			Example: {"invalidated": 1}
+	*/
+	invalidated := as.RefreshDiscoveryCache()
+	log.Infof("Dropped %d API discovery caches", invalidated)
+
+	b, err := json.Marshal(apiv1.DiscoveryRefreshResponse{Invalidated: invalidated})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("postRefreshDiscovery", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+	incrementRequestMetric("postRefreshDiscovery", http.StatusOK)
+}
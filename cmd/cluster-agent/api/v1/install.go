@@ -45,6 +45,10 @@ func Install(r *mux.Router, sc clusteragent.ServerContext) {
 	r.HandleFunc("/tags/node/{nodeName}", getNodeMetadata).Methods("GET")
 	installClusterCheckEndpoints(r, sc)
 	installEndpointsCheckEndpoints(r, sc)
+	installBlocklistEndpoints(r)
+	installPodEndpoints(r)
+	installServiceEndpoints(r)
+	installDiscoveryEndpoints(r)
 }
 
 // getNodeMetadata is only used when the node agent hits the DCA for the list of labels
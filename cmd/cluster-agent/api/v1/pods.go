@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	as "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// installPodEndpoints registers the v1 API endpoints of the pods
+func installPodEndpoints(r *mux.Router) {
+	r.HandleFunc("/pods/by-ip/{ip}", getPodMetadataByIP).Methods("GET")
+}
+
+// getPodMetadataByIP is used by the node agents and the process agents to map
+// the IPs of the connections to pods
+func getPodMetadataByIP(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5001/api/v1/pods/by-ip/10.4.1.12
+		Outputs
+			Status: 200
+			Returns: apiv1.PodMetadataByIPResponse
+			Example: {"name": "my-nginx-5d69", "namespace": "default", "uid": "...", "node_name": "node1", "tags": ["kube_service:my-nginx-service"]}
+
+			Status: 400
+			Returns: string
+			Example: "invalid IP: 10.4.1"
+
+			Status: 404
+			Returns: string
+			Example: "no running pod found with the IP 10.4.1.12"
+
+			Status: 500
+			Returns: string
+			Example: "the pods are not indexed by IP on the Cluster Agent, see kubernetes_pod_ip_index"
+	*/
+	ip := mux.Vars(r)["ip"]
+	if net.ParseIP(ip) == nil {
+		http.Error(w, fmt.Sprintf("invalid IP: %s", ip), http.StatusBadRequest)
+		incrementRequestMetric("getPodMetadataByIP", http.StatusBadRequest)
+		return
+	}
+
+	pod, err := as.GetPodMetadataByIP(ip)
+	if err != nil {
+		log.Errorf("Could not retrieve the pod with the IP %s: %v", ip, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("getPodMetadataByIP", http.StatusInternalServerError)
+		return
+	}
+	if pod == nil {
+		// the pod may not be synced yet, the agents shouldn't cache its absence
+		w.Header().Set("Cache-Control", "no-cache")
+		http.Error(w, fmt.Sprintf("no running pod found with the IP %s", ip), http.StatusNotFound)
+		incrementRequestMetric("getPodMetadataByIP", http.StatusNotFound)
+		return
+	}
+
+	b, err := json.Marshal(pod)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("getPodMetadataByIP", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", config.Datadog.GetInt("kubernetes_pod_ip_index_max_age")))
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+	incrementRequestMetric("getPodMetadataByIP", http.StatusOK)
+}
@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	as "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// installServiceEndpoints registers the v1 API endpoints of the services
+func installServiceEndpoints(r *mux.Router) {
+	r.HandleFunc("/services/by-cluster-ip", getServicesByClusterIP).Methods("GET")
+}
+
+// getServicesByClusterIP serves the services, name and namespace, of the
+// ClusterIPs. The response carries an ETag so that the clients polling it only
+// download the services again when they changed. No agent polls it yet: it's
+// meant for the Process Agent to tag the connections to the ClusterIPs, once
+// the connections payload carries tags.
+func getServicesByClusterIP(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5001/api/v1/services/by-cluster-ip
+		Outputs
+			Status: 200
+			Returns: apiv1.ServicesByClusterIPResponse
+			Example: {"services": {"10.96.0.12": {"name": "redis", "namespace": "default"}}}
+
+			Status: 304 when the If-None-Match header matches the ETag of the services
+
+			Status: 500
+			Returns: string
+			Example: "the services are not mapped by ClusterIP on the Cluster Agent, see kubernetes_service_ip_map"
+	*/
+	services, err := as.GetServicesByClusterIP()
+	if err != nil {
+		log.Errorf("Could not retrieve the services by ClusterIP: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("getServicesByClusterIP", http.StatusInternalServerError)
+		return
+	}
+
+	b, err := json.Marshal(services)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("getServicesByClusterIP", http.StatusInternalServerError)
+		return
+	}
+
+	// json.Marshal sorts the map keys, so the same services always give the same ETag
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(b))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		incrementRequestMetric("getServicesByClusterIP", http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+	incrementRequestMetric("getServicesByClusterIP", http.StatusOK)
+}
@@ -177,10 +177,12 @@ func start(cmd *cobra.Command, args []string) error {
 		}
 		stopCh := make(chan struct{})
 		ctx := apiserver.ControllerContext{
-			InformerFactory: apiCl.InformerFactory,
-			Client:          apiCl.Cl,
-			LeaderElector:   le,
-			StopCh:          stopCh,
+			InformerFactory:             apiCl.InformerFactory,
+			NamespacedInformerFactories: apiCl.InformerFactoriesByNamespace(),
+			Client:                      apiCl.Cl,
+			DiscoveryClient:             apiCl.DiscoveryCl,
+			LeaderElector:               le,
+			StopCh:                      stopCh,
 		}
 		if err := apiserver.StartControllers(ctx); err != nil {
 			log.Errorf("Could not start controllers: %v", err)
@@ -235,6 +237,7 @@ func start(cmd *cobra.Command, args []string) error {
 	mainCtxCancel()
 	// wait for the External Metrics Server to stop properly
 	wg.Wait()
+	aggregator.StopDefaultAggregator()
 
 	if stopCh != nil {
 		close(stopCh)
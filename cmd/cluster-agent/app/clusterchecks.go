@@ -20,6 +20,7 @@ import (
 )
 
 func init() {
+	clusterChecksCmd.AddCommand(rebalanceClusterChecksCmd)
 	ClusterAgentCmd.AddCommand(clusterChecksCmd)
 }
 
@@ -52,3 +53,29 @@ var clusterChecksCmd = &cobra.Command{
 		return flare.GetEndpointsChecks(color.Output)
 	},
 }
+
+var rebalanceClusterChecksCmd = &cobra.Command{
+	Use:   "rebalance",
+	Short: "Rebalances the cluster checks between the cluster check runners",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		// we'll search for a config file named `datadog-cluster.yaml`
+		config.Datadog.SetConfigName("datadog-cluster")
+		err := common.SetupConfig(confPath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global cluster agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return flare.RebalanceClusterChecks(color.Output)
+	},
+}
@@ -20,6 +20,7 @@ import (
 
 func init() {
 	ClusterAgentCmd.AddCommand(diagnoseCommand)
+	diagnoseCommand.AddCommand(diagnoseKubeRBACCommand)
 }
 
 var diagnoseCommand = &cobra.Command{
@@ -29,7 +30,30 @@ var diagnoseCommand = &cobra.Command{
 	RunE:  doDiagnose,
 }
 
+var diagnoseKubeRBACCommand = &cobra.Command{
+	Use:   "kube-rbac",
+	Short: "Review the permissions needed on the Kubernetes API server",
+	Long: `Review with SelfSubjectAccessReviews the permissions the features enabled in the
+configuration need on the Kubernetes API server, and print the granted and denied ones.`,
+	RunE: doDiagnoseKubeRBAC,
+}
+
 func doDiagnose(cmd *cobra.Command, args []string) error {
+	if err := setupDiagnose(); err != nil {
+		return err
+	}
+	return diagnose.RunAll(color.Output)
+}
+
+func doDiagnoseKubeRBAC(cmd *cobra.Command, args []string) error {
+	if err := setupDiagnose(); err != nil {
+		return err
+	}
+	return diagnose.RunKubeRBAC(color.Output, true)
+}
+
+// setupDiagnose loads the configuration and sets up the logger of the diagnosis
+func setupDiagnose() error {
 	// Global config setup
 	err := common.SetupConfig(confPath)
 	if err != nil {
@@ -52,6 +76,5 @@ func doDiagnose(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("Error while setting up logging, exiting: %v", err)
 	}
-
-	return diagnose.RunAll(color.Output)
+	return nil
 }
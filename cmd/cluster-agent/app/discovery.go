@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func init() {
+	discoveryCmd.AddCommand(refreshDiscoveryCmd)
+	ClusterAgentCmd.AddCommand(discoveryCmd)
+}
+
+var discoveryCmd = &cobra.Command{
+	Use:   "discovery",
+	Short: "Manage the cache of the API groups and resources discovered on the API server",
+}
+
+var refreshDiscoveryCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Drop the cached API discovery, the next lookups query the API server",
+	Long: `The discovery of the API groups and resources is cached in memory and on disk
+for cluster_agent.discovery_cache.ttl. The refresh command drops the cache, e.g.
+after installing a custom resource definition or an aggregated API.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		// we'll search for a config file named `datadog-cluster.yaml`
+		config.Datadog.SetConfigName("datadog-cluster")
+		err := common.SetupConfig(confPath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global cluster agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return refreshDiscovery()
+	},
+}
+
+func refreshDiscovery() error {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	urlstr := fmt.Sprintf("https://localhost:%v/api/v1/discovery/refresh", config.Datadog.GetInt("cluster_agent.cmd_port"))
+
+	// Set session token
+	if err := util.SetAuthToken(); err != nil {
+		return err
+	}
+
+	r, err := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer([]byte{}))
+	if err != nil {
+		if r != nil && string(r) != "" {
+			fmt.Printf("The agent ran into an error while refreshing the discovery: %s\n", string(r))
+		} else {
+			fmt.Printf("Failed to query the agent (running?): %s\n", err)
+		}
+		return err
+	}
+
+	var response apiv1.DiscoveryRefreshResponse
+	if err = json.Unmarshal(r, &response); err != nil {
+		return err
+	}
+	if response.Invalidated == 0 {
+		fmt.Println("The API discovery isn't cached, see cluster_agent.discovery_cache.ttl")
+		return nil
+	}
+	fmt.Fprintln(color.Output, color.GreenString("Dropped the cached API discovery of %d cluster(s)", response.Invalidated))
+	return nil
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/flare"
+)
+
+var (
+	fromAnnotations bool
+	outputDir       string
+)
+
+func init() {
+	ClusterAgentCmd.AddCommand(generateConfigsCmd)
+
+	generateConfigsCmd.Flags().BoolVarP(&fromAnnotations, "from-annotations", "", false, "generate the configurations derived from the Autodiscovery annotations")
+	generateConfigsCmd.Flags().StringVarP(&outputDir, "output-dir", "o", "generated.d", "directory the configuration files are written to")
+}
+
+var generateConfigsCmd = &cobra.Command{
+	Use:   "generate-configs",
+	Short: "Write the configurations of a running cluster agent to static configuration files",
+	Long: `The generate-configs command writes the configurations a running cluster agent
+derived from the Autodiscovery annotations to static YAML files, one folder per
+check like conf.d. Each file starts with comments telling where the configuration
+comes from, to audit a migration away from the annotations or debug the template
+resolution.`,
+	Example: "datadog-cluster-agent generate-configs --from-annotations -o /tmp/conf.d",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		if !fromAnnotations {
+			return fmt.Errorf("no configuration source given, use --from-annotations")
+		}
+
+		// we'll search for a config file named `datadog-cluster.yaml`
+		config.Datadog.SetConfigName("datadog-cluster")
+		err := common.SetupConfig(confPath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global cluster agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return flare.GenerateClusterAgentConfigsFromAnnotations(color.Output, outputDir)
+	},
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	model "github.com/DataDog/agent-payload/process"
+	"github.com/DataDog/datadog-agent/pkg/process/checks"
 	"github.com/DataDog/datadog-agent/pkg/process/config"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
@@ -46,7 +47,11 @@ const (
   Docker socket: {{.Status.DockerSocket}}{{end}}
   Number of processes: {{.Status.ProcessCount}}
   Number of containers: {{.Status.ContainerCount}}
-  Queue length: {{.Status.QueueSize}}
+  Queue length: {{.Status.QueueSize}}{{if .Status.ConnectionFilters.Rules}}
+  Connections excluded by the filters: {{.Status.ConnectionFilters.Total}}{{range $list, $rules := .Status.ConnectionFilters.Rules}}{{range $rule, $count := $rules}}
+    {{$list}} {{$rule}}: {{$count}}{{end}}{{end}}{{end}}{{if .Status.Config.RejectedConnectionFilters}}
+  Connection filters not respected: {{len .Status.Config.RejectedConnectionFilters}}{{range .Status.Config.RejectedConnectionFilters}}
+    {{.}}{{end}}{{end}}
 
   Logs: {{.Status.Config.LogFile}}{{if .Status.ProxyURL}}
   HttpProxy: {{.Status.ProxyURL}}{{end}}{{if ne .Status.ContainerID ""}}
@@ -148,6 +153,10 @@ func publishQueueSize() interface{} {
 	return infoQueueSize
 }
 
+func publishConnectionFilters() interface{} {
+	return checks.Connections.FilterCounts()
+}
+
 func publishContainerID() interface{} {
 	cgroupFile := "/proc/self/cgroup"
 	if !util.PathExists(cgroupFile) {
@@ -200,18 +209,19 @@ type infoVersion struct {
 
 // StatusInfo is a structure to get information from expvar and feed to template
 type StatusInfo struct {
-	Pid             int                    `json:"pid"`
-	Uptime          int                    `json:"uptime"`
-	MemStats        struct{ Alloc uint64 } `json:"memstats"`
-	Version         infoVersion            `json:"version"`
-	Config          config.AgentConfig     `json:"config"`
-	DockerSocket    string                 `json:"docker_socket"`
-	LastCollectTime string                 `json:"last_collect_time"`
-	ProcessCount    int                    `json:"process_count"`
-	ContainerCount  int                    `json:"container_count"`
-	QueueSize       int                    `json:"queue_size"`
-	ContainerID     string                 `json:"container_id"`
-	ProxyURL        string                 `json:"proxy_url"`
+	Pid               int                         `json:"pid"`
+	Uptime            int                         `json:"uptime"`
+	MemStats          struct{ Alloc uint64 }      `json:"memstats"`
+	Version           infoVersion                 `json:"version"`
+	Config            config.AgentConfig          `json:"config"`
+	DockerSocket      string                      `json:"docker_socket"`
+	LastCollectTime   string                      `json:"last_collect_time"`
+	ProcessCount      int                         `json:"process_count"`
+	ContainerCount    int                         `json:"container_count"`
+	QueueSize         int                         `json:"queue_size"`
+	ContainerID       string                      `json:"container_id"`
+	ProxyURL          string                      `json:"proxy_url"`
+	ConnectionFilters util.ConnectionFilterCounts `json:"connection_filters"`
 }
 
 func initInfo(conf *config.AgentConfig) error {
@@ -235,6 +245,7 @@ func initInfo(conf *config.AgentConfig) error {
 		expvar.Publish("container_count", expvar.Func(publishContainerCount))
 		expvar.Publish("queue_size", expvar.Func(publishQueueSize))
 		expvar.Publish("container_id", expvar.Func(publishContainerID))
+		expvar.Publish("connection_filters", expvar.Func(publishConnectionFilters))
 		c := *conf
 		var buf []byte
 		buf, err = json.Marshal(&c)
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -137,3 +138,59 @@ func TestError(t *testing.T) {
 
 	assert.Equal(errInfo, info)
 }
+
+func TestInfoConnectionFilters(t *testing.T) {
+	assert := assert.New(t)
+	conf := config.NewDefaultAgentConfig()
+	assert.NoError(initInfo(conf))
+
+	var buf bytes.Buffer
+	err := infoTmpl.Execute(&buf, struct {
+		Banner  string
+		Program string
+		Status  *StatusInfo
+	}{
+		Status: &StatusInfo{
+			ConnectionFilters: util.ConnectionFilterCounts{
+				Total: 5,
+				Rules: map[string]map[string]int64{
+					util.SourceExcludesList: {"10.0.0.0/8": 3, "10.1.0.0/16": 0},
+					util.DestExcludesList:   {"192.168.1.1": 1},
+					util.IncludesList:       {util.NoIncludeMatchRule: 1},
+				},
+			},
+		},
+	})
+	assert.NoError(err)
+	assert.Contains(buf.String(), `  Queue length: 0
+  Connections excluded by the filters: 5
+    dest_excludes 192.168.1.1: 1
+    includes no_match: 1
+    source_excludes 10.0.0.0/8: 3
+    source_excludes 10.1.0.0/16: 0
+`)
+}
+
+func TestInfoRejectedConnectionFilters(t *testing.T) {
+	assert := assert.New(t)
+	conf := config.NewDefaultAgentConfig()
+	assert.NoError(initInfo(conf))
+
+	status := &StatusInfo{}
+	status.Config.RejectedConnectionFilters = []util.RejectedConnectionFilter{
+		{List: util.DestExcludesList, Rule: "10.0.0.0/8", Port: "30-ABC", Reason: "ABC is neither a port, a port range nor *"},
+		{List: util.IncludesList, Rule: "10.1.0.0/16", Port: "65536", Reason: "port 65536 is out of the 0-65535 range"},
+	}
+	var buf bytes.Buffer
+	err := infoTmpl.Execute(&buf, struct {
+		Banner  string
+		Program string
+		Status  *StatusInfo
+	}{Status: status})
+	assert.NoError(err)
+	assert.Contains(buf.String(), `  Queue length: 0
+  Connection filters not respected: 2
+    dest_excludes 10.0.0.0/8, port 30-ABC: ABC is neither a port, a port range nor *
+    includes 10.1.0.0/16, port 65536: port 65536 is out of the 0-65535 range
+`)
+}
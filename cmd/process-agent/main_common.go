@@ -163,7 +163,9 @@ func runAgent(exit chan bool) {
 		return
 	}
 
-	// Run a profile server.
+	// Run a profile server, also serving the origin of the configuration values
+	// for the `agent config check-origin` command
+	http.Handle("/config/origin", ddconfig.ValueWithOriginHandler(ddconfig.Datadog))
 	go func() {
 		http.ListenAndServe(fmt.Sprintf("localhost:%d", cfg.ProcessExpVarPort), nil)
 	}()
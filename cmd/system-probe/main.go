@@ -5,7 +5,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	ddconfig "github.com/DataDog/datadog-agent/pkg/config"
@@ -135,12 +137,28 @@ func main() {
 	go sysprobe.Run()
 	log.Infof("system probe started")
 
+	// Reload the connection filters on SIGHUP
+	go handleReloads(sysprobe, opts.configPath)
+
 	// Handles signals, which tells us whether we should exit.
 	e := make(chan bool)
 	go util.HandleSignals(e)
 	<-e
 }
 
+// handleReloads reloads the connection filters of the system probe whenever
+// the process receives a SIGHUP
+func handleReloads(sysprobe *SystemProbe, configPath string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		log.Infof("Caught SIGHUP, reloading the connection filters from %s", configPath)
+		if err := sysprobe.ReloadConnectionFilters(configPath); err != nil {
+			log.Errorf("Could not reload the connection filters, keeping the current ones: %s", err)
+		}
+	}
+}
+
 func gracefulExit() {
 	// A sleep is necessary to ensure that supervisor registers this process as "STARTED"
 	// If the exit is "too quick", we enter a BACKOFF->FATAL loop even though this is an expected exit
@@ -198,6 +198,17 @@ func writeAsJSON(w http.ResponseWriter, data interface{}) {
 	w.Write(buf)
 }
 
+// ReloadConnectionFilters reads the connection filters from the config file
+// again and swaps them in the tracer
+func (nt *SystemProbe) ReloadConnectionFilters(yamlPath string) error {
+	rules, err := config.LoadConnectionFilters(yamlPath)
+	if err != nil {
+		return err
+	}
+	nt.tracer.SetConnectionFilters(rules)
+	return nil
+}
+
 // Close will stop all system probe activities
 func (nt *SystemProbe) Close() {
 	nt.conn.Stop()
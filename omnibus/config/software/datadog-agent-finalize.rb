@@ -92,6 +92,7 @@ build do
 
             # remove windows specific configs
             delete "/etc/datadog-agent/conf.d/winproc.d"
+            delete "/etc/datadog-agent/conf.d/windows_iis.d"
 
             # cleanup clutter
             delete "#{install_dir}/etc"
@@ -136,6 +137,7 @@ build do
 
             # remove windows specific configs
             delete "#{install_dir}/etc/conf.d/winproc.d"
+            delete "#{install_dir}/etc/conf.d/windows_iis.d"
 
             # Nothing to move on osx, the confs already live in /opt/datadog-agent/etc/
         end
@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
+	"github.com/DataDog/datadog-agent/pkg/util/buffer"
+	"github.com/DataDog/datadog-agent/pkg/util/clockdrift"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/version"
 
@@ -68,7 +70,7 @@ func expStatsMap(statsMap map[string]*Stats) func() interface{} {
 }
 
 func timeNowNano() float64 {
-	return float64(time.Now().UnixNano()) / float64(time.Second) // Unix time with nanosecond precision
+	return float64(clockdrift.Now().UnixNano()) / float64(time.Second) // Unix time with nanosecond precision
 }
 
 var (
@@ -153,12 +155,24 @@ func SetDefaultAggregator(agg *BufferedAggregator) {
 	go aggregatorInstance.run()
 }
 
+// Names of the instrumented buffers of the buffered channels, the senders to
+// these channels acquire room in the buffers with buffer.Get
+const (
+	MetricSamplesBufferName = "aggregator_metric_samples"
+	ServiceChecksBufferName = "aggregator_service_checks"
+	EventsBufferName        = "aggregator_events"
+)
+
 // BufferedAggregator aggregates metrics in buckets for dogstatsd Metrics
 type BufferedAggregator struct {
 	bufferedMetricIn       chan []*metrics.MetricSample
 	bufferedServiceCheckIn chan []*metrics.ServiceCheck
 	bufferedEventIn        chan []*metrics.Event
 
+	metricSamplesBuffer *buffer.Buffer
+	serviceChecksBuffer *buffer.Buffer
+	eventsBuffer        *buffer.Buffer
+
 	metricIn       chan *metrics.MetricSample
 	eventIn        chan metrics.Event
 	serviceCheckIn chan metrics.ServiceCheck
@@ -179,14 +193,30 @@ type BufferedAggregator struct {
 	TickerChan         <-chan time.Time // For test/benchmark purposes: it allows the flush to be controlled from the outside
 	health             *health.Handle
 	agentName          string // Name of the agent for telemetry metrics (agent / cluster-agent)
+	// the last series flushed for `agent top metrics`, nil if disabled
+	recentSeries *recentSeriesStore
+	// the local downtimes muting or tagging the service checks and events
+	downtimes downtimes
+	// counts the identical events to forward them once, nil if disabled
+	eventDeduplicator *eventDeduplicator
+	stopChan          chan struct{}
+	stopDone          chan struct{}
 }
 
 // NewBufferedAggregator instantiates a BufferedAggregator
 func NewBufferedAggregator(s serializer.MetricSerializer, hostname, agentName string, flushInterval time.Duration) *BufferedAggregator {
+	bufferSize := config.Datadog.GetInt("aggregator_buffer_size")
+	metricSamplesBuffer := buffer.New(MetricSamplesBufferName, bufferSize)
+	serviceChecksBuffer := buffer.New(ServiceChecksBufferName, bufferSize)
+	eventsBuffer := buffer.New(EventsBufferName, bufferSize)
+
 	aggregator := &BufferedAggregator{
-		bufferedMetricIn:       make(chan []*metrics.MetricSample, 100), // TODO make buffer size configurable
-		bufferedServiceCheckIn: make(chan []*metrics.ServiceCheck, 100), // TODO make buffer size configurable
-		bufferedEventIn:        make(chan []*metrics.Event, 100),        // TODO make buffer size configurable
+		bufferedMetricIn:       make(chan []*metrics.MetricSample, metricSamplesBuffer.Capacity()),
+		bufferedServiceCheckIn: make(chan []*metrics.ServiceCheck, serviceChecksBuffer.Capacity()),
+		bufferedEventIn:        make(chan []*metrics.Event, eventsBuffer.Capacity()),
+		metricSamplesBuffer:    metricSamplesBuffer,
+		serviceChecksBuffer:    serviceChecksBuffer,
+		eventsBuffer:           eventsBuffer,
 
 		metricIn:       make(chan *metrics.MetricSample, 100), // TODO make buffer size configurable
 		serviceCheckIn: make(chan metrics.ServiceCheck, 100),  // TODO make buffer size configurable
@@ -204,8 +234,25 @@ func NewBufferedAggregator(s serializer.MetricSerializer, hostname, agentName st
 		hostnameUpdateDone: make(chan struct{}),
 		health:             health.Register("aggregator"),
 		agentName:          agentName,
+		stopChan:           make(chan struct{}),
+		stopDone:           make(chan struct{}),
+	}
+
+	if maxSize := config.Datadog.GetInt("recent_series.max_size"); maxSize > 0 {
+		retention := time.Duration(config.Datadog.GetInt64("recent_series.retention")) * time.Second
+		aggregator.recentSeries = newRecentSeriesStore(maxSize, retention)
 	}
 
+	if window := config.Datadog.GetInt64("event_deduplication_window"); window > 0 {
+		aggregator.eventDeduplicator = newEventDeduplicator(time.Duration(window) * time.Second)
+	}
+
+	dts, err := loadDowntimes()
+	if err != nil {
+		log.Errorf("Invalid downtimes, ignoring them all: %v", err)
+	}
+	aggregator.downtimes = dts
+
 	return aggregator
 }
 
@@ -226,6 +273,22 @@ func deduplicateTags(tags []string) []string {
 	return tags[:j+1]
 }
 
+// SendAgentShutdownEvent sends a shutdown event through the default aggregator
+func SendAgentShutdownEvent(text string, tags []string) error {
+	if aggregatorInstance == nil {
+		return fmt.Errorf("aggregator is not initialized")
+	}
+	return aggregatorInstance.SendAgentShutdownEvent(text, tags)
+}
+
+// StopDefaultAggregator stops the default aggregator, if any, forwarding the
+// events it still holds
+func StopDefaultAggregator() {
+	if aggregatorInstance != nil {
+		aggregatorInstance.Stop()
+	}
+}
+
 // AddRecurrentSeries adds a serie to the series that are sent at every flush
 func AddRecurrentSeries(newSerie *metrics.Serie) {
 	recurrentSeriesLock.Lock()
@@ -282,6 +345,26 @@ func (agg *BufferedAggregator) AddAgentStartupTelemetry(agentVersion string) {
 	}
 }
 
+// SendAgentShutdownEvent sends a shutdown event right away, bypassing the
+// flush loop as the agent is about to exit
+func (agg *BufferedAggregator) SendAgentShutdownEvent(text string, tags []string) error {
+	if agg.hostname == "" {
+		return fmt.Errorf("no hostname, not sending the shutdown event")
+	}
+	events := metrics.Events{
+		&metrics.Event{
+			Title:          fmt.Sprintf("Datadog %s is shutting down", agg.agentName),
+			Text:           text,
+			Ts:             time.Now().Unix(),
+			SourceTypeName: "System",
+			Host:           agg.hostname,
+			Tags:           tags,
+			EventType:      "Agent Shutdown",
+		},
+	}
+	return agg.serializer.SendEvents(events)
+}
+
 func (agg *BufferedAggregator) registerSender(id check.ID) error {
 	agg.mu.Lock()
 	defer agg.mu.Unlock()
@@ -329,9 +412,12 @@ func (agg *BufferedAggregator) handleSenderBucket(checkBucket senderHistogramBuc
 // addServiceCheck adds the service check to the slice of current service checks
 func (agg *BufferedAggregator) addServiceCheck(sc metrics.ServiceCheck) {
 	if sc.Ts == 0 {
-		sc.Ts = time.Now().Unix()
+		sc.Ts = clockdrift.Now().Unix()
 	}
 	sc.Tags = deduplicateTags(sc.Tags)
+	if agg.downtimes != nil && !agg.downtimes.filterServiceCheck(&sc, time.Now()) {
+		return
+	}
 
 	agg.serviceChecks = append(agg.serviceChecks, &sc)
 }
@@ -339,9 +425,15 @@ func (agg *BufferedAggregator) addServiceCheck(sc metrics.ServiceCheck) {
 // addEvent adds the event to the slice of current events
 func (agg *BufferedAggregator) addEvent(e metrics.Event) {
 	if e.Ts == 0 {
-		e.Ts = time.Now().Unix()
+		e.Ts = clockdrift.Now().Unix()
 	}
 	e.Tags = deduplicateTags(e.Tags)
+	if agg.downtimes != nil && !agg.downtimes.filterEvent(&e, time.Now()) {
+		return
+	}
+	if agg.eventDeduplicator != nil && !agg.eventDeduplicator.add(&e, time.Now()) {
+		return
+	}
 
 	agg.events = append(agg.events, &e)
 }
@@ -440,6 +532,10 @@ func (agg *BufferedAggregator) sendSeries(series metrics.Series, start time.Time
 
 	addFlushCount("Series", int64(len(series)))
 
+	if agg.recentSeries != nil {
+		agg.recentSeries.add(series, start)
+	}
+
 	// For debug purposes print out all metrics/tag combinations
 	if config.Datadog.GetBool("log_payloads") {
 		log.Debug("Flushing the following metrics:")
@@ -510,10 +606,20 @@ func (agg *BufferedAggregator) flushServiceChecks(start time.Time) {
 	}()
 }
 
-// GetEvents grabs the events from the queue and clears it
+// GetEvents grabs the events from the queue and clears it, along with the
+// summaries of the deduplicated events whose window ended
 func (agg *BufferedAggregator) GetEvents() metrics.Events {
+	return agg.getEvents(false)
+}
+
+// getEvents grabs the events from the queue and clears it, closing all the
+// deduplication windows if force is set
+func (agg *BufferedAggregator) getEvents(force bool) metrics.Events {
 	agg.mu.Lock()
 	defer agg.mu.Unlock()
+	if agg.eventDeduplicator != nil {
+		agg.events = append(agg.events, agg.eventDeduplicator.flush(time.Now(), force)...)
+	}
 	events := agg.events
 	agg.events = nil
 	return events
@@ -548,6 +654,33 @@ func (agg *BufferedAggregator) flushEvents(start time.Time) {
 	}()
 }
 
+// flushRemainingEvents forwards the events still queued or held by the
+// deduplicator right away, as the aggregator is stopping
+func (agg *BufferedAggregator) flushRemainingEvents() {
+	events := agg.getEvents(true)
+	if len(events) == 0 {
+		return
+	}
+	log.Debugf("Flushing the %d remaining events to the forwarder", len(events))
+	if err := agg.serializer.SendEvents(events); err != nil {
+		log.Warnf("Error flushing the remaining events: %v", err)
+		aggregatorEventsFlushErrors.Add(1)
+		return
+	}
+	aggregatorEventsFlushed.Add(int64(len(events)))
+}
+
+// Stop stops the aggregator, forwarding the events it still holds. It must be
+// called before the forwarder is stopped.
+func (agg *BufferedAggregator) Stop() {
+	select {
+	case <-agg.stopDone:
+		return
+	case agg.stopChan <- struct{}{}:
+	}
+	<-agg.stopDone
+}
+
 func (agg *BufferedAggregator) flush(start time.Time) {
 	agg.flushSeriesAndSketches(start)
 	agg.flushServiceChecks(start)
@@ -561,6 +694,11 @@ func (agg *BufferedAggregator) run() {
 	}
 	for {
 		select {
+		case <-agg.stopChan:
+			agg.flushRemainingEvents()
+			agg.health.Deregister()
+			close(agg.stopDone)
+			return
 		case <-agg.health.C:
 		case <-agg.TickerChan:
 			start := time.Now()
@@ -586,16 +724,19 @@ func (agg *BufferedAggregator) run() {
 			agg.addServiceCheck(serviceCheck)
 
 		case metrics := <-agg.bufferedMetricIn:
+			agg.metricSamplesBuffer.Release()
 			aggregatorDogstatsdMetricSample.Add(int64(len(metrics)))
 			for _, sample := range metrics {
 				agg.addSample(sample, timeNowNano())
 			}
 		case serviceChecks := <-agg.bufferedServiceCheckIn:
+			agg.serviceChecksBuffer.Release()
 			aggregatorServiceCheck.Add(int64(len(serviceChecks)))
 			for _, serviceCheck := range serviceChecks {
 				agg.addServiceCheck(*serviceCheck)
 			}
 		case events := <-agg.bufferedEventIn:
+			agg.eventsBuffer.Release()
 			aggregatorEvent.Add(int64(len(events)))
 			for _, event := range events {
 				agg.addEvent(*event)
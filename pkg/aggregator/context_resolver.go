@@ -10,6 +10,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/intern"
 )
 
 // Context holds the elements that form a context, and can be serialized into a context key
@@ -41,10 +42,19 @@ func newContextResolver() *ContextResolver {
 func (cr *ContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) ckey.ContextKey {
 	contextKey := generateContextKey(metricSampleContext)
 	if _, ok := cr.contextsByKey[contextKey]; !ok {
+		// contexts live until they expire, share their strings with the
+		// other contexts and modules. The tags of the sample may be shared
+		// with its sender, they're copied before being interned.
+		var tags []string
+		if sampleTags := metricSampleContext.GetTags(); sampleTags != nil {
+			tags = make([]string, len(sampleTags))
+			copy(tags, sampleTags)
+			intern.InternSlice(tags)
+		}
 		cr.contextsByKey[contextKey] = &Context{
-			Name: metricSampleContext.GetName(),
-			Tags: metricSampleContext.GetTags(),
-			Host: metricSampleContext.GetHost(),
+			Name: intern.Intern(metricSampleContext.GetName()),
+			Tags: tags,
+			Host: intern.Intern(metricSampleContext.GetHost()),
 		}
 	}
 	cr.lastSeenByKey[contextKey] = currentTimestamp
@@ -0,0 +1,281 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package aggregator
+
+import (
+	"expvar"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const (
+	// downtimeActionMute drops the service checks and events in scope
+	downtimeActionMute = "mute"
+	// downtimeActionTag tags the service checks and events in scope with
+	// `downtime:<name>`
+	downtimeActionTag = "tag"
+
+	downtimeTargetServiceChecks = "service_checks"
+	downtimeTargetEvents        = "events"
+
+	// downtimeTimeOfDayLayout is the layout of the daily windows
+	downtimeTimeOfDayLayout = "15:04"
+)
+
+var (
+	aggregatorServiceChecksMuted = expvar.Int{}
+	aggregatorEventsMuted        = expvar.Int{}
+)
+
+func init() {
+	aggregatorExpvars.Set("ServiceChecksMuted", &aggregatorServiceChecksMuted)
+	aggregatorExpvars.Set("EventsMuted", &aggregatorEventsMuted)
+}
+
+// downtime is a window during which the service checks and events in its
+// scope are muted or tagged, as configured in `downtimes`. The window is
+// either a one-off window with RFC3339 start and end times, or a daily
+// window with "15:04" start and end times, optionally restricted to some
+// days of the week.
+type downtime struct {
+	Name          string   `mapstructure:"name"`
+	Start         string   `mapstructure:"start"`
+	End           string   `mapstructure:"end"`
+	Days          []string `mapstructure:"days"`
+	Timezone      string   `mapstructure:"timezone"`
+	Scope         []string `mapstructure:"scope"`
+	ServiceChecks []string `mapstructure:"service_checks"`
+	Targets       []string `mapstructure:"targets"`
+	Action        string   `mapstructure:"action"`
+
+	// one-off window
+	from, to time.Time
+	// daily window, in minutes since midnight in location
+	daily                  bool
+	startMin, endMin       int
+	days                   map[time.Weekday]bool
+	location               *time.Location
+	appliesToServiceChecks bool
+	appliesToEvents        bool
+	tag                    string
+	// the scope and service_checks globs, `*` matching any characters
+	scope         []*regexp.Regexp
+	serviceChecks []*regexp.Regexp
+}
+
+var downtimeWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// init validates the definition and computes the window
+func (d *downtime) init() error {
+	if d.Name == "" {
+		return fmt.Errorf("the name must be set")
+	}
+
+	d.location = time.Local
+	if d.Timezone != "" {
+		loc, err := time.LoadLocation(d.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %v", d.Timezone, err)
+		}
+		d.location = loc
+	}
+
+	if start, err := time.ParseInLocation(downtimeTimeOfDayLayout, d.Start, d.location); err == nil {
+		end, err := time.ParseInLocation(downtimeTimeOfDayLayout, d.End, d.location)
+		if err != nil {
+			return fmt.Errorf("invalid end %q, expected a time of day like the start: %v", d.End, err)
+		}
+		d.daily = true
+		d.startMin = start.Hour()*60 + start.Minute()
+		d.endMin = end.Hour()*60 + end.Minute()
+		if d.startMin == d.endMin {
+			return fmt.Errorf("the daily window from %s to %s is empty", d.Start, d.End)
+		}
+		for _, day := range d.Days {
+			key := strings.ToLower(day)
+			if len(key) > 3 {
+				key = key[:3] // "monday" is "mon"
+			}
+			weekday, found := downtimeWeekdays[key]
+			if !found {
+				return fmt.Errorf("unknown day %q", day)
+			}
+			if d.days == nil {
+				d.days = make(map[time.Weekday]bool)
+			}
+			d.days[weekday] = true
+		}
+	} else {
+		if len(d.Days) > 0 {
+			return fmt.Errorf("days only apply to daily windows")
+		}
+		if d.from, err = time.Parse(time.RFC3339, d.Start); err != nil {
+			return fmt.Errorf("invalid start %q, expected a time of day (15:04) or an RFC3339 time", d.Start)
+		}
+		if d.to, err = time.Parse(time.RFC3339, d.End); err != nil {
+			return fmt.Errorf("invalid end %q, expected an RFC3339 time like the start", d.End)
+		}
+		if !d.to.After(d.from) {
+			return fmt.Errorf("the end %s is not after the start %s", d.End, d.Start)
+		}
+	}
+
+	d.scope = make([]*regexp.Regexp, 0, len(d.Scope))
+	for _, pattern := range d.Scope {
+		d.scope = append(d.scope, globToRegexp(pattern))
+	}
+	d.serviceChecks = make([]*regexp.Regexp, 0, len(d.ServiceChecks))
+	for _, pattern := range d.ServiceChecks {
+		d.serviceChecks = append(d.serviceChecks, globToRegexp(pattern))
+	}
+
+	if len(d.Targets) == 0 {
+		d.appliesToServiceChecks, d.appliesToEvents = true, true
+	}
+	for _, target := range d.Targets {
+		switch target {
+		case downtimeTargetServiceChecks:
+			d.appliesToServiceChecks = true
+		case downtimeTargetEvents:
+			d.appliesToEvents = true
+		default:
+			return fmt.Errorf("unknown target %q, expected %q or %q", target, downtimeTargetServiceChecks, downtimeTargetEvents)
+		}
+	}
+
+	switch d.Action {
+	case "":
+		d.Action = downtimeActionMute
+	case downtimeActionMute, downtimeActionTag:
+	default:
+		return fmt.Errorf("unknown action %q, expected %q or %q", d.Action, downtimeActionMute, downtimeActionTag)
+	}
+	d.tag = "downtime:" + d.Name
+	return nil
+}
+
+// active returns whether the window includes now. A daily window ending
+// before it starts ends the next day, its days being the days it starts.
+func (d *downtime) active(now time.Time) bool {
+	if !d.daily {
+		return !now.Before(d.from) && now.Before(d.to)
+	}
+	now = now.In(d.location)
+	minute := now.Hour()*60 + now.Minute()
+	if d.startMin < d.endMin {
+		return minute >= d.startMin && minute < d.endMin && d.onDay(now)
+	}
+	if minute >= d.startMin {
+		return d.onDay(now)
+	}
+	return minute < d.endMin && d.onDay(now.AddDate(0, 0, -1))
+}
+
+func (d *downtime) onDay(t time.Time) bool {
+	return d.days == nil || d.days[t.Weekday()]
+}
+
+// inScope returns whether all the scope patterns match the host or one of the
+// tags
+func (d *downtime) inScope(host string, tags []string) bool {
+	for _, pattern := range d.scope {
+		if !matchesHostOrTag(pattern, host, tags) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesHostOrTag(pattern *regexp.Regexp, host string, tags []string) bool {
+	if pattern.MatchString("host:" + host) {
+		return true
+	}
+	for _, tag := range tags {
+		if pattern.MatchString(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *downtime) matchesServiceCheck(name string) bool {
+	if len(d.serviceChecks) == 0 {
+		return true
+	}
+	for _, pattern := range d.serviceChecks {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// downtimes are the local downtimes, all the active ones in scope applying
+type downtimes []downtime
+
+// filterServiceCheck returns false when the service check is muted, and tags
+// it with the active downtimes tagging it
+func (dts downtimes) filterServiceCheck(sc *metrics.ServiceCheck, now time.Time) bool {
+	for i := range dts {
+		d := &dts[i]
+		if !d.appliesToServiceChecks || !d.matchesServiceCheck(sc.CheckName) || !d.active(now) || !d.inScope(sc.Host, sc.Tags) {
+			continue
+		}
+		if d.Action == downtimeActionMute {
+			aggregatorServiceChecksMuted.Add(1)
+			return false
+		}
+		sc.Tags = append(sc.Tags, d.tag)
+	}
+	return true
+}
+
+// filterEvent returns false when the event is muted, and tags it with the
+// active downtimes tagging it
+func (dts downtimes) filterEvent(e *metrics.Event, now time.Time) bool {
+	for i := range dts {
+		d := &dts[i]
+		if !d.appliesToEvents || !d.active(now) || !d.inScope(e.Host, e.Tags) {
+			continue
+		}
+		if d.Action == downtimeActionMute {
+			aggregatorEventsMuted.Add(1)
+			return false
+		}
+		e.Tags = append(e.Tags, d.tag)
+	}
+	return true
+}
+
+// loadDowntimes reads and validates the downtimes
+func loadDowntimes() (downtimes, error) {
+	var dts downtimes
+	if !config.Datadog.IsSet("downtimes") {
+		return nil, nil
+	}
+	if err := config.Datadog.UnmarshalKey("downtimes", &dts); err != nil {
+		return nil, err
+	}
+	for i := range dts {
+		if err := dts[i].init(); err != nil {
+			return nil, fmt.Errorf("downtime %d: %v", i, err)
+		}
+	}
+	return dts, nil
+}
@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func newTestDowntime(t *testing.T, d downtime) downtime {
+	if d.Timezone == "" {
+		d.Timezone = "UTC"
+	}
+	require.NoError(t, d.init())
+	return d
+}
+
+func TestDowntimeActiveDaily(t *testing.T) {
+	// 2019-06-03 is a Monday
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return ts
+	}
+
+	d := newTestDowntime(t, downtime{Name: "restart", Start: "02:00", End: "03:30"})
+	assert.False(t, d.active(at("2019-06-03T01:59:59Z")))
+	assert.True(t, d.active(at("2019-06-03T02:00:00Z")))
+	assert.True(t, d.active(at("2019-06-03T03:29:59Z")))
+	assert.False(t, d.active(at("2019-06-03T03:30:00Z")))
+	// in the location of the downtime
+	assert.True(t, d.active(at("2019-06-03T04:00:00+02:00")))
+
+	// over midnight, on the days it starts
+	d = newTestDowntime(t, downtime{Name: "backup", Start: "23:00", End: "01:00", Days: []string{"Monday", "tue"}})
+	assert.True(t, d.active(at("2019-06-03T23:30:00Z")))
+	assert.True(t, d.active(at("2019-06-04T00:30:00Z")))
+	assert.True(t, d.active(at("2019-06-04T23:30:00Z")))
+	assert.True(t, d.active(at("2019-06-05T00:30:00Z")))
+	assert.False(t, d.active(at("2019-06-05T23:30:00Z")))
+	assert.False(t, d.active(at("2019-06-03T00:30:00Z")))
+	assert.False(t, d.active(at("2019-06-04T01:00:00Z")))
+
+	// one-off
+	d = newTestDowntime(t, downtime{Name: "migration", Start: "2019-06-03T22:00:00Z", End: "2019-06-04T02:00:00+02:00"})
+	assert.False(t, d.active(at("2019-06-03T21:59:59Z")))
+	assert.True(t, d.active(at("2019-06-03T23:59:59Z")))
+	assert.False(t, d.active(at("2019-06-04T00:00:00Z")))
+}
+
+func TestDowntimesFilter(t *testing.T) {
+	now := time.Date(2019, 6, 3, 2, 30, 0, 0, time.UTC)
+	dts := downtimes{
+		newTestDowntime(t, downtime{Name: "restart", Start: "02:00", End: "03:00", Scope: []string{"site:edge-*", "env:prod"}}),
+		newTestDowntime(t, downtime{Name: "web", Start: "02:00", End: "03:00", Scope: []string{"host:web-*"}, ServiceChecks: []string{"http.*"}, Action: downtimeActionTag}),
+		newTestDowntime(t, downtime{Name: "later", Start: "04:00", End: "05:00"}),
+	}
+
+	sc := &metrics.ServiceCheck{CheckName: "nginx.can_connect", Host: "node", Tags: []string{"site:edge-paris", "env:prod"}}
+	assert.False(t, dts.filterServiceCheck(sc, now))
+	sc = &metrics.ServiceCheck{CheckName: "nginx.can_connect", Host: "node", Tags: []string{"site:edge-paris", "env:staging"}}
+	assert.True(t, dts.filterServiceCheck(sc, now))
+	assert.Equal(t, []string{"site:edge-paris", "env:staging"}, sc.Tags)
+	assert.True(t, dts.filterServiceCheck(sc, now.Add(-time.Hour)))
+	sc = &metrics.ServiceCheck{CheckName: "nginx.can_connect", Host: "node"}
+	assert.False(t, dts.filterServiceCheck(sc, now.Add(2*time.Hour)))
+
+	// tagged
+	sc = &metrics.ServiceCheck{CheckName: "http.can_connect", Host: "web-1", Tags: []string{"url:http://localhost"}}
+	assert.True(t, dts.filterServiceCheck(sc, now))
+	assert.Equal(t, []string{"url:http://localhost", "downtime:web"}, sc.Tags)
+	sc = &metrics.ServiceCheck{CheckName: "ntp.in_sync", Host: "web-1"}
+	assert.True(t, dts.filterServiceCheck(sc, now))
+	assert.Empty(t, sc.Tags)
+
+	// the globs aren't path patterns, * matches the separators too
+	dts = append(dts, newTestDowntime(t, downtime{Name: "api", Start: "02:00", End: "03:00", Scope: []string{"url:http*/api/*"}}))
+	sc = &metrics.ServiceCheck{CheckName: "http.can_connect", Host: "node", Tags: []string{"url:http://localhost/api/v1"}}
+	assert.False(t, dts.filterServiceCheck(sc, now))
+	sc = &metrics.ServiceCheck{CheckName: "http.can_connect", Host: "node", Tags: []string{"url:[http://localhost]"}}
+	assert.True(t, dts.filterServiceCheck(sc, now))
+	dts = dts[:len(dts)-1]
+
+	e := &metrics.Event{Title: "restarted", Host: "web-1"}
+	assert.True(t, dts.filterEvent(e, now))
+	assert.Equal(t, []string{"downtime:web"}, e.Tags)
+	e = &metrics.Event{Title: "restarted", Tags: []string{"site:edge-paris", "env:prod"}}
+	assert.False(t, dts.filterEvent(e, now))
+
+	// service checks only
+	dts = downtimes{newTestDowntime(t, downtime{Name: "checks", Start: "02:00", End: "03:00", Targets: []string{downtimeTargetServiceChecks}})}
+	assert.True(t, dts.filterEvent(&metrics.Event{Title: "restarted"}, now))
+	assert.False(t, dts.filterServiceCheck(&metrics.ServiceCheck{CheckName: "ntp.in_sync"}, now))
+}
+
+func TestLoadDowntimes(t *testing.T) {
+	mockConfig := config.Mock()
+	defer config.Mock()
+
+	dts, err := loadDowntimes()
+	require.NoError(t, err)
+	assert.Empty(t, dts)
+
+	mockConfig.Set("downtimes", []map[string]interface{}{
+		{"name": "restart", "start": "02:00", "end": "03:00", "timezone": "Europe/Paris", "days": []string{"sat", "sun"}, "scope": []string{"site:edge-*"}},
+		{"name": "migration", "start": "2019-06-03T22:00:00Z", "end": "2019-06-04T02:00:00Z", "action": "tag", "targets": []string{"events"}},
+	})
+	dts, err = loadDowntimes()
+	require.NoError(t, err)
+	require.Len(t, dts, 2)
+	assert.True(t, dts[0].daily)
+	assert.Equal(t, downtimeActionMute, dts[0].Action)
+	assert.Equal(t, "Europe/Paris", dts[0].location.String())
+	assert.False(t, dts[1].daily)
+	assert.False(t, dts[1].appliesToServiceChecks)
+	assert.True(t, dts[1].appliesToEvents)
+
+	for _, invalid := range []map[string]interface{}{
+		{"start": "02:00", "end": "03:00"},
+		{"name": "a", "start": "02:00", "end": "02:00"},
+		{"name": "a", "start": "02:00", "end": "2019-06-04T02:00:00Z"},
+		{"name": "a", "start": "2019-06-04T02:00:00Z", "end": "2019-06-04T01:00:00Z"},
+		{"name": "a", "start": "2019-06-04T02:00:00Z", "end": "2019-06-04T03:00:00Z", "days": []string{"mon"}},
+		{"name": "a", "start": "02:00", "end": "03:00", "days": []string{"someday"}},
+		{"name": "a", "start": "02:00", "end": "03:00", "timezone": "Nowhere/Special"},
+		{"name": "a", "start": "02:00", "end": "03:00", "targets": []string{"metrics"}},
+		{"name": "a", "start": "02:00", "end": "03:00", "action": "drop"},
+	} {
+		mockConfig.Set("downtimes", []map[string]interface{}{invalid})
+		_, err = loadDowntimes()
+		assert.Error(t, err, invalid)
+	}
+}
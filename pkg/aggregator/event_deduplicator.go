@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package aggregator
+
+import (
+	"expvar"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// eventDuplicatesTextFormat completes the text of the summary of a window
+// with the number of identical events dropped after the first one
+const eventDuplicatesTextFormat = "\n\nIdentical events dropped after this one: %d"
+
+var aggregatorEventsDeduplicated = expvar.Int{}
+
+func init() {
+	aggregatorExpvars.Set("EventsDeduplicated", &aggregatorEventsDeduplicated)
+}
+
+// heldEvent is the first event of a window, and the number of identical
+// events dropped during the window
+type heldEvent struct {
+	event      metrics.Event
+	duplicates int
+	expires    time.Time
+}
+
+// summary returns a copy of the first event with the number of duplicates in
+// its text, the first event itself was already forwarded. Both share their
+// aggregation key, so that the intake groups them.
+func (h *heldEvent) summary() *metrics.Event {
+	e := h.event
+	e.Text += fmt.Sprintf(eventDuplicatesTextFormat, h.duplicates)
+	return &e
+}
+
+// eventDeduplicator opens a window with the first occurrence of an event,
+// which is forwarded right away, during which the identical events, with the
+// same host, title, text and tags, are only counted. At the end of the window
+// a summary with the number of duplicates is forwarded if there were any, so
+// that a pathological emitter doesn't flood the event intake. The count is
+// in the text of the summary rather than in a tag, not to create a new tag
+// value per burst.
+type eventDeduplicator struct {
+	sync.Mutex
+	window time.Duration
+	held   map[ckey.ContextKey]*heldEvent
+	// the summaries of the windows that ended while an identical event came
+	ended metrics.Events
+}
+
+func newEventDeduplicator(window time.Duration) *eventDeduplicator {
+	return &eventDeduplicator{
+		window: window,
+		held:   make(map[ckey.ContextKey]*heldEvent),
+	}
+}
+
+// add returns true when the event is the first occurrence of a window and
+// must be forwarded, or counts it as a duplicate otherwise. The first
+// occurrence is given an aggregation key if it has none, to be grouped with
+// its summary. The tags of the event must be sorted and deduplicated.
+func (d *eventDeduplicator) add(e *metrics.Event, now time.Time) bool {
+	// ckey.Generate sorts the tags in place, they already are
+	key := ckey.Generate(e.Title+"\n"+e.Text, e.Host, e.Tags)
+
+	d.Lock()
+	defer d.Unlock()
+
+	if held, found := d.held[key]; found {
+		if now.Before(held.expires) {
+			held.duplicates++
+			aggregatorEventsDeduplicated.Add(1)
+			return false
+		}
+		// the window ended before the last flush could close it
+		if held.duplicates > 0 {
+			d.ended = append(d.ended, held.summary())
+		}
+	}
+	if e.AggregationKey == "" {
+		e.AggregationKey = key.String()
+	}
+	d.held[key] = &heldEvent{event: *e, expires: now.Add(d.window)}
+	return true
+}
+
+// flush closes the windows that ended, all of them if force is set, and
+// returns the summaries of the ones with duplicates, ordered by timestamp
+func (d *eventDeduplicator) flush(now time.Time, force bool) metrics.Events {
+	d.Lock()
+	defer d.Unlock()
+
+	events := d.ended
+	d.ended = nil
+	for key, held := range d.held {
+		if !force && now.Before(held.expires) {
+			continue
+		}
+		if held.duplicates > 0 {
+			events = append(events, held.summary())
+		}
+		delete(d.held, key)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Ts != events[j].Ts {
+			return events[i].Ts < events[j].Ts
+		}
+		return events[i].Title < events[j].Title
+	})
+	return events
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/serializer"
+)
+
+func TestEventDeduplicator(t *testing.T) {
+	d := newEventDeduplicator(time.Minute)
+	now := time.Now()
+
+	// the first occurrence is forwarded, the duplicates are only counted
+	for i := 0; i < 3; i++ {
+		forward := d.add(&metrics.Event{Title: "disk full", Text: "/var is full", Ts: int64(100 + i), Tags: []string{"device:sda"}}, now.Add(time.Duration(i)*time.Second))
+		assert.Equal(t, i == 0, forward)
+	}
+	// a different text, host or tags isn't a duplicate
+	assert.True(t, d.add(&metrics.Event{Title: "disk full", Text: "/home is full", Ts: 101, Tags: []string{"device:sda"}}, now))
+	assert.True(t, d.add(&metrics.Event{Title: "disk full", Text: "/var is full", Ts: 102, Host: "other", Tags: []string{"device:sda"}}, now))
+	assert.True(t, d.add(&metrics.Event{Title: "disk full", Text: "/var is full", Ts: 103, Tags: []string{"device:sdb"}}, now))
+
+	assert.Empty(t, d.flush(now.Add(30*time.Second), false))
+
+	// only the windows with duplicates are summarized
+	events := d.flush(now.Add(time.Minute), false)
+	require.Len(t, events, 1)
+	assert.Equal(t, &metrics.Event{
+		Title:          "disk full",
+		Text:           "/var is full\n\nIdentical events dropped after this one: 2",
+		Ts:             100,
+		Tags:           []string{"device:sda"},
+		AggregationKey: ckey.Generate("disk full\n/var is full", "", []string{"device:sda"}).String(),
+	}, events[0])
+	assert.Empty(t, d.held)
+
+	// a new window starts with the next occurrence
+	assert.True(t, d.add(&metrics.Event{Title: "disk full", Text: "/var is full", Ts: 200, Tags: []string{"device:sda"}}, now.Add(2*time.Minute)))
+	assert.False(t, d.add(&metrics.Event{Title: "disk full", Text: "/var is full", Ts: 201, Tags: []string{"device:sda"}}, now.Add(2*time.Minute)))
+	assert.Empty(t, d.flush(now.Add(2*time.Minute), false))
+	events = d.flush(now.Add(2*time.Minute), true)
+	require.Len(t, events, 1)
+	assert.Equal(t, []string{"device:sda"}, events[0].Tags)
+	assert.Equal(t, "/var is full\n\nIdentical events dropped after this one: 1", events[0].Text)
+
+	// a window ended before being flushed is summarized when the next one starts
+	assert.True(t, d.add(&metrics.Event{Title: "restart", Ts: 300}, now.Add(3*time.Minute)))
+	assert.False(t, d.add(&metrics.Event{Title: "restart", Ts: 301}, now.Add(3*time.Minute)))
+	assert.True(t, d.add(&metrics.Event{Title: "restart", Ts: 400}, now.Add(5*time.Minute)))
+	events = d.flush(now.Add(5*time.Minute), false)
+	require.Len(t, events, 1)
+	assert.Equal(t, int64(300), events[0].Ts)
+	assert.Empty(t, events[0].Tags)
+}
+
+func TestAddEventDeduplicated(t *testing.T) {
+	resetAggregator()
+	agg := InitAggregator(nil, "resolved-hostname", "agent")
+	agg.eventDeduplicator = newEventDeduplicator(time.Nanosecond)
+	defer func() { agg.eventDeduplicator = nil }()
+
+	agg.addEvent(metrics.Event{Title: "restart", Tags: []string{"b", "a"}})
+	agg.addEvent(metrics.Event{Title: "restart", Tags: []string{"a", "b", "a"}})
+	require.Len(t, agg.events, 1)
+	assert.Equal(t, []string{"a", "b"}, agg.events[0].Tags)
+
+	time.Sleep(time.Millisecond)
+	events := agg.GetEvents()
+	require.Len(t, events, 2)
+	assert.Equal(t, []string{"a", "b"}, events[0].Tags)
+	assert.Equal(t, []string{"a", "b"}, events[1].Tags)
+	// the summary is grouped with the first event by the intake
+	assert.NotEmpty(t, events[0].AggregationKey)
+	assert.Equal(t, events[0].AggregationKey, events[1].AggregationKey)
+	assert.Equal(t, "\n\nIdentical events dropped after this one: 1", events[1].Text)
+}
+
+func TestStopFlushesDeduplicatedEvents(t *testing.T) {
+	resetAggregator()
+	s := &serializer.MockSerializer{}
+	agg := NewBufferedAggregator(s, "hostname", "agent", DefaultFlushInterval)
+	agg.eventDeduplicator = newEventDeduplicator(time.Hour)
+	agg.TickerChan = make(chan time.Time)
+
+	agg.addEvent(metrics.Event{Title: "restart", Ts: 100, AggregationKey: "restart"})
+	agg.addEvent(metrics.Event{Title: "restart", Ts: 101, AggregationKey: "restart"})
+
+	s.On("SendEvents", metrics.Events{
+		{Title: "restart", Ts: 100, AggregationKey: "restart"},
+		{Title: "restart", Text: "\n\nIdentical events dropped after this one: 1", Ts: 100, AggregationKey: "restart"},
+	}).Return(nil).Times(1)
+
+	go agg.run()
+	agg.Stop()
+	s.AssertExpectations(t)
+
+	// stopping again doesn't block
+	agg.Stop()
+}
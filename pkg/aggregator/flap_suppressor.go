@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package aggregator
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// flapStateMaxIdleRuns is the number of check runs after which the state of
+// a service check that isn't submitted anymore is forgotten
+const flapStateMaxIdleRuns = 5
+
+var aggregatorServiceCheckFlapsSuppressed = expvar.Int{}
+
+func init() {
+	aggregatorExpvars.Set("ServiceCheckFlapsSuppressed", &aggregatorServiceCheckFlapsSuppressed)
+}
+
+// flapState is the status forwarded for a service check context, and the
+// transition to another status being confirmed
+type flapState struct {
+	forwarded    metrics.ServiceCheckStatus
+	pending      metrics.ServiceCheckStatus
+	pendingCount int
+	lastRun      uint64
+}
+
+// flapSuppressor only forwards a transition of a service check once its new
+// status was submitted threshold consecutive times. Until then the previously
+// forwarded status is sent, so that a flapping check doesn't flap its monitors.
+type flapSuppressor struct {
+	sync.Mutex
+	threshold int
+	states    map[ckey.ContextKey]*flapState
+	run       uint64
+}
+
+func newFlapSuppressor(threshold int) *flapSuppressor {
+	return &flapSuppressor{
+		threshold: threshold,
+		states:    make(map[ckey.ContextKey]*flapState),
+	}
+}
+
+// filter returns the status to forward for the service check, and whether its
+// transition was suppressed
+func (f *flapSuppressor) filter(sc *metrics.ServiceCheck) (metrics.ServiceCheckStatus, bool) {
+	// ckey.Generate sorts the tags in place
+	tags := make([]string, len(sc.Tags))
+	copy(tags, sc.Tags)
+	key := ckey.Generate(sc.CheckName, sc.Host, tags)
+
+	f.Lock()
+	defer f.Unlock()
+
+	state, found := f.states[key]
+	if !found {
+		f.states[key] = &flapState{forwarded: sc.Status, lastRun: f.run}
+		return sc.Status, false
+	}
+	state.lastRun = f.run
+
+	if sc.Status == state.forwarded {
+		state.pendingCount = 0
+		return sc.Status, false
+	}
+	if state.pendingCount > 0 && sc.Status == state.pending {
+		state.pendingCount++
+	} else {
+		state.pending = sc.Status
+		state.pendingCount = 1
+	}
+	if state.pendingCount >= f.threshold {
+		state.forwarded = sc.Status
+		state.pendingCount = 0
+		return sc.Status, false
+	}
+	return state.forwarded, true
+}
+
+// commit ends a check run, forgetting the service checks not submitted
+// during the last flapStateMaxIdleRuns runs
+func (f *flapSuppressor) commit() {
+	f.Lock()
+	defer f.Unlock()
+
+	f.run++
+	for key, state := range f.states {
+		if f.run-state.lastRun > flapStateMaxIdleRuns {
+			delete(f.states, key)
+		}
+	}
+}
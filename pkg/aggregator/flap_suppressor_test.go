@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestFlapSuppressorFilter(t *testing.T) {
+	f := newFlapSuppressor(3)
+	submit := func(status metrics.ServiceCheckStatus, tags ...string) (metrics.ServiceCheckStatus, bool) {
+		return f.filter(&metrics.ServiceCheck{CheckName: "my.check", Host: "host", Status: status, Tags: tags})
+	}
+
+	// the first status is forwarded
+	status, suppressed := submit(metrics.ServiceCheckOK)
+	assert.Equal(t, metrics.ServiceCheckOK, status)
+	assert.False(t, suppressed)
+
+	// a flap is suppressed
+	status, suppressed = submit(metrics.ServiceCheckCritical)
+	assert.Equal(t, metrics.ServiceCheckOK, status)
+	assert.True(t, suppressed)
+	status, suppressed = submit(metrics.ServiceCheckOK)
+	assert.Equal(t, metrics.ServiceCheckOK, status)
+	assert.False(t, suppressed)
+
+	// a change of the pending status restarts the confirmation
+	submit(metrics.ServiceCheckCritical)
+	status, suppressed = submit(metrics.ServiceCheckWarning)
+	assert.Equal(t, metrics.ServiceCheckOK, status)
+	assert.True(t, suppressed)
+	submit(metrics.ServiceCheckWarning)
+	status, suppressed = submit(metrics.ServiceCheckWarning)
+	assert.Equal(t, metrics.ServiceCheckWarning, status)
+	assert.False(t, suppressed)
+
+	// the contexts are independent
+	status, suppressed = submit(metrics.ServiceCheckCritical, "foo:bar")
+	assert.Equal(t, metrics.ServiceCheckCritical, status)
+	assert.False(t, suppressed)
+}
+
+func TestFlapSuppressorForgetsIdleContexts(t *testing.T) {
+	f := newFlapSuppressor(2)
+	f.filter(&metrics.ServiceCheck{CheckName: "my.check", Status: metrics.ServiceCheckOK})
+	require.Len(t, f.states, 1)
+
+	for i := 0; i < flapStateMaxIdleRuns; i++ {
+		f.commit()
+	}
+	assert.Len(t, f.states, 1)
+	f.commit()
+	assert.Len(t, f.states, 0)
+}
+
+func TestCheckSenderSuppressesFlaps(t *testing.T) {
+	senderMetricSampleChan := make(chan senderMetricSample, 10)
+	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
+	checkSender := newCheckSender(checkID1, "default-hostname", senderMetricSampleChan, serviceCheckChan, nil, nil)
+	checkSender.SetServiceCheckFlapThreshold(2)
+
+	checkSender.ServiceCheck("my_service.can_connect", metrics.ServiceCheckOK, "", nil, "")
+	checkSender.ServiceCheck("my_service.can_connect", metrics.ServiceCheckCritical, "", nil, "timeout")
+
+	assert.Equal(t, metrics.ServiceCheckOK, (<-serviceCheckChan).Status)
+	suppressedCheck := <-serviceCheckChan
+	assert.Equal(t, metrics.ServiceCheckOK, suppressedCheck.Status)
+	assert.Equal(t, "timeout", suppressedCheck.Message)
+
+	suppressedSample := <-senderMetricSampleChan
+	assert.Equal(t, "datadog.agent.service_check.suppressed_transitions", suppressedSample.metricSample.Name)
+	assert.Equal(t, metrics.CountType, suppressedSample.metricSample.Mtype)
+	assert.Equal(t, "default-hostname", suppressedSample.metricSample.Host)
+	assert.Equal(t, []string{"service_check:my_service.can_connect"}, suppressedSample.metricSample.Tags)
+
+	checkSender.ServiceCheck("my_service.can_connect", metrics.ServiceCheckCritical, "", nil, "timeout")
+	assert.Equal(t, metrics.ServiceCheckCritical, (<-serviceCheckChan).Status)
+}
@@ -74,6 +74,16 @@ func (m *MockSender) SetCheckCustomTags(tags []string) {
 	m.Called(tags)
 }
 
+//SetCheckService enables the set of check service mock call.
+func (m *MockSender) SetCheckService(service string) {
+	m.Called(service)
+}
+
+//SetServiceCheckFlapThreshold enables the set of the service check flap threshold mock call.
+func (m *MockSender) SetServiceCheckFlapThreshold(threshold int) {
+	m.Called(threshold)
+}
+
 //GetMetricStats enables the get metric stats mock call.
 func (m *MockSender) GetMetricStats() map[string]int64 {
 	m.Called()
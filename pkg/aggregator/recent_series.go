@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// RecentSerie is a serie flushed by the aggregator, kept for `agent top metrics`
+type RecentSerie struct {
+	Name    string          `json:"metric"`
+	Host    string          `json:"host"`
+	Tags    []string        `json:"tags"`
+	Type    string          `json:"type"`
+	Points  []metrics.Point `json:"points"`
+	Flushed int64           `json:"flushed"` // unix timestamp in seconds
+}
+
+// RecentSeriesFilter selects the recent series by metric name, host and tag,
+// the globs of a dimension being ANDed
+type RecentSeriesFilter struct {
+	names []*regexp.Regexp
+	hosts []*regexp.Regexp
+	tags  []*regexp.Regexp
+}
+
+// ParseRecentSeriesFilter parses the filters given as <dimension>:<glob>, the
+// dimension being name, host or tag, e.g. name:docker.* or tag:image_name:redis
+func ParseRecentSeriesFilter(filters []string) (RecentSeriesFilter, error) {
+	var f RecentSeriesFilter
+	for _, filter := range filters {
+		parts := strings.SplitN(filter, ":", 2)
+		if len(parts) != 2 {
+			return f, fmt.Errorf("invalid filter %q, expected <name|host|tag>:<glob>", filter)
+		}
+		re := globToRegexp(parts[1])
+		switch parts[0] {
+		case "name":
+			f.names = append(f.names, re)
+		case "host":
+			f.hosts = append(f.hosts, re)
+		case "tag":
+			f.tags = append(f.tags, re)
+		default:
+			return f, fmt.Errorf("invalid filter %q, expected <name|host|tag>:<glob>", filter)
+		}
+	}
+	return f, nil
+}
+
+func globToRegexp(glob string) *regexp.Regexp {
+	return regexp.MustCompile("^" + strings.Replace(regexp.QuoteMeta(glob), `\*`, ".*", -1) + "$")
+}
+
+func (f RecentSeriesFilter) match(serie *RecentSerie) bool {
+	for _, re := range f.names {
+		if !re.MatchString(serie.Name) {
+			return false
+		}
+	}
+	for _, re := range f.hosts {
+		if !re.MatchString(serie.Host) {
+			return false
+		}
+	}
+	for _, re := range f.tags {
+		found := false
+		for _, tag := range serie.Tags {
+			if re.MatchString(tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// recentSeriesStore is a ring buffer of the last maxSize series flushed, only
+// the ones flushed within the retention being returned
+type recentSeriesStore struct {
+	sync.Mutex
+	retention time.Duration
+	series    []RecentSerie
+	next      int
+	full      bool
+}
+
+func newRecentSeriesStore(maxSize int, retention time.Duration) *recentSeriesStore {
+	return &recentSeriesStore{
+		retention: retention,
+		series:    make([]RecentSerie, maxSize),
+	}
+}
+
+// add records the series of a flush
+func (s *recentSeriesStore) add(series metrics.Series, flushed time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	for _, serie := range series {
+		s.series[s.next] = RecentSerie{
+			Name:    serie.Name,
+			Host:    serie.Host,
+			Tags:    serie.Tags,
+			Type:    serie.MType.String(),
+			Points:  serie.Points,
+			Flushed: flushed.Unix(),
+		}
+		s.next++
+		if s.next == len(s.series) {
+			s.next = 0
+			s.full = true
+		}
+	}
+}
+
+// get returns the series flushed within the retention matching the filter,
+// the most recent first
+func (s *recentSeriesStore) get(filter RecentSeriesFilter, now time.Time) []RecentSerie {
+	s.Lock()
+	defer s.Unlock()
+
+	size := s.next
+	if s.full {
+		size = len(s.series)
+	}
+	since := now.Add(-s.retention).Unix()
+	result := []RecentSerie{}
+	for i := 1; i <= size; i++ {
+		serie := &s.series[(s.next-i+len(s.series))%len(s.series)]
+		if serie.Flushed < since {
+			// the older ones are out of the retention too
+			break
+		}
+		if filter.match(serie) {
+			result = append(result, *serie)
+		}
+	}
+	return result
+}
+
+// GetRecentSeries returns the series flushed by the aggregator in the last
+// recent_series.retention seconds matching the filter, the most recent first
+func GetRecentSeries(filter RecentSeriesFilter) ([]RecentSerie, error) {
+	if aggregatorInstance == nil {
+		return nil, fmt.Errorf("aggregator is not initialized")
+	}
+	if aggregatorInstance.recentSeries == nil {
+		return nil, fmt.Errorf("the recent series aren't kept, recent_series.max_size is 0")
+	}
+	return aggregatorInstance.recentSeries.get(filter, time.Now()), nil
+}
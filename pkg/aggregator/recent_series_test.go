@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func recentSerieNames(series []RecentSerie) []string {
+	names := []string{}
+	for _, s := range series {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestRecentSeriesStoreRing(t *testing.T) {
+	s := newRecentSeriesStore(3, 5*time.Minute)
+	now := time.Now()
+	all, _ := ParseRecentSeriesFilter(nil)
+
+	assert.Empty(t, s.get(all, now))
+
+	s.add(metrics.Series{{Name: "a"}, {Name: "b"}}, now)
+	assert.Equal(t, []string{"b", "a"}, recentSerieNames(s.get(all, now)))
+
+	// the oldest series are overwritten
+	s.add(metrics.Series{{Name: "c"}, {Name: "d"}}, now)
+	assert.Equal(t, []string{"d", "c", "b"}, recentSerieNames(s.get(all, now)))
+}
+
+func TestRecentSeriesStoreRetention(t *testing.T) {
+	s := newRecentSeriesStore(10, 5*time.Minute)
+	now := time.Now()
+	all, _ := ParseRecentSeriesFilter(nil)
+
+	s.add(metrics.Series{{Name: "old"}}, now.Add(-10*time.Minute))
+	s.add(metrics.Series{{Name: "recent", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 1, Value: 42}}}}, now.Add(-time.Minute))
+
+	series := s.get(all, now)
+	require.Len(t, series, 1)
+	assert.Equal(t, "recent", series[0].Name)
+	assert.Equal(t, "gauge", series[0].Type)
+	assert.Equal(t, 42.0, series[0].Points[0].Value)
+	assert.Equal(t, now.Add(-time.Minute).Unix(), series[0].Flushed)
+}
+
+func TestRecentSeriesFilter(t *testing.T) {
+	s := newRecentSeriesStore(10, 5*time.Minute)
+	now := time.Now()
+	s.add(metrics.Series{
+		{Name: "docker.cpu.usage", Host: "host1", Tags: []string{"image_name:redis", "env:prod"}},
+		{Name: "docker.mem.rss", Host: "host1", Tags: []string{"image_name:nginx"}},
+		{Name: "system.cpu.user", Host: "host2"},
+	}, now)
+
+	for _, tc := range []struct {
+		filters  []string
+		expected []string
+	}{
+		{[]string{"name:docker.*"}, []string{"docker.mem.rss", "docker.cpu.usage"}},
+		{[]string{"name:*.cpu.*"}, []string{"system.cpu.user", "docker.cpu.usage"}},
+		{[]string{"name:docker.*", "tag:image_name:redis"}, []string{"docker.cpu.usage"}},
+		{[]string{"tag:env:*"}, []string{"docker.cpu.usage"}},
+		{[]string{"host:host2"}, []string{"system.cpu.user"}},
+		{[]string{"name:docker"}, []string{}},
+	} {
+		filter, err := ParseRecentSeriesFilter(tc.filters)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, recentSerieNames(s.get(filter, now)), "filters %v", tc.filters)
+	}
+
+	_, err := ParseRecentSeriesFilter([]string{"docker.*"})
+	assert.Error(t, err)
+	_, err = ParseRecentSeriesFilter([]string{"source:docker"})
+	assert.Error(t, err)
+}
@@ -11,8 +11,11 @@ import (
 	"sync"
 	"time"
 
+	yaml "gopkg.in/yaml.v2"
+
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
@@ -37,6 +40,8 @@ type Sender interface {
 	GetMetricStats() map[string]int64
 	DisableDefaultHostname(disable bool)
 	SetCheckCustomTags(tags []string)
+	SetCheckService(service string)
+	SetServiceCheckFlapThreshold(threshold int)
 }
 
 type metricStats struct {
@@ -66,6 +71,9 @@ type checkSender struct {
 	eventOut                chan<- metrics.Event
 	histogramBucketOut      chan<- senderHistogramBucket
 	checkTags               []string
+	customTags              []string
+	service                 string
+	flapSuppressor          *flapSuppressor
 }
 
 type senderMetricSample struct {
@@ -133,6 +141,52 @@ func SetSender(sender Sender, id check.ID) error {
 	return senderPool.setSender(sender, id)
 }
 
+// ConfigureCheckSender sets up the sender of a check with the options common
+// to all the check instances, so that any check honors them without code
+// changes: `empty_default_hostname`, `tags`, `service` and
+// `service_check_flap_threshold`. The service and the flap threshold of the
+// instance override the ones of the init_config.
+func ConfigureCheckSender(id check.ID, instance, initConfig integration.Data) error {
+	commonOptions := integration.CommonInstanceConfig{}
+	if err := yaml.Unmarshal(instance, &commonOptions); err != nil {
+		return fmt.Errorf("invalid instance section: %s", err)
+	}
+	commonInitOptions := integration.CommonInitConfig{}
+	if err := yaml.Unmarshal(initConfig, &commonInitOptions); err != nil {
+		return fmt.Errorf("invalid init_config section: %s", err)
+	}
+	service := commonOptions.Service
+	if service == "" {
+		service = commonInitOptions.Service
+	}
+	flapThreshold := commonOptions.ServiceCheckFlapThreshold
+	if flapThreshold == 0 {
+		flapThreshold = commonInitOptions.ServiceCheckFlapThreshold
+	}
+
+	if !commonOptions.EmptyDefaultHostname && len(commonOptions.Tags) == 0 && service == "" && flapThreshold <= 1 {
+		return nil
+	}
+
+	s, err := GetSender(id)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve a sender: %s", err)
+	}
+	if commonOptions.EmptyDefaultHostname {
+		s.DisableDefaultHostname(true)
+	}
+	if len(commonOptions.Tags) > 0 {
+		s.SetCheckCustomTags(commonOptions.Tags)
+	}
+	if service != "" {
+		s.SetCheckService(service)
+	}
+	if flapThreshold > 1 {
+		s.SetServiceCheckFlapThreshold(flapThreshold)
+	}
+	return nil
+}
+
 // GetDefaultSender returns the default sender
 func GetDefaultSender() (Sender, error) {
 	if aggregatorInstance == nil {
@@ -166,7 +220,56 @@ func (s *checkSender) DisableDefaultHostname(disable bool) {
 // SetCheckCustomTags stores the tags set in the check configuration file.
 // They will be appended to each send (metric, event and service)
 func (s *checkSender) SetCheckCustomTags(tags []string) {
-	s.checkTags = tags
+	s.customTags = tags
+	s.updateCheckTags()
+}
+
+// SetCheckService stores the service set in the check configuration file.
+// A `service` tag will be appended to each send (metric, event and service)
+func (s *checkSender) SetCheckService(service string) {
+	s.service = service
+	s.updateCheckTags()
+}
+
+// SetServiceCheckFlapThreshold sets the number of consecutive submissions of a
+// new status required to forward the transition of a service check. The
+// transitions are forwarded right away with a threshold of 1 or less.
+func (s *checkSender) SetServiceCheckFlapThreshold(threshold int) {
+	if threshold <= 1 {
+		s.flapSuppressor = nil
+		return
+	}
+	s.flapSuppressor = newFlapSuppressor(threshold)
+}
+
+func (s *checkSender) updateCheckTags() {
+	if s.service == "" {
+		s.checkTags = s.customTags
+		return
+	}
+	checkTags := make([]string, 0, len(s.customTags)+1)
+	checkTags = append(checkTags, s.customTags...)
+	s.checkTags = append(checkTags, "service:"+s.service)
+}
+
+// appendCheckTags appends the check tags missing from tags: checks, like the
+// python ones, may already add the tags of their instance themselves
+func (s *checkSender) appendCheckTags(tags []string) []string {
+	for _, checkTag := range s.checkTags {
+		if !containsTag(tags, checkTag) {
+			tags = append(tags, checkTag)
+		}
+	}
+	return tags
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
 }
 
 // Commit commits the metric samples & histogram buckets that were added during a check run
@@ -175,6 +278,9 @@ func (s *checkSender) Commit() {
 	// we use a metric sample to commit both for metrics & sketches
 	s.smsOut <- senderMetricSample{s.id, &metrics.MetricSample{}, true}
 	s.cyclemetricStats()
+	if s.flapSuppressor != nil {
+		s.flapSuppressor.commit()
+	}
 }
 
 func (s *checkSender) GetMetricStats() map[string]int64 {
@@ -212,7 +318,7 @@ func (s *checkSender) SendRawMetricSample(sample *metrics.MetricSample) {
 }
 
 func (s *checkSender) sendMetricSample(metric string, value float64, hostname string, tags []string, mType metrics.MetricType) {
-	tags = append(tags, s.checkTags...)
+	tags = s.appendCheckTags(tags)
 
 	log.Trace(mType.String(), " sample: ", metric, ": ", value, " for hostname: ", hostname, " tags: ", tags)
 
@@ -272,7 +378,7 @@ func (s *checkSender) Histogram(metric string, value float64, hostname string, t
 
 // HistogramBucket should be called to directly send raw buckets to be submitted as distribution metrics
 func (s *checkSender) HistogramBucket(metric string, value int, lowerBound, upperBound float64, monotonic bool, hostname string, tags []string) {
-	tags = append(tags, s.checkTags...)
+	tags = s.appendCheckTags(tags)
 
 	log.Tracef(
 		"Histogram Bucket %s submitted: %v [%f-%f] monotonic: %v for host %s tags: %v",
@@ -327,7 +433,7 @@ func (s *checkSender) ServiceCheck(checkName string, status metrics.ServiceCheck
 		Status:    status,
 		Host:      hostname,
 		Ts:        time.Now().Unix(),
-		Tags:      append(tags, s.checkTags...),
+		Tags:      s.appendCheckTags(tags),
 		Message:   message,
 	}
 
@@ -335,6 +441,14 @@ func (s *checkSender) ServiceCheck(checkName string, status metrics.ServiceCheck
 		serviceCheck.Host = s.defaultHostname
 	}
 
+	if s.flapSuppressor != nil {
+		var suppressed bool
+		if serviceCheck.Status, suppressed = s.flapSuppressor.filter(&serviceCheck); suppressed {
+			aggregatorServiceCheckFlapsSuppressed.Add(1)
+			s.sendMetricSample("datadog.agent.service_check.suppressed_transitions", 1, hostname, []string{"service_check:" + checkName}, metrics.CountType)
+		}
+	}
+
 	s.serviceCheckOut <- serviceCheck
 
 	s.metricStats.Lock.Lock()
@@ -344,7 +458,7 @@ func (s *checkSender) ServiceCheck(checkName string, status metrics.ServiceCheck
 
 // Event submits an event
 func (s *checkSender) Event(e metrics.Event) {
-	e.Tags = append(e.Tags, s.checkTags...)
+	e.Tags = s.appendCheckTags(e.Tags)
 
 	log.Trace("Event submitted: ", e.Title, " for hostname: ", e.Host, " tags: ", e.Tags)
 
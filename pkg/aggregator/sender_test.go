@@ -295,6 +295,69 @@ func TestGetSenderAddCheckCustomTagsHistogramBucket(t *testing.T) {
 	assert.Equal(t, append(checkTags, customTags...), bucketSample.bucket.Tags)
 }
 
+func TestGetSenderAddCheckService(t *testing.T) {
+	resetAggregator()
+	InitAggregator(nil, "testhostname", "")
+
+	senderMetricSampleChan := make(chan senderMetricSample, 10)
+	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
+	eventChan := make(chan metrics.Event, 10)
+	bucketChan := make(chan senderHistogramBucket, 10)
+	checkSender := newCheckSender(checkID1, "", senderMetricSampleChan, serviceCheckChan, eventChan, bucketChan)
+
+	customTags := []string{"custom:tag1", "custom:tag2"}
+	checkSender.SetCheckCustomTags(customTags)
+	checkSender.SetCheckService("my-service")
+	assert.Equal(t, []string{"custom:tag1", "custom:tag2", "service:my-service"}, checkSender.checkTags)
+	// the custom tags are not modified
+	assert.Equal(t, []string{"custom:tag1", "custom:tag2"}, customTags)
+
+	checkSender.sendMetricSample("metric.test", 42.0, "testhostname", []string{"check:tag1"}, metrics.GaugeType)
+	sms := <-senderMetricSampleChan
+	assert.Equal(t, []string{"check:tag1", "custom:tag1", "custom:tag2", "service:my-service"}, sms.metricSample.Tags)
+
+	// the tags already added by the check are not duplicated
+	checkSender.sendMetricSample("metric.test", 42.0, "testhostname", []string{"custom:tag1", "check:tag1"}, metrics.GaugeType)
+	sms = <-senderMetricSampleChan
+	assert.Equal(t, []string{"custom:tag1", "check:tag1", "custom:tag2", "service:my-service"}, sms.metricSample.Tags)
+
+	checkSender.SetCheckService("")
+	assert.Equal(t, customTags, checkSender.checkTags)
+}
+
+func TestConfigureCheckSender(t *testing.T) {
+	resetAggregator()
+	InitAggregator(nil, "testhostname", "")
+
+	// no common option, no sender is created
+	err := ConfigureCheckSender(checkID1, []byte("foo: bar"), nil)
+	assert.NoError(t, err)
+	_, err = senderPool.getSender(checkID1)
+	assert.Error(t, err)
+
+	instance := []byte("tags: [\"foo:bar\"]\nempty_default_hostname: true")
+	initConfig := []byte("service: init-service")
+	err = ConfigureCheckSender(checkID1, instance, initConfig)
+	assert.NoError(t, err)
+	s, err := senderPool.getSender(checkID1)
+	require.NoError(t, err)
+	checkSender := s.(*checkSender)
+	assert.True(t, checkSender.defaultHostnameDisabled)
+	assert.Equal(t, []string{"foo:bar", "service:init-service"}, checkSender.checkTags)
+
+	// the service of the instance overrides the one of the init_config
+	err = ConfigureCheckSender(checkID2, []byte("service: instance-service"), initConfig)
+	assert.NoError(t, err)
+	s, err = senderPool.getSender(checkID2)
+	require.NoError(t, err)
+	checkSender = s.(*checkSender)
+	assert.False(t, checkSender.defaultHostnameDisabled)
+	assert.Equal(t, []string{"service:instance-service"}, checkSender.checkTags)
+
+	err = ConfigureCheckSender(checkID1, []byte("tags: foo"), nil)
+	assert.Error(t, err)
+}
+
 func TestCheckSenderInterface(t *testing.T) {
 	senderMetricSampleChan := make(chan senderMetricSample, 10)
 	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
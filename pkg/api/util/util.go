@@ -11,11 +11,16 @@ import (
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/api/security"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 var (
 	token    string
 	dcaToken string
+
+	// dcaTokenValidator validates the tokens of the requests to the DCA
+	// other than the shared one, like the service account tokens
+	dcaTokenValidator func(token string) error
 )
 
 // SetAuthToken sets the session token
@@ -56,6 +61,12 @@ func GetDCAAuthToken() string {
 	return dcaToken
 }
 
+// SetDCATokenValidator sets the validator of the tokens other than the DCA
+// session token, it must be called before the DCA API is served
+func SetDCATokenValidator(validator func(token string) error) {
+	dcaTokenValidator = validator
+}
+
 // Validate validates an http request
 func Validate(w http.ResponseWriter, r *http.Request) error {
 	var err error
@@ -103,8 +114,21 @@ func ValidateDCARequest(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	if len(tok) != 2 || tok[1] != GetDCAAuthToken() {
+	if len(tok) != 2 {
+		err = fmt.Errorf("invalid session token")
+		http.Error(w, err.Error(), 403)
+		return err
+	}
+
+	if tok[1] != GetDCAAuthToken() {
 		err = fmt.Errorf("invalid session token")
+		if dcaTokenValidator != nil {
+			validationErr := dcaTokenValidator(tok[1])
+			if validationErr == nil {
+				return nil
+			}
+			log.Debugf("Rejected a request to the DCA from %s: %v", r.RemoteAddr, validationErr)
+		}
 		http.Error(w, err.Error(), 403)
 	}
 
@@ -21,8 +21,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/blocklist"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/retry"
+	"github.com/DataDog/datadog-agent/pkg/util/supervisor"
 )
 
 var (
@@ -64,7 +66,9 @@ type AutoConfig struct {
 	newService         chan listeners.Service
 	delService         chan listeners.Service
 	store              *store
-	m                  sync.RWMutex
+	// blocklist is the workload blocklist the services were resolved with
+	blocklist *blocklist.Blocklist
+	m         sync.RWMutex
 }
 
 // NewAutoConfig creates an AutoConfig instance.
@@ -79,9 +83,10 @@ func NewAutoConfig(scheduler *scheduler.MetaScheduler) *AutoConfig {
 		delService:         make(chan listeners.Service),
 		store:              newStore(),
 		scheduler:          scheduler,
+		blocklist:          blocklist.Get(),
 	}
 	// We need to listen to the service channels before anything is sent to them
-	go ac.serviceListening()
+	supervisor.Go("ad-servicelistening", ac.serviceListening)
 	return ac
 }
 
@@ -107,6 +112,7 @@ func (ac *AutoConfig) serviceListening() {
 			})
 		case <-tagFreshnessTicker.C:
 			ac.checkTagFreshness()
+			ac.checkBlocklist()
 		}
 	}
 }
@@ -132,6 +138,27 @@ func (ac *AutoConfig) checkTagFreshness() {
 	}
 }
 
+// checkBlocklist reschedules the services whose checks or logs are excluded,
+// or not anymore, by the workload blocklist since it last changed
+func (ac *AutoConfig) checkBlocklist() {
+	current := blocklist.Get()
+	if current == ac.blocklist {
+		return
+	}
+	previous := ac.blocklist
+	ac.blocklist = current
+	for _, service := range ac.store.getServices() {
+		entity := service.GetEntity()
+		if previous.IsEntityExcluded(entity, blocklist.Metrics) == current.IsEntityExcluded(entity, blocklist.Metrics) &&
+			previous.IsEntityExcluded(entity, blocklist.Logs) == current.IsEntityExcluded(entity, blocklist.Logs) {
+			continue
+		}
+		log.Debugf("Workload blocklist changed for service %s, rescheduling associated checks and logs", entity)
+		ac.processDelService(service)
+		ac.processNewService(service)
+	}
+}
+
 // Stop just shuts down AutoConfig in a clean way.
 // AutoConfig is not supposed to be restarted, so this is expected
 // to be called only once at program exit.
@@ -531,6 +558,15 @@ func (ac *AutoConfig) resolveTemplateForService(tpl integration.Config, svc list
 		errorStats.setResolveWarning(tpl.Name, newErr.Error())
 		return tpl, log.Warn(newErr)
 	}
+	// drop the parts of the config excluded by the workload blocklist
+	if blocklist.IsEntityExcluded(svc.GetEntity(), blocklist.Metrics) {
+		log.Debugf("Metrics of %s are excluded by the workload blocklist", svc.GetEntity())
+		resolvedConfig.Instances = nil
+	}
+	if blocklist.IsEntityExcluded(svc.GetEntity(), blocklist.Logs) {
+		log.Debugf("Logs of %s are excluded by the workload blocklist", svc.GetEntity())
+		resolvedConfig.LogsConfig = nil
+	}
 	ac.store.setLoadedConfig(resolvedConfig)
 	ac.store.addConfigForService(svc.GetEntity(), resolvedConfig)
 	ac.store.addConfigForTemplate(tpl.Digest(), resolvedConfig)
@@ -60,6 +60,16 @@ type CommonInstanceConfig struct {
 	Tags                  []string `yaml:"tags"`
 	Name                  string   `yaml:"name"`
 	Namespace             string   `yaml:"namespace"`
+	Service               string   `yaml:"service"`
+	// ServiceCheckFlapThreshold is the number of consecutive submissions of a new
+	// service check status required to forward the transition
+	ServiceCheckFlapThreshold int `yaml:"service_check_flap_threshold"`
+}
+
+// CommonInitConfig holds the reserved fields for the yaml init_config data
+type CommonInitConfig struct {
+	Service                   string `yaml:"service"`
+	ServiceCheckFlapThreshold int    `yaml:"service_check_flap_threshold"`
 }
 
 // Equal determines whether the passed config is the same
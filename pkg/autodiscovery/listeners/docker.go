@@ -63,7 +63,7 @@ func NewDockerListener() (ServiceListener, error) {
 	if err != nil {
 		return nil, err
 	}
-	filter, err := containers.NewFilterFromConfigIncludePause()
+	filter, err := containers.GetFilter(containers.ADFilter)
 	if err != nil {
 		return nil, err
 	}
@@ -200,7 +200,7 @@ func (l *DockerListener) createService(cID string) {
 			log.Warnf("error while resolving image name: %s", err)
 			image = ""
 		}
-		if l.filter.IsExcluded(cInspect.Name, image) {
+		if l.filter.IsContainerExcluded(containers.FilterableContainer{Name: cInspect.Name, Image: image, Labels: cInspect.Config.Labels}) {
 			log.Debugf("container %s filtered out: name %q image %q", cID[:12], cInspect.Name, image)
 			return
 		}
@@ -343,7 +343,7 @@ func (l *DockerListener) isExcluded(co types.Container) bool {
 		image = ""
 	}
 	for _, name := range co.Names {
-		if l.filter.IsExcluded(name, image) {
+		if l.filter.IsContainerExcluded(containers.FilterableContainer{Name: name, Image: image, Labels: co.Labels}) {
 			log.Debugf("container %s filtered out: name %q image %q", co.ID[:12], name, image)
 			return true
 		}
@@ -53,7 +53,7 @@ func init() {
 
 // NewECSListener creates an ECSListener
 func NewECSListener() (ServiceListener, error) {
-	filter, err := containers.NewFilterFromConfigIncludePause()
+	filter, err := containers.GetFilter(containers.ADFilter)
 	if err != nil {
 		return nil, err
 	}
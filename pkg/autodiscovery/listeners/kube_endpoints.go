@@ -17,6 +17,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	infov1 "k8s.io/client-go/informers/core/v1"
@@ -30,12 +31,14 @@ const (
 
 // KubeEndpointsListener listens to kubernetes endpoints creation
 type KubeEndpointsListener struct {
-	endpointsInformer infov1.EndpointsInformer
-	serviceLister     listv1.ServiceLister
-	endpoints         map[types.UID][]*KubeEndpointService
-	newService        chan<- Service
-	delService        chan<- Service
-	m                 sync.RWMutex
+	endpointsInformers []infov1.EndpointsInformer
+	// serviceListers list the services by watched namespace, see
+	// apiserver.APIClient.InformerFactoriesByNamespace
+	serviceListers map[string]listv1.ServiceLister
+	endpoints      map[types.UID][]*KubeEndpointService
+	newService     chan<- Service
+	delService     chan<- Service
+	m              sync.RWMutex
 }
 
 // KubeEndpointService represents an endpoint in a Kubernetes Endpoints
@@ -56,19 +59,23 @@ func NewKubeEndpointsListener() (ServiceListener, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
 	}
-	endpointsInformer := ac.InformerFactory.Core().V1().Endpoints()
-	if endpointsInformer == nil {
-		return nil, fmt.Errorf("cannot get endpoints informer: %s", err)
+	l := &KubeEndpointsListener{
+		endpoints:      make(map[types.UID][]*KubeEndpointService),
+		serviceListers: make(map[string]listv1.ServiceLister),
 	}
-	serviceInformer := ac.InformerFactory.Core().V1().Services()
-	if serviceInformer == nil {
-		return nil, fmt.Errorf("cannot get service informer: %s", err)
+	for ns, factory := range ac.InformerFactoriesByNamespace() {
+		endpointsInformer := factory.Core().V1().Endpoints()
+		if endpointsInformer == nil {
+			return nil, fmt.Errorf("cannot get endpoints informer: %s", err)
+		}
+		serviceInformer := factory.Core().V1().Services()
+		if serviceInformer == nil {
+			return nil, fmt.Errorf("cannot get service informer: %s", err)
+		}
+		l.endpointsInformers = append(l.endpointsInformers, endpointsInformer)
+		l.serviceListers[ns] = serviceInformer.Lister()
 	}
-	return &KubeEndpointsListener{
-		endpoints:         make(map[types.UID][]*KubeEndpointService),
-		endpointsInformer: endpointsInformer,
-		serviceLister:     serviceInformer.Lister(),
-	}, nil
+	return l, nil
 }
 
 func (l *KubeEndpointsListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
@@ -76,19 +83,21 @@ func (l *KubeEndpointsListener) Listen(newSvc chan<- Service, delSvc chan<- Serv
 	l.newService = newSvc
 	l.delService = delSvc
 
-	l.endpointsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    l.added,
-		UpdateFunc: l.updated,
-		DeleteFunc: l.deleted,
-	})
-
-	// Initial fill
-	endpoints, err := l.endpointsInformer.Lister().List(labels.Everything())
-	if err != nil {
-		log.Errorf("Cannot list Kubernetes endpoints: %s", err)
-	}
-	for _, e := range endpoints {
-		l.createService(e, true)
+	for _, endpointsInformer := range l.endpointsInformers {
+		endpointsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    l.added,
+			UpdateFunc: l.updated,
+			DeleteFunc: l.deleted,
+		})
+
+		// Initial fill
+		endpoints, err := endpointsInformer.Lister().List(labels.Everything())
+		if err != nil {
+			log.Errorf("Cannot list Kubernetes endpoints: %s", err)
+		}
+		for _, e := range endpoints {
+			l.createService(e, true)
+		}
 	}
 }
 
@@ -160,7 +169,15 @@ func subsetsDiffer(first, second *v1.Endpoints) bool {
 // isEndpointsAnnotated looks for the corresponding service of a kubernetes endpoints object
 // and returns true if the service has endpoints annotations, otherwise returns false.
 func (l *KubeEndpointsListener) isEndpointsAnnotated(kep *v1.Endpoints) bool {
-	ksvc, err := l.serviceLister.Services(kep.Namespace).Get(kep.Name)
+	serviceLister, found := l.serviceListers[kep.Namespace]
+	if !found {
+		// the agent watches the whole cluster
+		serviceLister, found = l.serviceListers[metav1.NamespaceAll]
+	}
+	if !found {
+		return false
+	}
+	ksvc, err := serviceLister.Services(kep.Namespace).Get(kep.Name)
 	if err != nil {
 		log.Tracef("Cannot get Kubernetes service: %s", err)
 	}
@@ -30,7 +30,7 @@ const (
 
 // KubeServiceListener listens to kubernetes service creation
 type KubeServiceListener struct {
-	informer   infov1.ServiceInformer
+	informers  []infov1.ServiceInformer
 	services   map[types.UID]Service
 	newService chan<- Service
 	delService chan<- Service
@@ -55,13 +55,17 @@ func NewKubeServiceListener() (ServiceListener, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
 	}
-	servicesInformer := ac.InformerFactory.Core().V1().Services()
-	if servicesInformer == nil {
-		return nil, fmt.Errorf("cannot get service informer: %s", err)
+	var informers []infov1.ServiceInformer
+	for _, factory := range ac.InformerFactoriesByNamespace() {
+		servicesInformer := factory.Core().V1().Services()
+		if servicesInformer == nil {
+			return nil, fmt.Errorf("cannot get service informer: %s", err)
+		}
+		informers = append(informers, servicesInformer)
 	}
 	return &KubeServiceListener{
-		services: make(map[types.UID]Service),
-		informer: servicesInformer,
+		services:  make(map[types.UID]Service),
+		informers: informers,
 	}, nil
 }
 
@@ -70,19 +74,21 @@ func (l *KubeServiceListener) Listen(newSvc chan<- Service, delSvc chan<- Servic
 	l.newService = newSvc
 	l.delService = delSvc
 
-	l.informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    l.added,
-		UpdateFunc: l.updated,
-		DeleteFunc: l.deleted,
-	})
-
-	// Initial fill
-	services, err := l.informer.Lister().List(labels.Everything())
-	if err != nil {
-		log.Errorf("Cannot list Kubernetes services: %s", err)
-	}
-	for _, s := range services {
-		l.createService(s, true)
+	for _, informer := range l.informers {
+		informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    l.added,
+			UpdateFunc: l.updated,
+			DeleteFunc: l.deleted,
+		})
+
+		// Initial fill
+		services, err := informer.Lister().List(labels.Everything())
+		if err != nil {
+			log.Errorf("Cannot list Kubernetes services: %s", err)
+		}
+		for _, s := range services {
+			l.createService(s, true)
+		}
 	}
 }
 
@@ -65,11 +65,11 @@ func init() {
 }
 
 func NewKubeletListener() (ServiceListener, error) {
-	watcher, err := kubelet.NewPodWatcher(15*time.Second, false)
+	watcher, err := kubelet.NewPodWatcher(time.Duration(config.Datadog.GetInt("kubernetes_deleted_pods_grace_period"))*time.Second, false)
 	if err != nil {
 		return nil, err
 	}
-	filter, err := containers.NewFilterFromConfigIncludePause()
+	filter, err := containers.GetFilter(containers.ADFilter)
 	if err != nil {
 		return nil, err
 	}
@@ -207,7 +207,12 @@ func (l *KubeletListener) createService(entity string, pod *kubelet.Pod, firstRu
 	var containerName string
 	for _, container := range pod.Status.GetAllContainers() {
 		if container.ID == svc.entity {
-			if l.filter.IsExcluded(container.Name, container.Image) {
+			if l.filter.IsContainerExcluded(containers.FilterableContainer{
+				Name:      container.Name,
+				Image:     container.Image,
+				Namespace: pod.Metadata.Namespace,
+				Labels:    pod.Metadata.Labels,
+			}) {
 				log.Debugf("container %s filtered out: name %q image %q", container.ID, container.Name, container.Image)
 				return
 			}
@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// datadogCheckGVR is the resource of the DatadogCheck custom resources, see
+// Dockerfiles/manifests/cluster-agent/datadogcheck-crd.yaml
+var datadogCheckGVR = schema.GroupVersionResource{
+	Group:    "datadoghq.com",
+	Version:  "v1alpha1",
+	Resource: "datadogchecks",
+}
+
+// KubeDatadogCheckConfigProvider implements the ConfigProvider interface for
+// the DatadogCheck custom resources. Each resource holds the configuration of
+// a cluster check:
+//
+//  apiVersion: datadoghq.com/v1alpha1
+//  kind: DatadogCheck
+//  metadata:
+//    name: redis
+//  spec:
+//    checkName: redisdb
+//    initConfig: {}
+//    instances:
+//      - host: redis.prod.svc
+//        port: 6379
+type KubeDatadogCheckConfigProvider struct {
+	sync.RWMutex
+	informers []cache.SharedIndexInformer
+	upToDate  bool
+}
+
+// NewKubeDatadogCheckConfigProvider returns a new ConfigProvider watching the
+// DatadogCheck resources of the namespaces watched by the cluster agent.
+func NewKubeDatadogCheckConfigProvider(cfg config.ConfigurationProviders) (ConfigProvider, error) {
+	ac, err := apiserver.GetAPIClient()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
+	}
+
+	p := &KubeDatadogCheckConfigProvider{}
+	resync := apiserver.ResyncPeriod("datadogchecks")
+	// the provider lives as long as the cluster agent
+	stopCh := make(chan struct{})
+	for ns := range ac.InformerFactoriesByNamespace() {
+		informer := cache.NewSharedIndexInformer(ac.NewUnstructuredListWatch(datadogCheckGVR, ns), &unstructured.Unstructured{}, resync, cache.Indexers{})
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    p.invalidate,
+			UpdateFunc: p.invalidateIfChanged,
+			DeleteFunc: p.invalidate,
+		})
+		apiserver.RegisterInformerTelemetry(apiserver.InformerName("datadogchecks", ns), informer)
+		p.informers = append(p.informers, informer)
+		go informer.Run(stopCh)
+	}
+
+	return p, nil
+}
+
+// String returns a string representation of the KubeDatadogCheckConfigProvider
+func (k *KubeDatadogCheckConfigProvider) String() string {
+	return KubeDatadogChecks
+}
+
+// Collect converts the DatadogCheck resources to cluster check configs
+func (k *KubeDatadogCheckConfigProvider) Collect() ([]integration.Config, error) {
+	k.Lock()
+	k.upToDate = true
+	k.Unlock()
+
+	var configs []integration.Config
+	for _, informer := range k.informers {
+		for _, obj := range informer.GetStore().List() {
+			check, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			cfg, err := parseDatadogCheck(check)
+			if err != nil {
+				log.Errorf("Ignoring the invalid DatadogCheck %s/%s: %s", check.GetNamespace(), check.GetName(), err)
+				continue
+			}
+			configs = append(configs, cfg)
+		}
+	}
+	return configs, nil
+}
+
+// IsUpToDate allows to cache configs as long as no DatadogCheck changes
+func (k *KubeDatadogCheckConfigProvider) IsUpToDate() (bool, error) {
+	k.RLock()
+	defer k.RUnlock()
+	return k.upToDate, nil
+}
+
+func (k *KubeDatadogCheckConfigProvider) invalidate(obj interface{}) {
+	if obj != nil {
+		log.Trace("Invalidating configs on new/deleted DatadogCheck")
+		k.Lock()
+		k.upToDate = false
+		k.Unlock()
+	}
+}
+
+func (k *KubeDatadogCheckConfigProvider) invalidateIfChanged(old, obj interface{}) {
+	castedObj, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		log.Errorf("Expected an Unstructured type, got: %v", obj)
+		return
+	}
+	castedOld, ok := old.(*unstructured.Unstructured)
+	if ok && castedObj.GetResourceVersion() == castedOld.GetResourceVersion() {
+		return
+	}
+	k.invalidate(obj)
+}
+
+// parseDatadogCheck converts the spec of a DatadogCheck to a cluster check config
+func parseDatadogCheck(check *unstructured.Unstructured) (integration.Config, error) {
+	cfg := integration.Config{
+		ClusterCheck: true,
+		Source:       fmt.Sprintf("kube_datadogchecks:%s/%s", check.GetNamespace(), check.GetName()),
+	}
+
+	checkName, found, err := unstructured.NestedString(check.Object, "spec", "checkName")
+	if err != nil {
+		return cfg, err
+	}
+	if !found || checkName == "" {
+		return cfg, errors.New("spec.checkName is required")
+	}
+	cfg.Name = checkName
+
+	instances, found, err := unstructured.NestedSlice(check.Object, "spec", "instances")
+	if err != nil {
+		return cfg, err
+	}
+	if !found || len(instances) == 0 {
+		return cfg, errors.New("spec.instances needs at least an instance")
+	}
+	for i, instance := range instances {
+		if _, ok := instance.(map[string]interface{}); !ok {
+			return cfg, fmt.Errorf("spec.instances[%d] is not an object", i)
+		}
+		data, err := json.Marshal(instance)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Instances = append(cfg.Instances, data)
+	}
+
+	initConfig, found, err := unstructured.NestedMap(check.Object, "spec", "initConfig")
+	if err != nil {
+		return cfg, err
+	}
+	if !found {
+		initConfig = map[string]interface{}{}
+	}
+	if cfg.InitConfig, err = json.Marshal(initConfig); err != nil {
+		return cfg, err
+	}
+
+	logs, found, err := unstructured.NestedSlice(check.Object, "spec", "logs")
+	if err != nil {
+		return cfg, err
+	}
+	if found {
+		if cfg.LogsConfig, err = json.Marshal(logs); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+func init() {
+	RegisterProvider("kube_datadogchecks", NewKubeDatadogCheckConfigProvider)
+}
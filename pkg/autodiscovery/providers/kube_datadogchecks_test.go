@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+func newDatadogCheck(spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "datadoghq.com/v1alpha1",
+		"kind":       "DatadogCheck",
+		"metadata":   map[string]interface{}{"name": "redis", "namespace": "prod"},
+		"spec":       spec,
+	}}
+}
+
+func TestParseDatadogCheck(t *testing.T) {
+	cfg, err := parseDatadogCheck(newDatadogCheck(map[string]interface{}{
+		"checkName":  "redisdb",
+		"initConfig": map[string]interface{}{"service": "cache"},
+		"instances": []interface{}{
+			map[string]interface{}{"host": "redis.prod.svc", "port": int64(6379)},
+		},
+		"logs": []interface{}{
+			map[string]interface{}{"type": "file", "path": "/var/log/redis.log"},
+		},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, integration.Config{
+		Name:         "redisdb",
+		InitConfig:   integration.Data(`{"service":"cache"}`),
+		Instances:    []integration.Data{integration.Data(`{"host":"redis.prod.svc","port":6379}`)},
+		LogsConfig:   integration.Data(`[{"path":"/var/log/redis.log","type":"file"}]`),
+		ClusterCheck: true,
+		Source:       "kube_datadogchecks:prod/redis",
+	}, cfg)
+
+	cfg, err = parseDatadogCheck(newDatadogCheck(map[string]interface{}{
+		"checkName": "http_check",
+		"instances": []interface{}{map[string]interface{}{"url": "http://example.com"}},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, integration.Data("{}"), cfg.InitConfig)
+	assert.Nil(t, cfg.LogsConfig)
+}
+
+func TestParseInvalidDatadogCheck(t *testing.T) {
+	for name, spec := range map[string]map[string]interface{}{
+		"no check name":         {"instances": []interface{}{map[string]interface{}{}}},
+		"no instance":           {"checkName": "redisdb"},
+		"empty instances":       {"checkName": "redisdb", "instances": []interface{}{}},
+		"instance not object":   {"checkName": "redisdb", "instances": []interface{}{"host"}},
+		"check name not string": {"checkName": int64(1), "instances": []interface{}{map[string]interface{}{}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseDatadogCheck(newDatadogCheck(spec))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -30,9 +30,14 @@ const (
 
 // KubeEndpointsConfigProvider implements the ConfigProvider interface for the apiserver.
 type KubeEndpointsConfigProvider struct {
+	listers  []endpointsListers
+	upToDate bool
+}
+
+// endpointsListers list the services and the endpoints of a watched namespace
+type endpointsListers struct {
 	serviceLister   listersv1.ServiceLister
 	endpointsLister listersv1.EndpointsLister
-	upToDate        bool
 }
 
 // configInfo contains an endpoint check config template with its name and namespace
@@ -49,26 +54,28 @@ func NewKubeEndpointsConfigProvider(config config.ConfigurationProviders) (Confi
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
 	}
-	servicesInformer := ac.InformerFactory.Core().V1().Services()
-	if servicesInformer == nil {
-		return nil, fmt.Errorf("cannot get service informer: %s", err)
-	}
-
-	p := &KubeEndpointsConfigProvider{
-		serviceLister: servicesInformer.Lister(),
-	}
 
-	servicesInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    p.invalidate,
-		UpdateFunc: p.invalidateIfChanged,
-		DeleteFunc: p.invalidate,
-	})
-
-	endpointsInformer := ac.InformerFactory.Core().V1().Endpoints()
-	if endpointsInformer == nil {
-		return nil, fmt.Errorf("cannot get endpoint informer: %s", err)
+	p := &KubeEndpointsConfigProvider{}
+	for _, factory := range ac.InformerFactoriesByNamespace() {
+		servicesInformer := factory.Core().V1().Services()
+		if servicesInformer == nil {
+			return nil, fmt.Errorf("cannot get service informer: %s", err)
+		}
+		servicesInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    p.invalidate,
+			UpdateFunc: p.invalidateIfChanged,
+			DeleteFunc: p.invalidate,
+		})
+
+		endpointsInformer := factory.Core().V1().Endpoints()
+		if endpointsInformer == nil {
+			return nil, fmt.Errorf("cannot get endpoint informer: %s", err)
+		}
+		p.listers = append(p.listers, endpointsListers{
+			serviceLister:   servicesInformer.Lister(),
+			endpointsLister: endpointsInformer.Lister(),
+		})
 	}
-	p.endpointsLister = endpointsInformer.Lister()
 
 	return p, nil
 }
@@ -80,22 +87,24 @@ func (k *KubeEndpointsConfigProvider) String() string {
 
 // Collect retrieves services from the apiserver, builds Config objects and returns them
 func (k *KubeEndpointsConfigProvider) Collect() ([]integration.Config, error) {
-	services, err := k.serviceLister.List(labels.Everything())
-	if err != nil {
-		return nil, err
-	}
-	k.upToDate = true
-
 	var generatedConfigs []integration.Config
-	parsedConfigsInfo := parseServiceAnnotationsForEndpoints(services)
-	for _, config := range parsedConfigsInfo {
-		kep, err := k.endpointsLister.Endpoints(config.namespace).Get(config.name)
+	for _, listers := range k.listers {
+		services, err := listers.serviceLister.List(labels.Everything())
 		if err != nil {
-			log.Errorf("Cannot get Kubernetes endpoints: %s", err)
-			continue
+			return nil, err
+		}
+
+		parsedConfigsInfo := parseServiceAnnotationsForEndpoints(services)
+		for _, config := range parsedConfigsInfo {
+			kep, err := listers.endpointsLister.Endpoints(config.namespace).Get(config.name)
+			if err != nil {
+				log.Errorf("Cannot get Kubernetes endpoints: %s", err)
+				continue
+			}
+			generatedConfigs = append(generatedConfigs, generateConfigs(config.tpl, kep)...)
 		}
-		generatedConfigs = append(generatedConfigs, generateConfigs(config.tpl, kep)...)
 	}
+	k.upToDate = true
 	return generatedConfigs, nil
 }
 
@@ -30,7 +30,7 @@ const (
 
 // KubeServiceConfigProvider implements the ConfigProvider interface for the apiserver.
 type KubeServiceConfigProvider struct {
-	lister   listersv1.ServiceLister
+	listers  []listersv1.ServiceLister
 	upToDate bool
 }
 
@@ -41,21 +41,21 @@ func NewKubeServiceConfigProvider(config config.ConfigurationProviders) (ConfigP
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
 	}
-	servicesInformer := ac.InformerFactory.Core().V1().Services()
-	if servicesInformer == nil {
-		return nil, fmt.Errorf("cannot get service informer: %s", err)
-	}
 
-	p := &KubeServiceConfigProvider{
-		lister: servicesInformer.Lister(),
+	p := &KubeServiceConfigProvider{}
+	for _, factory := range ac.InformerFactoriesByNamespace() {
+		servicesInformer := factory.Core().V1().Services()
+		if servicesInformer == nil {
+			return nil, fmt.Errorf("cannot get service informer: %s", err)
+		}
+		servicesInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    p.invalidate,
+			UpdateFunc: p.invalidateIfChanged,
+			DeleteFunc: p.invalidate,
+		})
+		p.listers = append(p.listers, servicesInformer.Lister())
 	}
 
-	servicesInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    p.invalidate,
-		UpdateFunc: p.invalidateIfChanged,
-		DeleteFunc: p.invalidate,
-	})
-
 	return p, nil
 }
 
@@ -66,9 +66,13 @@ func (k *KubeServiceConfigProvider) String() string {
 
 // Collect retrieves services from the apiserver, builds Config objects and returns them
 func (k *KubeServiceConfigProvider) Collect() ([]integration.Config, error) {
-	services, err := k.lister.List(labels.Everything())
-	if err != nil {
-		return nil, err
+	var services []*v1.Service
+	for _, lister := range k.listers {
+		list, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, list...)
 	}
 	k.upToDate = true
 
@@ -12,17 +12,18 @@ import (
 
 // User-facing names for the config providers
 const (
-	Consul          = "consul"
-	ClusterChecks   = "cluster-checks"
-	Docker          = "docker"
-	ECS             = "ecs"
-	EndpointsChecks = "endpoints-checks"
-	Etcd            = "etcd"
-	File            = "file"
-	Kubernetes      = "kubernetes"
-	KubeServices    = "kubernetes-services"
-	KubeEndpoints   = "kubernetes-endpoints"
-	Zookeeper       = "zookeeper"
+	Consul            = "consul"
+	ClusterChecks     = "cluster-checks"
+	Docker            = "docker"
+	ECS               = "ecs"
+	EndpointsChecks   = "endpoints-checks"
+	Etcd              = "etcd"
+	File              = "file"
+	Kubernetes        = "kubernetes"
+	KubeServices      = "kubernetes-services"
+	KubeEndpoints     = "kubernetes-endpoints"
+	KubeDatadogChecks = "kubernetes-datadogchecks"
+	Zookeeper         = "zookeeper"
 )
 
 // KubeEndpointsProviderName defines the kube endpoints provider name
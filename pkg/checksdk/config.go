@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package checksdk
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// configCheckPath is the agent API endpoint listing the check configurations
+const configCheckPath = "/agent/config-check"
+
+// ConfigClient is a ConfigProvider fetching the check configurations from the
+// API of the local agent. The agent API uses a self-signed certificate and
+// authenticates its clients with the token stored in the `auth_token` file
+// next to `datadog.yaml`.
+type ConfigClient struct {
+	url       string
+	authToken string
+	client    *http.Client
+}
+
+// configCheckResponse is the subset of the agent API response used by the client
+type configCheckResponse struct {
+	Configs []struct {
+		Name       string   `json:"check_name"`
+		Instances  [][]byte `json:"instances"`
+		InitConfig []byte   `json:"init_config"`
+		Provider   string   `json:"provider"`
+		Source     string   `json:"source"`
+	} `json:"configs"`
+}
+
+// NewConfigClient returns a client for the agent API listening on host:port,
+// the `cmd_host` and `cmd_port` settings of the agent.
+func NewConfigClient(host string, port int, authToken string) *ConfigClient {
+	return &ConfigClient{
+		url:       fmt.Sprintf("https://%s:%d%s", host, port, configCheckPath),
+		authToken: strings.TrimSpace(authToken),
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				// the agent API is only reachable locally with a self-signed certificate
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+// GetCheckConfigs returns the configurations of the check named checkName
+// scheduled by the agent, all of them if checkName is empty.
+func (c *ConfigClient) GetCheckConfigs(checkName string) ([]CheckConfig, error) {
+	req, err := http.NewRequest("GET", c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.authToken)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach the agent API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from the agent API: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var response configCheckResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("could not parse the agent API response: %v", err)
+	}
+
+	configs := []CheckConfig{}
+	for _, config := range response.Configs {
+		if checkName != "" && config.Name != checkName {
+			continue
+		}
+		configs = append(configs, CheckConfig{
+			Name:       config.Name,
+			InitConfig: config.InitConfig,
+			Instances:  config.Instances,
+			Provider:   config.Provider,
+			Source:     config.Source,
+		})
+	}
+	return configs, nil
+}
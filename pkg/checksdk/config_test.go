@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package checksdk
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configs are base64 encoded in the agent API responses
+const testConfigCheckResponse = `{
+	"configs": [
+		{"check_name": "redisdb", "instances": ["aG9zdDogbG9jYWxob3N0"], "init_config": "e30=", "provider": "file", "source": "file:/etc/redisdb.yaml"},
+		{"check_name": "nginx", "instances": [], "init_config": null, "provider": "docker", "source": "docker:abc"}
+	]
+}`
+
+func newTestConfigClient(t *testing.T, handler http.HandlerFunc) (*ConfigClient, func()) {
+	server := httptest.NewTLSServer(handler)
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return NewConfigClient(host, p, "token\n"), server.Close
+}
+
+func TestConfigClientGetCheckConfigs(t *testing.T) {
+	client, cleanup := newTestConfigClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, configCheckPath, r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.Write([]byte(testConfigCheckResponse))
+	})
+	defer cleanup()
+
+	configs, err := client.GetCheckConfigs("redisdb")
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "redisdb", configs[0].Name)
+	assert.Equal(t, [][]byte{[]byte("host: localhost")}, configs[0].Instances)
+	assert.Equal(t, []byte("{}"), configs[0].InitConfig)
+	assert.Equal(t, "file", configs[0].Provider)
+	assert.Equal(t, "file:/etc/redisdb.yaml", configs[0].Source)
+
+	configs, err = client.GetCheckConfigs("")
+	require.NoError(t, err)
+	assert.Len(t, configs, 2)
+}
+
+func TestConfigClientUnauthorized(t *testing.T) {
+	client, cleanup := newTestConfigClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid session token", http.StatusUnauthorized)
+	})
+	defer cleanup()
+
+	_, err := client.GetCheckConfigs("redisdb")
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+/*
+Package checksdk provides the building blocks of external check runners:
+out-of-process collectors that fetch their configuration from the local agent
+and submit their data through it, so that it gets the agent hostname, tags,
+retries and proxy settings like the data of the builtin checks.
+
+	sender, err := checksdk.NewDogStatsDSender("localhost:8125")
+	if err != nil {
+		return err
+	}
+	defer sender.Close()
+
+	client := checksdk.NewConfigClient("localhost", 5001, authToken)
+	configs, err := client.GetCheckConfigs("my_check")
+
+	sender.Gauge("my_check.value", 1, "", []string{"env:prod"})
+	sender.ServiceCheck("my_check.can_connect", checksdk.ServiceCheckOK, "", nil, "")
+	sender.Commit()
+
+The package only depends on the standard library and never imports other
+packages of the agent, so that it can be vendored on its own.
+
+The API of the package follows semantic versioning, its version is exposed by
+the Version constant: breaking changes of the exported identifiers bump the
+major version, new features the minor version. The internal packages of the
+agent don't provide any of these guarantees and must not be used by external
+check runners.
+*/
+package checksdk
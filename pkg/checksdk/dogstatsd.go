@@ -0,0 +1,186 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package checksdk
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// unixPrefix is the prefix of the addresses of unix sockets
+	unixPrefix = "unix://"
+
+	// maximum size of the datagrams, the UDP one avoids fragmentation
+	udpMaxPacketSize  = 1432
+	unixMaxPacketSize = 8192
+)
+
+// DogStatsDSender is a Sender submitting the data to the DogStatsD server of
+// the local agent. The data is buffered until Commit is called and sent in as
+// few datagrams as possible.
+type DogStatsDSender struct {
+	m             sync.Mutex
+	conn          net.Conn
+	maxPacketSize int
+	buffer        [][]byte
+}
+
+// NewDogStatsDSender returns a sender for the DogStatsD server listening on
+// address, either `host:port` for UDP or `unix:///path/to/socket` for UDS.
+func NewDogStatsDSender(address string) (*DogStatsDSender, error) {
+	var conn net.Conn
+	var err error
+	maxPacketSize := udpMaxPacketSize
+	if strings.HasPrefix(address, unixPrefix) {
+		conn, err = net.Dial("unixgram", strings.TrimPrefix(address, unixPrefix))
+		maxPacketSize = unixMaxPacketSize
+	} else {
+		conn, err = net.Dial("udp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to dogstatsd on %s: %v", address, err)
+	}
+	return &DogStatsDSender{
+		conn:          conn,
+		maxPacketSize: maxPacketSize,
+	}, nil
+}
+
+// Gauge submits a gauge
+func (s *DogStatsDSender) Gauge(metric string, value float64, hostname string, tags []string) {
+	s.addMetric(metric, value, "g", hostname, tags)
+}
+
+// Count submits a count
+func (s *DogStatsDSender) Count(metric string, value float64, hostname string, tags []string) {
+	s.addMetric(metric, value, "c", hostname, tags)
+}
+
+// Histogram submits a histogram
+func (s *DogStatsDSender) Histogram(metric string, value float64, hostname string, tags []string) {
+	s.addMetric(metric, value, "h", hostname, tags)
+}
+
+// Distribution submits a distribution
+func (s *DogStatsDSender) Distribution(metric string, value float64, hostname string, tags []string) {
+	s.addMetric(metric, value, "d", hostname, tags)
+}
+
+// ServiceCheck submits a service check
+func (s *DogStatsDSender) ServiceCheck(checkName string, status ServiceCheckStatus, hostname string, tags []string, message string) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "_sc|%s|%d", checkName, status)
+	if hostname != "" {
+		b.WriteString("|h:" + hostname)
+	}
+	writeTags(&b, tags)
+	if message != "" {
+		// the message must be the last field
+		b.WriteString("|m:" + escape(message))
+	}
+	s.add(b.Bytes())
+}
+
+// Event submits an event
+func (s *DogStatsDSender) Event(e Event) {
+	title := escape(e.Title)
+	text := escape(e.Text)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "_e{%d,%d}:%s|%s", len(title), len(text), title, text)
+	if e.Ts != 0 {
+		b.WriteString("|d:" + strconv.FormatInt(e.Ts, 10))
+	}
+	if e.Priority != "" {
+		b.WriteString("|p:" + e.Priority)
+	}
+	if e.Host != "" {
+		b.WriteString("|h:" + e.Host)
+	}
+	if e.AlertType != "" {
+		b.WriteString("|t:" + e.AlertType)
+	}
+	if e.AggregationKey != "" {
+		b.WriteString("|k:" + e.AggregationKey)
+	}
+	if e.SourceTypeName != "" {
+		b.WriteString("|s:" + e.SourceTypeName)
+	}
+	writeTags(&b, e.Tags)
+	s.add(b.Bytes())
+}
+
+// Commit sends the buffered data
+func (s *DogStatsDSender) Commit() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var packet []byte
+	var err error
+	for _, line := range s.buffer {
+		if len(packet) > 0 && len(packet)+1+len(line) > s.maxPacketSize {
+			if _, werr := s.conn.Write(packet); werr != nil {
+				err = werr
+			}
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+	if len(packet) > 0 {
+		if _, werr := s.conn.Write(packet); werr != nil {
+			err = werr
+		}
+	}
+	s.buffer = nil
+	return err
+}
+
+// Close closes the connection to DogStatsD, the data not committed is lost
+func (s *DogStatsDSender) Close() error {
+	return s.conn.Close()
+}
+
+func (s *DogStatsDSender) addMetric(metric string, value float64, metricType string, hostname string, tags []string) {
+	var b bytes.Buffer
+	b.WriteString(metric)
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	b.WriteByte('|')
+	b.WriteString(metricType)
+	if hostname != "" {
+		// DogStatsD metrics don't have a hostname field, it's read from the tags
+		tags = append(tags[:len(tags):len(tags)], "host:"+hostname)
+	}
+	writeTags(&b, tags)
+	s.add(b.Bytes())
+}
+
+func (s *DogStatsDSender) add(line []byte) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.buffer = append(s.buffer, line)
+}
+
+func writeTags(b *bytes.Buffer, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	b.WriteString("|#")
+	b.WriteString(strings.Join(tags, ","))
+}
+
+// escape escapes the new lines, DogStatsD uses them to separate the messages
+func escape(s string) string {
+	return strings.Replace(s, "\n", "\\n", -1)
+}
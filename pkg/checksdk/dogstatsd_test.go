@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package checksdk
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSender(t *testing.T) (Sender, net.PacketConn) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	sender, err := NewDogStatsDSender(conn.LocalAddr().String())
+	require.NoError(t, err)
+	return sender, conn
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) []string {
+	buf := make([]byte, unixMaxPacketSize)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	return strings.Split(string(buf[:n]), "\n")
+}
+
+func TestDogStatsDSenderMetrics(t *testing.T) {
+	sender, conn := newTestSender(t)
+	defer conn.Close()
+
+	sender.Gauge("my.gauge", 1.5, "", []string{"foo:bar"})
+	sender.Count("my.count", 2, "myhost", []string{"foo:bar"})
+	sender.Histogram("my.histogram", 3, "", nil)
+	sender.Distribution("my.distribution", 4, "", nil)
+	require.NoError(t, sender.Commit())
+
+	assert.Equal(t, []string{
+		"my.gauge:1.5|g|#foo:bar",
+		"my.count:2|c|#foo:bar,host:myhost",
+		"my.histogram:3|h",
+		"my.distribution:4|d",
+	}, readPacket(t, conn))
+}
+
+func TestDogStatsDSenderServiceCheckAndEvent(t *testing.T) {
+	sender, conn := newTestSender(t)
+	defer conn.Close()
+
+	sender.ServiceCheck("my.check", ServiceCheckCritical, "myhost", []string{"foo:bar"}, "line1\nline2")
+	sender.Event(Event{
+		Title:     "title",
+		Text:      "some\ntext",
+		Ts:        12345,
+		AlertType: EventAlertTypeError,
+		Tags:      []string{"foo:bar"},
+	})
+	require.NoError(t, sender.Commit())
+
+	assert.Equal(t, []string{
+		"_sc|my.check|2|h:myhost|#foo:bar|m:line1\\nline2",
+		"_e{5,10}:title|some\\ntext|d:12345|t:error|#foo:bar",
+	}, readPacket(t, conn))
+}
+
+func TestDogStatsDSenderSplitsPackets(t *testing.T) {
+	sender, conn := newTestSender(t)
+	defer conn.Close()
+
+	name := strings.Repeat("a", 1000)
+	sender.Gauge(name, 1, "", nil)
+	sender.Gauge(name, 2, "", nil)
+	require.NoError(t, sender.Commit())
+
+	assert.Equal(t, []string{name + ":1|g"}, readPacket(t, conn))
+	assert.Equal(t, []string{name + ":2|g"}, readPacket(t, conn))
+}
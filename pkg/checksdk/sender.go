@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package checksdk
+
+// ServiceCheckStatus represents the status of a service check
+type ServiceCheckStatus int
+
+// Enumeration of the service check statuses, they match the agent ones
+const (
+	ServiceCheckOK       ServiceCheckStatus = 0
+	ServiceCheckWarning  ServiceCheckStatus = 1
+	ServiceCheckCritical ServiceCheckStatus = 2
+	ServiceCheckUnknown  ServiceCheckStatus = 3
+)
+
+// Event priorities
+const (
+	EventPriorityNormal = "normal"
+	EventPriorityLow    = "low"
+)
+
+// Event alert types
+const (
+	EventAlertTypeError   = "error"
+	EventAlertTypeWarning = "warning"
+	EventAlertTypeInfo    = "info"
+	EventAlertTypeSuccess = "success"
+)
+
+// Event holds an event
+type Event struct {
+	Title          string
+	Text           string
+	Ts             int64
+	Priority       string
+	Host           string
+	Tags           []string
+	AlertType      string
+	AggregationKey string
+	SourceTypeName string
+}
+
+// Sender submits the data of a check, its methods mirror the sender of the
+// builtin checks. An empty hostname lets the agent use its own hostname.
+type Sender interface {
+	Gauge(metric string, value float64, hostname string, tags []string)
+	Count(metric string, value float64, hostname string, tags []string)
+	Histogram(metric string, value float64, hostname string, tags []string)
+	Distribution(metric string, value float64, hostname string, tags []string)
+	ServiceCheck(checkName string, status ServiceCheckStatus, hostname string, tags []string, message string)
+	Event(e Event)
+	// Commit sends the data submitted since the last commit, it must be called
+	// at the end of every check run.
+	Commit() error
+}
+
+// ConfigProvider fetches the configurations of the checks scheduled by the
+// agent, including the ones resolved by autodiscovery.
+type ConfigProvider interface {
+	GetCheckConfigs(checkName string) ([]CheckConfig, error)
+}
+
+// CheckConfig is the configuration of a check
+type CheckConfig struct {
+	Name       string
+	InitConfig []byte
+	// Instances holds the YAML configurations of the instances
+	Instances [][]byte
+	// Provider is the config provider the configuration comes from
+	Provider string
+	// Source identifies the configuration, e.g. its file or the container
+	Source string
+}
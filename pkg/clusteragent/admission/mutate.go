@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"fmt"
+
+	"k8s.io/api/core/v1"
+)
+
+// Labels of the pods, or of their namespace, holding the standard tags
+const (
+	envLabel     = "tags.datadoghq.com/env"
+	serviceLabel = "tags.datadoghq.com/service"
+	versionLabel = "tags.datadoghq.com/version"
+)
+
+// Environment variables injected in the containers
+const (
+	envVar      = "DD_ENV"
+	serviceVar  = "DD_SERVICE"
+	versionVar  = "DD_VERSION"
+	entityIDVar = "DD_ENTITY_ID"
+)
+
+// standardTags maps the standard tags environment variables to their labels
+var standardTags = []struct {
+	envVar string
+	label  string
+}{
+	{envVar, envLabel},
+	{serviceVar, serviceLabel},
+	{versionVar, versionLabel},
+}
+
+// jsonPatchOperation is an operation of a JSON patch, see RFC 6902
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// injectTags returns the JSON patch adding the standard tags and entity ID
+// environment variables to the containers of the pod. The standard tags are
+// read from the labels of the pod, then from the ones of its namespace.
+// The variables already defined by a container are left untouched.
+func injectTags(pod *v1.Pod, namespaceLabels map[string]string) []jsonPatchOperation {
+	var injected []v1.EnvVar
+	for _, tag := range standardTags {
+		value, found := pod.Labels[tag.label]
+		if !found {
+			value, found = namespaceLabels[tag.label]
+		}
+		if found && value != "" {
+			injected = append(injected, v1.EnvVar{Name: tag.envVar, Value: value})
+		}
+	}
+	injected = append(injected, v1.EnvVar{
+		Name: entityIDVar,
+		ValueFrom: &v1.EnvVarSource{
+			FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.uid"},
+		},
+	})
+
+	var patch []jsonPatchOperation
+	patch = append(patch, injectEnv("/spec/initContainers", pod.Spec.InitContainers, injected)...)
+	patch = append(patch, injectEnv("/spec/containers", pod.Spec.Containers, injected)...)
+	return patch
+}
+
+// injectEnv returns the operations replacing the environment of the containers
+// missing some of the injected variables
+func injectEnv(path string, containers []v1.Container, injected []v1.EnvVar) []jsonPatchOperation {
+	var patch []jsonPatchOperation
+	for i, container := range containers {
+		env := append([]v1.EnvVar{}, container.Env...)
+		for _, envVar := range injected {
+			if !containsEnvVar(env, envVar.Name) {
+				env = append(env, envVar)
+			}
+		}
+		if len(env) == len(container.Env) {
+			continue
+		}
+		// adding an existing member replaces it
+		patch = append(patch, jsonPatchOperation{
+			Op:    "add",
+			Path:  fmt.Sprintf("%s/%d/env", path, i),
+			Value: env,
+		})
+	}
+	return patch
+}
+
+func containsEnvVar(env []v1.EnvVar, name string) bool {
+	for _, envVar := range env {
+		if envVar.Name == name {
+			return true
+		}
+	}
+	return false
+}
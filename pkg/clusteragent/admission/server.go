@@ -0,0 +1,187 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// InjectTagsPath is the path of the webhook injecting the standard tags, to
+// reference in the MutatingWebhookConfiguration
+const InjectTagsPath = "/inject-tags"
+
+var mutatedPods = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Subsystem: "admission_webhooks",
+		Name:      "mutated_pods_total",
+		Help:      "Number of pods the standard tags were injected into, dry_run telling whether the patch was discarded.",
+	},
+	[]string{"dry_run"},
+)
+
+func init() {
+	prometheus.MustRegister(mutatedPods)
+}
+
+// Server is the mutating admission webhook server of the cluster agent, it
+// injects the standard tags environment variables into the pods created in
+// the cluster.
+type Server struct {
+	namespaceLister   corelisters.NamespaceLister
+	namespacesSynced  cache.InformerSynced
+	podSelector       labels.Selector
+	namespaceSelector labels.Selector
+	// dryRun logs the patches instead of applying them
+	dryRun bool
+}
+
+// NewServer returns a Server configured with the `admission_controller`
+// settings, the namespaces are listed to read their labels
+func NewServer(namespaceLister corelisters.NamespaceLister, namespacesSynced cache.InformerSynced) (*Server, error) {
+	podSelector, err := labels.Parse(config.Datadog.GetString("admission_controller.pod_selector"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid admission_controller.pod_selector: %v", err)
+	}
+	namespaceSelector, err := labels.Parse(config.Datadog.GetString("admission_controller.namespace_selector"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid admission_controller.namespace_selector: %v", err)
+	}
+	return &Server{
+		namespaceLister:   namespaceLister,
+		namespacesSynced:  namespacesSynced,
+		podSelector:       podSelector,
+		namespaceSelector: namespaceSelector,
+		dryRun:            config.Datadog.GetBool("admission_controller.dry_run"),
+	}, nil
+}
+
+// Run serves the webhooks over TLS on `admission_controller.port` until
+// stopCh is closed
+func (s *Server) Run(stopCh <-chan struct{}) {
+	if !cache.WaitForCacheSync(stopCh, s.namespacesSynced) {
+		log.Error("Admission controller: namespaces cache not synced, not serving the webhooks")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(InjectTagsPath, s.serveInjectTags)
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", config.Datadog.GetInt("admission_controller.port")),
+		Handler: mux,
+	}
+	go func() {
+		<-stopCh
+		srv.Close()
+	}()
+
+	log.Infof("Admission controller: serving the webhooks on %s (dry run: %v)", srv.Addr, s.dryRun)
+	err := srv.ListenAndServeTLS(
+		config.Datadog.GetString("admission_controller.tls_cert_file"),
+		config.Datadog.GetString("admission_controller.tls_key_file"),
+	)
+	if err != nil && err != http.ErrServerClosed {
+		log.Errorf("Admission controller: cannot serve the webhooks: %v", err)
+	}
+}
+
+func (s *Server) serveInjectTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("invalid admission review: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	review.Response = s.mutate(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	response, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
+
+// mutate returns the admission response of the pod creation request. The
+// pods are always admitted, their creation must not fail because of the agent.
+func (s *Server) mutate(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	response := &admissionv1beta1.AdmissionResponse{Allowed: true}
+
+	pod := &v1.Pod{}
+	if err := json.Unmarshal(req.Object.Raw, pod); err != nil {
+		log.Warnf("Admission controller: cannot decode the pod of %s: %v", req.Namespace, err)
+		response.Result = &metav1.Status{Message: err.Error()}
+		return response
+	}
+	// generated pod names are not set yet
+	podName := pod.Name
+	if podName == "" {
+		podName = pod.GenerateName
+	}
+
+	var namespaceLabels map[string]string
+	ns, err := s.namespaceLister.Get(req.Namespace)
+	if err == nil {
+		namespaceLabels = ns.Labels
+	} else if !errors.IsNotFound(err) {
+		log.Debugf("Admission controller: cannot get the namespace %s: %v", req.Namespace, err)
+	}
+
+	if !s.podSelector.Matches(labels.Set(pod.Labels)) || !s.namespaceSelector.Matches(labels.Set(namespaceLabels)) {
+		log.Tracef("Admission controller: pod %s/%s not selected", req.Namespace, podName)
+		return response
+	}
+
+	patch := injectTags(pod, namespaceLabels)
+	if len(patch) == 0 {
+		return response
+	}
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		log.Warnf("Admission controller: cannot encode the patch of the pod %s/%s: %v", req.Namespace, podName, err)
+		return response
+	}
+
+	mutatedPods.WithLabelValues(fmt.Sprint(s.dryRun)).Inc()
+	if s.dryRun {
+		log.Infof("Admission controller: dry run, would patch the pod %s/%s with %s", req.Namespace, podName, raw)
+		return response
+	}
+	log.Debugf("Admission controller: patching the pod %s/%s with %s", req.Namespace, podName, raw)
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	response.Patch = raw
+	response.PatchType = &patchType
+	return response
+}
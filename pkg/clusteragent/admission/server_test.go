@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestServer(t *testing.T, podSelector string, dryRun bool, namespaces ...*v1.Namespace) *Server {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range namespaces {
+		require.NoError(t, indexer.Add(ns))
+	}
+	selector, err := labels.Parse(podSelector)
+	require.NoError(t, err)
+	return &Server{
+		namespaceLister:   corelisters.NewNamespaceLister(indexer),
+		namespacesSynced:  func() bool { return true },
+		podSelector:       selector,
+		namespaceSelector: labels.Everything(),
+		dryRun:            dryRun,
+	}
+}
+
+func newTestRequest(t *testing.T, pod *v1.Pod) *admissionv1beta1.AdmissionRequest {
+	raw, err := json.Marshal(pod)
+	require.NoError(t, err)
+	return &admissionv1beta1.AdmissionRequest{
+		UID:       "uid",
+		Namespace: "default",
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+func TestInjectTags(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{serviceLabel: "web", versionLabel: "1.2"},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "app", Env: []v1.EnvVar{{Name: "FOO", Value: "bar"}}},
+				{Name: "sidecar", Env: []v1.EnvVar{
+					{Name: envVar, Value: "dev"},
+					{Name: serviceVar, Value: "proxy"},
+					{Name: versionVar, Value: "0.1"},
+					{Name: entityIDVar, Value: "id"},
+				}},
+			},
+			InitContainers: []v1.Container{{Name: "init"}},
+		},
+	}
+
+	patch := injectTags(pod, map[string]string{envLabel: "prod", serviceLabel: "ignored"})
+	require.Len(t, patch, 2)
+
+	assert.Equal(t, "/spec/initContainers/0/env", patch[0].Path)
+	assert.Equal(t, "/spec/containers/0/env", patch[1].Path)
+	env := patch[1].Value.([]v1.EnvVar)
+	require.Len(t, env, 5)
+	assert.Equal(t, v1.EnvVar{Name: "FOO", Value: "bar"}, env[0])
+	assert.Equal(t, v1.EnvVar{Name: envVar, Value: "prod"}, env[1])
+	assert.Equal(t, v1.EnvVar{Name: serviceVar, Value: "web"}, env[2])
+	assert.Equal(t, v1.EnvVar{Name: versionVar, Value: "1.2"}, env[3])
+	assert.Equal(t, "metadata.uid", env[4].ValueFrom.FieldRef.FieldPath)
+
+	// the pod is left untouched
+	assert.Len(t, pod.Spec.Containers[0].Env, 1)
+}
+
+func TestMutate(t *testing.T) {
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{envLabel: "prod"}}}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "web-", Labels: map[string]string{"app": "web"}},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+	}
+
+	response := newTestServer(t, "app=web", false, ns).mutate(newTestRequest(t, pod))
+	assert.True(t, response.Allowed)
+	require.NotNil(t, response.PatchType)
+	assert.Equal(t, admissionv1beta1.PatchTypeJSONPatch, *response.PatchType)
+	assert.Contains(t, string(response.Patch), `{"name":"DD_ENV","value":"prod"}`)
+
+	// dry run
+	response = newTestServer(t, "app=web", true, ns).mutate(newTestRequest(t, pod))
+	assert.True(t, response.Allowed)
+	assert.Nil(t, response.Patch)
+
+	// pod not selected
+	response = newTestServer(t, "app=db", false, ns).mutate(newTestRequest(t, pod))
+	assert.True(t, response.Allowed)
+	assert.Nil(t, response.Patch)
+}
+
+func TestServeInjectTags(t *testing.T) {
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}}}}
+	body, err := json.Marshal(admissionv1beta1.AdmissionReview{Request: newTestRequest(t, pod)})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	newTestServer(t, "", false).serveInjectTags(rec, httptest.NewRequest(http.MethodPost, InjectTagsPath, bytes.NewBuffer(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	review := admissionv1beta1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &review))
+	assert.Nil(t, review.Request)
+	require.NotNil(t, review.Response)
+	assert.EqualValues(t, "uid", review.Response.UID)
+	assert.True(t, review.Response.Allowed)
+	assert.Contains(t, string(review.Response.Patch), entityIDVar)
+
+	rec = httptest.NewRecorder()
+	newTestServer(t, "", false).serveInjectTags(rec, httptest.NewRequest(http.MethodPost, InjectTagsPath, bytes.NewBufferString("{}")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,22 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package v1
+
+// BlocklistRule excludes the workloads of a namespace, or matching a label
+// selector, from the logs collection and/or the container metrics of the
+// node agents. An empty namespace matches all the namespaces, an empty
+// selector all the pods of the namespace.
+type BlocklistRule struct {
+	Namespace     string `json:"namespace,omitempty" mapstructure:"namespace"`
+	LabelSelector string `json:"label_selector,omitempty" mapstructure:"label_selector"`
+	Logs          bool   `json:"logs" mapstructure:"logs"`
+	Metrics       bool   `json:"metrics" mapstructure:"metrics"`
+}
+
+// BlocklistResponse is the response of the workload blocklist endpoint
+type BlocklistResponse struct {
+	Rules []BlocklistRule `json:"rules"`
+}
@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package v1
+
+// KubeService identifies the service of a ClusterIP
+type KubeService struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// ServicesByClusterIPResponse is the response of the endpoint mapping the
+// ClusterIPs to their services
+type ServicesByClusterIPResponse struct {
+	Services map[string]KubeService `json:"services"`
+}
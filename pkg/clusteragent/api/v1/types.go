@@ -106,17 +106,33 @@ func (m NamespacesPodsStringsSet) Delete(namespace string, strings ...string) {
 	}
 }
 
+// DeletePod deletes the strings of a pod.
+func (m NamespacesPodsStringsSet) DeletePod(namespace, podName string) {
+	if _, ok := m[namespace]; !ok {
+		// Nothing to delete.
+		return
+	}
+	delete(m[namespace], podName)
+	if len(m[namespace]) == 0 {
+		delete(m, namespace)
+	}
+}
+
 // MetadataResponseBundle maps pod names to associated metadata.
 type MetadataResponseBundle struct {
 	// Services maps pod names to the names of the services targeting the pod.
 	// keyed by the namespace a pod belongs to.
 	Services NamespacesPodsStringsSet `json:"services,omitempty"`
+	// Pods maps pod names to the tags of the pod itself, like its owners,
+	// keyed by the namespace a pod belongs to.
+	Pods NamespacesPodsStringsSet `json:"pods,omitempty"`
 }
 
 // NewMetadataResponseBundle returns new MetadataResponseBundle initialized instance
 func NewMetadataResponseBundle() *MetadataResponseBundle {
 	return &MetadataResponseBundle{
 		Services: NewNamespacesPodsStringsSet(),
+		Pods:     NewNamespacesPodsStringsSet(),
 	}
 }
 
@@ -128,6 +144,22 @@ type MetadataResponse struct {
 	// TODO: Since it is Errors, it should be []string and not string
 }
 
+// PodMetadataByIPResponse is the metadata of the pod with a given IP, used to
+// encode /api/v1/pods/by-ip payloads
+type PodMetadataByIPResponse struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	UID       string   `json:"uid"`
+	NodeName  string   `json:"node_name"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// DiscoveryRefreshResponse is the response of the discovery cache refresh endpoint
+type DiscoveryRefreshResponse struct {
+	// Invalidated is the number of discovery caches dropped, one per cluster
+	Invalidated int `json:"invalidated"`
+}
+
 // NewMetadataResponse returns new NewMetadataResponse initialized instance
 func NewMetadataResponse() *MetadataResponse {
 	return &MetadataResponse{
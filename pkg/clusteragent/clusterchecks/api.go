@@ -8,6 +8,7 @@
 package clusterchecks
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -88,3 +89,14 @@ func (h *Handler) GetAllEndpointsCheckConfigs() (types.ConfigResponse, error) {
 	}
 	return response, err
 }
+
+// Rebalance collects the stats of the cluster check runners and moves the
+// checks from the busiest runners to the least busy ones, it returns the
+// checks moved
+func (h *Handler) Rebalance() ([]types.RebalanceResponse, error) {
+	if !h.dispatcher.advancedDispatching {
+		return nil, errors.New("advanced dispatching is not enabled, the cluster checks can't be rebalanced")
+	}
+	h.dispatcher.updateRunnersStats()
+	return h.dispatcher.rebalance(), nil
+}
@@ -10,6 +10,7 @@ package clusterchecks
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
@@ -31,6 +32,8 @@ type dispatcher struct {
 	extraTags             []string
 	clcRunnersClient      clusteragent.CLCRunnerClientInterface
 	advancedDispatching   bool
+	// rebalanceMutex serializes the periodic and the manual rebalancing
+	rebalanceMutex sync.Mutex
 }
 
 func newDispatcher() *dispatcher {
@@ -67,6 +70,11 @@ func (d *dispatcher) Stop() {
 
 // Schedule implements the scheduler.Scheduler interface
 func (d *dispatcher) Schedule(configs []integration.Config) {
+	start := time.Now()
+	defer func() {
+		reconcileDuration.WithLabelValues("schedule").Observe(time.Since(start).Seconds())
+	}()
+
 	for _, c := range configs {
 		if !c.ClusterCheck {
 			continue // Ignore non cluster-check configs
@@ -92,6 +100,11 @@ func (d *dispatcher) Schedule(configs []integration.Config) {
 
 // Unschedule implements the scheduler.Scheduler interface
 func (d *dispatcher) Unschedule(configs []integration.Config) {
+	start := time.Now()
+	defer func() {
+		reconcileDuration.WithLabelValues("unschedule").Observe(time.Since(start).Seconds())
+	}()
+
 	for _, c := range configs {
 		if !c.ClusterCheck {
 			continue // Ignore non cluster-check configs
@@ -175,6 +188,8 @@ func (d *dispatcher) run(ctx context.Context) {
 		case <-healthProbe.C:
 			// This goroutine might hang if the store is deadlocked during a cleanup
 		case <-cleanupTicker.C:
+			start := time.Now()
+
 			// Expire old nodes, orphaned configs are moved to dangling
 			d.expireNodes()
 
@@ -183,6 +198,8 @@ func (d *dispatcher) run(ctx context.Context) {
 				danglingConfs := d.retrieveAndClearDangling()
 				d.reschedule(danglingConfs)
 			}
+
+			reconcileDuration.WithLabelValues("cleanup").Observe(time.Since(start).Seconds())
 		case <-runnerStatsTicker.C:
 			// Collect stats with an exponential backoff 2 - 5 - 10 minutes
 			if runnerStatsMinutes == firstRunnerStatsMinutes {
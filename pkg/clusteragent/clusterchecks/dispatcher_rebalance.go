@@ -12,6 +12,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -34,8 +35,8 @@ func (d *dispatcher) calculateAvg() (int, error) {
 	defer d.store.RUnlock()
 
 	for _, node := range d.store.nodes {
-		busyness = node.GetBusyness(busynessFunc)
-		length += 1
+		busyness += node.GetBusyness(busynessFunc)
+		length++
 	}
 
 	if length == 0 {
@@ -157,17 +158,24 @@ func (d *dispatcher) moveCheck(src, dest, checkID string) error {
 
 // rebalance tries to optimize the checks repartition on cluster level check
 // runners with less possible check moves based on the runner stats.
-func (d *dispatcher) rebalance() {
+// It returns the checks moved.
+func (d *dispatcher) rebalance() []types.RebalanceResponse {
+	d.rebalanceMutex.Lock()
+	defer d.rebalanceMutex.Unlock()
+
 	start := time.Now()
 	defer func() {
 		rebalancingDuration.Set(time.Since(start).Seconds())
+		d.updateBusynessMetrics()
 	}()
 
+	var checksMoved []types.RebalanceResponse
+
 	log.Trace("Trying to rebalance cluster checks distribution if needed")
 	totalAvg, err := d.calculateAvg()
 	if err != nil {
 		log.Debugf("Cannot rebalance checks: %v", err)
-		return
+		return checksMoved
 	}
 	diffMap, weights := d.getDiffAndWeights(totalAvg)
 	sort.Sort(weights)
@@ -177,8 +185,8 @@ func (d *dispatcher) rebalance() {
 			sourceNodeName := nodeWeight.nodeName
 			checkID, checkWeight, err := d.pickCheckToMove(sourceNodeName)
 			if err != nil {
-				log.Debugf("Cannot pick a destination node to receive check: %v", err)
-				continue
+				log.Debugf("Cannot pick a check to move from node %s: %v", sourceNodeName, err)
+				break
 			}
 
 			pickedNodeName := pickNode(diffMap, sourceNodeName)
@@ -195,6 +203,15 @@ func (d *dispatcher) rebalance() {
 				successfulRebalancing.Inc()
 				log.Tracef("Check %s with weight %d moved, total avg: %d, source diff: %d, dest diff: %d", checkID, checkWeight, totalAvg, diffMap[sourceNodeName], diffMap[pickedNodeName])
 
+				checksMoved = append(checksMoved, types.RebalanceResponse{
+					CheckID:        checkID,
+					CheckWeight:    checkWeight,
+					SourceNodeName: sourceNodeName,
+					SourceDiff:     diffMap[sourceNodeName],
+					DestNodeName:   pickedNodeName,
+					DestDiff:       diffMap[pickedNodeName],
+				})
+
 				// diffMap needs to be updated on every check moved
 				diffMap = d.updateDiff(totalAvg)
 			} else {
@@ -202,4 +219,31 @@ func (d *dispatcher) rebalance() {
 			}
 		}
 	}
+
+	return checksMoved
+}
+
+// updateBusynessMetrics exports the busyness of the runners and the skew of
+// the dispatching, the ratio between the busiest runner and the average
+func (d *dispatcher) updateBusynessMetrics() {
+	d.store.RLock()
+	defer d.store.RUnlock()
+
+	// the expired nodes are not reported anymore
+	runnerBusyness.Reset()
+	total, max := 0, 0
+	for nodeName, node := range d.store.nodes {
+		busyness := node.GetBusyness(busynessFunc)
+		runnerBusyness.WithLabelValues(nodeName).Set(float64(busyness))
+		total += busyness
+		if busyness > max {
+			max = busyness
+		}
+	}
+
+	if total == 0 {
+		dispatchSkew.Set(1)
+		return
+	}
+	dispatchSkew.Set(float64(max*len(d.store.nodes)) / float64(total))
 }
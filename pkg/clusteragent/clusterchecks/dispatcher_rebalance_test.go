@@ -1059,3 +1059,38 @@ func TestMoveCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestRebalanceReturnsMoves(t *testing.T) {
+	dispatcher := newDispatcher()
+	dispatcher.store.active = true
+	dispatcher.store.nodes["busyNode"] = newNodeStore("busyNode", "")
+	dispatcher.store.nodes["idleNode"] = newNodeStore("idleNode", "")
+
+	stats := types.CLCRunnersStats{}
+	for _, name := range []string{"check1", "check2"} {
+		config := integration.Config{
+			Name:       name,
+			Instances:  []integration.Data{integration.Data("")},
+			InitConfig: integration.Data(""),
+		}
+		id := check.BuildID(config.Name, config.Instances[0], config.InitConfig)
+		dispatcher.addConfig(config, "busyNode")
+		stats[string(id)] = types.CLCRunnerStats{
+			AverageExecutionTime: 100,
+			MetricSamples:        100,
+		}
+	}
+	dispatcher.store.nodes["busyNode"].clcRunnerStats = stats
+
+	moves := dispatcher.rebalance()
+	assert.Len(t, moves, 1)
+	assert.Equal(t, "busyNode", moves[0].SourceNodeName)
+	assert.Equal(t, "idleNode", moves[0].DestNodeName)
+	assert.Len(t, dispatcher.store.nodes["busyNode"].clcRunnerStats, 1)
+	assert.Len(t, dispatcher.store.nodes["idleNode"].clcRunnerStats, 1)
+
+	// the checks are balanced, nothing else should move
+	assert.Len(t, dispatcher.rebalance(), 0)
+
+	requireNotLocked(t, dispatcher.store)
+}
@@ -70,6 +70,30 @@ var (
 			Help:      "Duration of collecting stats from check runners and updating cache",
 		},
 	)
+	runnerBusyness = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "cluster_checks",
+			Name:      "busyness",
+			Help:      "Busyness of the cluster check runners computed from the stats of their checks, by node.",
+		},
+		[]string{"node"},
+	)
+	dispatchSkew = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Subsystem: "cluster_checks",
+			Name:      "dispatch_skew",
+			Help:      "Ratio between the busyness of the busiest cluster check runner and the average busyness, 1 when the checks are evenly dispatched.",
+		},
+	)
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "cluster_checks",
+			Name:      "reconcile_duration_seconds",
+			Help:      "Duration of the dispatcher operations: schedule, unschedule and cleanup",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+		[]string{"operation"},
+	)
 
 	allMetrics = []prometheus.Collector{
 		nodeAgents,
@@ -80,6 +104,9 @@ var (
 		rebalancingDuration,
 		statsCollectionFails,
 		updateStatsDuration,
+		runnerBusyness,
+		dispatchSkew,
+		reconcileDuration,
 	}
 )
 
@@ -66,3 +66,13 @@ type CLCRunnerStats struct {
 	AverageExecutionTime int `json:"AverageExecutionTime"`
 	MetricSamples        int `json:"MetricSamples"`
 }
+
+// RebalanceResponse describes a check moved by a rebalancing
+type RebalanceResponse struct {
+	CheckID        string `json:"check_id"`
+	CheckWeight    int    `json:"check_weight"`
+	SourceNodeName string `json:"source_node_name"`
+	SourceDiff     int    `json:"source_diff"`
+	DestNodeName   string `json:"dest_node_name"`
+	DestDiff       int    `json:"dest_diff"`
+}
@@ -39,3 +39,13 @@ func BuildID(checkName string, instance, initConfig integration.Data) ID {
 func IDToCheckName(id ID) string {
 	return strings.SplitN(string(id), ":", 2)[0]
 }
+
+// IDToConfigHash returns the hash of the configuration from a check ID, or an
+// empty string for the IDs not built by BuildID
+func IDToConfigHash(id ID) string {
+	parts := strings.Split(string(id), ":")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-1]
+}
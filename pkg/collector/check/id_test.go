@@ -75,3 +75,33 @@ func TestIDToCheckName(t *testing.T) {
 		})
 	}
 }
+
+func TestIDToConfigHash(t *testing.T) {
+	testCases := []struct {
+		in  string
+		out string
+	}{
+		{
+			in:  "valid:9505c316b4e4a028",
+			out: "9505c316b4e4a028",
+		},
+		{
+			in:  "",
+			out: "",
+		},
+		{
+			in:  "nocolon",
+			out: "",
+		},
+		{
+			in:  "multiple:colon:9505c316b4e4a028",
+			out: "9505c316b4e4a028",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("case %d: %s", i, tc.in), func(t *testing.T) {
+			assert.Equal(t, tc.out, IDToConfigHash(ID(tc.in)))
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package canary
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/status/canary"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	canaryCheckName = "agent_canary"
+	// the canary metric is flushed every 15 seconds, one canary a minute
+	// leaves the time for the previous one to go through the pipeline
+	defaultMinCollectionInterval = 60
+	defaultTimeout               = 120
+
+	serviceCheckPrefix = "datadog.agent.pipeline."
+)
+
+// lastCanaryID is shared by the instances of the check, the IDs are small
+// enough to be held exactly by the value of the canary metric
+var lastCanaryID uint64
+
+// canaryInstanceConfig selects the pipelines the canaries go through
+type canaryInstanceConfig struct {
+	Metrics *bool `yaml:"metrics"`
+	Logs    *bool `yaml:"logs"`
+	Traces  *bool `yaml:"traces"`
+	// Timeout is the number of seconds after which a canary not having
+	// reached the end of its pipeline is considered lost
+	Timeout int `yaml:"timeout"`
+}
+
+// sentCanary is a canary waiting to reach the end of its pipeline
+type sentCanary struct {
+	id   string
+	sent time.Time
+}
+
+// Check injects canaries in the metrics, logs and traces pipelines of the
+// agent, and reports the health of each pipeline as a service check
+type Check struct {
+	core.CheckBase
+	metrics bool
+	logs    bool
+	traces  bool
+	timeout time.Duration
+
+	pending map[canary.Pipeline][]sentCanary
+	// sendLogsCanary and traceURL are overridden by the tests
+	sendLogsCanary func(id string) error
+	traceURL       string
+	httpClient     *http.Client
+}
+
+func (c *Check) String() string {
+	return canaryCheckName
+}
+
+func (c *canaryInstanceConfig) parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return err
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	return nil
+}
+
+func enabled(b *bool) bool {
+	return b == nil || *b
+}
+
+// Configure parses the check configuration
+func (c *Check) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	instance := canaryInstanceConfig{}
+	if err := instance.parse(data); err != nil {
+		return err
+	}
+	c.BuildID(data, initConfig)
+	if err := c.CommonConfigure(data, source); err != nil {
+		return err
+	}
+
+	c.metrics = enabled(instance.Metrics)
+	c.logs = enabled(instance.Logs) && config.Datadog.GetBool("logs_enabled")
+	c.traces = enabled(instance.Traces) && config.Datadog.GetBool("apm_config.enabled")
+	c.timeout = time.Duration(instance.Timeout) * time.Second
+
+	port := 8126
+	if config.Datadog.IsSet("apm_config.receiver_port") {
+		port = config.Datadog.GetInt("apm_config.receiver_port")
+	}
+	c.traceURL = fmt.Sprintf("http://%s:%d/v0.4/traces", config.Datadog.GetString("bind_host"), port)
+	return nil
+}
+
+// Run checks the canaries sent by the previous runs and sends new ones
+func (c *Check) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	seq := atomic.AddUint64(&lastCanaryID, 1)
+	id := strconv.FormatUint(seq, 10)
+
+	if c.metrics {
+		c.reportPending(sender, canary.Metrics, now)
+		canary.Expect(canary.Metrics, id)
+		c.pending[canary.Metrics] = append(c.pending[canary.Metrics], sentCanary{id: id, sent: now})
+		sender.Gauge(canary.MetricName, canary.MetricValue(seq), "", nil)
+	}
+
+	if c.logs {
+		c.reportPending(sender, canary.Logs, now)
+		// expected before being sent, the auditor may see it straight away
+		canary.Expect(canary.Logs, id)
+		if err := c.sendLogsCanary(id); err != nil {
+			canary.Forget(canary.Logs, id)
+			sender.ServiceCheck(serviceCheckPrefix+string(canary.Logs), metrics.ServiceCheckCritical, "", nil, fmt.Sprintf("could not send the canary log: %v", err))
+		} else {
+			c.pending[canary.Logs] = append(c.pending[canary.Logs], sentCanary{id: id, sent: now})
+		}
+	}
+
+	if c.traces {
+		if err := c.sendTraceCanary(now); err != nil {
+			sender.ServiceCheck(serviceCheckPrefix+"traces", metrics.ServiceCheckCritical, "", nil, fmt.Sprintf("could not send the canary trace: %v", err))
+		} else {
+			sender.ServiceCheck(serviceCheckPrefix+"traces", metrics.ServiceCheckOK, "", nil, "")
+		}
+	}
+
+	sender.Commit()
+	return nil
+}
+
+// reportPending sends the service check of the pipeline: OK if a canary made
+// it through since the last run, CRITICAL if one timed out, nothing while
+// the canaries are still on their way
+func (c *Check) reportPending(sender aggregator.Sender, pipeline canary.Pipeline, now time.Time) {
+	var received, lost int
+	var stillPending []sentCanary
+	for _, sc := range c.pending[pipeline] {
+		switch {
+		case canary.Received(pipeline, sc.id):
+			received++
+		case now.Sub(sc.sent) > c.timeout:
+			lost++
+		default:
+			stillPending = append(stillPending, sc)
+			continue
+		}
+		canary.Forget(pipeline, sc.id)
+	}
+	c.pending[pipeline] = stillPending
+
+	name := serviceCheckPrefix + string(pipeline)
+	switch {
+	case received > 0:
+		sender.ServiceCheck(name, metrics.ServiceCheckOK, "", nil, "")
+	case lost > 0:
+		log.Warnf("%d canaries did not go through the %s pipeline in %s", lost, pipeline, c.timeout)
+		sender.ServiceCheck(name, metrics.ServiceCheckCritical, "", nil, fmt.Sprintf("the canary did not go through the pipeline in %s", c.timeout))
+	}
+}
+
+// sendTraceCanary sends a canary trace to the receiver of the trace-agent. The
+// trace being rejected by its sampling priority, it is not kept once it went
+// through the sampler.
+func (c *Check) sendTraceCanary(now time.Time) error {
+	traceID := uint64(now.UnixNano())
+	payload := fmt.Sprintf(`[[{"trace_id":%d,"span_id":%d,"parent_id":0,"name":%q,"resource":"canary","service":"datadog-agent","start":%d,"duration":1,"metrics":{"_sampling_priority_v1":-1}}]]`,
+		traceID, traceID, canary.MetricName, now.UnixNano())
+
+	resp, err := c.httpClient.Post(c.traceURL, "application/json", bytes.NewBufferString(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func canaryFactory() check.Check {
+	return &Check{
+		CheckBase:      core.NewCheckBaseWithInterval(canaryCheckName, time.Duration(defaultMinCollectionInterval)*time.Second),
+		pending:        make(map[canary.Pipeline][]sentCanary),
+		sendLogsCanary: logs.SendCanary,
+		httpClient:     &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func init() {
+	core.RegisterCheck(canaryCheckName, canaryFactory)
+}
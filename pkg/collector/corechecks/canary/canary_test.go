@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package canary
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/status/canary"
+)
+
+func newTestCheck(t *testing.T) *Check {
+	c := canaryFactory().(*Check)
+	require.NoError(t, c.Configure([]byte("timeout: 1"), nil, "test"))
+	c.metrics = true
+	return c
+}
+
+func TestCanaryMetrics(t *testing.T) {
+	c := newTestCheck(t)
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", canary.MetricName, canary.MetricValue(lastCanaryID), "", nil)
+	sender.AssertNotCalled(t, "ServiceCheck", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	require.Len(t, c.pending[canary.Metrics], 1)
+
+	// the canary reached the forwarder
+	canary.ObserveValue(canary.Metrics, canary.MetricValue(lastCanaryID))
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "datadog.agent.pipeline.metrics", metrics.ServiceCheckOK, "", nil, "")
+
+	// the canary is lost
+	sender.ResetCalls()
+	c.pending[canary.Metrics][0].sent = time.Now().Add(-time.Minute)
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "datadog.agent.pipeline.metrics", metrics.ServiceCheckCritical, "", nil, "the canary did not go through the pipeline in 1s")
+}
+
+func TestCanaryLogs(t *testing.T) {
+	c := newTestCheck(t)
+	c.metrics = false
+	c.logs = true
+	c.sendLogsCanary = func(id string) error {
+		canary.Observe(canary.Logs, id)
+		return nil
+	}
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+
+	require.NoError(t, c.Run())
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "datadog.agent.pipeline.logs", metrics.ServiceCheckOK, "", nil, "")
+
+	sender.ResetCalls()
+	c.sendLogsCanary = func(id string) error { return errors.New("logs pipeline is full") }
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "datadog.agent.pipeline.logs", metrics.ServiceCheckCritical, "", nil, "could not send the canary log: logs pipeline is full")
+	assert.Empty(t, c.pending[canary.Logs])
+}
+
+func TestCanaryTraces(t *testing.T) {
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v0.4/traces", r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	c := newTestCheck(t)
+	c.metrics = false
+	c.traces = true
+	c.traceURL = ts.URL + "/v0.4/traces"
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "datadog.agent.pipeline.traces", metrics.ServiceCheckOK, "", nil, "")
+
+	sender.ResetCalls()
+	status = http.StatusServiceUnavailable
+	require.NoError(t, c.Run())
+	sender.AssertServiceCheck(t, "datadog.agent.pipeline.traces", metrics.ServiceCheckCritical, "", nil, "could not send the canary trace: unexpected status code 503")
+}
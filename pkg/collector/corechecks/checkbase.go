@@ -71,7 +71,7 @@ func (c *CheckBase) Configure(data integration.Data, initConfig integration.Data
 }
 
 // CommonConfigure is called when checks implement their own Configure method,
-// in order to setup common options (run interval, empty hostname)
+// in order to setup common options (run interval, empty hostname, tags, service)
 func (c *CheckBase) CommonConfigure(instance integration.Data, source string) error {
 	commonOptions := integration.CommonInstanceConfig{}
 	err := yaml.Unmarshal(instance, &commonOptions)
@@ -85,24 +85,10 @@ func (c *CheckBase) CommonConfigure(instance integration.Data, source string) er
 		c.checkInterval = time.Duration(commonOptions.MinCollectionInterval) * time.Second
 	}
 
-	// Disable default hostname if specified
-	if commonOptions.EmptyDefaultHostname {
-		s, err := aggregator.GetSender(c.checkID)
-		if err != nil {
-			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.ID()), err)
-			return err
-		}
-		s.DisableDefaultHostname(true)
-	}
-
-	// Set custom tags configured for this check
-	if len(commonOptions.Tags) > 0 {
-		s, err := aggregator.GetSender(c.checkID)
-		if err != nil {
-			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.ID()), err)
-			return err
-		}
-		s.SetCheckCustomTags(commonOptions.Tags)
+	// Apply the empty hostname, custom tags and service options
+	if err := aggregator.ConfigureCheckSender(c.checkID, instance, nil); err != nil {
+		log.Errorf("failed to configure the sender of check %s: %s", string(c.ID()), err)
+		return err
 	}
 
 	c.source = source
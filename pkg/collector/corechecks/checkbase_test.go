@@ -68,3 +68,18 @@ func TestCommonConfigureCustomID(t *testing.T) {
 	assert.Equal(t, string(mycheck.ID()), "test:foobar:bd63a7031add5db9")
 	mockSender.AssertExpectations(t)
 }
+
+func TestCommonConfigureTagsService(t *testing.T) {
+	checkName := "test"
+	mycheck := &dummyCheck{
+		CheckBase: NewCheckBase(checkName),
+	}
+	mockSender := mocksender.NewMockSender(mycheck.ID())
+
+	mockSender.On("SetCheckCustomTags", []string{"foo:bar"}).Return().Once()
+	mockSender.On("SetCheckService", "my-service").Return().Once()
+	err := mycheck.CommonConfigure([]byte("tags: [\"foo:bar\"]\nservice: my-service"), "test")
+	assert.NoError(t, err)
+	mockSender.AssertExpectations(t)
+	mockSender.AssertNumberOfCalls(t, "DisableDefaultHostname", 0)
+}
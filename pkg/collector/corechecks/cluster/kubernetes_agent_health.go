@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Covers the health the node agents publish in the health lease of their node.
+const (
+	KubeAgentHealthCheck  = "kube_apiserver_agent.up"
+	kubeAgentHealthMetric = "kubernetes_apiserver.agent.health.nodes"
+)
+
+// reportAgentHealth aggregates the health published by the agents in the
+// health lease of their node
+func (k *KubeASCheck) reportAgentHealth(sender aggregator.Sender) error {
+	healths, err := k.ac.ListNodeAgentHealth()
+	if err != nil {
+		return err
+	}
+	var nodes []v1.Node
+	err = k.ac.ListNodesPaginated(apiserver.ListSelectors{}, func(page []v1.Node) error {
+		nodes = append(nodes, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	k.parseAgentHealth(sender, nodes, healths, time.Now())
+	return nil
+}
+
+// parseAgentHealth submits a service check per node with an agent health, and
+// the count of nodes per status. The health of an agent that stopped
+// publishing it is stale, the nodes without agent health are only counted.
+func (k *KubeASCheck) parseAgentHealth(sender aggregator.Sender, nodes []v1.Node, healths map[string]string, now time.Time) {
+	staleTimeout := time.Duration(k.instance.AgentHealthStaleTimeout) * time.Second
+	counts := map[string]int{"healthy": 0, "unhealthy": 0, "stale": 0, "missing": 0}
+
+	for i := range nodes {
+		node := &nodes[i]
+		tags := []string{fmt.Sprintf("kube_node:%s", node.Name)}
+
+		value, found := healths[node.Name]
+		if !found {
+			counts["missing"]++
+			continue
+		}
+
+		health, err := apiserver.ParseNodeAgentHealth(value)
+		switch {
+		case err != nil:
+			log.Debugf("Invalid agent health on node %s: %v", node.Name, err)
+			counts["unhealthy"]++
+			sender.ServiceCheck(KubeAgentHealthCheck, metrics.ServiceCheckUnknown, "", tags, "invalid agent health annotation")
+		case health.IsStale(now, staleTimeout):
+			counts["stale"]++
+			message := fmt.Sprintf("the agent stopped publishing its health %s ago", now.Sub(time.Unix(health.Timestamp, 0)).Truncate(time.Second))
+			sender.ServiceCheck(KubeAgentHealthCheck, metrics.ServiceCheckCritical, "", tags, message)
+		case !health.Healthy:
+			counts["unhealthy"]++
+			message := fmt.Sprintf("unhealthy components: %s", strings.Join(health.Unhealthy, ", "))
+			sender.ServiceCheck(KubeAgentHealthCheck, metrics.ServiceCheckCritical, "", tags, message)
+		default:
+			counts["healthy"]++
+			sender.ServiceCheck(KubeAgentHealthCheck, metrics.ServiceCheckOK, "", tags, "")
+		}
+	}
+
+	for status, count := range counts {
+		sender.Gauge(kubeAgentHealthMetric, float64(count), "", []string{fmt.Sprintf("status:%s", status)})
+	}
+}
@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	obj "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestParseAgentHealth(t *testing.T) {
+	kubeASCheck := &KubeASCheck{
+		instance:  &KubeASConfig{AgentHealthStaleTimeout: 180},
+		CheckBase: core.NewCheckBase(kubernetesAPIServerCheckName),
+	}
+	now := time.Unix(1500000300, 0)
+	var nodes []v1.Node
+	for _, name := range []string{"healthy", "unhealthy", "stale", "invalid", "missing"} {
+		nodes = append(nodes, v1.Node{ObjectMeta: obj.ObjectMeta{Name: name}})
+	}
+	healths := map[string]string{
+		"healthy":   `{"healthy":true,"timestamp":1500000280}`,
+		"unhealthy": `{"healthy":false,"unhealthy":["aggregator","forwarder"],"timestamp":1500000280}`,
+		"stale":     `{"healthy":true,"timestamp":1500000000}`,
+		"invalid":   `{`,
+		// the lease of a deleted node is ignored
+		"deleted": `{"healthy":true,"timestamp":1500000280}`,
+	}
+
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.SetupAcceptAll()
+	kubeASCheck.parseAgentHealth(mocked, nodes, healths, now)
+
+	mocked.AssertNumberOfCalls(t, "ServiceCheck", 4)
+	mocked.AssertServiceCheck(t, KubeAgentHealthCheck, metrics.ServiceCheckOK, "", []string{"kube_node:healthy"}, "")
+	mocked.AssertServiceCheck(t, KubeAgentHealthCheck, metrics.ServiceCheckCritical, "", []string{"kube_node:unhealthy"}, "unhealthy components: aggregator, forwarder")
+	mocked.AssertServiceCheck(t, KubeAgentHealthCheck, metrics.ServiceCheckCritical, "", []string{"kube_node:stale"}, "the agent stopped publishing its health 5m0s ago")
+	mocked.AssertServiceCheck(t, KubeAgentHealthCheck, metrics.ServiceCheckUnknown, "", []string{"kube_node:invalid"}, "invalid agent health annotation")
+
+	mocked.AssertMetric(t, "Gauge", kubeAgentHealthMetric, 1, "", []string{"status:healthy"})
+	mocked.AssertMetric(t, "Gauge", kubeAgentHealthMetric, 2, "", []string{"status:unhealthy"})
+	mocked.AssertMetric(t, "Gauge", kubeAgentHealthMetric, 1, "", []string{"status:stale"})
+	mocked.AssertMetric(t, "Gauge", kubeAgentHealthMetric, 1, "", []string{"status:missing"})
+}
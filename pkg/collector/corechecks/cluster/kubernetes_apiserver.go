@@ -8,6 +8,7 @@
 package cluster
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -37,10 +38,16 @@ const (
 
 // KubeASConfig is the config of the API server.
 type KubeASConfig struct {
-	CollectEvent             bool     `yaml:"collect_events"`
-	CollectOShiftQuotas      bool     `yaml:"collect_openshift_clusterquotas"`
-	FilteredEventType        []string `yaml:"filtered_event_types"`
-	EventCollectionTimeoutMs int      `yaml:"kubernetes_event_read_timeout_ms"`
+	CollectEvent             bool                  `yaml:"collect_events"`
+	CollectOShiftQuotas      bool                  `yaml:"collect_openshift_clusterquotas"`
+	CollectOShiftRoutes      bool                  `yaml:"collect_openshift_routes"`
+	FilteredEventType        []string              `yaml:"filtered_event_types"`
+	EventFilter              apiserver.EventFilter `yaml:"event_filters"`
+	EventCollectionTimeoutMs int                   `yaml:"kubernetes_event_read_timeout_ms"`
+	CollectAgentHealth       bool                  `yaml:"collect_agent_health"`
+	AgentHealthStaleTimeout  int                   `yaml:"agent_health_stale_timeout"`
+	CollectNodeInfo          bool                  `yaml:"collect_node_info"`
+	CollectPendingPods       bool                  `yaml:"collect_pending_pods"`
 }
 
 // KubeASCheck grabs metrics and events from the API server.
@@ -49,7 +56,9 @@ type KubeASCheck struct {
 	instance              *KubeASConfig
 	KubeAPIServerHostname string
 	latestEventToken      string
-	configMapAvailable    bool
+	eventFieldSelector    string
+	tokenStoreAvailable   bool
+	tokenStore            apiserver.TokenStore
 	ac                    *apiserver.APIClient
 	oshiftAPILevel        apiserver.OpenShiftAPILevel
 }
@@ -59,6 +68,7 @@ func (c *KubeASConfig) parse(data []byte) error {
 	c.CollectEvent = config.Datadog.GetBool("collect_kubernetes_events")
 	c.CollectOShiftQuotas = true
 	c.EventCollectionTimeoutMs = config.Datadog.GetInt("kubernetes_event_collection_timeout")
+	c.AgentHealthStaleTimeout = 180
 
 	return yaml.Unmarshal(data, c)
 }
@@ -77,6 +87,12 @@ func (k *KubeASCheck) Configure(config, initConfig integration.Data, source stri
 		return err
 	}
 
+	// The event filters are applied by the apiserver
+	k.eventFieldSelector, err = k.instance.EventFilter.FieldSelector()
+	if err != nil {
+		return fmt.Errorf("invalid event_filters: %s", err)
+	}
+
 	log.Debugf("Running config %s", config)
 	return nil
 }
@@ -117,8 +133,8 @@ func (k *KubeASCheck) Run() error {
 			return err
 		}
 
-		// We detect OpenShift presence for quota collection
-		if k.instance.CollectOShiftQuotas {
+		// We detect OpenShift presence for quota and route collection
+		if k.instance.CollectOShiftQuotas || k.instance.CollectOShiftRoutes {
 			k.oshiftAPILevel = k.ac.DetectOpenShiftAPILevel()
 		}
 	}
@@ -144,6 +160,37 @@ func (k *KubeASCheck) Run() error {
 		}
 	}
 
+	// Running OpenShift Route collection if available
+	if k.instance.CollectOShiftRoutes && k.oshiftAPILevel != apiserver.NotOpenShift {
+		routes, err := k.retrieveOShiftRoutes()
+		if err != nil {
+			k.Warnf("Could not collect OpenShift routes: %s", err.Error())
+		} else {
+			k.reportRoutes(routes, sender)
+		}
+	}
+
+	// Running the aggregation of the health published by the node agents
+	if k.instance.CollectAgentHealth {
+		if err := k.reportAgentHealth(sender); err != nil {
+			k.Warnf("Could not collect the health of the node agents: %s", err.Error())
+		}
+	}
+
+	// Running the collection of the node conditions and taints
+	if k.instance.CollectNodeInfo {
+		if err := k.reportNodeInfo(sender); err != nil {
+			k.Warnf("Could not collect the node conditions and taints: %s", err.Error())
+		}
+	}
+
+	// Running the collection of the pending pods and their scheduling failures
+	if k.instance.CollectPendingPods {
+		if err := k.reportPendingPods(sender); err != nil {
+			k.Warnf("Could not collect the pending pods: %s", err.Error())
+		}
+	}
+
 	// Running the event collection.
 	if !k.instance.CollectEvent {
 		return nil
@@ -158,6 +205,12 @@ func (k *KubeASCheck) Run() error {
 		return err
 	}
 
+	// Count the scheduling failures of the events
+	if k.instance.CollectPendingPods {
+		k.reportSchedulingEvents(sender, newEvents)
+		k.reportSchedulingEvents(sender, modifiedEvents)
+	}
+
 	// Process the events to have a Datadog format.
 	err = k.processEvents(sender, newEvents, false)
 	if err != nil {
@@ -205,18 +258,25 @@ func (k *KubeASCheck) runLeaderElection() error {
 }
 func (k *KubeASCheck) eventCollectionInit() {
 	if k.latestEventToken == "" {
-		// Initialization: Checking if we previously stored the latestEventToken in a configMap
-		tokenValue, found, err := k.ac.GetTokenFromConfigmap(eventTokenKey, 3600)
+		var err error
+		k.tokenStore, err = k.ac.GetTokenStore()
+		if err != nil {
+			k.Warnf("Could not get the token store, the LastEventToken won't be stored: %s", err)
+			k.latestEventToken = "0"
+			return
+		}
+		// Initialization: Checking if we previously stored the latestEventToken in the token store
+		tokenValue, found, err := k.tokenStore.GetToken(context.Background(), eventTokenKey, 3600)
 		switch {
 		case err == apiserver.ErrOutdated:
-			k.configMapAvailable = found
+			k.tokenStoreAvailable = found
 			k.latestEventToken = "0"
 
 		case err == apiserver.ErrNotFound:
 			k.latestEventToken = "0"
 
 		case err == nil:
-			k.configMapAvailable = found
+			k.tokenStoreAvailable = found
 			k.latestEventToken = tokenValue
 
 		default:
@@ -229,7 +289,7 @@ func (k *KubeASCheck) eventCollectionInit() {
 func (k *KubeASCheck) eventCollectionCheck() ([]*v1.Event, []*v1.Event, error) {
 	timeout := time.Duration(k.instance.EventCollectionTimeoutMs) * time.Millisecond
 
-	newEvents, modifiedEvents, versionToken, err := k.ac.LatestEvents(k.latestEventToken, timeout)
+	newEvents, modifiedEvents, versionToken, err := k.ac.LatestFilteredEvents(k.latestEventToken, k.eventFieldSelector, timeout)
 	if err != nil {
 		k.Warnf("Could not collect events from the api server: %s", err.Error())
 		return nil, nil, err
@@ -237,7 +297,7 @@ func (k *KubeASCheck) eventCollectionCheck() ([]*v1.Event, []*v1.Event, error) {
 
 	if versionToken == "0" {
 		// API server cache expired or no recent events to process. Resetting the Resversion token.
-		_, _, versionToken, err = k.ac.LatestEvents("0", timeout)
+		_, _, versionToken, err = k.ac.LatestFilteredEvents("0", k.eventFieldSelector, timeout)
 		if err != nil {
 			k.Warnf("Could not collect cached events from the api server: %s", err.Error())
 			return nil, nil, err
@@ -258,10 +318,10 @@ func (k *KubeASCheck) eventCollectionCheck() ([]*v1.Event, []*v1.Event, error) {
 	}
 
 	k.latestEventToken = versionToken
-	if k.configMapAvailable {
-		configMapErr := k.ac.UpdateTokenInConfigmap(eventTokenKey, versionToken)
-		if configMapErr != nil {
-			k.Warnf("Could not store the LastEventToken in the ConfigMap: %s", configMapErr.Error())
+	if k.tokenStoreAvailable {
+		tokenStoreErr := k.tokenStore.UpdateToken(context.Background(), eventTokenKey, versionToken)
+		if tokenStoreErr != nil {
+			k.Warnf("Could not store the LastEventToken: %s", tokenStoreErr.Error())
 		}
 	}
 
@@ -289,3 +289,27 @@ func TestProcessEvent(t *testing.T) {
 	mocked.AssertNotCalled(t, "Event")
 	mocked.AssertExpectations(t)
 }
+
+func TestConfigureEventFilters(t *testing.T) {
+	kubeASCheck := KubernetesASFactory().(*KubeASCheck)
+	err := kubeASCheck.Configure([]byte(`
+event_filters:
+  include:
+    kind: Pod
+  exclude:
+    reason:
+      - Scheduled
+      - Pulled
+`), []byte(""), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "involvedObject.kind=Pod,reason!=Scheduled,reason!=Pulled", kubeASCheck.eventFieldSelector)
+
+	kubeASCheck = KubernetesASFactory().(*KubeASCheck)
+	err = kubeASCheck.Configure([]byte(`
+event_filters:
+  exclude:
+    source:
+      - kubelet
+`), []byte(""), "test")
+	assert.Error(t, err)
+}
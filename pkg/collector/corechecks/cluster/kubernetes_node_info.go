@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+// Covers the conditions, taints and schedulability of the nodes.
+const (
+	kubeNodeSchedulableMetric = "kubernetes_apiserver.node.schedulable"
+	kubeNodeConditionMetric   = "kubernetes_apiserver.node.condition"
+	kubeNodeTaintsMetric      = "kubernetes_apiserver.node.taints"
+	kubeNodesMetric           = "kubernetes_apiserver.nodes"
+)
+
+// reportNodeInfo submits the state of every node of the cluster
+func (k *KubeASCheck) reportNodeInfo(sender aggregator.Sender) error {
+	var nodes []v1.Node
+	err := k.ac.ListNodesPaginated(apiserver.ListSelectors{}, func(page []v1.Node) error {
+		nodes = append(nodes, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	k.parseNodeInfo(sender, nodes)
+	return nil
+}
+
+// parseNodeInfo submits per node whether it is schedulable, its conditions and
+// its taint count, tagged with the node topology, and the count of schedulable
+// and unschedulable nodes.
+func (k *KubeASCheck) parseNodeInfo(sender aggregator.Sender, nodes []v1.Node) {
+	counts := map[string]int{"schedulable": 0, "unschedulable": 0, "cordoned": 0}
+
+	for i := range nodes {
+		info := apiserver.NewNodeInfo(&nodes[i])
+		tags := []string{fmt.Sprintf("kube_node:%s", info.Name)}
+		for tag, value := range info.Topology {
+			tags = append(tags, fmt.Sprintf("%s:%s", tag, value))
+		}
+
+		schedulable := 0.0
+		switch {
+		case info.IsSchedulable():
+			schedulable = 1
+			counts["schedulable"]++
+		case info.Unschedulable:
+			counts["cordoned"]++
+		default:
+			counts["unschedulable"]++
+		}
+		sender.Gauge(kubeNodeSchedulableMetric, schedulable, "", tags)
+		sender.Gauge(kubeNodeTaintsMetric, float64(len(info.Taints)), "", tags)
+
+		for conditionType, status := range info.Conditions {
+			conditionTags := append([]string{
+				fmt.Sprintf("condition:%s", conditionType),
+				fmt.Sprintf("status:%s", status),
+			}, tags...)
+			sender.Gauge(kubeNodeConditionMetric, 1, "", conditionTags)
+		}
+	}
+
+	for status, count := range counts {
+		sender.Gauge(kubeNodesMetric, float64(count), "", []string{fmt.Sprintf("status:%s", status)})
+	}
+}
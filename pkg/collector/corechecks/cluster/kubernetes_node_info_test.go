@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	obj "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+)
+
+func TestParseNodeInfo(t *testing.T) {
+	kubeASCheck := &KubeASCheck{
+		instance:  &KubeASConfig{},
+		CheckBase: core.NewCheckBase(kubernetesAPIServerCheckName),
+	}
+	ready := []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+	nodes := []v1.Node{
+		{
+			ObjectMeta: obj.ObjectMeta{Name: "ready", Labels: map[string]string{"topology.kubernetes.io/zone": "zone-a"}},
+			Status:     v1.NodeStatus{Conditions: ready},
+		},
+		{
+			ObjectMeta: obj.ObjectMeta{Name: "cordoned"},
+			Spec:       v1.NodeSpec{Unschedulable: true},
+			Status:     v1.NodeStatus{Conditions: ready},
+		},
+		{
+			ObjectMeta: obj.ObjectMeta{Name: "tainted"},
+			Spec:       v1.NodeSpec{Taints: []v1.Taint{{Key: "maintenance", Effect: v1.TaintEffectNoSchedule}}},
+			Status:     v1.NodeStatus{Conditions: ready},
+		},
+	}
+
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.SetupAcceptAll()
+	kubeASCheck.parseNodeInfo(mocked, nodes)
+
+	mocked.AssertMetric(t, "Gauge", kubeNodeSchedulableMetric, 1, "", []string{"kube_node:ready", "kube_zone:zone-a"})
+	mocked.AssertMetric(t, "Gauge", kubeNodeSchedulableMetric, 0, "", []string{"kube_node:cordoned"})
+	mocked.AssertMetric(t, "Gauge", kubeNodeTaintsMetric, 1, "", []string{"kube_node:tainted"})
+	mocked.AssertMetric(t, "Gauge", kubeNodeConditionMetric, 1, "", []string{"condition:Ready", "status:True", "kube_node:ready", "kube_zone:zone-a"})
+
+	mocked.AssertMetric(t, "Gauge", kubeNodesMetric, 1, "", []string{"status:schedulable"})
+	mocked.AssertMetric(t, "Gauge", kubeNodesMetric, 1, "", []string{"status:cordoned"})
+	mocked.AssertMetric(t, "Gauge", kubeNodesMetric, 1, "", []string{"status:unschedulable"})
+}
@@ -21,31 +21,70 @@ import (
 )
 
 const (
-	oapiClusterQuotaEndpoint     = "/oapi/v1/clusterresourcequotas/"
-	apiGroupClusterQuotaEndpoint = "/apis/quota.openshift.io/v1/clusterresourcequotas/"
+	oapiClusterQuotaEndpoint = "/oapi/v1/clusterresourcequotas/"
 )
 
 // retrieveOShiftClusterQuotas lists and unmarshalls Openshift
 // ClusterResourceQuota objects from the APIserver
 func (k *KubeASCheck) retrieveOShiftClusterQuotas() ([]osq.ClusterResourceQuota, error) {
-	var url string
 	switch k.oshiftAPILevel {
 	case apiserver.OpenShiftAPIGroup:
-		url = apiGroupClusterQuotaEndpoint
-		break
+		return k.ac.ListOpenShiftClusterResourceQuotas()
 	case apiserver.OpenShiftOAPI:
-		url = oapiClusterQuotaEndpoint
-		break
+		list := &osq.ClusterResourceQuotaList{}
+		err := k.ac.GetRESTObjectWithOptions(oapiClusterQuotaEndpoint, list, apiserver.RESTObjectOptions{
+			Retries:            2,
+			AcceptContentTypes: "application/json",
+		})
+		switch err.(type) {
+		case nil:
+			return list.Items, nil
+		case *apiserver.NotFoundError:
+			// the legacy API doesn't serve the quotas on every OpenShift version
+			log.Debugf("No OpenShift cluster quotas to collect: %v", err)
+			return nil, nil
+		case *apiserver.ForbiddenError:
+			return nil, fmt.Errorf("the cluster role of the agent must allow to list the clusterresourcequotas: %v", err)
+		}
+		return nil, err
 	default:
 		return nil, errors.New("OpenShift APIs unavailable")
 	}
+}
 
-	list := &osq.ClusterResourceQuotaList{}
-	err := k.ac.GetRESTObject(url, list)
-	if err != nil {
-		return nil, err
+// retrieveOShiftRoutes lists the Openshift Route objects of all the
+// namespaces, they are only collected from the route.openshift.io API group
+func (k *KubeASCheck) retrieveOShiftRoutes() ([]apiserver.OpenShiftRoute, error) {
+	if k.oshiftAPILevel != apiserver.OpenShiftAPIGroup {
+		return nil, errors.New("OpenShift route.openshift.io API group unavailable")
+	}
+	return k.ac.ListOpenShiftRoutes("")
+}
+
+// reportRoutes reports whether the OpenShift Route objects are admitted by
+// a router, tagged with their host and the service they expose
+func (k *KubeASCheck) reportRoutes(routes []apiserver.OpenShiftRoute, sender aggregator.Sender) {
+	for _, route := range routes {
+		tags := []string{
+			fmt.Sprintf("route:%s", route.Name),
+			fmt.Sprintf("kube_namespace:%s", route.Namespace),
+		}
+		if route.Spec.Host != "" {
+			tags = append(tags, fmt.Sprintf("route_host:%s", route.Spec.Host))
+		}
+		if route.Spec.To.Kind == "Service" && route.Spec.To.Name != "" {
+			tags = append(tags, fmt.Sprintf("kube_service:%s", route.Spec.To.Name))
+		}
+		if route.Spec.TLS != nil && route.Spec.TLS.Termination != "" {
+			tags = append(tags, fmt.Sprintf("route_tls_termination:%s", route.Spec.TLS.Termination))
+		}
+
+		admitted := 0.0
+		if route.IsAdmitted() {
+			admitted = 1.0
+		}
+		sender.Gauge("openshift.route.admitted", admitted, "", tags)
 	}
-	return list.Items, nil
 }
 
 // reportClusterQuotas reports metrics on OpenShift ClusterResourceQuota objects
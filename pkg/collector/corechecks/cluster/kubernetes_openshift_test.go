@@ -15,8 +15,10 @@ import (
 
 	osq "github.com/openshift/api/quota/v1"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 )
 
 func TestReportClusterQuotas(t *testing.T) {
@@ -89,3 +91,52 @@ func TestReportClusterQuotas(t *testing.T) {
 		}
 	}
 }
+
+func TestReportRoutes(t *testing.T) {
+	routes := []apiserver.OpenShiftRoute{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default"},
+			Spec: apiserver.OpenShiftRouteSpec{
+				Host: "www.example.com",
+				To:   apiserver.OpenShiftRouteReference{Kind: "Service", Name: "frontend"},
+				TLS:  &apiserver.OpenShiftRouteTLS{Termination: "edge"},
+			},
+			Status: apiserver.OpenShiftRouteStatus{
+				Ingress: []apiserver.OpenShiftRouteIngress{{
+					RouterName: "router",
+					Conditions: []apiserver.OpenShiftRouteIngressCondition{{Type: "Admitted", Status: "True"}},
+				}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "default"},
+			Spec: apiserver.OpenShiftRouteSpec{
+				Host: "backend.example.com",
+				To:   apiserver.OpenShiftRouteReference{Kind: "Service", Name: "backend"},
+			},
+		},
+	}
+
+	kubeASCheck := KubernetesASFactory().(*KubeASCheck)
+	err := kubeASCheck.Configure([]byte(""), []byte(""), "test")
+	require.NoError(t, err)
+
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.SetupAcceptAll()
+	kubeASCheck.reportRoutes(routes, mocked)
+	mocked.AssertNumberOfCalls(t, "Gauge", 2)
+
+	mocked.AssertMetric(t, "Gauge", "openshift.route.admitted", 1, "", []string{
+		"route:frontend",
+		"kube_namespace:default",
+		"route_host:www.example.com",
+		"kube_service:frontend",
+		"route_tls_termination:edge",
+	})
+	mocked.AssertMetric(t, "Gauge", "openshift.route.admitted", 0, "", []string{
+		"route:backend",
+		"kube_namespace:default",
+		"route_host:backend.example.com",
+		"kube_service:backend",
+	})
+}
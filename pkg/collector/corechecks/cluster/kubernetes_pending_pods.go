@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+// Covers the pending pods and the reasons the scheduler can't place them.
+const (
+	kubePendingPodsMetric            = "kubernetes_apiserver.pods.pending"
+	kubeSchedulingFailuresMetric     = "kubernetes_apiserver.pods.scheduling_failures"
+	kubeFailedSchedulingEventsMetric = "kubernetes_apiserver.pods.failed_scheduling_events"
+
+	failedSchedulingEventReason = "FailedScheduling"
+	podScheduledReason          = "Scheduled"
+	podNotSeenReason            = "NotSeenByScheduler"
+	otherSchedulingFailure      = "other"
+)
+
+// schedulingFailureReasons maps the fragments of the scheduler messages to
+// short reasons, the first matching fragment wins
+var schedulingFailureReasons = []struct {
+	fragment string
+	reason   string
+}{
+	{"volume node affinity conflict", "volume_node_affinity"},
+	{"unbound immediate persistentvolumeclaims", "unbound_pvc"},
+	{"node affinity", "node_affinity"},
+	{"node selector", "node_affinity"},
+	{"anti-affinity", "pod_anti_affinity"},
+	{"pod affinity", "pod_affinity"},
+	{"topology spread constraints", "topology_spread"},
+	{"taint", "untolerated_taint"},
+	{"insufficient cpu", "insufficient_cpu"},
+	{"insufficient memory", "insufficient_memory"},
+	{"insufficient ephemeral-storage", "insufficient_ephemeral_storage"},
+	{"insufficient", "insufficient_resource"},
+	{"free ports", "host_ports"},
+	{"too many pods", "too_many_pods"},
+	{"were unschedulable", "node_unschedulable"},
+	{"no nodes available", "no_nodes"},
+}
+
+type namespaceReason struct {
+	namespace string
+	reason    string
+}
+
+// reportPendingPods submits the pending pods of the cluster, and the reasons
+// the unschedulable ones can't be scheduled
+func (k *KubeASCheck) reportPendingPods(sender aggregator.Sender) error {
+	var pods []v1.Pod
+	selectors := apiserver.ListSelectors{FieldSelector: fields.OneTermEqualSelector("status.phase", string(v1.PodPending)).String()}
+	err := k.ac.ListPodsPaginated("", selectors, func(page []v1.Pod) error {
+		pods = append(pods, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	k.parsePendingPods(sender, pods)
+	return nil
+}
+
+// parsePendingPods submits the count of pending pods per namespace and
+// PodScheduled condition reason, and the count of unschedulable pods per
+// namespace and scheduling failure, parsed from the scheduler message. A pod
+// failing for several reasons is counted for each of them.
+func (k *KubeASCheck) parsePendingPods(sender aggregator.Sender, pods []v1.Pod) {
+	pending := make(map[namespaceReason]int)
+	failures := make(map[namespaceReason]int)
+
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != v1.PodPending {
+			continue
+		}
+		reason, message := podSchedulingStatus(pod)
+		pending[namespaceReason{pod.Namespace, reason}]++
+		if reason != v1.PodReasonUnschedulable {
+			continue
+		}
+		for _, failure := range parseSchedulingFailures(message) {
+			failures[namespaceReason{pod.Namespace, failure}]++
+		}
+	}
+
+	for key, count := range pending {
+		tags := []string{fmt.Sprintf("kube_namespace:%s", key.namespace), fmt.Sprintf("reason:%s", key.reason)}
+		sender.Gauge(kubePendingPodsMetric, float64(count), "", tags)
+	}
+	for key, count := range failures {
+		tags := []string{fmt.Sprintf("kube_namespace:%s", key.namespace), fmt.Sprintf("scheduling_failure:%s", key.reason)}
+		sender.Gauge(kubeSchedulingFailuresMetric, float64(count), "", tags)
+	}
+}
+
+// reportSchedulingEvents counts the FailedScheduling events of the pods per
+// namespace and scheduling failure
+func (k *KubeASCheck) reportSchedulingEvents(sender aggregator.Sender, events []*v1.Event) {
+	for _, event := range events {
+		if event.Reason != failedSchedulingEventReason || event.InvolvedObject.Kind != "Pod" {
+			continue
+		}
+		for _, failure := range parseSchedulingFailures(event.Message) {
+			tags := []string{fmt.Sprintf("kube_namespace:%s", event.InvolvedObject.Namespace), fmt.Sprintf("scheduling_failure:%s", failure)}
+			sender.Count(kubeFailedSchedulingEventsMetric, 1, "", tags)
+		}
+	}
+}
+
+// podSchedulingStatus returns the reason and the message of the PodScheduled
+// condition of a pod, Scheduled once it's bound to a node
+func podSchedulingStatus(pod *v1.Pod) (string, string) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != v1.PodScheduled {
+			continue
+		}
+		if condition.Status == v1.ConditionTrue {
+			return podScheduledReason, ""
+		}
+		if condition.Reason == "" {
+			return v1.PodReasonUnschedulable, condition.Message
+		}
+		return condition.Reason, condition.Message
+	}
+	if pod.Spec.NodeName != "" {
+		return podScheduledReason, ""
+	}
+	return podNotSeenReason, ""
+}
+
+// parseSchedulingFailures returns the sorted short reasons of a scheduler
+// message, e.g. "0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had
+// taint {dedicated: gpu}, that the pod didn't tolerate." gives
+// insufficient_cpu and untolerated_taint
+func parseSchedulingFailures(message string) []string {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil
+	}
+	if i := strings.Index(message, "nodes are available: "); i >= 0 {
+		message = message[i+len("nodes are available: "):]
+	}
+	// the preemption attempts follow the failures
+	if i := strings.Index(message, ". "); i >= 0 {
+		message = message[:i]
+	}
+
+	// the failures are "<node count> <reason>", a reason may hold commas
+	var items []string
+	for _, item := range strings.Split(strings.TrimSuffix(message, "."), ", ") {
+		if len(items) > 0 && (item == "" || item[0] < '0' || item[0] > '9') {
+			items[len(items)-1] += ", " + item
+			continue
+		}
+		items = append(items, item)
+	}
+
+	seen := make(map[string]bool)
+	var reasons []string
+	for _, item := range items {
+		reason := schedulingFailureReason(item)
+		if !seen[reason] {
+			seen[reason] = true
+			reasons = append(reasons, reason)
+		}
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+func schedulingFailureReason(item string) string {
+	item = strings.ToLower(item)
+	for _, r := range schedulingFailureReasons {
+		if strings.Contains(item, r.fragment) {
+			return r.reason
+		}
+	}
+	return otherSchedulingFailure
+}
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	obj "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+)
+
+func TestParseSchedulingFailures(t *testing.T) {
+	for message, expected := range map[string][]string{
+		"0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had taint {dedicated: gpu}, that the pod didn't tolerate.": {"insufficient_cpu", "untolerated_taint"},
+		"0/5 nodes are available: 2 Insufficient memory, 3 Insufficient cpu.":                                              {"insufficient_cpu", "insufficient_memory"},
+		"0/2 nodes are available: 2 Insufficient nvidia.com/gpu.":                                                          {"insufficient_resource"},
+		"0/4 nodes are available: 1 node(s) had volume node affinity conflict, 3 node(s) didn't match node selector.":      {"node_affinity", "volume_node_affinity"},
+		"0/3 nodes are available: 3 node(s) didn't match pod anti-affinity rules. preemption: 0/3 nodes are available.":    {"pod_anti_affinity"},
+		"0/1 nodes are available: 1 node(s) were unschedulable.":                                                           {"node_unschedulable"},
+		"pod has unbound immediate PersistentVolumeClaims (repeated 3 times)":                                              {"unbound_pvc"},
+		"no nodes available to schedule pods":                                                                              {"no_nodes"},
+		"0/1 nodes are available: 1 something new.":                                                                        {"other"},
+		"": nil,
+	} {
+		assert.Equal(t, expected, parseSchedulingFailures(message), message)
+	}
+}
+
+func TestParsePendingPods(t *testing.T) {
+	kubeASCheck := &KubeASCheck{
+		instance:  &KubeASConfig{},
+		CheckBase: core.NewCheckBase(kubernetesAPIServerCheckName),
+	}
+	unschedulable := func(name, namespace, message string) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: obj.ObjectMeta{Name: name, Namespace: namespace},
+			Status: v1.PodStatus{
+				Phase: v1.PodPending,
+				Conditions: []v1.PodCondition{{
+					Type:    v1.PodScheduled,
+					Status:  v1.ConditionFalse,
+					Reason:  v1.PodReasonUnschedulable,
+					Message: message,
+				}},
+			},
+		}
+	}
+	pods := []v1.Pod{
+		unschedulable("web-1", "default", "0/3 nodes are available: 3 Insufficient cpu."),
+		unschedulable("web-2", "default", "0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had taint {dedicated: gpu}, that the pod didn't tolerate."),
+		unschedulable("db-0", "storage", "pod has unbound immediate PersistentVolumeClaims"),
+		{
+			ObjectMeta: obj.ObjectMeta{Name: "pulling", Namespace: "default"},
+			Spec:       v1.PodSpec{NodeName: "node-1"},
+			Status: v1.PodStatus{
+				Phase:      v1.PodPending,
+				Conditions: []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionTrue}},
+			},
+		},
+		{
+			ObjectMeta: obj.ObjectMeta{Name: "new", Namespace: "default"},
+			Status:     v1.PodStatus{Phase: v1.PodPending},
+		},
+		{
+			ObjectMeta: obj.ObjectMeta{Name: "running", Namespace: "default"},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		},
+	}
+
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.SetupAcceptAll()
+	kubeASCheck.parsePendingPods(mocked, pods)
+
+	mocked.AssertMetric(t, "Gauge", kubePendingPodsMetric, 2, "", []string{"kube_namespace:default", "reason:Unschedulable"})
+	mocked.AssertMetric(t, "Gauge", kubePendingPodsMetric, 1, "", []string{"kube_namespace:default", "reason:Scheduled"})
+	mocked.AssertMetric(t, "Gauge", kubePendingPodsMetric, 1, "", []string{"kube_namespace:default", "reason:NotSeenByScheduler"})
+	mocked.AssertMetric(t, "Gauge", kubePendingPodsMetric, 1, "", []string{"kube_namespace:storage", "reason:Unschedulable"})
+
+	mocked.AssertMetric(t, "Gauge", kubeSchedulingFailuresMetric, 2, "", []string{"kube_namespace:default", "scheduling_failure:insufficient_cpu"})
+	mocked.AssertMetric(t, "Gauge", kubeSchedulingFailuresMetric, 1, "", []string{"kube_namespace:default", "scheduling_failure:untolerated_taint"})
+	mocked.AssertMetric(t, "Gauge", kubeSchedulingFailuresMetric, 1, "", []string{"kube_namespace:storage", "scheduling_failure:unbound_pvc"})
+	mocked.AssertNumberOfCalls(t, "Gauge", 7)
+}
+
+func TestReportSchedulingEvents(t *testing.T) {
+	kubeASCheck := &KubeASCheck{
+		instance:  &KubeASConfig{},
+		CheckBase: core.NewCheckBase(kubernetesAPIServerCheckName),
+	}
+	events := []*v1.Event{
+		{
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "web-1", Namespace: "default"},
+			Reason:         "FailedScheduling",
+			Message:        "0/3 nodes are available: 3 Insufficient memory.",
+		},
+		{
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "web-1", Namespace: "default"},
+			Reason:         "Pulled",
+			Message:        "Container image \"nginx\" already present on machine",
+		},
+	}
+
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.SetupAcceptAll()
+	kubeASCheck.reportSchedulingEvents(mocked, events)
+
+	mocked.AssertMetric(t, "Count", kubeFailedSchedulingEventsMetric, 1, "", []string{"kube_namespace:default", "scheduling_failure:insufficient_memory"})
+	mocked.AssertNumberOfCalls(t, "Count", 1)
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	cutil "github.com/DataDog/datadog-agent/pkg/util/containerd"
 	ddContainers "github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/blocklist"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -82,8 +83,8 @@ func (c *ContainerdCheck) Configure(config, initConfig integration.Data, source
 		return err
 	}
 	c.sub.Filters = c.instance.ContainerdFilters
-	// GetSharedFilter should not return a nil instance of *Filter if there is an error during its setup.
-	fil, err := ddContainers.GetSharedFilter()
+	// GetFilter should not return a nil instance of *Filter if there is an error during its setup.
+	fil, err := ddContainers.GetFilter(ddContainers.MetricsFilter)
 	if err != nil {
 		return err
 	}
@@ -182,7 +183,7 @@ func computeMetrics(sender aggregator.Sender, cu cutil.ContainerdItf, fil *ddCon
 			log.Errorf("Could not retrieve the metadata of the container: %s", ctn.ID()[:12])
 			continue
 		}
-		if isExcluded(info, fil) {
+		if isExcluded(info, fil) || blocklist.IsEntityExcluded("containerd://"+ctn.ID(), blocklist.Metrics) {
 			continue
 		}
 
@@ -19,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/containers/cri"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/blocklist"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -77,18 +78,22 @@ func (c *CRICheck) Run() error {
 		return err
 	}
 
-	util, err := cri.GetUtil()
+	// the check runs against all the CRI sockets, for the nodes running
+	// several runtimes
+	utils, err := cri.GetUtils()
 	if err != nil {
 		c.Warnf("Error initialising check: %s", err)
 		return err
 	}
 
-	containerStats, err := util.ListContainerStats()
+	containerStatsByRuntime, err := cri.ListContainerStatsByRuntime(utils)
 	if err != nil {
 		c.Warnf("Cannot get containers from the CRI: %s", err)
 		return err
 	}
-	c.processContainerStats(sender, util.Runtime, containerStats)
+	for runtime, containerStats := range containerStatsByRuntime {
+		c.processContainerStats(sender, runtime, containerStats)
+	}
 
 	sender.Commit()
 	return nil
@@ -98,6 +103,10 @@ func (c *CRICheck) Run() error {
 func (c *CRICheck) processContainerStats(sender aggregator.Sender, runtime string, containerStats map[string]*pb.ContainerStats) {
 	for cid, stats := range containerStats {
 		entityID := containers.BuildTaggerEntityName(cid)
+		// the kubelet reports the containers with the name of their runtime
+		if blocklist.IsEntityExcluded(runtime+"://"+cid, blocklist.Metrics) {
+			continue
+		}
 		tags, err := tagger.Tag(entityID, collectors.HighCardinality)
 		if err != nil {
 			log.Errorf("Could not collect tags for container %s: %s", cid[:12], err)
@@ -27,6 +27,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	cmetrics "github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/blocklist"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -75,6 +76,7 @@ type DockerCheck struct {
 	dockerHostname              string
 	cappedSender                *cappedSender
 	collectContainerSizeCounter uint64
+	metricsFilter               *containers.Filter
 }
 
 func updateContainerRunningCount(images map[string]*containerPerImage, c *containers.Container) {
@@ -180,6 +182,12 @@ func (d *DockerCheck) Run() error {
 		if c.State != containers.ContainerRunningState || c.Excluded {
 			continue
 		}
+		if blocklist.IsEntityExcluded(c.EntityID, blocklist.Metrics) {
+			continue
+		}
+		if d.metricsFilter != nil && d.metricsFilter.IsExcluded(c.Name, c.Image) {
+			continue
+		}
 		tags, err := tagger.Tag(c.EntityID, collectors.HighCardinality)
 		if err != nil {
 			log.Errorf("Could not collect tags for container %s: %s", c.ID[:12], err)
@@ -383,6 +391,11 @@ func (d *DockerCheck) Configure(config, initConfig integration.Data, source stri
 
 	d.instance.Parse(config)
 
+	d.metricsFilter, err = containers.GetFilter(containers.MetricsFilter)
+	if err != nil {
+		return err
+	}
+
 	if len(d.instance.FilteredEventType) == 0 {
 		d.instance.FilteredEventType = []string{"top", "exec_create", "exec_start", "exec_die"}
 	}
@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubelet
+
+package containers
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	kubeletStatsCheckName = "kubelet_stats"
+
+	ephemeralStorageResource = "ephemeral-storage"
+)
+
+// KubeletStatsCheck grabs the pod level metrics of the kubelet /stats/summary
+// endpoint: network, ephemeral storage and persistent volumes usage
+type KubeletStatsCheck struct {
+	core.CheckBase
+}
+
+func init() {
+	core.RegisterCheck(kubeletStatsCheckName, KubeletStatsFactory)
+}
+
+// KubeletStatsFactory is exported for integration testing
+func KubeletStatsFactory() check.Check {
+	return &KubeletStatsCheck{
+		CheckBase: core.NewCheckBase(kubeletStatsCheckName),
+	}
+}
+
+// Configure parses the check configuration and init the check
+func (c *KubeletStatsCheck) Configure(config, initConfig integration.Data, source string) error {
+	return c.CommonConfigure(config, source)
+}
+
+// Run executes the check
+func (c *KubeletStatsCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	ku, err := kubelet.GetKubeUtil()
+	if err != nil {
+		c.Warnf("Error initialising check: %s", err)
+		return err
+	}
+
+	summary, err := ku.GetStatsSummary()
+	if err != nil {
+		c.Warnf("Cannot get the stats summary from the kubelet: %s", err)
+		return err
+	}
+	pods, err := ku.GetLocalPodList()
+	if err != nil {
+		// only the ephemeral storage limits are missing
+		log.Debugf("Cannot get the pod list from the kubelet: %s", err)
+	}
+	c.processStatsSummary(sender, summary, pods)
+
+	sender.Commit()
+	return nil
+}
+
+// processStatsSummary extracts the pod metrics from the stats summary
+func (c *KubeletStatsCheck) processStatsSummary(sender aggregator.Sender, summary *kubelet.StatsSummary, pods []*kubelet.Pod) {
+	podsByUID := make(map[string]*kubelet.Pod, len(pods))
+	for _, pod := range pods {
+		podsByUID[pod.Metadata.UID] = pod
+	}
+
+	for _, podStats := range summary.Pods {
+		entityID := kubelet.PodUIDToTaggerEntityName(podStats.PodRef.UID)
+		tags, err := tagger.Tag(entityID, collectors.OrchestratorCardinality)
+		if err != nil {
+			log.Debugf("Could not collect tags for pod %s: %s", podStats.PodRef.Name, err)
+		}
+		if len(tags) == 0 {
+			tags = []string{"pod_name:" + podStats.PodRef.Name, "kube_namespace:" + podStats.PodRef.Namespace}
+		}
+
+		if podStats.Network != nil {
+			c.processNetworkStats(sender, podStats.Network, tags)
+		}
+		if podStats.EphemeralStorage != nil {
+			c.processEphemeralStorage(sender, podStats.EphemeralStorage, podsByUID[podStats.PodRef.UID], tags)
+		}
+		for _, volume := range podStats.VolumeStats {
+			if volume.PVCRef == nil {
+				// only the persistent volumes have a stable identity to tag with
+				continue
+			}
+			volumeTags := append(copyTags(tags), "persistentvolumeclaim:"+volume.PVCRef.Name)
+			gaugeIfSet(sender, "kubernetes.kubelet.volume.stats.used_bytes", volume.UsedBytes, volumeTags)
+			gaugeIfSet(sender, "kubernetes.kubelet.volume.stats.capacity_bytes", volume.CapacityBytes, volumeTags)
+			gaugeIfSet(sender, "kubernetes.kubelet.volume.stats.available_bytes", volume.AvailableBytes, volumeTags)
+			gaugeIfSet(sender, "kubernetes.kubelet.volume.stats.inodes", volume.Inodes, volumeTags)
+			gaugeIfSet(sender, "kubernetes.kubelet.volume.stats.inodes_used", volume.InodesUsed, volumeTags)
+			gaugeIfSet(sender, "kubernetes.kubelet.volume.stats.inodes_free", volume.InodesFree, volumeTags)
+		}
+	}
+}
+
+// processNetworkStats sends the network counters of every interface of the pod
+func (c *KubeletStatsCheck) processNetworkStats(sender aggregator.Sender, network *kubelet.NetworkStats, tags []string) {
+	interfaces := network.Interfaces
+	if len(interfaces) == 0 {
+		// kubelets older than 1.12 only report the default interface
+		interfaces = []kubelet.InterfaceStats{network.InterfaceStats}
+	}
+	for _, iface := range interfaces {
+		ifaceTags := tags
+		if iface.Name != "" {
+			ifaceTags = append(copyTags(tags), "interface:"+iface.Name)
+		}
+		rateIfSet(sender, "kubernetes.pod.network.rx_bytes", iface.RxBytes, ifaceTags)
+		rateIfSet(sender, "kubernetes.pod.network.tx_bytes", iface.TxBytes, ifaceTags)
+		rateIfSet(sender, "kubernetes.pod.network.rx_errors", iface.RxErrors, ifaceTags)
+		rateIfSet(sender, "kubernetes.pod.network.tx_errors", iface.TxErrors, ifaceTags)
+	}
+}
+
+// processEphemeralStorage sends the ephemeral storage usage of the pod and,
+// when every container has one, the limit of the pod
+func (c *KubeletStatsCheck) processEphemeralStorage(sender aggregator.Sender, stats *kubelet.FsStats, pod *kubelet.Pod, tags []string) {
+	if stats.UsedBytes == nil {
+		return
+	}
+	used := float64(*stats.UsedBytes)
+	sender.Gauge("kubernetes.pod.ephemeral_storage.usage", used, "", tags)
+
+	limit, found := ephemeralStorageLimit(pod)
+	if !found || limit <= 0 {
+		return
+	}
+	sender.Gauge("kubernetes.pod.ephemeral_storage.limit", limit, "", tags)
+	sender.Gauge("kubernetes.pod.ephemeral_storage.usage_pct", used/limit, "", tags)
+}
+
+// ephemeralStorageLimit returns the sum of the ephemeral storage limits of the
+// containers of the pod, the pod is unbounded if a container has no limit
+func ephemeralStorageLimit(pod *kubelet.Pod) (float64, bool) {
+	if pod == nil || len(pod.Spec.Containers) == 0 {
+		return 0, false
+	}
+	var limit float64
+	for _, container := range pod.Spec.Containers {
+		if container.Resources == nil {
+			return 0, false
+		}
+		value, found := container.Resources.Limits[ephemeralStorageResource]
+		if !found {
+			return 0, false
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			log.Debugf("Invalid ephemeral storage limit %q for pod %s: %s", value, pod.Metadata.Name, err)
+			return 0, false
+		}
+		limit += float64(quantity.Value())
+	}
+	return limit, true
+}
+
+func gaugeIfSet(sender aggregator.Sender, metric string, value *uint64, tags []string) {
+	if value != nil {
+		sender.Gauge(metric, float64(*value), "", tags)
+	}
+}
+
+func rateIfSet(sender aggregator.Sender, metric string, value *uint64, tags []string) {
+	if value != nil {
+		sender.Rate(metric, float64(*value), "", tags)
+	}
+}
+
+func copyTags(tags []string) []string {
+	return append(make([]string, 0, len(tags)+1), tags...)
+}
@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubelet
+
+package containers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+)
+
+const testStatsSummary = `{
+  "pods": [
+    {
+      "podRef": {"name": "web-0", "namespace": "default", "uid": "1234"},
+      "network": {
+        "name": "eth0", "rxBytes": 100, "rxErrors": 0, "txBytes": 200, "txErrors": 1,
+        "interfaces": [
+          {"name": "eth0", "rxBytes": 100, "rxErrors": 0, "txBytes": 200, "txErrors": 1}
+        ]
+      },
+      "volume": [
+        {"name": "default-token", "usedBytes": 10},
+        {"name": "data", "usedBytes": 300, "capacityBytes": 1000, "availableBytes": 700,
+         "inodes": 50, "inodesUsed": 5, "inodesFree": 45,
+         "pvcRef": {"name": "data-web-0", "namespace": "default"}}
+      ],
+      "ephemeral-storage": {"usedBytes": 512}
+    }
+  ]
+}`
+
+func TestKubeletStatsProcessStatsSummary(t *testing.T) {
+	kubeletStatsCheck := &KubeletStatsCheck{
+		CheckBase: core.NewCheckBase(kubeletStatsCheckName),
+	}
+
+	var summary kubelet.StatsSummary
+	require.NoError(t, json.Unmarshal([]byte(testStatsSummary), &summary))
+	pods := []*kubelet.Pod{
+		{
+			Metadata: kubelet.PodMetadata{Name: "web-0", UID: "1234"},
+			Spec: kubelet.Spec{
+				Containers: []kubelet.ContainerSpec{
+					{Name: "web", Resources: &kubelet.ContainerResources{Limits: map[string]string{"ephemeral-storage": "1Ki"}}},
+					{Name: "sidecar", Resources: &kubelet.ContainerResources{Limits: map[string]string{"ephemeral-storage": "1Ki"}}},
+				},
+			},
+		},
+	}
+
+	mocked := mocksender.NewMockSender(kubeletStatsCheck.ID())
+	mocked.SetupAcceptAll()
+	kubeletStatsCheck.processStatsSummary(mocked, &summary, pods)
+
+	podTags := []string{"pod_name:web-0", "kube_namespace:default"}
+	ifaceTags := append(podTags, "interface:eth0")
+	mocked.AssertMetric(t, "Rate", "kubernetes.pod.network.rx_bytes", 100, "", ifaceTags)
+	mocked.AssertMetric(t, "Rate", "kubernetes.pod.network.tx_bytes", 200, "", ifaceTags)
+	mocked.AssertMetric(t, "Rate", "kubernetes.pod.network.rx_errors", 0, "", ifaceTags)
+	mocked.AssertMetric(t, "Rate", "kubernetes.pod.network.tx_errors", 1, "", ifaceTags)
+
+	mocked.AssertMetric(t, "Gauge", "kubernetes.pod.ephemeral_storage.usage", 512, "", podTags)
+	mocked.AssertMetric(t, "Gauge", "kubernetes.pod.ephemeral_storage.limit", 2048, "", podTags)
+	mocked.AssertMetric(t, "Gauge", "kubernetes.pod.ephemeral_storage.usage_pct", 0.25, "", podTags)
+
+	volumeTags := append(podTags, "persistentvolumeclaim:data-web-0")
+	mocked.AssertMetric(t, "Gauge", "kubernetes.kubelet.volume.stats.used_bytes", 300, "", volumeTags)
+	mocked.AssertMetric(t, "Gauge", "kubernetes.kubelet.volume.stats.capacity_bytes", 1000, "", volumeTags)
+	mocked.AssertMetric(t, "Gauge", "kubernetes.kubelet.volume.stats.available_bytes", 700, "", volumeTags)
+	mocked.AssertMetric(t, "Gauge", "kubernetes.kubelet.volume.stats.inodes", 50, "", volumeTags)
+	mocked.AssertMetric(t, "Gauge", "kubernetes.kubelet.volume.stats.inodes_used", 5, "", volumeTags)
+	mocked.AssertMetric(t, "Gauge", "kubernetes.kubelet.volume.stats.inodes_free", 45, "", volumeTags)
+	// volumes without claim are not reported
+	mocked.AssertNumberOfCalls(t, "Gauge", 9)
+}
+
+func TestEphemeralStorageLimitUnbounded(t *testing.T) {
+	pod := &kubelet.Pod{
+		Spec: kubelet.Spec{
+			Containers: []kubelet.ContainerSpec{
+				{Name: "web", Resources: &kubelet.ContainerResources{Limits: map[string]string{"ephemeral-storage": "1Gi"}}},
+				{Name: "sidecar"},
+			},
+		},
+	}
+	_, found := ephemeralStorageLimit(pod)
+	require.False(t, found)
+	_, found = ephemeralStorageLimit(nil)
+	require.False(t, found)
+}
@@ -0,0 +1,432 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package httpjson
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmespath/go-jmespath"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	httpJSONCheckName = "http_json"
+	defaultTimeout    = 10
+
+	canConnectServiceCheck = "http_json.can_connect"
+
+	// the responses are read up to maxResponseSize, the endpoints polled are
+	// expected to be small admin or status pages
+	maxResponseSize = 10 * 1024 * 1024
+)
+
+type instanceConfig struct {
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method"`
+	Body    string            `yaml:"body"`
+	Headers map[string]string `yaml:"headers"`
+	Timeout int               `yaml:"timeout"`
+
+	Username        string `yaml:"username"`
+	Password        string `yaml:"password"`
+	BearerToken     string `yaml:"bearer_token"`
+	BearerTokenPath string `yaml:"bearer_token_path"`
+
+	TLSVerify *bool  `yaml:"tls_verify"`
+	TLSCACert string `yaml:"tls_ca_cert"`
+	TLSCert   string `yaml:"tls_cert"`
+	TLSKey    string `yaml:"tls_key"`
+
+	Metrics []metricConfig `yaml:"metrics"`
+}
+
+// metricConfig extracts a metric from the JSON document. With items set, the
+// path and the tags are evaluated on each element of the array it selects,
+// e.g. one metric per queue of a queues array.
+type metricConfig struct {
+	Name     string             `yaml:"name"`
+	Type     string             `yaml:"type"`
+	Items    string             `yaml:"items"`
+	Path     string             `yaml:"path"`
+	Tags     map[string]string  `yaml:"tags"`
+	ValueMap map[string]float64 `yaml:"value_map"`
+}
+
+// metric is a compiled metricConfig
+type metric struct {
+	name     string
+	submit   func(sender aggregator.Sender, name string, value float64, tags []string)
+	items    *jmespath.JMESPath
+	path     *jmespath.JMESPath
+	tagNames []string
+	tagPaths []*jmespath.JMESPath
+	valueMap map[string]float64
+}
+
+var submitters = map[string]func(sender aggregator.Sender, name string, value float64, tags []string){
+	"gauge": func(s aggregator.Sender, n string, v float64, t []string) { s.Gauge(n, v, "", t) },
+	"count": func(s aggregator.Sender, n string, v float64, t []string) { s.Count(n, v, "", t) },
+	"rate":  func(s aggregator.Sender, n string, v float64, t []string) { s.Rate(n, v, "", t) },
+	"monotonic_count": func(s aggregator.Sender, n string, v float64, t []string) {
+		s.MonotonicCount(n, v, "", t)
+	},
+}
+
+// Check polls an HTTP endpoint returning a JSON document, and extracts
+// metrics from it with JMESPath expressions
+type Check struct {
+	core.CheckBase
+	url             string
+	method          string
+	body            string
+	headers         map[string]string
+	username        string
+	password        string
+	bearerToken     string
+	bearerTokenPath string
+	metrics         []*metric
+	httpClient      *http.Client
+}
+
+func (c *Check) String() string {
+	return httpJSONCheckName
+}
+
+// Configure parses the check configuration
+func (c *Check) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	instance := instanceConfig{}
+	if err := yaml.Unmarshal(data, &instance); err != nil {
+		return err
+	}
+	c.BuildID(data, initConfig)
+	if err := c.CommonConfigure(data, source); err != nil {
+		return err
+	}
+
+	if instance.URL == "" {
+		return fmt.Errorf("instance config `url` must not be empty")
+	}
+	if len(instance.Metrics) == 0 {
+		return fmt.Errorf("instance config `metrics` must not be empty")
+	}
+	if instance.Username != "" && (instance.BearerToken != "" || instance.BearerTokenPath != "") {
+		return fmt.Errorf("the basic and bearer token authentications are exclusive")
+	}
+
+	c.url = instance.URL
+	c.method = strings.ToUpper(instance.Method)
+	if c.method == "" {
+		c.method = http.MethodGet
+	}
+	c.body = instance.Body
+	c.headers = instance.Headers
+	c.username = instance.Username
+	c.password = instance.Password
+	c.bearerToken = instance.BearerToken
+	c.bearerTokenPath = instance.BearerTokenPath
+
+	c.metrics = nil
+	for _, mc := range instance.Metrics {
+		m, err := compileMetric(mc)
+		if err != nil {
+			return err
+		}
+		c.metrics = append(c.metrics, m)
+	}
+
+	tlsConfig, err := instance.tlsConfig()
+	if err != nil {
+		return err
+	}
+	transport := httputils.CreateHTTPTransport()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	timeout := instance.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	c.httpClient = &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(timeout) * time.Second,
+	}
+	return nil
+}
+
+// tlsConfig returns the TLS configuration of the client, nil to keep the
+// default one of the agent
+func (i *instanceConfig) tlsConfig() (*tls.Config, error) {
+	if i.TLSVerify == nil && i.TLSCACert == "" && i.TLSCert == "" && i.TLSKey == "" {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{}
+	if i.TLSVerify != nil {
+		tlsConfig.InsecureSkipVerify = !*i.TLSVerify
+	}
+	if i.TLSCACert != "" {
+		pem, err := ioutil.ReadFile(i.TLSCACert)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read tls_ca_cert: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in tls_ca_cert %s", i.TLSCACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if (i.TLSCert == "") != (i.TLSKey == "") {
+		return nil, fmt.Errorf("tls_cert and tls_key must be set together")
+	}
+	if i.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(i.TLSCert, i.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func compileMetric(mc metricConfig) (*metric, error) {
+	if mc.Name == "" {
+		return nil, fmt.Errorf("metric `name` must not be empty")
+	}
+	if mc.Path == "" {
+		return nil, fmt.Errorf("metric %s: `path` must not be empty", mc.Name)
+	}
+	metricType := mc.Type
+	if metricType == "" {
+		metricType = "gauge"
+	}
+	submit, ok := submitters[metricType]
+	if !ok {
+		return nil, fmt.Errorf("metric %s: unknown type %q, expected gauge, count, monotonic_count or rate", mc.Name, mc.Type)
+	}
+
+	m := &metric{name: mc.Name, submit: submit, valueMap: mc.ValueMap}
+	var err error
+	if mc.Items != "" {
+		if m.items, err = jmespath.Compile(mc.Items); err != nil {
+			return nil, fmt.Errorf("metric %s: invalid items expression %q: %s", mc.Name, mc.Items, err)
+		}
+	}
+	if m.path, err = jmespath.Compile(mc.Path); err != nil {
+		return nil, fmt.Errorf("metric %s: invalid path expression %q: %s", mc.Name, mc.Path, err)
+	}
+	for _, tagName := range sortedKeys(mc.Tags) {
+		tagPath, err := jmespath.Compile(mc.Tags[tagName])
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: invalid expression %q of tag %s: %s", mc.Name, mc.Tags[tagName], tagName, err)
+		}
+		m.tagNames = append(m.tagNames, tagName)
+		m.tagPaths = append(m.tagPaths, tagPath)
+	}
+	return m, nil
+}
+
+// Run polls the endpoint and submits the metrics extracted
+func (c *Check) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+	defer sender.Commit()
+
+	scTags := []string{"url:" + c.url}
+	doc, err := c.fetch()
+	if err != nil {
+		sender.ServiceCheck(canConnectServiceCheck, metrics.ServiceCheckCritical, "", scTags, err.Error())
+		return err
+	}
+	sender.ServiceCheck(canConnectServiceCheck, metrics.ServiceCheckOK, "", scTags, "")
+
+	for _, m := range c.metrics {
+		if err := m.extract(sender, doc); err != nil {
+			c.Warnf("Unable to extract metric %s from %s: %s", m.name, c.url, err)
+		}
+	}
+	return nil
+}
+
+func (c *Check) fetch() (interface{}, error) {
+	var body io.Reader
+	if c.body != "" {
+		body = strings.NewReader(c.body)
+	}
+	req, err := http.NewRequest(c.method, c.url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	switch {
+	case c.username != "":
+		req.SetBasicAuth(c.username, c.password)
+	case c.bearerToken != "" || c.bearerTokenPath != "":
+		token, err := c.getBearerToken()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var doc interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %s", err)
+	}
+	return doc, nil
+}
+
+// getBearerToken returns the bearer token, the file being read on every run
+// to pick up the rotated tokens
+func (c *Check) getBearerToken() (string, error) {
+	if c.bearerTokenPath == "" {
+		return c.bearerToken, nil
+	}
+	token, err := ioutil.ReadFile(c.bearerTokenPath)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the bearer token: %s", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// extract submits the metric from the JSON document, once per item if items
+// is set
+func (m *metric) extract(sender aggregator.Sender, doc interface{}) error {
+	if m.items == nil {
+		return m.extractOne(sender, doc)
+	}
+	result, err := m.items.Search(doc)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	items, ok := result.([]interface{})
+	if !ok {
+		return fmt.Errorf("items expression returned a %T, not an array", result)
+	}
+	for _, item := range items {
+		if err := m.extractOne(sender, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *metric) extractOne(sender aggregator.Sender, data interface{}) error {
+	result, err := m.path.Search(data)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		// the field may be missing until the endpoint has something to report
+		log.Debugf("metric %s: path returned nothing", m.name)
+		return nil
+	}
+	value, err := m.coerce(result)
+	if err != nil {
+		return err
+	}
+
+	tags := make([]string, 0, len(m.tagNames))
+	for i, tagPath := range m.tagPaths {
+		tagValue, err := tagPath.Search(data)
+		if err != nil {
+			return err
+		}
+		if tagValue == nil {
+			continue
+		}
+		tags = append(tags, fmt.Sprintf("%s:%s", m.tagNames[i], tagString(tagValue)))
+	}
+	m.submit(sender, m.name, value, tags)
+	return nil
+}
+
+// coerce converts the value extracted to a float: the numbers, the numeric
+// strings and the booleans are converted, the other strings are looked up in
+// the value_map
+func (m *metric) coerce(v interface{}) (float64, error) {
+	switch value := v.(type) {
+	case float64:
+		return value, nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if mapped, ok := m.valueMap[value]; ok {
+			return mapped, nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0, fmt.Errorf("string %q is neither a number nor in the value_map", value)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("path returned a %T, expected a number, a string or a boolean", v)
+}
+
+func tagString(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func httpJSONFactory() check.Check {
+	return &Check{
+		CheckBase: core.NewCheckBase(httpJSONCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(httpJSONCheckName, httpJSONFactory)
+}
@@ -0,0 +1,215 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package httpjson
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const testDocument = `{
+  "status": "up",
+  "healthy": true,
+  "uptime": "3600",
+  "requests": {"total": 1200, "errors": 3},
+  "queues": [
+    {"name": "orders", "depth": 12, "shard": 1},
+    {"name": "emails", "depth": 0, "shard": 2},
+    {"name": "broken"}
+  ]
+}`
+
+func newTestServer(t *testing.T, check func(r *http.Request) bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil && !check(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, testDocument)
+	}))
+}
+
+func newTestCheck(t *testing.T, config string) *Check {
+	c := httpJSONFactory().(*Check)
+	require.NoError(t, c.Configure([]byte(config), nil, "test"))
+	return c
+}
+
+func TestHTTPJSONMetrics(t *testing.T) {
+	ts := newTestServer(t, nil)
+	defer ts.Close()
+
+	c := newTestCheck(t, fmt.Sprintf(`
+url: %s
+metrics:
+  - name: app.status
+    path: status
+    value_map: {up: 1, down: 0}
+  - name: app.healthy
+    path: healthy
+  - name: app.uptime
+    path: uptime
+  - name: app.requests
+    type: monotonic_count
+    path: requests.total
+  - name: app.queue.depth
+    items: queues
+    path: depth
+    tags:
+      queue: name
+      shard: shard
+`, ts.URL))
+
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+
+	sender.AssertServiceCheck(t, canConnectServiceCheck, metrics.ServiceCheckOK, "", []string{"url:" + ts.URL}, "")
+	sender.AssertMetric(t, "Gauge", "app.status", 1, "", []string{})
+	sender.AssertMetric(t, "Gauge", "app.healthy", 1, "", []string{})
+	sender.AssertMetric(t, "Gauge", "app.uptime", 3600, "", []string{})
+	sender.AssertMetric(t, "MonotonicCount", "app.requests", 1200, "", []string{})
+	sender.AssertMetric(t, "Gauge", "app.queue.depth", 12, "", []string{"queue:orders", "shard:1"})
+	sender.AssertMetric(t, "Gauge", "app.queue.depth", 0, "", []string{"queue:emails", "shard:2"})
+	// the queue without a depth is skipped
+	sender.AssertNumberOfCalls(t, "Gauge", 5)
+}
+
+func TestHTTPJSONUncoercibleValue(t *testing.T) {
+	ts := newTestServer(t, nil)
+	defer ts.Close()
+
+	c := newTestCheck(t, fmt.Sprintf(`
+url: %s
+metrics:
+  - name: app.status
+    path: status
+  - name: app.queues
+    path: queues
+  - name: app.errors
+    path: requests.errors
+`, ts.URL))
+
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+
+	sender.AssertMetric(t, "Gauge", "app.errors", 3, "", []string{})
+	sender.AssertNumberOfCalls(t, "Gauge", 1)
+	assert.Len(t, c.GetWarnings(), 2)
+}
+
+func TestHTTPJSONBasicAuth(t *testing.T) {
+	ts := newTestServer(t, func(r *http.Request) bool {
+		user, password, ok := r.BasicAuth()
+		return ok && user == "admin" && password == "secret"
+	})
+	defer ts.Close()
+
+	c := newTestCheck(t, fmt.Sprintf(`
+url: %s
+username: admin
+password: secret
+metrics:
+  - name: app.errors
+    path: requests.errors
+`, ts.URL))
+
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "app.errors", 3, "", []string{})
+
+	c.password = "wrong"
+	sender.ResetCalls()
+	assert.Error(t, c.Run())
+	sender.AssertServiceCheck(t, canConnectServiceCheck, metrics.ServiceCheckCritical, "", []string{"url:" + ts.URL}, "unexpected status code 401")
+	sender.AssertNotCalled(t, "Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHTTPJSONBearerTokenPath(t *testing.T) {
+	token := "first"
+	ts := newTestServer(t, func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer "+token
+	})
+	defer ts.Close()
+
+	f, err := ioutil.TempFile("", "token")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("first\n")
+	require.NoError(t, err)
+	f.Close()
+
+	c := newTestCheck(t, fmt.Sprintf(`
+url: %s
+bearer_token_path: %s
+metrics:
+  - name: app.errors
+    path: requests.errors
+`, ts.URL, f.Name()))
+
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+
+	// the token file is read again on every run
+	token = "second"
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("second"), 0600))
+	require.NoError(t, c.Run())
+	sender.AssertNumberOfCalls(t, "Gauge", 2)
+}
+
+func TestHTTPJSONTLSVerify(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testDocument)
+	}))
+	defer ts.Close()
+
+	c := newTestCheck(t, fmt.Sprintf(`
+url: %s
+tls_verify: false
+metrics:
+  - name: app.errors
+    path: requests.errors
+`, ts.URL))
+
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "app.errors", 3, "", []string{})
+}
+
+func TestHTTPJSONConfigure(t *testing.T) {
+	for name, config := range map[string]string{
+		"no url":         "metrics: [{name: a, path: b}]",
+		"no metrics":     "url: http://localhost",
+		"unknown type":   "url: http://localhost\nmetrics: [{name: a, path: b, type: histogram}]",
+		"invalid path":   "url: http://localhost\nmetrics: [{name: a, path: 'a[?'}]",
+		"invalid tag":    "url: http://localhost\nmetrics: [{name: a, path: b, tags: {c: 'd[?'}}]",
+		"both auths":     "url: http://localhost\nusername: a\nbearer_token: b\nmetrics: [{name: a, path: b}]",
+		"cert alone":     "url: http://localhost\ntls_cert: /tmp/cert.pem\nmetrics: [{name: a, path: b}]",
+		"missing ca":     "url: http://localhost\ntls_ca_cert: /does/not/exist\nmetrics: [{name: a, path: b}]",
+		"empty metric":   "url: http://localhost\nmetrics: [{path: b}]",
+		"metric no path": "url: http://localhost\nmetrics: [{name: a}]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := httpJSONFactory().(*Check)
+			assert.Error(t, c.Configure([]byte(config), nil, "test"))
+		})
+	}
+}
@@ -6,6 +6,7 @@
 package net
 
 import (
+	"context"
 	"expvar"
 	"fmt"
 	"math"
@@ -20,7 +21,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/clockdrift"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/resolver"
 )
 
 const ntpCheckName = "ntp"
@@ -29,7 +32,7 @@ const defaultMinCollectionInterval = 900 // 15 minutes, to follow pool.ntp.org's
 var (
 	ntpExpVar = expvar.NewFloat("ntpOffset")
 	// for testing purpose
-	ntpQuery = ntp.QueryWithOptions
+	ntpQuery = queryWithResolver
 )
 
 // NTPCheck only has sender and config
@@ -167,6 +170,7 @@ func (c *NTPCheck) Run() error {
 
 		sender.Gauge("ntp.offset", clockOffset, "", nil)
 		ntpExpVar.Set(clockOffset)
+		clockdrift.ReportNTPOffset(time.Duration(clockOffset * float64(time.Second)))
 	}
 
 	sender.ServiceCheck("ntp.in_sync", serviceCheckStatus, "", nil, serviceCheckMessage)
@@ -228,3 +232,27 @@ func ntpFactory() check.Check {
 func init() {
 	core.RegisterCheck(ntpCheckName, ntpFactory)
 }
+
+// queryWithResolver resolves the ntp host with the shared resolver before
+// querying it, so that an unresolvable host doesn't wait for the DNS timeout
+// on every run
+func queryWithResolver(host string, opt ntp.QueryOptions) (*ntp.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), opt.Timeout)
+	defer cancel()
+	ips, err := resolver.LookupIP(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no address found for %s", host)
+	}
+	// prefer IPv4, the most common setup of ntp servers
+	ip := ips[0]
+	for _, candidate := range ips {
+		if candidate.To4() != nil {
+			ip = candidate
+			break
+		}
+	}
+	return ntp.QueryWithOptions(ip.String(), opt)
+}
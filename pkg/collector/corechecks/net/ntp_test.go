@@ -53,7 +53,7 @@ func TestNTPOK(t *testing.T) {
 
 	offset = 21
 	ntpQuery = testNTPQuery
-	defer func() { ntpQuery = ntp.QueryWithOptions }()
+	defer func() { ntpQuery = queryWithResolver }()
 
 	ntpCheck := new(NTPCheck)
 	ntpCheck.Configure(ntpCfg, ntpInitCfg, "test")
@@ -83,7 +83,7 @@ func TestNTPCritical(t *testing.T) {
 
 	offset = 100
 	ntpQuery = testNTPQuery
-	defer func() { ntpQuery = ntp.QueryWithOptions }()
+	defer func() { ntpQuery = queryWithResolver }()
 
 	ntpCheck := new(NTPCheck)
 	ntpCheck.Configure(ntpCfg, ntpInitCfg, "test")
@@ -112,7 +112,7 @@ func TestNTPError(t *testing.T) {
 	var ntpInitCfg = []byte("")
 
 	ntpQuery = testNTPQueryError
-	defer func() { ntpQuery = ntp.QueryWithOptions }()
+	defer func() { ntpQuery = queryWithResolver }()
 
 	ntpCheck := new(NTPCheck)
 	ntpCheck.Configure(ntpCfg, ntpInitCfg, "test")
@@ -140,7 +140,7 @@ func TestNTPInvalid(t *testing.T) {
 	var ntpInitCfg = []byte("")
 
 	ntpQuery = testNTPQueryInvalid
-	defer func() { ntpQuery = ntp.QueryWithOptions }()
+	defer func() { ntpQuery = queryWithResolver }()
 
 	ntpCheck := new(NTPCheck)
 	ntpCheck.Configure(ntpCfg, ntpInitCfg, "test")
@@ -169,7 +169,7 @@ func TestNTPNegativeOffsetCritical(t *testing.T) {
 
 	offset = -100
 	ntpQuery = testNTPQuery
-	defer func() { ntpQuery = ntp.QueryWithOptions }()
+	defer func() { ntpQuery = queryWithResolver }()
 
 	ntpCheck := new(NTPCheck)
 	ntpCheck.Configure(ntpCfg, ntpInitCfg, "test")
@@ -210,7 +210,7 @@ hosts:
 			Stratum:     15,
 		}, nil
 	}
-	defer func() { ntpQuery = ntp.QueryWithOptions }()
+	defer func() { ntpQuery = queryWithResolver }()
 
 	ntpCheck := new(NTPCheck)
 	ntpCheck.Configure(ntpCfg, ntpInitCfg, "test")
@@ -251,7 +251,7 @@ hosts:
 			Stratum:     15,
 		}, nil
 	}
-	defer func() { ntpQuery = ntp.QueryWithOptions }()
+	defer func() { ntpQuery = queryWithResolver }()
 
 	ntpCheck := new(NTPCheck)
 	ntpCheck.Configure(ntpCfg, ntpInitCfg, "test")
@@ -351,7 +351,7 @@ func TestNTPPortConfig(t *testing.T) {
 		detectedPorts = append(detectedPorts, opt.Port)
 		return testNTPQuery(host, opt)
 	}
-	defer func() { ntpQuery = ntp.QueryWithOptions }()
+	defer func() { ntpQuery = queryWithResolver }()
 
 	ntpCheck := new(NTPCheck)
 	const expectedPort = 42
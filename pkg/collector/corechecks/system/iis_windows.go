@@ -0,0 +1,440 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+// +build windows
+
+package system
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/winutil/pdhutil"
+)
+
+const (
+	iisCheckName = "windows_iis"
+
+	defaultAppPool  = "DefaultAppPool"
+	pdhTotal        = "_Total"
+	pdhGlobal       = "_Global_"
+	clrMemoryClass  = ".NET CLR Memory"
+	clrProcessID    = "Process ID"
+	workerProcClass = "W3SVC_W3WP"
+)
+
+// iisCounter is a counter collected on every instance of its class, the
+// instances being discovered on each run
+type iisCounter struct {
+	class   string
+	counter string
+	metric  string
+	set     *pdhutil.PdhMultiInstanceCounterSet
+}
+
+// values returns the values of the counter by instance, nil while its class
+// isn't there, e.g. when IIS or the .NET framework isn't installed
+func (c *iisCounter) values() map[string]float64 {
+	if c.set == nil {
+		set, err := pdhutil.GetMultiInstanceCounter(c.class, c.counter, nil, nil)
+		if err != nil {
+			log.Debugf("counter %s of %s not available yet: %v", c.counter, c.class, err)
+			return nil
+		}
+		c.set = set
+	}
+	vals, err := c.set.GetAllValues()
+	if err != nil {
+		log.Debugf("Error getting the values of %s of %s: %v", c.counter, c.class, err)
+		return nil
+	}
+	return vals
+}
+
+func newIISCounters(class string, metrics map[string]string) []*iisCounter {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	counters := make([]*iisCounter, 0, len(names))
+	for _, name := range names {
+		counters = append(counters, &iisCounter{class: class, counter: name, metric: metrics[name]})
+	}
+	return counters
+}
+
+type iisInstanceConfig struct {
+	ApplicationHostConfig string `yaml:"application_host_config"`
+	AppPoolBlacklist      string `yaml:"app_pool_blacklist_re"`
+	SiteBlacklist         string `yaml:"site_blacklist_re"`
+	CollectDotNetCLR      *bool  `yaml:"collect_dotnet_clr"`
+}
+
+// IISCheck discovers the IIS application pools and sites, and the .NET CLR
+// instances, from the performance counters. The pools and sites are tagged
+// from the site bindings of the IIS configuration.
+type IISCheck struct {
+	core.CheckBase
+	appHostConfig    string
+	appPoolBlacklist *regexp.Regexp
+	siteBlacklist    *regexp.Regexp
+
+	appPools      []*iisCounter
+	sites         []*iisCounter
+	workers       []*iisCounter
+	clr           []*iisCounter
+	clrProcessIDs *iisCounter
+
+	topology      *iisTopology
+	topologyMTime time.Time
+}
+
+// Configure the IIS check
+func (c *IISCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	if err := c.CommonConfigure(data, source); err != nil {
+		return err
+	}
+
+	var conf iisInstanceConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+
+	var err error
+	if conf.AppPoolBlacklist != "" {
+		if c.appPoolBlacklist, err = regexp.Compile(conf.AppPoolBlacklist); err != nil {
+			return fmt.Errorf("invalid app_pool_blacklist_re: %s", err)
+		}
+	}
+	if conf.SiteBlacklist != "" {
+		if c.siteBlacklist, err = regexp.Compile(conf.SiteBlacklist); err != nil {
+			return fmt.Errorf("invalid site_blacklist_re: %s", err)
+		}
+	}
+	c.appHostConfig = conf.ApplicationHostConfig
+	if c.appHostConfig == "" {
+		c.appHostConfig = filepath.Join(os.Getenv("windir"), "System32", "inetsrv", "config", "applicationHost.config")
+	}
+
+	c.appPools = newIISCounters("APP_POOL_WAS", map[string]string{
+		"Current Application Pool State":  "iis.app_pool.state",
+		"Current Application Pool Uptime": "iis.app_pool.uptime",
+		"Current Worker Processes":        "iis.app_pool.worker_processes",
+		"Total Application Pool Recycles": "iis.app_pool.recycles",
+		"Total Worker Process Failures":   "iis.app_pool.worker_process_failures",
+	})
+	c.workers = newIISCounters(workerProcClass, map[string]string{
+		"Active Requests": "iis.app_pool.active_requests",
+		"Requests / Sec":  "iis.app_pool.requests",
+	})
+	c.sites = newIISCounters("Web Service", map[string]string{
+		"Current Connections":  "iis.site.connections",
+		"Get Requests/sec":     "iis.site.get_requests",
+		"Post Requests/sec":    "iis.site.post_requests",
+		"Bytes Sent/sec":       "iis.site.bytes_sent",
+		"Bytes Received/sec":   "iis.site.bytes_received",
+		"Not Found Errors/sec": "iis.site.not_found_errors",
+		"Service Uptime":       "iis.site.uptime",
+	})
+	if conf.CollectDotNetCLR == nil || *conf.CollectDotNetCLR {
+		c.clr = append(newIISCounters(clrMemoryClass, map[string]string{
+			"# Bytes in all Heaps": "dotnet.clr.heap_bytes",
+			"% Time in GC":         "dotnet.clr.gc_time_pct",
+		}), newIISCounters(".NET CLR Exceptions", map[string]string{
+			"# of Exceps Thrown / sec": "dotnet.clr.exceptions",
+		})...)
+		c.clrProcessIDs = &iisCounter{class: clrMemoryClass, counter: clrProcessID}
+	}
+	return nil
+}
+
+// Run executes the check
+func (c *IISCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+	c.refreshTopology()
+
+	// the worker process instances are named <pid>_<app pool>, a pool may
+	// have several worker processes
+	workerPools := make(map[int]string)
+	for _, counter := range c.workers {
+		perPool := make(map[string]float64)
+		for inst, val := range counter.values() {
+			pid, pool, ok := parseWorkerInstance(inst)
+			if !ok || c.skipAppPool(pool) {
+				continue
+			}
+			workerPools[pid] = pool
+			perPool[pool] += val
+		}
+		for pool, val := range perPool {
+			sender.Gauge(counter.metric, val, "", c.topology.appPoolTags(pool))
+		}
+	}
+
+	for _, counter := range c.appPools {
+		for pool, val := range counter.values() {
+			if pool == pdhTotal || c.skipAppPool(pool) {
+				continue
+			}
+			sender.Gauge(counter.metric, val, "", c.topology.appPoolTags(pool))
+		}
+	}
+
+	for _, counter := range c.sites {
+		for site, val := range counter.values() {
+			if site == pdhTotal || (c.siteBlacklist != nil && c.siteBlacklist.MatchString(site)) {
+				continue
+			}
+			sender.Gauge(counter.metric, val, "", c.topology.siteTags(site))
+		}
+	}
+
+	if c.clrProcessIDs != nil {
+		pids := c.clrProcessIDs.values()
+		for _, counter := range c.clr {
+			for inst, val := range counter.values() {
+				if inst == pdhGlobal {
+					continue
+				}
+				tags := []string{"process_name:" + clrProcessName(inst)}
+				if pool, ok := workerPools[int(pids[inst])]; ok {
+					tags = append(tags, c.topology.appPoolTags(pool)...)
+				}
+				sender.Gauge(counter.metric, val, "", tags)
+			}
+		}
+	}
+
+	sender.Commit()
+	return nil
+}
+
+func (c *IISCheck) skipAppPool(pool string) bool {
+	return c.appPoolBlacklist != nil && c.appPoolBlacklist.MatchString(pool)
+}
+
+// refreshTopology reloads the sites of the IIS configuration when it changed
+func (c *IISCheck) refreshTopology() {
+	info, err := os.Stat(c.appHostConfig)
+	if err != nil {
+		if c.topology == nil || !c.topologyMTime.IsZero() {
+			log.Debugf("IIS configuration %s not readable, the pools and sites won't be tagged with their bindings: %s", c.appHostConfig, err)
+		}
+		c.topology = &iisTopology{}
+		c.topologyMTime = time.Time{}
+		return
+	}
+	if c.topology != nil && info.ModTime().Equal(c.topologyMTime) {
+		return
+	}
+	topology, err := loadIISTopology(c.appHostConfig)
+	if err != nil {
+		log.Warnf("Unable to read the IIS configuration %s: %s", c.appHostConfig, err)
+		if c.topology == nil {
+			c.topology = &iisTopology{}
+		}
+		return
+	}
+	c.topology = topology
+	c.topologyMTime = info.ModTime()
+}
+
+// parseWorkerInstance splits a W3SVC_W3WP instance into its pid and app pool
+func parseWorkerInstance(inst string) (int, string, bool) {
+	parts := strings.SplitN(inst, "_", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return 0, "", false
+	}
+	pid, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", false
+	}
+	return pid, parts[1], true
+}
+
+// clrProcessName strips the #<n> suffix of the instances of the processes
+// sharing the same name, e.g. w3wp#2
+func clrProcessName(inst string) string {
+	if i := strings.LastIndex(inst, "#"); i > 0 {
+		return inst[:i]
+	}
+	return inst
+}
+
+// iisTopology holds the sites of the IIS configuration, and the app pools
+// serving their applications
+type iisTopology struct {
+	sites map[string]*iisSite
+	pools map[string][]string // app pool to the sites it serves
+}
+
+type iisSite struct {
+	bindingTags []string
+	pools       []string
+}
+
+type iisApplicationHost struct {
+	Sites struct {
+		Sites               []iisSiteConfig       `xml:"site"`
+		ApplicationDefaults iisApplicationDefault `xml:"applicationDefaults"`
+	} `xml:"system.applicationHost>sites"`
+}
+
+type iisApplicationDefault struct {
+	AppPool string `xml:"applicationPool,attr"`
+}
+
+type iisSiteConfig struct {
+	Name                string                `xml:"name,attr"`
+	ApplicationDefaults iisApplicationDefault `xml:"applicationDefaults"`
+	Applications        []struct {
+		Path    string `xml:"path,attr"`
+		AppPool string `xml:"applicationPool,attr"`
+	} `xml:"application"`
+	Bindings []struct {
+		Protocol string `xml:"protocol,attr"`
+		Info     string `xml:"bindingInformation,attr"`
+	} `xml:"bindings>binding"`
+}
+
+func loadIISTopology(path string) (*iisTopology, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseIISTopology(raw)
+}
+
+func parseIISTopology(raw []byte) (*iisTopology, error) {
+	var appHost iisApplicationHost
+	if err := xml.Unmarshal(raw, &appHost); err != nil {
+		return nil, err
+	}
+
+	defaultPool := appHost.Sites.ApplicationDefaults.AppPool
+	if defaultPool == "" {
+		defaultPool = defaultAppPool
+	}
+
+	t := &iisTopology{
+		sites: make(map[string]*iisSite),
+		pools: make(map[string][]string),
+	}
+	for _, sc := range appHost.Sites.Sites {
+		site := &iisSite{}
+
+		siteDefaultPool := sc.ApplicationDefaults.AppPool
+		if siteDefaultPool == "" {
+			siteDefaultPool = defaultPool
+		}
+		pools := make(map[string]bool)
+		for _, app := range sc.Applications {
+			pool := app.AppPool
+			if pool == "" {
+				pool = siteDefaultPool
+			}
+			pools[pool] = true
+		}
+		for pool := range pools {
+			site.pools = append(site.pools, pool)
+			t.pools[pool] = append(t.pools[pool], sc.Name)
+		}
+		sort.Strings(site.pools)
+
+		seen := make(map[string]bool)
+		for _, b := range sc.Bindings {
+			for _, tag := range bindingTags(b.Protocol, b.Info) {
+				if !seen[tag] {
+					seen[tag] = true
+					site.bindingTags = append(site.bindingTags, tag)
+				}
+			}
+		}
+		t.sites[sc.Name] = site
+	}
+	for pool := range t.pools {
+		sort.Strings(t.pools[pool])
+	}
+	return t, nil
+}
+
+// bindingTags returns the tags of a site binding, the HTTP bindings being
+// given as <ip>:<port>:<host header>, e.g. *:443:www.example.com
+func bindingTags(protocol, info string) []string {
+	if protocol != "http" && protocol != "https" {
+		return []string{"binding:" + protocol}
+	}
+	hostIdx := strings.LastIndex(info, ":")
+	if hostIdx < 0 {
+		return []string{"binding:" + protocol}
+	}
+	host := info[hostIdx+1:]
+	port := info[:hostIdx]
+	if i := strings.LastIndex(port, ":"); i >= 0 {
+		port = port[i+1:]
+	}
+	tags := []string{fmt.Sprintf("binding:%s:%s", protocol, port)}
+	if host != "" {
+		tags = append(tags, "host_header:"+strings.ToLower(host))
+	}
+	return tags
+}
+
+// siteTags returns the tags of a site, its app pools and bindings
+func (t *iisTopology) siteTags(name string) []string {
+	tags := []string{"site:" + name}
+	if site, ok := t.sites[name]; ok {
+		for _, pool := range site.pools {
+			tags = append(tags, "app_pool:"+pool)
+		}
+		tags = append(tags, site.bindingTags...)
+	}
+	return tags
+}
+
+// appPoolTags returns the tags of an app pool, the sites it serves and their
+// bindings
+func (t *iisTopology) appPoolTags(pool string) []string {
+	tags := []string{"app_pool:" + pool}
+	seen := make(map[string]bool)
+	for _, name := range t.pools[pool] {
+		tags = append(tags, "site:"+name)
+		for _, tag := range t.sites[name].bindingTags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+func iisFactory() check.Check {
+	return &IISCheck{
+		CheckBase: core.NewCheckBase(iisCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(iisCheckName, iisFactory)
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+// +build windows
+
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	pdhtest "github.com/DataDog/datadog-agent/pkg/util/winutil/pdhutil"
+)
+
+const iisTestConfig = `
+application_host_config: testfiles\applicationHost.config
+app_pool_blacklist_re: ^MSExchangeOAB
+`
+
+func TestIISTopology(t *testing.T) {
+	topology, err := loadIISTopology("testfiles\\applicationHost.config")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"site:Default Web Site",
+		"app_pool:DefaultAppPool",
+		"app_pool:MSExchangeOWAAppPool",
+		"binding:http:80",
+		"binding:https:443",
+		"host_header:mail.example.com",
+		"binding:net.tcp",
+	}, topology.siteTags("Default Web Site"))
+	assert.Equal(t, []string{
+		"app_pool:MSExchangeOWAAppPool",
+		"site:Default Web Site",
+		"binding:http:80",
+		"binding:https:443",
+		"host_header:mail.example.com",
+		"binding:net.tcp",
+		"site:Exchange Back End",
+		"binding:https:444",
+	}, topology.appPoolTags("MSExchangeOWAAppPool"))
+	assert.Equal(t, []string{"app_pool:unknown"}, topology.appPoolTags("unknown"))
+}
+
+func TestIISCheckWindows(t *testing.T) {
+	pdhtest.SetupTesting("testfiles\\counter_indexes_en-us.txt", "testfiles\\allcounters_en-us.txt")
+
+	pdhtest.SetQueryReturnValue("\\\\.\\APP_POOL_WAS(_Total)\\Current Application Pool State", 3)
+	pdhtest.SetQueryReturnValue("\\\\.\\APP_POOL_WAS(DefaultAppPool)\\Current Application Pool State", 3)
+	pdhtest.SetQueryReturnValue("\\\\.\\APP_POOL_WAS(MSExchangeOABAppPool)\\Current Application Pool State", 3)
+	pdhtest.SetQueryReturnValue("\\\\.\\W3SVC_W3WP(7648_MSExchangeOWAAppPool)\\Active Requests", 4)
+	pdhtest.SetQueryReturnValue("\\\\.\\W3SVC_W3WP(9808_MSExchangeOABAppPool)\\Active Requests", 5)
+	pdhtest.SetQueryReturnValue("\\\\.\\Web Service(_Total)\\Current Connections", 12)
+	pdhtest.SetQueryReturnValue("\\\\.\\Web Service(Exchange Back End)\\Current Connections", 7)
+	pdhtest.SetQueryReturnValue("\\\\.\\.NET CLR Memory(_Global_)\\# Bytes in all Heaps", 2048)
+	pdhtest.SetQueryReturnValue("\\\\.\\.NET CLR Memory(w3wp)\\# Bytes in all Heaps", 1024)
+	pdhtest.SetQueryReturnValue("\\\\.\\.NET CLR Memory(w3wp)\\Process ID", 7648)
+	pdhtest.SetQueryReturnValue("\\\\.\\.NET CLR Memory(Ec2Config)\\# Bytes in all Heaps", 512)
+
+	iisCheck := iisFactory().(*IISCheck)
+	require.NoError(t, iisCheck.Configure(integration.Data(iisTestConfig), nil, "test"))
+
+	mock := mocksender.NewMockSender(iisCheck.ID())
+
+	owaTags := []string{
+		"app_pool:MSExchangeOWAAppPool",
+		"site:Default Web Site",
+		"binding:http:80",
+		"binding:https:443",
+		"host_header:mail.example.com",
+		"binding:net.tcp",
+		"site:Exchange Back End",
+		"binding:https:444",
+	}
+	mock.On("Gauge", "iis.app_pool.state", 3.0, "", []string{
+		"app_pool:DefaultAppPool",
+		"site:Default Web Site",
+		"binding:http:80",
+		"binding:https:443",
+		"host_header:mail.example.com",
+		"binding:net.tcp",
+	}).Return().Times(1)
+	mock.On("Gauge", "iis.app_pool.active_requests", 4.0, "", owaTags).Return().Times(1)
+	mock.On("Gauge", "iis.site.connections", 7.0, "", []string{
+		"site:Exchange Back End",
+		"app_pool:MSExchangeOWAAppPool",
+		"binding:https:444",
+	}).Return().Times(1)
+	mock.On("Gauge", "dotnet.clr.heap_bytes", 1024.0, "", append([]string{"process_name:w3wp"}, owaTags...)).Return().Times(1)
+	mock.On("Gauge", "dotnet.clr.heap_bytes", 512.0, "", []string{"process_name:Ec2Config"}).Return().Times(1)
+	mock.On("Commit").Return().Times(1)
+	iisCheck.Run()
+
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, "Gauge", 5)
+	mock.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestParseWorkerInstance(t *testing.T) {
+	pid, pool, ok := parseWorkerInstance("7648_MSExchange_OWA")
+	assert.True(t, ok)
+	assert.Equal(t, 7648, pid)
+	assert.Equal(t, "MSExchange_OWA", pool)
+
+	_, _, ok = parseWorkerInstance(pdhTotal)
+	assert.False(t, ok)
+
+	assert.Equal(t, "w3wp", clrProcessName("w3wp#2"))
+	assert.Equal(t, "w3wp", clrProcessName("w3wp"))
+}
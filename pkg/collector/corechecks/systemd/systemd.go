@@ -9,6 +9,7 @@ package systemd
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -119,13 +120,15 @@ type SystemdCheck struct {
 type systemdInstanceConfig struct {
 	PrivateSocket string   `yaml:"private_socket"`
 	UnitNames     []string `yaml:"unit_names"`
+	UnitRegexes   []string `yaml:"unit_regexes"`
 }
 
 type systemdInitConfig struct{}
 
 type systemdConfig struct {
-	instance systemdInstanceConfig
-	initConf systemdInitConfig
+	instance    systemdInstanceConfig
+	initConf    systemdInitConfig
+	unitRegexes []*regexp.Regexp
 }
 
 type systemdStats interface {
@@ -446,6 +449,11 @@ func (c *SystemdCheck) isMonitored(unitName string) bool {
 			return true
 		}
 	}
+	for _, pattern := range c.config.unitRegexes {
+		if pattern.MatchString(unitName) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -464,8 +472,17 @@ func (c *SystemdCheck) Configure(rawInstance integration.Data, rawInitConfig int
 		return err
 	}
 
-	if len(c.config.instance.UnitNames) == 0 {
-		return fmt.Errorf("instance config `unit_names` must not be empty")
+	if len(c.config.instance.UnitNames) == 0 && len(c.config.instance.UnitRegexes) == 0 {
+		return fmt.Errorf("instance config `unit_names` or `unit_regexes` must not be empty")
+	}
+
+	c.config.unitRegexes = nil
+	for _, pattern := range c.config.instance.UnitRegexes {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid unit regex %q: %v", pattern, err)
+		}
+		c.config.unitRegexes = append(c.config.unitRegexes, re)
 	}
 
 	return nil
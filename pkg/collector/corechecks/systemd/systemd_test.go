@@ -94,10 +94,20 @@ func TestMissingUnitNamesShouldRaiseError(t *testing.T) {
 	check := SystemdCheck{}
 	err := check.Configure([]byte(``), []byte(``), "test")
 
-	expectedErrorMsg := "instance config `unit_names` must not be empty"
+	expectedErrorMsg := "instance config `unit_names` or `unit_regexes` must not be empty"
 	assert.EqualError(t, err, expectedErrorMsg)
 }
 
+func TestInvalidUnitRegexShouldRaiseError(t *testing.T) {
+	check := SystemdCheck{}
+	rawInstanceConfig := []byte(`
+unit_regexes:
+ - docker-[a-f0-9.scope
+`)
+	err := check.Configure(rawInstanceConfig, []byte(``), "test")
+	assert.Error(t, err)
+}
+
 func TestPrivateSocketConnection(t *testing.T) {
 	stats := &mockSystemdStats{}
 	stats.On("PrivateSocketConnection", mock.Anything).Return(&dbus.Conn{}, nil)
@@ -675,6 +685,35 @@ unit_names:
 	}
 }
 
+func TestIsMonitoredWithRegexes(t *testing.T) {
+	rawInstanceConfig := []byte(`
+unit_names:
+  - unit1.service
+unit_regexes:
+  - ^docker-[a-f0-9]+\.scope$
+  - ^kube.*\.service$
+`)
+
+	check := SystemdCheck{}
+	assert.Nil(t, check.Configure(rawInstanceConfig, nil, "test"))
+
+	data := []struct {
+		unitName              string
+		expectedToBeMonitored bool
+	}{
+		{"unit1.service", true},
+		{"docker-3f2a.scope", true},
+		{"kubelet.service", true},
+		{"docker.service", false},
+		{"kubelet.socket", false},
+	}
+	for _, d := range data {
+		t.Run(fmt.Sprintf("check.isMonitored('%s') expected to be %v", d.unitName, d.expectedToBeMonitored), func(t *testing.T) {
+			assert.Equal(t, d.expectedToBeMonitored, check.isMonitored(d.unitName))
+		})
+	}
+}
+
 func TestIsMonitoredEmptyConfigShouldNone(t *testing.T) {
 	rawInstanceConfig := []byte(``)
 	check := SystemdCheck{}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build vsphere
+
+package vsphere
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+const (
+	defaultCollectionTimeout        = 30
+	defaultRefreshInfrastructureSec = 300
+)
+
+type vsphereInstanceConfig struct {
+	Host                     string   `yaml:"host"`
+	Username                 string   `yaml:"username"`
+	Password                 string   `yaml:"password"`
+	SSLVerify                *bool    `yaml:"ssl_verify"`
+	SSLCAPath                string   `yaml:"ssl_capath"`
+	CollectionTimeout        int      `yaml:"collection_timeout"`
+	RefreshInfrastructureSec int      `yaml:"refresh_infrastructure_interval"`
+	Clusters                 []string `yaml:"clusters"`
+	CollectVMs               *bool    `yaml:"collect_vms"`
+}
+
+type vsphereConfig struct {
+	host                  string
+	username              string
+	password              string
+	insecure              bool
+	caPath                string
+	collectionTimeout     time.Duration
+	refreshInfrastructure time.Duration
+	clusters              map[string]bool
+	collectVMs            bool
+}
+
+func parseConfig(rawInstance integration.Data) (*vsphereConfig, error) {
+	instance := vsphereInstanceConfig{}
+	if err := yaml.Unmarshal(rawInstance, &instance); err != nil {
+		return nil, err
+	}
+
+	if instance.Host == "" {
+		return nil, fmt.Errorf("instance config `host` must not be empty")
+	}
+	if instance.Username == "" || instance.Password == "" {
+		return nil, fmt.Errorf("instance config `username` and `password` must be set")
+	}
+
+	conf := &vsphereConfig{
+		host:                  instance.Host,
+		username:              instance.Username,
+		password:              instance.Password,
+		insecure:              instance.SSLVerify != nil && !*instance.SSLVerify,
+		caPath:                instance.SSLCAPath,
+		collectionTimeout:     defaultCollectionTimeout * time.Second,
+		refreshInfrastructure: defaultRefreshInfrastructureSec * time.Second,
+		collectVMs:            instance.CollectVMs == nil || *instance.CollectVMs,
+	}
+	if instance.CollectionTimeout > 0 {
+		conf.collectionTimeout = time.Duration(instance.CollectionTimeout) * time.Second
+	}
+	if instance.RefreshInfrastructureSec > 0 {
+		conf.refreshInfrastructure = time.Duration(instance.RefreshInfrastructureSec) * time.Second
+	}
+	if len(instance.Clusters) > 0 {
+		conf.clusters = make(map[string]bool, len(instance.Clusters))
+		for _, cluster := range instance.Clusters {
+			conf.clusters[cluster] = true
+		}
+	}
+
+	return conf, nil
+}
+
+// isClusterMonitored returns true if no cluster filter is set or if the
+// cluster is part of it
+func (c *vsphereConfig) isClusterMonitored(name string) bool {
+	return c.clusters == nil || c.clusters[name]
+}
@@ -0,0 +1,16 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+/*
+Package vsphere provides a core check collecting metrics from vCenter
+
+The check keeps one authenticated session per vCenter and reuses it across
+runs. The infrastructure (clusters and their hosts) is cached and refreshed
+periodically, while the properties of the hosts and VMs are updated on every
+run by a property collector, within the collection timeout. Hosts missing from
+the inventory are reported through a service check and skipped, the metrics of
+the others are still submitted.
+*/
+package vsphere
@@ -0,0 +1,303 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build vsphere
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Only these properties are retrieved, to keep the payloads small
+var (
+	clusterProperties = []string{"name", "host"}
+	hostProperties    = []string{"name", "vm", "summary.quickStats", "summary.hardware", "runtime.connectionState"}
+	vmProperties      = []string{"name", "summary.quickStats", "summary.config", "summary.guest", "runtime.powerState"}
+)
+
+type hostRef struct {
+	ref     types.ManagedObjectReference
+	name    string
+	cluster string
+}
+
+type clusterInventory struct {
+	name  string
+	hosts []hostRef
+}
+
+type vmStats struct {
+	name          string
+	guestHostname string
+	poweredOn     bool
+	numCPU        int32
+	cpuUsageMhz   int32
+	guestMemoryMB int32
+	hostMemoryMB  int32
+	uptimeSeconds int32
+}
+
+type hostStats struct {
+	host          hostRef
+	connected     bool
+	cpuUsageMhz   int32
+	cpuTotalMhz   int64
+	memoryUsageMB int32
+	memoryTotalMB int64
+	uptimeSeconds int32
+	vms           []vmStats
+}
+
+// inventoryClient abstracts the vCenter API calls, to allow mocking in tests
+type inventoryClient interface {
+	listClusters(ctx context.Context) ([]clusterInventory, error)
+	// update fetches the properties of the hosts and VMs that changed since the last update
+	update(ctx context.Context) error
+	collectHost(host hostRef, collectVMs bool) (*hostStats, error)
+}
+
+// govmomiInventory keeps the properties of the hosts and VMs of a vCenter
+// session up to date with a dedicated property collector: the first update
+// gets all of them, the next ones only what changed, instead of reading the
+// whole inventory on every run.
+type govmomiInventory struct {
+	client     *govmomi.Client
+	collectVMs bool
+
+	// collector and its filter are created on the first update
+	collector *property.Collector
+	version   string
+
+	mux   sync.RWMutex
+	hosts map[types.ManagedObjectReference]*mo.HostSystem
+	vms   map[types.ManagedObjectReference]*mo.VirtualMachine
+}
+
+func newGovmomiInventory(client *govmomi.Client, collectVMs bool) *govmomiInventory {
+	return &govmomiInventory{
+		client:     client,
+		collectVMs: collectVMs,
+		hosts:      make(map[types.ManagedObjectReference]*mo.HostSystem),
+		vms:        make(map[types.ManagedObjectReference]*mo.VirtualMachine),
+	}
+}
+
+func (g *govmomiInventory) listClusters(ctx context.Context) ([]clusterInventory, error) {
+	manager := view.NewManager(g.client.Client)
+	containerView, err := manager.CreateContainerView(ctx, g.client.ServiceContent.RootFolder, []string{"ClusterComputeResource"}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer containerView.Destroy(ctx)
+
+	var clusters []mo.ClusterComputeResource
+	if err := containerView.Retrieve(ctx, []string{"ClusterComputeResource"}, clusterProperties, &clusters); err != nil {
+		return nil, err
+	}
+	if len(clusters) == 0 {
+		return nil, nil
+	}
+
+	inventory := make([]clusterInventory, 0, len(clusters))
+	collector := property.DefaultCollector(g.client.Client)
+	for _, cluster := range clusters {
+		if len(cluster.Host) == 0 {
+			continue
+		}
+		var hosts []mo.HostSystem
+		if err := collector.Retrieve(ctx, cluster.Host, []string{"name"}, &hosts); err != nil {
+			return nil, err
+		}
+		c := clusterInventory{name: cluster.Name}
+		for _, host := range hosts {
+			c.hosts = append(c.hosts, hostRef{ref: host.Reference(), name: host.Name, cluster: cluster.Name})
+		}
+		inventory = append(inventory, c)
+	}
+	return inventory, nil
+}
+
+// createFilter creates the property collector of the inventory, watching the
+// properties of all the hosts of the vCenter, and of the VMs when collected
+func (g *govmomiInventory) createFilter(ctx context.Context) error {
+	collector, err := property.DefaultCollector(g.client.Client).Create(ctx)
+	if err != nil {
+		return err
+	}
+
+	kinds := []string{"HostSystem"}
+	propSet := []types.PropertySpec{{Type: "HostSystem", PathSet: hostProperties}}
+	if g.collectVMs {
+		kinds = append(kinds, "VirtualMachine")
+		propSet = append(propSet, types.PropertySpec{Type: "VirtualMachine", PathSet: vmProperties})
+	}
+
+	manager := view.NewManager(g.client.Client)
+	containerView, err := manager.CreateContainerView(ctx, g.client.ServiceContent.RootFolder, kinds, true)
+	if err != nil {
+		collector.Destroy(ctx)
+		return err
+	}
+
+	// The view is kept for the lifetime of the collector, both are destroyed with the session
+	err = collector.CreateFilter(ctx, types.CreateFilter{
+		Spec: types.PropertyFilterSpec{
+			ObjectSet: []types.ObjectSpec{{
+				Obj:  containerView.Reference(),
+				Skip: types.NewBool(true),
+				SelectSet: []types.BaseSelectionSpec{
+					&types.TraversalSpec{Type: "ContainerView", Path: "view"},
+				},
+			}},
+			PropSet: propSet,
+		},
+	})
+	if err != nil {
+		containerView.Destroy(ctx)
+		collector.Destroy(ctx)
+		return err
+	}
+
+	g.collector = collector
+	return nil
+}
+
+func (g *govmomiInventory) update(ctx context.Context) error {
+	if g.collector == nil {
+		if err := g.createFilter(ctx); err != nil {
+			return err
+		}
+	}
+
+	for {
+		// MaxWaitSeconds 0 returns the pending changes right away, or none
+		res, err := methods.WaitForUpdatesEx(ctx, g.client.Client, &types.WaitForUpdatesEx{
+			This:    g.collector.Reference(),
+			Version: g.version,
+			Options: &types.WaitOptions{MaxWaitSeconds: types.NewInt32(0)},
+		})
+		if err != nil {
+			return err
+		}
+		set := res.Returnval
+		if set == nil {
+			return nil
+		}
+
+		g.apply(set)
+		g.version = set.Version
+		if set.Truncated == nil || !*set.Truncated {
+			return nil
+		}
+	}
+}
+
+// apply merges the changes of an update into the cached hosts and VMs
+func (g *govmomiInventory) apply(set *types.UpdateSet) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	for _, filterUpdate := range set.FilterSet {
+		for _, objectUpdate := range filterUpdate.ObjectSet {
+			ref := objectUpdate.Obj
+			switch ref.Type {
+			case "HostSystem":
+				if objectUpdate.Kind == types.ObjectUpdateKindLeave {
+					delete(g.hosts, ref)
+					continue
+				}
+				host, found := g.hosts[ref]
+				if !found {
+					host = &mo.HostSystem{}
+					host.Self = ref
+					g.hosts[ref] = host
+				}
+				mo.ApplyPropertyChange(host, assignedChanges(objectUpdate.ChangeSet))
+			case "VirtualMachine":
+				if objectUpdate.Kind == types.ObjectUpdateKindLeave {
+					delete(g.vms, ref)
+					continue
+				}
+				vm, found := g.vms[ref]
+				if !found {
+					vm = &mo.VirtualMachine{}
+					vm.Self = ref
+					g.vms[ref] = vm
+				}
+				mo.ApplyPropertyChange(vm, assignedChanges(objectUpdate.ChangeSet))
+			}
+		}
+	}
+}
+
+// assignedChanges filters out the changes without a value, e.g. the removed
+// properties, which the previous value is kept for
+func assignedChanges(changes []types.PropertyChange) []types.PropertyChange {
+	assigned := changes[:0]
+	for _, change := range changes {
+		if change.Val != nil {
+			assigned = append(assigned, change)
+		}
+	}
+	return assigned
+}
+
+// collectHost returns the stats of the host from the last update
+func (g *govmomiInventory) collectHost(host hostRef, collectVMs bool) (*hostStats, error) {
+	g.mux.RLock()
+	defer g.mux.RUnlock()
+
+	hostSystem, found := g.hosts[host.ref]
+	if !found {
+		return nil, fmt.Errorf("host %s is not in the vCenter inventory", host.name)
+	}
+
+	stats := &hostStats{
+		host:          host,
+		connected:     hostSystem.Runtime.ConnectionState == types.HostSystemConnectionStateConnected,
+		cpuUsageMhz:   hostSystem.Summary.QuickStats.OverallCpuUsage,
+		memoryUsageMB: hostSystem.Summary.QuickStats.OverallMemoryUsage,
+		uptimeSeconds: hostSystem.Summary.QuickStats.Uptime,
+	}
+	if hw := hostSystem.Summary.Hardware; hw != nil {
+		stats.cpuTotalMhz = int64(hw.CpuMhz) * int64(hw.NumCpuCores)
+		stats.memoryTotalMB = hw.MemorySize / (1024 * 1024)
+	}
+
+	if !collectVMs {
+		return stats, nil
+	}
+
+	for _, ref := range hostSystem.Vm {
+		vm, found := g.vms[ref]
+		if !found {
+			continue
+		}
+		s := vmStats{
+			name:          vm.Name,
+			poweredOn:     vm.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn,
+			numCPU:        vm.Summary.Config.NumCpu,
+			cpuUsageMhz:   vm.Summary.QuickStats.OverallCpuUsage,
+			guestMemoryMB: vm.Summary.QuickStats.GuestMemoryUsage,
+			hostMemoryMB:  vm.Summary.QuickStats.HostMemoryUsage,
+			uptimeSeconds: vm.Summary.QuickStats.UptimeSeconds,
+		}
+		if vm.Summary.Guest != nil {
+			s.guestHostname = vm.Summary.Guest.HostName
+		}
+		stats.vms = append(stats.vms, s)
+	}
+	return stats, nil
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build vsphere
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// sessionPool keeps one authenticated client per vCenter and user, shared
+// by all the instances of the check, so that we don't log in on every run.
+type sessionPool struct {
+	sync.Mutex
+	clients map[string]*govmomi.Client
+}
+
+var globalSessionPool = &sessionPool{
+	clients: make(map[string]*govmomi.Client),
+}
+
+func sessionKey(conf *vsphereConfig) string {
+	return fmt.Sprintf("%s@%s", conf.username, conf.host)
+}
+
+// get returns a client with a valid session, logging in again if the
+// cached session expired
+func (p *sessionPool) get(ctx context.Context, conf *vsphereConfig) (*govmomi.Client, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	key := sessionKey(conf)
+	if client, found := p.clients[key]; found {
+		userSession, err := client.SessionManager.UserSession(ctx)
+		if err == nil && userSession != nil {
+			return client, nil
+		}
+		log.Debugf("vSphere session for %s is not valid anymore, logging in again: %v", key, err)
+		delete(p.clients, key)
+	}
+
+	client, err := newClient(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[key] = client
+	return client, nil
+}
+
+// release logs out and forgets the session of the given configuration
+func (p *sessionPool) release(ctx context.Context, conf *vsphereConfig) {
+	p.Lock()
+	defer p.Unlock()
+
+	key := sessionKey(conf)
+	client, found := p.clients[key]
+	if !found {
+		return
+	}
+	delete(p.clients, key)
+	if err := client.Logout(ctx); err != nil {
+		log.Debugf("Error logging out of vSphere session %s: %v", key, err)
+	}
+}
+
+func newClient(ctx context.Context, conf *vsphereConfig) (*govmomi.Client, error) {
+	u, err := soap.ParseURL(conf.host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vSphere host %s: %v", conf.host, err)
+	}
+	u.User = url.UserPassword(conf.username, conf.password)
+
+	soapClient := soap.NewClient(u, conf.insecure)
+	if conf.caPath != "" {
+		if err := soapClient.SetRootCAs(conf.caPath); err != nil {
+			return nil, fmt.Errorf("cannot load CA from %s: %v", conf.caPath, err)
+		}
+	}
+
+	vimClient, err := vim25.NewClient(ctx, soapClient)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to %s: %v", conf.host, err)
+	}
+
+	client := &govmomi.Client{
+		Client:         vimClient,
+		SessionManager: session.NewManager(vimClient),
+	}
+	if err := client.Login(ctx, u.User); err != nil {
+		return nil, fmt.Errorf("cannot log in to %s: %v", conf.host, err)
+	}
+	return client, nil
+}
@@ -0,0 +1,223 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build vsphere
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/hostname"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	vsphereCheckName = "vsphere"
+
+	canConnectServiceCheck  = "vsphere.can_connect"
+	hostCollectServiceCheck = "vsphere.host.can_collect"
+)
+
+// VSphereCheck collects host and VM metrics from a vCenter
+type VSphereCheck struct {
+	core.CheckBase
+	config *vsphereConfig
+
+	// newInventory is overridden in tests
+	newInventory func(ctx context.Context, conf *vsphereConfig) (inventoryClient, error)
+
+	clusters    []clusterInventory
+	lastRefresh time.Time
+
+	// inventory of the current session, its properties are updated incrementally
+	inventory *govmomiInventory
+}
+
+type hostResult struct {
+	host  hostRef
+	stats *hostStats
+	err   error
+}
+
+// Run executes the check
+func (c *VSphereCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	tags := []string{"vcenter_server:" + c.config.host}
+
+	inventory, err := c.newInventory(ctx, c.config)
+	if err != nil {
+		sender.ServiceCheck(canConnectServiceCheck, metrics.ServiceCheckCritical, "", tags, err.Error())
+		sender.Commit()
+		return err
+	}
+
+	if c.clusters == nil || time.Since(c.lastRefresh) > c.config.refreshInfrastructure {
+		refreshCtx, cancel := context.WithTimeout(ctx, c.config.collectionTimeout)
+		clusters, err := inventory.listClusters(refreshCtx)
+		cancel()
+		if err != nil {
+			// The session might be broken, force a new login on next run
+			globalSessionPool.release(ctx, c.config)
+			newErr := fmt.Errorf("cannot list vSphere clusters: %v", err)
+			sender.ServiceCheck(canConnectServiceCheck, metrics.ServiceCheckCritical, "", tags, newErr.Error())
+			sender.Commit()
+			return newErr
+		}
+		c.clusters = clusters
+		c.lastRefresh = time.Now()
+	}
+
+	updateCtx, cancel := context.WithTimeout(ctx, c.config.collectionTimeout)
+	err = inventory.update(updateCtx)
+	cancel()
+	if err != nil {
+		globalSessionPool.release(ctx, c.config)
+		newErr := fmt.Errorf("cannot update the vSphere inventory: %v", err)
+		sender.ServiceCheck(canConnectServiceCheck, metrics.ServiceCheckCritical, "", tags, newErr.Error())
+		sender.Commit()
+		return newErr
+	}
+	sender.ServiceCheck(canConnectServiceCheck, metrics.ServiceCheckOK, "", tags, "")
+
+	var hosts []hostRef
+	for _, cluster := range c.clusters {
+		if c.config.isClusterMonitored(cluster.name) {
+			hosts = append(hosts, cluster.hosts...)
+		}
+	}
+
+	failedByCluster := make(map[string]int)
+	for _, result := range collectHosts(inventory, hosts, c.config.collectVMs) {
+		hostTags := append([]string{"vsphere_cluster:" + result.host.cluster}, tags...)
+		if result.err != nil {
+			failedByCluster[result.host.cluster]++
+			sender.ServiceCheck(hostCollectServiceCheck, metrics.ServiceCheckCritical, result.host.name, hostTags, result.err.Error())
+			continue
+		}
+		sender.ServiceCheck(hostCollectServiceCheck, metrics.ServiceCheckOK, result.host.name, hostTags, "")
+		c.submitHostStats(sender, result.stats, hostTags)
+	}
+
+	for _, cluster := range c.clusters {
+		if !c.config.isClusterMonitored(cluster.name) {
+			continue
+		}
+		clusterTags := append([]string{"vsphere_cluster:" + cluster.name}, tags...)
+		sender.Gauge("vsphere.collection.hosts_failed", float64(failedByCluster[cluster.name]), "", clusterTags)
+		if failed := failedByCluster[cluster.name]; failed > 0 {
+			c.Warnf("vSphere check: could not collect %d/%d hosts of cluster %s, partial results were submitted", failed, len(cluster.hosts), cluster.name)
+		}
+	}
+
+	sender.Commit()
+	return nil
+}
+
+func (c *VSphereCheck) submitHostStats(sender aggregator.Sender, stats *hostStats, tags []string) {
+	host := stats.host.name
+	connected := 0.0
+	if stats.connected {
+		connected = 1
+	}
+	sender.Gauge("vsphere.host.connected", connected, host, tags)
+	sender.Gauge("vsphere.host.cpu.usage_mhz", float64(stats.cpuUsageMhz), host, tags)
+	sender.Gauge("vsphere.host.mem.usage_mb", float64(stats.memoryUsageMB), host, tags)
+	sender.Gauge("vsphere.host.uptime", float64(stats.uptimeSeconds), host, tags)
+	if stats.cpuTotalMhz > 0 {
+		sender.Gauge("vsphere.host.cpu.total_mhz", float64(stats.cpuTotalMhz), host, tags)
+	}
+	if stats.memoryTotalMB > 0 {
+		sender.Gauge("vsphere.host.mem.total_mb", float64(stats.memoryTotalMB), host, tags)
+	}
+
+	vmTags := append([]string{"vsphere_host:" + host}, tags...)
+	for _, vm := range stats.vms {
+		// VMs are reported as their own hosts, make their guest hostname an alias
+		if vm.guestHostname != "" && vm.guestHostname != vm.name {
+			hostname.SetExternalHostAliases(vm.name, []string{vm.guestHostname})
+		}
+
+		poweredOn := 0.0
+		if vm.poweredOn {
+			poweredOn = 1
+		}
+		sender.Gauge("vsphere.vm.powered_on", poweredOn, vm.name, vmTags)
+		if !vm.poweredOn {
+			continue
+		}
+		sender.Gauge("vsphere.vm.cpu.count", float64(vm.numCPU), vm.name, vmTags)
+		sender.Gauge("vsphere.vm.cpu.usage_mhz", float64(vm.cpuUsageMhz), vm.name, vmTags)
+		sender.Gauge("vsphere.vm.mem.guest_usage_mb", float64(vm.guestMemoryMB), vm.name, vmTags)
+		sender.Gauge("vsphere.vm.mem.host_usage_mb", float64(vm.hostMemoryMB), vm.name, vmTags)
+		sender.Gauge("vsphere.vm.uptime", float64(vm.uptimeSeconds), vm.name, vmTags)
+	}
+}
+
+// collectHosts reads the stats of the hosts from the inventory, as of its
+// last update. A host missing from it only fails itself.
+func collectHosts(inventory inventoryClient, hosts []hostRef, collectVMs bool) []hostResult {
+	results := make([]hostResult, 0, len(hosts))
+	for _, host := range hosts {
+		stats, err := inventory.collectHost(host, collectVMs)
+		if err != nil {
+			log.Debugf("Cannot collect vSphere host %s: %v", host.name, err)
+		}
+		results = append(results, hostResult{host: host, stats: stats, err: err})
+	}
+	return results
+}
+
+// Configure configures the vsphere check
+func (c *VSphereCheck) Configure(rawInstance integration.Data, rawInitConfig integration.Data, source string) error {
+	// Must be called before CommonConfigure
+	c.BuildID(rawInstance, rawInitConfig)
+
+	err := c.CommonConfigure(rawInstance, source)
+	if err != nil {
+		return err
+	}
+
+	c.config, err = parseConfig(rawInstance)
+	return err
+}
+
+// getGovmomiInventory returns the inventory of the pooled session, kept
+// between the runs so that only the changed properties are fetched
+func (c *VSphereCheck) getGovmomiInventory(ctx context.Context, conf *vsphereConfig) (inventoryClient, error) {
+	client, err := globalSessionPool.get(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	// The property collector of a previous session is gone with it
+	if c.inventory == nil || c.inventory.client != client {
+		c.inventory = newGovmomiInventory(client, conf.collectVMs)
+	}
+	return c.inventory, nil
+}
+
+func vsphereFactory() check.Check {
+	c := &VSphereCheck{
+		CheckBase: core.NewCheckBase(vsphereCheckName),
+	}
+	c.newInventory = c.getGovmomiInventory
+	return c
+}
+
+func init() {
+	core.RegisterCheck(vsphereCheckName, vsphereFactory)
+}
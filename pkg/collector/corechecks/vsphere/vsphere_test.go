@@ -0,0 +1,186 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build vsphere
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+type fakeInventory struct {
+	clusters     []clusterInventory
+	listCalls    int32
+	updateCalls  int32
+	failingHosts map[string]bool
+}
+
+func (f *fakeInventory) listClusters(ctx context.Context) ([]clusterInventory, error) {
+	atomic.AddInt32(&f.listCalls, 1)
+	return f.clusters, nil
+}
+
+func (f *fakeInventory) update(ctx context.Context) error {
+	atomic.AddInt32(&f.updateCalls, 1)
+	return nil
+}
+
+func (f *fakeInventory) collectHost(host hostRef, collectVMs bool) (*hostStats, error) {
+	if f.failingHosts[host.name] {
+		return nil, errors.New("host unreachable")
+	}
+	return &hostStats{
+		host:        host,
+		connected:   true,
+		cpuUsageMhz: 100,
+		vms: []vmStats{
+			{name: host.name + "-vm", poweredOn: true, cpuUsageMhz: 10},
+		},
+	}, nil
+}
+
+func testConfig() *vsphereConfig {
+	return &vsphereConfig{
+		host:                  "vcenter",
+		collectionTimeout:     50 * time.Millisecond,
+		refreshInfrastructure: time.Hour,
+		collectVMs:            true,
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	_, err := parseConfig([]byte("username: foo\npassword: bar"))
+	assert.Error(t, err)
+
+	conf, err := parseConfig([]byte(`
+host: vcenter.local
+username: foo
+password: bar
+ssl_verify: false
+clusters: [prod]
+`))
+	require.NoError(t, err)
+	assert.True(t, conf.insecure)
+	assert.Equal(t, defaultCollectionTimeout*time.Second, conf.collectionTimeout)
+	assert.True(t, conf.collectVMs)
+	assert.True(t, conf.isClusterMonitored("prod"))
+	assert.False(t, conf.isClusterMonitored("dev"))
+}
+
+func TestCollectHosts(t *testing.T) {
+	hosts := []hostRef{{name: "h1", cluster: "c1"}, {name: "h2", cluster: "c1"}}
+	inventory := &fakeInventory{failingHosts: map[string]bool{"h2": true}}
+
+	results := collectHosts(inventory, hosts, true)
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].err)
+	assert.Equal(t, "h1", results[0].stats.host.name)
+	assert.Error(t, results[1].err)
+}
+
+func TestRunPartialCollection(t *testing.T) {
+	inventory := &fakeInventory{
+		clusters: []clusterInventory{
+			{name: "c1", hosts: []hostRef{{name: "h1", cluster: "c1"}, {name: "h2", cluster: "c1"}}},
+			{name: "c2", hosts: []hostRef{{name: "h3", cluster: "c2"}}},
+		},
+		failingHosts: map[string]bool{"h2": true, "h3": true},
+	}
+
+	check := vsphereFactory().(*VSphereCheck)
+	check.config = testConfig()
+	check.newInventory = func(ctx context.Context, conf *vsphereConfig) (inventoryClient, error) {
+		return inventory, nil
+	}
+
+	sender := mocksender.NewMockSender(check.ID())
+	sender.SetupAcceptAll()
+
+	require.NoError(t, check.Run())
+	require.NoError(t, check.Run())
+
+	// the infrastructure is cached between runs, the properties are updated on every run
+	assert.Equal(t, int32(1), atomic.LoadInt32(&inventory.listCalls))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&inventory.updateCalls))
+
+	sender.AssertServiceCheck(t, canConnectServiceCheck, metrics.ServiceCheckOK, "", []string{"vcenter_server:vcenter"}, "")
+	sender.AssertMetric(t, "Gauge", "vsphere.host.cpu.usage_mhz", 100, "h1", []string{"vsphere_cluster:c1", "vcenter_server:vcenter"})
+	sender.AssertMetric(t, "Gauge", "vsphere.vm.cpu.usage_mhz", 10, "h1-vm", []string{"vsphere_host:h1", "vsphere_cluster:c1", "vcenter_server:vcenter"})
+	sender.AssertMetric(t, "Gauge", "vsphere.collection.hosts_failed", 1, "", []string{"vsphere_cluster:c1", "vcenter_server:vcenter"})
+	sender.AssertMetric(t, "Gauge", "vsphere.collection.hosts_failed", 1, "", []string{"vsphere_cluster:c2", "vcenter_server:vcenter"})
+	sender.AssertNotCalled(t, "Gauge", "vsphere.host.cpu.usage_mhz", mock.Anything, "h2", mock.Anything)
+	sender.AssertServiceCheck(t, hostCollectServiceCheck, metrics.ServiceCheckCritical, "h3", []string{"vsphere_cluster:c2", "vcenter_server:vcenter"}, "host unreachable")
+}
+
+func TestGovmomiInventoryApply(t *testing.T) {
+	hostMoRef := types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"}
+	vmMoRef := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+	host := hostRef{ref: hostMoRef, name: "h1", cluster: "c1"}
+
+	inventory := newGovmomiInventory(nil, true)
+	inventory.apply(&types.UpdateSet{FilterSet: []types.PropertyFilterUpdate{{
+		ObjectSet: []types.ObjectUpdate{
+			{
+				Kind: types.ObjectUpdateKindEnter,
+				Obj:  hostMoRef,
+				ChangeSet: []types.PropertyChange{
+					{Name: "name", Op: types.PropertyChangeOpAssign, Val: "h1"},
+					{Name: "vm", Op: types.PropertyChangeOpAssign, Val: types.ArrayOfManagedObjectReference{ManagedObjectReference: []types.ManagedObjectReference{vmMoRef}}},
+					{Name: "summary.quickStats", Op: types.PropertyChangeOpAssign, Val: types.HostListSummaryQuickStats{OverallCpuUsage: 100}},
+				},
+			},
+			{
+				Kind: types.ObjectUpdateKindEnter,
+				Obj:  vmMoRef,
+				ChangeSet: []types.PropertyChange{
+					{Name: "name", Op: types.PropertyChangeOpAssign, Val: "vm1"},
+					{Name: "runtime.powerState", Op: types.PropertyChangeOpAssign, Val: types.VirtualMachinePowerStatePoweredOn},
+				},
+			},
+		},
+	}}})
+
+	stats, err := inventory.collectHost(host, true)
+	require.NoError(t, err)
+	assert.Equal(t, int32(100), stats.cpuUsageMhz)
+	require.Len(t, stats.vms, 1)
+	assert.Equal(t, "vm1", stats.vms[0].name)
+	assert.True(t, stats.vms[0].poweredOn)
+
+	// Only the changed properties are sent, the others are kept
+	inventory.apply(&types.UpdateSet{FilterSet: []types.PropertyFilterUpdate{{
+		ObjectSet: []types.ObjectUpdate{
+			{
+				Kind: types.ObjectUpdateKindModify,
+				Obj:  hostMoRef,
+				ChangeSet: []types.PropertyChange{
+					{Name: "summary.quickStats", Op: types.PropertyChangeOpAssign, Val: types.HostListSummaryQuickStats{OverallCpuUsage: 200}},
+				},
+			},
+			{Kind: types.ObjectUpdateKindLeave, Obj: vmMoRef},
+		},
+	}}})
+
+	stats, err = inventory.collectHost(host, true)
+	require.NoError(t, err)
+	assert.Equal(t, int32(200), stats.cpuUsageMhz)
+	assert.Empty(t, stats.vms)
+
+	_, err = inventory.collectHost(hostRef{name: "unknown"}, true)
+	assert.Error(t, err)
+}
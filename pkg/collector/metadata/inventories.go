@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package metadata
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	md "github.com/DataDog/datadog-agent/pkg/metadata"
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
+	"github.com/DataDog/datadog-agent/pkg/serializer"
+)
+
+// run the inventories metadata collector every 600 seconds (10 minutes)
+const inventoriesCollectorInterval = 600 * time.Second
+
+type autoConfigInterface interface {
+	GetLoadedConfigs() map[string]integration.Config
+}
+
+type collectorInterface interface {
+	GetAllInstanceIDs(checkName string) []check.ID
+}
+
+// InventoriesCollector sends the agent and checks inventories metadata
+type InventoriesCollector struct {
+	ac   autoConfigInterface
+	coll collectorInterface
+}
+
+// Send collects the data needed and submits the payload
+func (c *InventoriesCollector) Send(s *serializer.Serializer) error {
+	payload := inventories.GetPayload(c.ac, c.coll)
+	if err := s.SendMetadata(payload); err != nil {
+		return fmt.Errorf("unable to submit inventories payload, %s", err)
+	}
+	return nil
+}
+
+// SetupInventories registers the inventories collector and schedules it. It
+// can't be a default collector since it needs the AutoConfig and Collector
+// instances.
+func SetupInventories(sch *md.Scheduler, ac autoConfigInterface, coll collectorInterface) error {
+	md.RegisterCollector("inventories", &InventoriesCollector{ac: ac, coll: coll})
+	return sch.AddCollector("inventories", inventoriesCollectorInterval)
+}
@@ -176,14 +176,10 @@ func (c *PythonCheck) Configure(data integration.Data, initConfig integration.Da
 		c.interval = time.Duration(commonOptions.MinCollectionInterval) * time.Second
 	}
 
-	// Disable default hostname if specified
-	if commonOptions.EmptyDefaultHostname {
-		s, err := aggregator.GetSender(c.id)
-		if err != nil {
-			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.id), err)
-		} else {
-			s.DisableDefaultHostname(true)
-		}
+	// Apply the empty hostname, custom tags and service options, python
+	// checks adding the tags of their instance themselves don't get them twice
+	if err := aggregator.ConfigureCheckSender(c.id, data, initConfig); err != nil {
+		log.Errorf("failed to configure the sender of check %s: %s", string(c.id), err)
 	}
 
 	cInitConfig := TrackedCString(string(initConfig))
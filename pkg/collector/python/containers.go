@@ -25,6 +25,7 @@ var filter *containers.Filter
 // IsContainerExcluded returns whether a container should be excluded,
 // based on it's name and image name. Exclusion patterns are configured
 // via the global options (ac_include/ac_exclude/exclude_pause_container)
+// and the container_include_metrics/container_exclude_metrics ones
 //export IsContainerExcluded
 func IsContainerExcluded(name, image *C.char) C.int {
 	// If init failed, fallback to False
@@ -44,7 +45,7 @@ func IsContainerExcluded(name, image *C.char) C.int {
 // Separated to unit testing
 func initContainerFilter() {
 	var err error
-	if filter, err = containers.GetSharedFilter(); err != nil {
+	if filter, err = containers.GetFilter(containers.MetricsFilter); err != nil {
 		log.Errorf("Error initializing container filtering: %s", err)
 	}
 }
@@ -0,0 +1,196 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package replay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Cassette is a recording of HTTP interactions
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is a request and the response it got
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+// Request is a recorded request, matched on its method and its URL
+type Request struct {
+	Method string `yaml:"method"`
+	// URL is the path and the query of the request
+	URL  string `yaml:"url"`
+	Body string `yaml:"body,omitempty"`
+}
+
+// Response is a recorded response
+type Response struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body"`
+}
+
+type requestKey struct {
+	method string
+	url    string
+}
+
+// CassetteServer is a local HTTP server replaying a cassette
+type CassetteServer struct {
+	// URL is the base URL of the server, e.g. http://127.0.0.1:41234
+	URL string
+
+	t        *testing.T
+	path     string
+	upstream string
+	server   *httptest.Server
+
+	mu       sync.Mutex
+	cassette Cassette
+	// replayed is the number of times the interactions of a request were
+	// replayed
+	replayed map[requestKey]int
+}
+
+// ServeCassette starts a server replaying the interactions of a cassette
+// file: the interactions of a request are replayed in the order they were
+// recorded, the last one repeated once they all were. When the
+// DD_REPLAY_UPDATE environment variable is set to true and an upstream URL is
+// given, the requests are forwarded to the upstream and the cassette recorded
+// from its responses instead, it's written on Close.
+func ServeCassette(t *testing.T, path, upstream string) *CassetteServer {
+	t.Helper()
+	s := &CassetteServer{t: t, path: path, replayed: make(map[requestKey]int)}
+
+	if updating() && upstream != "" {
+		s.upstream = upstream
+	} else {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("could not read the cassette: %s", err)
+		}
+		if err := yaml.Unmarshal(data, &s.cassette); err != nil {
+			t.Fatalf("could not parse the cassette %s: %s", path, err)
+		}
+	}
+
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+	return s
+}
+
+// ServeHTTP replays the response of a request, or records it from the
+// upstream
+func (s *CassetteServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request := Request{Method: req.Method, URL: req.URL.RequestURI(), Body: string(body)}
+
+	var response Response
+	if s.upstream != "" {
+		if response, err = s.record(req, request); err != nil {
+			s.t.Errorf("could not record %s %s from %s: %s", request.Method, request.URL, s.upstream, err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	} else {
+		var found bool
+		if response, found = s.replay(request); !found {
+			s.t.Errorf("no interaction recorded for %s %s in the cassette %s", request.Method, request.URL, s.path)
+			http.NotFound(w, req)
+			return
+		}
+	}
+
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+	w.WriteHeader(response.Status)
+	w.Write([]byte(response.Body))
+}
+
+func (s *CassetteServer) replay(request Request) (Response, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := requestKey{method: request.Method, url: request.URL}
+	var matching []Response
+	for _, interaction := range s.cassette.Interactions {
+		if interaction.Request.Method == request.Method && interaction.Request.URL == request.URL {
+			matching = append(matching, interaction.Response)
+		}
+	}
+	if len(matching) == 0 {
+		return Response{}, false
+	}
+	i := s.replayed[key]
+	if i >= len(matching) {
+		i = len(matching) - 1
+	}
+	s.replayed[key]++
+	return matching[i], true
+}
+
+func (s *CassetteServer) record(req *http.Request, request Request) (Response, error) {
+	upstreamReq, err := http.NewRequest(request.Method, s.upstream+request.URL, bytes.NewReader([]byte(request.Body)))
+	if err != nil {
+		return Response{}, err
+	}
+	upstreamReq.Header = req.Header
+	resp, err := http.DefaultClient.Do(upstreamReq)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Response{}, err
+	}
+
+	response := Response{Status: resp.StatusCode, Body: string(body)}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		response.Headers = map[string]string{"Content-Type": contentType}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cassette.Interactions = append(s.cassette.Interactions, Interaction{Request: request, Response: response})
+	return response, nil
+}
+
+// Close stops the server, and writes the cassette when it was recorded
+func (s *CassetteServer) Close() {
+	s.server.Close()
+	if s.upstream == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := yaml.Marshal(s.cassette)
+	if err != nil {
+		s.t.Fatalf("could not marshal the cassette: %s", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		s.t.Fatalf("could not create the directory of the cassette: %s", err)
+	}
+	if err := ioutil.WriteFile(s.path, data, 0644); err != nil {
+		s.t.Fatalf("could not write the cassette: %s", err)
+	}
+}
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+/*
+Package replay runs the Go checks against recorded fixtures in their tests,
+and compares what they submit with golden files, instead of asserting on each
+submission:
+
+	func TestCheck(t *testing.T) {
+		server := replay.ServeCassette(t, "testdata/status.yaml", "")
+		defer server.Close()
+
+		instance := fmt.Sprintf("url: %s/status", server.URL)
+		sender := replay.Run(t, new(Check), instance, "", 2)
+		replay.AssertGolden(t, sender, "testdata/status.golden.json")
+	}
+
+A cassette is a YAML file of HTTP interactions, replayed by a local server in
+the order they were recorded. The checks reading files, like the ones of
+procfs, are run against a copy of a snapshot directory made by CopySnapshot.
+
+With the DD_REPLAY_UPDATE environment variable set to true, the golden files
+are written with the submissions of the check, and the cassettes served with
+an upstream URL are recorded from it, e.g.
+
+	DD_REPLAY_UPDATE=true go test ./pkg/collector/corechecks/mycheck/
+
+The golden files and the cassettes are then reviewed and committed with the
+test.
+*/
+package replay
@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// Submission is a metric, a service check or an event submitted by a check
+type Submission struct {
+	Method   string   `json:"method"`
+	Name     string   `json:"name,omitempty"`
+	Value    *float64 `json:"value,omitempty"`
+	Hostname string   `json:"hostname,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+
+	// Status and Message are set for the service checks
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// the bounds of the histogram buckets
+	LowerBound *float64 `json:"lower_bound,omitempty"`
+	UpperBound *float64 `json:"upper_bound,omitempty"`
+	Monotonic  bool     `json:"monotonic,omitempty"`
+
+	// Event is set for the events, without their timestamp that varies
+	// between runs
+	Event *metrics.Event `json:"event,omitempty"`
+}
+
+// Submissions returns the submissions received by a mock sender, with their
+// tags sorted, in a stable order that doesn't depend on the order they were
+// submitted in
+func Submissions(sender *mocksender.MockSender) []Submission {
+	var submissions []Submission
+	for _, call := range sender.Calls {
+		args := call.Arguments
+		var s Submission
+		switch call.Method {
+		case "Rate", "Count", "MonotonicCount", "Counter", "Histogram", "Historate", "Gauge":
+			value := args.Get(1).(float64)
+			s = Submission{Name: args.String(0), Value: &value, Hostname: args.String(2), Tags: args.Get(3).([]string)}
+		case "ServiceCheck":
+			status := args.Get(1).(metrics.ServiceCheckStatus)
+			s = Submission{Name: args.String(0), Status: status.String(), Hostname: args.String(2), Tags: args.Get(3).([]string), Message: args.String(4)}
+		case "Event":
+			event := args.Get(0).(metrics.Event)
+			event.Ts = 0
+			event.Tags = sortedTags(event.Tags)
+			s = Submission{Event: &event}
+		case "HistogramBucket":
+			value, lower, upper := float64(args.Int(1)), args.Get(2).(float64), args.Get(3).(float64)
+			s = Submission{Name: args.String(0), Value: &value, LowerBound: &lower, UpperBound: &upper, Monotonic: args.Bool(4), Hostname: args.String(5), Tags: args.Get(6).([]string)}
+		default:
+			// the calls configuring the sender aren't submissions
+			continue
+		}
+		s.Method = call.Method
+		s.Tags = sortedTags(s.Tags)
+		submissions = append(submissions, s)
+	}
+
+	sort.SliceStable(submissions, func(i, j int) bool {
+		return submissions[i].sortKey() < submissions[j].sortKey()
+	})
+	return submissions
+}
+
+func (s *Submission) sortKey() string {
+	name := s.Name
+	if s.Event != nil {
+		name = s.Event.Title
+	}
+	var value string
+	if s.Value != nil {
+		value = fmt.Sprintf("%020.6f", *s.Value)
+	}
+	return strings.Join([]string{s.Method, name, s.Hostname, strings.Join(s.Tags, ","), value}, "\x00")
+}
+
+func sortedTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	sorted := make([]string, len(tags))
+	copy(sorted, tags)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// AssertGolden compares the submissions received by a mock sender with the
+// ones of a golden JSON file, it writes the file instead when the
+// DD_REPLAY_UPDATE environment variable is set to true
+func AssertGolden(t *testing.T, sender *mocksender.MockSender, path string) {
+	t.Helper()
+	actual, err := json.MarshalIndent(Submissions(sender), "", "  ")
+	if err != nil {
+		t.Fatalf("could not marshal the submissions: %s", err)
+	}
+	actual = append(actual, '\n')
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("could not create the directory of the golden file: %s", err)
+		}
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatalf("could not write the golden file: %s", err)
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read the golden file, run the test with %s=true to write it: %s", UpdateEnvVar, err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("the submissions differ from the golden file %s, run the test with %s=true to update it\nexpected:\n%s\nactual:\n%s", path, UpdateEnvVar, expected, actual)
+	}
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package replay
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+// UpdateEnvVar is the environment variable writing the golden files and
+// recording the cassettes when set to true
+const UpdateEnvVar = "DD_REPLAY_UPDATE"
+
+// updating returns whether the golden files and the cassettes are updated
+func updating() bool {
+	update, _ := strconv.ParseBool(os.Getenv(UpdateEnvVar))
+	return update
+}
+
+// Run configures a check with an instance and its init config, and runs it
+// the given number of times, some checks only submitting their rates from
+// their second run. It returns the mock sender the check submitted to.
+func Run(t *testing.T, c check.Check, instance, initConfig string, runs int) *mocksender.MockSender {
+	t.Helper()
+	if err := c.Configure(integration.Data(instance), integration.Data(initConfig), "replay"); err != nil {
+		t.Fatalf("could not configure the %s check: %s", c, err)
+	}
+
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	for i := 0; i < runs; i++ {
+		if err := c.Run(); err != nil {
+			t.Fatalf("run %d of the %s check failed: %s", i+1, c, err)
+		}
+	}
+	return sender
+}
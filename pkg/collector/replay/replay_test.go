@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// testCheck submits the queues of a status endpoint and the load of a procfs
+type testCheck struct {
+	core.CheckBase
+	url  string
+	proc string
+}
+
+func (c *testCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	c.BuildID(data, initConfig)
+	instance := struct {
+		URL  string `yaml:"url"`
+		Proc string `yaml:"proc"`
+	}{}
+	if err := yaml.Unmarshal(data, &instance); err != nil {
+		return err
+	}
+	c.url, c.proc = instance.URL, instance.Proc
+	return nil
+}
+
+func (c *testCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Get(c.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	status := struct {
+		Version string `json:"version"`
+		Queues  int    `json:"queues"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return err
+	}
+	sender.Gauge("test.queues", float64(status.Queues), "", []string{"version:" + status.Version, "env:test"})
+
+	loadavg, err := ioutil.ReadFile(filepath.Join(c.proc, "loadavg"))
+	if err != nil {
+		return err
+	}
+	load, err := strconv.ParseFloat(strings.Fields(string(loadavg))[0], 64)
+	if err != nil {
+		return err
+	}
+	sender.Gauge("test.load.1", load, "", nil)
+
+	sender.ServiceCheck("test.can_connect", metrics.ServiceCheckOK, "", []string{"env:test"}, "")
+	sender.Event(metrics.Event{Title: "status polled", Ts: time.Now().Unix(), Tags: []string{"env:test", "check:test"}})
+	sender.Commit()
+	return nil
+}
+
+func TestReplay(t *testing.T) {
+	server := ServeCassette(t, "testdata/status.yaml", "")
+	defer server.Close()
+	proc, cleanup := CopySnapshot(t, "testdata/proc")
+	defer cleanup()
+
+	instance := fmt.Sprintf("url: %s/status?format=json\nproc: %s", server.URL, proc)
+	sender := Run(t, &testCheck{CheckBase: core.NewCheckBase("replay_test")}, instance, "", 2)
+	AssertGolden(t, sender, "testdata/status.golden.json")
+}
+
+func TestCassetteReplay(t *testing.T) {
+	server := ServeCassette(t, "testdata/status.yaml", "")
+	defer server.Close()
+
+	// the interactions are replayed in order, the last one is repeated
+	for _, queues := range []string{"5", "3", "3"} {
+		response, found := server.replay(Request{Method: "GET", URL: "/status?format=json"})
+		require.True(t, found)
+		assert.Contains(t, response.Body, `"queues": `+queues)
+	}
+	_, found := server.replay(Request{Method: "POST", URL: "/status?format=json"})
+	assert.False(t, found)
+	_, found = server.replay(Request{Method: "GET", URL: "/status"})
+	assert.False(t, found)
+}
+
+func TestCassetteRecording(t *testing.T) {
+	os.Setenv(UpdateEnvVar, "true")
+	defer os.Unsetenv(UpdateEnvVar)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path": %q}`, r.URL.Path)
+	}))
+	defer upstream.Close()
+	dir, err := ioutil.TempDir("", "replay")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "recorded.yaml")
+	server := ServeCassette(t, path, upstream.URL)
+	resp, err := http.Get(server.URL + "/status")
+	require.NoError(t, err)
+	resp.Body.Close()
+	server.Close()
+
+	os.Unsetenv(UpdateEnvVar)
+	replayed := ServeCassette(t, path, upstream.URL)
+	defer replayed.Close()
+	resp, err = http.Get(replayed.URL + "/status")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"path": "/status"}`, string(body))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+}
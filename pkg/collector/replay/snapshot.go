@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package replay
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// CopySnapshot copies a snapshot directory, e.g. of procfs, into a temporary
+// directory the check can be pointed to and can modify without altering the
+// snapshot. It returns the directory and the function removing it.
+func CopySnapshot(t *testing.T, snapshot string) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "replay-snapshot")
+	if err != nil {
+		t.Fatalf("could not create the snapshot directory: %s", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	err = filepath.Walk(snapshot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(snapshot, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode()|0700)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, info.Mode())
+	})
+	if err != nil {
+		cleanup()
+		t.Fatalf("could not copy the snapshot %s: %s", snapshot, err)
+	}
+	return dir, cleanup
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package runner
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// maxFailingChecks bounds the number of checks whose failures are kept, the
+// check that failed the least recently is dropped first
+const maxFailingChecks = 100
+
+var checkFailures = newCheckFailuresStore()
+
+// CheckFailure is a failed run of a check
+type CheckFailure struct {
+	Timestamp  int64  `json:"timestamp"` // unix timestamp in seconds
+	Error      string `json:"error"`
+	Traceback  string `json:"traceback,omitempty"` // only set for the python checks
+	ConfigHash string `json:"config_hash"`
+}
+
+// checkFailuresStore keeps the last failing runs of each check. Unlike the
+// check stats, the failures are kept when the check is unscheduled, as the
+// transient failures are usually gone by the time a flare is taken.
+type checkFailuresStore struct {
+	sync.RWMutex
+	failures map[check.ID][]CheckFailure
+}
+
+func newCheckFailuresStore() *checkFailuresStore {
+	return &checkFailuresStore{failures: make(map[check.ID][]CheckFailure)}
+}
+
+// add records a failure of a check, keeping at most historySize failures per check
+func (s *checkFailuresStore) add(id check.ID, failure CheckFailure, historySize int) {
+	if historySize <= 0 {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	failures, found := s.failures[id]
+	if !found && len(s.failures) >= maxFailingChecks {
+		s.evictOldest()
+	}
+	failures = append(failures, failure)
+	if len(failures) > historySize {
+		failures = append([]CheckFailure{}, failures[len(failures)-historySize:]...)
+	}
+	s.failures[id] = failures
+}
+
+// evictOldest drops the failures of the check that failed the least recently
+func (s *checkFailuresStore) evictOldest() {
+	var oldestID check.ID
+	var oldest int64
+	for id, failures := range s.failures {
+		last := failures[len(failures)-1].Timestamp
+		if oldestID == "" || last < oldest {
+			oldestID, oldest = id, last
+		}
+	}
+	delete(s.failures, oldestID)
+}
+
+// get returns a copy of the failures, keyed by check ID
+func (s *checkFailuresStore) get() map[check.ID][]CheckFailure {
+	s.RLock()
+	defer s.RUnlock()
+
+	failures := make(map[check.ID][]CheckFailure, len(s.failures))
+	for id, f := range s.failures {
+		failures[id] = append([]CheckFailure{}, f...)
+	}
+	return failures
+}
+
+// newCheckFailure builds the failure of a run of the check, splitting the
+// message and the traceback of the python errors
+func newCheckFailure(id check.ID, err error) CheckFailure {
+	failure := CheckFailure{
+		Timestamp:  time.Now().Unix(),
+		Error:      err.Error(),
+		ConfigHash: check.IDToConfigHash(id),
+	}
+
+	var pyErrors []map[string]string
+	if json.Unmarshal([]byte(failure.Error), &pyErrors) == nil && len(pyErrors) > 0 {
+		failure.Error = pyErrors[0]["message"]
+		failure.Traceback = pyErrors[0]["traceback"]
+	}
+	return failure
+}
+
+func addCheckFailure(id check.ID, err error) {
+	checkFailures.add(id, newCheckFailure(id, err), config.Datadog.GetInt("check_failures_history_size"))
+}
+
+func expCheckFailures() interface{} {
+	return checkFailures.get()
+}
+
+// GetCheckFailures returns the last failing runs of the checks, keyed by check ID
+func GetCheckFailures() map[check.ID][]CheckFailure {
+	return checkFailures.get()
+}
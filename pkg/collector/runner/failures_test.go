@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+func TestNewCheckFailure(t *testing.T) {
+	failure := newCheckFailure("my_check:9505c316b4e4a028", errors.New("connection refused"))
+	assert.Equal(t, "connection refused", failure.Error)
+	assert.Empty(t, failure.Traceback)
+	assert.Equal(t, "9505c316b4e4a028", failure.ConfigHash)
+	assert.NotZero(t, failure.Timestamp)
+
+	// python checks errors carry their traceback
+	failure = newCheckFailure("my_check:9505c316b4e4a028", errors.New(`[{"message": "division by zero", "traceback": "Traceback (most recent call last):\nZeroDivisionError"}]`))
+	assert.Equal(t, "division by zero", failure.Error)
+	assert.Equal(t, "Traceback (most recent call last):\nZeroDivisionError", failure.Traceback)
+}
+
+func TestCheckFailuresStoreHistorySize(t *testing.T) {
+	s := newCheckFailuresStore()
+	for i := 0; i < 5; i++ {
+		s.add("check:1", CheckFailure{Timestamp: int64(i), Error: fmt.Sprintf("error %d", i)}, 3)
+	}
+	s.add("check:2", CheckFailure{Timestamp: 1, Error: "disabled"}, 0)
+
+	failures := s.get()
+	require.Len(t, failures, 1)
+	require.Len(t, failures["check:1"], 3)
+	assert.Equal(t, "error 2", failures["check:1"][0].Error)
+	assert.Equal(t, "error 4", failures["check:1"][2].Error)
+}
+
+func TestCheckFailuresStoreEviction(t *testing.T) {
+	s := newCheckFailuresStore()
+	for i := 0; i < maxFailingChecks; i++ {
+		s.add(check.ID(fmt.Sprintf("check:%d", i)), CheckFailure{Timestamp: int64(100 + i)}, 3)
+	}
+	// check:0 fails again and becomes the most recent one
+	s.add("check:0", CheckFailure{Timestamp: 1000}, 3)
+	s.add("new:1", CheckFailure{Timestamp: 1001}, 3)
+
+	failures := s.get()
+	assert.Len(t, failures, maxFailingChecks)
+	assert.Contains(t, failures, check.ID("check:0"))
+	assert.Contains(t, failures, check.ID("new:1"))
+	assert.NotContains(t, failures, check.ID("check:1"))
+}
@@ -43,6 +43,7 @@ var (
 func init() {
 	runnerStats = expvar.NewMap("runner")
 	runnerStats.Set("Checks", expvar.Func(expCheckStats))
+	runnerStats.Set("CheckFailures", expvar.Func(expCheckFailures))
 	checkStats = &runnerCheckStats{
 		Stats: make(map[string]map[check.ID]*check.Stats),
 	}
@@ -243,6 +244,13 @@ func (r *Runner) work() {
 	defer runnerStats.Add("Workers", -1)
 
 	for check := range r.pending {
+		// see if the run is shed to lower the agent footprint
+		if checkShedding.shed(check) {
+			log.Debugf("Check %s is throttled or paused, skip execution...", check)
+			runnerStats.Add("ShedRuns", 1)
+			continue
+		}
+
 		// see if the check is already running
 		r.m.Lock()
 		if _, isRunning := r.runningChecks[check.ID()]; isRunning {
@@ -291,6 +299,7 @@ func (r *Runner) work() {
 		if err != nil {
 			log.Errorf("Error running check %s: %s", check, err)
 			runnerStats.Add("Errors", 1)
+			addCheckFailure(check.ID(), err)
 			serviceCheckStatus = metrics.ServiceCheckCritical
 		}
 
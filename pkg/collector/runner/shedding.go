@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package runner
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+var checkShedding = newCheckSheddingState()
+
+// checkSheddingState holds the checks the runner skips to lower the agent
+// footprint, see ThrottleChecks and PauseChecks
+type checkSheddingState struct {
+	sync.Mutex
+	// throttled maps the check names to the number of schedules per run
+	throttled map[string]int
+	paused    map[string]bool
+	// skipped counts the schedules skipped since the last run, by check ID
+	skipped map[check.ID]int
+}
+
+func newCheckSheddingState() *checkSheddingState {
+	return &checkSheddingState{
+		throttled: make(map[string]int),
+		paused:    make(map[string]bool),
+		skipped:   make(map[check.ID]int),
+	}
+}
+
+// shed returns whether a scheduled run of a check should be skipped. The
+// long-running checks only run once and are never skipped.
+func (s *checkSheddingState) shed(c check.Check) bool {
+	if c.Interval() == 0 {
+		return false
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.paused[c.String()] {
+		return true
+	}
+	factor := s.throttled[c.String()]
+	if factor <= 1 {
+		return false
+	}
+	if s.skipped[c.ID()]+1 < factor {
+		s.skipped[c.ID()]++
+		return true
+	}
+	delete(s.skipped, c.ID())
+	return false
+}
+
+func (s *checkSheddingState) throttle(names []string, factor int) {
+	s.Lock()
+	defer s.Unlock()
+	for _, name := range names {
+		if factor <= 1 {
+			delete(s.throttled, name)
+		} else {
+			s.throttled[name] = factor
+		}
+	}
+	if len(s.throttled) == 0 {
+		s.skipped = make(map[check.ID]int)
+	}
+}
+
+func (s *checkSheddingState) pause(names []string, paused bool) {
+	s.Lock()
+	defer s.Unlock()
+	for _, name := range names {
+		if paused {
+			s.paused[name] = true
+		} else {
+			delete(s.paused, name)
+		}
+	}
+}
+
+// ThrottleChecks makes the runner run the checks with the given names once
+// every factor schedules. A factor of 1 or less restores their interval.
+func ThrottleChecks(names []string, factor int) {
+	checkShedding.throttle(names, factor)
+}
+
+// PauseChecks makes the runner skip the checks with the given names until
+// they're resumed. The checks stay scheduled.
+func PauseChecks(names []string, paused bool) {
+	checkShedding.pause(names, paused)
+}
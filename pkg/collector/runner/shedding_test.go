@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSheddingThrottle(t *testing.T) {
+	s := newCheckSheddingState()
+	c := newTestCheck(false, "1")
+
+	assert.False(t, s.shed(c))
+
+	s.throttle([]string{"TestCheck"}, 3)
+	var runs []bool
+	for i := 0; i < 6; i++ {
+		runs = append(runs, !s.shed(c))
+	}
+	assert.Equal(t, []bool{false, false, true, false, false, true}, runs)
+
+	s.throttle([]string{"TestCheck"}, 1)
+	assert.False(t, s.shed(c))
+	assert.Empty(t, s.skipped)
+}
+
+func TestCheckSheddingPause(t *testing.T) {
+	s := newCheckSheddingState()
+	c := newTestCheck(false, "1")
+
+	s.pause([]string{"TestCheck", "other"}, true)
+	assert.True(t, s.shed(c))
+	assert.True(t, s.shed(c))
+
+	s.pause([]string{"TestCheck"}, false)
+	assert.False(t, s.shed(c))
+	assert.True(t, s.paused["other"])
+}
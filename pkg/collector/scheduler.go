@@ -14,6 +14,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/loaders"
+	"github.com/DataDog/datadog-agent/pkg/util/audit"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -58,13 +59,16 @@ func InitCheckScheduler(collector *Collector) *CheckScheduler {
 
 // Schedule schedules configs to checks
 func (s *CheckScheduler) Schedule(configs []integration.Config) {
-	checks := s.GetChecksFromConfigs(configs, true)
-	for _, c := range checks {
-		_, err := s.collector.RunCheck(c)
-		if err != nil {
-			log.Errorf("Unable to run Check %s: %v", c, err)
-			errorStats.setRunError(c.ID(), err.Error())
-			continue
+	for _, config := range configs {
+		checks := s.GetChecksFromConfigs([]integration.Config{config}, true)
+		for _, c := range checks {
+			_, err := s.collector.RunCheck(c)
+			if err != nil {
+				log.Errorf("Unable to run Check %s: %v", c, err)
+				errorStats.setRunError(c.ID(), err.Error())
+				continue
+			}
+			audit.Record(audit.CheckScheduled, fmt.Sprintf("Check %s scheduled", c.ID()), auditFields(c.ID(), config))
 		}
 	}
 }
@@ -89,6 +93,7 @@ func (s *CheckScheduler) Unschedule(configs []integration.Config) {
 				errorStats.setRunError(id, err.Error())
 			} else {
 				stopped[id] = struct{}{}
+				audit.Record(audit.CheckUnscheduled, fmt.Sprintf("Check %s unscheduled", id), auditFields(id, config))
 			}
 		}
 
@@ -109,6 +114,16 @@ func (s *CheckScheduler) Unschedule(configs []integration.Config) {
 	}
 }
 
+// auditFields returns the audit log fields of a check
+func auditFields(id check.ID, config integration.Config) map[string]string {
+	return map[string]string{
+		"check":    config.Name,
+		"check_id": string(id),
+		"provider": config.Provider,
+		"source":   config.Source,
+	}
+}
+
 // Stop handles clean stop of registered schedulers
 func (s *CheckScheduler) Stop() {
 	if s.collector != nil {
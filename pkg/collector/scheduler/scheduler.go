@@ -12,6 +12,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
@@ -42,8 +43,8 @@ type Scheduler struct {
 	checkToQueue map[check.ID]*jobQueue      // Keep track of what is the queue for any Check
 	mu           sync.Mutex                  // To protect critical sections in struct's fields
 
-	cancelOneTime chan bool      // Used to internally communicate a cancel signal to one-time schedule goroutines
-	wgOneTime     sync.WaitGroup // WaitGroup to track the exit of one-time schedule goroutines
+	cancelOneTime chan bool         // Used to internally communicate a cancel signal to one-time schedule goroutines
+	wgOneTime     *health.WaitGroup // WaitGroup to track the exit of one-time schedule goroutines
 }
 
 // NewScheduler create a Scheduler and returns a pointer to it.
@@ -57,7 +58,7 @@ func NewScheduler(checksPipe chan<- check.Check) *Scheduler {
 		checkToQueue:  make(map[check.ID]*jobQueue),
 		running:       0,
 		cancelOneTime: make(chan bool),
-		wgOneTime:     sync.WaitGroup{},
+		wgOneTime:     health.NewWaitGroup("collector-scheduler-one-time"),
 	}
 }
 
@@ -230,15 +231,17 @@ func (s *Scheduler) startQueue(q *jobQueue) {
 // The queuing can be cancelled by closing the `cancelOneTime` channel.
 func (s *Scheduler) enqueueOnce(check check.Check) {
 	log.Infof("Scheduling check %v for one-time execution", check)
-	s.wgOneTime.Add(1)
-
-	go func(cancelOneTime <-chan bool) {
-		defer s.wgOneTime.Done()
+	cancelOneTime := s.cancelOneTime
+	err := s.wgOneTime.Go(func() {
 		select {
 		case s.checksPipe <- check:
 		case <-cancelOneTime:
 		}
-	}(s.cancelOneTime)
+	})
+	if err != nil {
+		log.Errorf("Could not schedule check %v for one-time execution: %s", check, err)
+		return
+	}
 
 	schedulerChecksEntered.Add(1)
 }
@@ -0,0 +1,30 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package config
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/util/component"
+)
+
+// ComponentName is the name of the agent configuration in the component
+// registries
+const ComponentName = "config"
+
+// FromRegistry returns the configuration registered in a component registry,
+// or Datadog if none is
+func FromRegistry(r *component.Registry) Config {
+	if cfg, ok := r.Get(ComponentName).(Config); ok {
+		return cfg
+	}
+	return Datadog
+}
+
+// Get returns the configuration of the default component registry, Datadog
+// unless a binary embedding the agent or a test registered another one. The
+// components resolved through the registry read their settings from it.
+func Get() Config {
+	return FromRegistry(component.Default())
+}
@@ -55,6 +55,13 @@ type MetadataProviders struct {
 	Interval time.Duration `mapstructure:"interval"`
 }
 
+// KubeconfigContext helps unmarshalling `kubernetes_kubeconfig_contexts` config param
+type KubeconfigContext struct {
+	Name           string `mapstructure:"name"`
+	KubeconfigPath string `mapstructure:"kubeconfig_path"`
+	Context        string `mapstructure:"context"`
+}
+
 // ConfigurationProviders helps unmarshalling `config_providers` config param
 type ConfigurationProviders struct {
 	Name             string `mapstructure:"name"`
@@ -102,6 +109,19 @@ func initConfig(config Config) {
 	config.SetDefault("proxy", nil)
 	config.BindEnvAndSetDefault("skip_ssl_validation", false)
 	config.BindEnvAndSetDefault("hostname", "")
+	config.BindEnvAndSetDefault("host_aliases", []string{})
+	config.BindEnvAndSetDefault("host_lifecycle", "persistent")       // persistent or ephemeral
+	config.BindEnvAndSetDefault("ephemeral_shutdown_grace_period", 2) // in seconds
+	config.BindEnvAndSetDefault("agent_uuid_file_path", "")
+	config.BindEnvAndSetDefault("install_method.tool", "")
+	config.BindEnvAndSetDefault("install_method.tool_version", "")
+	config.BindEnvAndSetDefault("install_method.installer_version", "")
+	config.BindEnvAndSetDefault("inventories_enabled", false)
+	config.BindEnvAndSetDefault("clock_drift.tolerance", 60) // in seconds
+	config.BindEnvAndSetDefault("clock_drift.correct_timestamps", false)
+	config.BindEnvAndSetDefault("dns_resolver.max_concurrent_lookups", 10)
+	config.BindEnvAndSetDefault("dns_resolver.cache_ttl", 60)          // in seconds
+	config.BindEnvAndSetDefault("dns_resolver.negative_cache_ttl", 10) // in seconds
 	config.BindEnvAndSetDefault("tags", []string{})
 	config.BindEnvAndSetDefault("tag_value_split_separator", map[string]string{})
 	config.BindEnvAndSetDefault("conf_path", ".")
@@ -111,6 +131,10 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("log_file", "")
 	config.BindEnvAndSetDefault("log_file_max_size", "10Mb")
 	config.BindEnvAndSetDefault("log_file_max_rolls", 1)
+	config.BindEnvAndSetDefault("audit_log.enabled", true)
+	config.BindEnvAndSetDefault("audit_log.file", "")
+	config.BindEnvAndSetDefault("audit_log.max_size", "10Mb")
+	config.BindEnvAndSetDefault("audit_log.max_rolls", 1)
 	config.BindEnvAndSetDefault("log_level", "info")
 	config.BindEnvAndSetDefault("log_to_syslog", false)
 	config.BindEnvAndSetDefault("log_to_console", true)
@@ -128,6 +152,7 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("enable_metadata_collection", true)
 	config.BindEnvAndSetDefault("enable_gohai", true)
 	config.BindEnvAndSetDefault("check_runners", int64(4))
+	config.BindEnvAndSetDefault("check_failures_history_size", 10)
 	config.BindEnvAndSetDefault("auth_token_file_path", "")
 	config.BindEnvAndSetDefault("bind_host", "localhost")
 	config.BindEnvAndSetDefault("ipc_address", "localhost")
@@ -142,6 +167,20 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("tracemalloc_whitelist", "")
 	config.BindEnvAndSetDefault("tracemalloc_blacklist", "")
 
+	// Supervisor: panic recovery and restart of long-running components
+	config.BindEnvAndSetDefault("supervisor.enabled", true)
+	config.BindEnvAndSetDefault("supervisor.max_restarts", 5)
+	config.BindEnvAndSetDefault("supervisor.restart_window", 300) // in seconds
+	config.BindEnvAndSetDefault("supervisor.max_backoff", 60)     // in seconds
+
+	// Resource budget: load shedding when the agent exceeds its CPU or memory budget
+	config.BindEnvAndSetDefault("resource_budget.max_cpu_percent", 0.0) // in percent of a core, 0 to disable
+	config.BindEnvAndSetDefault("resource_budget.max_memory", int64(0)) // in bytes, 0 to disable
+	config.BindEnvAndSetDefault("resource_budget.check_interval", 10)   // in seconds
+	config.BindEnvAndSetDefault("resource_budget.container_checks", []string{"docker", "containerd", "cri", "kubelet", "ecs_fargate"})
+	config.BindEnvAndSetDefault("resource_budget.container_checks_throttle", 4)
+	config.BindEnvAndSetDefault("resource_budget.low_priority_checks", []string{})
+
 	// Python 3 linter timeout, in seconds
 	// NOTE: linter is notoriously slow, in the absence of a better solution we
 	//       can only increase this timeout value. Linting operation is async.
@@ -209,6 +248,10 @@ func initConfig(config Config) {
 	// Warning: do not change the two following values. Your payloads will get dropped by Datadog's intake.
 	config.BindEnvAndSetDefault("serializer_max_payload_size", 2*megaByte+megaByte/2)
 	config.BindEnvAndSetDefault("serializer_max_uncompressed_payload_size", 4*megaByte)
+	// Spread the series of a flush over several requests, 0 disables the limit and the pacing.
+	// The total pacing of a flush is capped at 10s, below the flush interval.
+	config.BindEnvAndSetDefault("serializer_max_series_per_payload", 0)
+	config.BindEnvAndSetDefault("serializer_series_payload_pacing_ms", 0)
 	config.BindEnvAndSetDefault("use_v2_api.series", false)
 	config.BindEnvAndSetDefault("use_v2_api.events", false)
 	config.BindEnvAndSetDefault("use_v2_api.service_checks", false)
@@ -223,6 +266,11 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_timeout", 20)
 	config.BindEnvAndSetDefault("forwarder_retry_queue_max_size", 30)
 	config.BindEnvAndSetDefault("forwarder_num_workers", 1)
+	config.BindEnvAndSetDefault("forwarder_payload_checksum", false)
+	// The attempts to deliver the transactions in the last `retention` seconds are kept for `agent forwarder acks`,
+	// at most max_size of them, 0 disables it
+	config.BindEnvAndSetDefault("forwarder_ack_log.max_size", 0)
+	config.BindEnvAndSetDefault("forwarder_ack_log.retention", 3600) // in seconds
 	// Dogstatsd
 	config.BindEnvAndSetDefault("use_dogstatsd", true)
 	config.BindEnvAndSetDefault("dogstatsd_port", 8125) // Notice: 0 means UDP port closed
@@ -236,6 +284,14 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_packet_buffer_size", 512)
 	config.BindEnvAndSetDefault("dogstatsd_packet_buffer_flush_timeout", 100*time.Millisecond)
 	config.BindEnvAndSetDefault("dogstatsd_queue_size", 100)
+	// Size of the queues of the dogstatsd samples, events and service checks of the aggregator.
+	// The sizes of the queues can be lowered at runtime, and raised back up to this size.
+	config.BindEnvAndSetDefault("aggregator_buffer_size", 100)
+	// The series flushed in the last `retention` seconds are kept for `agent top metrics`, at most max_size of them
+	config.BindEnvAndSetDefault("recent_series.max_size", 10000)
+	config.BindEnvAndSetDefault("recent_series.retention", 300) // in seconds
+	// The identical events received within `event_deduplication_window` seconds are forwarded once, 0 disables it
+	config.BindEnvAndSetDefault("event_deduplication_window", 0)
 
 	config.BindEnvAndSetDefault("dogstatsd_non_local_traffic", false)
 	config.BindEnvAndSetDefault("dogstatsd_socket", "") // Notice: empty means feature disabled
@@ -244,18 +300,33 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_stats_buffer", 10)
 	config.BindEnvAndSetDefault("dogstatsd_expiry_seconds", 300)
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection", false) // Only supported for socket traffic
+	config.BindEnvAndSetDefault("dogstatsd_origin_detection_steps", []string{"peer_credentials", "cgroup", "container_pid"})
 	config.BindEnvAndSetDefault("dogstatsd_so_rcvbuf", 0)
+	config.BindEnvAndSetDefault("dogstatsd_so_reuseport_workers", 1)
 	config.BindEnvAndSetDefault("dogstatsd_metrics_stats_enable", false)
+	// number of strings per generation of the string interner, see pkg/util/intern
+	config.BindEnvAndSetDefault("string_interner_size", 20000)
 	config.BindEnvAndSetDefault("dogstatsd_tags", []string{})
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
 	config.BindEnvAndSetDefault("statsd_metric_namespace", "")
 	config.BindEnvAndSetDefault("statsd_metric_namespace_blacklist", StandardStatsdPrefixes)
+	// Series intake
+	config.BindEnvAndSetDefault("series_intake.enabled", false)
+	config.BindEnvAndSetDefault("series_intake.port", 8129)
+	config.BindEnvAndSetDefault("series_intake.max_payload_size", 5242880) // Same limit as the API, once decompressed
+	config.BindEnvAndSetDefault("series_intake.tags", []string{})
 	// Autoconfig
 	config.BindEnvAndSetDefault("autoconf_template_dir", "/datadog/check_configs")
 	config.BindEnvAndSetDefault("exclude_pause_container", true)
 	config.BindEnvAndSetDefault("ac_include", []string{})
 	config.BindEnvAndSetDefault("ac_exclude", []string{})
+	config.BindEnvAndSetDefault("container_include", []string{})
+	config.BindEnvAndSetDefault("container_exclude", []string{})
+	for _, scope := range []string{"metrics", "logs", "ad"} {
+		config.BindEnvAndSetDefault("container_include_"+scope, []string{})
+		config.BindEnvAndSetDefault("container_exclude_"+scope, []string{})
+	}
 	config.BindEnvAndSetDefault("ad_config_poll_interval", int64(10)) // in seconds
 	config.BindEnvAndSetDefault("extra_listeners", []string{})
 	config.BindEnvAndSetDefault("extra_config_providers", []string{})
@@ -271,6 +342,7 @@ func initConfig(config Config) {
 
 	// CRI
 	config.BindEnvAndSetDefault("cri_socket_path", "")              // empty is disabled
+	config.BindEnvAndSetDefault("cri_socket_paths", []string{})     // additional CRI sockets, collected along cri_socket_path
 	config.BindEnvAndSetDefault("cri_connection_timeout", int64(1)) // in seconds
 	config.BindEnvAndSetDefault("cri_query_timeout", int64(5))      // in seconds
 
@@ -292,27 +364,56 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("kubelet_client_key", "")
 
 	config.BindEnvAndSetDefault("kubernetes_pod_expiration_duration", 15*60) // in seconds, default 15 minutes
+	config.BindEnvAndSetDefault("kubernetes_deleted_pods_grace_period", 15)  // in seconds, before unscheduling the checks and removing the tags of deleted pods
 	config.BindEnvAndSetDefault("kubelet_wait_on_missing_container", 0)
 	config.BindEnvAndSetDefault("kubelet_cache_pods_duration", 5)       // Polling frequency in seconds of the agent to the kubelet "/pods" endpoint
 	config.BindEnvAndSetDefault("kubelet_listener_polling_interval", 5) // Polling frequency in seconds of the pod watcher to detect new pods/containers (affected by kubelet_cache_pods_duration setting)
 	config.BindEnvAndSetDefault("kubernetes_collect_metadata_tags", true)
+	config.BindEnvAndSetDefault("kubernetes_collect_pod_metadata_tags", false)
+	config.BindEnvAndSetDefault("kubernetes_pod_ip_index", false)
+	config.BindEnvAndSetDefault("kubernetes_pod_ip_index_max_age", 30)     // in seconds
+	config.BindEnvAndSetDefault("kubernetes_service_ip_map", false)        // serve the services by ClusterIP to the process agents
 	config.BindEnvAndSetDefault("kubernetes_metadata_tag_update_freq", 60) // Polling frequency of the Agent to the DCA in seconds (gets the local cache if the DCA is disabled)
 	config.BindEnvAndSetDefault("kubernetes_apiserver_client_timeout", 10)
+	config.BindEnvAndSetDefault("kubernetes_apiserver_list_page_size", 500)
+	config.BindEnvAndSetDefault("kubernetes_apiserver_cached_list", false) // serve the lists from the watch cache of the apiserver
+	config.BindEnvAndSetDefault("kubernetes_apiserver_client_qps", 5)      // Same defaults as client-go
+	config.BindEnvAndSetDefault("kubernetes_apiserver_client_burst", 10)
 	config.BindEnvAndSetDefault("kubernetes_map_services_on_ip", false) // temporary opt-out of the new mapping logic
+	config.BindEnvAndSetDefault("kubernetes_use_endpoint_slices", true) // map the services from the EndpointSlices when the API server serves them
 	config.BindEnvAndSetDefault("kubernetes_apiserver_use_protobuf", false)
+	config.BindEnvAndSetDefault("kubernetes_apiserver_protobuf_groups", []string{"core", "apps"}) // the other groups use JSON
+	config.BindEnvAndSetDefault("kubernetes_namespaces_include", []string{})
+	config.BindEnvAndSetDefault("kubernetes_node_health_lease.enabled", false)
+	config.BindEnvAndSetDefault("kubernetes_node_health_lease.interval", 60) // in seconds
 
 	// Kube ApiServer
 	config.BindEnvAndSetDefault("kubernetes_kubeconfig_path", "")
+	config.BindEnvAndSetDefault("kubernetes_token_store", "configmap") // configmap or secret
 	config.BindEnvAndSetDefault("leader_lease_duration", "60")
 	config.BindEnvAndSetDefault("leader_election", false)
+	config.BindEnvAndSetDefault("leader_election_resource", "configmap")
+	config.BindEnvAndSetDefault("leader_election_renew_deadline", 0)
 	config.BindEnvAndSetDefault("kube_resources_namespace", "")
 
 	// Datadog cluster agent
 	config.BindEnvAndSetDefault("cluster_agent.enabled", false)
 	config.BindEnvAndSetDefault("cluster_agent.auth_token", "")
+	config.BindEnvAndSetDefault("cluster_agent.service_account_token_path", "") // a projected token used instead of the auth token
+	config.BindEnvAndSetDefault("cluster_agent.token_review.enabled", false)
+	config.BindEnvAndSetDefault("cluster_agent.token_review.audience", "datadog-cluster-agent")
+	config.BindEnvAndSetDefault("cluster_agent.token_review.allowed_service_accounts", []string{}) // <namespace>:<name>, the namespace of the DCA when empty
 	config.BindEnvAndSetDefault("cluster_agent.url", "")
 	config.BindEnvAndSetDefault("cluster_agent.kubernetes_service_name", "datadog-cluster-agent")
 	config.BindEnvAndSetDefault("cluster_agent.tagging_fallback", false)
+	config.BindEnvAndSetDefault("cluster_agent.metadata_snapshot.enabled", false)
+	config.BindEnvAndSetDefault("cluster_agent.metadata_snapshot.path", filepath.Join(defaultRunPath, "metadata_mapper_snapshot.json"))
+	config.BindEnvAndSetDefault("cluster_agent.metadata_snapshot.interval", 60)          // in seconds
+	config.BindEnvAndSetDefault("cluster_agent.metadata_snapshot.max_age", 600)          // in seconds, older snapshots aren't restored
+	config.BindEnvAndSetDefault("cluster_agent.workload_blocklist_refresh_interval", 60) // in seconds
+	config.BindEnvAndSetDefault("cluster_agent.discovery_cache.ttl", 600)                // in seconds, 0 to disable the cache of the API discovery
+	config.BindEnvAndSetDefault("cluster_agent.discovery_cache.path", filepath.Join(defaultRunPath, "discovery_cache"))
+	config.BindEnvAndSetDefault("cluster_agent.controllers_max_goroutines", 0) // 0 for no limit
 	config.BindEnvAndSetDefault("metrics_port", "5000")
 
 	// Metadata endpoints
@@ -329,6 +430,12 @@ func initConfig(config Config) {
 	// GCE
 	config.BindEnvAndSetDefault("collect_gce_tags", true)
 
+	// Oracle Cloud
+	config.BindEnvAndSetDefault("collect_oracle_tags", true)
+
+	// Alibaba Cloud
+	config.BindEnvAndSetDefault("collect_alibaba_tags", true)
+
 	// Cloud Foundry
 	config.BindEnvAndSetDefault("cloud_foundry", false)
 	config.BindEnvAndSetDefault("bosh_id", "")
@@ -384,6 +491,10 @@ func initConfig(config Config) {
 	config.BindEnv("logs_config.processing_rules")
 	// enforce the agent to use files to collect container logs on kubernetes environment
 	config.BindEnvAndSetDefault("logs_config.k8s_container_use_file", false)
+	// send the logs with a priority status ahead of the others when the pipelines are backlogged
+	config.BindEnvAndSetDefault("logs_config.priority_queue.enabled", false)
+	config.BindEnvAndSetDefault("logs_config.priority_queue.statuses", []string{"emergency", "alert", "critical", "error"})
+	config.BindEnvAndSetDefault("logs_config.priority_queue.max_consecutive", 10)
 
 	// Internal Use Only: avoid modifying those configuration parameters, this could lead to unexpected results.
 	config.BindEnvAndSetDefault("logs_config.run_path", defaultRunPath)
@@ -422,6 +533,11 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("kubernetes_event_collection_timeout", 100)              // timeout between two successful event collections in milliseconds.
 	config.BindEnvAndSetDefault("kubernetes_informers_resync_period", 60*5)              // value in seconds. Default to 5 minutes
 	config.BindEnvAndSetDefault("external_metrics_provider.local_copy_refresh_rate", 30) // value in seconds
+	config.BindEnvAndSetDefault("external_metrics_provider.max_queries_per_call", 35)    // maximum number of metrics queried in a single call to Datadog
+	config.BindEnvAndSetDefault("external_metrics_provider.cache_staleness", 0)          // value in seconds. Serve the metrics queried less than this long ago from a cache refreshed in the background, 0 to disable
+	// values in seconds per resource (pods, services, endpointslices...), overriding kubernetes_informers_resync_period
+	config.BindEnvAndSetDefault("kubernetes_informers_resync_periods", map[string]string{})
+	config.BindEnvAndSetDefault("kubernetes_informers_stuck_watch_threshold", 60*30) // value in seconds, restart the watches silent for longer while the cluster is active. 0 to disable
 	// Cluster check Autodiscovery
 	config.BindEnvAndSetDefault("cluster_checks.enabled", false)
 	config.BindEnvAndSetDefault("cluster_checks.node_expiration_timeout", 30) // value in seconds
@@ -430,6 +546,15 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("cluster_checks.extra_tags", []string{})
 	config.BindEnvAndSetDefault("cluster_checks.advanced_dispatching_enabled", false)
 	config.BindEnvAndSetDefault("cluster_checks.clc_runners_port", 5005)
+
+	// Admission controller
+	config.BindEnvAndSetDefault("admission_controller.enabled", false)
+	config.BindEnvAndSetDefault("admission_controller.port", 8000)
+	config.BindEnvAndSetDefault("admission_controller.tls_cert_file", "")
+	config.BindEnvAndSetDefault("admission_controller.tls_key_file", "")
+	config.BindEnvAndSetDefault("admission_controller.pod_selector", "")       // label selector of the pods to mutate, all by default
+	config.BindEnvAndSetDefault("admission_controller.namespace_selector", "") // label selector of the namespaces of the pods to mutate, all by default
+	config.BindEnvAndSetDefault("admission_controller.dry_run", false)
 	// Cluster check runner
 	config.BindEnvAndSetDefault("clc_runner_enabled", false)
 	config.BindEnvAndSetDefault("clc_runner_host", "") // must be set using the Kubernetes downward API
@@ -440,6 +565,9 @@ func initConfig(config Config) {
 	// Declare other keys that don't have a default/env var.
 	// Mostly, keys we use IsSet() on, because IsSet always returns true if a key has a default.
 	config.SetKnown("metadata_providers")
+	config.SetKnown("kubernetes_kubeconfig_contexts")
+	config.SetKnown("dogstatsd_metric_prefix_rules")
+	config.SetKnown("downtimes")
 	config.SetKnown("config_providers")
 	config.SetKnown("clustername")
 	config.SetKnown("listeners")
@@ -467,6 +595,8 @@ func initConfig(config Config) {
 	config.SetKnown("process_config.additional_endpoints.*")
 	config.SetKnown("process_config.container_source")
 	config.SetKnown("process_config.intervals.connections")
+	config.SetKnown("process_config.connections_export.enabled")
+	config.SetKnown("process_config.connections_export.address")
 	config.SetKnown("process_config.expvar_port")
 
 	// System probe
@@ -490,6 +620,9 @@ func initConfig(config Config) {
 	config.SetKnown("system_probe_config.excluded_linux_versions")
 	config.SetKnown("system_probe_config.source_excludes")
 	config.SetKnown("system_probe_config.dest_excludes")
+	config.SetKnown("system_probe_config.filter_groups")
+	config.SetKnown("system_probe_config.network_connections_included")
+	config.SetKnown("system_probe_config.strict_connection_filters")
 	config.SetKnown("system_probe_config.closed_channel_size")
 
 	// Network
@@ -854,6 +987,12 @@ func FileUsedDir() string {
 	return filepath.Dir(Datadog.ConfigFileUsed())
 }
 
+// DefaultRunPath returns the folder of the files the agent keeps between its
+// runs, on this platform
+func DefaultRunPath() string {
+	return defaultRunPath
+}
+
 // GetIPCAddress returns the IPC address or an error if the address is not local
 func GetIPCAddress() (string, error) {
 	address := Datadog.GetString("ipc_address")
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package legacy
+
+import (
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// instance keys only found in the instances of the JMX checks
+var jmxInstanceKeys = []string{"jmx_url", "process_name_regex"}
+
+// convertCheckConf converts the configuration of an agent5 check, and reports
+// whether it has a logs section. The data is returned untouched, comments
+// included, when nothing needs to be converted.
+func convertCheckConf(checkName string, data []byte) ([]byte, bool, error) {
+	var conf yaml.MapSlice
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return nil, false, err
+	}
+
+	_, hasLogs := getItem(conf, "logs")
+
+	// agent5 ran any check connecting to a JMX server with jmxfetch, agent6
+	// needs the custom ones to be flagged with is_jmx in their init_config
+	if !needsJMXFlag(checkName, conf) {
+		return data, hasLogs, nil
+	}
+
+	initConfig, _ := getItem(conf, "init_config")
+	initMap, _ := initConfig.(yaml.MapSlice)
+	initMap = append(initMap, yaml.MapItem{Key: "is_jmx", Value: true})
+	conf = setItem(conf, "init_config", initMap)
+
+	converted, err := yaml.Marshal(conf)
+	if err != nil {
+		return nil, false, err
+	}
+	return converted, hasLogs, nil
+}
+
+// needsJMXFlag returns true for the custom JMX checks not flagged with is_jmx
+func needsJMXFlag(checkName string, conf yaml.MapSlice) bool {
+	if _, ok := config.StandardJMXIntegrations[checkName]; ok {
+		return false
+	}
+
+	initConfig, _ := getItem(conf, "init_config")
+	initMap, _ := initConfig.(yaml.MapSlice)
+	if _, found := getItem(initMap, "is_jmx"); found {
+		return false
+	}
+
+	instances, _ := getItem(conf, "instances")
+	instanceList, _ := instances.([]interface{})
+	for _, instance := range instanceList {
+		instanceMap, _ := instance.(yaml.MapSlice)
+		for _, key := range jmxInstanceKeys {
+			if _, found := getItem(instanceMap, key); found {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func getItem(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+func setItem(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package legacy
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertCheckConfUntouched(t *testing.T) {
+	data := []byte(`# keep the comments
+init_config:
+
+instances:
+  - url: http://localhost
+`)
+	converted, hasLogs, err := convertCheckConf("http_check", data)
+	require.NoError(t, err)
+	assert.False(t, hasLogs)
+	assert.Equal(t, data, converted)
+}
+
+func TestConvertCheckConfLogs(t *testing.T) {
+	data := []byte(`instances:
+  - {}
+logs:
+  - type: file
+    path: /var/log/app.log
+`)
+	converted, hasLogs, err := convertCheckConf("app", data)
+	require.NoError(t, err)
+	assert.True(t, hasLogs)
+	assert.Equal(t, data, converted)
+}
+
+func TestConvertCheckConfJMX(t *testing.T) {
+	data := []byte(`init_config:
+  custom_jar_paths:
+  - /opt/app/lib.jar
+instances:
+- jmx_url: service:jmx:rmi:///jndi/rmi://localhost:9999/jmxrmi
+`)
+	converted, _, err := convertCheckConf("myapp", data)
+	require.NoError(t, err)
+	assert.Equal(t, `init_config:
+  custom_jar_paths:
+  - /opt/app/lib.jar
+  is_jmx: true
+instances:
+- jmx_url: service:jmx:rmi:///jndi/rmi://localhost:9999/jmxrmi
+`, string(converted))
+
+	// the standard JMX checks don't need the flag
+	converted, _, err = convertCheckConf("tomcat", data)
+	require.NoError(t, err)
+	assert.Equal(t, data, converted)
+}
+
+func TestConfigWriterDryRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent_test_legacy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dst := filepath.Join(dir, "conf.yaml")
+	require.NoError(t, ioutil.WriteFile(dst, []byte("a: 1\n"), 0640))
+
+	var out bytes.Buffer
+	w := newConfigWriter(false, true, &out)
+	assert.Error(t, w.write(dst, []byte("a: 2\n"), false))
+
+	require.NoError(t, w.write(dst, []byte("a: 2\n"), true))
+	assert.Contains(t, out.String(), "-a: 1\n+a: 2\n")
+
+	// the file is left untouched
+	data, err := ioutil.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(data))
+	_, err = os.Stat(dst + ".bak")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestConvertFileDryRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent_test_legacy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dst := filepath.Join(dir, "trace-agent.conf")
+	var out bytes.Buffer
+	w := newConfigWriter(false, true, &out)
+	require.NoError(t, convertFile(w, "./tests/datadog.conf", dst, importTraceAgentConf))
+
+	// only the diff is printed, the converter's messages are dropped
+	assert.Contains(t, out.String(), "+++ "+dst)
+	assert.NotContains(t, out.String(), "Wrote")
+	_, err = os.Stat(dst)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -9,6 +9,7 @@ package legacy
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -30,12 +31,12 @@ const (
 	warningNewCheck string = "Warning: the new docker check includes a change in the exclude/include list that may impact your billing. Please check docs/agent/changes.md#docker-check documentation to learn more."
 )
 
-func handleFilterList(input []string, name string) []string {
+func handleFilterList(input []string, name string, out io.Writer) []string {
 	list := []string{}
 	for _, filter := range input {
 		items := strings.SplitN(filter, ":", 2)
 		if len(items) != 2 {
-			fmt.Printf(excludeIncludeBadFormat, name, filter)
+			fmt.Fprintf(out, excludeIncludeBadFormat, name, filter)
 			continue
 		}
 
@@ -46,7 +47,7 @@ func handleFilterList(input []string, name string) []string {
 		} else if prefix == "container_name" || prefix == "name" {
 			list = append(list, "name:"+value)
 		} else {
-			fmt.Printf(excludeIncludeWarn, name, prefix)
+			fmt.Fprintf(out, excludeIncludeWarn, name, prefix)
 		}
 	}
 	return list
@@ -66,7 +67,13 @@ type legacyDockerInstance struct {
 // and create the configuration for the new docker check (agent 6) and move
 // needed option to datadog.yaml
 func ImportDockerConf(src, dst string, overwrite bool) error {
-	fmt.Printf("%s\n", warningNewCheck)
+	return importDockerConf(src, dst, overwrite, os.Stdout)
+}
+
+// importDockerConf converts docker_daemon.yaml, printing the progress and the
+// warnings to out
+func importDockerConf(src, dst string, overwrite bool, out io.Writer) error {
+	fmt.Fprintf(out, "%s\n", warningNewCheck)
 
 	configConverter := config.NewConfigConverter()
 
@@ -92,7 +99,7 @@ func ImportDockerConf(src, dst string, overwrite bool) error {
 		return nil
 	}
 	if len(c.Instances) > 1 {
-		fmt.Printf("Warning: %s contains more than one instance: converting only the first one", src)
+		fmt.Fprintf(out, "Warning: %s contains more than one instance: converting only the first one", src)
 	}
 
 	dc := containers.DockerConfig{}
@@ -126,7 +133,7 @@ func ImportDockerConf(src, dst string, overwrite bool) error {
 		return fmt.Errorf("Could not write new docker configuration to %s: %s", dst, err)
 	}
 
-	fmt.Printf("Successfully imported the contents of %s into %s\n", src, dst)
+	fmt.Fprintf(out, "Successfully imported the contents of %s into %s\n", src, dst)
 
 	instance := &legacyDockerInstance{}
 	if err := yaml.Unmarshal(c.Instances[0], instance); err != nil {
@@ -134,11 +141,11 @@ func ImportDockerConf(src, dst string, overwrite bool) error {
 	}
 
 	// filter include/exclude list
-	if ac_exclude := handleFilterList(instance.Exclude, "exclude"); len(ac_exclude) != 0 {
+	if ac_exclude := handleFilterList(instance.Exclude, "exclude", out); len(ac_exclude) != 0 {
 		configConverter.Set("ac_exclude", ac_exclude)
 	}
 
-	if ac_include := handleFilterList(instance.Include, "include"); len(ac_include) != 0 {
+	if ac_include := handleFilterList(instance.Include, "include", out); len(ac_include) != 0 {
 		configConverter.Set("ac_include", ac_include)
 	}
 
@@ -151,6 +158,6 @@ func ImportDockerConf(src, dst string, overwrite bool) error {
 		configConverter.Set("docker_labels_as_tags", dockerLabelAsTags)
 	}
 
-	fmt.Printf("Successfully imported the contents of %s into datadog.yaml (see 'Autodiscovery' section in datadog.yaml.example)\n\n", src)
+	fmt.Fprintf(out, "Successfully imported the contents of %s into datadog.yaml (see 'Autodiscovery' section in datadog.yaml.example)\n\n", src)
 	return nil
 }
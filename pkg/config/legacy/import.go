@@ -0,0 +1,256 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package legacy
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	cfgExt = ".yaml"
+	dirExt = ".d"
+)
+
+// ImportOptions controls how ImportConfig writes the converted files
+type ImportOptions struct {
+	// Force overwrites the existing files, they're backed up to <file>.bak
+	Force bool
+	// DryRun prints the diff of every converted file instead of writing it
+	DryRun bool
+	// Out receives the progress messages and the diffs, defaults to color.Output
+	Out io.Writer
+}
+
+// checkConf is a converted check configuration waiting to be written
+type checkConf struct {
+	src  string
+	dst  string
+	data []byte
+}
+
+// ImportConfig imports the agent5 configuration found in oldConfigDir, that is
+// datadog.conf and the conf.d checks, into the agent6 configuration directory
+// newConfigDir.
+func ImportConfig(oldConfigDir, newConfigDir string, opts ImportOptions) error {
+	if opts.Out == nil {
+		opts.Out = color.Output
+	}
+	w := newConfigWriter(opts.Force, opts.DryRun, opts.Out)
+
+	datadogConfPath := filepath.Join(oldConfigDir, "datadog.conf")
+	datadogYamlPath := filepath.Join(newConfigDir, "datadog.yaml")
+	traceAgentConfPath := filepath.Join(newConfigDir, "trace-agent.conf")
+	oldConfd := filepath.Join(oldConfigDir, "conf.d")
+	newConfd := filepath.Join(newConfigDir, "conf.d")
+
+	// read the old configuration in memory
+	agentConfig, err := GetAgentConfig(datadogConfPath)
+	if err != nil {
+		return fmt.Errorf("unable to read data from %s: %v", datadogConfPath, err)
+	}
+
+	// we won't overwrite the conf file if it contains a valid api_key, unless
+	// forced or only printing the diff
+	if err := loadCurrentConfig(newConfigDir, datadogYamlPath, opts); err != nil {
+		return err
+	}
+
+	// convert the checks first, their logs sections enable the logs-agent
+	checks, logsEnabled, err := convertChecks(oldConfd, newConfd, opts.Out)
+	if err != nil {
+		return err
+	}
+
+	// merge current agent configuration with the converted data
+	if err := FromAgentConfig(agentConfig); err != nil {
+		return fmt.Errorf("unable to convert configuration data from %s: %v", datadogConfPath, err)
+	}
+	if logsEnabled {
+		config.Datadog.Set("logs_enabled", true)
+	}
+
+	b, err := yaml.Marshal(config.Datadog.AllSettings())
+	if err != nil {
+		return fmt.Errorf("unable to marshal config to YAML: %v", err)
+	}
+	// the api_key was already checked, datadog.yaml can always be overwritten
+	if err := w.write(datadogYamlPath, b, true); err != nil {
+		return fmt.Errorf("unable to write config to %s: %v", datadogYamlPath, err)
+	}
+	if !opts.DryRun {
+		fmt.Fprintf(opts.Out, "%s imported the contents of %s into %s\n",
+			color.GreenString("Success:"), datadogConfPath, datadogYamlPath)
+	}
+
+	for _, c := range checks {
+		if err := w.write(c.dst, c.data, false); err != nil {
+			return fmt.Errorf("unable to copy %s to %s: %v", c.src, c.dst, err)
+		}
+		if !opts.DryRun {
+			fmt.Fprintf(opts.Out, "Copied %s over to %s\n", color.BlueString(c.src), color.BlueString(c.dst))
+		}
+	}
+
+	// the docker_daemon and kubernetes checks are replaced by new checks
+	if err := convertFile(w, filepath.Join(oldConfd, "docker_daemon.yaml"), filepath.Join(newConfd, "docker.yaml"), importDockerConf); err != nil {
+		return err
+	}
+	if err := convertFile(w, filepath.Join(oldConfd, "kubernetes.yaml"), filepath.Join(newConfd, "kubelet.yaml"), importKubernetesConf); err != nil {
+		return err
+	}
+
+	// move existing config templates to the new auto_conf directory
+	autoConfFiles, err := ioutil.ReadDir(filepath.Join(oldConfd, "auto_conf"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to list auto_conf files from %s: %v", oldConfigDir, err)
+	}
+	for _, f := range autoConfFiles {
+		if f.IsDir() || filepath.Ext(f.Name()) != cfgExt {
+			continue
+		}
+		checkName := strings.TrimSuffix(f.Name(), cfgExt)
+		src := filepath.Join(oldConfd, "auto_conf", f.Name())
+		dst := filepath.Join(newConfd, checkName+dirExt, "auto_conf"+cfgExt)
+
+		input, err := ioutil.ReadFile(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to open %s: %v\n", src, err)
+			continue
+		}
+		// transform the AD configuration if needed
+		output := strings.Replace(string(input), "docker_images:", "ad_identifiers:", 1)
+		if err := w.write(dst, []byte(output), false); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to copy %s to %s: %v\n", src, dst, err)
+			continue
+		}
+		if !opts.DryRun {
+			fmt.Fprintf(opts.Out, "Copied %s over to %s\n", color.BlueString(src), color.BlueString(dst))
+		}
+	}
+
+	// extract trace-agent specific info and dump it to its own config file
+	if err := convertFile(w, datadogConfPath, traceAgentConfPath, importTraceAgentConf); err != nil {
+		return fmt.Errorf("failed to import Trace Agent specific settings: %v", err)
+	}
+
+	return nil
+}
+
+// loadCurrentConfig loads the existing datadog.yaml, if any, and refuses to
+// go on when it already contains an api_key, unless forced. A dry run never
+// writes datadog.yaml, it only prints its diff.
+func loadCurrentConfig(newConfigDir, datadogYamlPath string, opts ImportOptions) error {
+	if _, err := os.Stat(datadogYamlPath); os.IsNotExist(err) {
+		if opts.DryRun {
+			return nil
+		}
+		// the new config file might not exist, create it
+		f, err := os.Create(datadogYamlPath)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %v", datadogYamlPath, err)
+		}
+		f.Close()
+	}
+
+	config.Datadog.AddConfigPath(newConfigDir)
+	if err := config.Load(); err != nil {
+		return fmt.Errorf("unable to load Datadog config file: %s", err)
+	}
+	if config.Datadog.GetString("api_key") != "" && !opts.Force && !opts.DryRun {
+		return fmt.Errorf("%s seems to contain a valid configuration, run the command again with --force or -f to overwrite it",
+			datadogYamlPath)
+	}
+	return nil
+}
+
+// convertChecks converts the agent5 checks of oldConfd to be written to their
+// <check>.d directory in newConfd. It also reports whether one of the checks
+// has a logs section.
+func convertChecks(oldConfd, newConfd string, out io.Writer) ([]checkConf, bool, error) {
+	files, err := ioutil.ReadDir(oldConfd)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("unable to list config files from %s: %v", oldConfd, err)
+	}
+
+	var checks []checkConf
+	logsEnabled := false
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != cfgExt {
+			continue
+		}
+		switch f.Name() {
+		case "docker_daemon.yaml", "kubernetes.yaml":
+			// converted into the new checks by their own importer
+			continue
+		case "docker.yaml":
+			// if people upgrade from a very old version of the agent who ship the old docker check.
+			fmt.Fprintf(out, "Ignoring %s, old docker check has been deprecated.\n", filepath.Join(oldConfd, f.Name()))
+			continue
+		}
+		checkName := strings.TrimSuffix(f.Name(), cfgExt)
+		src := filepath.Join(oldConfd, f.Name())
+
+		data, err := ioutil.ReadFile(src)
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to read %s: %v", src, err)
+		}
+		converted, hasLogs, err := convertCheckConf(checkName, data)
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to convert %s: %v", src, err)
+		}
+		logsEnabled = logsEnabled || hasLogs
+		checks = append(checks, checkConf{
+			src:  src,
+			dst:  filepath.Join(newConfd, checkName+dirExt, "conf"+cfgExt),
+			data: converted,
+		})
+	}
+	return checks, logsEnabled, nil
+}
+
+// convertFile runs a converter writing its output to dst. In dry-run mode the
+// converter silently writes to a temporary directory so that only the diff is
+// printed.
+func convertFile(w *configWriter, src, dst string, convert func(src, dst string, overwrite bool, out io.Writer) error) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	if !w.dryRun {
+		return convert(src, dst, w.force, w.out)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "agent-import")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tmpDst := filepath.Join(tmpDir, filepath.Base(dst))
+	if err := convert(src, tmpDst, true, ioutil.Discard); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(tmpDst)
+	if os.IsNotExist(err) {
+		// nothing to convert
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return w.write(dst, data, false)
+}
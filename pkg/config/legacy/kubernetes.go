@@ -7,6 +7,7 @@ package legacy
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strconv"
@@ -70,13 +71,13 @@ func (k kubeDeprecations) add(field, message string) {
 	k[message] = append(k[message], field)
 }
 
-func (k kubeDeprecations) print() {
+func (k kubeDeprecations) print(out io.Writer) {
 	if len(k) == 0 {
 		return
 	}
-	fmt.Println("The following fields are deprecated and not converted:")
+	fmt.Fprintln(out, "The following fields are deprecated and not converted:")
 	for msg, fields := range k {
-		fmt.Printf("  - %s: %s\n", strings.Join(fields, ", "), msg)
+		fmt.Fprintf(out, "  - %s: %s\n", strings.Join(fields, ", "), msg)
 	}
 }
 
@@ -84,13 +85,19 @@ func (k kubeDeprecations) print() {
 // and create the configuration for the new kubelet check (agent 6) and moves
 // relevant options to datadog.yaml
 func ImportKubernetesConf(src, dst string, overwrite bool) error {
-	_, err := importKubernetesConfWithDeprec(src, dst, overwrite)
+	return importKubernetesConf(src, dst, overwrite, os.Stdout)
+}
+
+// importKubernetesConf converts kubernetes.yaml, printing the progress and the
+// deprecations to out
+func importKubernetesConf(src, dst string, overwrite bool, out io.Writer) error {
+	_, err := importKubernetesConfWithDeprec(src, dst, overwrite, out)
 	return err
 }
 
 // Deprecated options are listed in the kubeDeprecations return value, for testing
-func importKubernetesConfWithDeprec(src, dst string, overwrite bool) (kubeDeprecations, error) {
-	fmt.Printf("%s\n", warningNewKubeCheck)
+func importKubernetesConfWithDeprec(src, dst string, overwrite bool, out io.Writer) (kubeDeprecations, error) {
+	fmt.Fprintf(out, "%s\n", warningNewKubeCheck)
 	deprecations := make(kubeDeprecations)
 
 	// read kubernetes.yaml
@@ -103,7 +110,7 @@ func importKubernetesConfWithDeprec(src, dst string, overwrite bool) (kubeDeprec
 		return deprecations, nil
 	}
 	if len(c.Instances) > 1 {
-		fmt.Printf("Warning: %s contains more than one instance: converting only the first one", src)
+		fmt.Fprintf(out, "Warning: %s contains more than one instance: converting only the first one", src)
 	}
 
 	// kubelet.yaml (only tags for now)
@@ -132,7 +139,7 @@ func importKubernetesConfWithDeprec(src, dst string, overwrite bool) (kubeDeprec
 	if err := ioutil.WriteFile(dst, data, 0640); err != nil {
 		return deprecations, fmt.Errorf("Could not write new kubelet configuration to %s: %s", dst, err)
 	}
-	fmt.Printf("Successfully imported the contents of %s into %s\n", src, dst)
+	fmt.Fprintf(out, "Successfully imported the contents of %s into %s\n", src, dst)
 
 	// datadog.yaml
 	instance := &legacyKubernetesInstance{}
@@ -219,8 +226,8 @@ func importKubernetesConfWithDeprec(src, dst string, overwrite bool) (kubeDeprec
 		deprecations.add("port", deprecationCadvisorPort)
 	}
 
-	deprecations.print()
-	fmt.Printf("Successfully imported the contents of %s into datadog.yaml\n\n", src)
+	deprecations.print(out)
+	fmt.Fprintf(out, "Successfully imported the contents of %s into datadog.yaml\n\n", src)
 
 	return deprecations, nil
 }
@@ -122,7 +122,7 @@ func TestConvertKubernetes(t *testing.T) {
 	err = ioutil.WriteFile(srcEmpty, []byte(kubernetesLegacyEmptyConf), 0640)
 	require.NoError(t, err)
 
-	deprecations, err := importKubernetesConfWithDeprec(src, dst, true)
+	deprecations, err := importKubernetesConfWithDeprec(src, dst, true, ioutil.Discard)
 	require.NoError(t, err)
 	require.EqualValues(t, expectedKubeDeprecations, deprecations)
 
@@ -147,7 +147,7 @@ func TestConvertKubernetes(t *testing.T) {
 	assert.Equal(t, 3000, config.Datadog.GetInt("kubernetes_service_tag_update_freq"))
 
 	mockConfig.Set("kubelet_tls_verify", true)
-	deprecations, err = importKubernetesConfWithDeprec(srcEmpty, dstEmpty, true)
+	deprecations, err = importKubernetesConfWithDeprec(srcEmpty, dstEmpty, true, ioutil.Discard)
 	require.NoError(t, err)
 	assert.Equal(t, true, config.Datadog.GetBool("kubelet_tls_verify"))
 	assert.Equal(t, 0, len(deprecations))
@@ -9,10 +9,16 @@ package legacy
 
 import (
 	"fmt"
+	"io"
+	"os"
 )
 
 // ImportDockerConf is a place holder if the agent is built without the docker flag
 func ImportDockerConf(src, dst string, overwrite bool) error {
-	fmt.Println("This agent was build without docker support: could not convert docker_daemon.yaml")
+	return importDockerConf(src, dst, overwrite, os.Stdout)
+}
+
+func importDockerConf(src, dst string, overwrite bool, out io.Writer) error {
+	fmt.Fprintln(out, "This agent was build without docker support: could not convert docker_daemon.yaml")
 	return nil
 }
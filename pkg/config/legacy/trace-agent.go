@@ -6,6 +6,10 @@
 package legacy
 
 import (
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/go-ini/ini"
 )
 
@@ -42,3 +46,23 @@ func ImportTraceAgentConfig(datadogConfPath, traceAgentConfPath string) (bool, e
 
 	return false, nil
 }
+
+// importTraceAgentConf writes the trace-agent specific settings of src to dst,
+// an existing dst is backed up first when overwrite is set
+func importTraceAgentConf(src, dst string, overwrite bool, out io.Writer) error {
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		if !overwrite {
+			return fmt.Errorf("destination file %s already exists, run the command again with --force or -f to overwrite it", dst)
+		}
+		// we'll overwrite, backup the original file first
+		if err := os.Rename(dst, dst+".bak"); err != nil {
+			return fmt.Errorf("unable to create a backup for the existing file: %s", dst)
+		}
+	}
+
+	imported, err := ImportTraceAgentConfig(src, dst)
+	if imported {
+		fmt.Fprintf(out, "Wrote Trace Agent specific settings to %s\n", dst)
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package legacy
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// configWriter writes the converted configuration files. In dry-run mode,
+// it prints the diff between the existing files and the converted ones
+// instead of writing them.
+type configWriter struct {
+	force  bool
+	dryRun bool
+	out    io.Writer
+}
+
+func newConfigWriter(force, dryRun bool, out io.Writer) *configWriter {
+	return &configWriter{force: force, dryRun: dryRun, out: out}
+}
+
+// write writes data to dst, an existing dst is backed up to dst.bak when
+// overwriting is allowed by overwrite or by --force
+func (w *configWriter) write(dst string, data []byte, overwrite bool) error {
+	existing, err := ioutil.ReadFile(dst)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if exists && !overwrite && !w.force {
+		return fmt.Errorf("destination file %s already exists, run the command again with --force or -f to overwrite it", dst)
+	}
+
+	if w.dryRun {
+		return w.printDiff(dst, existing, data)
+	}
+
+	if exists {
+		if err := os.Rename(dst, dst+".bak"); err != nil {
+			return fmt.Errorf("unable to create a backup copy of %s: %v", dst, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	// file permissions have no effect on Windows
+	if err := ioutil.WriteFile(dst, data, 0640); err != nil {
+		return err
+	}
+	return chownToAgentUser(dst)
+}
+
+// printDiff prints the unified diff of a file, empty if the file is created
+func (w *configWriter) printDiff(dst string, existing, data []byte) error {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(existing)),
+		B:        difflib.SplitLines(string(data)),
+		FromFile: dst,
+		ToFile:   dst,
+		Context:  3,
+	})
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		fmt.Fprintf(w.out, "%s is unchanged\n", dst)
+		return nil
+	}
+	fmt.Fprint(w.out, diff)
+	return nil
+}
+
+// chownToAgentUser gives the file to the dd-agent user, if it exists. This
+// has no effect on Windows and macOS where the user isn't available.
+func chownToAgentUser(path string) error {
+	ddGroup, errGroup := user.LookupGroup("dd-agent")
+	ddUser, errUser := user.LookupId("dd-agent")
+	if errGroup != nil || errUser != nil {
+		return nil
+	}
+
+	ddGID, err := strconv.Atoi(ddGroup.Gid)
+	if err != nil {
+		return fmt.Errorf("Couldn't convert dd-agent group ID: %s into an int: %s", ddGroup.Gid, err)
+	}
+	ddUID, err := strconv.Atoi(ddUser.Uid)
+	if err != nil {
+		return fmt.Errorf("Couldn't convert dd-agent user ID: %s into an int: %s", ddUser.Uid, err)
+	}
+	if err := os.Chown(path, ddUID, ddGID); err != nil {
+		return fmt.Errorf("Couldn't change the file permissions for this check. Error: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Origins of a configuration value, in the order of precedence they are looked up
+const (
+	OriginEnvVar     = "environment variable"
+	OriginConfigFile = "configuration file"
+	OriginDefault    = "default"
+	OriginUnset      = "unset"
+)
+
+// ValueWithOrigin is the effective value of a configuration key in an agent,
+// and where it comes from
+type ValueWithOrigin struct {
+	Key string `json:"key"`
+	// Value is the YAML representation of the value, with the credentials scrubbed
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+	// EnvVar is the environment variable setting the value, for the OriginEnvVar origin
+	EnvVar string `json:"env_var,omitempty"`
+}
+
+// GetValueWithOrigin returns the effective value of a key and its origin. The
+// values set at runtime are reported with the origin they override.
+func GetValueWithOrigin(config Config, key string) (ValueWithOrigin, error) {
+	key = strings.ToLower(key)
+	v := ValueWithOrigin{Key: key, Origin: OriginUnset}
+
+	value := config.Get(key)
+	scrubbed, err := scrubValue(key, value)
+	if err != nil {
+		return v, err
+	}
+	v.Value = scrubbed
+
+	if envVar := envVarForKey(config, key); envVar != "" {
+		// like viper, ignore the empty env vars
+		if os.Getenv(envVar) != "" {
+			v.Origin = OriginEnvVar
+			v.EnvVar = envVar
+			return v, nil
+		}
+	}
+
+	inFile, err := inConfigFile(config.ConfigFileUsed(), key)
+	if err != nil {
+		log.Debugf("Unable to look for %s in the configuration file: %v", key, err)
+	}
+	if inFile {
+		v.Origin = OriginConfigFile
+	} else if value != nil {
+		v.Origin = OriginDefault
+	}
+	return v, nil
+}
+
+// envVarForKey returns the environment variable bound to a key, if any
+func envVarForKey(config Config, key string) string {
+	for _, envVar := range config.GetEnvVars() {
+		// the bound env vars are the env prefix and the key, before the env key replacer is applied
+		parts := strings.SplitN(strings.ToLower(envVar), "_", 2)
+		if len(parts) == 2 && parts[1] == key {
+			return strings.Replace(envVar, ".", "_", -1)
+		}
+	}
+	return ""
+}
+
+// inConfigFile returns whether a key, possibly nested, is set in a configuration file
+func inConfigFile(path, key string) (bool, error) {
+	if path == "" {
+		return false, nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	var node interface{}
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return false, err
+	}
+	for _, part := range strings.Split(key, ".") {
+		m, ok := node.(map[interface{}]interface{})
+		if !ok {
+			return false, nil
+		}
+		if node, ok = m[part]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// scrubValue renders a value as YAML with the credentials scrubbed, the
+// scrubber relying on the name of the key
+func scrubValue(key string, value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	prefix := key + ":"
+	b, err := yaml.Marshal(map[string]interface{}{key: value})
+	if err != nil {
+		return "", err
+	}
+	b, err = log.CredentialsCleanerBytes(b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimPrefix(string(b), prefix)), nil
+}
+
+// ValueWithOriginHandler serves the value and the origin of the key passed in
+// the `key` query parameter, for the `config check-origin` command
+func ValueWithOriginHandler(config Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		if key == "" {
+			body, _ := json.Marshal(map[string]string{"error": "missing key parameter"})
+			http.Error(w, string(body), http.StatusBadRequest)
+			return
+		}
+
+		v, err := GetValueWithOrigin(config, key)
+		if err != nil {
+			body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("unable to get the value of %s: %v", key, err)})
+			http.Error(w, string(body), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetValueWithOrigin(t *testing.T) {
+	f, err := ioutil.TempFile("", "datadog.yaml")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("log_level: debug\napm_config:\n  receiver_port: 8127\napi_key: aaaaaaaaaaaaaaaaaaaaaaaaaaaabbbb\n")
+	require.NoError(t, err)
+	f.Close()
+
+	os.Setenv("DD_HOSTNAME", "my-host")
+	defer os.Unsetenv("DD_HOSTNAME")
+
+	config := setupConf()
+	config.SetConfigFile(f.Name())
+	require.NoError(t, config.ReadInConfig())
+
+	v, err := GetValueWithOrigin(config, "hostname")
+	require.NoError(t, err)
+	assert.Equal(t, ValueWithOrigin{Key: "hostname", Value: "my-host", Origin: OriginEnvVar, EnvVar: "DD_HOSTNAME"}, v)
+
+	v, err = GetValueWithOrigin(config, "LOG_LEVEL")
+	require.NoError(t, err)
+	assert.Equal(t, ValueWithOrigin{Key: "log_level", Value: "debug", Origin: OriginConfigFile}, v)
+
+	v, err = GetValueWithOrigin(config, "apm_config.receiver_port")
+	require.NoError(t, err)
+	assert.Equal(t, OriginConfigFile, v.Origin)
+	assert.Equal(t, "8127", v.Value)
+
+	v, err = GetValueWithOrigin(config, "cmd_port")
+	require.NoError(t, err)
+	assert.Equal(t, ValueWithOrigin{Key: "cmd_port", Value: "5001", Origin: OriginDefault}, v)
+
+	v, err = GetValueWithOrigin(config, "unknown_key")
+	require.NoError(t, err)
+	assert.Equal(t, ValueWithOrigin{Key: "unknown_key", Origin: OriginUnset}, v)
+
+	// the credentials are scrubbed
+	v, err = GetValueWithOrigin(config, "api_key")
+	require.NoError(t, err)
+	assert.Equal(t, OriginConfigFile, v.Origin)
+	assert.NotContains(t, v.Value, "aaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+}
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package diagnose
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+)
+
+// RunContainerFilter prints, for every scope of the container filters,
+// whether the container is excluded and the rule that decided it.
+func RunContainerFilter(w io.Writer, c containers.FilterableContainer) error {
+	if w != color.Output {
+		color.NoColor = true
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(table, "SCOPE\tSTATUS\tRULE")
+	for _, scope := range append([]containers.Scope{containers.GlobalFilter}, containers.Scopes...) {
+		filter, err := containers.GetFilter(scope)
+		if err != nil {
+			return fmt.Errorf("invalid %s container filter: %v", scope, err)
+		}
+
+		excluded, match := filter.Explain(c)
+		status, rule := color.GreenString("included"), "no matching rule"
+		if excluded {
+			status = color.RedString("excluded")
+		}
+		if match != nil {
+			rule = fmt.Sprintf("%s (from the %s list)", match.Pattern, listName(match))
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\n", scope, status, rule)
+	}
+	return table.Flush()
+}
+
+// listName returns the name of the configuration option holding the rule
+func listName(match *containers.FilterRuleMatch) string {
+	kind := "exclude"
+	if match.Included {
+		kind = "include"
+	}
+	if match.Scope == containers.GlobalFilter {
+		return fmt.Sprintf("ac_%s/container_%s", kind, kind)
+	}
+	return fmt.Sprintf("container_%s_%s", kind, match.Scope)
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package diagnose
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+// RunKubeRBAC reviews the permissions the features enabled in the
+// configuration need on the Kubernetes API server, and prints a table of the
+// granted and denied ones with remediation hints. It returns an error if a
+// permission is denied.
+func RunKubeRBAC(w io.Writer, clusterAgent bool) error {
+	if w != color.Output {
+		color.NoColor = true
+	}
+
+	reqs := apiserver.RBACRequirements(clusterAgent)
+	if len(reqs) == 0 {
+		fmt.Fprintln(w, "No permission needed on the Kubernetes API server with the current configuration")
+		return nil
+	}
+	cl, err := apiserver.GetAPIClient()
+	if err != nil {
+		return fmt.Errorf("cannot connect to the Kubernetes API server: %v", err)
+	}
+
+	reviews := cl.ReviewRBAC(reqs)
+	denied := renderRBACReviews(w, reviews)
+	if denied > 0 {
+		return fmt.Errorf("%d of the %d permissions needed are denied", denied, len(reviews))
+	}
+	return nil
+}
+
+// renderRBACReviews prints the reviews and the remediation of the denied
+// permissions, it returns the number of permissions denied or not reviewed
+func renderRBACReviews(w io.Writer, reviews []apiserver.RBACReview) int {
+	var remediations []string
+	table := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(table, "PERMISSION\tFEATURE\tSTATUS\tREASON")
+	for _, review := range reviews {
+		status, reason := color.GreenString("granted"), review.Reason
+		switch {
+		case review.Err != nil:
+			status, reason = color.YellowString("unknown"), review.Err.Error()
+			remediations = append(remediations, fmt.Sprintf("%s: the review failed, the agent may lack the rights to create selfsubjectaccessreviews", review.RBACRequirement))
+		case !review.Allowed:
+			status = color.RedString("denied")
+			remediations = append(remediations, fmt.Sprintf("%s: %s", review.RBACRequirement, review.Remediation()))
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", review.RBACRequirement, review.Feature, status, reason)
+	}
+	table.Flush()
+
+	if len(remediations) > 0 {
+		fmt.Fprintln(w, "\nTo grant the missing permissions:")
+		for _, remediation := range remediations {
+			fmt.Fprintf(w, "  - %s\n", remediation)
+		}
+	}
+	return len(remediations)
+}
@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build !kubeapiserver
+
+package diagnose
+
+import (
+	"errors"
+	"io"
+)
+
+// RunKubeRBAC is not available without the Kubernetes API server support
+func RunKubeRBAC(w io.Writer, clusterAgent bool) error {
+	return errors.New("kubernetes apiserver support not compiled in")
+}
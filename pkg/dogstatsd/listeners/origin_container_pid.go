@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build linux,docker
+
+package listeners
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	containerPIDsCacheDuration = 30 * time.Second
+	// maxProcessTreeDepth bounds the walk up the ancestors of the sender
+	maxProcessTreeDepth = 32
+	// maxResolvedPIDs bounds the cache of the entities of the sender PIDs,
+	// it is emptied by each refresh of the container PIDs
+	maxResolvedPIDs = 4096
+)
+
+var (
+	containerPIDsMutex sync.Mutex
+	// containerPIDs and resolvedPIDs are replaced, never modified, by the
+	// refreshes running in the background
+	containerPIDs        map[int32]string
+	containerPIDsUpdated time.Time
+	containerPIDsRefresh bool
+	resolvedPIDs         map[int32]string
+
+	ppidPrefix = []byte("PPid:")
+)
+
+// getEntityForContainerPID returns the entity of the container whose main PID
+// is the PID or one of its ancestors. It works when the container IDs cannot
+// be read from the cgroups, as the container runtime reports the main PIDs.
+// It never waits for the container runtime: the PIDs are refreshed in the
+// background, the containers started since the last refresh are not matched.
+func getEntityForContainerPID(pid int32) (string, error) {
+	pids, resolved := getContainerPIDs()
+	if len(pids) == 0 {
+		return NoOrigin, nil
+	}
+
+	containerPIDsMutex.Lock()
+	entity, found := resolved[pid]
+	containerPIDsMutex.Unlock()
+	if found {
+		return entity, nil
+	}
+
+	entity = NoOrigin
+	for i, ancestor := 0, pid; i < maxProcessTreeDepth && ancestor > 1; i++ {
+		if id, found := pids[ancestor]; found {
+			entity = containers.BuildTaggerEntityName(id)
+			break
+		}
+		var err error
+		if ancestor, err = parentPID(ancestor); err != nil {
+			return NoOrigin, err
+		}
+	}
+
+	containerPIDsMutex.Lock()
+	if len(resolved) < maxResolvedPIDs {
+		resolved[pid] = entity
+	}
+	containerPIDsMutex.Unlock()
+	return entity, nil
+}
+
+// getContainerPIDs returns the container IDs by main PID and the cache of
+// the resolved PIDs, and starts a refresh from the docker daemon if they are
+// older than containerPIDsCacheDuration
+func getContainerPIDs() (map[int32]string, map[int32]string) {
+	containerPIDsMutex.Lock()
+	defer containerPIDsMutex.Unlock()
+	if !containerPIDsRefresh && time.Since(containerPIDsUpdated) >= containerPIDsCacheDuration {
+		containerPIDsRefresh = true
+		go refreshContainerPIDs()
+	}
+	return containerPIDs, resolvedPIDs
+}
+
+// refreshContainerPIDs lists the main PIDs of the docker containers
+func refreshContainerPIDs() {
+	pids, err := listContainerPIDs()
+	if err != nil {
+		log.Debugf("Could not list the PIDs of the containers: %v", err)
+	}
+
+	containerPIDsMutex.Lock()
+	defer containerPIDsMutex.Unlock()
+	if err == nil {
+		containerPIDs, resolvedPIDs = pids, make(map[int32]string)
+	}
+	// failed refreshes are retried after the cache duration as well
+	containerPIDsUpdated, containerPIDsRefresh = time.Now(), false
+}
+
+func listContainerPIDs() (map[int32]string, error) {
+	du, err := docker.GetDockerUtil()
+	if err != nil {
+		// no docker daemon to map the PIDs with
+		return nil, nil
+	}
+	list, err := du.RawContainerList(types.ContainerListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pids := make(map[int32]string, len(list))
+	for _, c := range list {
+		inspect, err := du.Inspect(c.ID, false)
+		if err != nil || inspect.State == nil || inspect.State.Pid <= 0 {
+			continue
+		}
+		pids[int32(inspect.State.Pid)] = c.ID
+	}
+	return pids, nil
+}
+
+// parentPID reads the parent PID of the process from its status in procfs
+func parentPID(pid int32) (int32, error) {
+	path := filepath.Join(config.Datadog.GetString("container_proc_root"), strconv.Itoa(int(pid)), "status")
+	status, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(status))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !bytes.HasPrefix(line, ppidPrefix) {
+			continue
+		}
+		ppid, err := strconv.Atoi(string(bytes.TrimSpace(line[len(ppidPrefix):])))
+		if err != nil {
+			return 0, err
+		}
+		return int32(ppid), nil
+	}
+	return 0, fmt.Errorf("no parent PID in %s", path)
+}
@@ -0,0 +1,14 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build !linux !docker
+
+package listeners
+
+// getEntityForContainerPID never matches when the agent is built without
+// docker support or on non-linux hosts
+func getEntityForContainerPID(pid int32) (string, error) {
+	return NoOrigin, nil
+}
@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package listeners
+
+import (
+	"bytes"
+	"expvar"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+)
+
+// Steps of the origin detection, configured in `dogstatsd_origin_detection_steps`
+const (
+	// originStepPeerCredentials reads the PID of the sender from the
+	// credentials of the socket, the other PID based steps require it
+	originStepPeerCredentials = "peer_credentials"
+	// originStepCgroup parses the container ID from the cgroups of the PID
+	originStepCgroup = "cgroup"
+	// originStepContainerPID matches the PID, or one of its ancestors, with
+	// the main PID of the containers reported by the container runtime
+	originStepContainerPID = "container_pid"
+	// originStepClientTag trusts the container ID sent by the client in the
+	// `dd.internal.container_id` tag
+	originStepClientTag = "client_tag"
+)
+
+var (
+	originDetectionExpvars = expvar.NewMap("dogstatsd-origin-detection")
+
+	containerIDTagPrefix    = []byte("dd.internal.container_id:")
+	lenContainerIDTagPrefix = len(containerIDTagPrefix)
+)
+
+// originRequest holds what is known about the sender of a packet while going
+// through the origin detection steps
+type originRequest struct {
+	ancillary []byte
+	contents  []byte
+	// pid is set by the peer credentials step, 0 if unknown
+	pid int32
+	// origin is set by the step detecting it
+	origin string
+}
+
+// originStep is a step of the origin detection, resolve returns false if the
+// step didn't learn anything about the sender
+type originStep struct {
+	name    string
+	resolve func(req *originRequest) (bool, error)
+
+	hits   expvar.Int
+	misses expvar.Int
+	errors expvar.Int
+}
+
+var originSteps = map[string]func(req *originRequest) (bool, error){
+	originStepPeerCredentials: resolvePeerCredentials,
+	originStepCgroup:          withPID(getEntityForPID),
+	originStepContainerPID:    withPID(getEntityForContainerPID),
+	originStepClientTag:       resolveClientTag,
+}
+
+// originChain runs the origin detection steps in order until one detects the
+// origin of the packet. The steps misbehaving on some container runtimes can
+// be removed from the configuration.
+type originChain struct {
+	steps []*originStep
+}
+
+// newOriginChain returns the originChain running the steps in this order
+func newOriginChain(names []string) (*originChain, error) {
+	chain := &originChain{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		resolve, found := originSteps[name]
+		if !found {
+			return nil, fmt.Errorf("unknown origin detection step %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("origin detection step %q configured twice", name)
+		}
+		if (name == originStepCgroup || name == originStepContainerPID) && !seen[originStepPeerCredentials] {
+			return nil, fmt.Errorf("origin detection step %q requires the %q step before it", name, originStepPeerCredentials)
+		}
+		seen[name] = true
+
+		step := &originStep{name: name, resolve: resolve}
+		stepExpvars := &expvar.Map{}
+		stepExpvars.Set("Hits", &step.hits)
+		stepExpvars.Set("Misses", &step.misses)
+		stepExpvars.Set("Errors", &step.errors)
+		originDetectionExpvars.Set(name, stepExpvars)
+		chain.steps = append(chain.steps, step)
+	}
+	return chain, nil
+}
+
+// needsCredentials returns whether the credentials of the sender must be
+// passed with the packets
+func (c *originChain) needsCredentials() bool {
+	for _, step := range c.steps {
+		if step.name == originStepPeerCredentials {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the origin of the packet, or NoOrigin and the error of the
+// last failing step if no step detected it
+func (c *originChain) resolve(ancillary, contents []byte) (string, error) {
+	req := &originRequest{ancillary: ancillary, contents: contents, origin: NoOrigin}
+	var lastErr error
+	for _, step := range c.steps {
+		ok, err := step.resolve(req)
+		if err != nil {
+			step.errors.Add(1)
+			lastErr = fmt.Errorf("%s: %v", step.name, err)
+			continue
+		}
+		if !ok {
+			step.misses.Add(1)
+			continue
+		}
+		step.hits.Add(1)
+		if req.origin != NoOrigin {
+			return req.origin, nil
+		}
+	}
+	return NoOrigin, lastErr
+}
+
+func resolvePeerCredentials(req *originRequest) (bool, error) {
+	pid, err := pidFromAncillary(req.ancillary)
+	if err != nil {
+		return false, err
+	}
+	req.pid = pid
+	return true, nil
+}
+
+// withPID wraps the steps detecting the origin from the PID of the sender
+func withPID(entityForPID func(pid int32) (string, error)) func(req *originRequest) (bool, error) {
+	return func(req *originRequest) (bool, error) {
+		if req.pid == 0 {
+			return false, nil
+		}
+		entity, err := entityForPID(req.pid)
+		if err != nil {
+			return false, err
+		}
+		if entity == NoOrigin {
+			return false, nil
+		}
+		req.origin = entity
+		return true, nil
+	}
+}
+
+// resolveClientTag looks for the container ID tag in the packet, all its
+// messages are assumed to be sent by the same container
+func resolveClientTag(req *originRequest) (bool, error) {
+	i := bytes.Index(req.contents, containerIDTagPrefix)
+	if i == -1 {
+		return false, nil
+	}
+	id := req.contents[i+lenContainerIDTagPrefix:]
+	if end := bytes.IndexAny(id, ",|\n"); end != -1 {
+		id = id[:end]
+	}
+	if len(id) == 0 {
+		return false, nil
+	}
+	req.origin = containers.BuildTaggerEntityName(string(id))
+	return true, nil
+}
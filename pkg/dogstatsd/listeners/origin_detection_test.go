@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package listeners
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOriginChain(t *testing.T) {
+	chain, err := newOriginChain([]string{originStepPeerCredentials, originStepContainerPID, originStepCgroup})
+	require.NoError(t, err)
+	assert.True(t, chain.needsCredentials())
+	require.Len(t, chain.steps, 3)
+	assert.Equal(t, originStepCgroup, chain.steps[2].name)
+
+	chain, err = newOriginChain([]string{originStepClientTag})
+	require.NoError(t, err)
+	assert.False(t, chain.needsCredentials())
+
+	for _, steps := range [][]string{
+		{"unknown"},
+		{originStepClientTag, originStepClientTag},
+		{originStepCgroup, originStepPeerCredentials},
+	} {
+		_, err = newOriginChain(steps)
+		assert.Error(t, err, "%v", steps)
+	}
+}
+
+func TestOriginChainFallback(t *testing.T) {
+	chain := &originChain{steps: []*originStep{
+		{name: "failing", resolve: func(req *originRequest) (bool, error) { return false, errors.New("boom") }},
+		{name: "missing", resolve: func(req *originRequest) (bool, error) { return false, nil }},
+		{name: originStepClientTag, resolve: resolveClientTag},
+	}}
+
+	origin, err := chain.resolve(nil, []byte("metric:1|g|#env:prod,dd.internal.container_id:abc123\nmetric:2|g"))
+	assert.NoError(t, err)
+	assert.Equal(t, "container_id://abc123", origin)
+	assert.EqualValues(t, 1, chain.steps[0].errors.Value())
+	assert.EqualValues(t, 1, chain.steps[1].misses.Value())
+	assert.EqualValues(t, 1, chain.steps[2].hits.Value())
+
+	origin, err = chain.resolve(nil, []byte("metric:1|g|#env:prod"))
+	assert.EqualError(t, err, "failing: boom")
+	assert.Equal(t, NoOrigin, origin)
+	assert.EqualValues(t, 1, chain.steps[2].misses.Value())
+}
+
+func TestOriginChainPIDSteps(t *testing.T) {
+	entityForPID := func(pid int32) (string, error) {
+		if pid == 42 {
+			return "container_id://forty-two", nil
+		}
+		return NoOrigin, nil
+	}
+	chain := &originChain{steps: []*originStep{
+		{name: originStepPeerCredentials, resolve: func(req *originRequest) (bool, error) {
+			req.pid = int32(len(req.ancillary))
+			return req.pid != 0, nil
+		}},
+		{name: originStepCgroup, resolve: withPID(entityForPID)},
+	}}
+
+	origin, err := chain.resolve(make([]byte, 42), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "container_id://forty-two", origin)
+
+	origin, err = chain.resolve(make([]byte, 7), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, NoOrigin, origin)
+
+	// no PID, the step is skipped
+	origin, err = chain.resolve(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, NoOrigin, origin)
+	assert.EqualValues(t, 2, chain.steps[1].misses.Value())
+}
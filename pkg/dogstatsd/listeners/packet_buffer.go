@@ -8,8 +8,14 @@ package listeners
 import (
 	"sync"
 	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/buffer"
 )
 
+// PacketsBufferName is the name of the instrumented buffer of the channel the
+// listeners flush their packets to
+const PacketsBufferName = "dogstatsd_packets"
+
 // packetBuffer is a buffer of packet that will automatically flush to configurable channel
 // when it is full or after a configurable duration
 type packetBuffer struct {
@@ -17,6 +23,7 @@ type packetBuffer struct {
 	flushTimer    *time.Ticker
 	bufferSize    uint
 	outputChannel chan Packets
+	outputBuffer  *buffer.Buffer
 	closeChannel  chan struct{}
 	m             sync.Mutex
 }
@@ -26,6 +33,7 @@ func newPacketBuffer(bufferSize uint, flushTimer time.Duration, outputChannel ch
 		bufferSize:    bufferSize,
 		flushTimer:    time.NewTicker(flushTimer),
 		outputChannel: outputChannel,
+		outputBuffer:  buffer.Get(PacketsBufferName),
 		packets:       make(Packets, 0, bufferSize),
 		closeChannel:  make(chan struct{}),
 	}
@@ -55,9 +63,13 @@ func (pb *packetBuffer) append(packet *Packet) {
 	}
 }
 
+// flush sends the packets to the workers, they are dropped, and counted by the
+// buffer, while the workers are behind instead of blocking the listener
 func (pb *packetBuffer) flush() {
 	if len(pb.packets) > 0 {
-		pb.outputChannel <- pb.packets
+		if pb.outputBuffer.TryAcquire() {
+			pb.outputChannel <- pb.packets
+		}
 		pb.packets = make(Packets, 0, pb.bufferSize)
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"sync"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -33,16 +34,22 @@ func init() {
 // It listens to a given UDP address and sends back packets ready to be
 // processed.
 // Origin detection is not implemented for UDP.
+// With `dogstatsd_so_reuseport_workers` > 1, several sockets are bound to the
+// address with SO_REUSEPORT, the kernel balancing the packets between them.
 type UDPListener struct {
+	sockets    []*udpSocket
+	packetPool *PacketPool
+}
+
+// udpSocket is a socket of the UDPListener, read by its own goroutine into
+// its own packetBuffer
+type udpSocket struct {
 	conn         net.PacketConn
-	packetPool   *PacketPool
 	packetBuffer *packetBuffer
 }
 
 // NewUDPListener returns an idle UDP Statsd listener
 func NewUDPListener(packetOut chan Packets, packetPool *PacketPool) (*UDPListener, error) {
-	var conn net.PacketConn
-	var err error
 	var url string
 
 	if config.Datadog.GetBool("dogstatsd_non_local_traffic") == true {
@@ -52,35 +59,76 @@ func NewUDPListener(packetOut chan Packets, packetPool *PacketPool) (*UDPListene
 		url = net.JoinHostPort(config.Datadog.GetString("bind_host"), config.Datadog.GetString("dogstatsd_port"))
 	}
 
-	conn, err = net.ListenPacket("udp", url)
+	workers := config.Datadog.GetInt("dogstatsd_so_reuseport_workers")
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > 1 && !reusePortSupported {
+		log.Warnf("dogstatsd-udp: dogstatsd_so_reuseport_workers is not supported on this platform, using a single socket")
+		workers = 1
+	}
 
-	if rcvbuf := config.Datadog.GetInt("dogstatsd_so_rcvbuf"); rcvbuf != 0 {
-		if err := conn.(*net.UDPConn).SetReadBuffer(rcvbuf); err != nil {
-			return nil, fmt.Errorf("could not set socket rcvbuf: %s", err)
+	listener := &UDPListener{
+		packetPool: packetPool,
+	}
+	for i := 0; i < workers; i++ {
+		conn, err := listenUDP(url, workers > 1)
+		if err != nil {
+			listener.Stop()
+			return nil, err
 		}
+		listener.sockets = append(listener.sockets, &udpSocket{
+			conn: conn,
+			packetBuffer: newPacketBuffer(uint(config.Datadog.GetInt("dogstatsd_packet_buffer_size")),
+				config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout"), packetOut),
+		})
 	}
+	log.Debugf("dogstatsd-udp: %s successfully initialized with %d socket(s)", listener.sockets[0].conn.LocalAddr(), workers)
+	return listener, nil
+}
 
+// listenUDP binds a UDP socket to url, with SO_REUSEPORT if reusePort is set
+func listenUDP(url string, reusePort bool) (net.PacketConn, error) {
+	var conn net.PacketConn
+	var err error
+	if reusePort {
+		conn, err = listenUDPReusePort(url)
+	} else {
+		conn, err = net.ListenPacket("udp", url)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("can't listen: %s", err)
 	}
 
-	listener := &UDPListener{
-		packetPool: packetPool,
-		conn:       conn,
-		packetBuffer: newPacketBuffer(uint(config.Datadog.GetInt("dogstatsd_packet_buffer_size")),
-			config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout"), packetOut),
+	if rcvbuf := config.Datadog.GetInt("dogstatsd_so_rcvbuf"); rcvbuf != 0 {
+		if err := conn.(*net.UDPConn).SetReadBuffer(rcvbuf); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("could not set socket rcvbuf: %s", err)
+		}
 	}
-	log.Debugf("dogstatsd-udp: %s successfully initialized", conn.LocalAddr())
-	return listener, nil
+	return conn, nil
 }
 
-// Listen runs the intake loop. Should be called in its own goroutine
+// Listen runs the intake loop of every socket. Should be called in its own goroutine
 func (l *UDPListener) Listen() {
-	log.Infof("dogstatsd-udp: starting to listen on %s", l.conn.LocalAddr())
+	log.Infof("dogstatsd-udp: starting to listen on %s", l.sockets[0].conn.LocalAddr())
+	var wg sync.WaitGroup
+	for _, socket := range l.sockets {
+		wg.Add(1)
+		go func(socket *udpSocket) {
+			defer wg.Done()
+			l.listen(socket)
+		}(socket)
+	}
+	wg.Wait()
+}
+
+// listen reads the packets of a socket until it's closed
+func (l *UDPListener) listen(socket *udpSocket) {
 	for {
 		packet := l.packetPool.Get()
 		udpPackets.Add(1)
-		n, _, err := l.conn.ReadFrom(packet.buffer)
+		n, _, err := socket.conn.ReadFrom(packet.buffer)
 		if err != nil {
 			// connection has been closed
 			if strings.HasSuffix(err.Error(), " use of closed network connection") {
@@ -95,12 +143,14 @@ func (l *UDPListener) Listen() {
 		packet.Contents = packet.buffer[:n]
 
 		// packetBuffer handles the forwarding of the packets to the dogstatsd server intake channel
-		l.packetBuffer.append(packet)
+		socket.packetBuffer.append(packet)
 	}
 }
 
-// Stop closes the UDP connection and stops listening
+// Stop closes the UDP connections and stops listening
 func (l *UDPListener) Stop() {
-	l.packetBuffer.close()
-	l.conn.Close()
+	for _, socket := range l.sockets {
+		socket.packetBuffer.close()
+		socket.conn.Close()
+	}
 }
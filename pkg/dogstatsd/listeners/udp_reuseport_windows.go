@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package listeners
+
+import (
+	"fmt"
+	"net"
+)
+
+const reusePortSupported = false
+
+// listenUDPReusePort is not supported on Windows
+func listenUDPReusePort(address string) (net.PacketConn, error) {
+	return nil, fmt.Errorf("SO_REUSEPORT is not supported on windows")
+}
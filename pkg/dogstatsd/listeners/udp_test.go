@@ -142,6 +142,47 @@ func TestUDPReceive(t *testing.T) {
 	}
 }
 
+func TestUDPReusePortReceive(t *testing.T) {
+	var contents = []byte("daemon:666|g|#sometag1:somevalue1,sometag2:somevalue2")
+
+	port, err := getAvailableUDPPort()
+	require.Nil(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
+	config.Datadog.SetDefault("dogstatsd_so_reuseport_workers", 4)
+	defer config.Datadog.SetDefault("dogstatsd_so_reuseport_workers", 1)
+
+	packetChannel := make(chan Packets)
+	s, err := NewUDPListener(packetChannel, packetPoolUDP)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	assert.Len(t, s.sockets, 4)
+
+	go s.Listen()
+	defer s.Stop()
+
+	// Local port should be unavailable without SO_REUSEPORT
+	address, _ := net.ResolveUDPAddr("udp", fmt.Sprintf("127.0.0.1:%d", port))
+	_, err = net.ListenUDP("udp", address)
+	assert.NotNil(t, err)
+
+	// the packets of every client are received, whatever the socket
+	for i := 0; i < 8; i++ {
+		conn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", port))
+		require.NoError(t, err)
+		conn.Write(contents)
+		conn.Close()
+
+		select {
+		case packets := <-packetChannel:
+			require.Equal(t, 1, len(packets))
+			assert.Equal(t, contents, packets[0].Contents)
+		case <-time.After(2 * time.Second):
+			assert.FailNow(t, "Timeout on receive channel")
+		}
+	}
+}
+
 // getAvailableUDPPort requests a random port number and makes sure it is available
 func getAvailableUDPPort() (int, error) {
 	conn, err := net.ListenPacket("udp", ":0")
@@ -43,6 +43,7 @@ type UDSListener struct {
 	packetPool      *PacketPool
 	oobPool         *sync.Pool // For origin detection ancilary data
 	OriginDetection bool
+	originChain     *originChain
 }
 
 // NewUDSListener returns an idle UDS Statsd listener
@@ -76,11 +77,21 @@ func NewUDSListener(packetOut chan Packets, packetPool *PacketPool) (*UDSListene
 		return nil, fmt.Errorf("can't set the socket at write only: %s", err)
 	}
 
+	var chain *originChain
 	if originDetection {
+		chain, err = newOriginChain(config.Datadog.GetStringSlice("dogstatsd_origin_detection_steps"))
+		if err != nil {
+			log.Errorf("dogstatsd-uds: invalid origin detection steps: %s", err)
+			originDetection = false
+		}
+	}
+	passCred := originDetection && chain.needsCredentials()
+	if passCred {
 		err = enableUDSPassCred(conn)
 		if err != nil {
 			log.Errorf("dogstatsd-uds: error enabling origin detection: %s", err)
 			originDetection = false
+			passCred = false
 		} else {
 			log.Debugf("dogstatsd-uds: enabling origin detection on %s", conn.LocalAddr())
 
@@ -95,14 +106,15 @@ func NewUDSListener(packetOut chan Packets, packetPool *PacketPool) (*UDSListene
 
 	listener := &UDSListener{
 		OriginDetection: originDetection,
+		originChain:     chain,
 		packetPool:      packetPool,
 		conn:            conn,
 		packetBuffer: newPacketBuffer(uint(config.Datadog.GetInt("dogstatsd_packet_buffer_size")),
 			config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout"), packetOut),
 	}
 
-	// Init the oob buffer pool if origin detection is enabled
-	if originDetection {
+	// Init the oob buffer pool if the credentials are passed
+	if passCred {
 		listener.oobPool = &sync.Pool{
 			New: func() interface{} {
 				return make([]byte, getUDSAncillarySize())
@@ -122,24 +134,30 @@ func (l *UDSListener) Listen() {
 		var err error
 		packet := l.packetPool.Get()
 		udsPackets.Add(1)
-		if l.OriginDetection {
+		var oob []byte
+		var oobn int
+		if l.oobPool != nil {
 			// Read datagram + credentials in ancilary data
-			oob := l.oobPool.Get().([]byte)
-			var oobn int
+			oob = l.oobPool.Get().([]byte)
 			n, oobn, _, _, err = l.conn.ReadMsgUnix(packet.buffer, oob)
-			// Extract container id from credentials
-			container, taggingErr := processUDSOrigin(oob[:oobn])
+		} else {
+			// Read only datagram contents with no credentials
+			n, _, err = l.conn.ReadFromUnix(packet.buffer)
+		}
+
+		if l.OriginDetection && err == nil {
+			// Extract container id from credentials, or from the contents
+			container, taggingErr := l.originChain.resolve(oob[:oobn], packet.buffer[:n])
 			if taggingErr != nil {
 				log.Warnf("dogstatsd-uds: error processing origin, data will not be tagged : %v", taggingErr)
 				udsOriginDetectionErrors.Add(1)
 			} else {
 				packet.Origin = container
 			}
+		}
+		if oob != nil {
 			// Return the buffer back to the pool for reuse
 			l.oobPool.Put(oob)
-		} else {
-			// Read only datagram contents with no credentials
-			n, _, err = l.conn.ReadFromUnix(packet.buffer)
 		}
 
 		if err != nil {
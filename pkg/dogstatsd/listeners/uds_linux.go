@@ -47,33 +47,27 @@ func enableUDSPassCred(conn *net.UnixConn) error {
 	})
 }
 
-// processUDSOrigin reads ancillary data to determine a packet's origin,
-// it returns a string identifying the source.
-// PID is added to ancillary data by the Linux kernel if we added the
-// SO_PASSCRED to the socket, see enableUDSPassCred.
-func processUDSOrigin(ancillary []byte) (string, error) {
+// pidFromAncillary reads ancillary data to determine the PID of a packet's
+// sender. The PID is added to ancillary data by the Linux kernel if we added
+// the SO_PASSCRED to the socket, see enableUDSPassCred.
+func pidFromAncillary(ancillary []byte) (int32, error) {
 	messages, err := unix.ParseSocketControlMessage(ancillary)
 	if err != nil {
-		return NoOrigin, err
+		return 0, err
 	}
 	if len(messages) == 0 {
-		return NoOrigin, fmt.Errorf("ancillary data empty")
+		return 0, fmt.Errorf("ancillary data empty")
 	}
 	cred, err := unix.ParseUnixCredentials(&messages[0])
 	if err != nil {
-		return NoOrigin, err
+		return 0, err
 	}
 
 	if cred.Pid == 0 {
-		return NoOrigin, fmt.Errorf("matched PID for the process is 0, it belongs " +
+		return 0, fmt.Errorf("matched PID for the process is 0, it belongs " +
 			"probably to another namespace. Is the agent in host PID mode?")
 	}
-
-	entity, err := getEntityForPID(cred.Pid)
-	if err != nil {
-		return NoOrigin, err
-	}
-	return entity, nil
+	return cred.Pid, nil
 }
 
 // getEntityForPID returns the container entity name and caches the value for future lookups
@@ -25,7 +25,12 @@ func enableUDSPassCred(conn *net.UnixConn) error {
 	return ErrLinuxOnly
 }
 
-// processUDSOrigin returns a "not implemented" error on non-linux hosts
-func processUDSOrigin(oob []byte) (string, error) {
+// pidFromAncillary returns a "not implemented" error on non-linux hosts
+func pidFromAncillary(ancillary []byte) (int32, error) {
+	return 0, ErrLinuxOnly
+}
+
+// getEntityForPID returns a "not implemented" error on non-linux hosts
+func getEntityForPID(pid int32) (string, error) {
 	return NoOrigin, ErrLinuxOnly
 }
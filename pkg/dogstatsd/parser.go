@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/intern"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -38,6 +39,7 @@ var (
 	valueSeparator                    = []byte(":")
 	hostTagPrefix                     = []byte("host:")
 	entityIDTagPrefix                 = []byte("dd.internal.entity_id:")
+	containerIDTagPrefix              = []byte("dd.internal.container_id:")
 	lenHostTagPrefix                  = len(hostTagPrefix)
 	lenEntityIDTagPrefix              = len(entityIDTagPrefix)
 	getTags              tagRetriever = tagger.Tag
@@ -84,7 +86,7 @@ func parseTags(rawTags []byte, defaultHostname string) ([]string, string) {
 	for {
 		tag, remainder = nextField(remainder, tagSeparator)
 		if bytes.HasPrefix(tag, hostTagPrefix) {
-			host = string(tag[lenHostTagPrefix:])
+			host = intern.LoadOrStore(tag[lenHostTagPrefix:])
 		} else if bytes.HasPrefix(tag, entityIDTagPrefix) {
 			// currently only supported for pods
 			entity := kubelet.KubePodTaggerEntityPrefix + string(tag[lenEntityIDTagPrefix:])
@@ -94,8 +96,10 @@ func parseTags(rawTags []byte, defaultHostname string) ([]string, string) {
 				continue
 			}
 			tagsList = append(tagsList, entityTags...)
+		} else if bytes.HasPrefix(tag, containerIDTagPrefix) {
+			// used by the origin detection of the listener, see the client_tag step
 		} else {
-			tagsList = append(tagsList, string(tag))
+			tagsList = append(tagsList, intern.LoadOrStore(tag))
 		}
 
 		if remainder == nil {
@@ -320,18 +324,7 @@ func parseMetricMessage(message []byte, namespace string, namespaceBlacklist []s
 		}
 	}
 
-	metricName := string(rawName)
-	if namespace != "" {
-		blacklisted := false
-		for _, prefix := range namespaceBlacklist {
-			if strings.HasPrefix(metricName, prefix) {
-				blacklisted = true
-			}
-		}
-		if !blacklisted {
-			metricName = namespace + metricName
-		}
-	}
+	metricName := addNamespace(intern.LoadOrStore(rawName), namespace, namespaceBlacklist)
 
 	metricType, ok := metricTypes[string(rawType)]
 	if !ok {
@@ -359,3 +352,17 @@ func parseMetricMessage(message []byte, namespace string, namespaceBlacklist []s
 
 	return sample, nil
 }
+
+// addNamespace prefixes a metric name with the namespace, unless it starts with
+// a blacklisted prefix
+func addNamespace(metricName string, namespace string, namespaceBlacklist []string) string {
+	if namespace == "" {
+		return metricName
+	}
+	for _, prefix := range namespaceBlacklist {
+		if strings.HasPrefix(metricName, prefix) {
+			return metricName
+		}
+	}
+	return namespace + metricName
+}
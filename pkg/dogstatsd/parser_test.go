@@ -803,3 +803,11 @@ func TestEntityOriginDetectionTagsError(t *testing.T) {
 	assert.Equal(t, "my-hostname", parsed.Host)
 	assert.InEpsilon(t, 1.0, parsed.SampleRate, epsilon)
 }
+
+func TestContainerIDTagRemoved(t *testing.T) {
+	parsed, err := parseMetricMessage([]byte("daemon:666|g|#sometag1:somevalue1,dd.internal.container_id:abc123,sometag2:somevalue2"), "", nil, "default-hostname")
+	assert.NoError(t, err)
+
+	// the container ID is resolved by the origin detection of the listener
+	assert.Equal(t, []string{"sometag1:somevalue1", "sometag2:somevalue2"}, parsed.Tags)
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"expvar"
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	// prefixPolicyPrefix prefixes the non-conforming metrics with the required prefix
+	prefixPolicyPrefix = "prefix"
+	// prefixPolicyDrop drops the non-conforming metrics
+	prefixPolicyDrop = "drop"
+)
+
+var (
+	dogstatsdMetricPrefixRulesPrefixed = expvar.Int{}
+	dogstatsdMetricPrefixRulesDropped  = expvar.Int{}
+)
+
+func init() {
+	dogstatsdExpvars.Set("MetricPrefixRulesPrefixed", &dogstatsdMetricPrefixRulesPrefixed)
+	dogstatsdExpvars.Set("MetricPrefixRulesDropped", &dogstatsdMetricPrefixRulesDropped)
+}
+
+// metricPrefixRule requires the metrics sent from a kubernetes namespace or a
+// container image to start with a prefix
+type metricPrefixRule struct {
+	KubeNamespace string `mapstructure:"kube_namespace"`
+	ImageName     string `mapstructure:"image_name"`
+	Prefix        string `mapstructure:"prefix"`
+	Policy        string `mapstructure:"policy"`
+}
+
+// matches returns whether the rule applies to an origin, given its tags
+func (r *metricPrefixRule) matches(originTags []string) bool {
+	namespaceMatch := r.KubeNamespace == ""
+	imageMatch := r.ImageName == ""
+	for _, tag := range originTags {
+		if !namespaceMatch && tag == "kube_namespace:"+r.KubeNamespace {
+			namespaceMatch = true
+		}
+		if !imageMatch && tag == "image_name:"+r.ImageName {
+			imageMatch = true
+		}
+	}
+	return namespaceMatch && imageMatch
+}
+
+// apply returns the name of a metric conforming to the rule, and false when
+// the metric must be dropped
+func (r *metricPrefixRule) apply(metricName string) (string, bool) {
+	if strings.HasPrefix(metricName, r.Prefix) {
+		return metricName, true
+	}
+	if r.Policy == prefixPolicyDrop {
+		dogstatsdMetricPrefixRulesDropped.Add(1)
+		return "", false
+	}
+	dogstatsdMetricPrefixRulesPrefixed.Add(1)
+	return r.Prefix + metricName, true
+}
+
+// metricPrefixRules are checked in order, the first rule matching an origin applies
+type metricPrefixRules []metricPrefixRule
+
+// match returns the rule applying to an origin, or nil
+func (rules metricPrefixRules) match(originTags []string) *metricPrefixRule {
+	for i := range rules {
+		if rules[i].matches(originTags) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// loadMetricPrefixRules reads and validates the dogstatsd_metric_prefix_rules
+func loadMetricPrefixRules() (metricPrefixRules, error) {
+	var rules metricPrefixRules
+	if !config.Datadog.IsSet("dogstatsd_metric_prefix_rules") {
+		return nil, nil
+	}
+	if err := config.Datadog.UnmarshalKey("dogstatsd_metric_prefix_rules", &rules); err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.KubeNamespace == "" && rule.ImageName == "" {
+			return nil, fmt.Errorf("rule %d: kube_namespace or image_name must be set", i)
+		}
+		if rule.Prefix == "" {
+			return nil, fmt.Errorf("rule %d: the prefix must be set", i)
+		}
+		if !strings.HasSuffix(rule.Prefix, ".") {
+			rule.Prefix = rule.Prefix + "."
+		}
+		switch rule.Policy {
+		case "":
+			rule.Policy = prefixPolicyPrefix
+		case prefixPolicyPrefix, prefixPolicyDrop:
+		default:
+			return nil, fmt.Errorf("rule %d: unknown policy %q, expected %q or %q", i, rule.Policy, prefixPolicyPrefix, prefixPolicyDrop)
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestLoadMetricPrefixRules(t *testing.T) {
+	mockConfig := config.Mock()
+	// don't leave invalid rules to the servers of the other tests
+	defer config.Mock()
+
+	rules, err := loadMetricPrefixRules()
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	mockConfig.Set("dogstatsd_metric_prefix_rules", []map[string]interface{}{
+		{"kube_namespace": "team-a", "prefix": "team_a"},
+		{"image_name": "legacy", "prefix": "legacy.", "policy": "drop"},
+	})
+	rules, err = loadMetricPrefixRules()
+	require.NoError(t, err)
+	assert.Equal(t, metricPrefixRules{
+		{KubeNamespace: "team-a", Prefix: "team_a.", Policy: prefixPolicyPrefix},
+		{ImageName: "legacy", Prefix: "legacy.", Policy: prefixPolicyDrop},
+	}, rules)
+
+	for _, invalid := range []map[string]interface{}{
+		{"prefix": "team_a"},
+		{"kube_namespace": "team-a"},
+		{"kube_namespace": "team-a", "prefix": "team_a", "policy": "rename"},
+	} {
+		mockConfig.Set("dogstatsd_metric_prefix_rules", []map[string]interface{}{invalid})
+		_, err = loadMetricPrefixRules()
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMetricPrefixRules(t *testing.T) {
+	rules := metricPrefixRules{
+		{KubeNamespace: "team-a", ImageName: "legacy", Prefix: "legacy.", Policy: prefixPolicyDrop},
+		{KubeNamespace: "team-a", Prefix: "team_a.", Policy: prefixPolicyPrefix},
+	}
+
+	assert.Nil(t, rules.match(nil))
+	assert.Nil(t, rules.match([]string{"kube_namespace:team-b", "image_name:legacy"}))
+
+	rule := rules.match([]string{"kube_namespace:team-a", "image_name:web"})
+	require.NotNil(t, rule)
+	name, keep := rule.apply("team_a.requests")
+	assert.True(t, keep)
+	assert.Equal(t, "team_a.requests", name)
+	name, keep = rule.apply("requests")
+	assert.True(t, keep)
+	assert.Equal(t, "team_a.requests", name)
+
+	rule = rules.match([]string{"image_name:legacy", "kube_namespace:team-a"})
+	require.NotNil(t, rule)
+	_, keep = rule.apply("requests")
+	assert.False(t, keep)
+	_, keep = rule.apply("legacy.requests")
+	assert.True(t, keep)
+}
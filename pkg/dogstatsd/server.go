@@ -15,16 +15,20 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/buffer"
+	"github.com/DataDog/datadog-agent/pkg/util/supervisor"
 )
 
 var (
@@ -51,6 +55,10 @@ func init() {
 type Server struct {
 	listeners             []listeners.StatsdListener
 	packetsIn             chan listeners.Packets
+	packetsInBuffer       *buffer.Buffer
+	metricOutBuffer       *buffer.Buffer
+	eventOutBuffer        *buffer.Buffer
+	serviceCheckOutBuffer *buffer.Buffer
 	Statistics            *util.Stats
 	Started               bool
 	packetPool            *listeners.PacketPool
@@ -58,6 +66,7 @@ type Server struct {
 	health                *health.Handle
 	metricPrefix          string
 	metricPrefixBlacklist []string
+	metricPrefixRules     metricPrefixRules
 	defaultHostname       string
 	histToDist            bool
 	histToDistPrefix      string
@@ -65,6 +74,9 @@ type Server struct {
 	debugMetricsStats     bool
 	metricsStats          map[string]metricStat
 	statsLock             sync.Mutex
+	// metricsStatsPaused is set while the metrics statistics are paused to
+	// lower the agent footprint, see PauseMetricsStats
+	metricsStatsPaused int32
 }
 
 // metricStat holds how many times a metric has been
@@ -93,7 +105,14 @@ func NewServer(metricOut chan<- []*metrics.MetricSample, eventOut chan<- []*metr
 		metricsStats = true
 	}
 
-	packetsChannel := make(chan listeners.Packets, config.Datadog.GetInt("dogstatsd_queue_size"))
+	metricPrefixRules, err := loadMetricPrefixRules()
+	if err != nil {
+		return nil, fmt.Errorf("invalid dogstatsd_metric_prefix_rules: %v", err)
+	}
+
+	// the listeners acquire room in the buffer before flushing their packets
+	packetsBuffer := buffer.New(listeners.PacketsBufferName, config.Datadog.GetInt("dogstatsd_queue_size"))
+	packetsChannel := make(chan listeners.Packets, packetsBuffer.Capacity())
 	packetPool := listeners.NewPacketPool(config.Datadog.GetInt("dogstatsd_buffer_size"))
 	tmpListeners := make([]listeners.StatsdListener, 0, 2)
 
@@ -140,12 +159,17 @@ func NewServer(metricOut chan<- []*metrics.MetricSample, eventOut chan<- []*metr
 		Started:               true,
 		Statistics:            stats,
 		packetsIn:             packetsChannel,
+		packetsInBuffer:       packetsBuffer,
+		metricOutBuffer:       buffer.Get(aggregator.MetricSamplesBufferName),
+		eventOutBuffer:        buffer.Get(aggregator.EventsBufferName),
+		serviceCheckOutBuffer: buffer.Get(aggregator.ServiceChecksBufferName),
 		listeners:             tmpListeners,
 		packetPool:            packetPool,
 		stopChan:              make(chan bool),
 		health:                health.Register("dogstatsd-main"),
 		metricPrefix:          metricPrefix,
 		metricPrefixBlacklist: metricPrefixBlacklist,
+		metricPrefixRules:     metricPrefixRules,
 		defaultHostname:       defaultHostname,
 		histToDist:            histToDist,
 		histToDistPrefix:      histToDistPrefix,
@@ -166,8 +190,10 @@ func NewServer(metricOut chan<- []*metrics.MetricSample, eventOut chan<- []*metr
 		if err != nil {
 			log.Warnf("Could not connect to statsd forward host : %s", err)
 		} else {
+			// the forwarder releases the room of the packets of the listeners
 			s.packetsIn = make(chan listeners.Packets, config.Datadog.GetInt("dogstatsd_queue_size"))
-			go s.forwarder(con, packetsChannel)
+			s.packetsInBuffer = nil
+			go s.forwarder(con, packetsChannel, packetsBuffer)
 		}
 	}
 
@@ -194,16 +220,19 @@ func (s *Server) handleMessages(metricOut chan<- []*metrics.MetricSample, eventO
 	}
 
 	for i := 0; i < workers; i++ {
-		go s.worker(metricOut, eventOut, serviceCheckOut)
+		supervisor.Go(fmt.Sprintf("dogstatsd-worker-%d", i), func() {
+			s.worker(metricOut, eventOut, serviceCheckOut)
+		})
 	}
 }
 
-func (s *Server) forwarder(fcon net.Conn, packetsChannel chan listeners.Packets) {
+func (s *Server) forwarder(fcon net.Conn, packetsChannel chan listeners.Packets, packetsBuffer *buffer.Buffer) {
 	for {
 		select {
 		case <-s.stopChan:
 			return
 		case packets := <-packetsChannel:
+			packetsBuffer.Release()
 			for _, packet := range packets {
 				_, err := fcon.Write(packet.Contents)
 
@@ -223,6 +252,7 @@ func (s *Server) worker(metricOut chan<- []*metrics.MetricSample, eventOut chan<
 			return
 		case <-s.health.C:
 		case packets := <-s.packetsIn:
+			s.packetsInBuffer.Release()
 			events := make([]*metrics.Event, 0, len(packets))
 			serviceChecks := make([]*metrics.ServiceCheck, 0, len(packets))
 			metricSamples := make([]*metrics.MetricSample, 0, len(packets))
@@ -233,12 +263,15 @@ func (s *Server) worker(metricOut chan<- []*metrics.MetricSample, eventOut chan<
 			}
 
 			if len(metricSamples) != 0 {
+				s.metricOutBuffer.Acquire()
 				metricOut <- metricSamples
 			}
 			if len(events) != 0 {
+				s.eventOutBuffer.Acquire()
 				eventOut <- events
 			}
 			if len(serviceChecks) != 0 {
+				s.serviceCheckOutBuffer.Acquire()
 				serviceCheckOut <- serviceChecks
 			}
 		}
@@ -247,6 +280,8 @@ func (s *Server) worker(metricOut chan<- []*metrics.MetricSample, eventOut chan<
 
 func (s *Server) parsePacket(packet *listeners.Packet, metricSamples []*metrics.MetricSample, events []*metrics.Event, serviceChecks []*metrics.ServiceCheck) ([]*metrics.MetricSample, []*metrics.Event, []*metrics.ServiceCheck) {
 	extraTags := s.extraTags
+	// the prefix rule applying to the origin of the packet, if any
+	var prefixRule *metricPrefixRule
 
 	log.Tracef("Dogstatsd receive: %s", packet.Contents)
 	if packet.Origin != listeners.NoOrigin {
@@ -255,6 +290,7 @@ func (s *Server) parsePacket(packet *listeners.Packet, metricSamples []*metrics.
 			log.Errorf(err.Error())
 		} else {
 			extraTags = append(extraTags, originTags...)
+			prefixRule = s.metricPrefixRules.match(originTags)
 		}
 	}
 
@@ -293,13 +329,25 @@ func (s *Server) parsePacket(packet *listeners.Packet, metricSamples []*metrics.
 			dogstatsdEventPackets.Add(1)
 			events = append(events, event)
 		} else {
-			sample, err := parseMetricMessage(message, s.metricPrefix, s.metricPrefixBlacklist, s.defaultHostname)
+			namespace := s.metricPrefix
+			if prefixRule != nil {
+				// the rules apply to the names sent by the clients, before the namespace
+				namespace = ""
+			}
+			sample, err := parseMetricMessage(message, namespace, s.metricPrefixBlacklist, s.defaultHostname)
 			if err != nil {
 				log.Errorf("Dogstatsd: error parsing metrics: %s", err)
 				dogstatsdMetricParseErrors.Add(1)
 				continue
 			}
-			if s.debugMetricsStats {
+			if prefixRule != nil {
+				name, keep := prefixRule.apply(sample.Name)
+				if !keep {
+					continue
+				}
+				sample.Name = addNamespace(name, s.metricPrefix, s.metricPrefixBlacklist)
+			}
+			if s.debugMetricsStats && atomic.LoadInt32(&s.metricsStatsPaused) == 0 {
 				s.storeMetricStats(sample.Name)
 			}
 			if len(extraTags) > 0 {
@@ -331,6 +379,22 @@ func (s *Server) Stop() {
 	s.Started = false
 }
 
+// PauseMetricsStats stops storing the metrics statistics, when enabled, until
+// they're resumed. The stored ones are dropped.
+func (s *Server) PauseMetricsStats(paused bool) {
+	if !s.debugMetricsStats {
+		return
+	}
+	if paused {
+		atomic.StoreInt32(&s.metricsStatsPaused, 1)
+		s.statsLock.Lock()
+		s.metricsStats = make(map[string]metricStat)
+		s.statsLock.Unlock()
+	} else {
+		atomic.StoreInt32(&s.metricsStatsPaused, 0)
+	}
+}
+
 func (s *Server) storeMetricStats(name string) {
 	now := time.Now()
 	s.statsLock.Lock()
@@ -71,6 +71,10 @@ type Config struct {
 
 	// ExcludedDestinationConnections is a map of destination connections to blacklist
 	ExcludedDestinationConnections map[string][]string
+
+	// IncludedConnections is a map of connections to allowlist, the other
+	// connections being excluded when set
+	IncludedConnections map[string][]string
 }
 
 // NewDefaultConfig enables traffic collection for all connection types
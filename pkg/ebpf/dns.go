@@ -5,6 +5,9 @@ import "github.com/DataDog/datadog-agent/pkg/process/util"
 // ReverseDNS translates IPs to names
 type ReverseDNS interface {
 	Resolve([]ConnectionStats) map[util.Address][]string
+	// Lookup returns the names an IP was resolved to, for the domain
+	// connection filters
+	Lookup(util.Address) []string
 	GetStats() map[string]int64
 	Close()
 }
@@ -15,6 +18,10 @@ func (nullReverseDNS) Resolve(_ []ConnectionStats) map[util.Address][]string {
 	return nil
 }
 
+func (nullReverseDNS) Lookup(_ util.Address) []string {
+	return nil
+}
+
 func (nullReverseDNS) GetStats() map[string]int64 {
 	return map[string]int64{
 		"lookups":  0,
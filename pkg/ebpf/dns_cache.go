@@ -116,6 +116,19 @@ func (c *reverseDNSCache) Get(conns []ConnectionStats, now time.Time) map[util.A
 	return resolved
 }
 
+// Lookup returns the names of an IP, without extending their expiration nor
+// counting it in the telemetry as it's done for every connection filtered
+func (c *reverseDNSCache) Lookup(addr util.Address) []string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	val, ok := c.data[addr]
+	if !ok {
+		return nil
+	}
+	return val.copy()
+}
+
 func (c *reverseDNSCache) Len() int {
 	return int(atomic.LoadInt64(&c.length))
 }
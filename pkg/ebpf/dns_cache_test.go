@@ -116,6 +116,25 @@ func TestDNSCacheExpiration(t *testing.T) {
 	assert.Equal(t, 0, cache.Len())
 }
 
+func TestDNSCacheLookup(t *testing.T) {
+	ttl := 100 * time.Millisecond
+	cache := newReverseDNSCache(1000, ttl, disableAutomaticExpiration)
+	t1 := time.Now()
+
+	raddr := util.AddressFromString("10.0.0.5")
+	vault := newTranslation([]byte("vault.internal.corp"))
+	vault.add(raddr)
+	cache.Add(vault, t1)
+
+	assert.Equal(t, []string{"vault.internal.corp"}, cache.Lookup(raddr))
+	assert.Nil(t, cache.Lookup(util.AddressFromString("10.0.0.6")))
+
+	// a lookup neither extends the expiration nor counts in the telemetry
+	cache.Expire(t1.Add(ttl + 10*time.Millisecond))
+	assert.Nil(t, cache.Lookup(raddr))
+	assert.Equal(t, int64(0), cache.Stats()["lookups"])
+}
+
 func BenchmarkDNSCacheGet(b *testing.B) {
 	const numIPs = 10000
 
@@ -81,6 +81,11 @@ func (s *SocketFilterSnooper) Resolve(connections []ConnectionStats) map[util.Ad
 	return s.cache.Get(connections, time.Now())
 }
 
+// Lookup returns the names an IP was resolved to
+func (s *SocketFilterSnooper) Lookup(addr util.Address) []string {
+	return s.cache.Lookup(addr)
+}
+
 func (s *SocketFilterSnooper) GetStats() map[string]int64 {
 	return s.cache.Stats()
 }
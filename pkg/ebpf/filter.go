@@ -0,0 +1,140 @@
+package ebpf
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// connectionProcessTTL is how long the processes owning the connections are
+// cached for the process filters
+const connectionProcessTTL = time.Minute
+
+// connectionFilters holds the user defined filters of a tracer, swapped as a
+// whole when they're reloaded
+type connectionFilters struct {
+	// rules are the filters as configured, to log the changes on reload
+	rules util.ConnectionFilterRules
+	set   *util.ConnectionFilterSet
+	// processes resolves the processes owning the connections, nil when no
+	// filter matches the processes or their containers
+	processes *util.ConnectionProcessCache
+	// reverseDNS resolves the names of the addresses from the DNS traffic,
+	// kept across reloads
+	reverseDNS ReverseDNS
+	// hasDomains is whether some filters match the domain names
+	hasDomains bool
+	// stats counts the excluded connections per rule, kept across reloads
+	stats *util.ConnectionFilterStats
+}
+
+// connectionFilterRules returns the user defined filters of the config
+func (c *Config) connectionFilterRules() util.ConnectionFilterRules {
+	return util.ConnectionFilterRules{
+		SourceExcludes: c.ExcludedSourceConnections,
+		DestExcludes:   c.ExcludedDestinationConnections,
+		Includes:       c.IncludedConnections,
+	}
+}
+
+func newConnectionFilters(rules util.ConnectionFilterRules, reverseDNS ReverseDNS, stats *util.ConnectionFilterStats) *connectionFilters {
+	if stats == nil {
+		stats = util.NewConnectionFilterStats(rules)
+	} else {
+		stats.SetRules(rules)
+	}
+	f := &connectionFilters{
+		rules:      rules,
+		set:        util.NewConnectionFilterSet(rules),
+		reverseDNS: reverseDNS,
+		stats:      stats,
+	}
+	hasContainers := f.set.HasContainerFilters()
+	if f.set.HasProcessFilters() || hasContainers {
+		f.processes = util.NewConnectionProcessCache(connectionProcessTTL, hasContainers)
+	}
+	f.hasDomains = f.set.HasDomainFilters()
+	if _, ok := reverseDNS.(nullReverseDNS); ok && f.hasDomains {
+		log.Warn("The DNS traffic isn't inspected, the connection filters on domain names will be ignored")
+	}
+	return f
+}
+
+// isExcludedConnection returns whether or not a tracer should ignore a given connection: the local DNS
+// (*:53) requests if configured (default: true), the connections matching the user defined exclusions,
+// and the ones matching none of the user defined inclusions when set. The domain filters match the names
+// resolved by the DNS requests seen so far, the container filters the tagger tags of the container of the
+// process owning the connection. The connections excluded by the user defined filters are counted per rule.
+func isExcludedConnection(config *Config, filters *connectionFilters, conn *ConnectionStats) bool {
+	isDNSConnection := conn.DPort == 53 || conn.SPort == 53
+	if !config.CollectLocalDNS && isDNSConnection && conn.Direction == LOCAL {
+		return true
+	}
+
+	protocol := util.ProtocolTCP
+	if conn.Type == UDP {
+		protocol = util.ProtocolUDP
+	}
+	source := util.ConnectionEnd{Addr: conn.Source, Port: conn.SPort, Protocol: protocol}
+	dest := util.ConnectionEnd{Addr: conn.Dest, Port: conn.DPort, Protocol: protocol}
+	if filters.processes != nil {
+		proc := filters.processes.Get(conn.Pid)
+		source.Process, dest.Process = proc, proc
+	}
+	if filters.hasDomains {
+		source.Names = filters.reverseDNS.Lookup(conn.Source)
+		dest.Names = filters.reverseDNS.Lookup(conn.Dest)
+	}
+	list, rule := filters.set.ExcludingRule(source, dest)
+	if list == "" {
+		return false
+	}
+	filters.stats.Add(list, rule)
+	return true
+}
+
+// describeFiltersChange summarizes the changes of a list of filters, for the
+// reload log line
+func describeFiltersChange(name string, old, new map[string][]string) string {
+	added, removed, changed := util.DiffConnectionFilters(old, new)
+	if len(added) == 0 && len(removed) == 0 && len(changed) == 0 {
+		return name + " unchanged"
+	}
+	return fmt.Sprintf("%s added %v, removed %v, changed %v", name, added, removed, changed)
+}
+
+// readLocalAddresses returns the addresses of the network interfaces, used to
+// determine the direction of the connections
+func readLocalAddresses() map[util.Address]struct{} {
+	addresses := make(map[util.Address]struct{}, 0)
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		_ = log.Errorf("error reading network interfaces: %s", err)
+		return addresses
+	}
+
+	for _, intf := range interfaces {
+		addrs, err := intf.Addrs()
+
+		if err != nil {
+			_ = log.Errorf("error reading interface %s addresses: %s", intf.Name, err)
+			continue
+		}
+
+		for _, addr := range addrs {
+			switch v := addr.(type) {
+			case *net.IPNet:
+				addresses[util.AddressFromNetIP(v.IP)] = struct{}{}
+			case *net.IPAddr:
+				addresses[util.AddressFromNetIP(v.IP)] = struct{}{}
+			}
+		}
+
+	}
+
+	return addresses
+}
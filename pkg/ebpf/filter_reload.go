@@ -0,0 +1,30 @@
+// +build linux_bpf darwin,cgo
+
+package ebpf
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// SetConnectionFilters swaps the user defined connection filters, without
+// restarting the tracer. They apply to the connections collected from now on.
+func (t *Tracer) SetConnectionFilters(rules util.ConnectionFilterRules) {
+	old := t.connectionFilters()
+	filters := newConnectionFilters(rules, old.reverseDNS, old.stats)
+	t.filters.Store(filters)
+	log.Infof("Reloaded the connection filters: %s; %s; %s",
+		describeFiltersChange("source excludes", old.rules.SourceExcludes, rules.SourceExcludes),
+		describeFiltersChange("destination excludes", old.rules.DestExcludes, rules.DestExcludes),
+		describeFiltersChange("includes", old.rules.Includes, rules.Includes))
+}
+
+func (t *Tracer) connectionFilters() *connectionFilters {
+	return t.filters.Load().(*connectionFilters)
+}
+
+// ConnectionFilterCounts returns the numbers of connections excluded by the
+// connection filters, kept across the reloads
+func (t *Tracer) ConnectionFilterCounts() util.ConnectionFilterCounts {
+	return t.connectionFilters().stats.Counts()
+}
@@ -0,0 +1,103 @@
+package ebpf
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticReverseDNS struct {
+	nullReverseDNS
+	names map[util.Address][]string
+}
+
+func (d staticReverseDNS) Lookup(addr util.Address) []string {
+	return d.names[addr]
+}
+
+func TestIsExcludedConnectionDomain(t *testing.T) {
+	config := NewDefaultConfig()
+	local := util.AddressFromString("10.0.0.1")
+	vault := util.AddressFromString("10.0.0.5")
+	reverseDNS := staticReverseDNS{names: map[util.Address][]string{
+		vault: {"vault.internal.corp"},
+	}}
+	filters := newConnectionFilters(util.ConnectionFilterRules{DestExcludes: map[string][]string{"dns:*.internal.corp": {"443"}}}, reverseDNS, nil)
+
+	assert.True(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: vault, SPort: 40000, DPort: 443}))
+	assert.False(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: vault, SPort: 40000, DPort: 8200}))
+	// unresolved address
+	assert.False(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: util.AddressFromString("10.0.0.6"), SPort: 40000, DPort: 443}))
+
+	// the domain filters are ignored without DNS inspection
+	filters = newConnectionFilters(util.ConnectionFilterRules{DestExcludes: map[string][]string{"dns:*.internal.corp": {"443"}}}, nullReverseDNS{}, nil)
+	assert.False(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: vault, SPort: 40000, DPort: 443}))
+}
+
+func TestIsExcludedConnectionIncludes(t *testing.T) {
+	config := NewDefaultConfig()
+	config.ExcludedDestinationConnections = map[string][]string{"10.0.0.5": {"8200"}}
+	config.IncludedConnections = map[string][]string{"10.0.0.0/24": {"*"}}
+	filters := newConnectionFilters(config.connectionFilterRules(), nullReverseDNS{}, nil)
+
+	local := util.AddressFromString("192.168.1.1")
+	assert.False(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: util.AddressFromString("10.0.0.5"), SPort: 40000, DPort: 443}))
+	// the exclusions take precedence
+	assert.True(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: util.AddressFromString("10.0.0.5"), SPort: 40000, DPort: 8200}))
+	// not included
+	assert.True(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: util.AddressFromString("10.0.1.5"), SPort: 40000, DPort: 443}))
+}
+
+func TestIsExcludedConnectionProtocol(t *testing.T) {
+	config := NewDefaultConfig()
+	config.ExcludedDestinationConnections = map[string][]string{"10.0.0.0/8": {"udp 1000-2000,3000", "tcp 443"}}
+	filters := newConnectionFilters(config.connectionFilterRules(), nullReverseDNS{}, nil)
+
+	local := util.AddressFromString("192.168.1.1")
+	remote := util.AddressFromString("10.0.0.5")
+	assert.True(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: remote, SPort: 40000, DPort: 3000, Type: UDP}))
+	assert.False(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: remote, SPort: 40000, DPort: 3000, Type: TCP}))
+	assert.True(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: remote, SPort: 40000, DPort: 443, Type: TCP}))
+	assert.False(t, isExcludedConnection(config, filters, &ConnectionStats{Source: local, Dest: remote, SPort: 40000, DPort: 443, Type: UDP}))
+}
+
+func TestIsExcludedConnectionStats(t *testing.T) {
+	config := NewDefaultConfig()
+	config.ExcludedSourceConnections = map[string][]string{"10.0.0.0/8": {"22"}, "10.1.0.0/16": {"*"}}
+	config.ExcludedDestinationConnections = map[string][]string{"192.168.1.1": {"8200"}}
+	config.IncludedConnections = map[string][]string{"192.168.0.0/16": {"*"}}
+	filters := newConnectionFilters(config.connectionFilterRules(), nullReverseDNS{}, nil)
+
+	remote := util.AddressFromString("192.168.1.1")
+	isExcludedConnection(config, filters, &ConnectionStats{Source: util.AddressFromString("10.2.0.1"), Dest: remote, SPort: 22, DPort: 443})
+	isExcludedConnection(config, filters, &ConnectionStats{Source: util.AddressFromString("10.1.0.1"), Dest: remote, SPort: 22, DPort: 443})
+	isExcludedConnection(config, filters, &ConnectionStats{Source: util.AddressFromString("10.1.0.1"), Dest: remote, SPort: 40000, DPort: 443})
+	isExcludedConnection(config, filters, &ConnectionStats{Source: util.AddressFromString("172.16.0.1"), Dest: remote, SPort: 40000, DPort: 8200})
+	isExcludedConnection(config, filters, &ConnectionStats{Source: util.AddressFromString("172.16.0.1"), Dest: util.AddressFromString("172.16.0.2"), SPort: 40000, DPort: 443})
+	// not excluded
+	isExcludedConnection(config, filters, &ConnectionStats{Source: util.AddressFromString("172.16.0.1"), Dest: remote, SPort: 40000, DPort: 443})
+
+	assert.Equal(t, util.ConnectionFilterCounts{
+		Total: 5,
+		Rules: map[string]map[string]int64{
+			util.SourceExcludesList: {"10.0.0.0/8": 2, "10.1.0.0/16": 1},
+			util.DestExcludesList:   {"192.168.1.1": 1},
+			util.IncludesList:       {util.NoIncludeMatchRule: 1},
+		},
+	}, filters.stats.Counts())
+
+	// the counts of the kept rules are kept on reload
+	rules := config.connectionFilterRules()
+	rules.DestExcludes = map[string][]string{"192.168.1.2": {"*"}}
+	rules.Includes = nil
+	filters = newConnectionFilters(rules, nullReverseDNS{}, filters.stats)
+	assert.Equal(t, util.ConnectionFilterCounts{
+		Total: 5,
+		Rules: map[string]map[string]int64{
+			util.SourceExcludesList: {"10.0.0.0/8": 2, "10.1.0.0/16": 1},
+			util.DestExcludesList:   {"192.168.1.2": 0},
+			util.IncludesList:       {},
+		},
+	}, filters.stats.Counts())
+}
@@ -0,0 +1,160 @@
+// +build darwin,cgo
+
+package ebpf
+
+/*
+#include <stdlib.h>
+#include <string.h>
+#include <arpa/inet.h>
+#include <libproc.h>
+#include <sys/proc_info.h>
+
+typedef struct {
+	int pid;
+	int ipv4;
+	int protocol;
+	int state; // TSI_S_* for TCP sockets, -1 otherwise
+	uint16_t lport;
+	uint16_t fport;
+	uint8_t laddr[16];
+	uint8_t faddr[16];
+} dd_socket;
+
+// dd_pid_sockets fills sockets with up to max IPv4/IPv6 sockets of pid,
+// returns their count or -1 if the process can't be inspected
+static int dd_pid_sockets(int pid, dd_socket *sockets, int max) {
+	int size = proc_pidinfo(pid, PROC_PIDLISTFDS, 0, NULL, 0);
+	if (size <= 0) {
+		return -1;
+	}
+	struct proc_fdinfo *fds = malloc(size);
+	if (fds == NULL) {
+		return -1;
+	}
+	size = proc_pidinfo(pid, PROC_PIDLISTFDS, 0, fds, size);
+	if (size <= 0) {
+		free(fds);
+		return -1;
+	}
+
+	int n = 0;
+	for (int i = 0; i < size / (int)PROC_PIDLISTFD_SIZE && n < max; i++) {
+		if (fds[i].proc_fdtype != PROX_FDTYPE_SOCKET) {
+			continue;
+		}
+		struct socket_fdinfo si;
+		if (proc_pidfdinfo(pid, fds[i].proc_fd, PROC_PIDFDSOCKETINFO, &si, PROC_PIDFDSOCKETINFO_SIZE) != PROC_PIDFDSOCKETINFO_SIZE) {
+			continue;
+		}
+		if (si.psi.soi_family != AF_INET && si.psi.soi_family != AF_INET6) {
+			continue;
+		}
+
+		struct in_sockinfo *ini;
+		int state = -1;
+		if (si.psi.soi_kind == SOCKINFO_TCP) {
+			ini = &si.psi.soi_proto.pri_tcp.tcpsi_ini;
+			state = si.psi.soi_proto.pri_tcp.tcpsi_state;
+		} else if (si.psi.soi_kind == SOCKINFO_IN) {
+			ini = &si.psi.soi_proto.pri_in;
+		} else {
+			continue;
+		}
+
+		dd_socket *s = &sockets[n++];
+		s->pid = pid;
+		s->protocol = si.psi.soi_protocol;
+		s->state = state;
+		s->lport = ntohs((uint16_t)ini->insi_lport);
+		s->fport = ntohs((uint16_t)ini->insi_fport);
+		// dual-stack sockets connected to an IPv4 peer are flagged IPv4
+		s->ipv4 = (ini->insi_vflag & INI_IPV4) != 0;
+		if (s->ipv4) {
+			memcpy(s->laddr, &ini->insi_laddr.ina_46.i46a_addr4, 4);
+			memcpy(s->faddr, &ini->insi_faddr.ina_46.i46a_addr4, 4);
+		} else {
+			memcpy(s->laddr, &ini->insi_laddr.ina_6, 16);
+			memcpy(s->faddr, &ini->insi_faddr.ina_6, 16);
+		}
+	}
+	free(fds);
+	return n;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// maxSocketsPerProcess caps the number of sockets inspected per process
+const maxSocketsPerProcess = 4096
+
+// socket is an IPv4 or IPv6 socket of a process, as reported by libproc
+type socket struct {
+	conn      ConnectionStats
+	listening bool
+	connected bool
+}
+
+// listSockets returns the TCP and UDP sockets of all the processes. Without
+// root privileges, only the sockets of the processes of the current user
+// can be inspected.
+func listSockets() ([]socket, error) {
+	n := C.proc_listallpids(nil, 0)
+	if n <= 0 {
+		return nil, fmt.Errorf("could not count the processes")
+	}
+	// leave some room for the processes started in between
+	pids := make([]C.int, n+64)
+	n = C.proc_listallpids(unsafe.Pointer(&pids[0]), C.int(len(pids))*C.int(unsafe.Sizeof(pids[0])))
+	if n <= 0 {
+		return nil, fmt.Errorf("could not list the processes")
+	}
+
+	var sockets []socket
+	buf := make([]C.dd_socket, maxSocketsPerProcess)
+	for _, pid := range pids[:n] {
+		count := C.dd_pid_sockets(pid, &buf[0], C.int(len(buf)))
+		for i := 0; i < int(count); i++ {
+			sockets = append(sockets, socketFromC(&buf[i]))
+		}
+	}
+	return sockets, nil
+}
+
+func socketFromC(s *C.dd_socket) socket {
+	conn := ConnectionStats{
+		Pid:   uint32(s.pid),
+		SPort: uint16(s.lport),
+		DPort: uint16(s.fport),
+	}
+
+	laddr := C.GoBytes(unsafe.Pointer(&s.laddr[0]), 16)
+	faddr := C.GoBytes(unsafe.Pointer(&s.faddr[0]), 16)
+	if s.ipv4 != 0 {
+		conn.Family = AFINET
+		conn.Source = util.V4AddressFromBytes(laddr[:4])
+		conn.Dest = util.V4AddressFromBytes(faddr[:4])
+	} else {
+		conn.Family = AFINET6
+		conn.Source = util.V6AddressFromBytes(laddr)
+		conn.Dest = util.V6AddressFromBytes(faddr)
+	}
+
+	sock := socket{conn: conn}
+	switch s.protocol {
+	case C.IPPROTO_TCP:
+		sock.conn.Type = TCP
+		sock.listening = s.state == C.TSI_S_LISTEN
+		sock.connected = s.state == C.TSI_S_ESTABLISHED
+	case C.IPPROTO_UDP:
+		// only connected UDP sockets have a peer
+		sock.conn.Type = UDP
+		sock.connected = s.fport != 0
+	}
+	return sock
+}
@@ -226,8 +226,11 @@ func (ctr *realConntracker) Close() {
 func (ctr *realConntracker) loadInitialState(sessions []ct.Conn) {
 	gen := getNthGeneration(generationLength, time.Now().UnixNano(), 3)
 	for _, c := range sessions {
-		if isNAT(c) {
-			ctr.state[formatKey(c)] = formatIPTranslation(c, gen)
+		if !isNAT(c) {
+			continue
+		}
+		if translation := formatIPTranslation(c, gen); translation != nil {
+			ctr.state[formatKey(c)] = translation
 		}
 	}
 }
@@ -250,7 +253,12 @@ func (ctr *realConntracker) register(c ct.Conn) int {
 	}
 
 	generation := getNthGeneration(generationLength, now, 3)
-	ctr.state[formatKey(c)] = formatIPTranslation(c, generation)
+	translation := formatIPTranslation(c, generation)
+	if translation == nil {
+		// the entry misses its reply ports, it can't be translated
+		return 0
+	}
+	ctr.state[formatKey(c)] = translation
 
 	then := time.Now().UnixNano()
 	atomic.AddInt64(&ctr.stats.registers, 1)
@@ -282,10 +290,12 @@ func (ctr *realConntracker) unregister(c ct.Conn) int {
 	translation, ok := ctr.state[k]
 
 	delete(ctr.state, k)
-	if len(ctr.shortLivedBuffer) < ctr.maxShortLivedBuffer && ok {
-		ctr.shortLivedBuffer[k] = translation.IPTranslation
-	} else {
-		log.Warn("exceeded maximum tracked short lived connections")
+	if ok {
+		if len(ctr.shortLivedBuffer) < ctr.maxShortLivedBuffer {
+			ctr.shortLivedBuffer[k] = translation.IPTranslation
+		} else {
+			log.Warn("exceeded maximum tracked short lived connections")
+		}
 	}
 
 	then := time.Now().UnixNano()
@@ -70,6 +70,19 @@ func TestRegisterNat(t *testing.T) {
 
 }
 
+func TestRegisterNatWithoutPorts(t *testing.T) {
+	rt := newConntracker()
+	c := makeTranslatedConn("10.0.0.0:12345", "50.30.40.10:80", "20.0.0.0:80")
+	delete(c, ct.AttrReplPortSrc)
+
+	rt.register(c)
+	assert.Empty(t, rt.state)
+	assert.Nil(t, rt.GetTranslationForConn(util.AddressFromString("10.0.0.0"), 12345))
+
+	rt.unregister(c)
+	assert.Empty(t, rt.shortLivedBuffer)
+}
+
 func TestGetUpdatesGen(t *testing.T) {
 	rt := newConntracker()
 	c := makeTranslatedConn("10.0.0.0:12345", "50.30.40.10:80", "20.0.0.0:80")
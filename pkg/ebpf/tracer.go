@@ -6,7 +6,6 @@ import (
 	"bytes"
 	"expvar"
 	"fmt"
-	"net"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -71,9 +70,9 @@ type Tracer struct {
 	// Internal buffer used to compute bytekeys
 	buf *bytes.Buffer
 
-	// Connections for the tracer to blacklist
-	sourceExcludes []*util.ConnectionFilter
-	destExcludes   []*util.ConnectionFilter
+	// Connections for the tracer to blacklist, a *connectionFilters swapped by
+	// SetConnectionFilters
+	filters atomic.Value
 }
 
 const (
@@ -187,9 +186,8 @@ func NewTracer(config *Config) (*Tracer, error) {
 		buffer:         make([]ConnectionStats, 0, 512),
 		buf:            &bytes.Buffer{},
 		conntracker:    conntracker,
-		sourceExcludes: util.ParseConnectionFilters(config.ExcludedSourceConnections),
-		destExcludes:   util.ParseConnectionFilters(config.ExcludedDestinationConnections),
 	}
+	tr.filters.Store(newConnectionFilters(config.connectionFilterRules(), reverseDNS, nil))
 
 	tr.perfMap, err = tr.initPerfPolling()
 	if err != nil {
@@ -300,16 +298,9 @@ func (t *Tracer) initPerfPolling() (*bpflib.PerfMap, error) {
 	return pm, nil
 }
 
-// shouldSkipConnection returns whether or not the tracer should ignore a given connection:
-//  • Local DNS (*:53) requests if configured (default: true)
+// shouldSkipConnection returns whether or not the tracer should ignore a given connection
 func (t *Tracer) shouldSkipConnection(conn *ConnectionStats) bool {
-	isDNSConnection := conn.DPort == 53 || conn.SPort == 53
-	if !t.config.CollectLocalDNS && isDNSConnection && conn.Direction == LOCAL {
-		return true
-	} else if util.IsBlacklistedConnection(t.sourceExcludes, conn.Source, conn.SPort) || util.IsBlacklistedConnection(t.destExcludes, conn.Dest, conn.DPort) {
-		return true
-	}
-	return false
+	return isExcludedConnection(t.config, t.connectionFilters(), conn)
 }
 
 func (t *Tracer) Stop() {
@@ -323,6 +314,12 @@ func (t *Tracer) GetActiveConnections(clientID string) (*Connections, error) {
 	t.bufferLock.Lock()
 	defer t.bufferLock.Unlock()
 
+	// the container tags of the processes seen since the last poll are
+	// resolved for the next one
+	if processes := t.connectionFilters().processes; processes != nil {
+		processes.ResolveTags()
+	}
+
 	latestConns, latestTime, err := t.getConnections(t.buffer[:0])
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving connections: %s", err)
@@ -596,6 +593,7 @@ func (t *Tracer) GetStats() (map[string]interface{}, error) {
 		"ebpf":    t.getEbpfTelemetry(),
 		"kprobes": GetProbeStats(),
 		"dns":     t.reverseDNS.GetStats(),
+		"filters": t.ConnectionFilterCounts(),
 	}, nil
 }
 
@@ -664,37 +662,6 @@ func (t *Tracer) isLocalAddress(address util.Address) bool {
 	return ok
 }
 
-func readLocalAddresses() map[util.Address]struct{} {
-	addresses := make(map[util.Address]struct{}, 0)
-
-	interfaces, err := net.Interfaces()
-	if err != nil {
-		_ = log.Errorf("error reading network interfaces: %s", err)
-		return addresses
-	}
-
-	for _, intf := range interfaces {
-		addrs, err := intf.Addrs()
-
-		if err != nil {
-			_ = log.Errorf("error reading interface %s addresses: %s", intf.Name, err)
-			continue
-		}
-
-		for _, addr := range addrs {
-			switch v := addr.(type) {
-			case *net.IPNet:
-				addresses[util.AddressFromNetIP(v.IP)] = struct{}{}
-			case *net.IPAddr:
-				addresses[util.AddressFromNetIP(v.IP)] = struct{}{}
-			}
-		}
-
-	}
-
-	return addresses
-}
-
 // SectionsFromConfig returns a map of string -> gobpf.SectionParams used to configure the way we load the BPF program (bpf map sizes)
 func SectionsFromConfig(c *Config) map[string]bpflib.SectionParams {
 	return map[string]bpflib.SectionParams{
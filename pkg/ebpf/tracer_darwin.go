@@ -0,0 +1,179 @@
+// +build darwin,cgo
+
+package ebpf
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// Tracer gathers the TCP and UDP connections of macOS hosts with libproc.
+// There is no eBPF on macOS, the sockets of all the processes are listed on
+// every request: the traffic of the connections isn't reported, and the
+// connections opened and closed between two requests are missed.
+type Tracer struct {
+	config *Config
+	state  NetworkState
+
+	// protects the snapshots, taken on every request
+	snapshotLock sync.Mutex
+	previous     map[string]ConnectionStats
+	buf          *bytes.Buffer
+
+	localAddresses map[util.Address]struct{}
+
+	// Connections for the tracer to blacklist, a *connectionFilters swapped by
+	// SetConnectionFilters
+	filters atomic.Value
+
+	// Telemetry
+	skippedConns int64
+	closedConns  int64
+}
+
+// CurrentKernelVersion is not implemented on macOS
+func CurrentKernelVersion() (uint32, error) {
+	return 0, ErrNotImplemented
+}
+
+// NewTracer returns a Tracer listing the connections with libproc
+func NewTracer(config *Config) (*Tracer, error) {
+	if _, err := listSockets(); err != nil {
+		return nil, fmt.Errorf("could not list the sockets: %s", err)
+	}
+
+	t := &Tracer{
+		config:         config,
+		state:          NewNetworkState(config.ClientStateExpiry, config.MaxClosedConnectionsBuffered, config.MaxConnectionsStateBuffered),
+		previous:       map[string]ConnectionStats{},
+		buf:            &bytes.Buffer{},
+		localAddresses: readLocalAddresses(),
+	}
+	t.filters.Store(newConnectionFilters(config.connectionFilterRules(), nullReverseDNS{}, nil))
+	return t, nil
+}
+
+// Stop is a noop, nothing runs in the background
+func (t *Tracer) Stop() {}
+
+// GetActiveConnections returns the connections of the hosts, along with the
+// connections closed since the last request of the client
+func (t *Tracer) GetActiveConnections(clientID string) (*Connections, error) {
+	t.snapshotLock.Lock()
+	defer t.snapshotLock.Unlock()
+
+	latestTime := uint64(time.Now().UnixNano())
+	latestConns, byKey, err := t.getConnections(latestTime)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving connections: %s", err)
+	}
+
+	// the connections of the previous snapshot that are gone have been closed
+	for key, conn := range t.previous {
+		if _, ok := byKey[key]; !ok {
+			t.state.StoreClosedConnection(conn)
+			atomic.AddInt64(&t.closedConns, 1)
+		}
+	}
+	t.previous = byKey
+
+	t.state.RemoveExpiredClients(time.Now())
+
+	return &Connections{Conns: t.state.Connections(clientID, latestTime, latestConns)}, nil
+}
+
+// getConnections returns the connected sockets that aren't excluded by the
+// configuration, as a list and indexed by their byte key
+func (t *Tracer) getConnections(latestTime uint64) ([]ConnectionStats, map[string]ConnectionStats, error) {
+	sockets, err := listSockets()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	listening := make(map[uint16]struct{})
+	for _, s := range sockets {
+		if s.listening {
+			listening[s.conn.SPort] = struct{}{}
+		}
+	}
+
+	active := make([]ConnectionStats, 0, len(sockets))
+	byKey := make(map[string]ConnectionStats, len(sockets))
+	for _, s := range sockets {
+		if !s.connected {
+			continue
+		}
+		if (!t.config.CollectTCPConns && s.conn.Type == TCP) || (!t.config.CollectUDPConns && s.conn.Type == UDP) {
+			continue
+		}
+		if !t.config.CollectIPv6Conns && s.conn.Family == AFINET6 {
+			continue
+		}
+
+		conn := s.conn
+		conn.LastUpdateEpoch = latestTime
+		conn.Direction = t.determineConnectionDirection(&conn, listening)
+		if isExcludedConnection(t.config, t.connectionFilters(), &conn) {
+			atomic.AddInt64(&t.skippedConns, 1)
+			continue
+		}
+
+		key, err := conn.ByteKey(t.buf)
+		if err != nil {
+			continue
+		}
+		// a process may hold several descriptors of the same socket
+		if _, ok := byKey[string(key)]; ok {
+			continue
+		}
+		byKey[string(key)] = conn
+		active = append(active, conn)
+	}
+	return active, byKey, nil
+}
+
+func (t *Tracer) determineConnectionDirection(conn *ConnectionStats, listening map[uint16]struct{}) ConnectionDirection {
+	_, sourceLocal := t.localAddresses[conn.Source]
+	_, destLocal := t.localAddresses[conn.Dest]
+
+	if sourceLocal && destLocal {
+		return LOCAL
+	}
+
+	if _, ok := listening[conn.SPort]; sourceLocal && ok {
+		return INCOMING
+	}
+
+	return OUTGOING
+}
+
+// GetStats returns a map of statistics about the current tracer's internal state
+func (t *Tracer) GetStats() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"state": t.state.GetStats(),
+		"tracer": map[string]int64{
+			"conn_valid_skipped": atomic.LoadInt64(&t.skippedConns), // Skipped connections (e.g. Local DNS requests)
+			"closed_conns":       atomic.LoadInt64(&t.closedConns),
+		},
+		"filters": t.ConnectionFilterCounts(),
+	}, nil
+}
+
+// DebugNetworkState returns a map with the current tracer's internal state, for debugging
+func (t *Tracer) DebugNetworkState(clientID string) (map[string]interface{}, error) {
+	return t.state.DumpState(clientID), nil
+}
+
+// DebugNetworkMaps returns the connections currently listed by libproc, without modifications from network state
+func (t *Tracer) DebugNetworkMaps() (*Connections, error) {
+	latestConns, _, err := t.getConnections(uint64(time.Now().UnixNano()))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving connections: %s", err)
+	}
+	return &Connections{Conns: latestConns}, nil
+}
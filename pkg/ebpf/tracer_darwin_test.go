@@ -0,0 +1,92 @@
+// +build darwin,cgo
+
+package ebpf
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findConnection(conns []ConnectionStats, sport, dport uint16) (ConnectionStats, bool) {
+	for _, c := range conns {
+		if c.Pid == uint32(os.Getpid()) && c.SPort == sport && c.DPort == dport {
+			return c, true
+		}
+	}
+	return ConnectionStats{}, false
+}
+
+func TestTracerDarwinTCPConnection(t *testing.T) {
+	tr, err := NewTracer(NewDefaultConfig())
+	require.NoError(t, err)
+	defer tr.Stop()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	conn, err := net.Dial("tcp4", ln.Addr().String())
+	require.NoError(t, err)
+
+	clientPort := uint16(conn.LocalAddr().(*net.TCPAddr).Port)
+	serverPort := uint16(ln.Addr().(*net.TCPAddr).Port)
+
+	conns, err := tr.GetActiveConnections("1")
+	require.NoError(t, err)
+
+	outgoing, ok := findConnection(conns.Conns, clientPort, serverPort)
+	require.True(t, ok)
+	assert.Equal(t, TCP, outgoing.Type)
+	assert.Equal(t, AFINET, outgoing.Family)
+	assert.Equal(t, LOCAL, outgoing.Direction)
+	assert.Equal(t, "127.0.0.1", outgoing.Dest.String())
+
+	_, ok = findConnection(conns.Conns, serverPort, clientPort)
+	assert.True(t, ok)
+
+	// the closed connection is reported once
+	conn.Close()
+	conns, err = tr.GetActiveConnections("1")
+	require.NoError(t, err)
+	_, ok = findConnection(conns.Conns, clientPort, serverPort)
+	assert.True(t, ok)
+
+	conns, err = tr.GetActiveConnections("1")
+	require.NoError(t, err)
+	_, ok = findConnection(conns.Conns, clientPort, serverPort)
+	assert.False(t, ok)
+}
+
+func TestTracerDarwinExcludedConnection(t *testing.T) {
+	config := NewDefaultConfig()
+	config.ExcludedDestinationConnections = map[string][]string{"127.0.0.1": {"*"}}
+	tr, err := NewTracer(config)
+	require.NoError(t, err)
+	defer tr.Stop()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	conn, err := net.Dial("tcp4", ln.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conns, err := tr.GetActiveConnections("1")
+	require.NoError(t, err)
+	_, ok := findConnection(conns.Conns, uint16(conn.LocalAddr().(*net.TCPAddr).Port), uint16(ln.Addr().(*net.TCPAddr).Port))
+	assert.False(t, ok)
+}
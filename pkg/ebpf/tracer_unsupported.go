@@ -1,13 +1,17 @@
-// +build !linux_bpf
+// +build !linux_bpf,!darwin !linux_bpf,!cgo
 
 package ebpf
 
+import (
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
 // CurrentKernelVersion is not implemented on non-linux systems
 func CurrentKernelVersion() (uint32, error) {
 	return 0, ErrNotImplemented
 }
 
-// Tracer is not implemented on non-linux systems
+// Tracer is not implemented on non-linux systems, nor on macOS without cgo
 type Tracer struct{}
 
 // NewTracer is not implemented on non-linux systems
@@ -37,3 +41,11 @@ func (t *Tracer) DebugNetworkState(clientID string) (map[string]interface{}, err
 func (t *Tracer) DebugNetworkMaps() (*Connections, error) {
 	return nil, ErrNotImplemented
 }
+
+// SetConnectionFilters is not implemented on non-linux systems
+func (t *Tracer) SetConnectionFilters(_ util.ConnectionFilterRules) {}
+
+// ConnectionFilterCounts is not implemented on non-linux systems
+func (t *Tracer) ConnectionFilterCounts() util.ConnectionFilterCounts {
+	return util.ConnectionFilterCounts{}
+}
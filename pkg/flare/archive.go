@@ -31,6 +31,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/status"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/audit"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/mholt/archiver"
@@ -227,6 +228,11 @@ func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths,
 		log.Errorf("Could not zip logs: %s", err)
 	}
 
+	err = zipAuditLog(tempDir, hostname, logFilePath, permsInfos)
+	if err != nil {
+		log.Errorf("Could not zip the audit log: %s", err)
+	}
+
 	// gets files infos and write the permissions.log file
 	if err := permsInfos.commit(tempDir, hostname, os.ModePerm); err != nil {
 		log.Errorf("Could not write permissions.log file: %s", err)
@@ -241,8 +247,9 @@ func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths,
 }
 
 func zipStatusFile(tempDir, hostname string) error {
-	// Grab the status
-	s, err := status.GetAndFormatStatus()
+	// Grab the status, with the recent failures of the checks as the transient
+	// ones are usually gone by the time the flare is taken
+	s, err := status.GetAndFormatVerboseStatus()
 	if err != nil {
 		return err
 	}
@@ -291,6 +298,30 @@ func zipLogFiles(tempDir, hostname, logFilePath string, permsInfos permissionsIn
 	return err
 }
 
+// zipAuditLog adds the audit log and its rotated files, unless they are in
+// the log files directory and already zipped with the logs
+func zipAuditLog(tempDir, hostname, logFilePath string, permsInfos permissionsInfos) error {
+	auditFilePath := audit.GetFilePath()
+	if auditFilePath == "" || filepath.Dir(auditFilePath) == filepath.Dir(logFilePath) {
+		return nil
+	}
+
+	paths, err := filepath.Glob(auditFilePath + "*")
+	if err != nil {
+		return err
+	}
+	for _, src := range paths {
+		dst := filepath.Join(tempDir, hostname, "logs", filepath.Base(src))
+		if permsInfos != nil {
+			permsInfos.add(src)
+		}
+		if err := util.CopyFileAll(src, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func zipExpVar(tempDir, hostname string) error {
 	var variables = make(map[string]interface{})
 	expvar.Do(func(kv expvar.KeyValue) {
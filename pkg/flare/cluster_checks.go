@@ -6,6 +6,7 @@
 package flare
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -108,6 +109,55 @@ func GetClusterChecks(w io.Writer) error {
 	return nil
 }
 
+// RebalanceClusterChecks triggers a rebalancing of the cluster checks and
+// dumps the checks moved to the writer
+func RebalanceClusterChecks(w io.Writer) error {
+	urlstr := fmt.Sprintf("https://localhost:%v/api/v1/clusterchecks/rebalance", config.Datadog.GetInt("cluster_agent.cmd_port"))
+
+	if w != color.Output {
+		color.NoColor = true
+	}
+
+	if !config.Datadog.GetBool("cluster_checks.enabled") {
+		fmt.Fprintln(w, "Cluster-checks are not enabled")
+		return nil
+	}
+
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	// Set session token
+	if err := util.SetAuthToken(); err != nil {
+		return err
+	}
+
+	r, err := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer([]byte{}))
+	if err != nil {
+		if r != nil && string(r) != "" {
+			fmt.Fprintln(w, fmt.Sprintf("The agent ran into an error while rebalancing the checks: %s", string(r)))
+		} else {
+			fmt.Fprintln(w, fmt.Sprintf("Failed to query the agent (running?): %s", err))
+		}
+		return err
+	}
+
+	var moves []types.RebalanceResponse
+	if err = json.Unmarshal(r, &moves); err != nil {
+		return err
+	}
+
+	if len(moves) == 0 {
+		fmt.Fprintln(w, "The cluster checks are balanced, no check was moved")
+		return nil
+	}
+	fmt.Fprintln(w, fmt.Sprintf("=== %d checks moved ===", len(moves)))
+	table := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(table, "\nCheck\tWeight\tFrom\tTo")
+	for _, m := range moves {
+		fmt.Fprintf(table, "%s\t%d\t%s\t%s\n", m.CheckID, m.CheckWeight, m.SourceNodeName, m.DestNodeName)
+	}
+	return table.Flush()
+}
+
 // GetEndpointsChecks dumps the endpointschecks dispatching state to the writer
 func GetEndpointsChecks(w io.Writer) error {
 	if !endpointschecksEnabled() {
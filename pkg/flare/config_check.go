@@ -28,30 +28,7 @@ func GetConfigCheck(w io.Writer, withDebug bool) error {
 		color.NoColor = true
 	}
 
-	c := util.GetClient(false) // FIX: get certificates right then make this true
-
-	// Set session token
-	err := util.SetAuthToken()
-	if err != nil {
-		return err
-	}
-	ipcAddress, err := config.GetIPCAddress()
-	if err != nil {
-		return err
-	}
-	if configCheckURL == "" {
-		configCheckURL = fmt.Sprintf("https://%v:%v/agent/config-check", ipcAddress, config.Datadog.GetInt("cmd_port"))
-	}
-	r, err := util.DoGet(c, configCheckURL)
-	if err != nil {
-		if r != nil && string(r) != "" {
-			return fmt.Errorf("the agent ran into an error while checking config: %s", string(r))
-		}
-		return fmt.Errorf("failed to query the agent (running?): %s", err)
-	}
-
-	cr := response.ConfigCheckResponse{}
-	err = json.Unmarshal(r, &cr)
+	cr, err := getConfigCheck()
 	if err != nil {
 		return err
 	}
@@ -92,6 +69,35 @@ func GetConfigCheck(w io.Writer, withDebug bool) error {
 	return nil
 }
 
+// getConfigCheck queries the configurations loaded by the running agent
+func getConfigCheck() (response.ConfigCheckResponse, error) {
+	cr := response.ConfigCheckResponse{}
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return cr, err
+	}
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return cr, err
+	}
+	if configCheckURL == "" {
+		configCheckURL = fmt.Sprintf("https://%v:%v/agent/config-check", ipcAddress, config.Datadog.GetInt("cmd_port"))
+	}
+	r, err := util.DoGet(c, configCheckURL)
+	if err != nil {
+		if r != nil && string(r) != "" {
+			return cr, fmt.Errorf("the agent ran into an error while checking config: %s", string(r))
+		}
+		return cr, fmt.Errorf("failed to query the agent (running?): %s", err)
+	}
+
+	err = json.Unmarshal(r, &cr)
+	return cr, err
+}
+
 // GetClusterAgentConfigCheck proxies GetConfigCheck overidding the URL
 func GetClusterAgentConfigCheck(w io.Writer, withDebug bool) error {
 	configCheckURL = fmt.Sprintf("https://localhost:%v/config-check", config.Datadog.GetInt("cluster_agent.cmd_port"))
@@ -0,0 +1,152 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package flare
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// annotationProviders are the config providers reading the Autodiscovery annotations
+var annotationProviders = map[string]bool{
+	providers.Kubernetes:    true,
+	providers.KubeServices:  true,
+	providers.KubeEndpoints: true,
+}
+
+// GenerateClusterAgentConfigsFromAnnotations writes the configurations the
+// running cluster agent derived from the Autodiscovery annotations to static
+// files in outputDir, in one `<check>.d` folder per check like in `conf.d`.
+// The templates no service matches yet are written as well.
+func GenerateClusterAgentConfigsFromAnnotations(w io.Writer, outputDir string) error {
+	if w != color.Output {
+		color.NoColor = true
+	}
+
+	configCheckURL = fmt.Sprintf("https://localhost:%v/config-check", config.Datadog.GetInt("cluster_agent.cmd_port"))
+	cr, err := getConfigCheck()
+	if err != nil {
+		return err
+	}
+
+	written := 0
+	for _, c := range cr.Configs {
+		if !annotationProviders[c.Provider] {
+			continue
+		}
+		path, err := writeConfigFile(outputDir, c, false)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.GreenString(c.Name), path))
+		written++
+	}
+	for _, configs := range cr.Unresolved {
+		for _, c := range configs {
+			if !annotationProviders[c.Provider] {
+				continue
+			}
+			path, err := writeConfigFile(outputDir, c, true)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, fmt.Sprintf("%s: %s (%s)", color.GreenString(c.Name), path, color.YellowString("unresolved template")))
+			written++
+		}
+	}
+
+	fmt.Fprintln(w, fmt.Sprintf("\n%d configuration files written to %s", written, outputDir))
+	return nil
+}
+
+// writeConfigFile writes the static configuration file of c in outputDir and
+// returns its path
+func writeConfigFile(outputDir string, c integration.Config, unresolved bool) (string, error) {
+	content, err := renderConfigFile(c, unresolved)
+	if err != nil {
+		return "", fmt.Errorf("cannot render the %s configuration from %s: %v", c.Name, c.Source, err)
+	}
+
+	dir := filepath.Join(outputDir, c.Name+".d")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.yaml", c.Provider, c.Digest()))
+	return path, ioutil.WriteFile(path, content, 0644)
+}
+
+// renderConfigFile returns the content of the static configuration file of c,
+// starting with comments telling where the configuration comes from.
+// The unresolved templates keep their Autodiscovery identifiers.
+func renderConfigFile(c integration.Config, unresolved bool) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "# Generated by `datadog-cluster-agent generate-configs --from-annotations`")
+	fmt.Fprintf(&buf, "# Configuration provider: %s\n", c.Provider)
+	if c.Source != "" {
+		fmt.Fprintf(&buf, "# Configuration source: %s\n", c.Source)
+	}
+	if unresolved {
+		fmt.Fprintln(&buf, "# Unresolved template: nothing matches its Autodiscovery identifiers yet")
+	}
+	if c.NodeName != "" {
+		fmt.Fprintf(&buf, "# Dispatched to: %s\n", c.NodeName)
+	}
+
+	content := yaml.MapSlice{}
+	if unresolved && len(c.ADIdentifiers) > 0 {
+		content = append(content, yaml.MapItem{Key: "ad_identifiers", Value: c.ADIdentifiers})
+	}
+	if c.ClusterCheck {
+		content = append(content, yaml.MapItem{Key: "cluster_check", Value: true})
+	}
+
+	var initConfig interface{}
+	if err := yaml.Unmarshal(c.InitConfig, &initConfig); err != nil {
+		return nil, err
+	}
+	if initConfig == nil {
+		initConfig = map[string]interface{}{}
+	}
+	content = append(content, yaml.MapItem{Key: "init_config", Value: initConfig})
+
+	if len(c.Instances) > 0 {
+		instances := make([]interface{}, 0, len(c.Instances))
+		for _, i := range c.Instances {
+			var instance interface{}
+			if err := yaml.Unmarshal(i, &instance); err != nil {
+				return nil, err
+			}
+			instances = append(instances, instance)
+		}
+		content = append(content, yaml.MapItem{Key: "instances", Value: instances})
+	}
+
+	if len(c.LogsConfig) > 0 {
+		var logsConfig interface{}
+		if err := yaml.Unmarshal(c.LogsConfig, &logsConfig); err != nil {
+			return nil, err
+		}
+		content = append(content, yaml.MapItem{Key: "logs", Value: logsConfig})
+	}
+
+	out, err := yaml.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(out)
+	return buf.Bytes(), nil
+}
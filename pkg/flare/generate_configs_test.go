@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package flare
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers"
+)
+
+func TestRenderConfigFile(t *testing.T) {
+	c := integration.Config{
+		Name:          "http_check",
+		Instances:     []integration.Data{integration.Data("name: foo\nurl: http://10.0.0.1")},
+		ADIdentifiers: []string{"kube_service_uid://1234"},
+		Provider:      providers.KubeServices,
+		Source:        "kube_services:kube_service_uid://1234",
+		ClusterCheck:  true,
+		NodeName:      "node1",
+	}
+
+	content, err := renderConfigFile(c, false)
+	require.NoError(t, err)
+	assert.Equal(t, "# Generated by `datadog-cluster-agent generate-configs --from-annotations`\n"+
+		"# Configuration provider: kubernetes-services\n"+
+		"# Configuration source: kube_services:kube_service_uid://1234\n"+
+		"# Dispatched to: node1\n"+
+		"cluster_check: true\n"+
+		"init_config: {}\n"+
+		"instances:\n"+
+		"- name: foo\n"+
+		"  url: http://10.0.0.1\n", string(content))
+
+	c.NodeName = ""
+	c.Instances = []integration.Data{integration.Data("url: http://%%host%%")}
+	c.LogsConfig = integration.Data(`[{"source": "nginx"}]`)
+	content, err = renderConfigFile(c, true)
+	require.NoError(t, err)
+	assert.Equal(t, "# Generated by `datadog-cluster-agent generate-configs --from-annotations`\n"+
+		"# Configuration provider: kubernetes-services\n"+
+		"# Configuration source: kube_services:kube_service_uid://1234\n"+
+		"# Unresolved template: nothing matches its Autodiscovery identifiers yet\n"+
+		"ad_identifiers:\n"+
+		"- kube_service_uid://1234\n"+
+		"cluster_check: true\n"+
+		"init_config: {}\n"+
+		"instances:\n"+
+		"- url: http://%%host%%\n"+
+		"logs:\n"+
+		"- source: nginx\n", string(content))
+}
+
+func TestWriteConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "generate-configs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := integration.Config{
+		Name:       "redisdb",
+		Instances:  []integration.Data{integration.Data("host: 10.0.0.2")},
+		InitConfig: integration.Data("service: redis"),
+		Provider:   providers.KubeEndpoints,
+	}
+	path, err := writeConfigFile(dir, c, false)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "redisdb.d", "kubernetes-endpoints-"+c.Digest()+".yaml"), path)
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "init_config:\n  service: redis\ninstances:\n- host: 10.0.0.2\n")
+}
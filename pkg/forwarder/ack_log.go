@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package forwarder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Acknowledgment is an attempt to deliver a transaction, kept for
+// `agent forwarder acks` to prove whether a payload left the host
+type Acknowledgment struct {
+	TransactionID string `json:"transaction_id"`
+	Attempt       int    `json:"attempt"`
+	// Endpoint is the sanitized URL the payload was sent to
+	Endpoint    string `json:"endpoint"`
+	PayloadSize int    `json:"payload_size"`
+	// Checksum is the SHA-256 of the payload, empty unless
+	// forwarder_payload_checksum is set
+	Checksum string `json:"checksum,omitempty"`
+	// StatusCode is 0 when no response was received
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+	Sent       int64  `json:"sent"`       // unix timestamp in seconds
+	LatencyMs  int64  `json:"latency_ms"` // until the response was read
+}
+
+// ackLog is a ring buffer of the last maxSize acknowledgments, only the ones
+// sent within the retention being returned
+type ackLog struct {
+	sync.Mutex
+	retention time.Duration
+	acks      []Acknowledgment
+	next      int
+	full      bool
+}
+
+// acknowledgments is the log of the last forwarder created, nil if disabled
+var acknowledgments *ackLog
+
+func newAckLog(maxSize int, retention time.Duration) *ackLog {
+	return &ackLog{
+		retention: retention,
+		acks:      make([]Acknowledgment, maxSize),
+	}
+}
+
+// add records an acknowledgment
+func (l *ackLog) add(ack Acknowledgment) {
+	l.Lock()
+	defer l.Unlock()
+	l.acks[l.next] = ack
+	l.next++
+	if l.next == len(l.acks) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// get returns the acknowledgments sent within the retention, the most recent
+// first
+func (l *ackLog) get(now time.Time) []Acknowledgment {
+	l.Lock()
+	defer l.Unlock()
+
+	size := l.next
+	if l.full {
+		size = len(l.acks)
+	}
+	since := now.Add(-l.retention).Unix()
+	result := []Acknowledgment{}
+	for i := 1; i <= size; i++ {
+		ack := l.acks[(l.next-i+len(l.acks))%len(l.acks)]
+		if ack.Sent < since {
+			// the workers record their attempts when they complete, so an
+			// older one may follow
+			continue
+		}
+		result = append(result, ack)
+	}
+	return result
+}
+
+// payloadChecksum returns the hex encoded SHA-256 of a payload
+func payloadChecksum(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// GetAcknowledgments returns the attempts to deliver the transactions in the
+// last forwarder_ack_log.retention seconds, the most recent first
+func GetAcknowledgments() ([]Acknowledgment, error) {
+	if acknowledgments == nil {
+		return nil, fmt.Errorf("the acknowledgments aren't kept, forwarder_ack_log.max_size is 0")
+	}
+	return acknowledgments.get(time.Now()), nil
+}
@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package forwarder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func ackTransactionIDs(acks []Acknowledgment) []string {
+	ids := []string{}
+	for _, ack := range acks {
+		ids = append(ids, ack.TransactionID)
+	}
+	return ids
+}
+
+func TestAckLogRing(t *testing.T) {
+	l := newAckLog(3, time.Hour)
+	now := time.Now()
+	assert.Empty(t, l.get(now))
+
+	l.add(Acknowledgment{TransactionID: "1", Sent: now.Unix()})
+	l.add(Acknowledgment{TransactionID: "2", Sent: now.Unix()})
+	assert.Equal(t, []string{"2", "1"}, ackTransactionIDs(l.get(now)))
+
+	// the oldest acknowledgments are overwritten
+	l.add(Acknowledgment{TransactionID: "3", Sent: now.Unix()})
+	l.add(Acknowledgment{TransactionID: "4", Sent: now.Unix()})
+	assert.Equal(t, []string{"4", "3", "2"}, ackTransactionIDs(l.get(now)))
+}
+
+func TestAckLogRetention(t *testing.T) {
+	l := newAckLog(10, time.Hour)
+	now := time.Now()
+
+	l.add(Acknowledgment{TransactionID: "old", Sent: now.Add(-2 * time.Hour).Unix()})
+	l.add(Acknowledgment{TransactionID: "recent", Sent: now.Add(-time.Minute).Unix()})
+	// a slow attempt completing after a more recent one
+	l.add(Acknowledgment{TransactionID: "slow", Sent: now.Add(-3 * time.Hour).Unix()})
+	assert.Equal(t, []string{"recent"}, ackTransactionIDs(l.get(now)))
+}
+
+func TestProcessAcknowledgments(t *testing.T) {
+	statusCode := http.StatusServiceUnavailable
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCode)
+	}))
+	defer ts.Close()
+
+	payload := []byte("test payload")
+	transaction := NewHTTPTransaction()
+	transaction.Domain = ts.URL
+	transaction.Endpoint = "/endpoint/test?api_key=abcdefabcdefabcdefabcdefabcdefab"
+	transaction.Payload = &payload
+	transaction.checksum = payloadChecksum(payload)
+	transaction.ackLog = newAckLog(10, time.Hour)
+
+	client := &http.Client{}
+	assert.NotNil(t, transaction.Process(context.Background(), client))
+	statusCode = http.StatusAccepted
+	assert.Nil(t, transaction.Process(context.Background(), client))
+
+	acks := transaction.ackLog.get(time.Now())
+	require.Len(t, acks, 2)
+	assert.Equal(t, transaction.id, acks[0].TransactionID)
+	assert.Equal(t, 2, acks[0].Attempt)
+	assert.Equal(t, http.StatusAccepted, acks[0].StatusCode)
+	assert.Equal(t, 1, acks[1].Attempt)
+	assert.Equal(t, http.StatusServiceUnavailable, acks[1].StatusCode)
+	for _, ack := range acks {
+		assert.Equal(t, len(payload), ack.PayloadSize)
+		assert.Equal(t, "813ca5285c28ccee5cab8b10ebda9c908fd6d78ed9dc94cc65ea6cb67a7f13ae", ack.Checksum)
+		assert.NotContains(t, ack.Endpoint, "abcdefabcdef")
+		assert.Empty(t, ack.Error)
+	}
+
+	transaction = NewHTTPTransaction()
+	transaction.Domain = "http://localhost:1234"
+	transaction.Endpoint = "/endpoint/test"
+	transaction.Payload = &payload
+	transaction.ackLog = newAckLog(10, time.Hour)
+	assert.NotNil(t, transaction.Process(context.Background(), client))
+
+	acks = transaction.ackLog.get(time.Now())
+	require.Len(t, acks, 1)
+	assert.Equal(t, 0, acks[0].StatusCode)
+	assert.NotEmpty(t, acks[0].Error)
+	assert.Empty(t, acks[0].Checksum)
+}
+
+func TestCreateHTTPTransactionsChecksum(t *testing.T) {
+	config.Datadog.Set("forwarder_payload_checksum", true)
+	config.Datadog.Set("forwarder_ack_log.max_size", 100)
+	defer config.Datadog.Set("forwarder_payload_checksum", false)
+	defer config.Datadog.Set("forwarder_ack_log.max_size", 0)
+
+	forwarder := NewDefaultForwarder(keysPerDomains)
+	require.NotNil(t, forwarder.ackLog)
+	p1 := []byte("A payload")
+	p2 := []byte("Another payload")
+	transactions := forwarder.createHTTPTransactions("/api/foo", Payloads{&p1, &p2}, false, make(http.Header))
+	require.Len(t, transactions, 4)
+
+	assert.Equal(t, payloadChecksum(p1), transactions[0].Headers.Get("DD-Payload-Checksum"))
+	assert.Equal(t, payloadChecksum(p1), transactions[1].checksum)
+	assert.Equal(t, payloadChecksum(p2), transactions[2].Headers.Get("DD-Payload-Checksum"))
+	assert.NotEqual(t, transactions[0].id, transactions[1].id)
+	for _, transaction := range transactions {
+		assert.True(t, transaction.ackLog == forwarder.ackLog)
+	}
+
+	acks, err := GetAcknowledgments()
+	require.NoError(t, err)
+	assert.Empty(t, acks)
+}
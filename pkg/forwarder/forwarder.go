@@ -12,6 +12,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -74,6 +75,7 @@ const (
 	apiHTTPHeaderKey       = "DD-Api-Key"
 	versionHTTPHeaderKey   = "DD-Agent-Version"
 	useragentHTTPHeaderKey = "User-Agent"
+	checksumHTTPHeaderKey  = "DD-Payload-Checksum"
 )
 
 // Payloads is a slice of pointers to byte arrays, an alias for the slices of
@@ -105,6 +107,11 @@ type DefaultForwarder struct {
 	healthChecker    *forwarderHealth
 	internalState    uint32
 	m                sync.Mutex // To control Start/Stop races
+
+	// payloadChecksum enables the SHA-256 of the payloads
+	payloadChecksum bool
+	// ackLog records the attempts to deliver the transactions, nil if disabled
+	ackLog *ackLog
 }
 
 // NewDefaultForwarder returns a new DefaultForwarder.
@@ -115,7 +122,13 @@ func NewDefaultForwarder(keysPerDomains map[string][]string) *DefaultForwarder {
 		keysPerDomains:   map[string][]string{},
 		internalState:    Stopped,
 		healthChecker:    &forwarderHealth{keysPerDomains: keysPerDomains},
+		payloadChecksum:  config.Datadog.GetBool("forwarder_payload_checksum"),
+	}
+	if maxSize := config.Datadog.GetInt("forwarder_ack_log.max_size"); maxSize > 0 {
+		retention := time.Duration(config.Datadog.GetInt("forwarder_ack_log.retention")) * time.Second
+		f.ackLog = newAckLog(maxSize, retention)
 	}
+	acknowledgments = f.ackLog
 	numWorkers := config.Datadog.GetInt("forwarder_num_workers")
 	retryQueueMaxSize := config.Datadog.GetInt("forwarder_retry_queue_max_size")
 
@@ -194,6 +207,10 @@ func (f *DefaultForwarder) State() uint32 {
 func (f *DefaultForwarder) createHTTPTransactions(endpoint string, payloads Payloads, apiKeyInQueryString bool, extra http.Header) []*HTTPTransaction {
 	transactions := []*HTTPTransaction{}
 	for _, payload := range payloads {
+		checksum := ""
+		if f.payloadChecksum {
+			checksum = payloadChecksum(*payload)
+		}
 		for domain, apiKeys := range f.keysPerDomains {
 			for _, apiKey := range apiKeys {
 				transactionEndpoint := endpoint
@@ -207,6 +224,11 @@ func (f *DefaultForwarder) createHTTPTransactions(endpoint string, payloads Payl
 				t.Headers.Set(apiHTTPHeaderKey, apiKey)
 				t.Headers.Set(versionHTTPHeaderKey, version.AgentVersion)
 				t.Headers.Set(useragentHTTPHeaderKey, fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+				if checksum != "" {
+					t.checksum = checksum
+					t.Headers.Set(checksumHTTPHeaderKey, checksum)
+				}
+				t.ackLog = f.ackLog
 
 				for key := range extra {
 					t.Headers.Set(key, extra.Get(key))
@@ -15,9 +15,11 @@ import (
 	"net/http"
 	"net/http/httptrace"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/clockdrift"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -35,6 +37,9 @@ var (
 	transactionsSentRequestErrors  = expvar.Int{}
 	transactionsHTTPErrors         = expvar.Int{}
 	transactionsHTTPErrorsByCode   = expvar.Map{}
+
+	// lastTransactionID is the id of the last transaction created
+	lastTransactionID uint64
 )
 
 var trace = &httptrace.ClientTrace{
@@ -95,6 +100,13 @@ type HTTPTransaction struct {
 	ErrorCount int
 
 	createdAt time.Time
+	// id identifies the transaction in the acknowledgments
+	id string
+	// checksum is the SHA-256 of the Payload, empty unless
+	// forwarder_payload_checksum is set
+	checksum string
+	// ackLog records the attempts to deliver the transaction, nil if disabled
+	ackLog *ackLog
 }
 
 // Transaction represents the task to process for a Worker.
@@ -108,6 +120,7 @@ type Transaction interface {
 func NewHTTPTransaction() *HTTPTransaction {
 	return &HTTPTransaction{
 		createdAt:  time.Now(),
+		id:         strconv.FormatUint(atomic.AddUint64(&lastTransactionID, 1), 10),
 		ErrorCount: 0,
 		Headers:    make(http.Header),
 	}
@@ -124,21 +137,46 @@ func (t *HTTPTransaction) GetTarget() string {
 	return httputils.SanitizeURL(url) // sanitized url that can be logged
 }
 
+// acknowledge records an attempt to deliver the transaction in the
+// acknowledgment log, if enabled
+func (t *HTTPTransaction) acknowledge(attempt int, logURL string, sent time.Time, statusCode int, err error) {
+	if t.ackLog == nil {
+		return
+	}
+	ack := Acknowledgment{
+		TransactionID: t.id,
+		Attempt:       attempt,
+		Endpoint:      logURL,
+		PayloadSize:   len(*t.Payload),
+		Checksum:      t.checksum,
+		StatusCode:    statusCode,
+		Sent:          sent.Unix(),
+		LatencyMs:     int64(time.Since(sent) / time.Millisecond),
+	}
+	if err != nil {
+		ack.Error = httputils.SanitizeURL(err.Error())
+	}
+	t.ackLog.add(ack)
+}
+
 // Process sends the Payload of the transaction to the right Endpoint and Domain.
 func (t *HTTPTransaction) Process(ctx context.Context, client *http.Client) error {
 	reader := bytes.NewReader(*t.Payload)
 	url := t.Domain + t.Endpoint
 	logURL := httputils.SanitizeURL(url) // sanitized url that can be logged
+	attempt := t.ErrorCount + 1
 
 	req, err := http.NewRequest("POST", url, reader)
 	if err != nil {
 		log.Errorf("Could not create request for transaction to invalid URL %q (dropping transaction): %s", logURL, err)
+		t.acknowledge(attempt, logURL, time.Now(), 0, err)
 		transactionsErrors.Add(1)
 		transactionsSentRequestErrors.Add(1)
 		return nil
 	}
 	req = req.WithContext(ctx)
 	req.Header = t.Headers
+	sent := time.Now()
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -146,13 +184,19 @@ func (t *HTTPTransaction) Process(ctx context.Context, client *http.Client) erro
 		if ctx.Err() == context.Canceled {
 			return nil
 		}
+		t.acknowledge(attempt, logURL, sent, 0, err)
 		t.ErrorCount++
 		transactionsErrors.Add(1)
 		return fmt.Errorf("error while sending transaction, rescheduling it: %s", httputils.SanitizeURL(err.Error()))
 	}
 	defer resp.Body.Close()
 
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		clockdrift.ReportIntakeDate(date, sent, time.Now())
+	}
+
 	body, err := ioutil.ReadAll(resp.Body)
+	t.acknowledge(attempt, logURL, sent, resp.StatusCode, err)
 	if err != nil {
 		log.Errorf("Fail to read the response Body: %s", err)
 		return err
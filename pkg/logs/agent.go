@@ -41,7 +41,7 @@ type Agent struct {
 }
 
 // NewAgent returns a new Agent
-func NewAgent(sources *config.LogSources, services *service.Services, processingRules []*config.ProcessingRule, endpoints *config.Endpoints) *Agent {
+func NewAgent(sources *config.LogSources, services *service.Services, processingRules []*config.ProcessingRule, priorityRules *config.PriorityRules, endpoints *config.Endpoints) *Agent {
 	health := health.Register("logs-agent")
 
 	// setup the auditor
@@ -51,7 +51,7 @@ func NewAgent(sources *config.LogSources, services *service.Services, processing
 	destinationsCtx := client.NewDestinationsContext()
 
 	// setup the pipeline provider that provides pairs of processor and sender
-	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, processingRules, endpoints, destinationsCtx)
+	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, processingRules, priorityRules, endpoints, destinationsCtx)
 
 	// setup the inputs
 	inputs := []restart.Restartable{
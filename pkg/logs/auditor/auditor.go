@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/status/canary"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -131,6 +132,11 @@ func (a *Auditor) run() {
 				// inputChan has been closed, no need to update the registry anymore
 				return
 			}
+			if msg.Origin.LogSource != nil && msg.Origin.LogSource.Name == canary.LogSourceName {
+				// the canary logs made it to the intake
+				canary.Observe(canary.Logs, msg.Origin.Offset)
+				continue
+			}
 			// update the registry with new entry
 			a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset)
 		case <-cleanUpTicker.C:
@@ -19,6 +19,14 @@ const (
 	WindowsEventType = "windows_event"
 )
 
+// File tailing modes
+const (
+	// DefaultTailingMode keeps the file open and reads it as it grows
+	DefaultTailingMode = "default"
+	// PollingTailingMode reopens the file periodically, for network filesystems
+	PollingTailingMode = "polling"
+)
+
 // LogsConfig represents a log source config, which can be for instance
 // a file to tail or a port to listen to.
 type LogsConfig struct {
@@ -27,6 +35,8 @@ type LogsConfig struct {
 	Port int    // Network
 	Path string // File, Journald
 
+	TailingMode string `mapstructure:"tailing_mode" json:"tailing_mode"` // File
+
 	IncludeUnits []string `mapstructure:"include_units" json:"include_units"` // Journald
 	ExcludeUnits []string `mapstructure:"exclude_units" json:"exclude_units"` // Journald
 
@@ -55,6 +65,8 @@ func (c *LogsConfig) Validate() error {
 		return fmt.Errorf("a config must have a type")
 	case c.Type == FileType && c.Path == "":
 		return fmt.Errorf("file source must have a path")
+	case c.Type == FileType && c.TailingMode != "" && c.TailingMode != DefaultTailingMode && c.TailingMode != PollingTailingMode:
+		return fmt.Errorf("invalid tailing mode %q, must be %q or %q", c.TailingMode, DefaultTailingMode, PollingTailingMode)
 	case c.Type == TCPType && c.Port == 0:
 		return fmt.Errorf("tcp source must have a port")
 	case c.Type == UDPType && c.Port == 0:
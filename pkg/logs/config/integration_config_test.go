@@ -16,6 +16,7 @@ import (
 func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 	validConfigs := []*LogsConfig{
 		{Type: FileType, Path: "/var/log/foo.log"},
+		{Type: FileType, Path: "/mnt/nfs/foo.log", TailingMode: PollingTailingMode},
 		{Type: TCPType, Port: 1234},
 		{Type: UDPType, Port: 5678},
 		{Type: DockerType},
@@ -32,6 +33,7 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 	invalidConfigs := []*LogsConfig{
 		{},
 		{Type: FileType},
+		{Type: FileType, Path: "/var/log/foo.log", TailingMode: "inotify"},
 		{Type: TCPType},
 		{Type: UDPType},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo"}}},
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// PriorityRules defines the messages sent ahead of the others by the pipelines,
+// for instance when they catch up after an outage of the backend.
+type PriorityRules struct {
+	// statuses are the statuses of the priority messages
+	statuses map[string]bool
+	// MaxConsecutive is the number of priority messages sent in a row before
+	// a waiting message of the backlog is sent, so that it doesn't starve
+	MaxConsecutive int
+}
+
+// NewPriorityRules returns the rules prioritizing the messages with one of
+// the statuses.
+func NewPriorityRules(statuses []string, maxConsecutive int) (*PriorityRules, error) {
+	if len(statuses) == 0 {
+		return nil, fmt.Errorf("no status to prioritize")
+	}
+	if maxConsecutive <= 0 {
+		return nil, fmt.Errorf("max_consecutive must be positive, got %d", maxConsecutive)
+	}
+	rules := &PriorityRules{
+		statuses:       make(map[string]bool, len(statuses)),
+		MaxConsecutive: maxConsecutive,
+	}
+	for _, status := range statuses {
+		rules.statuses[strings.ToLower(status)] = true
+	}
+	return rules, nil
+}
+
+// IsPriority returns true if the messages with the status are sent first.
+func (r *PriorityRules) IsPriority(status string) bool {
+	return r.statuses[status]
+}
+
+// GlobalPriorityRules returns the priority rules of `logs_config.priority_queue`,
+// nil if the priority queuing is disabled.
+func GlobalPriorityRules() (*PriorityRules, error) {
+	if !coreConfig.Datadog.GetBool("logs_config.priority_queue.enabled") {
+		return nil, nil
+	}
+	return NewPriorityRules(
+		coreConfig.Datadog.GetStringSlice("logs_config.priority_queue.statuses"),
+		coreConfig.Datadog.GetInt("logs_config.priority_queue.max_consecutive"),
+	)
+}
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
@@ -19,6 +20,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
 	"github.com/DataDog/datadog-agent/pkg/logs/service"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/blocklist"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -43,6 +46,7 @@ type Launcher struct {
 	erroredContainerID chan string
 	lock               *sync.Mutex
 	collectAllSource   *config.LogSource
+	logsFilter         *containers.Filter
 }
 
 // NewLauncher returns a new launcher
@@ -80,6 +84,10 @@ func (l *Launcher) setup() error {
 	if err != nil {
 		return err
 	}
+	l.logsFilter, err = containers.GetFilter(containers.LogsFilter)
+	if err != nil {
+		return err
+	}
 	// initialize the tagger
 	tagger.Init()
 	return nil
@@ -114,6 +122,14 @@ func (l *Launcher) run() {
 				log.Warnf("Could not find container with id: %v", err)
 				continue
 			}
+			if l.isExcluded(dockerContainer) {
+				log.Debugf("Logs of container %v are excluded by the container filters", ShortContainerID(service.Identifier))
+				continue
+			}
+			if blocklist.IsEntityExcluded(service.GetEntityID(), blocklist.Logs) {
+				log.Debugf("Logs of container %v are excluded by the workload blocklist", ShortContainerID(service.Identifier))
+				continue
+			}
 			container := NewContainer(dockerContainer, service)
 			source := container.FindSource(l.activeSources)
 			switch {
@@ -165,6 +181,20 @@ func (l *Launcher) run() {
 	}
 }
 
+// isExcluded returns whether the logs of the container are excluded by the
+// container filters
+func (l *Launcher) isExcluded(container types.Container) bool {
+	if l.logsFilter == nil {
+		return false
+	}
+	for _, name := range container.Names {
+		if l.logsFilter.IsContainerExcluded(containers.FilterableContainer{Name: name, Image: container.Image, Labels: container.Labels}) {
+			return true
+		}
+	}
+	return false
+}
+
 // overrideSource create a new source with the image short name if the source is ContainerCollectAll
 func (l *Launcher) overrideSource(container *Container, source *config.LogSource) *config.LogSource {
 	if source.Name != config.ContainerCollectAll {
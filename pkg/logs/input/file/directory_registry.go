@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// dirCheckPeriod represents the period of time between two checks of the
+// directories of the sources, much cheaper than a scan.
+const dirCheckPeriod = 1 * time.Second
+
+// directoryRegistry keeps track of the directories containing the files of
+// the sources, to detect the creation of new files without waiting for the
+// next scan. Creating or removing a file updates the modification time of
+// its directory.
+type directoryRegistry struct {
+	modTimes map[string]time.Time
+}
+
+// newDirectoryRegistry returns a new directoryRegistry
+func newDirectoryRegistry() *directoryRegistry {
+	return &directoryRegistry{
+		modTimes: make(map[string]time.Time),
+	}
+}
+
+// refresh tracks the directories matching the sources paths.
+// New directories matching a wildcard are only found by refresh,
+// so they are picked up by the regular scans.
+func (r *directoryRegistry) refresh(sources []*config.LogSource) {
+	modTimes := make(map[string]time.Time)
+	for _, source := range sources {
+		dirs, err := filepath.Glob(filepath.Dir(source.Config.Path))
+		if err != nil {
+			continue
+		}
+		for _, dir := range dirs {
+			if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+				modTimes[dir] = fi.ModTime()
+			}
+		}
+	}
+	r.modTimes = modTimes
+}
+
+// hasChanged returns true if one of the directories has been modified or
+// removed since the last refresh.
+func (r *directoryRegistry) hasChanged() bool {
+	for dir, modTime := range r.modTimes {
+		fi, err := os.Stat(dir)
+		if err != nil || !fi.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
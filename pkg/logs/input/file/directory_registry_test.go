@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build !windows
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func TestDirectoryRegistry(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "log-directory-registry-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	for _, dir := range []string{"a", "b"} {
+		require.NoError(t, os.Mkdir(filepath.Join(testDir, dir), os.ModePerm))
+	}
+
+	registry := newDirectoryRegistry()
+	registry.refresh([]*config.LogSource{
+		config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: filepath.Join(testDir, "*", "*.log")}),
+	})
+	assert.Len(t, registry.modTimes, 2)
+	assert.False(t, registry.hasChanged())
+
+	// appending to a file does not modify its directory
+	path := filepath.Join(testDir, "b", "foo.log")
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, ioutil.WriteFile(path, []byte("foo\n"), 0644))
+	require.NoError(t, os.Chtimes(filepath.Join(testDir, "b"), past, past))
+	registry.refresh([]*config.LogSource{
+		config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: filepath.Join(testDir, "*", "*.log")}),
+	})
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("bar\n")
+	require.NoError(t, err)
+	f.Close()
+	assert.False(t, registry.hasChanged())
+
+	// creating a file does
+	require.NoError(t, ioutil.WriteFile(filepath.Join(testDir, "b", "bar.log"), []byte("foo\n"), 0644))
+	assert.True(t, registry.hasChanged())
+}
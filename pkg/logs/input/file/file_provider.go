@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/status"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -22,6 +23,14 @@ import (
 // files are tailed
 const openFilesLimitWarningType = "open_files_limit_warning"
 
+// evictionMessagePrefix prefixes the keys of the messages of a source listing
+// the files evicted from one of its directories
+const evictionMessagePrefix = "evicted:"
+
+// maxEvictedFilesListed is the number of evicted files listed by directory in
+// the status of a source
+const maxEvictedFilesListed = 10
+
 // File represents a file to tail
 type File struct {
 	Path           string
@@ -37,10 +46,20 @@ func NewFile(path string, source *config.LogSource) *File {
 	}
 }
 
+// evictedFile is a file not tailed as the open files limit was reached, and
+// its modification time
+type evictedFile struct {
+	file    *File
+	modTime time.Time
+}
+
 // Provider implements the logic to retrieve at most filesLimit Files defined in sources
 type Provider struct {
 	filesLimit      int
 	shouldLogErrors bool
+	// the keys of the eviction messages of the sources, to remove them
+	// when their directories don't have evicted files anymore
+	evictionMessages map[*config.LogSource][]string
 }
 
 // NewProvider returns a new Provider
@@ -53,35 +72,50 @@ func NewProvider(filesLimit int) *Provider {
 
 // FilesToTail returns all the Files matching paths in sources,
 // it cannot return more than filesLimit Files.
-// For now, there is no way to prioritize specific Files over others,
-// they are just returned in reverse lexicographical order, see `searchFiles`
+// When more files match, the files which have not been modified for the
+// longest time are evicted first, the others are returned in the order of
+// their sources and in reverse lexicographical order, see `searchFiles`
 func (p *Provider) FilesToTail(sources []*config.LogSource) []*File {
-	var filesToTail []*File
+	var matchingFiles []*File
+	matchesBySource := make(map[*config.LogSource]int)
 	shouldLogErrors := p.shouldLogErrors
 	p.shouldLogErrors = false // Let's log errors on first run only
 
 	for i := 0; i < len(sources); i++ {
 		source := sources[i]
-		tailedFileCounter := 0
 		files, err := p.CollectFiles(source)
 		isWildcardPath := p.containsWildcard(source.Config.Path)
 		if err != nil {
 			source.Status.Error(err)
 			if isWildcardPath {
-				source.Messages.AddMessage(source.Config.Path, fmt.Sprintf("%d files tailed out of %d files matching", tailedFileCounter, len(files)))
+				source.Messages.AddMessage(source.Config.Path, "0 files tailed out of 0 files matching")
 			}
 			if shouldLogErrors {
 				log.Warnf("Could not collect files: %v", err)
 			}
 			continue
 		}
-		for j := 0; j < len(files) && len(filesToTail) < p.filesLimit; j++ {
-			file := files[j]
+		for _, file := range files {
 			file.IsWildcardPath = isWildcardPath
-			filesToTail = append(filesToTail, file)
-			tailedFileCounter++
 		}
+		matchingFiles = append(matchingFiles, files...)
+		matchesBySource[source] = len(files)
+	}
+
+	filesToTail, evicted := p.applyFilesLimit(matchingFiles)
+	p.reportEvictions(evicted)
+
+	tailedBySource := make(map[*config.LogSource]int)
+	for _, file := range filesToTail {
+		tailedBySource[file.Source]++
+	}
+	for source, matches := range matchesBySource {
+		if p.containsWildcard(source.Config.Path) {
+			source.Messages.AddMessage(source.Config.Path, fmt.Sprintf("%d files tailed out of %d files matching", tailedBySource[source], matches))
+		}
+	}
 
+	if len(matchesBySource) > 0 {
 		if len(filesToTail) >= p.filesLimit {
 			status.AddGlobalWarning(
 				openFilesLimitWarningType,
@@ -93,20 +127,103 @@ func (p *Provider) FilesToTail(sources []*config.LogSource) []*File {
 		} else {
 			status.RemoveGlobalWarning(openFilesLimitWarningType)
 		}
-
-		if isWildcardPath {
-			source.Messages.AddMessage(source.Config.Path, fmt.Sprintf("%d files tailed out of %d files matching", tailedFileCounter, len(files)))
-		}
 	}
 
 	if len(filesToTail) == p.filesLimit {
 		log.Warn("Reached the limit on the maximum number of files in use: ", p.filesLimit)
-		return filesToTail
 	}
 
 	return filesToTail
 }
 
+// applyFilesLimit returns at most filesLimit files, evicting the files with
+// the oldest modification time first. The order of the files is preserved.
+func (p *Provider) applyFilesLimit(files []*File) ([]*File, []evictedFile) {
+	if len(files) <= p.filesLimit {
+		return files, nil
+	}
+
+	modTimes := make(map[*File]time.Time, len(files))
+	for _, file := range files {
+		if fi, err := os.Stat(file.Path); err == nil {
+			modTimes[file] = fi.ModTime()
+		}
+	}
+
+	byActivity := make([]*File, len(files))
+	copy(byActivity, files)
+	sort.SliceStable(byActivity, func(i, j int) bool {
+		return modTimes[byActivity[i]].After(modTimes[byActivity[j]])
+	})
+	selected := make(map[*File]bool, p.filesLimit)
+	for _, file := range byActivity[:p.filesLimit] {
+		selected[file] = true
+	}
+
+	filesToTail := make([]*File, 0, p.filesLimit)
+	for _, file := range files {
+		if selected[file] {
+			filesToTail = append(filesToTail, file)
+		}
+	}
+	evicted := make([]evictedFile, 0, len(files)-len(filesToTail))
+	for _, file := range byActivity[p.filesLimit:] {
+		evicted = append(evicted, evictedFile{file: file, modTime: modTimes[file]})
+	}
+	log.Debugf("Evicted %d files from tailing, open files limit is %d", len(evicted), p.filesLimit)
+	return filesToTail, evicted
+}
+
+// reportEvictions adds to the status of the sources a message by directory
+// listing the files evicted, the most recently modified first, and removes
+// the messages of the directories without evicted files anymore
+func (p *Provider) reportEvictions(evicted []evictedFile) {
+	byDirectory := make(map[*config.LogSource]map[string][]evictedFile)
+	for _, e := range evicted {
+		dirs, found := byDirectory[e.file.Source]
+		if !found {
+			dirs = make(map[string][]evictedFile)
+			byDirectory[e.file.Source] = dirs
+		}
+		dir := filepath.Dir(e.file.Path)
+		dirs[dir] = append(dirs[dir], e)
+	}
+
+	messages := make(map[*config.LogSource][]string, len(byDirectory))
+	for source, dirs := range byDirectory {
+		for dir, files := range dirs {
+			key := evictionMessagePrefix + dir
+			source.Messages.AddMessage(key, p.evictionMessage(dir, files))
+			messages[source] = append(messages[source], key)
+		}
+	}
+	for source, keys := range p.evictionMessages {
+		for _, key := range keys {
+			if _, found := byDirectory[source][strings.TrimPrefix(key, evictionMessagePrefix)]; !found {
+				source.Messages.RemoveMessage(key)
+			}
+		}
+	}
+	p.evictionMessages = messages
+}
+
+// evictionMessage explains why the files of a directory were evicted
+func (p *Provider) evictionMessage(dir string, files []evictedFile) string {
+	names := make([]string, 0, maxEvictedFilesListed+1)
+	for i, e := range files {
+		if i == maxEvictedFilesListed {
+			names = append(names, fmt.Sprintf("and %d more", len(files)-i))
+			break
+		}
+		if e.modTime.IsZero() {
+			names = append(names, fmt.Sprintf("%s (could not be stat'ed)", filepath.Base(e.file.Path)))
+			continue
+		}
+		names = append(names, fmt.Sprintf("%s (modified %s)", filepath.Base(e.file.Path), e.modTime.UTC().Format(time.RFC3339)))
+	}
+	return fmt.Sprintf("%d files evicted from %s, the least recently modified ones when the open files limit (%d) was reached: %s", len(files), dir, p.filesLimit, strings.Join(names, ", "))
+}
+
 // CollectFiles returns all the files matching the source path.
 func (p *Provider) CollectFiles(source *config.LogSource) ([]*File, error) {
 	path := source.Config.Path
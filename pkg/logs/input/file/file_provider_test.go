@@ -11,7 +11,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 
@@ -171,7 +173,7 @@ func (suite *ProviderTestSuite) TestNumberOfFilesToTailDoesNotExceedLimit() {
 	status.CreateSources(logSources)
 	files := fileProvider.FilesToTail(logSources)
 	suite.Equal(suite.filesLimit, len(files))
-	suite.Equal([]string{"3 files tailed out of 5 files matching"}, logSources[0].Messages.GetMessages())
+	suite.Contains(logSources[0].Messages.GetMessages(), "3 files tailed out of 5 files matching")
 	suite.Equal(
 		[]string{
 			"The limit on the maximum number of files in use (3) has been reached. Increase this limit (thanks to the attribute logs_config.open_files_limit in datadog.yaml) or decrease the number of tailed file.",
@@ -188,16 +190,26 @@ func (suite *ProviderTestSuite) TestAllWildcardPathsAreUpdated() {
 		config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: fmt.Sprintf("%s/2/*.log", suite.testDir)}),
 	}
 	status.CreateSources(logSources)
+	// the files of the second source are the least recently modified ones
+	suite.setModTime(fmt.Sprintf("%s/1/1.log", suite.testDir), 30*time.Minute)
+	suite.setModTime(fmt.Sprintf("%s/2/1.log", suite.testDir), 2*time.Hour)
+	suite.setModTime(fmt.Sprintf("%s/2/2.log", suite.testDir), time.Hour)
 	files := fileProvider.FilesToTail(logSources)
 	suite.Equal(2, len(files))
-	suite.Equal([]string{"2 files tailed out of 3 files matching"}, logSources[0].Messages.GetMessages())
+	suite.ElementsMatch([]string{
+		"2 files tailed out of 3 files matching",
+		suite.evictionMessage(filesLimit, "1", "1.log"),
+	}, logSources[0].Messages.GetMessages())
 	suite.Equal(
 		[]string{
 			"The limit on the maximum number of files in use (2) has been reached. Increase this limit (thanks to the attribute logs_config.open_files_limit in datadog.yaml) or decrease the number of tailed file.",
 		},
 		status.Get().Warnings,
 	)
-	suite.Equal([]string{"0 files tailed out of 2 files matching"}, logSources[1].Messages.GetMessages())
+	suite.ElementsMatch([]string{
+		"0 files tailed out of 2 files matching",
+		suite.evictionMessage(filesLimit, "2", "2.log", "1.log"),
+	}, logSources[1].Messages.GetMessages())
 	suite.Equal(
 		[]string{
 			"The limit on the maximum number of files in use (2) has been reached. Increase this limit (thanks to the attribute logs_config.open_files_limit in datadog.yaml) or decrease the number of tailed file.",
@@ -210,6 +222,7 @@ func (suite *ProviderTestSuite) TestAllWildcardPathsAreUpdated() {
 	os.Remove(fmt.Sprintf("%s/2/2.log", suite.testDir))
 	files = fileProvider.FilesToTail(logSources)
 	suite.Equal(2, len(files))
+	// the eviction messages are removed with the evictions
 	suite.Equal([]string{"1 files tailed out of 1 files matching"}, logSources[0].Messages.GetMessages())
 
 	suite.Equal([]string{"1 files tailed out of 1 files matching"}, logSources[1].Messages.GetMessages())
@@ -229,6 +242,62 @@ func (suite *ProviderTestSuite) TestAllWildcardPathsAreUpdated() {
 	suite.Equal([]string{"0 files tailed out of 0 files matching"}, logSources[1].Messages.GetMessages())
 }
 
+func (suite *ProviderTestSuite) TestLeastRecentlyModifiedFilesAreEvictedFirst() {
+	path := fmt.Sprintf("%s/*/*.log", suite.testDir)
+	fileProvider := NewProvider(suite.filesLimit)
+	logSources := suite.newLogSources(path)
+	status.CreateSources(logSources)
+
+	suite.setModTime(fmt.Sprintf("%s/1/3.log", suite.testDir), 3*time.Hour)
+	suite.setModTime(fmt.Sprintf("%s/2/2.log", suite.testDir), 2*time.Hour)
+	suite.setModTime(fmt.Sprintf("%s/1/2.log", suite.testDir), time.Hour)
+	suite.setModTime(fmt.Sprintf("%s/2/1.log", suite.testDir), 0)
+	suite.setModTime(fmt.Sprintf("%s/1/1.log", suite.testDir), 0)
+
+	files := fileProvider.FilesToTail(logSources)
+	suite.Equal(3, len(files))
+	suite.Equal(fmt.Sprintf("%s/1/2.log", suite.testDir), files[0].Path)
+	suite.Equal(fmt.Sprintf("%s/2/1.log", suite.testDir), files[1].Path)
+	suite.Equal(fmt.Sprintf("%s/1/1.log", suite.testDir), files[2].Path)
+	suite.ElementsMatch([]string{
+		"3 files tailed out of 5 files matching",
+		suite.evictionMessage(suite.filesLimit, "1", "3.log"),
+		suite.evictionMessage(suite.filesLimit, "2", "2.log"),
+	}, logSources[0].Messages.GetMessages())
+
+	// a file becoming active again evicts the least recently modified one
+	suite.setModTime(fmt.Sprintf("%s/1/3.log", suite.testDir), 0)
+	files = fileProvider.FilesToTail(logSources)
+	suite.Equal(3, len(files))
+	suite.Equal(fmt.Sprintf("%s/1/3.log", suite.testDir), files[0].Path)
+	suite.Equal(fmt.Sprintf("%s/2/1.log", suite.testDir), files[1].Path)
+	suite.Equal(fmt.Sprintf("%s/1/1.log", suite.testDir), files[2].Path)
+	suite.ElementsMatch([]string{
+		"3 files tailed out of 5 files matching",
+		suite.evictionMessage(suite.filesLimit, "1", "2.log"),
+		suite.evictionMessage(suite.filesLimit, "2", "2.log"),
+	}, logSources[0].Messages.GetMessages())
+}
+
+// evictionMessage returns the status message of the files evicted from a
+// directory of the test directory, the most recently modified first
+func (suite *ProviderTestSuite) evictionMessage(filesLimit int, dir string, names ...string) string {
+	dir = fmt.Sprintf("%s/%s", suite.testDir, dir)
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		fi, err := os.Stat(fmt.Sprintf("%s/%s", dir, name))
+		suite.Nil(err)
+		files = append(files, fmt.Sprintf("%s (modified %s)", name, fi.ModTime().UTC().Format(time.RFC3339)))
+	}
+	return fmt.Sprintf("%d files evicted from %s, the least recently modified ones when the open files limit (%d) was reached: %s", len(names), dir, filesLimit, strings.Join(files, ", "))
+}
+
+// setModTime sets the modification time of the file at path to now minus age
+func (suite *ProviderTestSuite) setModTime(path string, age time.Duration) {
+	modTime := time.Now().Add(-age)
+	suite.Nil(os.Chtimes(path, modTime, modTime))
+}
+
 func TestProviderTestSuite(t *testing.T) {
 	suite.Run(t, new(ProviderTestSuite))
 }
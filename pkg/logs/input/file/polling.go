@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package file
+
+import (
+	"hash/crc32"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// fingerprintSize is the number of bytes at the beginning of a file used to
+// detect that it has been replaced
+const fingerprintSize = 1024
+
+// pollForever lets the tailer poll its file until it is stopped.
+// The file is reopened on every poll: network filesystems (NFS, SMB) only
+// guarantee that an open sees the latest content (close-to-open consistency),
+// and they don't provide stable inodes to detect rotations, so those are
+// detected with the file size and a checksum of its first bytes instead.
+func (t *Tailer) pollForever() {
+	defer t.onStop()
+	for {
+		select {
+		case <-t.stop:
+			// stop reading data from file
+			return
+		default:
+			if atomic.LoadInt32(&t.didFileRotate) != 0 {
+				// the rotated file can't be reopened by path, nothing left to read
+				return
+			}
+			n, err := t.poll()
+			if err != nil {
+				// network filesystems have transient errors, keep polling
+				log.Debugf("Could not poll file %s: %v", t.path, err)
+			}
+			if n == 0 {
+				// wait for new data to come
+				t.wait()
+			}
+		}
+	}
+}
+
+// poll reopens the file and reads the data written since the last read,
+// returns the number of bytes read.
+func (t *Tailer) poll() (int, error) {
+	if atomic.LoadInt32(&t.rotationDetected) != 0 {
+		// wait for the scanner to start a new tailer
+		return 0, nil
+	}
+
+	f, err := openFile(t.fullpath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	rotated, err := t.checkFingerprint(f)
+	if err != nil {
+		return 0, err
+	}
+	if rotated {
+		log.Infof("Log rotation detected by polling on %s", t.path)
+		atomic.StoreInt32(&t.rotationDetected, 1)
+		return 0, nil
+	}
+
+	inBuf := make([]byte, 4096)
+	n, err := f.ReadAt(inBuf, t.GetReadOffset())
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
+	t.incrementReadOffset(n)
+	return n, nil
+}
+
+// setupFingerprint records the fingerprint of the file tailed in polling mode
+func (t *Tailer) setupFingerprint(f *os.File) error {
+	fingerprint, length, err := computeFingerprint(f, fingerprintSize)
+	if err != nil {
+		return err
+	}
+	t.fingerprint = fingerprint
+	t.fingerprintLen = length
+	return nil
+}
+
+// checkFingerprint returns true if the file has been truncated or replaced
+// since the last poll. The fingerprint is extended while the file is smaller
+// than fingerprintSize.
+func (t *Tailer) checkFingerprint(f *os.File) (bool, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if fi.Size() < t.GetReadOffset() {
+		// truncated
+		return true, nil
+	}
+
+	fingerprint, length, err := computeFingerprint(f, t.fingerprintLen)
+	if err != nil {
+		return false, err
+	}
+	if length < t.fingerprintLen || fingerprint != t.fingerprint {
+		// the beginning of the file changed, it has been replaced
+		return true, nil
+	}
+
+	if t.fingerprintLen < fingerprintSize && fi.Size() > t.fingerprintLen {
+		return false, t.setupFingerprint(f)
+	}
+	return false, nil
+}
+
+// computeFingerprint returns the checksum of the first size bytes of the file
+// and the number of bytes it was computed on.
+func computeFingerprint(f *os.File, size int64) (uint32, int64, error) {
+	buf := make([]byte, size)
+	n, err := f.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return 0, 0, err
+	}
+	return crc32.ChecksumIEEE(buf[:n]), int64(n), nil
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build !windows
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newPollingTailer(t *testing.T) (*Tailer, string, func()) {
+	testDir, err := ioutil.TempDir("", "log-polling-test-")
+	require.NoError(t, err)
+	path := filepath.Join(testDir, "polling.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello world\n"), 0644))
+
+	source := config.NewLogSource("", &config.LogsConfig{
+		Type:        config.FileType,
+		Path:        path,
+		TailingMode: config.PollingTailingMode,
+	})
+	tailer := NewTailer(make(chan *message.Message, chanSize), source, path, 10*time.Millisecond, false)
+	tailer.closeTimeout = closeTimeout
+	require.NoError(t, tailer.StartFromBeginning())
+
+	return tailer, path, func() {
+		tailer.Stop()
+		os.RemoveAll(testDir)
+	}
+}
+
+func appendToFile(t *testing.T, path string, content string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+}
+
+func waitForRotation(tailer *Tailer) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if rotated, _ := tailer.didRotate(); rotated {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestPollingTailerReadsNewData(t *testing.T) {
+	tailer, path, cleanup := newPollingTailer(t)
+	defer cleanup()
+
+	// the file is not kept open between polls
+	assert.Nil(t, tailer.file)
+
+	msg := <-tailer.outputChan
+	assert.Equal(t, "hello world", string(msg.Content))
+
+	appendToFile(t, path, "hello again\n")
+	msg = <-tailer.outputChan
+	assert.Equal(t, "hello again", string(msg.Content))
+	assert.Equal(t, "24", msg.Origin.Offset)
+}
+
+func TestPollingTailerDetectsTruncation(t *testing.T) {
+	tailer, path, cleanup := newPollingTailer(t)
+	defer cleanup()
+	<-tailer.outputChan
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("new\n"), 0644))
+	assert.True(t, waitForRotation(tailer))
+}
+
+func TestPollingTailerDetectsReplacement(t *testing.T) {
+	tailer, path, cleanup := newPollingTailer(t)
+	defer cleanup()
+	<-tailer.outputChan
+
+	// a new file bigger than the read offset, with a different beginning
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, ioutil.WriteFile(path, []byte("a new file with more content\n"), 0644))
+	assert.True(t, waitForRotation(tailer))
+
+	// no data of the new file is read from the old offset
+	select {
+	case msg := <-tailer.outputChan:
+		assert.Fail(t, "unexpected message", string(msg.Content))
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the tailer stops once the scanner starts a new one
+	tailer.StopAfterFileRotation()
+	assert.True(t, atomic.LoadInt32(&tailer.didFileRotate) != 0)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
 )
@@ -31,6 +32,7 @@ type Scanner struct {
 	tailingLimit        int
 	fileProvider        *Provider
 	tailers             map[string]*Tailer
+	directories         *directoryRegistry
 	registry            auditor.Registry
 	tailerSleepDuration time.Duration
 	stop                chan struct{}
@@ -45,6 +47,7 @@ func NewScanner(sources *config.LogSources, tailingLimit int, pipelineProvider p
 		removedSources:      sources.GetRemovedForType(config.FileType),
 		fileProvider:        NewProvider(tailingLimit),
 		tailers:             make(map[string]*Tailer),
+		directories:         newDirectoryRegistry(),
 		registry:            registry,
 		tailerSleepDuration: tailerSleepDuration,
 		stop:                make(chan struct{}),
@@ -67,6 +70,8 @@ func (s *Scanner) Stop() {
 func (s *Scanner) run() {
 	scanTicker := time.NewTicker(scanPeriod)
 	defer scanTicker.Stop()
+	dirTicker := time.NewTicker(dirCheckPeriod)
+	defer dirTicker.Stop()
 	for {
 		select {
 		case source := <-s.addedSources:
@@ -76,6 +81,11 @@ func (s *Scanner) run() {
 		case <-scanTicker.C:
 			// check if there are new files to tail, tailers to stop and tailer to restart because of file rotation
 			s.scan()
+		case <-dirTicker.C:
+			// pick up new files within seconds
+			if s.directories.hasChanged() {
+				s.scan()
+			}
 		case <-s.stop:
 			// no more file should be tailed
 			return
@@ -102,6 +112,23 @@ func (s *Scanner) cleanup() {
 // and start a new one for the new file.
 func (s *Scanner) scan() {
 	files := s.fileProvider.FilesToTail(s.activeSources)
+	s.directories.refresh(s.activeSources)
+	defer func() {
+		metrics.TailedFiles.Set(int64(len(s.tailers)))
+	}()
+
+	// stop the tailers of the files evicted by the provider first,
+	// so that the newly selected files can be tailed right away
+	filesToTail := make(map[string]bool, len(files))
+	for _, file := range files {
+		filesToTail[file.Path] = true
+	}
+	for path, tailer := range s.tailers {
+		if !filesToTail[path] {
+			s.stopTailer(tailer)
+		}
+	}
+
 	filesTailed := make(map[string]bool)
 	tailersLen := len(s.tailers)
 
@@ -128,7 +155,7 @@ func (s *Scanner) scan() {
 			continue
 		}
 
-		didRotate, err := DidRotate(tailer.file, tailer.GetReadOffset())
+		didRotate, err := tailer.didRotate()
 		if err != nil {
 			continue
 		}
@@ -42,6 +42,12 @@ type Tailer struct {
 	isWildcardPath bool
 	tags           []string
 
+	// polling mode, see pollForever
+	polling          bool
+	fingerprint      uint32
+	fingerprintLen   int64
+	rotationDetected int32
+
 	outputChan  chan *message.Message
 	decoder     *decoder.Decoder
 	source      *config.LogSource
@@ -92,6 +98,7 @@ func NewTailer(outputChan chan *message.Message, source *config.LogSource, path
 		stop:           make(chan struct{}, 1),
 		done:           make(chan struct{}, 1),
 		isWildcardPath: isWildcardPath,
+		polling:        source.Config.TailingMode == config.PollingTailingMode,
 		forwardContext: forwardContext,
 		stopForward:    stopForward,
 	}
@@ -115,7 +122,11 @@ func (t *Tailer) Start(offset int64, whence int) error {
 	t.tagProvider.Start()
 	go t.forwardMessages()
 	t.decoder.Start()
-	go t.readForever()
+	if t.polling {
+		go t.pollForever()
+	} else {
+		go t.readForever()
+	}
 
 	return nil
 }
@@ -136,10 +147,17 @@ func (t *Tailer) setup(offset int64, whence int) error {
 		return err
 	}
 
-	t.file = f
 	ret, _ := f.Seek(offset, whence)
 	t.readOffset = ret
 	t.decodedOffset = ret
+	t.fullpath = fullpath
+
+	if t.polling {
+		// the file is reopened on every poll
+		defer f.Close()
+		return t.setupFingerprint(f)
+	}
+	t.file = f
 
 	return nil
 }
@@ -218,7 +236,9 @@ func (t *Tailer) startStopTimer() {
 // onStop finishes to stop the tailer
 func (t *Tailer) onStop() {
 	log.Info("Closing ", t.path)
-	t.file.Close()
+	if t.file != nil {
+		t.file.Close()
+	}
 	t.decoder.Stop()
 }
 
@@ -262,6 +282,14 @@ func (t *Tailer) GetReadOffset() int64 {
 	return atomic.LoadInt64(&t.readOffset)
 }
 
+// didRotate returns true if the file has been log-rotated
+func (t *Tailer) didRotate() (bool, error) {
+	if t.polling {
+		return atomic.LoadInt32(&t.rotationDetected) != 0, nil
+	}
+	return DidRotate(t.file, t.GetReadOffset())
+}
+
 // shouldTrackOffset returns whether the tailer should track the file offset or not
 func (t *Tailer) shouldTrackOffset() bool {
 	if atomic.LoadInt32(&t.didFileRotate) != 0 {
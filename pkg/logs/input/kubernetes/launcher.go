@@ -14,6 +14,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/blocklist"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -37,6 +38,7 @@ type Launcher struct {
 	addedServices      chan *service.Service
 	removedServices    chan *service.Service
 	collectAll         bool
+	logsFilter         *containers.Filter
 }
 
 // NewLauncher returns a new launcher.
@@ -74,6 +76,11 @@ func isIntegrationAvailable() bool {
 
 // setup initializes the pod watcher and the tagger.
 func (l *Launcher) setup() error {
+	var err error
+	l.logsFilter, err = containers.GetFilter(containers.LogsFilter)
+	if err != nil {
+		return err
+	}
 	// initialize the tagger to collect container tags
 	tagger.Init()
 	return nil
@@ -122,11 +129,24 @@ func (l *Launcher) addSource(svc *service.Service) {
 		log.Warnf("Could not add source for container %v: %v", svc.Identifier, err)
 		return
 	}
+	if blocklist.IsPodExcluded(pod, blocklist.Logs) {
+		log.Debugf("Logs of container %v are excluded by the workload blocklist", svc.Identifier)
+		return
+	}
 	container, err := l.kubeutil.GetStatusForContainerID(pod, svc.GetEntityID())
 	if err != nil {
 		log.Warn(err)
 		return
 	}
+	if l.logsFilter != nil && l.logsFilter.IsContainerExcluded(containers.FilterableContainer{
+		Name:      container.Name,
+		Image:     container.Image,
+		Namespace: pod.Metadata.Namespace,
+		Labels:    pod.Metadata.Labels,
+	}) {
+		log.Debugf("Logs of container %v are excluded by the container filters", svc.Identifier)
+		return
+	}
 	source, err := l.getSource(pod, container)
 	if err != nil {
 		if err != collectAllDisabledError {
@@ -14,17 +14,27 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/scheduler"
 	"github.com/DataDog/datadog-agent/pkg/logs/service"
 	"github.com/DataDog/datadog-agent/pkg/logs/status"
+	"github.com/DataDog/datadog-agent/pkg/status/canary"
 )
 
 const (
 	// key used to display a warning message on the agent status
 	invalidProcessingRules = "invalid_global_processing_rules"
+	invalidPriorityRules   = "invalid_priority_rules"
 	invalidEndpoints       = "invalid_endpoints"
 )
 
+// canarySource is the source of the canary logs, not added to the sources so
+// that it doesn't show up in the status
+var canarySource = config.NewLogSource(canary.LogSourceName, &config.LogsConfig{
+	Source:  "datadog-agent",
+	Service: "datadog-agent",
+})
+
 var (
 	// isRunning indicates whether logs-agent is running or not
 	isRunning int32
@@ -67,8 +77,16 @@ func Start() error {
 		return errors.New(message)
 	}
 
+	// setup the priority rules of the pipelines
+	priorityRules, err := config.GlobalPriorityRules()
+	if err != nil {
+		message := fmt.Sprintf("Invalid priority queue: %v", err)
+		status.AddGlobalError(invalidPriorityRules, message)
+		return errors.New(message)
+	}
+
 	// setup and start the agent
-	agent = NewAgent(sources, services, processingRules, endpoints)
+	agent = NewAgent(sources, services, processingRules, priorityRules, endpoints)
 	log.Info("Starting logs-agent...")
 	agent.Start()
 	atomic.StoreInt32(&isRunning, 1)
@@ -115,3 +133,21 @@ func GetStatus() status.Status {
 func GetScheduler() *scheduler.Scheduler {
 	return adScheduler
 }
+
+// SendCanary sends a canary log through a pipeline of the logs-agent, the
+// auditor reports it once it reached the intake
+func SendCanary(id string) error {
+	if !IsAgentRunning() || agent == nil {
+		return errors.New("logs-agent is not running")
+	}
+	origin := message.NewOrigin(canarySource)
+	// the identifier is left empty for the auditor to not register the offset
+	origin.Offset = id
+	msg := message.NewMessage([]byte("datadog-agent canary "+id), origin, message.StatusInfo)
+	select {
+	case agent.pipelineProvider.NextPipelineChan() <- msg:
+		return nil
+	default:
+		return errors.New("logs pipeline is full")
+	}
+}
@@ -16,12 +16,16 @@ var (
 	LogsDecoded = expvar.Int{}
 	// LogsProcessed is the total number of processed logs.
 	LogsProcessed = expvar.Int{}
+	// LogsPrioritized is the total number of logs matching the priority rules
+	LogsPrioritized = expvar.Int{}
 	// LogsSent is the total number of sent logs.
 	LogsSent = expvar.Int{}
 	// DestinationErrors is the total number of network errors.
 	DestinationErrors = expvar.Int{}
 	// DestinationLogsDropped is the total number of logs dropped per Destination
 	DestinationLogsDropped = expvar.Map{}
+	// TailedFiles is the number of files currently tailed
+	TailedFiles = expvar.Int{}
 	// TODO: Add LogsCollected for the total number of collected logs.
 )
 
@@ -29,7 +33,9 @@ func init() {
 	LogsExpvars = expvar.NewMap("logs-agent")
 	LogsExpvars.Set("LogsDecoded", &LogsDecoded)
 	LogsExpvars.Set("LogsProcessed", &LogsProcessed)
+	LogsExpvars.Set("LogsPrioritized", &LogsPrioritized)
 	LogsExpvars.Set("LogsSent", &LogsSent)
 	LogsExpvars.Set("DestinationErrors", &DestinationErrors)
 	LogsExpvars.Set("DestinationLogsDropped", &DestinationLogsDropped)
+	LogsExpvars.Set("TailedFiles", &TailedFiles)
 }
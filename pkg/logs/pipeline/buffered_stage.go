@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/buffer"
+)
+
+// ProcessorsOutputBufferName returns the name of the instrumented buffer of
+// the channel between the processor and the sender of a pipeline
+func ProcessorsOutputBufferName(pipelineID int) string {
+	return fmt.Sprintf("logs_processors_output_%d", pipelineID)
+}
+
+// A bufferedStage sits between the processor and the sender of a pipeline
+// without priority rules, it forwards the messages of the instrumented channel
+// of the processor to the sender and releases their room in the buffer.
+type bufferedStage struct {
+	inputChan   chan *message.Message
+	inputBuffer *buffer.Buffer
+	outputChan  chan *message.Message
+	done        chan struct{}
+}
+
+func newBufferedStage(inputChan, outputChan chan *message.Message, inputBuffer *buffer.Buffer) *bufferedStage {
+	return &bufferedStage{
+		inputChan:   inputChan,
+		inputBuffer: inputBuffer,
+		outputChan:  outputChan,
+		done:        make(chan struct{}),
+	}
+}
+
+// Start starts the bufferedStage.
+func (s *bufferedStage) Start() {
+	go s.forward()
+}
+
+// Stop stops the bufferedStage,
+// this call blocks until all the messages are forwarded
+func (s *bufferedStage) Stop() {
+	close(s.inputChan)
+	<-s.done
+}
+
+func (s *bufferedStage) forward() {
+	defer func() {
+		s.done <- struct{}{}
+	}()
+	for msg := range s.inputChan {
+		s.inputBuffer.Release()
+		s.outputChan <- msg
+	}
+}
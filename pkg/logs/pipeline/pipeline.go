@@ -13,17 +13,22 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/buffer"
 )
 
 // Pipeline processes and sends messages to the backend
 type Pipeline struct {
-	InputChan chan *message.Message
-	processor *processor.Processor
-	sender    *sender.Sender
+	InputChan     chan *message.Message
+	processor     *processor.Processor
+	priorityQueue *priorityQueue
+	bufferedStage *bufferedStage
+	sender        *sender.Sender
 }
 
-// NewPipeline returns a new Pipeline
-func NewPipeline(outputChan chan *message.Message, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) *Pipeline {
+// NewPipeline returns a new Pipeline, the messages matching the priority rules
+// are sent first when priorityRules is not nil. The id of the pipeline names
+// the instrumented buffer of its processor.
+func NewPipeline(id int, outputChan chan *message.Message, processingRules []*config.ProcessingRule, priorityRules *config.PriorityRules, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) *Pipeline {
 	var destinations *client.Destinations
 	if endpoints.UseHTTP {
 		main := http.NewDestination(endpoints.Main, http.JSONContentType, destinationsContext)
@@ -41,7 +46,10 @@ func NewPipeline(outputChan chan *message.Message, processingRules []*config.Pro
 		destinations = client.NewDestinations(main, additionals)
 	}
 
-	senderChan := make(chan *message.Message, config.ChanSize)
+	// the messages are buffered before the priority queue or the buffered
+	// stage, the sender channel is unbuffered for the priority queue to pick
+	// the next message to send
+	senderChan := make(chan *message.Message)
 
 	var strategy sender.Strategy
 	if endpoints.UseHTTP {
@@ -60,24 +68,49 @@ func NewPipeline(outputChan chan *message.Message, processingRules []*config.Pro
 		encoder = processor.RawEncoder
 	}
 
+	// the size of the buffer is kept across the restarts of the logs-agent
+	outputBuffer := buffer.GetOrNew(ProcessorsOutputBufferName(id), config.ChanSize)
+	processorOutputChan := make(chan *message.Message, outputBuffer.Capacity())
+
+	var priorityQueue *priorityQueue
+	var bufferedStage *bufferedStage
+	if priorityRules != nil {
+		priorityQueue = newPriorityQueue(processorOutputChan, senderChan, priorityRules)
+		priorityQueue.inputBuffer = outputBuffer
+	} else {
+		bufferedStage = newBufferedStage(processorOutputChan, senderChan, outputBuffer)
+	}
+
 	inputChan := make(chan *message.Message, config.ChanSize)
-	processor := processor.New(inputChan, senderChan, processingRules, encoder)
+	processor := processor.New(inputChan, processorOutputChan, outputBuffer, processingRules, encoder)
 
 	return &Pipeline{
-		InputChan: inputChan,
-		processor: processor,
-		sender:    sender,
+		InputChan:     inputChan,
+		processor:     processor,
+		priorityQueue: priorityQueue,
+		bufferedStage: bufferedStage,
+		sender:        sender,
 	}
 }
 
 // Start launches the pipeline
 func (p *Pipeline) Start() {
 	p.sender.Start()
+	if p.priorityQueue != nil {
+		p.priorityQueue.Start()
+	} else {
+		p.bufferedStage.Start()
+	}
 	p.processor.Start()
 }
 
 // Stop stops the pipeline
 func (p *Pipeline) Stop() {
 	p.processor.Stop()
+	if p.priorityQueue != nil {
+		p.priorityQueue.Stop()
+	} else {
+		p.bufferedStage.Stop()
+	}
 	p.sender.Stop()
 }
@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package pipeline
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/buffer"
+)
+
+// A priorityQueue sits between the processor and the sender of a pipeline,
+// it forwards the messages matching the priority rules ahead of the other ones
+// waiting to be sent. Every MaxConsecutive priority messages, a waiting message
+// of the backlog is forwarded so that the backlog never starves.
+type priorityQueue struct {
+	inputChan    chan *message.Message
+	inputBuffer  *buffer.Buffer // released when routing a message, nil if not instrumented
+	priorityChan chan *message.Message
+	backlogChan  chan *message.Message
+	outputChan   chan *message.Message
+	rules        *config.PriorityRules
+	done         chan struct{}
+}
+
+// newPriorityQueue returns a new priorityQueue, outputChan should be unbuffered
+// for the sender to always get the message with the highest priority.
+func newPriorityQueue(inputChan, outputChan chan *message.Message, rules *config.PriorityRules) *priorityQueue {
+	return &priorityQueue{
+		inputChan:    inputChan,
+		priorityChan: make(chan *message.Message, config.ChanSize),
+		backlogChan:  make(chan *message.Message, config.ChanSize),
+		outputChan:   outputChan,
+		rules:        rules,
+		done:         make(chan struct{}),
+	}
+}
+
+// Start starts the priorityQueue.
+func (q *priorityQueue) Start() {
+	go q.route()
+	go q.forward()
+}
+
+// Stop stops the priorityQueue,
+// this call blocks until all the messages are forwarded
+func (q *priorityQueue) Stop() {
+	close(q.inputChan)
+	<-q.done
+}
+
+// route splits the messages between the priority and the backlog queues.
+func (q *priorityQueue) route() {
+	for msg := range q.inputChan {
+		q.inputBuffer.Release()
+		if q.rules.IsPriority(msg.GetStatus()) {
+			metrics.LogsPrioritized.Add(1)
+			q.priorityChan <- msg
+		} else {
+			q.backlogChan <- msg
+		}
+	}
+	close(q.priorityChan)
+	close(q.backlogChan)
+}
+
+// forward sends the messages of the priority queue first to outputChan.
+func (q *priorityQueue) forward() {
+	defer func() {
+		q.done <- struct{}{}
+	}()
+	priorityChan, backlogChan := q.priorityChan, q.backlogChan
+	consecutive := 0
+	for priorityChan != nil || backlogChan != nil {
+		preferred, other := priorityChan, backlogChan
+		if consecutive >= q.rules.MaxConsecutive {
+			// let a message of the backlog through
+			preferred, other = backlogChan, priorityChan
+		}
+
+		var msg *message.Message
+		var isOpen bool
+		var from chan *message.Message
+		select {
+		case msg, isOpen = <-preferred:
+			from = preferred
+		default:
+			// nothing waiting in the preferred queue, take the first message
+			// of either queue
+			select {
+			case msg, isOpen = <-preferred:
+				from = preferred
+			case msg, isOpen = <-other:
+				from = other
+			}
+		}
+
+		if !isOpen {
+			// a closed queue is never selected again
+			if from == priorityChan {
+				priorityChan = nil
+			} else {
+				backlogChan = nil
+			}
+			continue
+		}
+		if from == priorityChan {
+			consecutive++
+		} else {
+			consecutive = 0
+		}
+		q.outputChan <- msg
+	}
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newTestPriorityQueue(t *testing.T, maxConsecutive int) *priorityQueue {
+	rules, err := config.NewPriorityRules([]string{"error", "Critical"}, maxConsecutive)
+	require.NoError(t, err)
+	return newPriorityQueue(make(chan *message.Message, config.ChanSize), make(chan *message.Message), rules)
+}
+
+func TestNewPriorityRules(t *testing.T) {
+	rules, err := config.NewPriorityRules([]string{"error", "Critical"}, 1)
+	require.NoError(t, err)
+	assert.True(t, rules.IsPriority(message.StatusError))
+	assert.True(t, rules.IsPriority(message.StatusCritical))
+	assert.False(t, rules.IsPriority(message.StatusInfo))
+
+	_, err = config.NewPriorityRules(nil, 1)
+	assert.Error(t, err)
+	_, err = config.NewPriorityRules([]string{"error"}, 0)
+	assert.Error(t, err)
+}
+
+func TestPriorityQueueFairness(t *testing.T) {
+	q := newTestPriorityQueue(t, 2)
+	for _, content := range []string{"e1", "e2", "e3"} {
+		q.priorityChan <- message.NewMessage([]byte(content), nil, message.StatusError)
+	}
+	for _, content := range []string{"i1", "i2", "i3"} {
+		q.backlogChan <- message.NewMessage([]byte(content), nil, message.StatusInfo)
+	}
+	close(q.priorityChan)
+	close(q.backlogChan)
+
+	go q.forward()
+	var sent []string
+	for i := 0; i < 6; i++ {
+		sent = append(sent, string((<-q.outputChan).Content))
+	}
+	<-q.done
+
+	// a message of the backlog is sent every two priority messages
+	assert.Equal(t, []string{"e1", "e2", "i1", "e3", "i2", "i3"}, sent)
+}
+
+func TestPriorityQueueStopFlushesMessages(t *testing.T) {
+	q := newTestPriorityQueue(t, 10)
+	q.inputChan <- message.NewMessage([]byte("info"), nil, message.StatusInfo)
+	q.inputChan <- message.NewMessage([]byte("critical"), nil, message.StatusCritical)
+	q.inputChan <- message.NewMessage([]byte("default"), nil, "")
+
+	sent := make(chan []string)
+	go func() {
+		var contents []string
+		for msg := range q.outputChan {
+			contents = append(contents, string(msg.Content))
+		}
+		sent <- contents
+	}()
+
+	q.Start()
+	q.Stop()
+	close(q.outputChan)
+	assert.ElementsMatch(t, []string{"info", "critical", "default"}, <-sent)
+}
@@ -28,6 +28,7 @@ type provider struct {
 	auditor           *auditor.Auditor
 	outputChan        chan *message.Message
 	processingRules   []*config.ProcessingRule
+	priorityRules     *config.PriorityRules
 	endpoints         *config.Endpoints
 
 	pipelines            []*Pipeline
@@ -36,11 +37,12 @@ type provider struct {
 }
 
 // NewProvider returns a new Provider
-func NewProvider(numberOfPipelines int, auditor *auditor.Auditor, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) Provider {
+func NewProvider(numberOfPipelines int, auditor *auditor.Auditor, processingRules []*config.ProcessingRule, priorityRules *config.PriorityRules, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) Provider {
 	return &provider{
 		numberOfPipelines:   numberOfPipelines,
 		auditor:             auditor,
 		processingRules:     processingRules,
+		priorityRules:       priorityRules,
 		endpoints:           endpoints,
 		pipelines:           []*Pipeline{},
 		destinationsContext: destinationsContext,
@@ -53,7 +55,7 @@ func (p *provider) Start() {
 	p.outputChan = p.auditor.Channel()
 
 	for i := 0; i < p.numberOfPipelines; i++ {
-		pipeline := NewPipeline(p.outputChan, p.processingRules, p.endpoints, p.destinationsContext)
+		pipeline := NewPipeline(i, p.outputChan, p.processingRules, p.priorityRules, p.endpoints, p.destinationsContext)
 		pipeline.Start()
 		p.pipelines = append(p.pipelines, pipeline)
 	}
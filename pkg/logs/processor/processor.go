@@ -6,6 +6,7 @@
 package processor
 
 import (
+	"github.com/DataDog/datadog-agent/pkg/util/buffer"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
@@ -18,16 +19,19 @@ import (
 type Processor struct {
 	inputChan       chan *message.Message
 	outputChan      chan *message.Message
+	outputBuffer    *buffer.Buffer
 	processingRules []*config.ProcessingRule
 	encoder         Encoder
 	done            chan struct{}
 }
 
-// New returns an initialized Processor.
-func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder) *Processor {
+// New returns an initialized Processor, it acquires room in outputBuffer
+// before sending to outputChan.
+func New(inputChan, outputChan chan *message.Message, outputBuffer *buffer.Buffer, processingRules []*config.ProcessingRule, encoder Encoder) *Processor {
 	return &Processor{
 		inputChan:       inputChan,
 		outputChan:      outputChan,
+		outputBuffer:    outputBuffer,
 		processingRules: processingRules,
 		encoder:         encoder,
 		done:            make(chan struct{}),
@@ -63,6 +67,7 @@ func (p *Processor) run() {
 				continue
 			}
 			msg.Content = content
+			p.outputBuffer.Acquire()
 			p.outputChan <- msg
 		}
 	}
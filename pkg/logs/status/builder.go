@@ -119,6 +119,7 @@ func (b *Builder) toDictionary(c *config.LogsConfig) map[string]interface{} {
 		dictionary["Port"] = c.Port
 	case config.FileType:
 		dictionary["Path"] = c.Path
+		dictionary["TailingMode"] = c.TailingMode
 	case config.DockerType:
 		dictionary["Image"] = c.Image
 		dictionary["Label"] = c.Label
@@ -140,8 +141,9 @@ func (b *Builder) toDictionary(c *config.LogsConfig) map[string]interface{} {
 
 // getMetricsStatus exposes some aggregated metrics of the log agent on the agent status
 func (b *Builder) getMetricsStatus() map[string]int64 {
-	var metrics = make(map[string]int64, 2)
+	var metrics = make(map[string]int64, 3)
 	metrics["LogsProcessed"] = b.logsExpVars.Get("LogsProcessed").(*expvar.Int).Value()
 	metrics["LogsSent"] = b.logsExpVars.Get("LogsSent").(*expvar.Int).Value()
+	metrics["TailedFiles"] = b.logsExpVars.Get("TailedFiles").(*expvar.Int).Value()
 	return metrics
 }
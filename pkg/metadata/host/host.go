@@ -13,16 +13,12 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/metadata/common"
 	"github.com/DataDog/datadog-agent/pkg/util"
-	"github.com/DataDog/datadog-agent/pkg/util/alibaba"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/metadata/host/container"
-	"github.com/DataDog/datadog-agent/pkg/util/azure"
-	"github.com/DataDog/datadog-agent/pkg/util/cloudfoundry"
 	"github.com/DataDog/datadog-agent/pkg/util/ec2"
-	"github.com/DataDog/datadog-agent/pkg/util/gce"
-	k8s "github.com/DataDog/datadog-agent/pkg/util/kubernetes/hostinfo"
+	"github.com/DataDog/datadog-agent/pkg/util/hostname"
 )
 
 const packageCachePrefix = "host"
@@ -41,6 +37,7 @@ func GetPayload(hostname string) *Payload {
 		HostTags:      getHostTags(),
 		ContainerMeta: getContainerMeta(1 * time.Second),
 		NetworkMeta:   getNetworkMeta(),
+		Lifecycle:     getLifecycle(),
 	}
 
 	// Cache the metadata for use in other payloads
@@ -81,62 +78,22 @@ func GetPythonVersion() string {
 	return "n/a"
 }
 
-// getHostAliases returns the hostname aliases from different provider
-// This should include GCE, Azure, Cloud foundry, kubernetes
-func getHostAliases() []string {
-	aliases := []string{}
-
-	alibabaAlias, err := alibaba.GetHostAlias()
-	if err != nil {
-		log.Debugf("no Alibaba Host Alias: %s", err)
-	} else if alibabaAlias != "" {
-		aliases = append(aliases, alibabaAlias)
-	}
-
-	azureAlias, err := azure.GetHostAlias()
-	if err != nil {
-		log.Debugf("no Azure Host Alias: %s", err)
-	} else if azureAlias != "" {
-		aliases = append(aliases, azureAlias)
-	}
-
-	gceAlias, err := gce.GetHostAlias()
-	if err != nil {
-		log.Debugf("no GCE Host Alias: %s", err)
-	} else {
-		aliases = append(aliases, gceAlias)
-	}
-
-	cfAliases, err := cloudfoundry.GetHostAliases()
-	if err != nil {
-		log.Debugf("no Cloud Foundry Host Alias: %s", err)
-	} else if cfAliases != nil {
-		aliases = append(aliases, cfAliases...)
-	}
-
-	k8sAlias, err := k8s.GetHostAlias()
-	if err != nil {
-		log.Debugf("no Kubernetes Host Alias (through kubelet API): %s", err)
-	} else if k8sAlias != "" {
-		aliases = append(aliases, k8sAlias)
-	}
-	return aliases
-}
-
 // getMeta grabs the information and refreshes the cache
 func getMeta() *Meta {
-	hostname, _ := os.Hostname()
+	osHostname, _ := os.Hostname()
 	tzname, _ := time.Now().Zone()
 	ec2Hostname, _ := ec2.GetHostname()
 	instanceID, _ := ec2.GetInstanceID()
 
 	m := &Meta{
-		SocketHostname: hostname,
+		SocketHostname: osHostname,
 		Timezones:      []string{tzname},
-		SocketFqdn:     util.Fqdn(hostname),
+		SocketFqdn:     util.Fqdn(osHostname),
 		EC2Hostname:    ec2Hostname,
-		HostAliases:    getHostAliases(),
+		HostAliases:    hostname.GetHostAliases(),
 		InstanceID:     instanceID,
+
+		ExternalHostAliases: hostname.GetExternalHostAliases(),
 	}
 
 	// Cache the metadata for use in other payload
@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package host
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/util/alibaba"
+	"github.com/DataDog/datadog-agent/pkg/util/azure"
+	"github.com/DataDog/datadog-agent/pkg/util/cloudfoundry"
+	"github.com/DataDog/datadog-agent/pkg/util/ec2"
+	"github.com/DataDog/datadog-agent/pkg/util/gce"
+	"github.com/DataDog/datadog-agent/pkg/util/hostname"
+	k8s "github.com/DataDog/datadog-agent/pkg/util/kubernetes/hostinfo"
+	"github.com/DataDog/datadog-agent/pkg/util/oracle"
+)
+
+// Register the host alias providers sent in the host metadata.
+// Other components can register their own with hostname.RegisterHostAliasProvider.
+func init() {
+	hostname.RegisterHostAliasProvider("alibaba", hostname.SingleAliasProvider(alibaba.GetHostAlias))
+	hostname.RegisterHostAliasProvider("azure", hostname.SingleAliasProvider(azure.GetHostAlias))
+	hostname.RegisterHostAliasProvider("ec2", hostname.SingleAliasProvider(ec2.GetHostAlias))
+	hostname.RegisterHostAliasProvider("gce", hostname.SingleAliasProvider(gce.GetHostAlias))
+	hostname.RegisterHostAliasProvider("oracle", hostname.SingleAliasProvider(oracle.GetHostAlias))
+	hostname.RegisterHostAliasProvider("cloudfoundry", cloudfoundry.GetHostAliases)
+	hostname.RegisterHostAliasProvider("kubernetes", hostname.SingleAliasProvider(k8s.GetHostAlias))
+}
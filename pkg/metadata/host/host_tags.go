@@ -5,11 +5,13 @@ import (
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/alibaba"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 	"github.com/DataDog/datadog-agent/pkg/util/ec2"
 	"github.com/DataDog/datadog-agent/pkg/util/gce"
 	k8s "github.com/DataDog/datadog-agent/pkg/util/kubernetes/hostinfo"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/oracle"
 )
 
 // this is a "low-tech" version of tagger/utils/taglist.go
@@ -61,6 +63,24 @@ func getHostTags() *tags {
 		}
 	}
 
+	if config.Datadog.GetBool("collect_oracle_tags") {
+		oracleTags, err := oracle.GetTags()
+		if err != nil {
+			log.Debugf("No Oracle Cloud host tags %v", err)
+		} else {
+			hostTags = appendToHostTags(hostTags, oracleTags)
+		}
+	}
+
+	if config.Datadog.GetBool("collect_alibaba_tags") {
+		alibabaTags, err := alibaba.GetTags()
+		if err != nil {
+			log.Debugf("No Alibaba Cloud host tags %v", err)
+		} else {
+			hostTags = appendToHostTags(hostTags, alibabaTags)
+		}
+	}
+
 	k8sTags, err := k8s.GetTags()
 	if err != nil {
 		log.Debugf("No Kubernetes host tags %v", err)
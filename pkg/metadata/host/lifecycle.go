@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package host
+
+import (
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/ec2"
+	"github.com/DataDog/datadog-agent/pkg/util/gce"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// LifecycleEphemeral is the host lifecycle of short-lived hosts (spot or
+	// preemptible instances, autoscaled nodes...)
+	LifecycleEphemeral = "ephemeral"
+)
+
+// shuttingDown is set to 1 once the agent started a graceful shutdown
+var shuttingDown int32
+
+// Lifecycle holds the hints sent to the backend to expire ephemeral hosts faster
+type Lifecycle struct {
+	Mode              string             `json:"mode"`
+	ShuttingDown      bool               `json:"shutting_down"`
+	TerminationNotice *TerminationNotice `json:"termination_notice,omitempty"`
+}
+
+// TerminationNotice is the termination notice given by the cloud provider
+type TerminationNotice struct {
+	Provider string `json:"provider"`
+	Action   string `json:"action"`
+	// Time is the scheduled termination time as a unix timestamp, 0 if unknown
+	Time int64 `json:"time,omitempty"`
+}
+
+// IsEphemeral returns whether the host is configured as ephemeral
+func IsEphemeral() bool {
+	return config.Datadog.GetString("host_lifecycle") == LifecycleEphemeral
+}
+
+// SetShuttingDown flags the agent as shutting down, the following host
+// payloads will carry this information
+func SetShuttingDown() {
+	atomic.StoreInt32(&shuttingDown, 1)
+}
+
+// GetTerminationNotice queries the metadata of the cloud provider the host
+// runs on for a spot or preemptible instance termination notice. Returns nil
+// if there is none.
+func GetTerminationNotice() *TerminationNotice {
+	if ec2.IsRunningOn() {
+		if action, err := ec2.GetSpotInstanceAction(); err == nil {
+			return &TerminationNotice{
+				Provider: "ec2",
+				Action:   action.Action,
+				Time:     action.Time.Unix(),
+			}
+		}
+	}
+
+	if gce.IsRunningOn() {
+		preempted, err := gce.IsPreempted()
+		if err != nil {
+			log.Debugf("no GCE preemption status: %s", err)
+		} else if preempted {
+			return &TerminationNotice{
+				Provider: "gce",
+				Action:   "preempt",
+			}
+		}
+	}
+
+	return nil
+}
+
+// getLifecycle returns the lifecycle hints of the host, nil if the host is
+// not ephemeral
+func getLifecycle() *Lifecycle {
+	if !IsEphemeral() {
+		return nil
+	}
+
+	return &Lifecycle{
+		Mode:              LifecycleEphemeral,
+		ShuttingDown:      atomic.LoadInt32(&shuttingDown) == 1,
+		TerminationNotice: GetTerminationNotice(),
+	}
+}
@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package host
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestGetLifecycle(t *testing.T) {
+	mockConfig := config.Mock()
+	defer atomic.StoreInt32(&shuttingDown, 0)
+
+	assert.Nil(t, getLifecycle())
+
+	mockConfig.Set("host_lifecycle", LifecycleEphemeral)
+	lifecycle := getLifecycle()
+	require.NotNil(t, lifecycle)
+	assert.Equal(t, LifecycleEphemeral, lifecycle.Mode)
+	assert.False(t, lifecycle.ShuttingDown)
+
+	SetShuttingDown()
+	assert.True(t, getLifecycle().ShuttingDown)
+}
@@ -26,6 +26,9 @@ type Meta struct {
 	Hostname       string   `json:"hostname"`
 	HostAliases    []string `json:"host_aliases"`
 	InstanceID     string   `json:"instance-id"`
+
+	// ExternalHostAliases maps hosts monitored by checks to their aliases
+	ExternalHostAliases map[string][]string `json:"external_host_aliases,omitempty"`
 }
 
 // NetworkMeta is metadata about the host's network
@@ -47,4 +50,5 @@ type Payload struct {
 	HostTags      *tags             `json:"host-tags"`
 	ContainerMeta map[string]string `json:"container-meta,omitempty"`
 	NetworkMeta   *NetworkMeta      `json:"network"`
+	Lifecycle     *Lifecycle        `json:"lifecycle,omitempty"`
 }
@@ -0,0 +1,167 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package identity
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	agentUUIDFileName   = "agent_uuid"
+	installInfoFileName = "install_info"
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+	agentUUID      string
+	agentUUIDMutex sync.Mutex
+)
+
+// InstallMethod describes how the agent was installed
+type InstallMethod struct {
+	// Tool is the installation tool (helm, operator, msi, install_script, chef...)
+	Tool string `json:"tool" yaml:"tool"`
+	// ToolVersion is the version of the tool (e.g. the helm chart version)
+	ToolVersion string `json:"tool_version" yaml:"tool_version"`
+	// InstallerVersion is the version of the installer integration (e.g. the
+	// Datadog chef cookbook version)
+	InstallerVersion string `json:"installer_version" yaml:"installer_version"`
+}
+
+type installInfoFile struct {
+	Method InstallMethod `yaml:"install_method"`
+}
+
+// GetAgentUUIDFilepath returns the path of the file persisting the agent UUID
+func GetAgentUUIDFilepath() string {
+	if path := config.Datadog.GetString("agent_uuid_file_path"); path != "" {
+		return path
+	}
+	return filepath.Join(identityDir(), agentUUIDFileName)
+}
+
+// identityDir returns the directory of the configuration file, or the run
+// directory of the agent when it runs without one, not to write to the
+// working directory
+func identityDir() string {
+	if config.Datadog.ConfigFileUsed() == "" {
+		return config.DefaultRunPath()
+	}
+	return config.FileUsedDir()
+}
+
+// GetAgentUUID returns the UUID of this agent install. It is generated on the
+// first run and persisted, so it survives hostname changes and upgrades.
+func GetAgentUUID() (string, error) {
+	agentUUIDMutex.Lock()
+	defer agentUUIDMutex.Unlock()
+
+	if agentUUID != "" {
+		return agentUUID, nil
+	}
+
+	path := GetAgentUUIDFilepath()
+	content, err := ioutil.ReadFile(path)
+	if err == nil {
+		id := strings.TrimSpace(string(content))
+		if uuidPattern.MatchString(id) {
+			agentUUID = id
+			return agentUUID, nil
+		}
+		log.Warnf("Invalid agent UUID in %s, generating a new one", path)
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("unable to read the agent UUID: %s", err)
+	}
+
+	id, err := newUUID()
+	if err != nil {
+		return "", fmt.Errorf("unable to generate the agent UUID: %s", err)
+	}
+	if err := ioutil.WriteFile(path, []byte(id), 0644); err != nil {
+		return "", fmt.Errorf("unable to persist the agent UUID: %s", err)
+	}
+	log.Infof("Saved a new agent UUID to %s", path)
+
+	agentUUID = id
+	return agentUUID, nil
+}
+
+// GetInstallMethod returns how the agent was installed. Installers either
+// write an install_info file next to datadog.yaml or, for container installs
+// (helm, operator), set the install_method.* options through the environment.
+func GetInstallMethod() *InstallMethod {
+	method := InstallMethod{
+		Tool:             config.Datadog.GetString("install_method.tool"),
+		ToolVersion:      config.Datadog.GetString("install_method.tool_version"),
+		InstallerVersion: config.Datadog.GetString("install_method.installer_version"),
+	}
+	if method.Tool != "" {
+		return &method
+	}
+
+	path := filepath.Join(identityDir(), installInfoFileName)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Debugf("No install info: %s", err)
+		return &InstallMethod{Tool: "undefined"}
+	}
+
+	info := installInfoFile{}
+	if err := yaml.Unmarshal(content, &info); err != nil || info.Method.Tool == "" {
+		log.Warnf("Invalid install info file %s: %v", path, err)
+		return &InstallMethod{Tool: "undefined"}
+	}
+	return &info.Method
+}
+
+// SetupInventories adds the agent identity to the inventories agent metadata
+func SetupInventories() {
+	if id, err := GetAgentUUID(); err != nil {
+		log.Warnf("Could not get the agent UUID: %s", err)
+	} else {
+		inventories.SetAgentMetadata("agent_uuid", id)
+	}
+
+	method := GetInstallMethod()
+	inventories.SetAgentMetadata("install_method_tool", method.Tool)
+	inventories.SetAgentMetadata("install_method_tool_version", method.ToolVersion)
+	inventories.SetAgentMetadata("install_method_installer_version", method.InstallerVersion)
+}
+
+// GetStatus returns the agent identity for the status page
+func GetStatus() map[string]interface{} {
+	status := make(map[string]interface{})
+	if id, err := GetAgentUUID(); err == nil {
+		status["uuid"] = id
+	}
+	status["install_method"] = GetInstallMethod()
+	return status
+}
+
+// newUUID generates a random (version 4) UUID
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
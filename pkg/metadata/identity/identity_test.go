@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package identity
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func resetUUIDCache() {
+	agentUUIDMutex.Lock()
+	defer agentUUIDMutex.Unlock()
+	agentUUID = ""
+}
+
+func TestGetAgentUUIDPersisted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "identity")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mockConfig := config.Mock()
+	path := filepath.Join(dir, "agent_uuid")
+	mockConfig.Set("agent_uuid_file_path", path)
+	defer mockConfig.Set("agent_uuid_file_path", "")
+	resetUUIDCache()
+	defer resetUUIDCache()
+
+	id, err := GetAgentUUID()
+	require.NoError(t, err)
+	assert.Regexp(t, uuidPattern, id)
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, id, string(content))
+
+	// a restart reads the persisted UUID
+	resetUUIDCache()
+	again, err := GetAgentUUID()
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+
+	// an invalid file is replaced
+	require.NoError(t, ioutil.WriteFile(path, []byte("garbage"), 0644))
+	resetUUIDCache()
+	replaced, err := GetAgentUUID()
+	require.NoError(t, err)
+	assert.Regexp(t, uuidPattern, replaced)
+	assert.NotEqual(t, id, replaced)
+}
+
+func TestGetAgentUUIDFilepathWithoutConfigFile(t *testing.T) {
+	config.Mock()
+	assert.Equal(t, filepath.Join(config.DefaultRunPath(), "agent_uuid"), GetAgentUUIDFilepath())
+}
+
+func TestGetInstallMethod(t *testing.T) {
+	mockConfig := config.Mock()
+
+	// no install info
+	assert.Equal(t, "undefined", GetInstallMethod().Tool)
+
+	mockConfig.Set("install_method.tool", "helm")
+	mockConfig.Set("install_method.tool_version", "1.38.2")
+	defer mockConfig.Set("install_method.tool", "")
+	defer mockConfig.Set("install_method.tool_version", "")
+
+	method := GetInstallMethod()
+	assert.Equal(t, "helm", method.Tool)
+	assert.Equal(t, "1.38.2", method.ToolVersion)
+	assert.Equal(t, "", method.InstallerVersion)
+}
+
+func TestNewUUID(t *testing.T) {
+	a, err := newUUID()
+	require.NoError(t, err)
+	b, err := newUUID()
+	require.NoError(t, err)
+	assert.Regexp(t, uuidPattern, a)
+	assert.NotEqual(t, a, b)
+}
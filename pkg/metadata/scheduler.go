@@ -126,6 +126,16 @@ func (c *Scheduler) SendNow(name string) {
 	sc.sendTimer.Reset(0) // Fire immediately
 }
 
+// SendFinal runs a collector synchronously, to be used on shutdown before the
+// forwarder is stopped
+func (c *Scheduler) SendFinal(name string) error {
+	p, found := catalog[name]
+	if !found {
+		return fmt.Errorf("Unable to find metadata collector: %s", name)
+	}
+	return p.Send(c.srl)
+}
+
 // Always send host metadata at the first run
 func (c *Scheduler) firstRun() error {
 	p, found := catalog["host"]
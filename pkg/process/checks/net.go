@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"runtime"
+	"sync"
 	"time"
 
 	model "github.com/DataDog/agent-payload/process"
@@ -11,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/ebpf/encoding"
 	"github.com/DataDog/datadog-agent/pkg/process/config"
 	"github.com/DataDog/datadog-agent/pkg/process/net"
+	procutil "github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -30,6 +33,13 @@ type ConnectionsCheck struct {
 	localTracer    *ebpf.Tracer
 	tracerClientID string
 	networkID      string
+
+	// IPFIX exporter of the connections to a local collector, nil if disabled
+	exporter *net.ConnectionsExporter
+
+	// Connections excluded by the connection filters, as of the last run
+	filterCountsMu sync.Mutex
+	filterCounts   procutil.ConnectionFilterCounts
 }
 
 // Init initializes a ConnectionsCheck instance.
@@ -40,11 +50,13 @@ func (c *ConnectionsCheck) Init(cfg *config.AgentConfig, sysInfo *model.SystemIn
 		log.Info("starting system probe locally")
 		c.useLocalTracer = true
 
-		// Checking whether the current kernel version is supported by the tracer
-		if supported, msg := ebpf.IsTracerSupportedByOS(cfg.ExcludedBPFLinuxVersions); !supported {
-			// err is always returned when false, so the above catches the !ok case as well
-			log.Warnf("system probe unsupported by OS: %s", msg)
-			return
+		// Checking whether the current kernel version is supported by the tracer, macOS relies on libproc instead
+		if runtime.GOOS != "darwin" {
+			if supported, msg := ebpf.IsTracerSupportedByOS(cfg.ExcludedBPFLinuxVersions); !supported {
+				// err is always returned when false, so the above catches the !ok case as well
+				log.Warnf("system probe unsupported by OS: %s", msg)
+				return
+			}
 		}
 
 		t, err := ebpf.NewTracer(config.SysProbeConfigFromConfig(cfg))
@@ -66,6 +78,15 @@ func (c *ConnectionsCheck) Init(cfg *config.AgentConfig, sysInfo *model.SystemIn
 	}
 	c.networkID = networkID
 
+	if cfg.EnableConnectionsExport && c.exporter == nil {
+		exporter, err := net.NewConnectionsExporter(cfg)
+		if err != nil {
+			log.Errorf("failed to set up the connections export: %s", err)
+		} else {
+			c.exporter = exporter
+		}
+	}
+
 	// Run the check one time on init to register the client on the system probe
 	c.Run(cfg, 0)
 }
@@ -80,8 +101,8 @@ func (c *ConnectionsCheck) Endpoint() string { return "/api/v1/collector" }
 func (c *ConnectionsCheck) RealTime() bool { return false }
 
 // Run runs the ConnectionsCheck to collect the live TCP connections on the
-// system. Currently only linux systems are supported as eBPF is used to gather
-// this information. For each connection we'll return a `model.Connection`
+// system. Linux systems use eBPF to gather this information, macOS systems list
+// the sockets of the processes with libproc. For each connection we'll return a `model.Connection`
 // that will be bundled up into a `CollectorConnections`.
 // See agent.proto for the schema of the message and models.
 func (c *ConnectionsCheck) Run(cfg *config.AgentConfig, groupID int32) ([]model.MessageBody, error) {
@@ -102,7 +123,15 @@ func (c *ConnectionsCheck) Run(cfg *config.AgentConfig, groupID int32) ([]model.
 	}
 
 	log.Debugf("collected connections in %s", time.Since(start))
-	return batchConnections(cfg, groupID, c.enrichConnections(conns), c.networkID), nil
+	c.updateFilterCounts()
+	conns = c.enrichConnections(conns)
+
+	if c.exporter != nil {
+		if err := c.exporter.Export(conns); err != nil {
+			log.Warnf("%s", err)
+		}
+	}
+	return batchConnections(cfg, groupID, conns, c.networkID), nil
 }
 
 func (c *ConnectionsCheck) getConnections() ([]*model.Connection, error) {
@@ -111,11 +140,14 @@ func (c *ConnectionsCheck) getConnections() ([]*model.Connection, error) {
 			return nil, fmt.Errorf("using local system probe, but no tracer was initialized")
 		}
 		cs, err := c.localTracer.GetActiveConnections(c.tracerClientID)
+		if err != nil {
+			return nil, err
+		}
 		conns := make([]*model.Connection, len(cs.Conns))
 		for i, ebpfConn := range cs.Conns {
 			conns[i] = encoding.FormatConnection(ebpfConn)
 		}
-		return conns, err
+		return conns, nil
 	}
 
 	tu, err := net.GetRemoteSystemProbeUtil()
@@ -129,6 +161,36 @@ func (c *ConnectionsCheck) getConnections() ([]*model.Connection, error) {
 	return tu.GetConnections(c.tracerClientID)
 }
 
+// updateFilterCounts fetches the numbers of connections excluded by the
+// connection filters, for the status output
+func (c *ConnectionsCheck) updateFilterCounts() {
+	var counts procutil.ConnectionFilterCounts
+	if c.useLocalTracer {
+		counts = c.localTracer.ConnectionFilterCounts()
+	} else {
+		tu, err := net.GetRemoteSystemProbeUtil()
+		if err != nil {
+			return
+		}
+		if counts, err = tu.GetConnectionFilterCounts(); err != nil {
+			log.Debugf("could not get the connection filter counts: %s", err)
+			return
+		}
+	}
+
+	c.filterCountsMu.Lock()
+	defer c.filterCountsMu.Unlock()
+	c.filterCounts = counts
+}
+
+// FilterCounts returns the numbers of connections excluded by the connection
+// filters, in total and per rule, as of the last run of the check
+func (c *ConnectionsCheck) FilterCounts() procutil.ConnectionFilterCounts {
+	c.filterCountsMu.Lock()
+	defer c.filterCountsMu.Unlock()
+	return c.filterCounts
+}
+
 func (c *ConnectionsCheck) enrichConnections(conns []*model.Connection) []*model.Connection {
 	// Process create-times required to construct unique process hash keys on the backend
 	createTimeForPID := Process.createTimesforPIDs(connectionPIDs(conns))
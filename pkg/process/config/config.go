@@ -28,6 +28,8 @@ var (
 	defaultSystemProbeSocketPath = "/opt/datadog-agent/run/sysprobe.sock"
 	// defaultSystemProbeFilePath is the default logging file for the system probe
 	defaultSystemProbeFilePath = "/var/log/datadog/system-probe.log"
+	// defaultConnectionsExportAddress is the default address the connections are exported to, the standard IPFIX port
+	defaultConnectionsExportAddress = "udp://127.0.0.1:4739"
 
 	defaultConntrackShortTermBufferSize = 10000
 
@@ -87,6 +89,8 @@ type AgentConfig struct {
 	ExcludedBPFLinuxVersions       []string
 	ExcludedSourceConnections      map[string][]string
 	ExcludedDestinationConnections map[string][]string
+	IncludedConnections            map[string][]string
+	RejectedConnectionFilters      []util.RejectedConnectionFilter
 	EnableConntrack                bool
 	ConntrackShortTermBufferSize   int
 	SystemProbeDebugPort           int
@@ -94,6 +98,10 @@ type AgentConfig struct {
 	MaxClosedConnectionsBuffered   int
 	MaxConnectionsStateBuffered    int
 
+	// Local export of the connections to third-party network tools
+	EnableConnectionsExport  bool
+	ConnectionsExportAddress string
+
 	// Check config
 	EnabledChecks  []string
 	CheckIntervals map[string]time.Duration
@@ -192,6 +200,10 @@ func NewDefaultAgentConfig() *AgentConfig {
 		ClosedChannelSize:            500,
 		ConntrackShortTermBufferSize: defaultConntrackShortTermBufferSize,
 
+		// Connections export
+		EnableConnectionsExport:  false,
+		ConnectionsExportAddress: defaultConnectionsExportAddress,
+
 		// Check config
 		EnabledChecks: enabledChecks,
 		CheckIntervals: map[string]time.Duration{
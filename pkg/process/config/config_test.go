@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/gopsutil/process"
 	"github.com/stretchr/testify/assert"
 )
@@ -257,6 +258,98 @@ func TestAgentConfigYamlAndSystemProbeConfig(t *testing.T) {
 	assert.Equal(map[string][]string(map[string][]string{"172.0.0.1/20": {"*"}, "*": {"*"}, "2001:db8::2:1": {"5005"}}), agentConfig.ExcludedDestinationConnections)
 }
 
+func TestLoadConnectionFilters(t *testing.T) {
+	config.Datadog = config.NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	defer restoreGlobalConfig()
+
+	assert := assert.New(t)
+
+	rules, err := LoadConnectionFilters("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-Net-2.yaml")
+	assert.NoError(err)
+	assert.Equal(map[string][]string{"172.0.0.1/20": {"*"}, "*": {"443"}, "127.0.0.1": {"5005"}}, rules.SourceExcludes)
+	assert.Equal(map[string][]string{"172.0.0.1/20": {"*"}, "*": {"*"}, "2001:db8::2:1": {"5005"}}, rules.DestExcludes)
+
+	// the filters removed from the file are dropped
+	rules, err = LoadConnectionFilters("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-Net.yaml")
+	assert.NoError(err)
+	assert.Empty(rules.SourceExcludes)
+	assert.Empty(rules.DestExcludes)
+
+	// the global config isn't reloaded
+	assert.False(config.Datadog.IsSet("system_probe_config.source_excludes"))
+
+	_, err = LoadConnectionFilters("./testdata/does-not-exist.yaml")
+	assert.Error(err)
+}
+
+func TestLoadConnectionFiltersGroups(t *testing.T) {
+	config.Datadog = config.NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	defer restoreGlobalConfig()
+
+	assert := assert.New(t)
+
+	rules, err := LoadConnectionFilters("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-FilterGroups.yaml")
+	assert.NoError(err)
+	assert.Equal(map[string][]string{
+		"10.0.0.0/8":    {"9100-9120", "10255"},
+		"172.16.0.0/12": {"9100-9120", "10255"},
+	}, rules.SourceExcludes)
+	assert.Equal(map[string][]string{
+		"*":             {"9100-9120", "10255"},
+		"10.0.0.0/8":    {"443", "8080"},
+		"172.16.0.0/12": {"8080"},
+	}, rules.DestExcludes)
+	assert.Empty(rules.Includes)
+
+	_, err = LoadConnectionFilters("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-FilterGroupsInvalid.yaml")
+	assert.EqualError(err, `invalid system_probe_config.dest_excludes: filter group "monitoring_ports" mixes IP/CIDRs and ports: "10.0.0.0/8"`)
+}
+
+func TestRejectedConnectionFilters(t *testing.T) {
+	config.Datadog = config.NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	defer restoreGlobalConfig()
+
+	assert := assert.New(t)
+
+	agentConfig, err := NewAgentConfig(
+		"test",
+		"./testdata/TestDDAgentConfigYamlAndSystemProbeConfig.yaml",
+		"./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-FiltersInvalid.yaml",
+	)
+	assert.NoError(err)
+	assert.Equal([]util.RejectedConnectionFilter{
+		{List: util.SourceExcludesList, Rule: "10.0.0.0/8", Port: "30-ABC", Reason: "ABC is neither a port, a port range nor *"},
+		{List: util.DestExcludesList, Rule: "10.1.0.0/16", Port: "65536", Reason: "port 65536 is out of the 0-65535 range"},
+	}, agentConfig.RejectedConnectionFilters)
+
+	// in strict mode an invalid filter fails the configuration
+	_, err = LoadConnectionFilters("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-FiltersInvalidStrict.yaml")
+	assert.EqualError(err, "invalid connection filter source_excludes 10.0.0.0/8, port 30-ABC: ABC is neither a port, a port range nor *")
+}
+
+func TestLoadConnectionFiltersIncludes(t *testing.T) {
+	config.Datadog = config.NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	defer restoreGlobalConfig()
+
+	assert := assert.New(t)
+
+	agentConfig, err := NewAgentConfig(
+		"test",
+		"./testdata/TestDDAgentConfigYamlAndSystemProbeConfig.yaml",
+		"./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-Includes.yaml",
+	)
+	assert.NoError(err)
+	assert.Equal(map[string][]string{
+		"10.0.0.0/8": {"*"},
+		"fd00::1":    {"*"},
+		"dns:*.corp": {"443"},
+	}, agentConfig.IncludedConnections)
+	assert.Equal(map[string][]string{"10.0.0.5": {"8200"}}, agentConfig.ExcludedDestinationConnections)
+
+	tracerConfig := SysProbeConfigFromConfig(agentConfig)
+	assert.Equal(agentConfig.IncludedConnections, tracerConfig.IncludedConnections)
+}
+
 func TestProxyEnv(t *testing.T) {
 	assert := assert.New(t)
 	for i, tc := range []struct {
@@ -3,6 +3,7 @@ package config
 import (
 	"io/ioutil"
 	"path/filepath"
+	"runtime"
 
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -45,6 +46,10 @@ func SysProbeConfigFromConfig(cfg *AgentConfig) *ebpf.Config {
 		tracerConfig.ExcludedDestinationConnections = cfg.ExcludedDestinationConnections
 	}
 
+	if len(cfg.IncludedConnections) > 0 {
+		tracerConfig.IncludedConnections = cfg.IncludedConnections
+	}
+
 	tracerConfig.CollectLocalDNS = cfg.CollectLocalDNS
 
 	tracerConfig.MaxTrackedConnections = cfg.MaxTrackedConnections
@@ -70,6 +75,10 @@ func SysProbeConfigFromConfig(cfg *AgentConfig) *ebpf.Config {
 }
 
 func isIPv6EnabledOnHost() bool {
+	if runtime.GOOS == "darwin" {
+		// there is no procfs on macOS, where IPv6 is always available
+		return true
+	}
 	_, err := ioutil.ReadFile(filepath.Join(util.GetProcRoot(), "net/if_inet6"))
 	return err == nil
 }
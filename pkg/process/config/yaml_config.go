@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"time"
 
@@ -37,6 +38,10 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 	}
 
 	a.EnableLocalSystemProbe = config.Datadog.GetBool(key(spNS, "use_local_system_probe"))
+	if runtime.GOOS == "darwin" {
+		// there is no system-probe on macOS, the connections are gathered by the process-agent
+		a.EnableLocalSystemProbe = true
+	}
 
 	// Whether agent should disable collection for TCP, UDP, or IPv6 connection type respectively
 	a.DisableTCPTracing = config.Datadog.GetBool(key(spNS, "disable_tcp"))
@@ -119,15 +124,74 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 		a.SystemProbeDebugPort = debugPort
 	}
 
-	if sourceExclude := key(spNS, "source_excludes"); config.Datadog.IsSet(sourceExclude) {
-		a.ExcludedSourceConnections = config.Datadog.GetStringMapStringSlice(sourceExclude)
+	rules, rejected, err := connectionFiltersFromConfig(config.Datadog)
+	if err != nil {
+		return err
 	}
+	a.ExcludedSourceConnections = rules.SourceExcludes
+	a.ExcludedDestinationConnections = rules.DestExcludes
+	a.IncludedConnections = rules.Includes
+	a.RejectedConnectionFilters = rejected
+
+	return nil
+}
 
-	if destinationExclude := key(spNS, "dest_excludes"); config.Datadog.IsSet(destinationExclude) {
-		a.ExcludedDestinationConnections = config.Datadog.GetStringMapStringSlice(destinationExclude)
+// connectionFiltersFromConfig returns the source and destination exclusions and the inclusions of
+// the connections, with the references to the filter groups expanded, and the invalid filters which
+// aren't respected. With strict_connection_filters, an invalid filter is an error.
+func connectionFiltersFromConfig(cfg config.Config) (util.ConnectionFilterRules, []util.RejectedConnectionFilter, error) {
+	var groups map[string][]string
+	if filterGroups := key(spNS, "filter_groups"); cfg.IsSet(filterGroups) {
+		groups = cfg.GetStringMapStringSlice(filterGroups)
+	}
+
+	var rules util.ConnectionFilterRules
+	for _, f := range []struct {
+		name    string
+		filters *map[string][]string
+	}{
+		{"source_excludes", &rules.SourceExcludes},
+		{"dest_excludes", &rules.DestExcludes},
+		{"network_connections_included", &rules.Includes},
+	} {
+		k := key(spNS, f.name)
+		if !cfg.IsSet(k) {
+			continue
+		}
+		filters, err := util.ExpandConnectionFilterGroups(cfg.GetStringMapStringSlice(k), groups)
+		if err != nil {
+			return util.ConnectionFilterRules{}, nil, fmt.Errorf("invalid %s: %s", k, err)
+		}
+		*f.filters = filters
 	}
 
-	return nil
+	rejected := rules.Validate()
+	if len(rejected) > 0 && cfg.GetBool(key(spNS, "strict_connection_filters")) {
+		return util.ConnectionFilterRules{}, nil, fmt.Errorf("invalid connection filter %s", rejected[0])
+	}
+	for _, r := range rejected {
+		log.Warnf("Connection filter will not be respected: %s", r)
+	}
+	return rules, rejected, nil
+}
+
+// LoadConnectionFilters reads the system-probe config file again and returns
+// its connection filters, to reload them. The file is parsed in a private
+// config, the global one is left untouched as it's read concurrently.
+func LoadConnectionFilters(yamlPath string) (util.ConnectionFilterRules, error) {
+	if !util.PathExists(yamlPath) {
+		return util.ConnectionFilterRules{}, fmt.Errorf("no config exists at %s", yamlPath)
+	}
+	cfg := config.NewConfig("system-probe", "DD", strings.NewReplacer(".", "_"))
+	cfg.AddConfigPath(yamlPath)
+	if strings.HasSuffix(yamlPath, ".yaml") {
+		cfg.SetConfigFile(yamlPath)
+	}
+	if err := cfg.ReadInConfig(); err != nil {
+		return util.ConnectionFilterRules{}, err
+	}
+	rules, _, err := connectionFiltersFromConfig(cfg)
+	return rules, err
 }
 
 // Process-specific configuration
@@ -218,6 +282,14 @@ func (a *AgentConfig) loadProcessYamlConfig(path string) error {
 		a.ProcessExpVarPort = port
 	}
 
+	// Whether to export the connections as IPFIX records to a local collector, for the on-prem network tools
+	if k := key(ns, "connections_export", "enabled"); config.Datadog.IsSet(k) {
+		a.EnableConnectionsExport = config.Datadog.GetBool(k)
+	}
+	if addr := config.Datadog.GetString(key(ns, "connections_export", "address")); addr != "" {
+		a.ConnectionsExportAddress = addr
+	}
+
 	// Enable/Disable the DataScrubber to obfuscate process args
 	if scrubArgsKey := key(ns, "scrub_args"); config.Datadog.IsSet(scrubArgsKey) {
 		a.Scrubber.Enabled = config.Datadog.GetBool(scrubArgsKey)
@@ -0,0 +1,319 @@
+package net
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	model "github.com/DataDog/agent-payload/process"
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// IPFIX (RFC 7011) constants
+const (
+	ipfixVersion       = 10
+	ipfixHeaderSize    = 16
+	ipfixSetHeaderSize = 4
+	ipfixTemplateSetID = 2
+
+	// ipfixTemplateIDv4 and ipfixTemplateIDv6 are the IDs of the templates
+	// describing the IPv4 and IPv6 connection records
+	ipfixTemplateIDv4 = 256
+	ipfixTemplateIDv6 = 257
+
+	// reversePEN is the private enterprise number of the reverse information
+	// elements of the biflows (RFC 5103)
+	reversePEN = 29305
+
+	// maxIPFIXMessageSize keeps the messages below the usual MTU so they're
+	// not fragmented when sent over UDP
+	maxIPFIXMessageSize = 1400
+)
+
+// ipfixField is a field specifier of an IPFIX template
+type ipfixField struct {
+	id         uint16
+	length     uint16
+	enterprise uint32
+}
+
+// Information elements of the IANA registry used by the connection records
+var (
+	ieOctetDeltaCount          = ipfixField{id: 1, length: 8}
+	ieProtocolIdentifier       = ipfixField{id: 4, length: 1}
+	ieSourceTransportPort      = ipfixField{id: 7, length: 2}
+	ieSourceIPv4Address        = ipfixField{id: 8, length: 4}
+	ieDestinationTransportPort = ipfixField{id: 11, length: 2}
+	ieDestinationIPv4Address   = ipfixField{id: 12, length: 4}
+	ieSourceIPv6Address        = ipfixField{id: 27, length: 16}
+	ieDestinationIPv6Address   = ipfixField{id: 28, length: 16}
+	ieFlowDirection            = ipfixField{id: 61, length: 1}
+	ieOctetTotalCount          = ipfixField{id: 85, length: 8}
+	ieReverseOctetDeltaCount   = ipfixField{id: 1, length: 8, enterprise: reversePEN}
+	ieReverseOctetTotalCount   = ipfixField{id: 85, length: 8, enterprise: reversePEN}
+)
+
+// ipfixCommonFields follow the addresses in the templates, the octets sent by
+// the connection are the forward counts and the octets received the reverse ones
+var ipfixCommonFields = []ipfixField{
+	ieSourceTransportPort,
+	ieDestinationTransportPort,
+	ieProtocolIdentifier,
+	ieFlowDirection,
+	ieOctetDeltaCount,
+	ieOctetTotalCount,
+	ieReverseOctetDeltaCount,
+	ieReverseOctetTotalCount,
+}
+
+// ipfixTemplate describes the layout of the records of a template ID
+type ipfixTemplate struct {
+	id     uint16
+	fields []ipfixField
+	// recordSize is the size of a data record of this template
+	recordSize int
+}
+
+func newIPFIXTemplate(id uint16, src, dst ipfixField) ipfixTemplate {
+	t := ipfixTemplate{id: id, fields: append([]ipfixField{src, dst}, ipfixCommonFields...)}
+	for _, f := range t.fields {
+		t.recordSize += int(f.length)
+	}
+	return t
+}
+
+var (
+	ipfixTemplatev4 = newIPFIXTemplate(ipfixTemplateIDv4, ieSourceIPv4Address, ieDestinationIPv4Address)
+	ipfixTemplatev6 = newIPFIXTemplate(ipfixTemplateIDv6, ieSourceIPv6Address, ieDestinationIPv6Address)
+)
+
+// ConnectionsExporter exports the connections collected by the system probe as
+// IPFIX biflow records to a local collector, so that on-prem network tools can
+// consume the same data as the agent.
+// The templates are sent with every message as the transport is connectionless.
+type ConnectionsExporter struct {
+	mux  sync.Mutex
+	conn net.Conn
+	// sequence is the number of data records sent, as defined by IPFIX
+	sequence uint32
+	// templates is the encoded template set prepended to every message
+	templates []byte
+}
+
+// NewConnectionsExporter returns a ConnectionsExporter sending to the address
+// of the connections export, either `udp://<host>:<port>` or `unix://<path>`
+func NewConnectionsExporter(cfg *config.AgentConfig) (*ConnectionsExporter, error) {
+	network, address, err := parseExportAddress(cfg.ConnectionsExportAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to the connections export address %s: %s", cfg.ConnectionsExportAddress, err)
+	}
+
+	log.Infof("exporting the connections as IPFIX to %s", cfg.ConnectionsExportAddress)
+	return newConnectionsExporter(conn), nil
+}
+
+func newConnectionsExporter(conn net.Conn) *ConnectionsExporter {
+	return &ConnectionsExporter{
+		conn:      conn,
+		templates: encodeTemplateSet(ipfixTemplatev4, ipfixTemplatev6),
+	}
+}
+
+func parseExportAddress(addr string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(addr, "udp://"):
+		return "udp", strings.TrimPrefix(addr, "udp://"), nil
+	case strings.HasPrefix(addr, "unix://"):
+		return "unixgram", strings.TrimPrefix(addr, "unix://"), nil
+	default:
+		return "", "", fmt.Errorf("invalid connections export address %q, expected udp://<host>:<port> or unix://<path>", addr)
+	}
+}
+
+// Export sends the connections as IPFIX messages, skipping the ones that
+// can't be represented, e.g. without addresses
+func (e *ConnectionsExporter) Export(conns []*model.Connection) error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	now := uint32(time.Now().Unix())
+	for _, msg := range e.encodeMessages(conns, now) {
+		if _, err := e.conn.Write(msg); err != nil {
+			return fmt.Errorf("can't export the connections: %s", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to the collector
+func (e *ConnectionsExporter) Close() error {
+	return e.conn.Close()
+}
+
+// encodeMessages encodes the connections in as many messages as needed to
+// stay below maxIPFIXMessageSize, with a data set per template in each of them
+func (e *ConnectionsExporter) encodeMessages(conns []*model.Connection, exportTime uint32) [][]byte {
+	var msgs [][]byte
+
+	var v4, v6 bytes.Buffer
+	var records uint32
+	flush := func() {
+		if v4.Len() == 0 && v6.Len() == 0 {
+			return
+		}
+		msgs = append(msgs, e.encodeMessage(exportTime, &v4, &v6))
+		e.sequence += records
+		records = 0
+		v4.Reset()
+		v6.Reset()
+	}
+
+	for _, conn := range conns {
+		template, src, dst, ok := connectionTemplate(conn)
+		if !ok {
+			continue
+		}
+
+		size := ipfixHeaderSize + len(e.templates) + 2*ipfixSetHeaderSize + v4.Len() + v6.Len() + template.recordSize
+		if size > maxIPFIXMessageSize {
+			flush()
+		}
+
+		buf := &v4
+		if template.id == ipfixTemplateIDv6 {
+			buf = &v6
+		}
+		encodeRecord(buf, conn, src, dst)
+		records++
+	}
+	flush()
+
+	return msgs
+}
+
+func (e *ConnectionsExporter) encodeMessage(exportTime uint32, v4, v6 *bytes.Buffer) []byte {
+	var msg bytes.Buffer
+
+	length := ipfixHeaderSize + len(e.templates)
+	for _, set := range []*bytes.Buffer{v4, v6} {
+		if set.Len() > 0 {
+			length += ipfixSetHeaderSize + set.Len()
+		}
+	}
+
+	// Message header
+	binary.Write(&msg, binary.BigEndian, uint16(ipfixVersion))
+	binary.Write(&msg, binary.BigEndian, uint16(length))
+	binary.Write(&msg, binary.BigEndian, exportTime)
+	binary.Write(&msg, binary.BigEndian, e.sequence)
+	binary.Write(&msg, binary.BigEndian, uint32(0)) // observation domain ID
+
+	msg.Write(e.templates)
+
+	for i, set := range []*bytes.Buffer{v4, v6} {
+		if set.Len() == 0 {
+			continue
+		}
+		id := uint16(ipfixTemplateIDv4)
+		if i == 1 {
+			id = ipfixTemplateIDv6
+		}
+		binary.Write(&msg, binary.BigEndian, id)
+		binary.Write(&msg, binary.BigEndian, uint16(ipfixSetHeaderSize+set.Len()))
+		msg.Write(set.Bytes())
+	}
+
+	return msg.Bytes()
+}
+
+// encodeTemplateSet encodes the template set describing the templates
+func encodeTemplateSet(templates ...ipfixTemplate) []byte {
+	var records bytes.Buffer
+	for _, t := range templates {
+		binary.Write(&records, binary.BigEndian, t.id)
+		binary.Write(&records, binary.BigEndian, uint16(len(t.fields)))
+		for _, f := range t.fields {
+			if f.enterprise != 0 {
+				// the enterprise bit is followed by the enterprise number
+				binary.Write(&records, binary.BigEndian, f.id|0x8000)
+				binary.Write(&records, binary.BigEndian, f.length)
+				binary.Write(&records, binary.BigEndian, f.enterprise)
+				continue
+			}
+			binary.Write(&records, binary.BigEndian, f.id)
+			binary.Write(&records, binary.BigEndian, f.length)
+		}
+	}
+
+	var set bytes.Buffer
+	binary.Write(&set, binary.BigEndian, uint16(ipfixTemplateSetID))
+	binary.Write(&set, binary.BigEndian, uint16(ipfixSetHeaderSize+records.Len()))
+	set.Write(records.Bytes())
+	return set.Bytes()
+}
+
+// connectionTemplate returns the template of the connection and its parsed
+// addresses, or false if it can't be exported
+func connectionTemplate(conn *model.Connection) (ipfixTemplate, net.IP, net.IP, bool) {
+	if conn.Laddr == nil || conn.Raddr == nil {
+		return ipfixTemplate{}, nil, nil, false
+	}
+	src, dst := net.ParseIP(conn.Laddr.Ip), net.ParseIP(conn.Raddr.Ip)
+	if src == nil || dst == nil {
+		return ipfixTemplate{}, nil, nil, false
+	}
+
+	switch conn.Family {
+	case model.ConnectionFamily_v4:
+		src, dst = src.To4(), dst.To4()
+		if src == nil || dst == nil {
+			return ipfixTemplate{}, nil, nil, false
+		}
+		return ipfixTemplatev4, src, dst, true
+	case model.ConnectionFamily_v6:
+		return ipfixTemplatev6, src.To16(), dst.To16(), true
+	default:
+		return ipfixTemplate{}, nil, nil, false
+	}
+}
+
+// encodeRecord encodes the connection following the field order of the templates
+func encodeRecord(buf *bytes.Buffer, conn *model.Connection, src, dst net.IP) {
+	buf.Write(src)
+	buf.Write(dst)
+	binary.Write(buf, binary.BigEndian, uint16(conn.Laddr.Port))
+	binary.Write(buf, binary.BigEndian, uint16(conn.Raddr.Port))
+	buf.WriteByte(ipfixProtocol(conn.Type))
+	buf.WriteByte(ipfixFlowDirection(conn.Direction))
+	binary.Write(buf, binary.BigEndian, conn.LastBytesSent)
+	binary.Write(buf, binary.BigEndian, conn.TotalBytesSent)
+	binary.Write(buf, binary.BigEndian, conn.LastBytesReceived)
+	binary.Write(buf, binary.BigEndian, conn.TotalBytesReceived)
+}
+
+// ipfixProtocol returns the IANA protocol number of the connection type
+func ipfixProtocol(t model.ConnectionType) byte {
+	if t == model.ConnectionType_udp {
+		return 17
+	}
+	return 6
+}
+
+// ipfixFlowDirection returns the flowDirection of the connection, 0 for the
+// incoming connections and 1 for the outgoing and local ones
+func ipfixFlowDirection(d model.ConnectionDirection) byte {
+	if d == model.ConnectionDirection_incoming {
+		return 0
+	}
+	return 1
+}
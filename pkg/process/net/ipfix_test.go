@@ -0,0 +1,131 @@
+package net
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	model "github.com/DataDog/agent-payload/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+)
+
+func newIPFIXTestConn(family model.ConnectionFamily, laddr, raddr string) *model.Connection {
+	return &model.Connection{
+		Family:             family,
+		Type:               model.ConnectionType_tcp,
+		Direction:          model.ConnectionDirection_outgoing,
+		Laddr:              &model.Addr{Ip: laddr, Port: 34567},
+		Raddr:              &model.Addr{Ip: raddr, Port: 443},
+		LastBytesSent:      10,
+		TotalBytesSent:     100,
+		LastBytesReceived:  20,
+		TotalBytesReceived: 200,
+	}
+}
+
+func TestParseExportAddress(t *testing.T) {
+	network, address, err := parseExportAddress("udp://127.0.0.1:4739")
+	assert.NoError(t, err)
+	assert.Equal(t, "udp", network)
+	assert.Equal(t, "127.0.0.1:4739", address)
+
+	network, address, err = parseExportAddress("unix:///var/run/ipfix.sock")
+	assert.NoError(t, err)
+	assert.Equal(t, "unixgram", network)
+	assert.Equal(t, "/var/run/ipfix.sock", address)
+
+	_, _, err = parseExportAddress("127.0.0.1:4739")
+	assert.Error(t, err)
+}
+
+func TestIPFIXEncodeMessages(t *testing.T) {
+	e := newConnectionsExporter(nil)
+
+	conns := []*model.Connection{
+		newIPFIXTestConn(model.ConnectionFamily_v4, "10.0.0.1", "10.0.0.2"),
+		newIPFIXTestConn(model.ConnectionFamily_v6, "fd00::1", "fd00::2"),
+		{Family: model.ConnectionFamily_v4}, // no addresses, skipped
+	}
+	msgs := e.encodeMessages(conns, 42)
+	require.Len(t, msgs, 1)
+
+	msg := msgs[0]
+	assert.Equal(t, uint16(ipfixVersion), binary.BigEndian.Uint16(msg[0:2]))
+	assert.Equal(t, uint16(len(msg)), binary.BigEndian.Uint16(msg[2:4]))
+	assert.Equal(t, uint32(42), binary.BigEndian.Uint32(msg[4:8]))
+	assert.Equal(t, uint32(0), binary.BigEndian.Uint32(msg[8:12]))
+
+	// template set, then a data set per template
+	offset := ipfixHeaderSize
+	assert.Equal(t, uint16(ipfixTemplateSetID), binary.BigEndian.Uint16(msg[offset:]))
+	offset += len(e.templates)
+
+	assert.Equal(t, uint16(ipfixTemplateIDv4), binary.BigEndian.Uint16(msg[offset:]))
+	assert.Equal(t, uint16(ipfixSetHeaderSize+ipfixTemplatev4.recordSize), binary.BigEndian.Uint16(msg[offset+2:]))
+	record := msg[offset+ipfixSetHeaderSize:]
+	assert.Equal(t, net.ParseIP("10.0.0.1").To4(), net.IP(record[0:4]))
+	assert.Equal(t, net.ParseIP("10.0.0.2").To4(), net.IP(record[4:8]))
+	assert.Equal(t, uint16(34567), binary.BigEndian.Uint16(record[8:10]))
+	assert.Equal(t, uint16(443), binary.BigEndian.Uint16(record[10:12]))
+	assert.Equal(t, byte(6), record[12])
+	assert.Equal(t, byte(1), record[13])
+	assert.Equal(t, uint64(10), binary.BigEndian.Uint64(record[14:22]))
+	assert.Equal(t, uint64(100), binary.BigEndian.Uint64(record[22:30]))
+	assert.Equal(t, uint64(20), binary.BigEndian.Uint64(record[30:38]))
+	assert.Equal(t, uint64(200), binary.BigEndian.Uint64(record[38:46]))
+	offset += ipfixSetHeaderSize + ipfixTemplatev4.recordSize
+
+	assert.Equal(t, uint16(ipfixTemplateIDv6), binary.BigEndian.Uint16(msg[offset:]))
+	assert.Equal(t, len(msg), offset+ipfixSetHeaderSize+ipfixTemplatev6.recordSize)
+
+	// the sequence number counts the records exported
+	msgs = e.encodeMessages(conns, 43)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, uint32(2), binary.BigEndian.Uint32(msgs[0][8:12]))
+}
+
+func TestIPFIXEncodeMessagesSplit(t *testing.T) {
+	e := newConnectionsExporter(nil)
+
+	var conns []*model.Connection
+	for i := 0; i < 100; i++ {
+		conns = append(conns, newIPFIXTestConn(model.ConnectionFamily_v4, "10.0.0.1", "10.0.0.2"))
+	}
+	msgs := e.encodeMessages(conns, 42)
+	require.True(t, len(msgs) > 1)
+
+	var sequence uint32
+	for _, msg := range msgs {
+		assert.True(t, len(msg) <= maxIPFIXMessageSize)
+		assert.Equal(t, uint16(len(msg)), binary.BigEndian.Uint16(msg[2:4]))
+		assert.Equal(t, sequence, binary.BigEndian.Uint32(msg[8:12]))
+		dataSetLength := int(binary.BigEndian.Uint16(msg[ipfixHeaderSize+len(e.templates)+2:]))
+		sequence += uint32((dataSetLength - ipfixSetHeaderSize) / ipfixTemplatev4.recordSize)
+	}
+	assert.Equal(t, uint32(100), sequence)
+}
+
+func TestConnectionsExporterExport(t *testing.T) {
+	collector, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	defer collector.Close()
+
+	cfg := config.NewDefaultAgentConfig()
+	cfg.ConnectionsExportAddress = "udp://" + collector.LocalAddr().String()
+	e, err := NewConnectionsExporter(cfg)
+	require.NoError(t, err)
+	defer e.Close()
+
+	require.NoError(t, e.Export([]*model.Connection{newIPFIXTestConn(model.ConnectionFamily_v4, "10.0.0.1", "10.0.0.2")}))
+
+	buf := make([]byte, maxIPFIXMessageSize)
+	collector.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := collector.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(ipfixVersion), binary.BigEndian.Uint16(buf[0:2]))
+	assert.Equal(t, uint16(n), binary.BigEndian.Uint16(buf[2:4]))
+}
@@ -3,6 +3,7 @@
 package net
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/encoding"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/retry"
 )
@@ -20,6 +22,7 @@ import (
 const (
 	statusURL           = "http://unix/status"
 	connectionsURL      = "http://unix/connections"
+	statsURL            = "http://unix/debug/stats"
 	contentTypeProtobuf = "application/protobuf"
 )
 
@@ -103,6 +106,26 @@ func (r *RemoteSysProbeUtil) GetConnections(clientID string) ([]*model.Connectio
 	return conns.Conns, nil
 }
 
+// GetConnectionFilterCounts returns the numbers of connections excluded by the
+// connection filters of the system probe service
+func (r *RemoteSysProbeUtil) GetConnectionFilterCounts() (util.ConnectionFilterCounts, error) {
+	var stats struct {
+		Filters util.ConnectionFilterCounts `json:"filters"`
+	}
+
+	resp, err := r.httpClient.Get(statsURL)
+	if err != nil {
+		return stats.Filters, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return stats.Filters, fmt.Errorf("stats request failed: socket %s, url: %s, status code: %d", r.socketPath, statsURL, resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	return stats.Filters, err
+}
+
 // ShouldLogTracerUtilError will return whether or not errors sourced from the RemoteSysProbeUtil _should_ be logged, for less noisy logging.
 // We only want to log errors if the tracer has been initialized, or it's the first error for a particular tracer status
 // (e.g. retrying, permafail)
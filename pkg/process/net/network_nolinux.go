@@ -5,6 +5,7 @@ package net
 import (
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
 // RemoteSysProbeUtil is only implemented on linux
@@ -25,6 +26,11 @@ func (r *RemoteSysProbeUtil) GetConnections(clientID string) ([]*model.Connectio
 	return nil, ebpf.ErrNotImplemented
 }
 
+// GetConnectionFilterCounts is only implemented on linux
+func (r *RemoteSysProbeUtil) GetConnectionFilterCounts() (util.ConnectionFilterCounts, error) {
+	return util.ConnectionFilterCounts{}, ebpf.ErrNotImplemented
+}
+
 // ShouldLogTracerUtilError is only implemented on linux
 func ShouldLogTracerUtilError() bool {
 	return false
@@ -0,0 +1,46 @@
+// +build linux
+
+package util
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
+)
+
+var (
+	// containerTagger resolves the container tags of the processes, apart
+	// from the tagger of the binary embedding the filters
+	containerTagger     *tagger.Tagger
+	containerTaggerOnce sync.Once
+)
+
+// initContainerTags starts the tagger the container tags are resolved with.
+// Only the collectors of the node run, the filters match the tags set by the
+// container runtime and the kubelet.
+func initContainerTags() {
+	containerTaggerOnce.Do(func() {
+		catalog := make(collectors.Catalog)
+		for name, factory := range collectors.DefaultCatalog {
+			switch collectors.CollectorPriorities[name] {
+			case collectors.NodeRuntime, collectors.NodeOrchestrator:
+				catalog[name] = factory
+			}
+		}
+		containerTagger = tagger.NewTagger()
+		containerTagger.Init(catalog)
+	})
+}
+
+// lookupContainerTags returns the tags of the container a process runs in,
+// nil when it doesn't run in a container
+func lookupContainerTags(pid uint32) ([]string, error) {
+	cID, err := metrics.ContainerIDForPID(int(pid))
+	if err != nil || cID == "" {
+		return nil, err
+	}
+	return containerTagger.Tag(containers.BuildTaggerEntityName(cID), collectors.HighCardinality)
+}
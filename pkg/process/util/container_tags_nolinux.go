@@ -0,0 +1,10 @@
+// +build !linux
+
+package util
+
+func initContainerTags() {}
+
+// lookupContainerTags returns no tags, the containers are only resolved on Linux
+func lookupContainerTags(pid uint32) ([]string, error) {
+	return nil, nil
+}
@@ -1,102 +1,485 @@
 package util
 
 import (
+	"fmt"
 	"net"
-	"strconv"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-// ConnectionFilter holds a user-defined blacklisted IP/CIDR, and ports
+const (
+	processFilterPrefix        = "process:"
+	cmdlineFilterPrefix        = "cmdline:"
+	domainFilterPrefix         = "dns:"
+	containerImageFilterPrefix = "image:"
+	kubeNamespaceFilterPrefix  = "kube_namespace:"
+	podLabelFilterPrefix       = "pod_label:"
+)
+
+// ConnectionFilter holds a user-defined blacklisted IP/CIDR, process name or
+// command line regex, or domain name glob, and ports
 type ConnectionFilter struct {
-	IP       *net.IPNet
-	Ports    map[uint16]struct{}
-	AllPorts bool
+	// Rule is the key of the filter as configured, e.g. "10.0.0.0/8"
+	Rule string
+
+	IP *net.IPNet
+	// Ports and AllPorts are matched for the connections of every protocol,
+	// ProtocolPorts only for the connections of their protocol
+	Ports         map[uint16]struct{}
+	AllPorts      bool
+	ProtocolPorts map[ConnectionProtocol]*FilterPorts
+
+	// Process and Cmdline match the name and the command line of the process
+	// owning the connection, at most one of them and IP is set
+	Process *regexp.Regexp
+	Cmdline *regexp.Regexp
+
+	// Domain matches the names the address was resolved to by the DNS
+	// requests seen by the system-probe
+	Domain *regexp.Regexp
+
+	// ContainerValue matches the values of the ContainerTags tags of the
+	// container of the process owning the connection, e.g. image_name and
+	// short_image for the container image filters
+	ContainerTags  []string
+	ContainerValue *regexp.Regexp
+}
+
+// ConnectionProcess is the process owning a connection, matched by the
+// process name, command line and container filters
+type ConnectionProcess struct {
+	Name    string
+	Cmdline string
+	// Tags are the tagger tags of the container the process runs in, only
+	// looked up when some filters match the containers
+	Tags []string
 }
 
-// ParseConnectionFilters takes the user defined blacklist and returns a slice of ConnectionFilters
+// RejectedConnectionFilter is a user defined connection filter which isn't
+// respected, as it or one of its ports is invalid
+type RejectedConnectionFilter struct {
+	// List is the list of the filter, e.g. SourceExcludesList, set by
+	// ConnectionFilterRules.Validate
+	List string `json:"list,omitempty"`
+	Rule string `json:"rule"`
+	// Port is the invalid port of the filter, empty when the rule itself is
+	// invalid
+	Port   string `json:"port,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (r RejectedConnectionFilter) String() string {
+	s := r.Rule
+	if r.List != "" {
+		s = r.List + " " + s
+	}
+	if r.Port != "" {
+		s += ", port " + r.Port
+	}
+	return s + ": " + r.Reason
+}
+
+// ParseConnectionFilters takes the user defined blacklist and returns a slice of ConnectionFilters.
+// The keys are IP/CIDR/*, "process:<regex>" and "cmdline:<regex>" to match the process owning
+// the connections, "dns:<glob>" to match the domain names of the addresses, e.g. "dns:*.internal.corp",
+// or "image:<glob>", "kube_namespace:<glob>" and "pod_label:<label>=<glob>" to match the container of
+// the process through its tagger tags, and the values are ports, port ranges as "<low>-<high>" or *,
+// optionally restricted to a protocol and as comma separated lists, e.g. "udp 1000-2000,3000" or
+// "tcp *", see parsePortSpec.
+// The invalid filters are skipped, ConnectionFilterRules.Validate reports them.
 func ParseConnectionFilters(filters map[string][]string) (blacklist []*ConnectionFilter) {
-	for ip, ports := range filters {
-		filter := &ConnectionFilter{Ports: map[uint16]struct{}{}}
+	blacklist, rejected := parseConnectionFilters(filters)
+	for _, r := range rejected {
+		log.Debugf("Connection filter will not be respected: %s", r)
+	}
+	return blacklist
+}
+
+func parseConnectionFilters(filters map[string][]string) (blacklist []*ConnectionFilter, rejected []RejectedConnectionFilter) {
+	for _, ip := range sortedKeys(filters) {
+		ports := filters[ip]
+		filter := &ConnectionFilter{Rule: ip, Ports: map[uint16]struct{}{}}
 		var subnet *net.IPNet
 		var err error
 
-		// retrieve valid IPs
-		if strings.ContainsRune(ip, '*') {
+		// retrieve valid IPs, or process regexes
+		if strings.HasPrefix(ip, processFilterPrefix) {
+			filter.Process, err = regexp.Compile(strings.TrimPrefix(ip, processFilterPrefix))
+		} else if strings.HasPrefix(ip, cmdlineFilterPrefix) {
+			filter.Cmdline, err = regexp.Compile(strings.TrimPrefix(ip, cmdlineFilterPrefix))
+		} else if strings.HasPrefix(ip, domainFilterPrefix) {
+			filter.Domain, err = domainGlobToRegexp(strings.TrimPrefix(ip, domainFilterPrefix))
+		} else if strings.HasPrefix(ip, containerImageFilterPrefix) {
+			filter.ContainerTags = []string{"image_name", "short_image"}
+			filter.ContainerValue, err = globToRegexp(strings.TrimPrefix(ip, containerImageFilterPrefix))
+		} else if strings.HasPrefix(ip, kubeNamespaceFilterPrefix) {
+			filter.ContainerTags = []string{"kube_namespace"}
+			filter.ContainerValue, err = globToRegexp(strings.TrimPrefix(ip, kubeNamespaceFilterPrefix))
+		} else if strings.HasPrefix(ip, podLabelFilterPrefix) {
+			filter.ContainerTags, filter.ContainerValue, err = parsePodLabelFilter(strings.TrimPrefix(ip, podLabelFilterPrefix))
+		} else if strings.ContainsRune(ip, '*') {
 			subnet = nil // use for wildcard
 		} else if strings.ContainsRune(ip, '/') {
 			_, subnet, err = net.ParseCIDR(ip)
 		} else if strings.ContainsRune(ip, '.') {
-			_, subnet, err = net.ParseCIDR(ip + "/32") // if given ipv4, prefix length of 32
+			// if given ipv4, prefix length of 32
+			if _, subnet, err = net.ParseCIDR(ip + "/32"); err != nil {
+				err = fmt.Errorf("invalid IPv4 address")
+			}
 		} else if strings.Contains(ip, "::") {
-			_, subnet, err = net.ParseCIDR(ip + "/64") // if given ipv6, prefix length of 64
+			// if given ipv6, prefix length of 64
+			if _, subnet, err = net.ParseCIDR(ip + "/64"); err != nil {
+				err = fmt.Errorf("invalid IPv6 address")
+			}
 		} else {
-			log.Debugf("Invalid IP/CIDR/* defined for connection filter: %s", err)
-			continue
+			err = fmt.Errorf("neither an IP, a CIDR, * nor a process:, cmdline:, dns:, image:, kube_namespace: or pod_label: filter")
 		}
 
 		if err != nil {
-			log.Errorf("Given filter will not be respected. Could not parse given IPs: %s", err)
+			rejected = append(rejected, RejectedConnectionFilter{Rule: ip, Reason: err.Error()})
+			continue
+		}
+		if len(ports) == 0 {
+			rejected = append(rejected, RejectedConnectionFilter{Rule: ip, Reason: "no port given, use * for all the ports"})
 			continue
 		}
 		filter.IP = subnet
+		isNamedFilter := filter.Process != nil || filter.Cmdline != nil || filter.Domain != nil || filter.ContainerValue != nil
 
 		validFilter := true
 		for _, v := range ports {
-			if v == "*" {
-				// This means that IP + port are both *, which effectively blacklists all conns, which is invalid.
-				if subnet == nil {
-					log.Errorf("Given rule will not be respected. Invalid filter with IP/CIDR as * and port as *: %s", err)
-					validFilter = false
-					break
-				}
-
-				filter.AllPorts = true
+			spec, err := parsePortSpec(v)
+			if err != nil {
+				rejected = append(rejected, RejectedConnectionFilter{Rule: ip, Port: v, Reason: err.Error()})
+				validFilter = false
 				continue
 			}
 
-			// The defined port is an integer, lets handle that
-			k, err := strconv.ParseUint(v, 10, 16)
-			if err != nil {
-				log.Debugf("Could not parse list of ports: %s", err)
+			// This means that IP + port are both *, which effectively blacklists all conns, which is invalid.
+			// A process or domain filter with all ports blacklists all the conns of the process or domain.
+			if spec.all && subnet == nil && !isNamedFilter {
+				reason := "IP/CIDR and port both * would exclude all the connections"
+				if spec.protocol != AnyProtocol {
+					reason = fmt.Sprintf("IP/CIDR and port both * would exclude all the %s connections", spec.protocol)
+				}
+				rejected = append(rejected, RejectedConnectionFilter{Rule: ip, Port: v, Reason: reason})
 				validFilter = false
+				break
 			}
-			filter.Ports[uint16(k)] = struct{}{}
+			filter.addPorts(spec)
 		}
 
 		if validFilter {
 			blacklist = append(blacklist, filter)
 		}
 	}
-	return blacklist
+	return blacklist, rejected
 }
 
-// IsBlacklistedConnection returns true if a given connection should be excluded
-// by the tracer based on user defined filters
-func IsBlacklistedConnection(cf []*ConnectionFilter, addrIP Address, addrPort uint16) bool {
-	ip := NetIPFromAddress(addrIP)
+// HasProcessFilters returns whether some filters match the process owning the
+// connections, which then has to be given to MatchConnectionFilters
+func HasProcessFilters(cf []*ConnectionFilter) bool {
+	for _, filter := range cf {
+		if filter.Process != nil || filter.Cmdline != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasContainerFilters returns whether some filters match the container of the
+// process owning the connections, whose tags then have to be looked up
+func HasContainerFilters(cf []*ConnectionFilter) bool {
+	for _, filter := range cf {
+		if filter.ContainerValue != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp compiles a glob, * matching any sequence of characters
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	if glob == "" {
+		return nil, fmt.Errorf("empty value")
+	}
+	return regexp.Compile("^" + strings.Replace(regexp.QuoteMeta(glob), `\*`, ".*", -1) + "$")
+}
+
+// parsePodLabelFilter parses a "<label>=<glob>" pod label selector. The pod
+// labels are only known through the tags the tagger sets from them, the label
+// has to be mapped to a tag by kubernetes_pod_labels_as_tags.
+func parsePodLabelFilter(selector string) ([]string, *regexp.Regexp, error) {
+	parts := strings.SplitN(selector, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, nil, fmt.Errorf("invalid pod label selector %q, expected <label>=<value>", selector)
+	}
+	tagName, ok := podLabelTagName(parts[0])
+	if !ok {
+		return nil, nil, fmt.Errorf("pod label %q isn't set as a tag by kubernetes_pod_labels_as_tags", parts[0])
+	}
+	value, err := globToRegexp(parts[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return []string{tagName}, value, nil
+}
+
+// podLabelTagName returns the name of the tag the tagger sets from a pod
+// label, as configured by kubernetes_pod_labels_as_tags
+func podLabelTagName(label string) (string, bool) {
+	for pattern, tmpl := range config.Datadog.GetStringMapString("kubernetes_pod_labels_as_tags") {
+		if ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(label)); !ok {
+			continue
+		}
+		// a leading + marks the high cardinality tags
+		return strings.Replace(strings.TrimPrefix(tmpl, "+"), "%%label%%", label, -1), true
+	}
+	return "", false
+}
+
+// domainGlobToRegexp compiles a domain name glob, * matching any sequence of
+// characters, case insensitive as the domain names
+func domainGlobToRegexp(glob string) (*regexp.Regexp, error) {
+	glob = strings.TrimSuffix(glob, ".")
+	if glob == "" {
+		return nil, fmt.Errorf("empty domain name")
+	}
+	return regexp.Compile("(?i)^" + strings.Replace(regexp.QuoteMeta(glob), `\*`, ".*", -1) + "$")
+}
+
+// HasDomainFilters returns whether some filters match the domain names of the
+// addresses, which then have to be given to MatchConnectionFilters
+func HasDomainFilters(cf []*ConnectionFilter) bool {
+	for _, filter := range cf {
+		if filter.Domain != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ConnectionEnd is the source or the destination of a connection, matched
+// against the user defined filters
+type ConnectionEnd struct {
+	Addr Address
+	Port uint16
+	// Protocol is the protocol of the connection, the protocol specific ports
+	// of the filters are skipped for AnyProtocol
+	Protocol ConnectionProtocol
+	// Process owns the connection, the process filters are skipped when nil
+	Process *ConnectionProcess
+	// Names are the domain names the address was resolved to, for the domain filters
+	Names []string
+}
 
+// MatchConnectionFilters returns true if a connection end matches one of the
+// user defined filters, on its IP, its process or its domain names, and port
+func MatchConnectionFilters(cf []*ConnectionFilter, end ConnectionEnd) bool {
 	// No filters so short-circuit
 	if len(cf) == 0 {
 		return false
 	}
 
+	ip := NetIPFromAddress(end.Addr)
 	// Iterate through filters to see if this connection matches any defined filter.
 	for _, filter := range cf {
-		// IP is wildcard (*) so only ports are defined
-		if filter.IP == nil {
-			if _, ok := filter.Ports[addrPort]; ok {
-				return true
+		if filter.matches(ip, end) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *ConnectionFilter) matches(ip net.IP, end ConnectionEnd) bool {
+	if !f.matchesPort(end.Port, end.Protocol) {
+		return false
+	}
+
+	switch {
+	case f.ContainerValue != nil:
+		if end.Process == nil {
+			return false
+		}
+		for _, tag := range end.Process.Tags {
+			parts := strings.SplitN(tag, ":", 2)
+			if len(parts) != 2 {
+				continue
 			}
-		} else if filter.IP.Contains(ip) {
-			if filter.AllPorts {
-				return true
-			} else if _, ok := filter.Ports[addrPort]; ok {
+			for _, name := range f.ContainerTags {
+				if parts[0] == name && f.ContainerValue.MatchString(parts[1]) {
+					return true
+				}
+			}
+		}
+		return false
+	case f.Domain != nil:
+		for _, name := range end.Names {
+			if f.Domain.MatchString(strings.TrimSuffix(name, ".")) {
 				return true
 			}
 		}
+		return false
+	case f.Process != nil || f.Cmdline != nil:
+		if end.Process == nil {
+			return false
+		}
+		if f.Process != nil && !f.Process.MatchString(end.Process.Name) {
+			return false
+		}
+		return f.Cmdline == nil || f.Cmdline.MatchString(end.Process.Cmdline)
+	case f.IP == nil:
+		// IP is wildcard (*) so only ports are defined
+		return true
+	default:
+		return f.IP.Contains(ip)
 	}
+}
 
-	return false
+// IsBlacklistedConnection returns true if a given connection should be excluded
+// by the tracer based on user defined filters. The process filters are skipped
+// when the process owning the connection is nil, the domain filters are
+// skipped as no names are given.
+func IsBlacklistedConnection(cf []*ConnectionFilter, addrIP Address, addrPort uint16, proc *ConnectionProcess) bool {
+	return MatchConnectionFilters(cf, ConnectionEnd{Addr: addrIP, Port: addrPort, Process: proc})
+}
+
+// The lists of user defined connection filters, as reported by ExcludingRule
+const (
+	SourceExcludesList = "source_excludes"
+	DestExcludesList   = "dest_excludes"
+	IncludesList       = "includes"
+
+	// NoIncludeMatchRule is the rule reported for the connections excluded
+	// because no end matches one of the inclusions
+	NoIncludeMatchRule = "no_match"
+)
+
+// ConnectionFilterRules are the user defined connection filters, as configured
+type ConnectionFilterRules struct {
+	SourceExcludes map[string][]string
+	DestExcludes   map[string][]string
+	// Includes restrict the connections to the ones with an end matching
+	// them, when set
+	Includes map[string][]string
+}
+
+// Validate returns the filters which aren't respected as they are invalid,
+// with the reason, ordered by list and rule
+func (r ConnectionFilterRules) Validate() []RejectedConnectionFilter {
+	var rejected []RejectedConnectionFilter
+	for _, l := range []struct {
+		name    string
+		filters map[string][]string
+	}{
+		{SourceExcludesList, r.SourceExcludes},
+		{DestExcludesList, r.DestExcludes},
+		{IncludesList, r.Includes},
+	} {
+		_, listRejected := parseConnectionFilters(l.filters)
+		for _, rej := range listRejected {
+			rej.List = l.name
+			rejected = append(rejected, rej)
+		}
+	}
+	return rejected
+}
+
+// ConnectionFilterSet evaluates the user defined connection filters
+type ConnectionFilterSet struct {
+	SourceExcludes []*ConnectionFilter
+	DestExcludes   []*ConnectionFilter
+	Includes       []*ConnectionFilter
+
+	// the filters indexed for IsExcluded, evaluated on every connection
+	sourceExcludes *ConnectionFilterIndex
+	destExcludes   *ConnectionFilterIndex
+	includes       *ConnectionFilterIndex
+}
+
+// NewConnectionFilterSet parses and indexes the user defined connection filters
+func NewConnectionFilterSet(rules ConnectionFilterRules) *ConnectionFilterSet {
+	s := &ConnectionFilterSet{
+		SourceExcludes: ParseConnectionFilters(rules.SourceExcludes),
+		DestExcludes:   ParseConnectionFilters(rules.DestExcludes),
+		Includes:       ParseConnectionFilters(rules.Includes),
+	}
+	s.sourceExcludes = NewConnectionFilterIndex(s.SourceExcludes)
+	s.destExcludes = NewConnectionFilterIndex(s.DestExcludes)
+	s.includes = NewConnectionFilterIndex(s.Includes)
+	return s
+}
+
+// HasProcessFilters returns whether some filters match the process owning
+// the connections, which then has to be set on the connection ends
+func (s *ConnectionFilterSet) HasProcessFilters() bool {
+	return HasProcessFilters(s.SourceExcludes) || HasProcessFilters(s.DestExcludes) || HasProcessFilters(s.Includes)
+}
+
+// HasDomainFilters returns whether some filters match the domain names of the
+// addresses, which then have to be set on the connection ends
+func (s *ConnectionFilterSet) HasDomainFilters() bool {
+	return HasDomainFilters(s.SourceExcludes) || HasDomainFilters(s.DestExcludes) || HasDomainFilters(s.Includes)
+}
+
+// HasContainerFilters returns whether some filters match the container of the
+// process owning the connections, whose tags then have to be set on the
+// process of the connection ends
+func (s *ConnectionFilterSet) HasContainerFilters() bool {
+	return HasContainerFilters(s.SourceExcludes) || HasContainerFilters(s.DestExcludes) || HasContainerFilters(s.Includes)
+}
+
+// IsExcluded returns true if a connection should be excluded by the tracer.
+// The exclusions take precedence: a connection matching a source or
+// destination exclusion is excluded even if it matches an inclusion. When
+// inclusions are set, the connections without an end matching one of them are
+// excluded.
+func (s *ConnectionFilterSet) IsExcluded(source, dest ConnectionEnd) bool {
+	list, _ := s.ExcludingRule(source, dest)
+	return list != ""
+}
+
+// ExcludingRule returns the list and the rule excluding a connection, as
+// IsExcluded decides, or empty strings if it isn't excluded. The connections
+// excluded because no end matches one of the inclusions are reported as the
+// NoIncludeMatchRule rule of the IncludesList list.
+func (s *ConnectionFilterSet) ExcludingRule(source, dest ConnectionEnd) (list, rule string) {
+	if filter := s.sourceExcludes.MatchFilter(source); filter != nil {
+		return SourceExcludesList, filter.Rule
+	}
+	if filter := s.destExcludes.MatchFilter(dest); filter != nil {
+		return DestExcludesList, filter.Rule
+	}
+	if len(s.Includes) == 0 || s.includes.Match(source) || s.includes.Match(dest) {
+		return "", ""
+	}
+	return IncludesList, NoIncludeMatchRule
+}
+
+// DiffConnectionFilters returns the keys of the user defined filters added,
+// removed and whose ports changed between two configurations, sorted
+func DiffConnectionFilters(old, new map[string][]string) (added, removed, changed []string) {
+	for k, ports := range new {
+		oldPorts, ok := old[k]
+		if !ok {
+			added = append(added, k)
+		} else if !reflect.DeepEqual(oldPorts, ports) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
 }
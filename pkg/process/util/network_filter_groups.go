@@ -0,0 +1,124 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// filterGroupPrefix references a named filter group in the user defined filters
+const filterGroupPrefix = "@"
+
+// filterGroup is a named set of IP/CIDRs, or of ports and port ranges
+type filterGroup struct {
+	addresses bool
+	entries   []string
+}
+
+// ExpandConnectionFilterGroups replaces the references to the named groups in
+// the user defined filters: "@<group>" keys by the IP/CIDRs of the group, and
+// "@<group>" ports by the ports of the group. A group holds either IP/CIDRs or
+// ports and port ranges, e.g. monitoring_ports: ["9100-9120", "10255"]. The
+// group names are case insensitive, as the configuration keys.
+func ExpandConnectionFilterGroups(filters, groups map[string][]string) (map[string][]string, error) {
+	parsed, err := parseFilterGroups(groups)
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) == 0 {
+		return filters, nil
+	}
+
+	expanded := make(map[string][]string, len(filters))
+	for _, k := range sortedKeys(filters) {
+		var ports []string
+		for _, port := range filters[k] {
+			if !strings.HasPrefix(port, filterGroupPrefix) {
+				ports = append(ports, port)
+				continue
+			}
+			group, err := lookupFilterGroup(parsed, port)
+			if err != nil {
+				return nil, fmt.Errorf("filter %q: %s", k, err)
+			}
+			if group.addresses {
+				return nil, fmt.Errorf("filter %q: filter group %q holds IP/CIDRs, not ports", k, strings.TrimPrefix(port, filterGroupPrefix))
+			}
+			ports = append(ports, group.entries...)
+		}
+
+		if !strings.HasPrefix(k, filterGroupPrefix) {
+			expanded[k] = append(expanded[k], ports...)
+			continue
+		}
+		group, err := lookupFilterGroup(parsed, k)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q: %s", k, err)
+		}
+		if !group.addresses {
+			return nil, fmt.Errorf("filter %q: filter group %q holds ports, not IP/CIDRs", k, strings.TrimPrefix(k, filterGroupPrefix))
+		}
+		for _, addr := range group.entries {
+			expanded[addr] = append(expanded[addr], ports...)
+		}
+	}
+	return expanded, nil
+}
+
+func lookupFilterGroup(groups map[string]*filterGroup, ref string) (*filterGroup, error) {
+	name := strings.TrimPrefix(ref, filterGroupPrefix)
+	group, ok := groups[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown filter group %q", name)
+	}
+	return group, nil
+}
+
+// parseFilterGroups validates the named filter groups, the errors name the
+// offending group and entry
+func parseFilterGroups(groups map[string][]string) (map[string]*filterGroup, error) {
+	parsed := make(map[string]*filterGroup, len(groups))
+	for _, name := range sortedKeys(groups) {
+		entries := groups[name]
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("filter group %q is empty", name)
+		}
+
+		group := &filterGroup{}
+		for i, entry := range entries {
+			isAddress := isFilterAddress(entry)
+			if !isAddress {
+				if spec, err := parsePortSpec(entry); err != nil || spec.all {
+					return nil, fmt.Errorf("filter group %q: %q is neither an IP/CIDR nor a port or port range", name, entry)
+				}
+			}
+			if i == 0 {
+				group.addresses = isAddress
+			} else if isAddress != group.addresses {
+				return nil, fmt.Errorf("filter group %q mixes IP/CIDRs and ports: %q", name, entry)
+			}
+			group.entries = append(group.entries, entry)
+		}
+		parsed[strings.ToLower(name)] = group
+	}
+	return parsed, nil
+}
+
+// isFilterAddress returns whether a group entry is an IP or a CIDR
+func isFilterAddress(entry string) bool {
+	if strings.ContainsRune(entry, '/') {
+		_, _, err := net.ParseCIDR(entry)
+		return err == nil
+	}
+	return net.ParseIP(entry) != nil
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFilterGroups = map[string][]string{
+	"monitoring_ports": {"9100-9120", "10255"},
+	"internal_nets":    {"10.0.0.0/8", "fd00::1"},
+}
+
+func TestExpandConnectionFilterGroups(t *testing.T) {
+	expanded, err := ExpandConnectionFilterGroups(map[string][]string{
+		"@internal_nets":  {"@Monitoring_Ports", "443"},
+		"10.0.0.0/8":      {"80"},
+		"*":               {"@monitoring_ports"},
+		"process:^nginx$": {"*"},
+	}, testFilterGroups)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"10.0.0.0/8":      {"80", "9100-9120", "10255", "443"},
+		"fd00::1":         {"9100-9120", "10255", "443"},
+		"*":               {"9100-9120", "10255"},
+		"process:^nginx$": {"*"},
+	}, expanded)
+
+	filters := ParseConnectionFilters(expanded)
+	assert.True(t, IsBlacklistedConnection(filters, AddressFromString("10.1.2.3"), uint16(9110), nil))
+	assert.True(t, IsBlacklistedConnection(filters, AddressFromString("192.168.0.1"), uint16(10255), nil))
+	assert.False(t, IsBlacklistedConnection(filters, AddressFromString("192.168.0.1"), uint16(9121), nil))
+
+	// no filters
+	expanded, err = ExpandConnectionFilterGroups(nil, testFilterGroups)
+	assert.NoError(t, err)
+	assert.Empty(t, expanded)
+}
+
+func TestExpandConnectionFilterGroupsErrors(t *testing.T) {
+	for _, tc := range []struct {
+		filters  map[string][]string
+		groups   map[string][]string
+		expected string
+	}{
+		{
+			filters:  map[string][]string{"*": {"@unknown"}},
+			groups:   testFilterGroups,
+			expected: `filter "*": unknown filter group "unknown"`,
+		},
+		{
+			filters:  map[string][]string{"@monitoring_ports": {"80"}},
+			groups:   testFilterGroups,
+			expected: `filter "@monitoring_ports": filter group "monitoring_ports" holds ports, not IP/CIDRs`,
+		},
+		{
+			filters:  map[string][]string{"10.0.0.1": {"@internal_nets"}},
+			groups:   testFilterGroups,
+			expected: `filter "10.0.0.1": filter group "internal_nets" holds IP/CIDRs, not ports`,
+		},
+		{
+			groups:   map[string][]string{"web": {"80", "10.0.0.1"}},
+			expected: `filter group "web" mixes IP/CIDRs and ports: "10.0.0.1"`,
+		},
+		{
+			groups:   map[string][]string{"web": {"80", "9120-9100"}},
+			expected: `filter group "web": "9120-9100" is neither an IP/CIDR nor a port or port range`,
+		},
+		{
+			groups:   map[string][]string{"web": {"*"}},
+			expected: `filter group "web": "*" is neither an IP/CIDR nor a port or port range`,
+		},
+		{
+			groups:   map[string][]string{"web": {}},
+			expected: `filter group "web" is empty`,
+		},
+	} {
+		_, err := ExpandConnectionFilterGroups(tc.filters, tc.groups)
+		assert.EqualError(t, err, tc.expected)
+	}
+}
+
+func TestParseConnectionFiltersPortRange(t *testing.T) {
+	filters := ParseConnectionFilters(map[string][]string{
+		"10.0.0.1": {"8000-8002", "9000"},
+		"10.0.0.2": {"8002-8000"}, // invalid config
+	})
+	require.Len(t, filters, 1)
+	assert.Len(t, filters[0].Ports, 4)
+	assert.True(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.1"), uint16(8001), nil))
+	assert.False(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.1"), uint16(8003), nil))
+}
@@ -0,0 +1,194 @@
+package util
+
+import (
+	"net"
+)
+
+// portBitmap holds a bit per port
+type portBitmap [1 << 16 / 64]uint64
+
+func (b *portBitmap) set(port uint16) {
+	b[port>>6] |= 1 << (port & 63)
+}
+
+func (b *portBitmap) has(port uint16) bool {
+	return b[port>>6]&(1<<(port&63)) != 0
+}
+
+// nodePorts are the ports of the filters of a trie node for one protocol
+type nodePorts struct {
+	all    bool
+	bitmap *portBitmap
+}
+
+func (p *nodePorts) add(ports map[uint16]struct{}, all bool) {
+	p.all = p.all || all
+	if len(ports) == 0 {
+		return
+	}
+	if p.bitmap == nil {
+		p.bitmap = &portBitmap{}
+	}
+	for port := range ports {
+		p.bitmap.set(port)
+	}
+}
+
+func (p *nodePorts) has(port uint16) bool {
+	return p.all || (p.bitmap != nil && p.bitmap.has(port))
+}
+
+// filterTrieNode is a node of a binary trie over the bits of the addresses.
+// The node at depth n holds the ports of the filters on the n bits long
+// prefix leading to it, per protocol, and these filters to tell which one
+// matched.
+type filterTrieNode struct {
+	children [2]*filterTrieNode
+	// ports are indexed by protocol, AnyProtocol holding the ports of every
+	// protocol
+	ports   [protocolCount]nodePorts
+	filters []*ConnectionFilter
+}
+
+func (n *filterTrieNode) addPorts(filter *ConnectionFilter) {
+	if !filter.hasPorts() {
+		return
+	}
+	n.filters = append(n.filters, filter)
+	n.ports[AnyProtocol].add(filter.Ports, filter.AllPorts)
+	for protocol, ports := range filter.ProtocolPorts {
+		n.ports[protocol].add(ports.Ports, ports.All)
+	}
+}
+
+func (n *filterTrieNode) matchesPort(port uint16, protocol ConnectionProtocol) bool {
+	return n.ports[AnyProtocol].has(port) || (protocol != AnyProtocol && n.ports[protocol].has(port))
+}
+
+// filterFor returns the first filter of the node matching the port
+func (n *filterTrieNode) filterFor(port uint16, protocol ConnectionProtocol) *ConnectionFilter {
+	for _, filter := range n.filters {
+		if filter.matchesPort(port, protocol) {
+			return filter
+		}
+	}
+	return nil
+}
+
+// insert adds the ports of a filter on the first prefixLen bits of ip
+func (n *filterTrieNode) insert(ip []byte, prefixLen int, filter *ConnectionFilter) {
+	node := n
+	for i := 0; i < prefixLen; i++ {
+		bit := ip[i/8] >> (7 - uint(i%8)) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &filterTrieNode{}
+		}
+		node = node.children[bit]
+	}
+	node.addPorts(filter)
+}
+
+// lookup returns the node of the shortest prefix of ip having a filter
+// matching the port of the protocol, nil if none has. It takes as many steps
+// as the longest prefix of ip in the trie.
+func (n *filterTrieNode) lookup(ip []byte, port uint16, protocol ConnectionProtocol) *filterTrieNode {
+	node := n
+	for i := 0; node != nil; i++ {
+		if node.matchesPort(port, protocol) {
+			return node
+		}
+		if i == len(ip)*8 {
+			return nil
+		}
+		node = node.children[ip[i/8]>>(7-uint(i%8))&1]
+	}
+	return nil
+}
+
+// ConnectionFilterIndex matches the connection ends against a list of
+// filters. The IP/CIDR and wildcard filters are indexed by a trie of the
+// address prefixes holding a bitmap of the ports, matching an address takes
+// at most as many steps as its number of bits whatever the number of filters.
+// The process, domain and container filters are matched one by one.
+type ConnectionFilterIndex struct {
+	v4    *filterTrieNode
+	v6    *filterTrieNode
+	named []*ConnectionFilter
+	empty bool
+}
+
+// NewConnectionFilterIndex indexes a list of filters
+func NewConnectionFilterIndex(cf []*ConnectionFilter) *ConnectionFilterIndex {
+	idx := &ConnectionFilterIndex{
+		v4:    &filterTrieNode{},
+		v6:    &filterTrieNode{},
+		empty: len(cf) == 0,
+	}
+	for _, filter := range cf {
+		switch {
+		case filter.Process != nil || filter.Cmdline != nil || filter.Domain != nil || filter.ContainerValue != nil:
+			idx.named = append(idx.named, filter)
+		case filter.IP == nil:
+			// wildcard, the empty prefix of both families
+			idx.v4.addPorts(filter)
+			idx.v6.addPorts(filter)
+		default:
+			ones, bits := filter.IP.Mask.Size()
+			ip := filter.IP.IP.To4()
+			if ip == nil {
+				idx.v6.insert(filter.IP.IP.To16(), ones, filter)
+				continue
+			}
+			// an IPv4-mapped IPv6 network is an IPv4 network, as with
+			// net.IPNet.Contains
+			if bits == 8*net.IPv6len {
+				ones -= 8 * (net.IPv6len - net.IPv4len)
+				if ones < 0 {
+					ones = 0
+				}
+			}
+			idx.v4.insert(ip, ones, filter)
+		}
+	}
+	return idx
+}
+
+// Match returns true if a connection end matches one of the filters, as
+// MatchConnectionFilters does
+func (idx *ConnectionFilterIndex) Match(end ConnectionEnd) bool {
+	return idx.MatchFilter(end) != nil
+}
+
+// MatchFilter returns a filter matching a connection end, nil if none does.
+// The IP/CIDR and wildcard filters are tried first, the shortest prefix first.
+func (idx *ConnectionFilterIndex) MatchFilter(end ConnectionEnd) *ConnectionFilter {
+	if idx.empty {
+		return nil
+	}
+
+	var node *filterTrieNode
+	raw := end.Addr.Bytes()
+	if len(raw) == net.IPv4len {
+		node = idx.v4.lookup(raw, end.Port, end.Protocol)
+	} else if ip := net.IP(raw).To4(); ip != nil {
+		// the IPv4-mapped IPv6 addresses only match the IPv4 filters, as
+		// with net.IPNet.Contains
+		node = idx.v4.lookup(ip, end.Port, end.Protocol)
+	} else {
+		node = idx.v6.lookup(raw, end.Port, end.Protocol)
+	}
+	if node != nil {
+		return node.filterFor(end.Port, end.Protocol)
+	}
+
+	if len(idx.named) == 0 {
+		return nil
+	}
+	ip := NetIPFromAddress(end.Addr)
+	for _, filter := range idx.named {
+		if filter.matches(ip, end) {
+			return filter
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package util
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionFilterIndex(t *testing.T) {
+	filters := ParseConnectionFilters(map[string][]string{
+		"10.0.0.0/8":      {"5432"},
+		"10.1.0.0/16":     {"6379-6380"},
+		"10.1.2.3":        {"*"},
+		"2001:db8::/32":   {"443"},
+		"*":               {"9000"},
+		"process:^ntpd$":  {"123"},
+		"::ffff:0:0/96":   {"53"},   // IPv4-mapped, the IPv4 addresses
+		"192.168.1.0/24":  {"ABCD"}, // invalid config
+		"fd00::1":         {"22"},
+		"172.16.0.0/12":   {"80", "8080"},
+		"172.16.5.0/24":   {"81"},
+		"100.64.0.0/10":   {},
+		"2001:db8:1::/48": {"*"},
+	})
+	idx := NewConnectionFilterIndex(filters)
+	ntpd := &ConnectionProcess{Name: "ntpd"}
+
+	for _, tc := range []struct {
+		addr    string
+		port    uint16
+		proc    *ConnectionProcess
+		matched bool
+	}{
+		{"10.200.0.1", 5432, nil, true},
+		{"10.200.0.1", 6379, nil, false},
+		{"10.1.200.1", 6380, nil, true},
+		{"10.1.200.1", 5432, nil, true},
+		{"10.1.2.3", 1, nil, true},
+		{"10.1.2.4", 1, nil, false},
+		{"11.0.0.1", 5432, nil, false},
+		{"11.0.0.1", 9000, nil, true},
+		{"2001:db8:2::1", 9000, nil, true},
+		{"2001:db8:2::1", 443, nil, true},
+		{"2001:db8:2::1", 80, nil, false},
+		{"2001:db8:1::1", 80, nil, true},
+		{"2001:db9::1", 443, nil, false},
+		{"fd00::1", 22, nil, true},
+		{"fd00::2", 22, nil, true}, // a single IPv6 address is a /64
+		{"fd00:0:0:1::1", 22, nil, false},
+		{"8.8.8.8", 53, nil, true},
+		{"172.16.5.1", 81, nil, true},
+		{"172.16.6.1", 81, nil, false},
+		{"172.31.6.1", 8080, nil, true},
+		{"100.64.0.1", 80, nil, false},
+		{"8.8.8.8", 123, ntpd, true},
+		{"8.8.8.8", 123, nil, false},
+	} {
+		end := ConnectionEnd{Addr: AddressFromString(tc.addr), Port: tc.port, Process: tc.proc}
+		assert.Equal(t, tc.matched, idx.Match(end), "%s:%d", tc.addr, tc.port)
+		assert.Equal(t, MatchConnectionFilters(filters, end), idx.Match(end), "%s:%d", tc.addr, tc.port)
+	}
+
+	assert.False(t, NewConnectionFilterIndex(nil).Match(ConnectionEnd{Addr: AddressFromString("10.0.0.1"), Port: 80}))
+}
+
+func TestConnectionFilterIndexMatchFilter(t *testing.T) {
+	idx := NewConnectionFilterIndex(ParseConnectionFilters(map[string][]string{
+		"10.0.0.0/8":     {"22"},
+		"10.1.0.0/16":    {"*"},
+		"*":              {"9000"},
+		"process:^ntpd$": {"123"},
+	}))
+	rule := func(addr string, port uint16, proc *ConnectionProcess) string {
+		if filter := idx.MatchFilter(ConnectionEnd{Addr: AddressFromString(addr), Port: port, Process: proc}); filter != nil {
+			return filter.Rule
+		}
+		return ""
+	}
+
+	// the shortest prefix first
+	assert.Equal(t, "10.0.0.0/8", rule("10.1.0.1", 22, nil))
+	assert.Equal(t, "10.1.0.0/16", rule("10.1.0.1", 80, nil))
+	assert.Equal(t, "*", rule("10.1.0.1", 9000, nil))
+	assert.Equal(t, "process:^ntpd$", rule("8.8.8.8", 123, &ConnectionProcess{Name: "ntpd"}))
+	assert.Equal(t, "", rule("8.8.8.8", 123, nil))
+}
+
+func TestConnectionFilterIndexMatchesLinearScan(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	for round := 0; round < 50; round++ {
+		filters := ParseConnectionFilters(randConnectionFilters(r, 50))
+		idx := NewConnectionFilterIndex(filters)
+		for i := 0; i < 1000; i++ {
+			var addr Address
+			switch r.Intn(3) {
+			case 0:
+				addr = AddressFromString(fmt.Sprintf("10.%d.%d.%d", r.Intn(4), r.Intn(4), r.Intn(4)))
+			case 1:
+				addr = AddressFromString(fmt.Sprintf("2001:db8:%x::%x", r.Intn(4), r.Intn(4)))
+			default:
+				// IPv4-mapped IPv6
+				addr = V6AddressFromBytes(net.IPv4(10, byte(r.Intn(4)), 0, 1).To16())
+			}
+			end := ConnectionEnd{Addr: addr, Port: uint16(r.Intn(130)), Protocol: ConnectionProtocol(r.Intn(int(protocolCount)))}
+			if !assert.Equal(t, MatchConnectionFilters(filters, end), idx.Match(end), "%s:%d/%s", addr, end.Port, end.Protocol) {
+				return
+			}
+		}
+	}
+}
+
+func randConnectionFilters(r *rand.Rand, count int) map[string][]string {
+	filters := make(map[string][]string, count)
+	for i := 0; i < count; i++ {
+		var key string
+		switch r.Intn(4) {
+		case 0:
+			key = fmt.Sprintf("10.%d.%d.%d", r.Intn(4), r.Intn(4), r.Intn(4))
+		case 1:
+			key = fmt.Sprintf("10.%d.%d.0/%d", r.Intn(4), r.Intn(4), 8+r.Intn(24))
+		case 2:
+			key = fmt.Sprintf("2001:db8:%x::/%d", r.Intn(4), 16+r.Intn(100))
+		default:
+			key = "*"
+		}
+		protocol := []string{"", "tcp ", "udp "}[r.Intn(3)]
+		low := r.Intn(100)
+		filters[key] = []string{fmt.Sprintf("%s%d-%d,%d", protocol, low, low+r.Intn(20), r.Intn(100)), fmt.Sprint(r.Intn(100))}
+		if key != "*" && r.Intn(10) == 0 {
+			filters[key] = []string{protocol + "*"}
+		}
+	}
+	return filters
+}
+
+// manyConnectionFilters returns count /24 filters on port ranges, as with
+// hundreds of CIDR and port range rules
+func manyConnectionFilters(count int) map[string][]string {
+	filters := make(map[string][]string, count)
+	for i := 0; i < count; i++ {
+		filters[fmt.Sprintf("10.%d.%d.0/24", i/256, i%256)] = []string{"1000-1100", "8080"}
+		filters[fmt.Sprintf("2001:db8:%x::/48", i)] = []string{"443"}
+	}
+	return filters
+}
+
+func BenchmarkConnectionFilterIndexIPv4(b *testing.B) {
+	sourceIdx := NewConnectionFilterIndex(ParseConnectionFilters(testSourceFilters))
+	destIdx := NewConnectionFilterIndex(ParseConnectionFilters(testDestinationFilters))
+	addrs := randIPv4(6)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, addr := range addrs {
+			sink = sourceIdx.Match(ConnectionEnd{Addr: addr, Port: uint16(rand.Intn(9999))})
+			sink = destIdx.Match(ConnectionEnd{Addr: addr, Port: uint16(rand.Intn(9999))})
+		}
+	}
+}
+
+func BenchmarkConnectionFilterIndexIPv6(b *testing.B) {
+	sourceIdx := NewConnectionFilterIndex(ParseConnectionFilters(testSourceFilters))
+	destIdx := NewConnectionFilterIndex(ParseConnectionFilters(testDestinationFilters))
+	addrs := randIPv6(6)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, addr := range addrs {
+			sink = sourceIdx.Match(ConnectionEnd{Addr: addr, Port: uint16(rand.Intn(9999))})
+			sink = destIdx.Match(ConnectionEnd{Addr: addr, Port: uint16(rand.Intn(9999))})
+		}
+	}
+}
+
+func BenchmarkIsBlacklistedConnectionManyFilters(b *testing.B) {
+	filters := ParseConnectionFilters(manyConnectionFilters(500))
+	addrs := append(randIPv4(3), randIPv6(3)...)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, addr := range addrs {
+			sink = IsBlacklistedConnection(filters, addr, uint16(rand.Intn(9999)), nil)
+		}
+	}
+}
+
+func BenchmarkConnectionFilterIndexManyFilters(b *testing.B) {
+	idx := NewConnectionFilterIndex(ParseConnectionFilters(manyConnectionFilters(500)))
+	addrs := append(randIPv4(3), randIPv6(3)...)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, addr := range addrs {
+			sink = idx.Match(ConnectionEnd{Addr: addr, Port: uint16(rand.Intn(9999))})
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConnectionProtocol is the transport protocol of a connection, matched by the
+// protocol specific ports of the filters
+type ConnectionProtocol uint8
+
+const (
+	// AnyProtocol is the protocol of the connection ends whose protocol isn't
+	// known, they only match the ports given without protocol
+	AnyProtocol ConnectionProtocol = iota
+	// ProtocolTCP is the protocol of the TCP connections
+	ProtocolTCP
+	// ProtocolUDP is the protocol of the UDP connections
+	ProtocolUDP
+
+	protocolCount
+)
+
+func (p ConnectionProtocol) String() string {
+	switch p {
+	case ProtocolTCP:
+		return "tcp"
+	case ProtocolUDP:
+		return "udp"
+	default:
+		return "any"
+	}
+}
+
+// FilterPorts are the ports matched by a filter for one protocol
+type FilterPorts struct {
+	Ports map[uint16]struct{}
+	All   bool
+}
+
+func (p *FilterPorts) matches(port uint16) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.Ports[port]
+	return ok || p.All
+}
+
+// portRange is an inclusive range of ports
+type portRange struct {
+	low, high uint16
+}
+
+// portSpec is a parsed port of a user defined filter
+type portSpec struct {
+	protocol ConnectionProtocol
+	all      bool
+	ranges   []portRange
+}
+
+// parsePortSpec parses a port of a user defined filter, following the grammar:
+//
+//	spec     = [ protocol " " ] portset
+//	protocol = "tcp" | "udp"
+//	portset  = "*" | item { "," item }
+//	item     = port [ "-" port ]
+//	port     = 0-65535
+//
+// e.g. "443", "9100-9120", "udp 1000-2000,3000" or "tcp *". The protocol is
+// case insensitive, a spec without protocol applies to every protocol.
+func parsePortSpec(v string) (portSpec, error) {
+	spec := portSpec{}
+	set := strings.TrimSpace(v)
+	if fields := strings.Fields(set); len(fields) > 1 {
+		switch strings.ToLower(fields[0]) {
+		case "tcp":
+			spec.protocol = ProtocolTCP
+		case "udp":
+			spec.protocol = ProtocolUDP
+		default:
+			return portSpec{}, fmt.Errorf("unknown protocol %s, expected tcp or udp", fields[0])
+		}
+		set = strings.TrimSpace(set[len(fields[0]):])
+	} else if p := strings.ToLower(set); p == "tcp" || p == "udp" {
+		return portSpec{}, fmt.Errorf("no port given after protocol %s, use %s * for all the ports", set, set)
+	}
+
+	if set == "*" {
+		spec.all = true
+		return spec, nil
+	}
+	for _, item := range strings.Split(set, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" {
+			return portSpec{}, fmt.Errorf("* can't be combined with other ports")
+		}
+		low, high, err := parsePortRange(item)
+		if err != nil {
+			return portSpec{}, err
+		}
+		spec.ranges = append(spec.ranges, portRange{low, high})
+	}
+	return spec, nil
+}
+
+// parsePortRange parses a port, or a range of ports as "<low>-<high>"
+func parsePortRange(v string) (low, high uint16, err error) {
+	parts := strings.SplitN(v, "-", 2)
+	if low, err = parsePort(strings.TrimSpace(parts[0])); err != nil {
+		return 0, 0, err
+	}
+	if len(parts) == 1 {
+		return low, low, nil
+	}
+	if high, err = parsePort(strings.TrimSpace(parts[1])); err != nil {
+		return 0, 0, err
+	}
+	if high < low {
+		return 0, 0, fmt.Errorf("invalid port range %s, it ends before it starts", v)
+	}
+	return low, high, nil
+}
+
+func parsePort(v string) (uint16, error) {
+	if v == "" {
+		return 0, fmt.Errorf("missing port")
+	}
+	port, err := strconv.ParseUint(v, 10, 16)
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		return 0, fmt.Errorf("port %s is out of the 0-65535 range", v)
+	} else if err != nil {
+		return 0, fmt.Errorf("%s is neither a port, a port range nor *", v)
+	}
+	return uint16(port), nil
+}
+
+// addPorts adds the ports of a spec to the filter, to the ports of its
+// protocol or to the ports of every protocol
+func (f *ConnectionFilter) addPorts(spec portSpec) {
+	ports := &FilterPorts{Ports: f.Ports, All: f.AllPorts}
+	if spec.protocol != AnyProtocol {
+		if f.ProtocolPorts == nil {
+			f.ProtocolPorts = map[ConnectionProtocol]*FilterPorts{}
+		}
+		if ports = f.ProtocolPorts[spec.protocol]; ports == nil {
+			ports = &FilterPorts{Ports: map[uint16]struct{}{}}
+			f.ProtocolPorts[spec.protocol] = ports
+		}
+	}
+
+	ports.All = ports.All || spec.all
+	for _, r := range spec.ranges {
+		for port := uint32(r.low); port <= uint32(r.high); port++ {
+			ports.Ports[uint16(port)] = struct{}{}
+		}
+	}
+	if spec.protocol == AnyProtocol {
+		f.AllPorts = ports.All
+	}
+}
+
+// matchesPort returns whether the filter matches the port of a connection end
+// of the protocol
+func (f *ConnectionFilter) matchesPort(port uint16, protocol ConnectionProtocol) bool {
+	if _, ok := f.Ports[port]; ok || f.AllPorts {
+		return true
+	}
+	return protocol != AnyProtocol && f.ProtocolPorts[protocol].matches(port)
+}
+
+// hasPorts returns whether the filter matches some ports
+func (f *ConnectionFilter) hasPorts() bool {
+	return f.AllPorts || len(f.Ports) > 0 || len(f.ProtocolPorts) > 0
+}
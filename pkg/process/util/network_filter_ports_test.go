@@ -0,0 +1,134 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePortSpec(t *testing.T) {
+	for _, tc := range []struct {
+		spec     string
+		expected portSpec
+		err      string
+	}{
+		{spec: "443", expected: portSpec{ranges: []portRange{{443, 443}}}},
+		{spec: "*", expected: portSpec{all: true}},
+		{spec: "9100-9120", expected: portSpec{ranges: []portRange{{9100, 9120}}}},
+		{spec: "0-65535", expected: portSpec{ranges: []portRange{{0, 65535}}}},
+		{spec: "80,443", expected: portSpec{ranges: []portRange{{80, 80}, {443, 443}}}},
+		{spec: "tcp 53361-53500", expected: portSpec{protocol: ProtocolTCP, ranges: []portRange{{53361, 53500}}}},
+		{spec: "udp 119", expected: portSpec{protocol: ProtocolUDP, ranges: []portRange{{119, 119}}}},
+		{spec: "udp 1000-2000,3000", expected: portSpec{protocol: ProtocolUDP, ranges: []portRange{{1000, 2000}, {3000, 3000}}}},
+		{spec: "UDP  1000 - 2000 , 3000 ", expected: portSpec{protocol: ProtocolUDP, ranges: []portRange{{1000, 2000}, {3000, 3000}}}},
+		{spec: "tcp *", expected: portSpec{protocol: ProtocolTCP, all: true}},
+		{spec: "Tcp\t*", expected: portSpec{protocol: ProtocolTCP, all: true}},
+
+		{spec: "", err: "missing port"},
+		{spec: "ABCD", err: "ABCD is neither a port, a port range nor *"},
+		{spec: "30-ABC", err: "ABC is neither a port, a port range nor *"},
+		{spec: "65536", err: "port 65536 is out of the 0-65535 range"},
+		{spec: "-1", err: "missing port"},
+		{spec: "2000-1000", err: "invalid port range 2000-1000, it ends before it starts"},
+		{spec: "80,", err: "missing port"},
+		{spec: "80,*", err: "* can't be combined with other ports"},
+		{spec: "sctp 80", err: "unknown protocol sctp, expected tcp or udp"},
+		{spec: "80 443", err: "unknown protocol 80, expected tcp or udp"},
+		{spec: "tcp", err: "no port given after protocol tcp, use tcp * for all the ports"},
+		{spec: "udp tcp 80", err: "tcp 80 is neither a port, a port range nor *"},
+	} {
+		spec, err := parsePortSpec(tc.spec)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, "%q", tc.spec)
+			continue
+		}
+		if assert.NoError(t, err, "%q", tc.spec) {
+			assert.Equal(t, tc.expected, spec, "%q", tc.spec)
+		}
+	}
+}
+
+func TestParseConnectionFiltersProtocol(t *testing.T) {
+	filters := ParseConnectionFilters(map[string][]string{
+		"10.0.0.0/8":       {"udp 1000-2000,3000", "tcp 53361-53500"},
+		"10.1.0.0/16":      {"tcp *", "udp 53"},
+		"2001:db8::/32":    {"udp 119", "443"},
+		"2001:db8:1::/48":  {"tcp *"},
+		"fd00::1":          {"udp *"},
+		"*":                {"udp 5353"},
+		"process:^ntpd$":   {"udp *"},
+		"192.168.0.0/16":   {"sctp 80"},  // invalid config
+		"172.16.0.0/12":    {"udp 80,*"}, // invalid config
+		"2001:db8:2::/48:": {"tcp 80"},   // invalid config
+	})
+	require.Len(t, filters, 7)
+	idx := NewConnectionFilterIndex(filters)
+	ntpd := &ConnectionProcess{Name: "ntpd"}
+
+	for _, tc := range []struct {
+		addr     string
+		port     uint16
+		protocol ConnectionProtocol
+		proc     *ConnectionProcess
+		matched  bool
+	}{
+		{"10.0.0.1", 1500, ProtocolUDP, nil, true},
+		{"10.0.0.1", 1500, ProtocolTCP, nil, false},
+		{"10.0.0.1", 3000, ProtocolUDP, nil, true},
+		{"10.0.0.1", 2500, ProtocolUDP, nil, false},
+		{"10.0.0.1", 53400, ProtocolTCP, nil, true},
+		{"10.0.0.1", 53400, ProtocolUDP, nil, false},
+		// the protocol specific ports don't match the ends of unknown protocol
+		{"10.0.0.1", 1500, AnyProtocol, nil, false},
+		// protocol specific wildcard on a CIDR
+		{"10.1.2.3", 8080, ProtocolTCP, nil, true},
+		{"10.1.2.3", 8080, ProtocolUDP, nil, false},
+		{"10.1.2.3", 53, ProtocolUDP, nil, true},
+		// the ranges of the shorter prefix still apply
+		{"10.1.2.3", 1500, ProtocolUDP, nil, true},
+		// IPv6 CIDRs
+		{"2001:db8:5::1", 119, ProtocolUDP, nil, true},
+		{"2001:db8:5::1", 119, ProtocolTCP, nil, false},
+		{"2001:db8:5::1", 443, ProtocolTCP, nil, true},
+		{"2001:db8:5::1", 443, ProtocolUDP, nil, true},
+		{"2001:db8:5::1", 443, AnyProtocol, nil, true},
+		{"2001:db8:1::1", 22, ProtocolTCP, nil, true},
+		{"2001:db8:1::1", 22, ProtocolUDP, nil, false},
+		{"2001:db9::1", 443, ProtocolTCP, nil, false},
+		{"fd00::2", 53, ProtocolUDP, nil, true}, // a single IPv6 address is a /64
+		{"fd00::2", 53, ProtocolTCP, nil, false},
+		{"fd00:0:0:1::1", 53, ProtocolUDP, nil, false},
+		// wildcard IP
+		{"8.8.8.8", 5353, ProtocolUDP, nil, true},
+		{"8.8.8.8", 5353, ProtocolTCP, nil, false},
+		{"2001:db9::1", 5353, ProtocolUDP, nil, true},
+		// process
+		{"8.8.8.8", 123, ProtocolUDP, ntpd, true},
+		{"8.8.8.8", 123, ProtocolTCP, ntpd, false},
+		// invalid configs
+		{"192.168.1.1", 80, ProtocolTCP, nil, false},
+		{"172.16.0.1", 80, ProtocolUDP, nil, false},
+		{"2001:db8:2::1", 80, ProtocolTCP, nil, false},
+	} {
+		end := ConnectionEnd{Addr: AddressFromString(tc.addr), Port: tc.port, Protocol: tc.protocol, Process: tc.proc}
+		assert.Equal(t, tc.matched, MatchConnectionFilters(filters, end), "%s:%d/%s", tc.addr, tc.port, tc.protocol)
+		assert.Equal(t, tc.matched, idx.Match(end), "%s:%d/%s", tc.addr, tc.port, tc.protocol)
+	}
+}
+
+func TestValidateConnectionFiltersProtocol(t *testing.T) {
+	rejected := ConnectionFilterRules{
+		DestExcludes: map[string][]string{
+			"*":             {"udp *"},
+			"10.0.0.0/8":    {"udp 1000-2000,3000", "icmp 8"},
+			"2001:db8::/32": {"tcp 443,65536"},
+		},
+	}.Validate()
+
+	assert.Equal(t, []RejectedConnectionFilter{
+		{List: DestExcludesList, Rule: "*", Port: "udp *", Reason: "IP/CIDR and port both * would exclude all the udp connections"},
+		{List: DestExcludesList, Rule: "10.0.0.0/8", Port: "icmp 8", Reason: "unknown protocol icmp, expected tcp or udp"},
+		{List: DestExcludesList, Rule: "2001:db8::/32", Port: "tcp 443,65536", Reason: "port 65536 is out of the 0-65535 range"},
+	}, rejected)
+}
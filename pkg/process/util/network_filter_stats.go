@@ -0,0 +1,90 @@
+package util
+
+import (
+	"sync"
+)
+
+// ConnectionFilterStats counts the connections excluded by the user defined
+// connection filters, in total and per rule, for the users to check that
+// their filters match the connections they expect
+type ConnectionFilterStats struct {
+	mu    sync.Mutex
+	total int64
+	// rules holds the counts per list, then per rule
+	rules map[string]map[string]int64
+}
+
+// ConnectionFilterCounts are the numbers of connections excluded by the
+// connection filters
+type ConnectionFilterCounts struct {
+	Total int64 `json:"total"`
+	// Rules holds the counts per list (SourceExcludesList, DestExcludesList
+	// or IncludesList), then per rule
+	Rules map[string]map[string]int64 `json:"rules"`
+}
+
+// NewConnectionFilterStats returns the stats of the configured filters,
+// which are reported even when they haven't excluded any connection yet
+func NewConnectionFilterStats(rules ConnectionFilterRules) *ConnectionFilterStats {
+	s := &ConnectionFilterStats{}
+	s.SetRules(rules)
+	return s
+}
+
+// SetRules updates the configured filters, after a reload: the counts of the
+// removed rules are dropped, the ones of the kept rules are kept
+func (s *ConnectionFilterStats) SetRules(rules ConnectionFilterRules) {
+	counts := map[string]map[string]int64{
+		SourceExcludesList: {},
+		DestExcludesList:   {},
+		IncludesList:       {},
+	}
+	for rule := range rules.SourceExcludes {
+		counts[SourceExcludesList][rule] = 0
+	}
+	for rule := range rules.DestExcludes {
+		counts[DestExcludesList][rule] = 0
+	}
+	if len(rules.Includes) > 0 {
+		counts[IncludesList][NoIncludeMatchRule] = 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for list, listCounts := range counts {
+		for rule := range listCounts {
+			listCounts[rule] = s.rules[list][rule]
+		}
+	}
+	s.rules = counts
+}
+
+// Add counts a connection excluded by a rule of a list
+func (s *ConnectionFilterStats) Add(list, rule string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total++
+	listCounts, ok := s.rules[list]
+	if !ok {
+		listCounts = make(map[string]int64)
+		s.rules[list] = listCounts
+	}
+	listCounts[rule]++
+}
+
+// Counts returns a copy of the counts
+func (s *ConnectionFilterStats) Counts() ConnectionFilterCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := ConnectionFilterCounts{
+		Total: s.total,
+		Rules: make(map[string]map[string]int64, len(s.rules)),
+	}
+	for list, listCounts := range s.rules {
+		counts.Rules[list] = make(map[string]int64, len(listCounts))
+		for rule, count := range listCounts {
+			counts.Rules[list][rule] = count
+		}
+	}
+	return counts
+}
@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
 )
 
 var testSourceFilters = map[string][]string{
@@ -28,24 +31,204 @@ func TestParseConnectionFilters(t *testing.T) {
 	sourceList := ParseConnectionFilters(testSourceFilters)
 	destList := ParseConnectionFilters(testDestinationFilters)
 
-	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("172.0.0.1"), uint16(10)))
-	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("*"), uint16(9000))) // only port 9000
-	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("10.0.1.24"), uint16(9000)))
-	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("::7f00:35:0:0"), uint16(443))) // ipv6
-	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("0"), uint16(443)))             // 0 == ::7f00:35:0:0
-	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("10.0.0.10"), uint16(6666)))    // port wildcard
-	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("10.0.0.10"), uint16(33)))
-	assert.False(t, IsBlacklistedConnection(sourceList, AddressFromString("10.0.0.25"), uint16(30))) // bad port config
-	assert.False(t, IsBlacklistedConnection(sourceList, AddressFromString("123.ABCD"), uint16(30)))  // bad IP config
+	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("172.0.0.1"), uint16(10), nil))
+	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("*"), uint16(9000), nil)) // only port 9000
+	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("10.0.1.24"), uint16(9000), nil))
+	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("::7f00:35:0:0"), uint16(443), nil)) // ipv6
+	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("0"), uint16(443), nil))             // 0 == ::7f00:35:0:0
+	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("10.0.0.10"), uint16(6666), nil))    // port wildcard
+	assert.True(t, IsBlacklistedConnection(sourceList, AddressFromString("10.0.0.10"), uint16(33), nil))
+	assert.False(t, IsBlacklistedConnection(sourceList, AddressFromString("10.0.0.25"), uint16(30), nil)) // bad port config
+	assert.False(t, IsBlacklistedConnection(sourceList, AddressFromString("123.ABCD"), uint16(30), nil))  // bad IP config
 
 	// destination
-	assert.True(t, IsBlacklistedConnection(destList, AddressFromString("10.0.0.5"), uint16(8080)))
-	assert.False(t, IsBlacklistedConnection(destList, AddressFromString("10.0.0.5"), uint16(80)))
-	assert.False(t, IsBlacklistedConnection(destList, AddressFromString(""), uint16(1234)))
-	assert.True(t, IsBlacklistedConnection(destList, AddressFromString("2001:db8::2:1"), uint16(5001))) // ipv6
-	assert.True(t, IsBlacklistedConnection(destList, AddressFromString("2001:db8::5:1"), uint16(80)))   // ipv6 CIDR
-	assert.False(t, IsBlacklistedConnection(destList, AddressFromString("*"), uint16(30)))
+	assert.True(t, IsBlacklistedConnection(destList, AddressFromString("10.0.0.5"), uint16(8080), nil))
+	assert.False(t, IsBlacklistedConnection(destList, AddressFromString("10.0.0.5"), uint16(80), nil))
+	assert.False(t, IsBlacklistedConnection(destList, AddressFromString(""), uint16(1234), nil))
+	assert.True(t, IsBlacklistedConnection(destList, AddressFromString("2001:db8::2:1"), uint16(5001), nil)) // ipv6
+	assert.True(t, IsBlacklistedConnection(destList, AddressFromString("2001:db8::5:1"), uint16(80), nil))   // ipv6 CIDR
+	assert.False(t, IsBlacklistedConnection(destList, AddressFromString("*"), uint16(30), nil))
+
+}
+
+func TestParseConnectionFiltersProcess(t *testing.T) {
+	filters := ParseConnectionFilters(map[string][]string{
+		"process:^chronyd$": {"*"},
+		"cmdline:ntpd .*-g": {"123"},
+		"process:[invalid":  {"*"},    // invalid config
+		"process:^dnsmasq$": {"ABCD"}, // invalid config
+		"10.0.0.1":          {"80"},
+	})
+	require.Len(t, filters, 3)
+	assert.True(t, HasProcessFilters(filters))
+	assert.False(t, HasProcessFilters(ParseConnectionFilters(testSourceFilters)))
+
+	chronyd := &ConnectionProcess{Name: "chronyd", Cmdline: "/usr/sbin/chronyd -F 2"}
+	ntpd := &ConnectionProcess{Name: "ntpd", Cmdline: "/usr/sbin/ntpd -p /var/run/ntpd.pid -g -u 112:116"}
+	curl := &ConnectionProcess{Name: "curl", Cmdline: "curl http://10.0.0.1"}
+
+	// all ports of chronyd
+	assert.True(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.5"), uint16(323), chronyd))
+	assert.True(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.5"), uint16(123), chronyd))
+	// only port 123 of ntpd
+	assert.True(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.5"), uint16(123), ntpd))
+	assert.False(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.5"), uint16(323), ntpd))
+	// the IP filters still apply
+	assert.True(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.1"), uint16(80), curl))
+	assert.False(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.2"), uint16(80), curl))
+	// unknown process
+	assert.False(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.5"), uint16(323), nil))
+	assert.True(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.1"), uint16(80), nil))
+}
+
+func TestParseConnectionFiltersDomain(t *testing.T) {
+	filters := ParseConnectionFilters(map[string][]string{
+		"dns:*.internal.corp":  {"443"},
+		"dns:metadata.google.": {"*"},
+		"dns:":                 {"*"},    // invalid config
+		"dns:*.example.com":    {"ABCD"}, // invalid config
+		"10.0.0.1":             {"80"},
+	})
+	require.Len(t, filters, 3)
+	assert.True(t, HasDomainFilters(filters))
+	assert.False(t, HasDomainFilters(ParseConnectionFilters(testSourceFilters)))
+
+	vault := AddressFromString("10.0.0.5")
+	assert.True(t, MatchConnectionFilters(filters, ConnectionEnd{Addr: vault, Port: 443, Names: []string{"vault.internal.corp"}}))
+	assert.True(t, MatchConnectionFilters(filters, ConnectionEnd{Addr: vault, Port: 443, Names: []string{"other.com", "Vault.Internal.Corp."}}))
+	assert.False(t, MatchConnectionFilters(filters, ConnectionEnd{Addr: vault, Port: 80, Names: []string{"vault.internal.corp"}}))
+	assert.False(t, MatchConnectionFilters(filters, ConnectionEnd{Addr: vault, Port: 443, Names: []string{"internal.corp"}}))
+	assert.True(t, MatchConnectionFilters(filters, ConnectionEnd{Addr: vault, Port: 80, Names: []string{"metadata.google"}}))
+	assert.False(t, MatchConnectionFilters(filters, ConnectionEnd{Addr: vault, Port: 443}))
+
+	// the domain filters don't match the addresses without names
+	assert.False(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.5"), uint16(443), nil))
+	assert.True(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.1"), uint16(80), nil))
+}
+
+func TestParseConnectionFiltersContainer(t *testing.T) {
+	config.Datadog.Set("kubernetes_pod_labels_as_tags", map[string]string{"app": "kube_app", "team-*": "+%%label%%"})
+	defer config.Datadog.Set("kubernetes_pod_labels_as_tags", map[string]string{})
+
+	filters := ParseConnectionFilters(map[string][]string{
+		"image:istio/proxyv2":    {"15090"},
+		"kube_namespace:kube-*":  {"*"},
+		"pod_label:app=frontend": {"8080"},
+		"pod_label:team-web=*":   {"9000"},
+		"pod_label:version=v2":   {"*"}, // invalid config, the label isn't a tag
+		"pod_label:app":          {"*"}, // invalid config
+		"image:":                 {"*"}, // invalid config
+		"10.0.0.1":               {"80"},
+	})
+	require.Len(t, filters, 5)
+	assert.True(t, HasContainerFilters(filters))
+	assert.False(t, HasProcessFilters(filters))
+	assert.False(t, HasContainerFilters(ParseConnectionFilters(testSourceFilters)))
+
+	addr := AddressFromString("10.0.0.5")
+	envoy := &ConnectionProcess{Name: "envoy", Tags: []string{"image_name:istio/proxyv2", "short_image:proxyv2", "kube_namespace:default"}}
+	coredns := &ConnectionProcess{Name: "coredns", Tags: []string{"image_name:coredns/coredns", "kube_namespace:kube-system"}}
+	frontend := &ConnectionProcess{Name: "node", Tags: []string{"kube_app:frontend", "team-web:checkout", "kube_namespace:default"}}
+	host := &ConnectionProcess{Name: "sshd"}
+
+	assert.True(t, IsBlacklistedConnection(filters, addr, uint16(15090), envoy))
+	assert.False(t, IsBlacklistedConnection(filters, addr, uint16(15001), envoy))
+	assert.True(t, IsBlacklistedConnection(filters, addr, uint16(53), coredns))
+	assert.True(t, IsBlacklistedConnection(filters, addr, uint16(8080), frontend))
+	assert.True(t, IsBlacklistedConnection(filters, addr, uint16(9000), frontend))
+	assert.False(t, IsBlacklistedConnection(filters, addr, uint16(8081), frontend))
+	// the processes out of the containers, or unknown, don't match
+	assert.False(t, IsBlacklistedConnection(filters, addr, uint16(15090), host))
+	assert.False(t, IsBlacklistedConnection(filters, addr, uint16(15090), nil))
+	assert.True(t, IsBlacklistedConnection(filters, AddressFromString("10.0.0.1"), uint16(80), host))
+
+	set := NewConnectionFilterSet(ConnectionFilterRules{SourceExcludes: map[string][]string{"image:istio/*": {"15090"}}})
+	assert.True(t, set.HasContainerFilters())
+	assert.False(t, set.HasProcessFilters())
+}
+
+func TestConnectionFilterSetIncludes(t *testing.T) {
+	set := NewConnectionFilterSet(ConnectionFilterRules{
+		DestExcludes: map[string][]string{"10.0.1.1": {"5432"}},
+		Includes: map[string][]string{
+			"10.0.1.0/24":     {"5432", "6379"},
+			"process:^nginx$": {"*"},
+		},
+	})
+	assert.True(t, set.HasProcessFilters())
+	assert.False(t, set.HasDomainFilters())
+
+	local := ConnectionEnd{Addr: AddressFromString("10.0.0.1"), Port: 40000}
+	for _, tc := range []struct {
+		source, dest ConnectionEnd
+		excluded     bool
+	}{
+		// included destination
+		{local, ConnectionEnd{Addr: AddressFromString("10.0.1.2"), Port: 6379}, false},
+		// not included
+		{local, ConnectionEnd{Addr: AddressFromString("10.0.1.2"), Port: 80}, true},
+		{local, ConnectionEnd{Addr: AddressFromString("10.0.2.2"), Port: 6379}, true},
+		// the exclusions take precedence
+		{local, ConnectionEnd{Addr: AddressFromString("10.0.1.1"), Port: 5432}, true},
+		// included source, e.g. an incoming connection
+		{ConnectionEnd{Addr: AddressFromString("10.0.1.2"), Port: 5432}, ConnectionEnd{Addr: AddressFromString("10.0.3.3"), Port: 50000}, false},
+	} {
+		assert.Equal(t, tc.excluded, set.IsExcluded(tc.source, tc.dest), "%v -> %v", tc.source, tc.dest)
+	}
+
+	// included process
+	nginx := &ConnectionProcess{Name: "nginx", Cmdline: "nginx: worker process"}
+	source := ConnectionEnd{Addr: AddressFromString("10.0.0.1"), Port: 40000, Process: nginx}
+	dest := ConnectionEnd{Addr: AddressFromString("10.0.2.2"), Port: 80, Process: nginx}
+	assert.False(t, set.IsExcluded(source, dest))
+
+	// no inclusions
+	set = NewConnectionFilterSet(ConnectionFilterRules{DestExcludes: map[string][]string{"10.0.1.1": {"5432"}}})
+	assert.False(t, set.IsExcluded(local, ConnectionEnd{Addr: AddressFromString("10.0.2.2"), Port: 80}))
+}
+
+func TestValidateConnectionFilters(t *testing.T) {
+	rejected := ConnectionFilterRules{
+		SourceExcludes: testSourceFilters,
+		DestExcludes:   testDestinationFilters,
+		Includes: map[string][]string{
+			"10.0.0.0/8":        {"30-ABC", "65536", "443"},
+			"10.1.0.0/16":       {"9000-8000"},
+			"10.2.0.0/16":       {},
+			"process:[invalid":  {"*"},
+			"process:^chronyd$": {"*"},
+		},
+	}.Validate()
+
+	assert.Equal(t, []RejectedConnectionFilter{
+		{List: SourceExcludesList, Rule: "10.0.0.25", Port: "ABCD", Reason: "ABCD is neither a port, a port range nor *"},
+		{List: SourceExcludesList, Rule: "123.ABCD", Reason: "invalid IPv4 address"},
+		{List: DestExcludesList, Rule: "", Reason: "neither an IP, a CIDR, * nor a process:, cmdline:, dns:, image:, kube_namespace: or pod_label: filter"},
+		{List: DestExcludesList, Rule: "*", Port: "*", Reason: "IP/CIDR and port both * would exclude all the connections"},
+		{List: IncludesList, Rule: "10.0.0.0/8", Port: "30-ABC", Reason: "ABC is neither a port, a port range nor *"},
+		{List: IncludesList, Rule: "10.0.0.0/8", Port: "65536", Reason: "port 65536 is out of the 0-65535 range"},
+		{List: IncludesList, Rule: "10.1.0.0/16", Port: "9000-8000", Reason: "invalid port range 9000-8000, it ends before it starts"},
+		{List: IncludesList, Rule: "10.2.0.0/16", Reason: "no port given, use * for all the ports"},
+		{List: IncludesList, Rule: "process:[invalid", Reason: "error parsing regexp: missing closing ]: `[invalid`"},
+	}, rejected)
+	assert.Equal(t, "includes 10.0.0.0/8, port 65536: port 65536 is out of the 0-65535 range", rejected[5].String())
+
+	assert.Empty(t, ConnectionFilterRules{SourceExcludes: map[string][]string{"10.0.0.0/8": {"*"}}}.Validate())
+}
+
+func TestDiffConnectionFilters(t *testing.T) {
+	added, removed, changed := DiffConnectionFilters(
+		map[string][]string{"10.0.0.1": {"80"}, "*": {"9000"}, "process:^chronyd$": {"*"}},
+		map[string][]string{"10.0.0.1": {"80", "443"}, "*": {"9000"}, "cmdline:ntpd": {"123"}},
+	)
+	assert.Equal(t, []string{"cmdline:ntpd"}, added)
+	assert.Equal(t, []string{"process:^chronyd$"}, removed)
+	assert.Equal(t, []string{"10.0.0.1"}, changed)
 
+	added, removed, changed = DiffConnectionFilters(nil, nil)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+	assert.Empty(t, changed)
 }
 
 var sink bool
@@ -59,8 +242,8 @@ func BenchmarkIsBlacklistedConnectionIPv4(b *testing.B) {
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		for _, addr := range addrs {
-			sink = IsBlacklistedConnection(sourceList, addr, uint16(rand.Intn(9999)))
-			sink = IsBlacklistedConnection(destList, addr, uint16(rand.Intn(9999)))
+			sink = IsBlacklistedConnection(sourceList, addr, uint16(rand.Intn(9999)), nil)
+			sink = IsBlacklistedConnection(destList, addr, uint16(rand.Intn(9999)), nil)
 		}
 	}
 
@@ -75,8 +258,8 @@ func BenchmarkIsBlacklistedConnectionIPv6(b *testing.B) {
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		for _, addr := range addrs {
-			sink = IsBlacklistedConnection(sourceList, addr, uint16(rand.Intn(9999)))
-			sink = IsBlacklistedConnection(destList, addr, uint16(rand.Intn(9999)))
+			sink = IsBlacklistedConnection(sourceList, addr, uint16(rand.Intn(9999)), nil)
+			sink = IsBlacklistedConnection(destList, addr, uint16(rand.Intn(9999)), nil)
 		}
 	}
 }
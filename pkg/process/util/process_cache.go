@@ -0,0 +1,134 @@
+package util
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/gopsutil/process"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ConnectionProcessCache resolves the processes owning the connections, for
+// the process and container filters. The processes are cached for a TTL, a
+// reused PID may be matched against the previous process until then.
+type ConnectionProcessCache struct {
+	mux       sync.Mutex
+	ttl       time.Duration
+	processes map[uint32]*cachedConnectionProcess
+	lastPurge time.Time
+	// lookup and lookupTags are overridden in the tests, lookupTags is nil
+	// when the container tags aren't needed
+	lookup     func(pid uint32) (*ConnectionProcess, error)
+	lookupTags func(pid uint32) ([]string, error)
+}
+
+type cachedConnectionProcess struct {
+	proc    *ConnectionProcess
+	fetched time.Time
+	// tagged is whether the container tags of the process were looked up
+	tagged bool
+}
+
+// NewConnectionProcessCache returns a cache of the processes owning the
+// connections, with the tags of their container if containerTags is set
+func NewConnectionProcessCache(ttl time.Duration, containerTags bool) *ConnectionProcessCache {
+	c := &ConnectionProcessCache{
+		ttl:       ttl,
+		processes: make(map[uint32]*cachedConnectionProcess),
+		lastPurge: time.Now(),
+		lookup:    lookupConnectionProcess,
+	}
+	if containerTags {
+		initContainerTags()
+		c.lookupTags = lookupContainerTags
+	}
+	return c
+}
+
+// Get returns the process of a PID, nil if it's gone or can't be read
+func (c *ConnectionProcessCache) Get(pid uint32) *ConnectionProcess {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.lastPurge) > c.ttl {
+		for p, cached := range c.processes {
+			if now.Sub(cached.fetched) > c.ttl {
+				delete(c.processes, p)
+			}
+		}
+		c.lastPurge = now
+	}
+
+	if cached, ok := c.processes[pid]; ok && now.Sub(cached.fetched) <= c.ttl {
+		return cached.proc
+	}
+	proc, err := c.lookup(pid)
+	if err != nil {
+		// cache the failure too, the process is likely gone
+		proc = nil
+	}
+	c.processes[pid] = &cachedConnectionProcess{proc: proc, fetched: now}
+	return proc
+}
+
+// ResolveTags looks up the container tags of the processes cached since its
+// last call, without holding the lock of the cache. The container filters
+// don't match a process until its tags are resolved, by the next poll, or
+// until it is looked up again if they can't be resolved yet.
+func (c *ConnectionProcessCache) ResolveTags() {
+	if c.lookupTags == nil {
+		return
+	}
+
+	c.mux.Lock()
+	var pids []uint32
+	for pid, cached := range c.processes {
+		if cached.proc != nil && !cached.tagged {
+			pids = append(pids, pid)
+		}
+	}
+	c.mux.Unlock()
+	if len(pids) == 0 {
+		return
+	}
+
+	tags := make(map[uint32][]string, len(pids))
+	for _, pid := range pids {
+		t, err := c.lookupTags(pid)
+		if err != nil {
+			log.Debugf("could not get the container tags of process %d: %s", pid, err)
+		}
+		tags[pid] = t
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for pid, t := range tags {
+		cached, ok := c.processes[pid]
+		if !ok || cached.proc == nil || cached.tagged {
+			continue
+		}
+		// the filters may still read the previous process
+		proc := *cached.proc
+		proc.Tags = t
+		cached.proc, cached.tagged = &proc, true
+	}
+}
+
+func lookupConnectionProcess(pid uint32) (*ConnectionProcess, error) {
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.Name()
+	if err != nil {
+		return nil, err
+	}
+	cmdline, err := p.Cmdline()
+	if err != nil {
+		return nil, err
+	}
+	return &ConnectionProcess{Name: name, Cmdline: cmdline}, nil
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionProcessCache(t *testing.T) {
+	c := NewConnectionProcessCache(time.Minute, false)
+	lookups := 0
+	c.lookup = func(pid uint32) (*ConnectionProcess, error) {
+		lookups++
+		if pid == 2 {
+			return nil, errors.New("no such process")
+		}
+		return &ConnectionProcess{Name: "chronyd"}, nil
+	}
+
+	assert.Equal(t, "chronyd", c.Get(1).Name)
+	assert.Equal(t, "chronyd", c.Get(1).Name)
+	assert.Nil(t, c.Get(2))
+	assert.Nil(t, c.Get(2))
+	assert.Equal(t, 2, lookups)
+
+	// expired
+	c.processes[1].fetched = time.Now().Add(-2 * time.Minute)
+	c.lastPurge = time.Now().Add(-2 * time.Minute)
+	assert.Equal(t, "chronyd", c.Get(1).Name)
+	assert.Equal(t, 3, lookups)
+	assert.Len(t, c.processes, 2)
+}
+
+func TestConnectionProcessCacheContainerTags(t *testing.T) {
+	c := NewConnectionProcessCache(time.Minute, false)
+	c.lookup = func(pid uint32) (*ConnectionProcess, error) {
+		if pid == 2 {
+			return nil, errors.New("no such process")
+		}
+		return &ConnectionProcess{Name: "envoy"}, nil
+	}
+	c.lookupTags = func(pid uint32) ([]string, error) {
+		if pid == 3 {
+			return nil, errors.New("no container")
+		}
+		return []string{"image_name:istio/proxyv2"}, nil
+	}
+
+	// the tags are unknown until they are resolved
+	assert.Empty(t, c.Get(1).Tags)
+	assert.Nil(t, c.Get(2))
+	assert.Equal(t, "envoy", c.Get(3).Name)
+
+	c.ResolveTags()
+	assert.Equal(t, []string{"image_name:istio/proxyv2"}, c.Get(1).Tags)
+	assert.Nil(t, c.Get(2))
+	// the process is still matched by the other filters
+	assert.Equal(t, "envoy", c.Get(3).Name)
+	assert.Empty(t, c.Get(3).Tags)
+
+	// the tags are only looked up once
+	c.lookupTags = func(pid uint32) ([]string, error) {
+		t.Errorf("unexpected lookup of the tags of process %d", pid)
+		return nil, nil
+	}
+	c.ResolveTags()
+}
+
+func TestLookupConnectionProcess(t *testing.T) {
+	proc, err := lookupConnectionProcess(uint32(os.Getpid()))
+	require.NoError(t, err)
+	assert.NotEmpty(t, proc.Name)
+	assert.NotEmpty(t, proc.Cmdline)
+}
@@ -79,7 +79,8 @@ var runCommand = execCommand
 
 // fetchSecret receives a list of secrets name to fetch, exec a custom
 // executable to fetch the actual secrets and returns them. Origin should be
-// the name of the configuration where the secret was referenced.
+// the name of the configuration where the secret was referenced. It adds the
+// secrets to the cache, secretCacheMux must be held.
 func fetchSecret(secretsHandle []string, origin string) (map[string]string, error) {
 	payload := map[string]interface{}{
 		"version": payloadVersion,
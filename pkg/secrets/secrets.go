@@ -10,6 +10,7 @@ package secrets
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -18,7 +19,10 @@ import (
 )
 
 var (
-	secretCache map[string]string
+	// secretCacheMux guards secretCache and secretOrigin, the configurations
+	// being decrypted from several goroutines
+	secretCacheMux sync.Mutex
+	secretCache    map[string]string
 	// list of handles and where they were found
 	secretOrigin map[string]common.StringSet
 
@@ -126,6 +130,11 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 		return data, nil
 	}
 
+	// Held until the new secrets are in the cache, which also avoids running
+	// the backend command concurrently for the same handles
+	secretCacheMux.Lock()
+	defer secretCacheMux.Unlock()
+
 	var config interface{}
 	err := yaml.Unmarshal(data, &config)
 	if err != nil {
@@ -198,6 +207,8 @@ func GetDebugInfo() (*SecretInfo, error) {
 	info := &SecretInfo{ExecutablePath: secretBackendCommand}
 	info.populateRights()
 
+	secretCacheMux.Lock()
+	defer secretCacheMux.Unlock()
 	info.SecretsHandles = map[string][]string{}
 	for handle, originNames := range secretOrigin {
 		info.SecretsHandles[handle] = originNames.GetAll()
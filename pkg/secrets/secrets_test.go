@@ -10,6 +10,8 @@ package secrets
 import (
 	"fmt"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/util/common"
@@ -261,6 +263,44 @@ func TestDecryptSecretFullCache(t *testing.T) {
 	assert.Equal(t, testConfDecrypted, newConf)
 }
 
+func TestDecryptConcurrent(t *testing.T) {
+	secretBackendCommand = "some_command"
+	defer func() {
+		secretBackendCommand = ""
+		secretCache = map[string]string{}
+		secretOrigin = map[string]common.StringSet{}
+		secretFetcher = fetchSecret
+	}()
+
+	var calls int32
+	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
+		atomic.AddInt32(&calls, 1)
+		res := map[string]string{}
+		for _, sec := range secrets {
+			res[sec] = "password"
+			secretCache[sec] = "password"
+			secretOrigin[sec] = common.NewStringSet(origin)
+		}
+		return res, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := Decrypt(testConf, fmt.Sprintf("test%d", i))
+			assert.NoError(t, err)
+			_, err = GetDebugInfo()
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	// the secrets are only fetched by the first decryption
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
 func TestDebugInfo(t *testing.T) {
 	secretBackendCommand = "some_command"
 
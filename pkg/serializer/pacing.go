@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package serializer
+
+import (
+	"expvar"
+	"net/http"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	serializerExpvars            = expvar.NewMap("serializer")
+	expvarsMaxSeriesPerPayload   = expvar.Int{}
+	expvarsSeriesPacingMs        = expvar.Int{}
+	expvarsSeriesLastFlushPacing = expvar.Int{}
+	expvarsSeriesPacedRequests   = expvar.Int{}
+	expvarsSeriesChunks          = expvar.Int{}
+
+	// sleep is overridden in the tests
+	sleep = time.Sleep
+	// maxSeriesFlushPacing bounds the pacing of the payloads of a flush,
+	// below the 15s flush interval of the aggregator so that the paced
+	// submissions of a flush are over before the next one
+	maxSeriesFlushPacing = 10 * time.Second
+)
+
+func init() {
+	serializerExpvars.Set("MaxSeriesPerPayload", &expvarsMaxSeriesPerPayload)
+	serializerExpvars.Set("SeriesPayloadPacingMs", &expvarsSeriesPacingMs)
+	serializerExpvars.Set("SeriesLastFlushPacingMs", &expvarsSeriesLastFlushPacing)
+	serializerExpvars.Set("SeriesPacedRequests", &expvarsSeriesPacedRequests)
+	serializerExpvars.Set("SeriesChunks", &expvarsSeriesChunks)
+}
+
+// splitSeries splits the series in chunks of at most maxSeriesPerPayload
+// series, each of them being serialized in its own payloads. Payloads that
+// aren't series are left as is.
+func (s *Serializer) splitSeries(series marshaler.StreamJSONMarshaler) []marshaler.StreamJSONMarshaler {
+	all, ok := series.(metrics.Series)
+	if !ok || s.maxSeriesPerPayload <= 0 || len(all) <= s.maxSeriesPerPayload {
+		return []marshaler.StreamJSONMarshaler{series}
+	}
+
+	chunks := make([]marshaler.StreamJSONMarshaler, 0, len(all)/s.maxSeriesPerPayload+1)
+	for start := 0; start < len(all); start += s.maxSeriesPerPayload {
+		end := start + s.maxSeriesPerPayload
+		if end > len(all) {
+			end = len(all)
+		}
+		chunks = append(chunks, all[start:end])
+	}
+	expvarsSeriesChunks.Add(int64(len(chunks)))
+	return chunks
+}
+
+// submitPacedSeries hands the first series payload over to the forwarder, and
+// the others one at a time from a goroutine, waiting for the pacing delay in
+// between so that the HTTP requests of a large flush are spread over time
+// instead of hitting the intake at once. The delay is shortened so that the
+// total pacing stays within maxSeriesFlushPacing.
+func (s *Serializer) submitPacedSeries(payloads forwarder.Payloads, extraHeaders http.Header, submit func(forwarder.Payloads, http.Header) error) error {
+	if s.seriesPayloadPacing <= 0 || len(payloads) <= 1 {
+		expvarsSeriesLastFlushPacing.Set(0)
+		return submit(payloads, extraHeaders)
+	}
+
+	pacing := s.seriesPayloadPacing
+	if pacing*time.Duration(len(payloads)-1) > maxSeriesFlushPacing {
+		pacing = maxSeriesFlushPacing / time.Duration(len(payloads)-1)
+	}
+	expvarsSeriesLastFlushPacing.Set(int64(pacing * time.Duration(len(payloads)-1) / time.Millisecond))
+
+	// the error of the first payload is returned to the caller
+	if err := submit(payloads[:1], extraHeaders); err != nil {
+		return err
+	}
+	go func() {
+		for i, payload := range payloads[1:] {
+			sleep(pacing)
+			expvarsSeriesPacedRequests.Add(1)
+			if err := submit(forwarder.Payloads{payload}, extraHeaders); err != nil {
+				log.Warnf("Dropping the %d remaining paced series payloads: %s", len(payloads)-1-i, err)
+				return
+			}
+		}
+	}()
+	return nil
+}
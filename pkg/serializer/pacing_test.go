@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package serializer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestSendSeriesMaxPerPayloadAndPacing(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("use_v2_api.series", true)
+	mockConfig.Set("serializer_max_series_per_payload", 2)
+	mockConfig.Set("serializer_series_payload_pacing_ms", 50)
+	defer func() {
+		mockConfig.Set("use_v2_api.series", nil)
+		mockConfig.Set("serializer_max_series_per_payload", 0)
+		mockConfig.Set("serializer_series_payload_pacing_ms", 0)
+	}()
+
+	for name, tc := range map[string]struct {
+		maxPacing time.Duration
+		slept     time.Duration
+		pacingMs  int64
+	}{
+		"paced":  {time.Second, 50 * time.Millisecond, 100},
+		"capped": {60 * time.Millisecond, 30 * time.Millisecond, 60},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func(max time.Duration) { maxSeriesFlushPacing = max }(maxSeriesFlushPacing)
+			maxSeriesFlushPacing = tc.maxPacing
+
+			var slept []time.Duration
+			sleep = func(d time.Duration) { slept = append(slept, d) }
+			defer func() { sleep = time.Sleep }()
+
+			submitted := make(chan struct{}, 3)
+			f := &forwarder.MockedForwarder{}
+			f.On("SubmitSeries", mock.AnythingOfType("forwarder.Payloads"), protobufExtraHeadersWithCompression).Return(nil).Times(3).Run(func(mock.Arguments) {
+				submitted <- struct{}{}
+			})
+
+			s := NewSerializer(f)
+			series := metrics.Series{}
+			for i := 0; i < 5; i++ {
+				series = append(series, &metrics.Serie{Name: "test.metric", Points: []metrics.Point{{Ts: 1, Value: float64(i)}}})
+			}
+
+			// the first request is sent right away, the others are paced in
+			// the background
+			require.NoError(t, s.SendSeries(series))
+			for i := 0; i < 3; i++ {
+				select {
+				case <-submitted:
+				case <-time.After(5 * time.Second):
+					require.FailNow(t, "the paced payloads weren't submitted")
+				}
+			}
+			f.AssertExpectations(t)
+
+			// one request per chunk of 2 series, paced in between
+			for _, call := range f.Calls {
+				assert.Len(t, call.Arguments.Get(0).(forwarder.Payloads), 1)
+			}
+			assert.Equal(t, []time.Duration{tc.slept, tc.slept}, slept)
+			assert.Equal(t, tc.pacingMs, expvarsSeriesLastFlushPacing.Value())
+		})
+	}
+}
+
+func TestSplitSeriesWithoutLimit(t *testing.T) {
+	s := &Serializer{}
+	series := metrics.Series{&metrics.Serie{}, &metrics.Serie{}}
+	assert.Len(t, s.splitSeries(series), 1)
+
+	s.maxSeriesPerPayload = 1
+	assert.Len(t, s.splitSeries(series), 2)
+	assert.Len(t, s.splitSeries(&testPayload{}), 1)
+}
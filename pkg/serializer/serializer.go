@@ -10,12 +10,15 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/serializer/jsonstream"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
+	"github.com/DataDog/datadog-agent/pkg/status/canary"
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -86,6 +89,12 @@ type Serializer struct {
 
 	seriesPayloadBuilder *jsonstream.PayloadBuilder
 
+	// maxSeriesPerPayload and seriesPayloadPacing spread the series of a
+	// large flush over several HTTP requests sent at a given pace, to avoid
+	// tripping the intake rate limits
+	maxSeriesPerPayload int
+	seriesPayloadPacing time.Duration
+
 	// Those variables allow users to blacklist any kind of payload
 	// from being sent by the agent. This was introduced for
 	// environment where, for example, events or serviceChecks
@@ -106,6 +115,8 @@ func NewSerializer(forwarder forwarder.Forwarder) *Serializer {
 	s := &Serializer{
 		Forwarder:                     forwarder,
 		seriesPayloadBuilder:          jsonstream.NewPayloadBuilder(),
+		maxSeriesPerPayload:           config.Datadog.GetInt("serializer_max_series_per_payload"),
+		seriesPayloadPacing:           time.Duration(config.Datadog.GetInt("serializer_series_payload_pacing_ms")) * time.Millisecond,
 		enableEvents:                  config.Datadog.GetBool("enable_payloads.events"),
 		enableSeries:                  config.Datadog.GetBool("enable_payloads.series"),
 		enableServiceChecks:           config.Datadog.GetBool("enable_payloads.service_checks"),
@@ -131,6 +142,9 @@ func NewSerializer(forwarder forwarder.Forwarder) *Serializer {
 		log.Warn("JSON to V1 intake is disabled: all payloads to that endpoint will be dropped")
 	}
 
+	expvarsMaxSeriesPerPayload.Set(int64(s.maxSeriesPerPayload))
+	expvarsSeriesPacingMs.Set(int64(s.seriesPayloadPacing / time.Millisecond))
+
 	return s
 }
 
@@ -228,22 +242,48 @@ func (s *Serializer) SendSeries(series marshaler.StreamJSONMarshaler) error {
 
 	var seriesPayloads forwarder.Payloads
 	var extraHeaders http.Header
-	var err error
 
-	if useV1API && s.enableJSONStream {
-		seriesPayloads, extraHeaders, err = s.serializeStreamablePayload(series)
-	} else {
-		seriesPayloads, extraHeaders, err = s.serializePayload(series, true, useV1API)
-	}
+	for _, chunk := range s.splitSeries(series) {
+		var payloads forwarder.Payloads
+		var err error
 
-	if err != nil {
-		return fmt.Errorf("dropping series payload: %s", err)
+		if useV1API && s.enableJSONStream {
+			payloads, extraHeaders, err = s.serializeStreamablePayload(chunk)
+		} else {
+			payloads, extraHeaders, err = s.serializePayload(chunk, true, useV1API)
+		}
+
+		if err != nil {
+			return fmt.Errorf("dropping series payload: %s", err)
+		}
+		seriesPayloads = append(seriesPayloads, payloads...)
 	}
 
+	submit := s.Forwarder.SubmitSeries
 	if useV1API {
-		return s.Forwarder.SubmitV1Series(seriesPayloads, extraHeaders)
+		submit = s.Forwarder.SubmitV1Series
+	}
+	err := s.submitPacedSeries(seriesPayloads, extraHeaders, submit)
+	if err == nil {
+		observeCanary(series)
+	}
+	return err
+}
+
+// observeCanary reports the canary metrics of the series handed over to the
+// forwarder
+func observeCanary(series marshaler.StreamJSONMarshaler) {
+	s, ok := series.(metrics.Series)
+	if !ok {
+		return
+	}
+	for _, serie := range s {
+		if serie.Name == canary.MetricName {
+			for _, point := range serie.Points {
+				canary.ObserveValue(canary.Metrics, point.Value)
+			}
+		}
 	}
-	return s.Forwarder.SubmitSeries(seriesPayloads, extraHeaders)
 }
 
 // SendSketch serializes a list of SketSeriesList and sends the payload to the forwarder
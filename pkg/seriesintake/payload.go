@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package seriesintake
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// Metric types of the v2 series API
+const (
+	typeUnspecified = 0
+	typeCount       = 1
+	typeRate        = 2
+	typeGauge       = 3
+)
+
+// payload is the subset of a `/api/v2/series` payload handled by the intake
+type payload struct {
+	Series []serie `json:"series"`
+}
+
+type serie struct {
+	Metric    string     `json:"metric"`
+	Type      int        `json:"type"`
+	Points    []point    `json:"points"`
+	Tags      []string   `json:"tags"`
+	Resources []resource `json:"resources"`
+}
+
+type point struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+type resource struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// host returns the name of the host resource of the serie, if any
+func (s *serie) host() string {
+	for _, r := range s.Resources {
+		if r.Type == "host" {
+			return r.Name
+		}
+	}
+	return ""
+}
+
+// toSamples converts the series of a payload to metric samples, adding
+// extraTags to their own tags
+func (p *payload) toSamples(defaultHostname string, extraTags []string) ([]*metrics.MetricSample, error) {
+	var samples []*metrics.MetricSample
+	now := time.Now().Unix()
+	for i := range p.Series {
+		s := &p.Series[i]
+		if s.Metric == "" {
+			return nil, fmt.Errorf("serie %d has no metric name", i)
+		}
+
+		var mtype metrics.MetricType
+		switch s.Type {
+		case typeCount:
+			mtype = metrics.CountType
+		case typeUnspecified, typeGauge:
+			mtype = metrics.GaugeType
+		case typeRate:
+			// the points of a rate are per second already, unlike the
+			// samples of the RateType the aggregator derives
+			mtype = metrics.GaugeType
+		default:
+			return nil, fmt.Errorf("serie %s has an unknown type %d", s.Metric, s.Type)
+		}
+
+		host := s.host()
+		if host == "" {
+			host = defaultHostname
+		}
+
+		tags := make([]string, 0, len(s.Tags)+len(extraTags))
+		tags = append(tags, s.Tags...)
+		tags = append(tags, extraTags...)
+
+		for _, pt := range s.Points {
+			timestamp := pt.Timestamp
+			if timestamp == 0 {
+				timestamp = now
+			}
+			samples = append(samples, &metrics.MetricSample{
+				Name:       s.Metric,
+				Value:      pt.Value,
+				Mtype:      mtype,
+				Tags:       tags,
+				Host:       host,
+				SampleRate: 1,
+				Timestamp:  float64(timestamp),
+			})
+		}
+	}
+	return samples, nil
+}
@@ -0,0 +1,212 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+/*
+Package seriesintake implements a local HTTP endpoint accepting the series of
+the `/api/v2/series` Datadog API, for the host applications that can't use a
+DogStatsD client. The series are sent to the aggregator like the DogStatsD
+metrics.
+*/
+package seriesintake
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	stdLog "log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/buffer"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// originTag is added to every serie received, to tell them from the metrics
+// sent by DogStatsD or the checks
+const originTag = "origin:series_intake"
+
+var (
+	seriesIntakeExpvars     = expvar.NewMap("seriesintake")
+	seriesIntakePayloads    = expvar.Int{}
+	seriesIntakePoints      = expvar.Int{}
+	seriesIntakeParseErrors = expvar.Int{}
+	seriesIntakeAuthErrors  = expvar.Int{}
+	seriesIntakeDrops       = expvar.Int{}
+)
+
+func init() {
+	seriesIntakeExpvars.Set("Payloads", &seriesIntakePayloads)
+	seriesIntakeExpvars.Set("Points", &seriesIntakePoints)
+	seriesIntakeExpvars.Set("ParseErrors", &seriesIntakeParseErrors)
+	seriesIntakeExpvars.Set("AuthErrors", &seriesIntakeAuthErrors)
+	seriesIntakeExpvars.Set("Drops", &seriesIntakeDrops)
+}
+
+// Server represents a series intake server
+type Server struct {
+	server          *http.Server
+	metricOut       chan<- []*metrics.MetricSample
+	metricOutBuffer *buffer.Buffer
+	apiKey          string
+	defaultHostname string
+	extraTags       []string
+	maxPayloadSize  int64
+}
+
+// NewServer returns a running series intake server, listening on
+// `series_intake.port` of `bind_host`
+func NewServer(metricOut chan<- []*metrics.MetricSample) (*Server, error) {
+	apiKey := config.Datadog.GetString("api_key")
+	if apiKey == "" {
+		return nil, fmt.Errorf("no api_key configured to authenticate the requests")
+	}
+
+	address := fmt.Sprintf("%s:%d", config.Datadog.GetString("bind_host"), config.Datadog.GetInt("series_intake.port"))
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen on %s: %v", address, err)
+	}
+
+	defaultHostname, err := util.GetHostname()
+	if err != nil {
+		log.Errorf("Series intake: unable to determine default hostname: %s", err.Error())
+	}
+
+	s := newServer(metricOut, apiKey, defaultHostname)
+	s.metricOutBuffer = buffer.Get(aggregator.MetricSamplesBufferName)
+	s.server = &http.Server{
+		Handler: s.router(),
+		ErrorLog: stdLog.New(&config.ErrorLogWriter{
+			AdditionalDepth: 4, // Use a stack depth of 4 on top of the default one to get a relevant filename in the stdlib
+		}, "Error from the series intake server: ", 0), // log errors to seelog,
+		ReadTimeout:  config.Datadog.GetDuration("server_timeout") * time.Second,
+		WriteTimeout: config.Datadog.GetDuration("server_timeout") * time.Second,
+	}
+	go s.server.Serve(listener)
+
+	log.Infof("Series intake listening on %s", address)
+	return s, nil
+}
+
+func newServer(metricOut chan<- []*metrics.MetricSample, apiKey, defaultHostname string) *Server {
+	extraTags := append(config.Datadog.GetStringSlice("series_intake.tags"), originTag)
+	return &Server{
+		metricOut:       metricOut,
+		apiKey:          apiKey,
+		defaultHostname: defaultHostname,
+		extraTags:       extraTags,
+		maxPayloadSize:  config.Datadog.GetInt64("series_intake.max_payload_size"),
+	}
+}
+
+func (s *Server) router() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/api/v2/series", s.handleSeries).Methods("POST")
+	return r
+}
+
+// Stop stops the server
+func (s *Server) Stop() {
+	if s.server != nil {
+		s.server.Close()
+	}
+}
+
+func (s *Server) handleSeries(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("DD-API-KEY")
+	if subtle.ConstantTimeCompare([]byte(key), []byte(s.apiKey)) != 1 {
+		seriesIntakeAuthErrors.Add(1)
+		writeErrors(w, http.StatusForbidden, "Forbidden")
+		return
+	}
+
+	body, err := s.readBody(r)
+	if err != nil {
+		seriesIntakeParseErrors.Add(1)
+		writeErrors(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if int64(len(body)) > s.maxPayloadSize {
+		seriesIntakeParseErrors.Add(1)
+		writeErrors(w, http.StatusRequestEntityTooLarge, "Payload too large")
+		return
+	}
+
+	var p payload
+	if err = json.Unmarshal(body, &p); err != nil {
+		seriesIntakeParseErrors.Add(1)
+		writeErrors(w, http.StatusBadRequest, fmt.Sprintf("Invalid payload: %v", err))
+		return
+	}
+	samples, err := p.toSamples(s.defaultHostname, s.extraTags)
+	if err != nil {
+		seriesIntakeParseErrors.Add(1)
+		writeErrors(w, http.StatusBadRequest, fmt.Sprintf("Invalid payload: %v", err))
+		return
+	}
+
+	if len(samples) > 0 {
+		// rejected instead of blocking the client while the aggregator is
+		// behind, the client retries the payload later
+		if !s.metricOutBuffer.TryAcquire() {
+			seriesIntakeDrops.Add(1)
+			writeErrors(w, http.StatusServiceUnavailable, "The aggregator queue is full")
+			return
+		}
+		s.metricOut <- samples
+	}
+	seriesIntakePayloads.Add(1)
+	seriesIntakePoints.Add(int64(len(samples)))
+	writeErrors(w, http.StatusAccepted)
+}
+
+// readBody reads the decompressed body of a request, up to one byte more
+// than the max payload size
+func (s *Server) readBody(r *http.Request) ([]byte, error) {
+	var reader io.Reader = r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "":
+	case "gzip":
+		gr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		reader = gr
+	case "deflate":
+		zr, err := zlib.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		reader = zr
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %s", r.Header.Get("Content-Encoding"))
+	}
+	return ioutil.ReadAll(io.LimitReader(reader, s.maxPayloadSize+1))
+}
+
+// writeErrors writes a response of the Datadog API
+func writeErrors(w http.ResponseWriter, status int, errors ...string) {
+	if errors == nil {
+		errors = []string{}
+	}
+	body, _ := json.Marshal(map[string][]string{"errors": errors})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package seriesintake
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/buffer"
+)
+
+const testPayload = `{
+	"series": [
+		{
+			"metric": "app.requests",
+			"type": 1,
+			"points": [{"timestamp": 1575317847, "value": 3}, {"timestamp": 1575317857, "value": 4}],
+			"tags": ["env:prod"]
+		},
+		{
+			"metric": "app.queue",
+			"type": 3,
+			"points": [{"timestamp": 1575317847, "value": 12.5}],
+			"resources": [{"name": "db-host", "type": "host"}]
+		}
+	]
+}`
+
+func newTestServer(t *testing.T) (*httptest.Server, chan []*metrics.MetricSample) {
+	mockConfig := config.Mock()
+	mockConfig.Set("series_intake.tags", []string{"team:foo"})
+
+	metricOut := make(chan []*metrics.MetricSample, 10)
+	s := newServer(metricOut, "apikey", "myhost")
+	return httptest.NewServer(s.router()), metricOut
+}
+
+func post(t *testing.T, url, apiKey string, body []byte, encoding string) *http.Response {
+	req, err := http.NewRequest("POST", url+"/api/v2/series", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("DD-API-KEY", apiKey)
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestSeries(t *testing.T) {
+	ts, metricOut := newTestServer(t)
+	defer ts.Close()
+
+	resp := post(t, ts.URL, "apikey", []byte(testPayload), "")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	require.Len(t, metricOut, 1)
+	samples := <-metricOut
+	require.Len(t, samples, 3)
+
+	assert.Equal(t, "app.requests", samples[0].Name)
+	assert.Equal(t, metrics.CountType, samples[0].Mtype)
+	assert.Equal(t, 3.0, samples[0].Value)
+	assert.Equal(t, float64(1575317847), samples[0].Timestamp)
+	assert.Equal(t, "myhost", samples[0].Host)
+	assert.Equal(t, []string{"env:prod", "team:foo", originTag}, samples[0].Tags)
+	assert.Equal(t, 4.0, samples[1].Value)
+
+	assert.Equal(t, "app.queue", samples[2].Name)
+	assert.Equal(t, metrics.GaugeType, samples[2].Mtype)
+	assert.Equal(t, "db-host", samples[2].Host)
+	assert.Equal(t, []string{"team:foo", originTag}, samples[2].Tags)
+}
+
+func TestSeriesQueueFull(t *testing.T) {
+	s := newServer(make(chan []*metrics.MetricSample, 10), "apikey", "myhost")
+	s.metricOutBuffer = buffer.New("test_series_intake", 1)
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	resp := post(t, ts.URL, "apikey", []byte(testPayload), "")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	// the aggregator didn't receive the first payload yet
+	resp = post(t, ts.URL, "apikey", []byte(testPayload), "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, 1, s.metricOutBuffer.Stats().Drops)
+
+	s.metricOutBuffer.Release()
+	resp = post(t, ts.URL, "apikey", []byte(testPayload), "")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+func TestSeriesGzip(t *testing.T) {
+	ts, metricOut := newTestServer(t)
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(testPayload))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	resp := post(t, ts.URL, "apikey", buf.Bytes(), "gzip")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Len(t, metricOut, 1)
+	assert.Len(t, <-metricOut, 3)
+}
+
+func TestSeriesErrors(t *testing.T) {
+	ts, metricOut := newTestServer(t)
+	defer ts.Close()
+
+	for name, tc := range map[string]struct {
+		apiKey   string
+		body     string
+		encoding string
+		status   int
+	}{
+		"wrong api key":        {"wrong", testPayload, "", http.StatusForbidden},
+		"no api key":           {"", testPayload, "", http.StatusForbidden},
+		"invalid json":         {"apikey", `{"series": [`, "", http.StatusBadRequest},
+		"no metric name":       {"apikey", `{"series": [{"points": [{"value": 1}]}]}`, "", http.StatusBadRequest},
+		"unknown type":         {"apikey", `{"series": [{"metric": "a", "type": 5}]}`, "", http.StatusBadRequest},
+		"unsupported encoding": {"apikey", testPayload, "br", http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := post(t, ts.URL, tc.apiKey, []byte(tc.body), tc.encoding)
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+	assert.Len(t, metricOut, 0)
+
+	mockConfig := config.Mock()
+	mockConfig.Set("series_intake.max_payload_size", 10)
+	s := newServer(metricOut, "apikey", "myhost")
+	small := httptest.NewServer(s.router())
+	defer small.Close()
+	resp := post(t, small.URL, "apikey", []byte(testPayload), "")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
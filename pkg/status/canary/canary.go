@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package canary tracks the canaries injected in the pipelines of the agent
+// by the agent_canary check, the pipelines report the canaries reaching their
+// last stage so that the check can tell which pipelines are broken.
+package canary
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Pipeline is a pipeline of the agent a canary goes through
+type Pipeline string
+
+const (
+	// Metrics is the pipeline of the check metrics, from the sender to the forwarder
+	Metrics Pipeline = "metrics"
+	// Logs is the pipeline of the logs, from the processor to the logs intake
+	Logs Pipeline = "logs"
+)
+
+const (
+	// MetricName is the name of the canary metric, its value is the ID of
+	// the canary so that it keeps a single context
+	MetricName = "datadog.agent.canary"
+	// LogSourceName is the name of the log source of the canary logs, their
+	// ID is held by the offset of their origin
+	LogSourceName = "agent_canary"
+)
+
+var globalTracker = newTracker()
+
+// tracker holds the canaries expected by the check, the canaries it doesn't
+// expect are ignored so that it never grows on its own
+type tracker struct {
+	sync.Mutex
+	expected map[Pipeline]map[string]bool
+}
+
+func newTracker() *tracker {
+	return &tracker{expected: make(map[Pipeline]map[string]bool)}
+}
+
+func (t *tracker) expect(pipeline Pipeline, id string) {
+	t.Lock()
+	defer t.Unlock()
+	if t.expected[pipeline] == nil {
+		t.expected[pipeline] = make(map[string]bool)
+	}
+	t.expected[pipeline][id] = false
+}
+
+func (t *tracker) observe(pipeline Pipeline, id string) {
+	t.Lock()
+	defer t.Unlock()
+	if _, found := t.expected[pipeline][id]; found {
+		t.expected[pipeline][id] = true
+	}
+}
+
+func (t *tracker) received(pipeline Pipeline, id string) bool {
+	t.Lock()
+	defer t.Unlock()
+	return t.expected[pipeline][id]
+}
+
+func (t *tracker) forget(pipeline Pipeline, id string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.expected[pipeline], id)
+}
+
+// Expect registers a canary about to be injected in the pipeline
+func Expect(pipeline Pipeline, id string) {
+	globalTracker.expect(pipeline, id)
+}
+
+// Observe is called by the pipeline when a canary reaches its last stage
+func Observe(pipeline Pipeline, id string) {
+	globalTracker.observe(pipeline, id)
+}
+
+// MetricValue returns the value of the canary metric holding the ID
+func MetricValue(id uint64) float64 {
+	return float64(id)
+}
+
+// ObserveValue observes the canary ID held by the value of a canary metric
+func ObserveValue(pipeline Pipeline, value float64) {
+	Observe(pipeline, strconv.FormatUint(uint64(value), 10))
+}
+
+// Received returns whether the expected canary reached the end of the pipeline
+func Received(pipeline Pipeline, id string) bool {
+	return globalTracker.received(pipeline, id)
+}
+
+// Forget stops tracking the canary
+func Forget(pipeline Pipeline, id string) {
+	globalTracker.forget(pipeline, id)
+}
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package canary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker(t *testing.T) {
+	tr := newTracker()
+
+	// unexpected canaries are ignored
+	tr.observe(Metrics, "1")
+	assert.False(t, tr.received(Metrics, "1"))
+	assert.Empty(t, tr.expected[Metrics])
+
+	tr.expect(Metrics, "2")
+	assert.False(t, tr.received(Metrics, "2"))
+	tr.observe(Logs, "2")
+	assert.False(t, tr.received(Metrics, "2"))
+	tr.observe(Metrics, "2")
+	assert.True(t, tr.received(Metrics, "2"))
+
+	tr.forget(Metrics, "2")
+	assert.False(t, tr.received(Metrics, "2"))
+	assert.Empty(t, tr.expected[Metrics])
+}
+
+func TestObserveValue(t *testing.T) {
+	defer Forget(Metrics, "42")
+
+	Expect(Metrics, "42")
+	ObserveValue(Metrics, MetricValue(41))
+	assert.False(t, Received(Metrics, "42"))
+	ObserveValue(Metrics, MetricValue(42))
+	assert.True(t, Received(Metrics, "42"))
+}
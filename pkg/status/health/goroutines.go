@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package health
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrGoroutineLimit is returned when a component reached its goroutine limit
+var ErrGoroutineLimit = errors.New("goroutine limit of the component reached")
+
+// growthWindow is the number of goroutine counts, sampled at each ping of the
+// components, over which a count growing without ever decreasing is flagged
+// as a leak: 20 samples are 5 minutes
+var growthWindow = 20
+
+var globalGoroutines = newGoroutineCatalog()
+
+// WaitGroup waits for the goroutines of a component like a sync.WaitGroup,
+// and accounts them to the component, so that the health status reports
+// their number and flags the components whose goroutines keep growing, e.g.
+// informers started and never stopped
+type WaitGroup struct {
+	wg      sync.WaitGroup
+	counter *goroutineCounter
+}
+
+// NewWaitGroup returns a WaitGroup accounting its goroutines to a component,
+// the WaitGroups of the same component share its count
+func NewWaitGroup(component string) *WaitGroup {
+	return globalGoroutines.newWaitGroup(component)
+}
+
+// SetLimit caps the number of goroutines of the component, 0 removes the cap.
+// The limit is shared by the WaitGroups of the component.
+func (w *WaitGroup) SetLimit(limit int64) {
+	atomic.StoreInt64(&w.counter.limit, limit)
+}
+
+// Go runs a function in a goroutine tracked by the WaitGroup. It returns
+// ErrGoroutineLimit without running it if the component reached its limit.
+func (w *WaitGroup) Go(f func()) error {
+	if !w.counter.reserve(1) {
+		return ErrGoroutineLimit
+	}
+	w.wg.Add(1)
+	go func() {
+		defer func() {
+			atomic.AddInt64(&w.counter.running, -1)
+			w.wg.Done()
+		}()
+		f()
+	}()
+	return nil
+}
+
+// Track accounts goroutines started outside of the WaitGroup, e.g. by an
+// informer factory, as running until done is closed. They already run, so
+// they're accounted even when they take the component over its limit, in
+// which case ErrGoroutineLimit is returned.
+func (w *WaitGroup) Track(n int, done <-chan struct{}) error {
+	var err error
+	if !w.counter.reserve(int64(n)) {
+		atomic.AddInt64(&w.counter.running, int64(n))
+		err = ErrGoroutineLimit
+	}
+	w.wg.Add(n)
+	go func() {
+		<-done
+		atomic.AddInt64(&w.counter.running, -int64(n))
+		w.wg.Add(-n)
+	}()
+	return err
+}
+
+// Wait blocks until the goroutines of the WaitGroup returned
+func (w *WaitGroup) Wait() {
+	w.wg.Wait()
+}
+
+type goroutineCounter struct {
+	running int64
+	limit   int64
+	// rejected counts the goroutines over the limit
+	rejected int64
+	// samples are the last counts, guarded by the catalog lock
+	samples []int64
+}
+
+// reserve accounts n more running goroutines, unless it takes the count over
+// the limit
+func (c *goroutineCounter) reserve(n int64) bool {
+	for {
+		running := atomic.LoadInt64(&c.running)
+		if limit := atomic.LoadInt64(&c.limit); limit > 0 && running+n > limit {
+			atomic.AddInt64(&c.rejected, n)
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&c.running, running, running+n) {
+			return true
+		}
+	}
+}
+
+type goroutineCatalog struct {
+	sync.Mutex
+	counters map[string]*goroutineCounter
+}
+
+func newGoroutineCatalog() *goroutineCatalog {
+	return &goroutineCatalog{counters: make(map[string]*goroutineCounter)}
+}
+
+func (c *goroutineCatalog) newWaitGroup(component string) *WaitGroup {
+	c.Lock()
+	defer c.Unlock()
+
+	counter, found := c.counters[component]
+	if !found {
+		counter = &goroutineCounter{}
+		c.counters[component] = counter
+	}
+	return &WaitGroup{counter: counter}
+}
+
+// sample records the goroutine counts of the components, keeping the ones of
+// the growth window
+func (c *goroutineCatalog) sample() {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, counter := range c.counters {
+		counter.samples = append(counter.samples, atomic.LoadInt64(&counter.running))
+		if len(counter.samples) > growthWindow {
+			counter.samples = counter.samples[len(counter.samples)-growthWindow:]
+		}
+	}
+}
+
+// status returns the goroutine counts of the components, the sorted names
+// of the components whose count grew without decreasing over the growth
+// window, and the sorted names of the ones which went over their limit
+func (c *goroutineCatalog) status() (map[string]int64, []string, []string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.counters) == 0 {
+		return nil, nil, nil
+	}
+	counts := make(map[string]int64, len(c.counters))
+	var leaking, limited []string
+	for name, counter := range c.counters {
+		counts[name] = atomic.LoadInt64(&counter.running)
+		if counter.growing() {
+			leaking = append(leaking, name)
+		}
+		if atomic.LoadInt64(&counter.rejected) > 0 {
+			limited = append(limited, name)
+		}
+	}
+	sort.Strings(leaking)
+	sort.Strings(limited)
+	return counts, leaking, limited
+}
+
+func (c *goroutineCounter) growing() bool {
+	if len(c.samples) < growthWindow {
+		return false
+	}
+	for i := 1; i < len(c.samples); i++ {
+		if c.samples[i] < c.samples[i-1] {
+			return false
+		}
+	}
+	return c.samples[len(c.samples)-1] > c.samples[0]
+}
@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package health
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitGroupCounts(t *testing.T) {
+	goroutines := newGoroutineCatalog()
+	wg1 := goroutines.newWaitGroup("informers")
+	wg2 := goroutines.newWaitGroup("informers")
+	other := goroutines.newWaitGroup("workers")
+
+	stop := make(chan struct{})
+	started := make(chan struct{})
+	for _, wg := range []*WaitGroup{wg1, wg2, wg2} {
+		wg.Go(func() {
+			started <- struct{}{}
+			<-stop
+		})
+	}
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+
+	counts, leaking, limited := goroutines.status()
+	assert.Equal(t, map[string]int64{"informers": 3, "workers": 0}, counts)
+	assert.Empty(t, leaking)
+	assert.Empty(t, limited)
+
+	close(stop)
+	wg1.Wait()
+	wg2.Wait()
+	other.Wait()
+	counts, _, _ = goroutines.status()
+	assert.Equal(t, map[string]int64{"informers": 0, "workers": 0}, counts)
+}
+
+func TestGoroutinesLeaking(t *testing.T) {
+	defer func(window int) { growthWindow = window }(growthWindow)
+	growthWindow = 4
+
+	goroutines := newGoroutineCatalog()
+	growing := goroutines.newWaitGroup("growing")
+	steady := goroutines.newWaitGroup("steady")
+	goroutines.newWaitGroup("idle")
+
+	for _, n := range []int64{1, 2, 2, 3} {
+		growing.counter.running = n
+		steady.counter.running = 5 - n%2
+		goroutines.sample()
+	}
+	_, leaking, _ := goroutines.status()
+	assert.Equal(t, []string{"growing"}, leaking)
+
+	// a decrease within the window isn't a leak
+	growing.counter.running = 1
+	goroutines.sample()
+	_, leaking, _ = goroutines.status()
+	assert.Empty(t, leaking)
+}
+
+func TestWaitGroupLimit(t *testing.T) {
+	goroutines := newGoroutineCatalog()
+	wg1 := goroutines.newWaitGroup("informers")
+	wg2 := goroutines.newWaitGroup("informers")
+	wg1.SetLimit(2)
+
+	stop := make(chan struct{})
+	assert.NoError(t, wg1.Go(func() { <-stop }))
+	assert.NoError(t, wg2.Go(func() { <-stop }))
+	// the limit is shared by the WaitGroups of the component
+	assert.Equal(t, ErrGoroutineLimit, wg2.Go(func() { t.Error("ran over the limit") }))
+
+	counts, _, limited := goroutines.status()
+	assert.Equal(t, map[string]int64{"informers": 2}, counts)
+	assert.Equal(t, []string{"informers"}, limited)
+
+	close(stop)
+	wg1.Wait()
+	wg2.Wait()
+	assert.NoError(t, wg1.Go(func() {}))
+	wg1.Wait()
+}
+
+func TestWaitGroupTrack(t *testing.T) {
+	goroutines := newGoroutineCatalog()
+	wg := goroutines.newWaitGroup("informers")
+	wg.SetLimit(3)
+
+	done := make(chan struct{})
+	assert.NoError(t, wg.Track(2, done))
+	// goroutines already running are accounted over the limit
+	assert.Equal(t, ErrGoroutineLimit, wg.Track(2, done))
+	counts, _, limited := goroutines.status()
+	assert.Equal(t, map[string]int64{"informers": 4}, counts)
+	assert.Equal(t, []string{"informers"}, limited)
+
+	close(done)
+	wg.Wait()
+	counts, _, _ = goroutines.status()
+	assert.Equal(t, map[string]int64{"informers": 0}, counts)
+}
+
+func TestCatalogReportsGoroutines(t *testing.T) {
+	cat := newCatalog()
+	cat.goroutines = newGoroutineCatalog()
+	cat.goroutines.newWaitGroup("informers").counter.running = 2
+
+	status := cat.getStatus()
+	assert.Equal(t, map[string]int64{"informers": 2}, status.Goroutines)
+	assert.Empty(t, status.Leaking)
+}
@@ -34,12 +34,14 @@ type catalog struct {
 	sync.RWMutex
 	components map[*Handle]*component
 	latestRun  time.Time
+	goroutines *goroutineCatalog
 }
 
 func newCatalog() *catalog {
 	return &catalog{
 		components: make(map[*Handle]*component),
 		latestRun:  time.Now(), // Start healthy
+		goroutines: globalGoroutines,
 	}
 }
 
@@ -100,6 +102,7 @@ func (c *catalog) pingComponents() bool {
 		}
 	}
 	c.latestRun = time.Now()
+	c.goroutines.sample()
 	return len(c.components) == 0
 }
 
@@ -120,6 +123,13 @@ func (c *catalog) deregister(handle *Handle) error {
 type Status struct {
 	Healthy   []string
 	Unhealthy []string
+	// Goroutines are the numbers of goroutines of the components tracking
+	// them with a WaitGroup
+	Goroutines map[string]int64 `json:",omitempty"`
+	// Leaking are the components whose number of goroutines kept growing
+	Leaking []string `json:",omitempty"`
+	// Limited are the components which went over their goroutine limit
+	Limited []string `json:",omitempty"`
 }
 
 // getStatus allows to query the health status of the agent
@@ -143,5 +153,6 @@ func (c *catalog) getStatus() Status {
 			status.Unhealthy = append(status.Unhealthy, component.name)
 		}
 	}
+	status.Goroutines, status.Leaking, status.Limited = c.goroutines.status()
 	return status
 }
@@ -27,6 +27,7 @@ func Fmap() template.FuncMap {
 		"lastError":          lastError,
 		"lastErrorTraceback": lastErrorTraceback,
 		"lastErrorMessage":   lastErrorMessage,
+		"traceback":          traceback,
 		"configError":        configError,
 		"printDashes":        printDashes,
 		"formatUnixTime":     formatUnixTime,
@@ -68,6 +69,12 @@ func lastErrorTraceback(value string) template.HTML {
 	return template.HTML(lastErrorArray[0]["traceback"])
 }
 
+// traceback indents a traceback under its error
+func traceback(value string) template.HTML {
+	value = strings.Replace(value, "\n", "\n      ", -1)
+	return template.HTML(strings.TrimRight(value, "\n\t "))
+}
+
 // lastErrorMessage converts the last error message to html
 func lastErrorMessage(value string) template.HTML {
 	var lastErrorArray []map[string]string
@@ -30,6 +30,16 @@ func init() {
 
 // FormatStatus takes a json bytestring and prints out the formatted statuspage
 func FormatStatus(data []byte) (string, error) {
+	return formatStatus(data, false)
+}
+
+// FormatVerboseStatus takes a json bytestring and prints out the formatted
+// statuspage, with the recent failures of the checks
+func FormatVerboseStatus(data []byte) (string, error) {
+	return formatStatus(data, true)
+}
+
+func formatStatus(data []byte, verbose bool) (string, error) {
 	var b = new(bytes.Buffer)
 
 	stats := make(map[string]interface{})
@@ -49,7 +59,7 @@ func FormatStatus(data []byte) (string, error) {
 	title := fmt.Sprintf("Agent (v%s)", stats["version"])
 	stats["title"] = title
 	renderHeader(b, stats)
-	renderChecksStats(b, runnerStats, pyLoaderStats, pythonInit, autoConfigStats, checkSchedulerStats, "")
+	renderChecksStats(b, runnerStats, pyLoaderStats, pythonInit, autoConfigStats, checkSchedulerStats, "", verbose)
 	renderJMXFetchStatus(b, jmxStats)
 	renderForwarderStatus(b, forwarderStats)
 	renderEndpointsInfos(b, endpointsInfos)
@@ -77,9 +87,12 @@ func FormatDCAStatus(data []byte) (string, error) {
 	title := fmt.Sprintf("Datadog Cluster Agent (v%s)", stats["version"])
 	stats["title"] = title
 	renderHeader(b, stats)
-	renderChecksStats(b, runnerStats, nil, nil, autoConfigStats, checkSchedulerStats, "")
+	renderChecksStats(b, runnerStats, nil, nil, autoConfigStats, checkSchedulerStats, "", false)
 	renderForwarderStatus(b, forwarderStats)
 	renderEndpointsInfos(b, endpointsInfos)
+	if stats["informerStats"] != nil {
+		renderInformersStats(b, stats["informerStats"], stats["reflectorStats"])
+	}
 
 	return b.String(), nil
 }
@@ -156,6 +169,17 @@ func renderDatadogClusterAgentStatus(w io.Writer, dcaStats interface{}) {
 	}
 }
 
+func renderInformersStats(w io.Writer, informerStats, reflectorStats interface{}) {
+	stats := make(map[string]interface{})
+	stats["InformerStats"] = informerStats
+	stats["ReflectorStats"] = reflectorStats
+	t := template.Must(template.New("informers.tmpl").Funcs(fmap).ParseFiles(filepath.Join(templateFolder, "informers.tmpl")))
+	err := t.Execute(w, stats)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
 func renderHPAStats(w io.Writer, hpaStats interface{}) {
 	t := template.Must(template.New("custommetricsprovider.tmpl").Funcs(fmap).ParseFiles(filepath.Join(templateFolder, "custommetricsprovider.tmpl")))
 	err := t.Execute(w, hpaStats)
@@ -164,7 +188,7 @@ func renderHPAStats(w io.Writer, hpaStats interface{}) {
 	}
 }
 
-func renderChecksStats(w io.Writer, runnerStats, pyLoaderStats, pythonInit, autoConfigStats, checkSchedulerStats interface{}, onlyCheck string) {
+func renderChecksStats(w io.Writer, runnerStats, pyLoaderStats, pythonInit, autoConfigStats, checkSchedulerStats interface{}, onlyCheck string, verbose bool) {
 	checkStats := make(map[string]interface{})
 	checkStats["RunnerStats"] = runnerStats
 	checkStats["pyLoaderStats"] = pyLoaderStats
@@ -172,6 +196,7 @@ func renderChecksStats(w io.Writer, runnerStats, pyLoaderStats, pythonInit, auto
 	checkStats["AutoConfigStats"] = autoConfigStats
 	checkStats["CheckSchedulerStats"] = checkSchedulerStats
 	checkStats["OnlyCheck"] = onlyCheck
+	checkStats["Verbose"] = verbose
 	t := template.Must(template.New("collector.tmpl").Funcs(fmap).ParseFiles(filepath.Join(templateFolder, "collector.tmpl")))
 
 	err := t.Execute(w, checkStats)
@@ -190,7 +215,7 @@ func renderCheckStats(data []byte, checkName string) (string, error) {
 	pythonInit := stats["pythonInit"]
 	autoConfigStats := stats["autoConfigStats"]
 	checkSchedulerStats := stats["checkSchedulerStats"]
-	renderChecksStats(b, runnerStats, pyLoaderStats, pythonInit, autoConfigStats, checkSchedulerStats, checkName)
+	renderChecksStats(b, runnerStats, pyLoaderStats, pythonInit, autoConfigStats, checkSchedulerStats, checkName, false)
 
 	return b.String(), nil
 }
@@ -0,0 +1,591 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SchemaV1 is the first version of the machine-readable status document.
+//
+// The raw JSON status mirrors the internal state of the agent and changes
+// between versions. The versioned documents are stable: fields can be added
+// to a version, but never renamed, retyped or removed. Breaking changes need
+// a new version.
+const SchemaV1 = "v1"
+
+// StatusV1 is the v1 machine-readable status document
+type StatusV1 struct {
+	SchemaVersion     string                       `json:"schema_version"`
+	Agent             AgentStatusV1                `json:"agent"`
+	Checks            []CheckStatusV1              `json:"checks"`
+	CheckConfigErrors map[string]string            `json:"check_config_errors"`
+	CheckLoaderErrors map[string]map[string]string `json:"check_loader_errors"`
+	Forwarder         ForwarderStatusV1            `json:"forwarder"`
+	Aggregator        AggregatorStatusV1           `json:"aggregator"`
+	DogStatsD         DogStatsDStatusV1            `json:"dogstatsd"`
+	Logs              LogsStatusV1                 `json:"logs"`
+}
+
+// AgentStatusV1 describes the agent process
+type AgentStatusV1 struct {
+	Version       string `json:"version"`
+	PID           int    `json:"pid"`
+	Hostname      string `json:"hostname"`
+	GoVersion     string `json:"go_version"`
+	PythonVersion string `json:"python_version"`
+	StartTime     string `json:"start_time"`
+	Time          string `json:"time"`
+	ConfigFile    string `json:"config_file"`
+}
+
+// CheckStatusV1 describes a check instance, execution times are in milliseconds
+type CheckStatusV1 struct {
+	Name                   string   `json:"name"`
+	ID                     string   `json:"id"`
+	Version                string   `json:"version"`
+	ConfigSource           string   `json:"config_source"`
+	TotalRuns              uint64   `json:"total_runs"`
+	TotalErrors            uint64   `json:"total_errors"`
+	TotalWarnings          uint64   `json:"total_warnings"`
+	MetricSamples          int64    `json:"metric_samples"`
+	Events                 int64    `json:"events"`
+	ServiceChecks          int64    `json:"service_checks"`
+	AverageExecutionTimeMs int64    `json:"average_execution_time_ms"`
+	LastExecutionTimeMs    int64    `json:"last_execution_time_ms"`
+	LastError              string   `json:"last_error"`
+	LastWarnings           []string `json:"last_warnings"`
+	LastRunTimestamp       int64    `json:"last_run_timestamp"`
+}
+
+// ForwarderStatusV1 describes the transactions sent to the intake
+type ForwarderStatusV1 struct {
+	TransactionsSuccess        int64             `json:"transactions_success"`
+	TransactionsErrors         int64             `json:"transactions_errors"`
+	TransactionsDropped        int64             `json:"transactions_dropped"`
+	TransactionsRetryQueueSize int64             `json:"transactions_retry_queue_size"`
+	APIKeyStatus               map[string]string `json:"api_key_status"`
+}
+
+// AggregatorStatusV1 describes the data processed by the aggregator
+type AggregatorStatusV1 struct {
+	ChecksMetricSamples    int64 `json:"checks_metric_samples"`
+	DogStatsDMetricSamples int64 `json:"dogstatsd_metric_samples"`
+	Events                 int64 `json:"events"`
+	ServiceChecks          int64 `json:"service_checks"`
+	Flushes                int64 `json:"flushes"`
+	SeriesFlushed          int64 `json:"series_flushed"`
+	SketchesFlushed        int64 `json:"sketches_flushed"`
+	EventsFlushed          int64 `json:"events_flushed"`
+	ServiceChecksFlushed   int64 `json:"service_checks_flushed"`
+}
+
+// DogStatsDStatusV1 describes the packets received by DogStatsD
+type DogStatsDStatusV1 struct {
+	MetricPackets           int64 `json:"metric_packets"`
+	MetricParseErrors       int64 `json:"metric_parse_errors"`
+	EventPackets            int64 `json:"event_packets"`
+	EventParseErrors        int64 `json:"event_parse_errors"`
+	ServiceCheckPackets     int64 `json:"service_check_packets"`
+	ServiceCheckParseErrors int64 `json:"service_check_parse_errors"`
+	UDPPackets              int64 `json:"udp_packets"`
+	UDPPacketReadingErrors  int64 `json:"udp_packet_reading_errors"`
+	UDSPackets              int64 `json:"uds_packets"`
+	UDSPacketReadingErrors  int64 `json:"uds_packet_reading_errors"`
+}
+
+// LogsStatusV1 describes the logs agent
+type LogsStatusV1 struct {
+	Running bool `json:"running"`
+}
+
+// rawStatus holds the fields of the raw JSON status the versioned documents
+// are built from
+type rawStatus struct {
+	Version       string `json:"version"`
+	PID           int    `json:"pid"`
+	GoVersion     string `json:"go_version"`
+	PythonVersion string `json:"python_version"`
+	AgentStart    string `json:"agent_start"`
+	Time          string `json:"time"`
+	ConfFile      string `json:"conf_file"`
+	Metadata      struct {
+		Meta struct {
+			Hostname string `json:"hostname"`
+		} `json:"meta"`
+	} `json:"metadata"`
+	RunnerStats struct {
+		Checks map[string]map[string]struct {
+			CheckName            string
+			CheckVersion         string
+			CheckConfigSource    string
+			CheckID              string
+			TotalRuns            uint64
+			TotalErrors          uint64
+			TotalWarnings        uint64
+			MetricSamples        int64
+			Events               int64
+			ServiceChecks        int64
+			AverageExecutionTime int64
+			LastExecutionTime    int64
+			LastError            string
+			LastWarnings         []string
+			UpdateTimestamp      int64
+		}
+	} `json:"runnerStats"`
+	AutoConfigStats struct {
+		ConfigErrors map[string]string
+	} `json:"autoConfigStats"`
+	CheckSchedulerStats struct {
+		LoaderErrors map[string]map[string]string
+	} `json:"checkSchedulerStats"`
+	ForwarderStats struct {
+		Transactions struct {
+			Success        int64
+			Errors         int64
+			Dropped        int64
+			RetryQueueSize int64
+		}
+		APIKeyStatus map[string]string
+	} `json:"forwarderStats"`
+	AggregatorStats struct {
+		ChecksMetricSample    int64
+		DogstatsdMetricSample int64
+		Event                 int64
+		ServiceCheck          int64
+		NumberOfFlush         int64
+		SeriesFlushed         int64
+		SketchesFlushed       int64
+		EventsFlushed         int64
+		ServiceCheckFlushed   int64
+	} `json:"aggregatorStats"`
+	DogstatsdStats struct {
+		MetricPackets           int64
+		MetricParseErrors       int64
+		EventPackets            int64
+		EventParseErrors        int64
+		ServiceCheckPackets     int64
+		ServiceCheckParseErrors int64
+		UDPPackets              int64 `json:"UdpPackets"`
+		UDPPacketReadingErrors  int64 `json:"UdpPacketReadingErrors"`
+		UDSPackets              int64 `json:"UdsPackets"`
+		UDSPacketReadingErrors  int64 `json:"UdsPacketReadingErrors"`
+	} `json:"dogstatsdStats"`
+	LogsStats struct {
+		IsRunning bool `json:"is_running"`
+	} `json:"logsStats"`
+}
+
+// FormatStatusJSON converts the raw JSON status returned by the agent into
+// the versioned document of the given schema
+func FormatStatusJSON(data []byte, schema string) ([]byte, error) {
+	switch schema {
+	case SchemaV1:
+		s, err := convertStatusV1(data)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(s)
+	default:
+		return nil, fmt.Errorf("unknown status schema %q, supported schemas: %s", schema, SchemaV1)
+	}
+}
+
+// GetJSONSchema returns the JSON Schema describing the versioned status
+// document of the given schema
+func GetJSONSchema(schema string) (string, error) {
+	switch schema {
+	case SchemaV1:
+		return jsonSchemaV1, nil
+	default:
+		return "", fmt.Errorf("unknown status schema %q, supported schemas: %s", schema, SchemaV1)
+	}
+}
+
+func convertStatusV1(data []byte) (*StatusV1, error) {
+	raw := rawStatus{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid status: %v", err)
+	}
+
+	s := &StatusV1{
+		SchemaVersion: SchemaV1,
+		Agent: AgentStatusV1{
+			Version:       raw.Version,
+			PID:           raw.PID,
+			Hostname:      raw.Metadata.Meta.Hostname,
+			GoVersion:     raw.GoVersion,
+			PythonVersion: raw.PythonVersion,
+			StartTime:     raw.AgentStart,
+			Time:          raw.Time,
+			ConfigFile:    raw.ConfFile,
+		},
+		Checks:            []CheckStatusV1{},
+		CheckConfigErrors: map[string]string{},
+		CheckLoaderErrors: map[string]map[string]string{},
+		Forwarder: ForwarderStatusV1{
+			TransactionsSuccess:        raw.ForwarderStats.Transactions.Success,
+			TransactionsErrors:         raw.ForwarderStats.Transactions.Errors,
+			TransactionsDropped:        raw.ForwarderStats.Transactions.Dropped,
+			TransactionsRetryQueueSize: raw.ForwarderStats.Transactions.RetryQueueSize,
+			APIKeyStatus:               map[string]string{},
+		},
+		Aggregator: AggregatorStatusV1{
+			ChecksMetricSamples:    raw.AggregatorStats.ChecksMetricSample,
+			DogStatsDMetricSamples: raw.AggregatorStats.DogstatsdMetricSample,
+			Events:                 raw.AggregatorStats.Event,
+			ServiceChecks:          raw.AggregatorStats.ServiceCheck,
+			Flushes:                raw.AggregatorStats.NumberOfFlush,
+			SeriesFlushed:          raw.AggregatorStats.SeriesFlushed,
+			SketchesFlushed:        raw.AggregatorStats.SketchesFlushed,
+			EventsFlushed:          raw.AggregatorStats.EventsFlushed,
+			ServiceChecksFlushed:   raw.AggregatorStats.ServiceCheckFlushed,
+		},
+		DogStatsD: DogStatsDStatusV1{
+			MetricPackets:           raw.DogstatsdStats.MetricPackets,
+			MetricParseErrors:       raw.DogstatsdStats.MetricParseErrors,
+			EventPackets:            raw.DogstatsdStats.EventPackets,
+			EventParseErrors:        raw.DogstatsdStats.EventParseErrors,
+			ServiceCheckPackets:     raw.DogstatsdStats.ServiceCheckPackets,
+			ServiceCheckParseErrors: raw.DogstatsdStats.ServiceCheckParseErrors,
+			UDPPackets:              raw.DogstatsdStats.UDPPackets,
+			UDPPacketReadingErrors:  raw.DogstatsdStats.UDPPacketReadingErrors,
+			UDSPackets:              raw.DogstatsdStats.UDSPackets,
+			UDSPacketReadingErrors:  raw.DogstatsdStats.UDSPacketReadingErrors,
+		},
+		Logs: LogsStatusV1{
+			Running: raw.LogsStats.IsRunning,
+		},
+	}
+
+	for _, instances := range raw.RunnerStats.Checks {
+		for _, c := range instances {
+			lastWarnings := c.LastWarnings
+			if lastWarnings == nil {
+				lastWarnings = []string{}
+			}
+			s.Checks = append(s.Checks, CheckStatusV1{
+				Name:                   c.CheckName,
+				ID:                     c.CheckID,
+				Version:                c.CheckVersion,
+				ConfigSource:           c.CheckConfigSource,
+				TotalRuns:              c.TotalRuns,
+				TotalErrors:            c.TotalErrors,
+				TotalWarnings:          c.TotalWarnings,
+				MetricSamples:          c.MetricSamples,
+				Events:                 c.Events,
+				ServiceChecks:          c.ServiceChecks,
+				AverageExecutionTimeMs: c.AverageExecutionTime,
+				LastExecutionTimeMs:    c.LastExecutionTime,
+				LastError:              c.LastError,
+				LastWarnings:           lastWarnings,
+				LastRunTimestamp:       c.UpdateTimestamp,
+			})
+		}
+	}
+	// the order of the checks is stable between two calls
+	sort.Slice(s.Checks, func(i, j int) bool {
+		return s.Checks[i].ID < s.Checks[j].ID
+	})
+
+	for name, err := range raw.AutoConfigStats.ConfigErrors {
+		s.CheckConfigErrors[name] = err
+	}
+	for name, errs := range raw.CheckSchedulerStats.LoaderErrors {
+		s.CheckLoaderErrors[name] = errs
+	}
+	for endpoint, status := range raw.ForwarderStats.APIKeyStatus {
+		s.Forwarder.APIKeyStatus[endpoint] = status
+	}
+
+	return s, nil
+}
+
+// jsonSchemaV1 is the JSON Schema (draft-07) of StatusV1
+const jsonSchemaV1 = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://github.com/DataDog/datadog-agent/pkg/status/schema/v1.json",
+  "title": "Datadog Agent status v1",
+  "type": "object",
+  "properties": {
+    "schema_version": {
+      "type": "string",
+      "const": "v1"
+    },
+    "agent": {
+      "type": "object",
+      "properties": {
+        "version": {
+          "type": "string"
+        },
+        "pid": {
+          "type": "integer"
+        },
+        "hostname": {
+          "type": "string"
+        },
+        "go_version": {
+          "type": "string"
+        },
+        "python_version": {
+          "type": "string"
+        },
+        "start_time": {
+          "type": "string"
+        },
+        "time": {
+          "type": "string"
+        },
+        "config_file": {
+          "type": "string"
+        }
+      },
+      "required": [
+        "version",
+        "pid",
+        "hostname",
+        "go_version",
+        "python_version",
+        "start_time",
+        "time",
+        "config_file"
+      ]
+    },
+    "checks": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "name": {
+            "type": "string"
+          },
+          "id": {
+            "type": "string"
+          },
+          "version": {
+            "type": "string"
+          },
+          "config_source": {
+            "type": "string"
+          },
+          "total_runs": {
+            "type": "integer"
+          },
+          "total_errors": {
+            "type": "integer"
+          },
+          "total_warnings": {
+            "type": "integer"
+          },
+          "metric_samples": {
+            "type": "integer"
+          },
+          "events": {
+            "type": "integer"
+          },
+          "service_checks": {
+            "type": "integer"
+          },
+          "average_execution_time_ms": {
+            "type": "integer"
+          },
+          "last_execution_time_ms": {
+            "type": "integer"
+          },
+          "last_error": {
+            "type": "string"
+          },
+          "last_warnings": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "last_run_timestamp": {
+            "type": "integer"
+          }
+        },
+        "required": [
+          "name",
+          "id",
+          "version",
+          "config_source",
+          "total_runs",
+          "total_errors",
+          "total_warnings",
+          "metric_samples",
+          "events",
+          "service_checks",
+          "average_execution_time_ms",
+          "last_execution_time_ms",
+          "last_error",
+          "last_warnings",
+          "last_run_timestamp"
+        ]
+      }
+    },
+    "check_config_errors": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "string"
+      }
+    },
+    "check_loader_errors": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "object",
+        "additionalProperties": {
+          "type": "string"
+        }
+      }
+    },
+    "forwarder": {
+      "type": "object",
+      "properties": {
+        "transactions_success": {
+          "type": "integer"
+        },
+        "transactions_errors": {
+          "type": "integer"
+        },
+        "transactions_dropped": {
+          "type": "integer"
+        },
+        "transactions_retry_queue_size": {
+          "type": "integer"
+        },
+        "api_key_status": {
+          "type": "object",
+          "additionalProperties": {
+            "type": "string"
+          }
+        }
+      },
+      "required": [
+        "transactions_success",
+        "transactions_errors",
+        "transactions_dropped",
+        "transactions_retry_queue_size",
+        "api_key_status"
+      ]
+    },
+    "aggregator": {
+      "type": "object",
+      "properties": {
+        "checks_metric_samples": {
+          "type": "integer"
+        },
+        "dogstatsd_metric_samples": {
+          "type": "integer"
+        },
+        "events": {
+          "type": "integer"
+        },
+        "service_checks": {
+          "type": "integer"
+        },
+        "flushes": {
+          "type": "integer"
+        },
+        "series_flushed": {
+          "type": "integer"
+        },
+        "sketches_flushed": {
+          "type": "integer"
+        },
+        "events_flushed": {
+          "type": "integer"
+        },
+        "service_checks_flushed": {
+          "type": "integer"
+        }
+      },
+      "required": [
+        "checks_metric_samples",
+        "dogstatsd_metric_samples",
+        "events",
+        "service_checks",
+        "flushes",
+        "series_flushed",
+        "sketches_flushed",
+        "events_flushed",
+        "service_checks_flushed"
+      ]
+    },
+    "dogstatsd": {
+      "type": "object",
+      "properties": {
+        "metric_packets": {
+          "type": "integer"
+        },
+        "metric_parse_errors": {
+          "type": "integer"
+        },
+        "event_packets": {
+          "type": "integer"
+        },
+        "event_parse_errors": {
+          "type": "integer"
+        },
+        "service_check_packets": {
+          "type": "integer"
+        },
+        "service_check_parse_errors": {
+          "type": "integer"
+        },
+        "udp_packets": {
+          "type": "integer"
+        },
+        "udp_packet_reading_errors": {
+          "type": "integer"
+        },
+        "uds_packets": {
+          "type": "integer"
+        },
+        "uds_packet_reading_errors": {
+          "type": "integer"
+        }
+      },
+      "required": [
+        "metric_packets",
+        "metric_parse_errors",
+        "event_packets",
+        "event_parse_errors",
+        "service_check_packets",
+        "service_check_parse_errors",
+        "udp_packets",
+        "udp_packet_reading_errors",
+        "uds_packets",
+        "uds_packet_reading_errors"
+      ]
+    },
+    "logs": {
+      "type": "object",
+      "properties": {
+        "running": {
+          "type": "boolean"
+        }
+      },
+      "required": [
+        "running"
+      ]
+    }
+  },
+  "required": [
+    "schema_version",
+    "agent",
+    "checks",
+    "check_config_errors",
+    "check_loader_errors",
+    "forwarder",
+    "aggregator",
+    "dogstatsd",
+    "logs"
+  ]
+}
+`
@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package status
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rawStatusJSON = `{
+	"version": "6.14.0",
+	"pid": 42,
+	"go_version": "go1.12.9",
+	"python_version": "2.7.16",
+	"agent_start": "2019-08-01 10:00:00.000000 UTC",
+	"time": "2019-08-01 10:05:00.000000 UTC",
+	"conf_file": "/etc/datadog-agent/datadog.yaml",
+	"metadata": {"meta": {"hostname": "my-host"}},
+	"runnerStats": {
+		"Checks": {
+			"redisdb": {
+				"redisdb:b": {"CheckName": "redisdb", "CheckID": "redisdb:b", "TotalRuns": 2, "LastError": "boom", "AverageExecutionTime": 12}
+			},
+			"cpu": {
+				"cpu": {"CheckName": "cpu", "CheckID": "cpu", "CheckVersion": "", "TotalRuns": 20, "MetricSamples": 9, "LastWarnings": ["careful"], "UpdateTimestamp": 1564653900}
+			}
+		}
+	},
+	"autoConfigStats": {"ConfigErrors": {"foo": "yaml: line 1"}},
+	"checkSchedulerStats": {"LoaderErrors": {"bar": {"python": "could not load"}}},
+	"forwarderStats": {"Transactions": {"Success": 10, "Errors": 1, "Dropped": 2, "RetryQueueSize": 3}, "APIKeyStatus": {"API key ending with 12345": "API Key valid"}},
+	"aggregatorStats": {"ChecksMetricSample": 100, "DogstatsdMetricSample": 50, "NumberOfFlush": 20, "SeriesFlushed": 40},
+	"dogstatsdStats": {"MetricPackets": 50, "UdpPackets": 60, "UdsPacketReadingErrors": 1},
+	"logsStats": {"is_running": true},
+	"someInternalField": {"not": "exported"}
+}`
+
+func TestFormatStatusJSONV1(t *testing.T) {
+	data, err := FormatStatusJSON([]byte(rawStatusJSON), SchemaV1)
+	require.NoError(t, err)
+
+	s := StatusV1{}
+	require.NoError(t, json.Unmarshal(data, &s))
+	assert.Equal(t, "v1", s.SchemaVersion)
+	assert.Equal(t, AgentStatusV1{
+		Version:       "6.14.0",
+		PID:           42,
+		Hostname:      "my-host",
+		GoVersion:     "go1.12.9",
+		PythonVersion: "2.7.16",
+		StartTime:     "2019-08-01 10:00:00.000000 UTC",
+		Time:          "2019-08-01 10:05:00.000000 UTC",
+		ConfigFile:    "/etc/datadog-agent/datadog.yaml",
+	}, s.Agent)
+
+	require.Len(t, s.Checks, 2)
+	assert.Equal(t, CheckStatusV1{
+		Name:             "cpu",
+		ID:               "cpu",
+		TotalRuns:        20,
+		MetricSamples:    9,
+		LastWarnings:     []string{"careful"},
+		LastRunTimestamp: 1564653900,
+	}, s.Checks[0])
+	assert.Equal(t, "redisdb:b", s.Checks[1].ID)
+	assert.Equal(t, "boom", s.Checks[1].LastError)
+	assert.Equal(t, int64(12), s.Checks[1].AverageExecutionTimeMs)
+	assert.Equal(t, []string{}, s.Checks[1].LastWarnings)
+
+	assert.Equal(t, map[string]string{"foo": "yaml: line 1"}, s.CheckConfigErrors)
+	assert.Equal(t, map[string]map[string]string{"bar": {"python": "could not load"}}, s.CheckLoaderErrors)
+	assert.Equal(t, ForwarderStatusV1{
+		TransactionsSuccess:        10,
+		TransactionsErrors:         1,
+		TransactionsDropped:        2,
+		TransactionsRetryQueueSize: 3,
+		APIKeyStatus:               map[string]string{"API key ending with 12345": "API Key valid"},
+	}, s.Forwarder)
+	assert.Equal(t, int64(100), s.Aggregator.ChecksMetricSamples)
+	assert.Equal(t, int64(20), s.Aggregator.Flushes)
+	assert.Equal(t, int64(60), s.DogStatsD.UDPPackets)
+	assert.Equal(t, int64(1), s.DogStatsD.UDSPacketReadingErrors)
+	assert.True(t, s.Logs.Running)
+
+	// the internal fields are not exported
+	assert.NotContains(t, string(data), "someInternalField")
+}
+
+func TestFormatStatusJSONEmpty(t *testing.T) {
+	data, err := FormatStatusJSON([]byte(`{}`), SchemaV1)
+	require.NoError(t, err)
+
+	// collections are never null
+	doc := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, []interface{}{}, doc["checks"])
+	assert.Equal(t, map[string]interface{}{}, doc["check_config_errors"])
+	assert.Equal(t, map[string]interface{}{}, doc["check_loader_errors"])
+	assert.Equal(t, map[string]interface{}{}, doc["forwarder"].(map[string]interface{})["api_key_status"])
+}
+
+func TestFormatStatusJSONUnknownSchema(t *testing.T) {
+	_, err := FormatStatusJSON([]byte(rawStatusJSON), "v0")
+	assert.Error(t, err)
+	_, err = GetJSONSchema("v0")
+	assert.Error(t, err)
+
+	_, err = FormatStatusJSON([]byte("not json"), SchemaV1)
+	assert.Error(t, err)
+}
+
+// TestJSONSchemaV1 ensures the published JSON Schema describes every field of
+// StatusV1, and only them
+func TestJSONSchemaV1(t *testing.T) {
+	schema, err := GetJSONSchema(SchemaV1)
+	require.NoError(t, err)
+	doc := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal([]byte(schema), &doc))
+
+	assertSchemaMatchesType(t, doc, reflect.TypeOf(StatusV1{}), "")
+}
+
+func assertSchemaMatchesType(t *testing.T, schema map[string]interface{}, typ reflect.Type, path string) {
+	switch typ.Kind() {
+	case reflect.Struct:
+		require.Equal(t, "object", schema["type"], path)
+		properties, ok := schema["properties"].(map[string]interface{})
+		require.True(t, ok, "no properties for %s", path)
+
+		var fields []string
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			fields = append(fields, name)
+			fieldSchema, ok := properties[name].(map[string]interface{})
+			if assert.True(t, ok, "field %s.%s not in the schema", path, name) {
+				assertSchemaMatchesType(t, fieldSchema, field.Type, path+"."+name)
+			}
+		}
+		var required []string
+		for _, r := range schema["required"].([]interface{}) {
+			required = append(required, r.(string))
+		}
+		sort.Strings(fields)
+		sort.Strings(required)
+		assert.Equal(t, fields, required, path)
+		assert.Len(t, properties, len(fields), path)
+	case reflect.Slice:
+		require.Equal(t, "array", schema["type"], path)
+		assertSchemaMatchesType(t, schema["items"].(map[string]interface{}), typ.Elem(), path+"[]")
+	case reflect.Map:
+		require.Equal(t, "object", schema["type"], path)
+		assertSchemaMatchesType(t, schema["additionalProperties"].(map[string]interface{}), typ.Elem(), path+"{}")
+	case reflect.String:
+		assert.Equal(t, "string", schema["type"], path)
+	case reflect.Bool:
+		assert.Equal(t, "boolean", schema["type"], path)
+	case reflect.Int, reflect.Int64, reflect.Uint64:
+		assert.Equal(t, "integer", schema["type"], path)
+	default:
+		assert.Fail(t, "unexpected type", "%s: %s", path, typ)
+	}
+}
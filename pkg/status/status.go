@@ -22,8 +22,11 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/metadata/identity"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/clockdrift"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/supervisor"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
 
@@ -64,6 +67,7 @@ func GetStatus() (map[string]interface{}, error) {
 	stats["python_version"] = strings.Split(pythonVersion, " ")[0]
 	stats["agent_start"] = startTime.Format(timeFormat)
 	stats["hostinfo"] = host.GetStatusInformation()
+	stats["identity"] = identity.GetStatus()
 	now := time.Now()
 	stats["time"] = now.Format(timeFormat)
 
@@ -71,6 +75,10 @@ func GetStatus() (map[string]interface{}, error) {
 
 	stats["logsStats"] = logs.GetStatus()
 
+	if components := supervisor.GetStatus(); len(components) > 0 {
+		stats["supervisor"] = components
+	}
+
 	endpointsInfos, err := getEndpointsInfos()
 	if endpointsInfos != nil && err == nil {
 		stats["endpointsInfos"] = endpointsInfos
@@ -87,6 +95,16 @@ func GetStatus() (map[string]interface{}, error) {
 
 // GetAndFormatStatus gets and formats the status all in one go
 func GetAndFormatStatus() ([]byte, error) {
+	return getAndFormatStatus(FormatStatus)
+}
+
+// GetAndFormatVerboseStatus gets and formats the status with the recent
+// failures of the checks, for the flares
+func GetAndFormatVerboseStatus() ([]byte, error) {
+	return getAndFormatStatus(FormatVerboseStatus)
+}
+
+func getAndFormatStatus(format func([]byte) (string, error)) ([]byte, error) {
 	s, err := GetStatus()
 	if err != nil {
 		return nil, err
@@ -97,7 +115,7 @@ func GetAndFormatStatus() ([]byte, error) {
 		return nil, err
 	}
 
-	st, err := FormatStatus(statusJSON)
+	st, err := format(statusJSON)
 	if err != nil {
 		return nil, err
 	}
@@ -174,6 +192,16 @@ func GetDCAStatus() (map[string]interface{}, error) {
 		stats["custommetrics"] = custommetrics.GetStatus(apiCl.Cl)
 	}
 
+	// only published when the informers are started
+	if informers := expvar.Get("informers"); informers != nil {
+		informerStats := make(map[string]interface{})
+		json.Unmarshal([]byte(informers.String()), &informerStats)
+		reflectorStats := make(map[string]interface{})
+		json.Unmarshal([]byte(expvar.Get("reflectors").String()), &reflectorStats)
+		stats["informerStats"] = informerStats
+		stats["reflectorStats"] = reflectorStats
+	}
+
 	if config.Datadog.GetBool("cluster_checks.enabled") {
 		cchecks, err := clusterchecks.GetStats()
 		if err != nil {
@@ -317,6 +345,7 @@ func expvarStats(stats map[string]interface{}) (map[string]interface{}, error) {
 	if expvar.Get("ntpOffset").String() != "" {
 		stats["ntpOffset"], err = strconv.ParseFloat(expvar.Get("ntpOffset").String(), 64)
 	}
+	stats["clockDrift"] = clockdrift.GetStatus()
 
 	return stats, err
 }
@@ -18,7 +18,6 @@ import (
 
 const (
 	kubeletCollectorName = "kubelet"
-	kubeletExpireFreq    = 5 * time.Minute
 )
 
 // KubeletCollector connects to the local kubelet to get kubernetes container
@@ -35,14 +34,17 @@ type KubeletCollector struct {
 
 // Detect tries to connect to the kubelet
 func (c *KubeletCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error) {
-	watcher, err := kubelet.NewPodWatcher(5*time.Minute, true)
+	// the deleted pods expire after the grace period, the expiries being
+	// throttled by the same period their tags outlive their checks
+	gracePeriod := time.Duration(config.Datadog.GetInt("kubernetes_deleted_pods_grace_period")) * time.Second
+	watcher, err := kubelet.NewPodWatcher(gracePeriod, true)
 	if err != nil {
 		return NoCollection, err
 	}
 	c.watcher = watcher
 	c.infoOut = out
 	c.lastExpire = time.Now()
-	c.expireFreq = kubeletExpireFreq
+	c.expireFreq = gracePeriod
 
 	// We lower-case the values collected by viper as well as the ones from inspecting the labels of containers.
 	labelsList := config.Datadog.GetStringMapString("kubernetes_pod_labels_as_tags")
@@ -62,7 +64,8 @@ func (c *KubeletCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error)
 }
 
 // Pull triggers a podlist refresh and sends new info. It also triggers
-// container deletion computation every 'expireFreq'
+// container deletion computation every 'expireFreq', or right away when
+// the deleted pods are purged
 func (c *KubeletCollector) Pull() error {
 	// Compute new/updated pods
 	updatedPods, err := c.watcher.PullChanges()
@@ -77,7 +80,7 @@ func (c *KubeletCollector) Pull() error {
 	c.infoOut <- updates
 
 	// Throttle deletion computations
-	if time.Now().Sub(c.lastExpire) < c.expireFreq {
+	if time.Now().Sub(c.lastExpire) < c.expireFreq && !c.watcher.PurgeRequested() {
 		return nil
 	}
 
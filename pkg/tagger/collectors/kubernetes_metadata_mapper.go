@@ -18,6 +18,13 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+// orchestratorCardMetadataTags are the cluster level tags with the orchestrator
+// cardinality, the other ones have the low cardinality
+var orchestratorCardMetadataTags = map[string]bool{
+	"kube_replica_set": true,
+	"kube_job":         true,
+}
+
 func (c *KubeMetadataCollector) getTagInfos(pods []*kubelet.Pod) []*TagInfo {
 	var err error
 	var metadataByNsPods apiv1.NamespacesPodsStringsSet
@@ -77,7 +84,11 @@ func (c *KubeMetadataCollector) getTagInfos(pods []*kubelet.Pod) []*TagInfo {
 				// but not the tag key
 				tagList.AddLow("kube_service", tag[0])
 			case 2:
-				tagList.AddLow(tag[0], tag[1])
+				if orchestratorCardMetadataTags[tag[0]] {
+					tagList.AddOrchestrator(tag[0], tag[1])
+				} else {
+					tagList.AddLow(tag[0], tag[1])
+				}
 			default:
 				continue
 			}
@@ -46,6 +46,12 @@ type FakeDCAClient struct {
 
 	EndpointsCheckConfigs    types.ConfigResponse
 	EndpointsCheckConfigsErr error
+
+	WorkloadBlocklist    []apiv1.BlocklistRule
+	WorkloadBlocklistErr error
+
+	ServicesByClusterIP    map[string]apiv1.KubeService
+	ServicesByClusterIPErr error
 }
 
 func (f *FakeDCAClient) Version() version.Version {
@@ -79,6 +85,14 @@ func (f *FakeDCAClient) GetEndpointsCheckConfigs(nodeName string) (types.ConfigR
 	return f.EndpointsCheckConfigs, f.EndpointsCheckConfigsErr
 }
 
+func (f *FakeDCAClient) GetWorkloadBlocklist() ([]apiv1.BlocklistRule, error) {
+	return f.WorkloadBlocklist, f.WorkloadBlocklistErr
+}
+
+func (f *FakeDCAClient) GetServicesByClusterIP(etag string) (map[string]apiv1.KubeService, string, error) {
+	return f.ServicesByClusterIP, "", f.ServicesByClusterIPErr
+}
+
 func TestKubeMetadataCollector_getMetadaNames(t *testing.T) {
 	type fields struct {
 		dcaClient           clusteragent.DCAClientInterface
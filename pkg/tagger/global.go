@@ -13,13 +13,38 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/component"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-// defaultTagger is the shared tagger instance backing the global Tag and Init functions
+// ComponentName is the name of the tagger in the component registries
+const ComponentName = "tagger"
+
+// Component is the tagger backing the global functions. A binary embedding
+// the agent, or a test, registers its own in the default component registry.
+type Component interface {
+	Init(catalog collectors.Catalog)
+	Tag(entity string, cardinality collectors.TagCardinality) ([]string, error)
+	Stop() error
+	Purge()
+	List(cardinality collectors.TagCardinality) response.TaggerListResponse
+	GetEntityHash(entity string) string
+}
+
+// defaultTagger is the shared tagger instance backing the global Tag and Init
+// functions, unless another tagger is registered
 var defaultTagger *Tagger
 var initOnce sync.Once
 
+// getTagger returns the tagger of the default component registry, or
+// defaultTagger
+func getTagger() Component {
+	if t, ok := component.Default().Get(ComponentName).(Component); ok {
+		return t
+	}
+	return defaultTagger
+}
+
 // ChecksCardinality defines the cardinality of tags we should send for check metrics
 // this can still be overridden when calling get_tags in python checks.
 var ChecksCardinality collectors.TagCardinality
@@ -32,8 +57,8 @@ var DogstatsdCardinality collectors.TagCardinality
 func Init() {
 	initOnce.Do(func() {
 		var err error
-		checkCard := config.Datadog.GetString("checks_tag_cardinality")
-		dsdCard := config.Datadog.GetString("dogstatsd_tag_cardinality")
+		checkCard := config.Get().GetString("checks_tag_cardinality")
+		dsdCard := config.Get().GetString("dogstatsd_tag_cardinality")
 
 		ChecksCardinality, err = stringToTagCardinality(checkCard)
 		if err != nil {
@@ -46,30 +71,35 @@ func Init() {
 			DogstatsdCardinality = collectors.LowCardinality
 		}
 
-		defaultTagger.Init(collectors.DefaultCatalog)
+		getTagger().Init(collectors.DefaultCatalog)
 	})
 }
 
-// Tag queries the defaultTagger to get entity tags from cache or sources.
+// Tag queries the tagger component to get entity tags from cache or sources.
 // It can return tags at high cardinality (with tags about individual containers),
 // or at orchestrator cardinality (pod/task level)
 func Tag(entity string, cardinality collectors.TagCardinality) ([]string, error) {
-	return defaultTagger.Tag(entity, cardinality)
+	return getTagger().Tag(entity, cardinality)
 }
 
-// Stop queues a stop signal to the defaultTagger
+// Stop queues a stop signal to the tagger component
 func Stop() error {
-	return defaultTagger.Stop()
+	return getTagger().Stop()
+}
+
+// Purge deletes the entities removed from the tagger component collectors
+func Purge() {
+	getTagger().Purge()
 }
 
-// List the content of the defaulTagger
+// List the content of the tagger component
 func List(cardinality collectors.TagCardinality) response.TaggerListResponse {
-	return defaultTagger.List(cardinality)
+	return getTagger().List(cardinality)
 }
 
 // GetEntityHash returns the hash for the tags associated with the given entity
 func GetEntityHash(entity string) string {
-	return defaultTagger.GetEntityHash(entity)
+	return getTagger().GetEntityHash(entity)
 }
 
 // stringToTagCardinality extracts a TagCardinality from a string.
@@ -88,5 +118,5 @@ func stringToTagCardinality(c string) (collectors.TagCardinality, error) {
 }
 
 func init() {
-	defaultTagger = newTagger()
+	defaultTagger = NewTagger()
 }
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package tagger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/component"
+)
+
+// staticTagger returns the same tags for all the entities
+type staticTagger struct {
+	tags []string
+}
+
+func (t *staticTagger) Init(collectors.Catalog) {}
+func (t *staticTagger) Tag(string, collectors.TagCardinality) ([]string, error) {
+	return t.tags, nil
+}
+func (t *staticTagger) Stop() error { return nil }
+func (t *staticTagger) Purge()      {}
+func (t *staticTagger) List(collectors.TagCardinality) response.TaggerListResponse {
+	return response.TaggerListResponse{}
+}
+func (t *staticTagger) GetEntityHash(string) string { return "" }
+
+func TestRegisteredTagger(t *testing.T) {
+	assert.True(t, getTagger() == Component(defaultTagger))
+
+	restore := component.Default().Override(ComponentName, &staticTagger{tags: []string{"env:test"}})
+	tags, err := Tag("docker://abc", collectors.HighCardinality)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"env:test"}, tags)
+	restore()
+	assert.True(t, getTagger() == Component(defaultTagger))
+
+	// a registry of its own, like a binary embedding the agent
+	registry := component.NewRegistry()
+	registry.Register(ComponentName, &staticTagger{tags: []string{"embedded:true"}})
+	restore = component.SetDefault(registry)
+	defer restore()
+	tags, err = Tag("docker://abc", collectors.HighCardinality)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"embedded:true"}, tags)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/errors"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
@@ -34,6 +35,7 @@ type Tagger struct {
 	pullTicker  *time.Ticker
 	pruneTicker *time.Ticker
 	retryTicker *time.Ticker
+	purge       chan chan struct{}
 	stop        chan bool
 	health      *health.Handle
 }
@@ -44,11 +46,12 @@ type collectorReply struct {
 	instance collectors.Collector
 }
 
-// newTagger returns an allocated tagger. You still have to run Init()
+// NewTagger returns an allocated tagger. You still have to run Init()
 // once the config package is ready.
 // You are probably looking for tagger.Tag() using the global instance
-// instead of creating your own.
-func newTagger() *Tagger {
+// instead of creating your own, unless you register it as the tagger
+// component of a registry.
+func NewTagger() *Tagger {
 	return &Tagger{
 		tagStore:    newTagStore(),
 		candidates:  make(map[string]collectors.CollectorFactory),
@@ -59,6 +62,7 @@ func newTagger() *Tagger {
 		pullTicker:  time.NewTicker(5 * time.Second),
 		pruneTicker: time.NewTicker(5 * time.Minute),
 		retryTicker: time.NewTicker(30 * time.Second),
+		purge:       make(chan chan struct{}),
 		stop:        make(chan bool),
 	}
 }
@@ -77,6 +81,12 @@ func (t *Tagger) Init(catalog collectors.Catalog) {
 	for name, factory := range catalog {
 		t.candidates[name] = factory
 	}
+
+	// the deleted pods are pruned within their grace period
+	if gracePeriod := time.Duration(config.Get().GetInt("kubernetes_deleted_pods_grace_period")) * time.Second; gracePeriod > 0 && gracePeriod < 5*time.Minute {
+		t.pruneTicker.Stop()
+		t.pruneTicker = time.NewTicker(gracePeriod)
+	}
 	t.Unlock()
 
 	t.startCollectors()
@@ -112,6 +122,11 @@ func (t *Tagger) run() error {
 			go t.pull()
 		case <-t.pruneTicker.C:
 			t.tagStore.prune()
+		case done := <-t.purge:
+			// the deletions pulled before the purge are processed first
+			t.processPendingInfo()
+			t.tagStore.prune()
+			close(done)
 		}
 	}
 }
@@ -197,6 +212,20 @@ func (t *Tagger) registerCollectors(replies []collectorReply) {
 	t.Unlock()
 }
 
+// processPendingInfo processes the messages already queued by the collectors
+func (t *Tagger) processPendingInfo() {
+	for {
+		select {
+		case msg := <-t.infoIn:
+			for _, info := range msg {
+				t.tagStore.processTagInfo(info)
+			}
+		default:
+			return
+		}
+	}
+}
+
 func (t *Tagger) pull() {
 	t.RLock()
 	for _, puller := range t.pullers {
@@ -208,6 +237,15 @@ func (t *Tagger) pull() {
 	t.RUnlock()
 }
 
+// Purge pulls the collectors and deletes the entities they removed right
+// away, instead of waiting for the next prune
+func (t *Tagger) Purge() {
+	t.pull()
+	done := make(chan struct{})
+	t.purge <- done
+	<-done
+}
+
 // Stop queues a shutdown of Tagger
 func (t *Tagger) Stop() error {
 	t.stop <- true
@@ -79,7 +79,7 @@ func TestInit(t *testing.T) {
 	}
 	assert.Equal(t, 3, len(catalog))
 
-	tagger := newTagger()
+	tagger := NewTagger()
 	tagger.Init(catalog)
 
 	assert.Equal(t, 3, len(tagger.fetchers))
@@ -101,7 +101,7 @@ func TestInit(t *testing.T) {
 
 func TestFetchAllMiss(t *testing.T) {
 	catalog := collectors.Catalog{"stream": NewDummyStreamer, "pull": NewDummyPuller}
-	tagger := newTagger()
+	tagger := NewTagger()
 	tagger.Init(catalog)
 
 	streamer := tagger.streamers["stream"].(*DummyCollector)
@@ -123,7 +123,7 @@ func TestFetchAllMiss(t *testing.T) {
 
 func TestFetchAllCached(t *testing.T) {
 	catalog := collectors.Catalog{"stream": NewDummyStreamer, "pull": NewDummyPuller}
-	tagger := newTagger()
+	tagger := NewTagger()
 	tagger.Init(catalog)
 
 	tagger.tagStore.processTagInfo(&collectors.TagInfo{
@@ -167,7 +167,7 @@ func TestFetchOneCached(t *testing.T) {
 		"pull":    NewDummyPuller,
 		"fetcher": NewDummyFetcher,
 	}
-	tagger := newTagger()
+	tagger := NewTagger()
 	tagger.Init(catalog)
 
 	tagger.tagStore.processTagInfo(&collectors.TagInfo{
@@ -201,7 +201,7 @@ func TestEmptyEntity(t *testing.T) {
 	catalog := collectors.Catalog{
 		"fetcher": NewDummyFetcher,
 	}
-	tagger := newTagger()
+	tagger := NewTagger()
 	tagger.Init(catalog)
 
 	tagger.tagStore.processTagInfo(&collectors.TagInfo{
@@ -228,7 +228,7 @@ func TestRetryCollector(t *testing.T) {
 	catalog := collectors.Catalog{
 		"fetcher": func() collectors.Collector { return c },
 	}
-	tagger := newTagger()
+	tagger := NewTagger()
 	tagger.Init(catalog)
 
 	assert.Len(t, tagger.candidates, 1)
@@ -264,7 +264,7 @@ func TestErrNotFound(t *testing.T) {
 	catalog := collectors.Catalog{
 		"fetcher": func() collectors.Collector { return c },
 	}
-	tagger := newTagger()
+	tagger := NewTagger()
 	tagger.Init(catalog)
 
 	// Result should not be cached
@@ -288,7 +288,7 @@ func TestErrNotFound(t *testing.T) {
 
 func TestSafeCache(t *testing.T) {
 	catalog := collectors.Catalog{"pull": NewDummyPuller}
-	tagger := newTagger()
+	tagger := NewTagger()
 	tagger.Init(catalog)
 
 	tagger.tagStore.processTagInfo(&collectors.TagInfo{
@@ -310,3 +310,34 @@ func TestSafeCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, []string{"low1", "low2", "low3"}, tags2)
 }
+
+func TestPurge(t *testing.T) {
+	newDeletingPuller := func() collectors.Collector {
+		var out chan<- []*collectors.TagInfo
+		c := new(DummyCollector)
+		c.On("Detect", mock.Anything).Return(collectors.PullCollection, nil).Run(func(args mock.Arguments) {
+			out = args.Get(0).(chan<- []*collectors.TagInfo)
+		})
+		c.On("Pull").Return(nil).Run(func(args mock.Arguments) {
+			out <- []*collectors.TagInfo{{Source: "pull", Entity: "entity_name", DeleteEntity: true}}
+		})
+		return c
+	}
+
+	tagger := NewTagger()
+	tagger.Init(collectors.Catalog{"pull": newDeletingPuller})
+	defer tagger.Stop()
+
+	tagger.tagStore.processTagInfo(&collectors.TagInfo{
+		Source:      "stream",
+		Entity:      "entity_name",
+		LowCardTags: []string{"low"},
+	})
+	_, sources, _ := tagger.tagStore.lookup("entity_name", collectors.LowCardinality)
+	assert.Len(t, sources, 1)
+
+	// the entity deleted by the puller is pruned right away
+	tagger.Purge()
+	_, sources, _ = tagger.tagStore.lookup("entity_name", collectors.LowCardinality)
+	assert.Len(t, sources, 0)
+}
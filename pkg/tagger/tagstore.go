@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/DataDog/datadog-agent/pkg/util/intern"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
@@ -83,6 +84,10 @@ func (s *tagStore) processTagInfo(info *collectors.TagInfo) error {
 		log.Tracef("processTagInfo err: %v", err)
 		return err
 	}
+	// the same tags are collected for many entities
+	intern.InternSlice(info.LowCardTags)
+	intern.InternSlice(info.OrchestratorCardTags)
+	intern.InternSlice(info.HighCardTags)
 	storedTags.lowCardTags[info.Source] = info.LowCardTags
 	storedTags.orchestratorCardTags[info.Source] = info.OrchestratorCardTags
 	storedTags.highCardTags[info.Source] = info.HighCardTags
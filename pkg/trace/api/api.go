@@ -29,6 +29,7 @@ import (
 
 	"github.com/tinylib/msgp/msgp"
 
+	ddconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
@@ -177,6 +178,9 @@ func (r *HTTPReceiver) attachDebugHandlers(mux *http.ServeMux) {
 	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 
+	// the origin of the configuration values, for the `agent config check-origin` command
+	mux.HandleFunc("/debug/config/origin", ddconfig.ValueWithOriginHandler(ddconfig.Datadog))
+
 	mux.HandleFunc("/debug/blockrate", func(w http.ResponseWriter, r *http.Request) {
 		// this endpoint calls runtime.SetBlockProfileRate(v), where v is an optional
 		// query string parameter defaulting to 10000 (1 sample per 10μs blocked).
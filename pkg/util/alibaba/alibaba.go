@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -16,10 +18,32 @@ import (
 
 // declare these as vars not const to ease testing
 var (
-	metadataURL = "http://100.100.100.200"
-	timeout     = 300 * time.Millisecond
+	metadataURL   = "http://100.100.100.200"
+	timeout       = 300 * time.Millisecond
+	tokenTTL      = 6 * time.Hour
+	sysVendorPath = "/sys/class/dmi/id/sys_vendor"
 )
 
+// sysVendor is the DMI system vendor of the Alibaba Cloud instances
+const sysVendor = "Alibaba Cloud"
+
+var errNotAlibaba = fmt.Errorf("not running on Alibaba Cloud")
+
+// IsRunningOn returns whether the agent runs on an Alibaba Cloud instance,
+// from its DMI system vendor, so that the metadata api, and its token in
+// security hardening mode, are only requested there
+func IsRunningOn() bool {
+	vendor, err := ioutil.ReadFile(sysVendorPath)
+	return err == nil && strings.TrimSpace(string(vendor)) == sysVendor
+}
+
+// the token of the metadata service in security hardening mode, see getToken
+var token struct {
+	sync.Mutex
+	value   string
+	expires time.Time
+}
+
 // GetHostAlias returns the VM ID from the Alibaba Metadata api
 func GetHostAlias() (string, error) {
 	res, err := getResponseWithMaxLength(metadataURL+"/latest/meta-data/instance-id",
@@ -30,6 +54,59 @@ func GetHostAlias() (string, error) {
 	return res, err
 }
 
+// GetHostname returns the hostname of the instance from the Alibaba Metadata api
+func GetHostname() (string, error) {
+	if !IsRunningOn() {
+		return "", errNotAlibaba
+	}
+	res, err := getResponseWithMaxLength(metadataURL+"/latest/meta-data/hostname",
+		config.Datadog.GetInt("metadata_endpoints_max_hostname_size"))
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve hostname from Alibaba: %s", err)
+	}
+	return res, nil
+}
+
+// GetTags returns the region, the zone, the instance type and the instance
+// tags from the Alibaba Metadata api. The instance tags are only served when
+// their access is enabled on the instance.
+func GetTags() ([]string, error) {
+	if !IsRunningOn() {
+		return nil, errNotAlibaba
+	}
+	var tags []string
+	for _, tag := range []struct{ name, path string }{
+		{"region", "/latest/meta-data/region-id"},
+		{"zone", "/latest/meta-data/zone-id"},
+		{"instance-type", "/latest/meta-data/instance/instance-type"},
+		{"instance-id", "/latest/meta-data/instance-id"},
+	} {
+		value, err := getResponse(metadataURL + tag.path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve the %s from Alibaba: %s", tag.name, err)
+		}
+		tags = append(tags, fmt.Sprintf("%s:%s", tag.name, strings.TrimSpace(value)))
+	}
+
+	keys, err := getResponse(metadataURL + "/latest/meta-data/tags/instance/")
+	if err != nil {
+		// the instance tags aren't exposed in the metadata
+		return tags, nil
+	}
+	for _, key := range strings.Split(keys, "\n") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		value, err := getResponse(metadataURL + "/latest/meta-data/tags/instance/" + key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve the instance tag %s from Alibaba: %s", key, err)
+		}
+		tags = append(tags, fmt.Sprintf("%s:%s", key, strings.TrimSpace(value)))
+	}
+	return tags, nil
+}
+
 func getResponseWithMaxLength(endpoint string, maxLength int) (string, error) {
 	result, err := getResponse(endpoint)
 	if err != nil {
@@ -50,17 +127,20 @@ func getResponse(url string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if t := getToken(); t != "" {
+		req.Header.Set("X-aliyun-ecs-metadata-token", t)
+	}
 
 	res, err := client.Do(req)
 	if err != nil {
 		return "", err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
 		return "", fmt.Errorf("status code %d trying to GET %s", res.StatusCode, url)
 	}
 
-	defer res.Body.Close()
 	all, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return "", fmt.Errorf("error while reading response from alibaba metadata endpoint: %s", err)
@@ -68,3 +148,50 @@ func getResponse(url string) (string, error) {
 
 	return string(all), nil
 }
+
+// getToken returns a token of the metadata service, required by the
+// instances in security hardening mode and accepted by the others. An empty
+// token is returned when it can't be retrieved, the requests are then sent
+// without token as in normal mode.
+func getToken() string {
+	token.Lock()
+	defer token.Unlock()
+	if time.Now().Before(token.expires) {
+		return token.value
+	}
+	// on failure, don't ask again for a while: outside of Alibaba, the
+	// request only times out
+	token.value = ""
+	token.expires = time.Now().Add(time.Minute)
+
+	client := http.Client{
+		Timeout: timeout,
+	}
+	req, err := http.NewRequest("PUT", metadataURL+"/latest/api/token", nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("X-aliyun-ecs-metadata-token-ttl-seconds", fmt.Sprintf("%d", int(tokenTTL/time.Second)))
+	res, err := client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return ""
+	}
+	all, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return ""
+	}
+
+	token.value = strings.TrimSpace(string(all))
+	// renew the token before it expires
+	token.expires = time.Now().Add(tokenTTL - time.Minute)
+	return token.value
+}
+
+// HostnameProvider Alibaba implementation of the HostnameProvider
+func HostnameProvider() (string, error) {
+	return GetHostname()
+}
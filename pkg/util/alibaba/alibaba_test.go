@@ -7,14 +7,63 @@ package alibaba
 
 import (
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// TestMain runs the tests as on an Alibaba Cloud instance, with a mocked DMI
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "alibaba-dmi")
+	if err != nil {
+		panic(err)
+	}
+	sysVendorPath = filepath.Join(dir, "dmi")
+	if err := ioutil.WriteFile(sysVendorPath, []byte("Alibaba Cloud\n"), 0644); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestNotRunningOn(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+	defer func(path string) { sysVendorPath = path }(sysVendorPath)
+
+	for _, path := range []string{"other", "missing"} {
+		sysVendorPath = filepath.Join(filepath.Dir(sysVendorPath), path)
+		if path == "other" {
+			require.NoError(t, ioutil.WriteFile(sysVendorPath, []byte("Google\n"), 0644))
+		}
+		assert.False(t, IsRunningOn())
+		_, err := GetHostname()
+		assert.Equal(t, errNotAlibaba, err)
+		_, err = GetTags()
+		assert.Equal(t, errNotAlibaba, err)
+	}
+}
+
+func resetToken() {
+	token.Lock()
+	defer token.Unlock()
+	token.value = ""
+	token.expires = time.Time{}
+}
+
 func TestGetHostname(t *testing.T) {
+	resetToken()
 	expected := "i-rj9aql2pwopjn4sm24ix"
 	var lastRequest *http.Request
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -30,3 +79,77 @@ func TestGetHostname(t *testing.T) {
 	assert.Equal(t, expected, val)
 	assert.Equal(t, lastRequest.URL.Path, "/latest/meta-data/instance-id")
 }
+
+func TestHardenedMode(t *testing.T) {
+	resetToken()
+	tokens := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" && r.URL.Path == "/latest/api/token" {
+			assert.Equal(t, "21600", r.Header.Get("X-aliyun-ecs-metadata-token-ttl-seconds"))
+			tokens++
+			io.WriteString(w, "secret")
+			return
+		}
+		if r.Header.Get("X-aliyun-ecs-metadata-token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/latest/meta-data/hostname":
+			io.WriteString(w, "iZbp1example")
+		case "/latest/meta-data/region-id":
+			io.WriteString(w, "cn-hangzhou")
+		case "/latest/meta-data/zone-id":
+			io.WriteString(w, "cn-hangzhou-i")
+		case "/latest/meta-data/instance/instance-type":
+			io.WriteString(w, "ecs.g6.large")
+		case "/latest/meta-data/instance-id":
+			io.WriteString(w, "i-bp1example")
+		case "/latest/meta-data/tags/instance/":
+			io.WriteString(w, "env\nteam")
+		case "/latest/meta-data/tags/instance/env":
+			io.WriteString(w, "prod")
+		case "/latest/meta-data/tags/instance/team":
+			io.WriteString(w, "web")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	hostname, err := GetHostname()
+	require.NoError(t, err)
+	assert.Equal(t, "iZbp1example", hostname)
+
+	tags, err := GetTags()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"region:cn-hangzhou",
+		"zone:cn-hangzhou-i",
+		"instance-type:ecs.g6.large",
+		"instance-id:i-bp1example",
+		"env:prod",
+		"team:web",
+	}, tags)
+	// the token is reused
+	assert.Equal(t, 1, tokens)
+}
+
+func TestNormalModeWithoutTags(t *testing.T) {
+	resetToken()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/meta-data/region-id", "/latest/meta-data/zone-id", "/latest/meta-data/instance/instance-type", "/latest/meta-data/instance-id":
+			io.WriteString(w, "value")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	tags, err := GetTags()
+	require.NoError(t, err)
+	assert.Len(t, tags, 4)
+}
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package audit records the changes of the agent configuration and of the
+// checks schedule to an append-only local file, so that operators can find
+// out when and why a check stopped running.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Event types
+const (
+	AgentStarted        = "agent_started"
+	AgentStopped        = "agent_stopped"
+	ConfigChanged       = "config_changed"
+	CheckConfigChanged  = "check_config_changed"
+	CheckConfigDisabled = "check_config_disabled"
+	CheckScheduled      = "check_scheduled"
+	CheckUnscheduled    = "check_unscheduled"
+	CheckReloaded       = "check_reloaded"
+)
+
+// Event is an entry of the audit log
+type Event struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Type      string            `json:"type"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// Log is an append-only audit log rotated on size
+type Log struct {
+	m        sync.Mutex
+	path     string
+	maxSize  int64
+	maxRolls int
+	file     *os.File
+	size     int64
+}
+
+var (
+	globalLog *Log
+	globalM   sync.RWMutex
+)
+
+// NewLog opens the audit log at path, creating it if needed. The file is
+// rotated once it reaches maxSize bytes, keeping maxRolls rotated files.
+func NewLog(path string, maxSize int64, maxRolls int) (*Log, error) {
+	l := &Log{
+		path:     path,
+		maxSize:  maxSize,
+		maxRolls: maxRolls,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record appends an event to the log
+func (l *Log) Record(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("audit log %s is closed", l.path)
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// Close closes the log
+func (l *Log) Close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func (l *Log) open() error {
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = fi.Size()
+	return nil
+}
+
+// rotate renames the log to path.1, path.1 to path.2... and reopens it
+func (l *Log) rotate() error {
+	l.file.Close()
+	l.file = nil
+
+	if l.maxRolls < 1 {
+		os.Remove(l.path)
+	} else {
+		for i := l.maxRolls - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+		}
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			log.Warnf("Could not rotate the audit log: %v", err)
+		}
+	}
+	return l.open()
+}
+
+// Init opens the global audit log
+func Init(path string, maxSize int64, maxRolls int) error {
+	l, err := NewLog(path, maxSize, maxRolls)
+	if err != nil {
+		return err
+	}
+	globalM.Lock()
+	defer globalM.Unlock()
+	if globalLog != nil {
+		globalLog.Close()
+	}
+	globalLog = l
+	return nil
+}
+
+// Close closes the global audit log
+func Close() {
+	globalM.Lock()
+	defer globalM.Unlock()
+	if globalLog != nil {
+		globalLog.Close()
+		globalLog = nil
+	}
+}
+
+// GetFilePath returns the path of the global audit log, empty if disabled
+func GetFilePath() string {
+	globalM.RLock()
+	defer globalM.RUnlock()
+	if globalLog == nil {
+		return ""
+	}
+	return globalLog.path
+}
+
+// Record appends an event to the global audit log, it's a noop if the audit
+// log is disabled
+func Record(eventType, message string, fields map[string]string) {
+	globalM.RLock()
+	defer globalM.RUnlock()
+	if globalLog == nil {
+		return
+	}
+	event := Event{
+		Timestamp: time.Now().UTC(),
+		Type:      eventType,
+		Message:   message,
+		Fields:    fields,
+	}
+	if err := globalLog.Record(event); err != nil {
+		log.Debugf("Could not record audit event %s: %v", eventType, err)
+	}
+}
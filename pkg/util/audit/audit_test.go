@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEvents(t *testing.T, path string) []Event {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestRecordAppends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	require.NoError(t, Init(path, 1024*1024, 1))
+	Record(CheckScheduled, "Check cpu scheduled", map[string]string{"provider": "file"})
+	Close()
+
+	// events survive restarts
+	require.NoError(t, Init(path, 1024*1024, 1))
+	Record(CheckUnscheduled, "Check cpu unscheduled", nil)
+	assert.Equal(t, path, GetFilePath())
+	Close()
+
+	events := readEvents(t, path)
+	require.Len(t, events, 2)
+	assert.Equal(t, CheckScheduled, events[0].Type)
+	assert.Equal(t, "file", events[0].Fields["provider"])
+	assert.Equal(t, CheckUnscheduled, events[1].Type)
+	assert.False(t, events[1].Timestamp.IsZero())
+
+	// noop once closed
+	assert.Equal(t, "", GetFilePath())
+	Record(CheckScheduled, "Check cpu scheduled", nil)
+	assert.Len(t, readEvents(t, path), 2)
+}
+
+func TestRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	l, err := NewLog(path, 200, 2)
+	require.NoError(t, err)
+	defer l.Close()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, l.Record(Event{Type: ConfigChanged, Message: "Agent configuration file written from the GUI"}))
+	}
+
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		fi, err := os.Stat(p)
+		require.NoError(t, err)
+		assert.True(t, fi.Size() <= 200)
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package buffer instruments the buffered channels between the internal
+// components of the agent. The number of items a channel holds is capped by
+// the size of its Buffer, which can be lowered at runtime and raised back up
+// to the capacity of the channel, and the Buffer tracks the time its senders
+// are blocked and the items they drop to guide the sizing.
+package buffer
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	buffersExpvars = expvar.NewMap("buffers")
+
+	buffers   = make(map[string]*Buffer)
+	buffersMu sync.RWMutex
+)
+
+func init() {
+	buffersExpvars.Set("Buffers", expvar.Func(func() interface{} {
+		return GetStats()
+	}))
+}
+
+// Stats holds the telemetry of a Buffer
+type Stats struct {
+	Size     int   `json:"size"`
+	Capacity int   `json:"capacity"`
+	Length   int   `json:"length"`
+	Sends    int64 `json:"sends"`
+	Drops    int64 `json:"drops"`
+	// BlockedTime is the total time the senders waited for room, in milliseconds
+	BlockedTime int64 `json:"blocked_time_ms"`
+}
+
+// Buffer caps the number of items held by a channel. The senders call Acquire
+// or TryAcquire before sending to the channel, the receivers call Release
+// after receiving from it. The channel must be created with Capacity().
+//
+// The room is counted with atomic operations, the senders only synchronize
+// when they wait for room in a full buffer.
+//
+// A nil Buffer doesn't cap anything, for the channels built without one.
+type Buffer struct {
+	// the int64 fields first, for their alignment on 32-bit platforms
+	size        int64
+	length      int64
+	sends       int64
+	drops       int64
+	blockedTime int64 // in nanoseconds
+
+	name     string
+	capacity int
+
+	// waiters is the number of senders waiting for room, woken one at a time
+	// through wake
+	waiters int32
+	wake    chan struct{}
+}
+
+// New returns a Buffer of the given size, which is also its capacity,
+// registered under name. A Buffer previously registered under the same name
+// is replaced.
+func New(name string, size int) *Buffer {
+	if size < 1 {
+		size = 1
+	}
+	b := &Buffer{
+		name:     name,
+		capacity: size,
+		size:     int64(size),
+		wake:     make(chan struct{}, 1),
+	}
+
+	buffersMu.Lock()
+	buffers[name] = b
+	buffersMu.Unlock()
+	return b
+}
+
+// GetOrNew returns the Buffer registered under name, creating it if needed,
+// for the channels rebuilt when their component restarts
+func GetOrNew(name string, size int) *Buffer {
+	if b := Get(name); b != nil {
+		return b
+	}
+	return New(name, size)
+}
+
+// Get returns the Buffer registered under name, nil if there is none
+func Get(name string) *Buffer {
+	buffersMu.RLock()
+	defer buffersMu.RUnlock()
+	return buffers[name]
+}
+
+// SetSize changes the size of the Buffer registered under name
+func SetSize(name string, size int) error {
+	b := Get(name)
+	if b == nil {
+		return fmt.Errorf("unknown buffer %q", name)
+	}
+	return b.SetSize(size)
+}
+
+// GetStats returns the telemetry of the registered buffers, by name
+func GetStats() map[string]Stats {
+	buffersMu.RLock()
+	defer buffersMu.RUnlock()
+	stats := make(map[string]Stats, len(buffers))
+	for name, b := range buffers {
+		stats[name] = b.Stats()
+	}
+	return stats
+}
+
+// Capacity returns the capacity the channel must be created with
+func (b *Buffer) Capacity() int {
+	return b.capacity
+}
+
+// Acquire waits for room in the channel before a send
+func (b *Buffer) Acquire() {
+	if b == nil {
+		return
+	}
+	atomic.AddInt64(&b.sends, 1)
+	if b.reserve() {
+		return
+	}
+
+	start := time.Now()
+	atomic.AddInt32(&b.waiters, 1)
+	for !b.reserve() {
+		<-b.wake
+	}
+	atomic.AddInt32(&b.waiters, -1)
+	atomic.AddInt64(&b.blockedTime, int64(time.Since(start)))
+	// pass the wake up on if there is still room for another waiter
+	b.wakeWaiter()
+}
+
+// TryAcquire reserves room in the channel before a send, it returns false and
+// counts a drop if the channel is full
+func (b *Buffer) TryAcquire() bool {
+	if b == nil {
+		return true
+	}
+	if !b.reserve() {
+		atomic.AddInt64(&b.drops, 1)
+		return false
+	}
+	atomic.AddInt64(&b.sends, 1)
+	return true
+}
+
+// reserve increments the length if it is under the size
+func (b *Buffer) reserve() bool {
+	for {
+		length := atomic.LoadInt64(&b.length)
+		if length >= atomic.LoadInt64(&b.size) {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&b.length, length, length+1) {
+			return true
+		}
+	}
+}
+
+// wakeWaiter wakes a sender waiting for room, if any
+func (b *Buffer) wakeWaiter() {
+	if atomic.LoadInt32(&b.waiters) == 0 || atomic.LoadInt64(&b.length) >= atomic.LoadInt64(&b.size) {
+		return
+	}
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Release frees the room of an item received from the channel
+func (b *Buffer) Release() {
+	if b == nil {
+		return
+	}
+	for {
+		length := atomic.LoadInt64(&b.length)
+		// the senders not acquiring room must not make the length negative
+		if length <= 0 {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&b.length, length, length-1) {
+			break
+		}
+	}
+	b.wakeWaiter()
+}
+
+// Size returns the maximum number of items of the channel
+func (b *Buffer) Size() int {
+	return int(atomic.LoadInt64(&b.size))
+}
+
+// SetSize changes the maximum number of items of the channel, up to its
+// capacity. Shrinking the buffer doesn't drop the items it holds, the senders
+// wait for the length to go below the new size.
+func (b *Buffer) SetSize(size int) error {
+	if size < 1 || size > b.capacity {
+		return fmt.Errorf("invalid size %d for buffer %q, it must be between 1 and %d", size, b.name, b.capacity)
+	}
+	atomic.StoreInt64(&b.size, int64(size))
+	b.wakeWaiter()
+	return nil
+}
+
+// Stats returns the telemetry of the Buffer
+func (b *Buffer) Stats() Stats {
+	return Stats{
+		Size:        b.Size(),
+		Capacity:    b.capacity,
+		Length:      int(atomic.LoadInt64(&b.length)),
+		Sends:       atomic.LoadInt64(&b.sends),
+		Drops:       atomic.LoadInt64(&b.drops),
+		BlockedTime: atomic.LoadInt64(&b.blockedTime) / int64(time.Millisecond),
+	}
+}
@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package buffer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireBlocksUntilRelease(t *testing.T) {
+	b := New("test_acquire", 1)
+	assert.Equal(t, 1, b.Capacity())
+
+	b.Acquire()
+	acquired := make(chan struct{})
+	go func() {
+		b.Acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		assert.FailNow(t, "acquired room in a full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b.Release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		assert.FailNow(t, "room not acquired after a release")
+	}
+
+	stats := b.Stats()
+	assert.Equal(t, 1, stats.Length)
+	assert.Equal(t, int64(2), stats.Sends)
+	assert.True(t, stats.BlockedTime >= 50)
+}
+
+func TestTryAcquireDrops(t *testing.T) {
+	b := New("test_try_acquire", 2)
+
+	assert.True(t, b.TryAcquire())
+	assert.True(t, b.TryAcquire())
+	assert.False(t, b.TryAcquire())
+
+	b.Release()
+	assert.True(t, b.TryAcquire())
+
+	stats := b.Stats()
+	assert.Equal(t, int64(3), stats.Sends)
+	assert.Equal(t, int64(1), stats.Drops)
+}
+
+func TestSetSize(t *testing.T) {
+	b := New("test_set_size", 2)
+	require.NoError(t, b.SetSize(1))
+	b.Acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		b.Acquire()
+		close(acquired)
+	}()
+
+	require.NoError(t, b.SetSize(2))
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		assert.FailNow(t, "room not acquired after growing the buffer")
+	}
+
+	// the size can't exceed the capacity of the channel
+	assert.Error(t, b.SetSize(0))
+	assert.Error(t, b.SetSize(3))
+	assert.Equal(t, 2, b.Size())
+}
+
+func TestConcurrentAcquireRelease(t *testing.T) {
+	b := New("test_concurrent", 4)
+	ch := make(chan int, b.Capacity())
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				b.Acquire()
+				ch <- n
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		for n := 0; n < 8*1000; n++ {
+			<-ch
+			b.Release()
+		}
+		close(done)
+	}()
+	wg.Wait()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		assert.FailNow(t, "senders blocked")
+	}
+
+	stats := b.Stats()
+	assert.Equal(t, 0, stats.Length)
+	assert.Equal(t, int64(8000), stats.Sends)
+}
+
+func TestRegistry(t *testing.T) {
+	b := New("test_registry", 5)
+	assert.Equal(t, b, Get("test_registry"))
+	assert.Equal(t, b, GetOrNew("test_registry", 1))
+	assert.Nil(t, Get("test_unknown"))
+
+	require.NoError(t, SetSize("test_registry", 3))
+	assert.Equal(t, 3, GetStats()["test_registry"].Size)
+	assert.Error(t, SetSize("test_unknown", 3))
+}
+
+func TestNilBuffer(t *testing.T) {
+	var b *Buffer
+	b.Acquire()
+	assert.True(t, b.TryAcquire())
+	b.Release()
+}
@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package clockdrift tracks the offset between the local clock and the
+// intake or ntp servers clocks, and optionally corrects metrics timestamps.
+package clockdrift
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// SourceNTP is used when the offset comes from the ntp check
+	SourceNTP = "ntp"
+	// SourceIntake is used when the offset comes from the intake responses
+	SourceIntake = "intake"
+
+	// number of intake samples the median offset is computed on
+	intakeSamples = 5
+	// the ntp check runs every 15 minutes, its offset is preferred while fresh
+	ntpValidity = time.Hour
+)
+
+// Tracker tracks the offset between the local clock and a reference clock.
+// Offsets are positive when the local clock is late: adding the offset to the
+// local time gives the reference time.
+type Tracker struct {
+	m sync.Mutex
+
+	intakeOffsets []time.Duration
+	intakeNext    int
+	ntpOffset     time.Duration
+	ntpUpdated    time.Time
+
+	// for testing purpose
+	now func() time.Time
+}
+
+// NewTracker returns a new Tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		intakeOffsets: make([]time.Duration, 0, intakeSamples),
+		now:           time.Now,
+	}
+}
+
+// ReportIntakeDate records the Date header of an intake response. The request
+// was sent at `sent` and its response received at `received`, local time.
+func (t *Tracker) ReportIntakeDate(date, sent, received time.Time) {
+	// The Date header has a one second resolution, compare it to the middle
+	// of the round trip
+	local := sent.Add(received.Sub(sent) / 2)
+	offset := date.Add(500 * time.Millisecond).Sub(local)
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	if len(t.intakeOffsets) < intakeSamples {
+		t.intakeOffsets = append(t.intakeOffsets, offset)
+	} else {
+		t.intakeOffsets[t.intakeNext] = offset
+	}
+	t.intakeNext = (t.intakeNext + 1) % intakeSamples
+}
+
+// ReportNTPOffset records the clock offset measured by the ntp check
+func (t *Tracker) ReportNTPOffset(offset time.Duration) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.ntpOffset = offset
+	t.ntpUpdated = t.now()
+}
+
+// Offset returns the current offset and its source. The ntp offset is more
+// precise and preferred when fresh. The source is empty if no offset is known.
+func (t *Tracker) Offset() (time.Duration, string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if !t.ntpUpdated.IsZero() && t.now().Sub(t.ntpUpdated) < ntpValidity {
+		return t.ntpOffset, SourceNTP
+	}
+	if len(t.intakeOffsets) == 0 {
+		return 0, ""
+	}
+
+	// the median filters out the outliers caused by slow responses
+	offsets := make([]time.Duration, len(t.intakeOffsets))
+	copy(offsets, t.intakeOffsets)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets[len(offsets)/2], SourceIntake
+}
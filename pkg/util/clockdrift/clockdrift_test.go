@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package clockdrift
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestTrackerNoOffset(t *testing.T) {
+	tracker := NewTracker()
+	offset, source := tracker.Offset()
+	assert.Equal(t, time.Duration(0), offset)
+	assert.Equal(t, "", source)
+}
+
+func TestTrackerIntakeMedian(t *testing.T) {
+	tracker := NewTracker()
+	sent := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	received := sent.Add(200 * time.Millisecond)
+
+	// the intake is 2 minutes ahead, with one slow response
+	for _, delta := range []time.Duration{120, 121, 600, 120, 119} {
+		tracker.ReportIntakeDate(sent.Add(delta*time.Second), sent, received)
+	}
+
+	offset, source := tracker.Offset()
+	assert.Equal(t, SourceIntake, source)
+	assert.InDelta(t, 120, offset.Seconds(), 1)
+
+	// older samples are overwritten
+	for i := 0; i < intakeSamples; i++ {
+		tracker.ReportIntakeDate(sent, sent, received)
+	}
+	offset, _ = tracker.Offset()
+	assert.InDelta(t, 0, offset.Seconds(), 1)
+}
+
+func TestTrackerPrefersFreshNTP(t *testing.T) {
+	now := time.Now()
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+
+	tracker.ReportIntakeDate(now.Add(time.Minute), now, now)
+	tracker.ReportNTPOffset(-3 * time.Second)
+
+	offset, source := tracker.Offset()
+	assert.Equal(t, SourceNTP, source)
+	assert.Equal(t, -3*time.Second, offset)
+
+	// the ntp offset is stale, fall back to the intake
+	now = now.Add(2 * ntpValidity)
+	_, source = tracker.Offset()
+	assert.Equal(t, SourceIntake, source)
+}
+
+func TestGlobalCorrection(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("clock_drift.tolerance", 60)
+	mockConfig.Set("clock_drift.correct_timestamps", true)
+	defer mockConfig.Set("clock_drift.correct_timestamps", false)
+	defer func() {
+		globalTracker = NewTracker()
+		atomic.StoreInt64(&correction, 0)
+		atomic.StoreInt32(&drifting, 0)
+	}()
+
+	ReportNTPOffset(10 * time.Second)
+	assert.False(t, IsDrifting())
+	assert.WithinDuration(t, time.Now(), Now(), time.Second)
+
+	ReportNTPOffset(10 * time.Minute)
+	assert.True(t, IsDrifting())
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), Now(), time.Second)
+	assert.Equal(t, true, GetStatus()["drifting"])
+
+	mockConfig.Set("clock_drift.correct_timestamps", false)
+	ReportNTPOffset(10 * time.Minute)
+	assert.True(t, IsDrifting())
+	assert.WithinDuration(t, time.Now(), Now(), time.Second)
+}
@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package clockdrift
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	globalTracker = NewTracker()
+
+	// correction applied to the timestamps, in nanoseconds
+	correction int64
+	// set to 1 while the drift exceeds the tolerance
+	drifting int32
+
+	clockDriftExpvars   = expvar.NewMap("clockdrift")
+	offsetExpvar        = expvar.Float{}
+	sourceExpvar        = expvar.String{}
+	correctionExpvar    = expvar.Float{}
+	driftDetectedExpvar = expvar.Int{}
+)
+
+func init() {
+	clockDriftExpvars.Set("Offset", &offsetExpvar)
+	clockDriftExpvars.Set("Source", &sourceExpvar)
+	clockDriftExpvars.Set("Correction", &correctionExpvar)
+	clockDriftExpvars.Set("DriftDetected", &driftDetectedExpvar)
+}
+
+// ReportIntakeDate records the Date header of an intake response on the
+// global tracker
+func ReportIntakeDate(date, sent, received time.Time) {
+	globalTracker.ReportIntakeDate(date, sent, received)
+	update()
+}
+
+// ReportNTPOffset records the offset measured by the ntp check on the global
+// tracker
+func ReportNTPOffset(offset time.Duration) {
+	globalTracker.ReportNTPOffset(offset)
+	update()
+}
+
+// Now returns the current time, corrected by the clock drift when
+// `clock_drift.correct_timestamps` is enabled and the drift exceeds the
+// tolerance
+func Now() time.Time {
+	return time.Now().Add(time.Duration(atomic.LoadInt64(&correction)))
+}
+
+// IsDrifting returns whether the clock drift exceeds the tolerance
+func IsDrifting() bool {
+	return atomic.LoadInt32(&drifting) == 1
+}
+
+// GetStatus returns the clock drift information for the status page
+func GetStatus() map[string]interface{} {
+	offset, source := globalTracker.Offset()
+	if source == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"offset":     offset.Seconds(),
+		"source":     source,
+		"drifting":   IsDrifting(),
+		"correction": time.Duration(atomic.LoadInt64(&correction)).Seconds(),
+	}
+}
+
+// update compares the offset to the tolerance and updates the correction
+func update() {
+	offset, source := globalTracker.Offset()
+	offsetExpvar.Set(offset.Seconds())
+	sourceExpvar.Set(source)
+
+	tolerance := time.Duration(config.Datadog.GetInt("clock_drift.tolerance")) * time.Second
+	exceeded := offset > tolerance || offset < -tolerance
+
+	if exceeded && atomic.CompareAndSwapInt32(&drifting, 0, 1) {
+		driftDetectedExpvar.Add(1)
+		log.Warnf("The local clock is off by %v (source: %s), metrics timestamps may be wrong or dropped by the intake", offset, source)
+	} else if !exceeded && atomic.CompareAndSwapInt32(&drifting, 1, 0) {
+		log.Infof("The local clock offset is back within tolerance: %v (source: %s)", offset, source)
+	}
+
+	var newCorrection time.Duration
+	if exceeded && config.Datadog.GetBool("clock_drift.correct_timestamps") {
+		newCorrection = offset
+	}
+	atomic.StoreInt64(&correction, int64(newCorrection))
+	correctionExpvar.Set(newCorrection.Seconds())
+}
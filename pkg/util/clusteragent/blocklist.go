@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+)
+
+const dcaBlocklistPath = "api/v1/blocklist"
+
+// GetWorkloadBlocklist returns the workload blocklist rules of the Cluster Agent
+func (c *DCAClient) GetWorkloadBlocklist() ([]apiv1.BlocklistRule, error) {
+	var response apiv1.BlocklistResponse
+
+	// https://host:port/api/v1/blocklist
+	rawURL := fmt.Sprintf("%s/%s", c.clusterAgentAPIEndpoint, dcaBlocklistPath)
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = c.clusterAgentAPIRequestHeaders
+
+	resp, err := c.clusterAgentAPIClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from cluster agent: %d", resp.StatusCode)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &response)
+	return response.Rules, err
+}
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"fmt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+)
+
+var dummyBlocklist = `{
+"rules": [
+  {"namespace": "kube-system", "logs": true, "metrics": false},
+  {"label_selector": "app in (noisy)", "logs": true, "metrics": true}
+]
+}`
+
+func (suite *clusterAgentSuite) TestGetWorkloadBlocklist() {
+	dca, err := newDummyClusterAgent()
+	require.NoError(suite.T(), err)
+
+	dca.rawResponses["/api/v1/blocklist"] = dummyBlocklist
+
+	ts, p, err := dca.StartTLS()
+	defer ts.Close()
+	require.NoError(suite.T(), err)
+	mockConfig.Set("cluster_agent.url", fmt.Sprintf("https://127.0.0.1:%d", p))
+
+	ca, err := GetClusterAgentClient()
+	require.NoError(suite.T(), err)
+
+	rules, err := ca.GetWorkloadBlocklist()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []apiv1.BlocklistRule{
+		{Namespace: "kube-system", Logs: true},
+		{LabelSelector: "app in (noisy)", Logs: true, Metrics: true},
+	}, rules)
+}
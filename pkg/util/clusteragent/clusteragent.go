@@ -52,6 +52,9 @@ type DCAClientInterface interface {
 	PostClusterCheckStatus(nodeName string, status types.NodeStatus) (types.StatusResponse, error)
 	GetClusterCheckConfigs(nodeName string) (types.ConfigResponse, error)
 	GetEndpointsCheckConfigs(nodeName string) (types.ConfigResponse, error)
+
+	GetWorkloadBlocklist() ([]apiv1.BlocklistRule, error)
+	GetServicesByClusterIP(etag string) (map[string]apiv1.KubeService, string, error)
 }
 
 // DCAClient is required to query the API of Datadog cluster agent
@@ -99,17 +102,25 @@ func (c *DCAClient) init() error {
 		return err
 	}
 
-	authToken, err := security.GetClusterAgentAuthToken()
-	if err != nil {
-		return err
-	}
-
-	c.clusterAgentAPIRequestHeaders = http.Header{}
-	c.clusterAgentAPIRequestHeaders.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", authToken))
-
 	// TODO remove insecure
 	c.clusterAgentAPIClient = util.GetClient(false)
 	c.clusterAgentAPIClient.Timeout = 2 * time.Second
+	c.clusterAgentAPIRequestHeaders = http.Header{}
+
+	if tokenPath := config.Datadog.GetString("cluster_agent.service_account_token_path"); tokenPath != "" {
+		transport, err := newServiceAccountTokenTransport(c.clusterAgentAPIClient.Transport, tokenPath)
+		if err != nil {
+			return fmt.Errorf("unable to read the service account token: %v", err)
+		}
+		c.clusterAgentAPIClient.Transport = transport
+		log.Debugf("Authenticating to the Cluster Agent with the service account token %s", tokenPath)
+	} else {
+		authToken, err := security.GetClusterAgentAuthToken()
+		if err != nil {
+			return err
+		}
+		c.clusterAgentAPIRequestHeaders.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", authToken))
+	}
 
 	// Validate the cluster-agent client by checking the version
 	c.ClusterAgentVersion, err = c.GetVersion()
@@ -321,10 +332,15 @@ func (c *DCAClient) GetPodsMetadataForNode(nodeName string) (apiv1.NamespacesPod
 		return nil, err
 	}
 
-	if _, ok := metadataPodPayload.Nodes[nodeName]; !ok {
+	bundle, ok := metadataPodPayload.Nodes[nodeName]
+	if !ok {
 		return nil, fmt.Errorf("cluster agent didn't return pods metadata for node: %s", nodeName)
 	}
-	return metadataPodPayload.Nodes[nodeName].Services, nil
+	// The services are returned without a tag name, unlike the tags of the pods
+	metadata := apiv1.NewNamespacesPodsStringsSet()
+	metadata.DeepCopy(&bundle.Services)
+	metadata.DeepCopy(&bundle.Pods)
+	return metadata, nil
 }
 
 // GetKubernetesMetadataNames queries the datadog cluster agent to get nodeName/podName registered
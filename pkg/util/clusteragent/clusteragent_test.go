@@ -36,6 +36,7 @@ type dummyClusterAgent struct {
 	responses       map[string][]string
 	responsesByNode apiv1.MetadataResponse
 	rawResponses    map[string]string
+	rawETags        map[string]string
 	requests        chan *http.Request
 	sync.RWMutex
 	token       string
@@ -82,12 +83,21 @@ func newDummyClusterAgent() (*dummyClusterAgent, error) {
 							"pod-00003": sets.NewString("kube_service:svc1"),
 						},
 					},
+					Pods: apiv1.NamespacesPodsStringsSet{
+						"foo": {
+							"pod-00003": sets.NewString("kube_qos:Burstable", "kube_deployment:foo"),
+						},
+						"bar": {
+							"pod-00005": sets.NewString("kube_qos:BestEffort"),
+						},
+					},
 				},
 			},
 		},
 		rawResponses: map[string]string{
 			"/version": `{"Major":0, "Minor":0, "Patch":0, "Pre":"test", "Meta":"test", "Commit":"1337"}`,
 		},
+		rawETags: make(map[string]string),
 		token:    config.Datadog.GetString("cluster_agent.auth_token"),
 		requests: make(chan *http.Request, 100),
 	}
@@ -123,8 +133,16 @@ func (d *dummyClusterAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Handle raw responses if listed
 	d.RLock()
 	response, found := d.rawResponses[r.URL.Path]
+	etag := d.rawETags[r.URL.Path]
 	d.RUnlock()
 	if found {
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
 		w.Write([]byte(response))
 		return
 	}
@@ -517,11 +535,14 @@ func (suite *clusterAgentSuite) TestGetPodsMetadataForNode() {
 			},
 		},
 		{
-			name:     "basic case",
+			name:     "services and pod tags",
 			nodeName: "node2",
 			expectedMetadatas: apiv1.NamespacesPodsStringsSet{
 				"foo": apiv1.MapStringSet{
-					"pod-00003": sets.NewString("kube_service:svc1"),
+					"pod-00003": sets.NewString("kube_service:svc1", "kube_qos:Burstable", "kube_deployment:foo"),
+				},
+				"bar": apiv1.MapStringSet{
+					"pod-00005": sets.NewString("kube_qos:BestEffort"),
 				},
 			},
 		},
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+)
+
+const dcaServicesByClusterIPPath = "api/v1/services/by-cluster-ip"
+
+// GetServicesByClusterIP returns the services of the cluster by ClusterIP and
+// their ETag. When etag is the one of the current services, the Cluster Agent
+// doesn't send them again and the returned services are nil. It's the client
+// side of the server-only endpoint, to be polled by the Process Agent once
+// the connections payload carries tags.
+func (c *DCAClient) GetServicesByClusterIP(etag string) (map[string]apiv1.KubeService, string, error) {
+	var response apiv1.ServicesByClusterIPResponse
+
+	// https://host:port/api/v1/services/by-cluster-ip
+	rawURL := fmt.Sprintf("%s/%s", c.clusterAgentAPIEndpoint, dcaServicesByClusterIPPath)
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	// Copy the shared headers before setting the ETag of this request
+	for k, v := range c.clusterAgentAPIRequestHeaders {
+		req.Header[k] = v
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := c.clusterAgentAPIClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status code from cluster agent: %d", resp.StatusCode)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = json.Unmarshal(b, &response); err != nil {
+		return nil, "", err
+	}
+	return response.Services, resp.Header.Get("ETag"), nil
+}
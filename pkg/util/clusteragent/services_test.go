@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"fmt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+)
+
+var dummyServicesByClusterIP = `{
+"services": {
+  "10.96.0.12": {"name": "redis", "namespace": "default"},
+  "10.96.0.10": {"name": "kube-dns", "namespace": "kube-system"}
+}
+}`
+
+func (suite *clusterAgentSuite) TestGetServicesByClusterIP() {
+	dca, err := newDummyClusterAgent()
+	require.NoError(suite.T(), err)
+
+	dca.rawResponses["/api/v1/services/by-cluster-ip"] = dummyServicesByClusterIP
+	dca.rawETags["/api/v1/services/by-cluster-ip"] = `"1"`
+
+	ts, p, err := dca.StartTLS()
+	defer ts.Close()
+	require.NoError(suite.T(), err)
+	mockConfig.Set("cluster_agent.url", fmt.Sprintf("https://127.0.0.1:%d", p))
+
+	ca, err := GetClusterAgentClient()
+	require.NoError(suite.T(), err)
+
+	services, etag, err := ca.GetServicesByClusterIP("")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string]apiv1.KubeService{
+		"10.96.0.12": {Name: "redis", Namespace: "default"},
+		"10.96.0.10": {Name: "kube-dns", Namespace: "kube-system"},
+	}, services)
+	assert.Equal(suite.T(), `"1"`, etag)
+
+	// Unchanged services are not sent again
+	services, etag, err = ca.GetServicesByClusterIP(etag)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), services)
+	assert.Equal(suite.T(), `"1"`, etag)
+
+	// Changed services come with their new ETag
+	dca.Lock()
+	dca.rawETags["/api/v1/services/by-cluster-ip"] = `"2"`
+	dca.Unlock()
+	services, etag, err = ca.GetServicesByClusterIP(etag)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), services, 2)
+	assert.Equal(suite.T(), `"2"`, etag)
+}
@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serviceAccountTokenRefreshPeriod is how often the projected service account
+// token is read again. The kubelet rotates it at 80% of its lifetime, which is
+// at least 10 minutes.
+const serviceAccountTokenRefreshPeriod = time.Minute
+
+// serviceAccountTokenTransport authenticates the requests to the Cluster Agent
+// with the projected service account token of the pod, instead of the shared
+// auth token. The Cluster Agent validates it with a TokenReview.
+type serviceAccountTokenTransport struct {
+	base http.RoundTripper
+	path string
+
+	m      sync.Mutex
+	token  string
+	readAt time.Time
+}
+
+func newServiceAccountTokenTransport(base http.RoundTripper, path string) (*serviceAccountTokenTransport, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &serviceAccountTokenTransport{base: base, path: path}
+	// fail early on a missing token
+	if _, err := t.getToken(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// getToken returns the token, read again from its file after the refresh
+// period. The last token is kept if the file can't be read anymore.
+func (t *serviceAccountTokenTransport) getToken() (string, error) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if t.token != "" && time.Since(t.readAt) < serviceAccountTokenRefreshPeriod {
+		return t.token, nil
+	}
+
+	raw, err := ioutil.ReadFile(t.path)
+	token := strings.TrimSpace(string(raw))
+	if err == nil && token == "" {
+		err = fmt.Errorf("the service account token %s is empty", t.path)
+	}
+	if err != nil {
+		if t.token != "" {
+			return t.token, nil
+		}
+		return "", err
+	}
+
+	t.token = token
+	t.readAt = time.Now()
+	return t.token, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *serviceAccountTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.getToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// the round trippers must not modify the request, and the requests of
+	// the client share their headers
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", token))
+	return t.base.RoundTrip(r)
+}
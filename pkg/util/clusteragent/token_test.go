@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccountTokenTransport(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get(authorizationHeaderKey)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := dir + "/token"
+
+	_, err = newServiceAccountTokenTransport(nil, path)
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("first\n"), 0600))
+	transport, err := newServiceAccountTokenTransport(nil, path)
+	require.NoError(t, err)
+	client := &http.Client{Transport: transport}
+
+	headers := http.Header{}
+	headers.Set(authorizationHeaderKey, "Bearer shared")
+	req, err := http.NewRequest("GET", server.URL, nil)
+	require.NoError(t, err)
+	req.Header = headers
+	_, err = client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer first", authorization)
+	// the shared headers are left untouched
+	assert.Equal(t, "Bearer shared", headers.Get(authorizationHeaderKey))
+
+	// the rotated token is read after the refresh period
+	require.NoError(t, ioutil.WriteFile(path, []byte("second"), 0600))
+	_, err = client.Get(server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer first", authorization)
+
+	transport.readAt = time.Now().Add(-serviceAccountTokenRefreshPeriod)
+	_, err = client.Get(server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer second", authorization)
+
+	// the last token is kept when the file is gone
+	require.NoError(t, os.Remove(path))
+	transport.readAt = time.Now().Add(-serviceAccountTokenRefreshPeriod)
+	_, err = client.Get(server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer second", authorization)
+}
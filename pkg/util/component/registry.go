@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package component holds the shared components of the agent, like its
+// configuration, its tagger or its API server client, in registries. The
+// global accessors of these components resolve them through the default
+// registry: a binary embedding the collection pipeline, or a test, installs
+// its own registry, or overrides some components of the default one, instead
+// of colliding with the process-global state.
+package component
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds components by name
+type Registry struct {
+	m          sync.RWMutex
+	components map[string]interface{}
+	// creating serializes the creations of a component by GetOrRegister,
+	// without holding m while the component is created
+	creating map[string]*sync.Mutex
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		components: make(map[string]interface{}),
+		creating:   make(map[string]*sync.Mutex),
+	}
+}
+
+// Register sets the component of a name, replacing the previous one
+func (r *Registry) Register(name string, c interface{}) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.components[name] = c
+}
+
+// Get returns the component of a name, nil if none is registered
+func (r *Registry) Get(name string) interface{} {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return r.components[name]
+}
+
+// GetOrRegister returns the component of a name. When none is registered, it
+// registers and returns the one returned by create, called once whatever the
+// number of concurrent callers. The registry isn't locked while create runs,
+// so it can resolve other components, like the configuration, from it.
+func (r *Registry) GetOrRegister(name string, create func() interface{}) interface{} {
+	if c := r.Get(name); c != nil {
+		return c
+	}
+
+	r.m.Lock()
+	creating, found := r.creating[name]
+	if !found {
+		creating = &sync.Mutex{}
+		r.creating[name] = creating
+	}
+	r.m.Unlock()
+
+	creating.Lock()
+	defer creating.Unlock()
+	if c := r.Get(name); c != nil {
+		return c
+	}
+	c := create()
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	// a component registered meanwhile wins
+	if registered, found := r.components[name]; found && registered != nil {
+		return registered
+	}
+	r.components[name] = c
+	return c
+}
+
+// Override registers a component, and returns a function registering the
+// previous one back
+func (r *Registry) Override(name string, c interface{}) (restore func()) {
+	r.m.Lock()
+	previous, found := r.components[name]
+	r.components[name] = c
+	r.m.Unlock()
+
+	return func() {
+		r.m.Lock()
+		defer r.m.Unlock()
+		if found {
+			r.components[name] = previous
+		} else {
+			delete(r.components, name)
+		}
+	}
+}
+
+// Names returns the sorted names of the registered components
+func (r *Registry) Names() []string {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	names := make([]string, 0, len(r.components))
+	for name := range r.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultRegistry holds the *Registry resolving the global accessors
+var defaultRegistry atomic.Value
+
+func init() {
+	defaultRegistry.Store(NewRegistry())
+}
+
+// Default returns the registry resolving the global accessors of the
+// components
+func Default() *Registry {
+	return defaultRegistry.Load().(*Registry)
+}
+
+// SetDefault installs a registry resolving the global accessors of the
+// components, and returns a function installing the previous one back
+func SetDefault(r *Registry) (restore func()) {
+	previous := Default()
+	defaultRegistry.Store(r)
+	return func() {
+		defaultRegistry.Store(previous)
+	}
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package component
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	assert.Nil(t, r.Get("config"))
+
+	r.Register("config", "first")
+	assert.Equal(t, "first", r.Get("config"))
+
+	restore := r.Override("config", "second")
+	assert.Equal(t, "second", r.Get("config"))
+	restore()
+	assert.Equal(t, "first", r.Get("config"))
+
+	restore = r.Override("tagger", "fake")
+	assert.Equal(t, []string{"config", "tagger"}, r.Names())
+	restore()
+	assert.Nil(t, r.Get("tagger"))
+	assert.Equal(t, []string{"config"}, r.Names())
+}
+
+func TestRegistryGetOrRegister(t *testing.T) {
+	r := NewRegistry()
+	var created int
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "client", r.GetOrRegister("apiserver", func() interface{} {
+				created++
+				return "client"
+			}))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, created)
+
+	r.Register("apiserver", "other")
+	assert.Equal(t, "other", r.GetOrRegister("apiserver", func() interface{} { return "client" }))
+}
+
+func TestRegistryGetOrRegisterResolvesComponents(t *testing.T) {
+	r := NewRegistry()
+	r.Register("config", "cfg")
+
+	// create resolves another component of the registry
+	done := make(chan interface{})
+	go func() {
+		done <- r.GetOrRegister("apiserver", func() interface{} {
+			return "client of " + r.Get("config").(string)
+		})
+	}()
+	select {
+	case c := <-done:
+		assert.Equal(t, "client of cfg", c)
+	case <-time.After(5 * time.Second):
+		t.Fatal("GetOrRegister deadlocked")
+	}
+	assert.Equal(t, "client of cfg", r.Get("apiserver"))
+}
+
+func TestSetDefault(t *testing.T) {
+	previous := Default()
+	r := NewRegistry()
+	restore := SetDefault(r)
+	assert.True(t, Default() == r)
+	restore()
+	assert.True(t, Default() == previous)
+}
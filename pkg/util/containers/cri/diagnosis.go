@@ -16,9 +16,9 @@ func init() {
 	diagnosis.Register("CRI availability", diagnose)
 }
 
-// diagnose the CRI socket connectivity
+// diagnose the CRI sockets connectivity
 func diagnose() error {
-	_, err := GetUtil()
+	_, err := GetUtils()
 	if err != nil {
 		log.Error(err)
 	}
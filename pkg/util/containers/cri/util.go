@@ -11,6 +11,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,8 +23,9 @@ import (
 )
 
 var (
-	globalCRIUtil *CRIUtil
-	once          sync.Once
+	// the CRIUtils are shared singletons, by socket path
+	globalCRIUtils   = make(map[string]*CRIUtil)
+	globalCRIUtilsMu sync.Mutex
 )
 
 // CRIUtil wraps interactions with the CRI
@@ -73,28 +75,87 @@ func (c *CRIUtil) init() error {
 	return nil
 }
 
-// GetUtil returns a ready to use CRIUtil. It is backed by a shared singleton.
+// SocketPaths returns the paths of the CRI sockets to collect, cri_socket_path
+// first then cri_socket_paths, to run against several runtimes at once
+func SocketPaths() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	candidates := append([]string{config.Datadog.GetString("cri_socket_path")}, config.Datadog.GetStringSlice("cri_socket_paths")...)
+	for _, path := range candidates {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// GetUtil returns a ready to use CRIUtil of the main CRI socket. It is backed
+// by a shared singleton.
 func GetUtil() (*CRIUtil, error) {
-	once.Do(func() {
-		globalCRIUtil = &CRIUtil{
+	socketPath := ""
+	if paths := SocketPaths(); len(paths) > 0 {
+		socketPath = paths[0]
+	}
+	return getUtilForSocket(socketPath)
+}
+
+// GetUtils returns ready to use CRIUtils for all the CRI sockets, they are
+// backed by shared singletons. The sockets not reachable are skipped, it only
+// fails if none of them is.
+func GetUtils() ([]*CRIUtil, error) {
+	paths := SocketPaths()
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no cri_socket_path was set")
+	}
+
+	var utils []*CRIUtil
+	var errs []string
+	for _, path := range paths {
+		util, err := getUtilForSocket(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", path, err))
+			continue
+		}
+		utils = append(utils, util)
+	}
+	if len(utils) == 0 {
+		return nil, fmt.Errorf("could not connect to any CRI socket: %s", strings.Join(errs, ", "))
+	}
+	return utils, nil
+}
+
+func getUtilForSocket(socketPath string) (*CRIUtil, error) {
+	globalCRIUtilsMu.Lock()
+	util, found := globalCRIUtils[socketPath]
+	if !found {
+		util = &CRIUtil{
 			queryTimeout:      config.Datadog.GetDuration("cri_query_timeout") * time.Second,
 			connectionTimeout: config.Datadog.GetDuration("cri_connection_timeout") * time.Second,
-			socketPath:        config.Datadog.GetString("cri_socket_path"),
+			socketPath:        socketPath,
 		}
-		globalCRIUtil.initRetry.SetupRetrier(&retry.Config{
-			Name:          "criutil",
-			AttemptMethod: globalCRIUtil.init,
+		util.initRetry.SetupRetrier(&retry.Config{
+			Name:          "criutil " + socketPath,
+			AttemptMethod: util.init,
 			Strategy:      retry.RetryCount,
 			RetryCount:    10,
 			RetryDelay:    30 * time.Second,
 		})
-	})
+		globalCRIUtils[socketPath] = util
+	}
+	globalCRIUtilsMu.Unlock()
 
-	if err := globalCRIUtil.initRetry.TriggerRetry(); err != nil {
+	if err := util.initRetry.TriggerRetry(); err != nil {
 		log.Debugf("CRI init error: %s", err)
 		return nil, err
 	}
-	return globalCRIUtil, nil
+	return util, nil
+}
+
+// SocketPath returns the path of the CRI socket of the CRIUtil
+func (c *CRIUtil) SocketPath() string {
+	return c.socketPath
 }
 
 // ListContainerStats sends a ListContainerStatsRequest to the server, and parses the returned response
@@ -114,3 +175,44 @@ func (c *CRIUtil) ListContainerStats() (map[string]*pb.ContainerStats, error) {
 	}
 	return stats, nil
 }
+
+// ListContainerStatsByRuntime merges the container stats of several CRIUtils,
+// grouped by the name of their runtime. A container reported by several
+// runtimes is only kept from the first CRIUtil listing it. It only fails if
+// none of the CRIUtils can list its containers.
+func ListContainerStatsByRuntime(utils []*CRIUtil) (map[string]map[string]*pb.ContainerStats, error) {
+	merged := make(map[string]map[string]*pb.ContainerStats)
+	seen := make(map[string]string)
+	var errs []string
+	for _, util := range utils {
+		stats, err := util.ListContainerStats()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", util.socketPath, err))
+			continue
+		}
+		mergeContainerStats(merged, seen, util.Runtime, stats)
+	}
+	if len(errs) > 0 {
+		if len(errs) == len(utils) {
+			return nil, fmt.Errorf("could not list the containers of any CRI socket: %s", strings.Join(errs, ", "))
+		}
+		log.Warnf("Could not list the containers of some CRI sockets: %s", strings.Join(errs, ", "))
+	}
+	return merged, nil
+}
+
+// mergeContainerStats adds the stats of a runtime to merged, skipping the
+// containers already seen from another runtime
+func mergeContainerStats(merged map[string]map[string]*pb.ContainerStats, seen map[string]string, runtime string, stats map[string]*pb.ContainerStats) {
+	for cid, s := range stats {
+		if from, found := seen[cid]; found {
+			log.Debugf("Container %s reported by %s and %s, keeping the stats of %s", cid, from, runtime, from)
+			continue
+		}
+		seen[cid] = runtime
+		if merged[runtime] == nil {
+			merged[runtime] = make(map[string]*pb.ContainerStats)
+		}
+		merged[runtime][cid] = s
+	}
+}
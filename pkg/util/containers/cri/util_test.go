@@ -14,7 +14,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 	fakeremote "k8s.io/kubernetes/pkg/kubelet/remote/fake"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
 )
 
 func TestCRIUtilInit(t *testing.T) {
@@ -64,3 +67,36 @@ func createAndStartFakeRemoteRuntime(t *testing.T) (*fakeremote.RemoteRuntime, s
 
 	return fakeRuntime, endpoint
 }
+
+func TestSocketPaths(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("cri_socket_path", "/var/run/dockershim.sock")
+	mockConfig.Set("cri_socket_paths", []string{"/var/run/containerd/containerd.sock", "/var/run/dockershim.sock", ""})
+	defer mockConfig.Set("cri_socket_path", "")
+	defer mockConfig.Set("cri_socket_paths", []string{})
+
+	assert.Equal(t, []string{"/var/run/dockershim.sock", "/var/run/containerd/containerd.sock"}, SocketPaths())
+}
+
+func TestMergeContainerStats(t *testing.T) {
+	merged := make(map[string]map[string]*pb.ContainerStats)
+	seen := make(map[string]string)
+
+	dockerStats := map[string]*pb.ContainerStats{
+		"foo": {Attributes: &pb.ContainerAttributes{Id: "foo"}},
+		"bar": {Attributes: &pb.ContainerAttributes{Id: "bar"}},
+	}
+	containerdStats := map[string]*pb.ContainerStats{
+		"bar": {Attributes: &pb.ContainerAttributes{Id: "bar"}},
+		"baz": {Attributes: &pb.ContainerAttributes{Id: "baz"}},
+	}
+	mergeContainerStats(merged, seen, "docker", dockerStats)
+	mergeContainerStats(merged, seen, "containerd", containerdStats)
+
+	require.Len(t, merged, 2)
+	assert.Len(t, merged["docker"], 2)
+	assert.Contains(t, merged["docker"], "bar")
+	// bar was already reported by docker
+	assert.Len(t, merged["containerd"], 1)
+	assert.Contains(t, merged["containerd"], "baz")
+}
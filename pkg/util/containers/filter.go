@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 )
@@ -30,89 +31,189 @@ const (
 	pauseContainerRancher = `image:rancher/pause(.*)`
 )
 
+// Scope is the kind of data a container filter applies to
+type Scope string
+
+const (
+	// GlobalFilter excludes the containers from everything but the autodiscovery
+	GlobalFilter Scope = "global"
+	// MetricsFilter excludes the containers from the container metrics
+	MetricsFilter Scope = "metrics"
+	// LogsFilter excludes the containers from the logs collection
+	LogsFilter Scope = "logs"
+	// ADFilter excludes the containers from the autodiscovery
+	ADFilter Scope = "ad"
+)
+
+// Scopes lists the scopes having their own include and exclude lists
+var Scopes = []Scope{MetricsFilter, LogsFilter, ADFilter}
+
+const (
+	imageField     = "image"
+	nameField      = "name"
+	namespaceField = "kube_namespace"
+	labelField     = "label"
+)
+
+// FilterableContainer holds the container attributes the filter rules select on.
+// The namespace and labels are optional, the rules selecting on them don't
+// match the containers without them.
+type FilterableContainer struct {
+	Name      string
+	Image     string
+	Namespace string
+	Labels    map[string]string
+}
+
+// filterRule is a compiled include or exclude pattern
+type filterRule struct {
+	pattern  string // the pattern as configured, reported by Explain
+	scope    Scope
+	field    string
+	labelKey string
+	regex    *regexp.Regexp
+}
+
+func (r *filterRule) matches(c FilterableContainer) bool {
+	switch r.field {
+	case imageField:
+		return r.regex.MatchString(c.Image)
+	case nameField:
+		return r.regex.MatchString(c.Name)
+	case namespaceField:
+		return c.Namespace != "" && r.regex.MatchString(c.Namespace)
+	case labelField:
+		value, found := c.Labels[r.labelKey]
+		return found && r.regex.MatchString(value)
+	}
+	return false
+}
+
+// filterLists holds the compiled include and exclude lists of a scope
+type filterLists struct {
+	whitelist []*filterRule
+	blacklist []*filterRule
+}
+
 // Filter holds the state for the container filtering logic
 type Filter struct {
-	Enabled        bool
-	ImageWhitelist []*regexp.Regexp
-	NameWhitelist  []*regexp.Regexp
-	ImageBlacklist []*regexp.Regexp
-	NameBlacklist  []*regexp.Regexp
+	Enabled bool
+	// lists holds the lists of the scope of the filter before the global ones
+	lists []filterLists
 }
 
-var sharedFilter *Filter
+var (
+	sharedFilters     = make(map[Scope]*Filter)
+	sharedFiltersLock sync.Mutex
+)
 
-func parseFilters(filters []string) (imageFilters, nameFilters []*regexp.Regexp, err error) {
+// parseFilters compiles the patterns, each pattern has the format
+// "field:pattern" where field can be: [image, name, kube_namespace], or
+// "label:key=pattern". Patterns with an unknown field are ignored.
+func parseFilters(filters []string, scope Scope) ([]*filterRule, error) {
+	var rules []*filterRule
 	for _, filter := range filters {
-		switch {
-		case strings.HasPrefix(filter, "image:"):
-			pat := strings.TrimPrefix(filter, "image:")
-			r, err := regexp.Compile(strings.TrimPrefix(pat, "image:"))
-			if err != nil {
-				return nil, nil, fmt.Errorf("invalid regex '%s': %s", pat, err)
-			}
-			imageFilters = append(imageFilters, r)
-		case strings.HasPrefix(filter, "name:"):
-			pat := strings.TrimPrefix(filter, "name:")
-			r, err := regexp.Compile(pat)
-			if err != nil {
-				return nil, nil, fmt.Errorf("invalid regex '%s': %s", pat, err)
+		parts := strings.SplitN(filter, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		rule := &filterRule{pattern: filter, scope: scope, field: parts[0]}
+		pat := parts[1]
+		switch rule.field {
+		case imageField:
+			pat = strings.TrimPrefix(pat, "image:")
+		case nameField, namespaceField:
+		case labelField:
+			kv := strings.SplitN(pat, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return nil, fmt.Errorf("invalid label filter '%s', the format is label:<key>=<pattern>", filter)
 			}
-			nameFilters = append(nameFilters, r)
+			rule.labelKey, pat = kv[0], kv[1]
+		default:
+			continue
+		}
+		r, err := regexp.Compile(pat)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex '%s': %s", pat, err)
 		}
+		rule.regex = r
+		rules = append(rules, rule)
 	}
-	return imageFilters, nameFilters, nil
+	return rules, nil
 }
 
-// GetSharedFilter allows to share the result of NewFilterFromConfig
-// for several user classes
-func GetSharedFilter() (*Filter, error) {
-	if sharedFilter != nil {
-		return sharedFilter, nil
+// GetFilter allows to share the filter of a scope, built from the
+// configuration, for several user classes: the patterns are compiled once.
+func GetFilter(scope Scope) (*Filter, error) {
+	sharedFiltersLock.Lock()
+	defer sharedFiltersLock.Unlock()
+
+	if f, found := sharedFilters[scope]; found {
+		return f, nil
 	}
-	f, err := NewFilterFromConfig()
+	f, err := newFilterForScope(scope)
 	if err != nil {
 		return nil, err
 	}
-	sharedFilter = f
+	sharedFilters[scope] = f
 	return f, nil
 }
 
+// GetSharedFilter allows to share the result of NewFilterFromConfig
+// for several user classes
+func GetSharedFilter() (*Filter, error) {
+	return GetFilter(GlobalFilter)
+}
+
 // ResetSharedFilter is only to be used in unit tests: it resets the global
-// filter instance to force re-parsing of the configuration.
+// filter instances to force re-parsing of the configuration.
 func ResetSharedFilter() {
-	sharedFilter = nil
+	sharedFiltersLock.Lock()
+	defer sharedFiltersLock.Unlock()
+	sharedFilters = make(map[Scope]*Filter)
 }
 
 // NewFilter creates a new container filter from a two slices of
 // regexp patterns for a whitelist and blacklist. Each pattern should have
-// the following format: "field:pattern" where field can be: [image, name].
+// the following format: "field:pattern" where field can be: [image, name,
+// kube_namespace], or "label:key=pattern" to select on a container label.
 // An error is returned if any of the expression don't compile.
 func NewFilter(whitelist, blacklist []string) (*Filter, error) {
-	iwl, nwl, err := parseFilters(whitelist)
-	if err != nil {
-		return nil, err
-	}
-	ibl, nbl, err := parseFilters(blacklist)
-	if err != nil {
-		return nil, err
-	}
+	return newFilter(map[Scope][2][]string{GlobalFilter: {whitelist, blacklist}})
+}
 
-	return &Filter{
-		Enabled:        len(whitelist) > 0 || len(blacklist) > 0,
-		ImageWhitelist: iwl,
-		NameWhitelist:  nwl,
-		ImageBlacklist: ibl,
-		NameBlacklist:  nbl,
-	}, nil
+// newFilter compiles the whitelist and blacklist of every scope in one filter
+func newFilter(lists map[Scope][2][]string) (*Filter, error) {
+	f := &Filter{}
+	// the scope rules take precedence over the global ones
+	for _, scope := range append(Scopes, GlobalFilter) {
+		l, found := lists[scope]
+		if !found {
+			continue
+		}
+		wl, err := parseFilters(l[0], scope)
+		if err != nil {
+			return nil, err
+		}
+		bl, err := parseFilters(l[1], scope)
+		if err != nil {
+			return nil, err
+		}
+		f.lists = append(f.lists, filterLists{whitelist: wl, blacklist: bl})
+		f.Enabled = f.Enabled || len(l[0]) > 0 || len(l[1]) > 0
+	}
+	return f, nil
 }
 
-// NewFilterFromConfig creates a new container filter, sourcing patterns
-// from the pkg/config options
-func NewFilterFromConfig() (*Filter, error) {
-	whitelist := config.Datadog.GetStringSlice("ac_include")
-	blacklist := config.Datadog.GetStringSlice("ac_exclude")
+// newFilterForScope creates the container filter of a scope, made of the
+// global ac_include/ac_exclude and container_include/container_exclude
+// patterns, plus the container_include_<scope>/container_exclude_<scope> ones.
+// The pause containers are excluded from every scope but the autodiscovery.
+func newFilterForScope(scope Scope) (*Filter, error) {
+	whitelist := append(config.Datadog.GetStringSlice("ac_include"), config.Datadog.GetStringSlice("container_include")...)
+	blacklist := append(config.Datadog.GetStringSlice("ac_exclude"), config.Datadog.GetStringSlice("container_exclude")...)
 
-	if config.Datadog.GetBool("exclude_pause_container") {
+	if scope != ADFilter && config.Datadog.GetBool("exclude_pause_container") {
 		blacklist = append(blacklist,
 			pauseContainerGCR,
 			pauseContainerOpenshift,
@@ -123,47 +224,72 @@ func NewFilterFromConfig() (*Filter, error) {
 			pauseContainerRancher,
 		)
 	}
-	return NewFilter(whitelist, blacklist)
+
+	lists := map[Scope][2][]string{GlobalFilter: {whitelist, blacklist}}
+	if scope != GlobalFilter {
+		lists[scope] = [2][]string{
+			config.Datadog.GetStringSlice(fmt.Sprintf("container_include_%s", scope)),
+			config.Datadog.GetStringSlice(fmt.Sprintf("container_exclude_%s", scope)),
+		}
+	}
+	return newFilter(lists)
+}
+
+// NewFilterFromConfig creates a new container filter, sourcing patterns
+// from the pkg/config options
+func NewFilterFromConfig() (*Filter, error) {
+	return newFilterForScope(GlobalFilter)
 }
 
 // NewFilterFromConfigIncludePause creates a new container filter, sourcing patterns
 // from the pkg/config options, but ignoring the exclude_pause_container option, for
 // use in autodiscovery
 func NewFilterFromConfigIncludePause() (*Filter, error) {
-	whitelist := config.Datadog.GetStringSlice("ac_include")
-	blacklist := config.Datadog.GetStringSlice("ac_exclude")
-	return NewFilter(whitelist, blacklist)
+	return newFilterForScope(ADFilter)
 }
 
 // IsExcluded returns a bool indicating if the container should be excluded
 // based on the filters in the containerFilter instance.
 func (cf Filter) IsExcluded(containerName, containerImage string) bool {
+	return cf.IsContainerExcluded(FilterableContainer{Name: containerName, Image: containerImage})
+}
+
+// IsContainerExcluded is IsExcluded, with the namespace and labels rules
+// applied as well
+func (cf Filter) IsContainerExcluded(c FilterableContainer) bool {
+	excluded, _ := cf.Explain(c)
+	return excluded
+}
+
+// Explain returns whether the container is excluded, along with the rule
+// that decided it, nil if no rule matched
+func (cf Filter) Explain(c FilterableContainer) (bool, *FilterRuleMatch) {
 	if !cf.Enabled {
-		return false
+		return false, nil
 	}
 
-	// Any whitelisted take precedence on excluded
-	for _, r := range cf.ImageWhitelist {
-		if r.MatchString(containerImage) {
-			return false
+	// The lists of the scope decide before the global ones, within a scope
+	// any whitelisted take precedence on excluded
+	for _, l := range cf.lists {
+		for _, r := range l.whitelist {
+			if r.matches(c) {
+				return false, &FilterRuleMatch{Pattern: r.pattern, Scope: r.scope, Included: true}
+			}
 		}
-	}
-	for _, r := range cf.NameWhitelist {
-		if r.MatchString(containerName) {
-			return false
+		for _, r := range l.blacklist {
+			if r.matches(c) {
+				return true, &FilterRuleMatch{Pattern: r.pattern, Scope: r.scope}
+			}
 		}
 	}
+	return false, nil
+}
 
-	// Check if blacklisted
-	for _, r := range cf.ImageBlacklist {
-		if r.MatchString(containerImage) {
-			return true
-		}
-	}
-	for _, r := range cf.NameBlacklist {
-		if r.MatchString(containerName) {
-			return true
-		}
-	}
-	return false
+// FilterRuleMatch is the rule deciding whether a container is excluded
+type FilterRuleMatch struct {
+	Pattern string
+	// Scope is the scope of the list holding the rule, GlobalFilter for the
+	// ac_include/ac_exclude and container_include/container_exclude lists
+	Scope    Scope
+	Included bool
 }
@@ -178,3 +178,80 @@ func TestNewFilterFromConfigIncludePause(t *testing.T) {
 	config.Datadog.SetDefault("ac_include", []string{})
 	config.Datadog.SetDefault("ac_exclude", []string{})
 }
+
+func TestFilterNamespaceAndLabels(t *testing.T) {
+	f, err := NewFilter(
+		[]string{"label:app=important"},
+		[]string{"kube_namespace:^kube-system$", "label:team=sandbox-.*"},
+	)
+	require.NoError(t, err)
+
+	assert.True(t, f.IsContainerExcluded(FilterableContainer{Name: "dns", Namespace: "kube-system"}))
+	assert.False(t, f.IsContainerExcluded(FilterableContainer{Name: "dns", Namespace: "default"}))
+	assert.True(t, f.IsContainerExcluded(FilterableContainer{Name: "app", Labels: map[string]string{"team": "sandbox-1"}}))
+	assert.False(t, f.IsContainerExcluded(FilterableContainer{
+		Name:      "app",
+		Namespace: "kube-system",
+		Labels:    map[string]string{"app": "important"},
+	}))
+	// the namespace and label rules don't match the containers without them
+	assert.False(t, f.IsExcluded("dns", "coredns"))
+
+	_, err = NewFilter(nil, []string{"label:no-value"})
+	assert.Error(t, err)
+}
+
+func TestGetFilterScopes(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("container_exclude", []string{"name:global-.*"})
+	mockConfig.Set("container_exclude_logs", []string{"image:noisy"})
+	mockConfig.Set("container_include_metrics", []string{"name:global-keep"})
+	defer func() {
+		mockConfig.Set("container_exclude", []string{})
+		mockConfig.Set("container_exclude_logs", []string{})
+		mockConfig.Set("container_include_metrics", []string{})
+		ResetSharedFilter()
+	}()
+	ResetSharedFilter()
+
+	logs, err := GetFilter(LogsFilter)
+	require.NoError(t, err)
+	metrics, err := GetFilter(MetricsFilter)
+	require.NoError(t, err)
+	ad, err := GetFilter(ADFilter)
+	require.NoError(t, err)
+
+	// the filters are shared
+	shared, err := GetFilter(LogsFilter)
+	require.NoError(t, err)
+	assert.True(t, logs == shared)
+
+	noisy := FilterableContainer{Name: "app", Image: "noisy:latest"}
+	assert.True(t, logs.IsContainerExcluded(noisy))
+	assert.False(t, metrics.IsContainerExcluded(noisy))
+
+	excluded, match := logs.Explain(noisy)
+	assert.True(t, excluded)
+	assert.Equal(t, &FilterRuleMatch{Pattern: "image:noisy", Scope: LogsFilter}, match)
+
+	keep := FilterableContainer{Name: "global-keep", Image: "app"}
+	assert.True(t, logs.IsContainerExcluded(keep))
+	excluded, match = metrics.Explain(keep)
+	assert.False(t, excluded)
+	assert.Equal(t, &FilterRuleMatch{Pattern: "name:global-keep", Scope: MetricsFilter, Included: true}, match)
+
+	// the scope exclusions take precedence over the global inclusions
+	mockConfig.Set("container_include", []string{"image:noisy"})
+	ResetSharedFilter()
+	logs, err = GetFilter(LogsFilter)
+	require.NoError(t, err)
+	excluded, match = logs.Explain(noisy)
+	assert.True(t, excluded)
+	assert.Equal(t, &FilterRuleMatch{Pattern: "image:noisy", Scope: LogsFilter}, match)
+	mockConfig.Set("container_include", []string{})
+
+	// the pause containers are only kept for the autodiscovery
+	pause := FilterableContainer{Name: "pause", Image: "k8s.gcr.io/pause-amd64:3.1"}
+	assert.True(t, metrics.IsContainerExcluded(pause))
+	assert.False(t, ad.IsContainerExcluded(pause))
+}
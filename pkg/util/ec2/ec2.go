@@ -6,6 +6,7 @@
 package ec2
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -24,8 +25,36 @@ var (
 	instanceIdentityURL = "http://169.254.169.254/latest/dynamic/instance-identity/document/"
 	timeout             = 100 * time.Millisecond
 	defaultPrefixes     = []string{"ip-", "domu"}
+	sysVendorPath       = "/sys/class/dmi/id/sys_vendor"
+	hypervisorUUIDPath  = "/sys/hypervisor/uuid"
 )
 
+// sysVendor is the DMI system vendor of the Nitro EC2 instances, the Xen ones
+// have a hypervisor UUID starting with "ec2" instead
+const sysVendor = "Amazon EC2"
+
+// IsRunningOn returns whether the agent runs on an EC2 instance, from its DMI
+// system vendor or its Xen hypervisor UUID, without querying the metadata api
+// whose address is shared with other clouds
+func IsRunningOn() bool {
+	if vendor, err := ioutil.ReadFile(sysVendorPath); err == nil && strings.TrimSpace(string(vendor)) == sysVendor {
+		return true
+	}
+	uuid, err := ioutil.ReadFile(hypervisorUUIDPath)
+	return err == nil && strings.HasPrefix(strings.ToLower(strings.TrimSpace(string(uuid))), "ec2")
+}
+
+var errNotEC2 = fmt.Errorf("not running on EC2")
+
+// GetHostAlias returns the instance id of the host as a host alias, only
+// querying the EC2 metadata API on EC2 instances
+func GetHostAlias() (string, error) {
+	if !IsRunningOn() {
+		return "", errNotEC2
+	}
+	return GetInstanceID()
+}
+
 // GetInstanceID fetches the instance id for current host from the EC2 metadata API
 func GetInstanceID() (string, error) {
 	return getMetadataItemWithMaxLength("/instance-id", config.Datadog.GetInt("metadata_endpoints_max_hostname_size"))
@@ -96,6 +125,28 @@ func getMetadataItem(endpoint string) (string, error) {
 	return string(all), nil
 }
 
+// SpotInstanceAction is the interruption notice of a spot instance
+type SpotInstanceAction struct {
+	Action string    `json:"action"`
+	Time   time.Time `json:"time"`
+}
+
+// GetSpotInstanceAction returns the interruption notice of the current
+// spot instance. The metadata API returns a 404, hence an error, as long
+// as the instance is not scheduled for interruption.
+func GetSpotInstanceAction() (*SpotInstanceAction, error) {
+	resp, err := getMetadataItem("/spot/instance-action")
+	if err != nil {
+		return nil, err
+	}
+
+	action := &SpotInstanceAction{}
+	if err := json.Unmarshal([]byte(resp), action); err != nil {
+		return nil, fmt.Errorf("unable to parse spot instance action: %s", err)
+	}
+	return action, nil
+}
+
 // GetClusterName returns the name of the cluster containing the current EC2 instance
 func GetClusterName() (string, error) {
 	tags, err := GetTags()
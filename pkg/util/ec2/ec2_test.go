@@ -9,8 +9,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,6 +26,40 @@ func TestIsDefaultHostname(t *testing.T) {
 	assert.False(t, IsDefaultHostname(""))
 }
 
+func TestIsRunningOn(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ec2-dmi")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(vendor, uuid string) { sysVendorPath, hypervisorUUIDPath = vendor, uuid }(sysVendorPath, hypervisorUUIDPath)
+	sysVendorPath = filepath.Join(dir, "sys_vendor")
+	hypervisorUUIDPath = filepath.Join(dir, "uuid")
+
+	assert.False(t, IsRunningOn())
+
+	// Xen instances
+	require.NoError(t, ioutil.WriteFile(hypervisorUUIDPath, []byte("EC2E1916-9099-7CAF-FD21-012345ABCDEF\n"), 0644))
+	assert.True(t, IsRunningOn())
+	require.NoError(t, ioutil.WriteFile(hypervisorUUIDPath, []byte("4f1e2a3b-0000-0000-0000-000000000000\n"), 0644))
+	assert.False(t, IsRunningOn())
+
+	// Nitro instances
+	require.NoError(t, ioutil.WriteFile(sysVendorPath, []byte("Amazon EC2\n"), 0644))
+	assert.True(t, IsRunningOn())
+}
+
+func TestGetHostAliasNotRunningOn(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+	defer func(vendor, uuid string) { sysVendorPath, hypervisorUUIDPath = vendor, uuid }(sysVendorPath, hypervisorUUIDPath)
+	sysVendorPath, hypervisorUUIDPath = "/nonexistent/sys_vendor", "/nonexistent/uuid"
+
+	_, err := GetHostAlias()
+	assert.Equal(t, errNotEC2, err)
+}
+
 func TestGetInstanceID(t *testing.T) {
 	expected := "i-0123456789abcdef0"
 	var lastRequest *http.Request
@@ -172,3 +209,26 @@ func TestGetInstanceIDMultipleVPC(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "too many mac addresses returned")
 }
+
+func TestGetSpotInstanceAction(t *testing.T) {
+	scheduled := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/spot/instance-action", r.URL.Path)
+		if !scheduled {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, `{"action": "terminate", "time": "2017-09-18T08:22:00Z"}`)
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	_, err := GetSpotInstanceAction()
+	assert.Error(t, err)
+
+	scheduled = true
+	action, err := GetSpotInstanceAction()
+	require.NoError(t, err)
+	assert.Equal(t, "terminate", action.Action)
+	assert.Equal(t, int64(1505722920), action.Time.Unix())
+}
@@ -18,10 +18,22 @@ import (
 
 // declare these as vars not const to ease testing
 var (
-	metadataURL = "http://169.254.169.254/computeMetadata/v1"
-	timeout     = 300 * time.Millisecond
+	metadataURL     = "http://169.254.169.254/computeMetadata/v1"
+	timeout         = 300 * time.Millisecond
+	productNamePath = "/sys/class/dmi/id/product_name"
 )
 
+// productName is the DMI product name of the GCE instances
+const productName = "Google Compute Engine"
+
+// IsRunningOn returns whether the agent runs on a GCE instance, from its DMI
+// product name, without querying the metadata api whose address is shared
+// with other clouds
+func IsRunningOn() bool {
+	name, err := ioutil.ReadFile(productNamePath)
+	return err == nil && strings.TrimSpace(string(name)) == productName
+}
+
 type gceMetadata struct {
 	Instance gceInstanceMetadata
 	Project  gceProjectMetadata
@@ -112,6 +124,16 @@ func GetNetworkID() (string, error) {
 
 }
 
+// IsPreempted returns whether the current preemptible instance has been
+// preempted and is about to be stopped
+func IsPreempted() (bool, error) {
+	preempted, err := getResponse(metadataURL + "/instance/preempted")
+	if err != nil {
+		return false, fmt.Errorf("unable to retrieve preemption status from GCE: %s", err)
+	}
+	return strings.TrimSpace(preempted) == "TRUE", nil
+}
+
 func getResponseWithMaxLength(endpoint string, maxLength int) (string, error) {
 	result, err := getResponse(endpoint)
 	if err != nil {
@@ -7,14 +7,31 @@ package gce
 
 import (
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+func TestIsRunningOn(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gce-dmi")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(path string) { productNamePath = path }(productNamePath)
+	productNamePath = filepath.Join(dir, "product_name")
+
+	assert.False(t, IsRunningOn())
+	require.NoError(t, ioutil.WriteFile(productNamePath, []byte("Standard PC (i440FX + PIIX, 1996)\n"), 0644))
+	assert.False(t, IsRunningOn())
+	require.NoError(t, ioutil.WriteFile(productNamePath, []byte("Google Compute Engine\n"), 0644))
+	assert.True(t, IsRunningOn())
+}
+
 func TestGetHostname(t *testing.T) {
 	expected := "gke-cluster-massi-agent59-default-pool-6087cc76-9cfa"
 	var lastRequest *http.Request
@@ -148,3 +165,23 @@ func TestGetNetworkMultipleVPC(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "more than one network interface")
 }
+
+func TestIsPreempted(t *testing.T) {
+	preempted := "FALSE"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		assert.Equal(t, "/instance/preempted", r.RequestURI)
+		io.WriteString(w, preempted)
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	val, err := IsPreempted()
+	require.NoError(t, err)
+	assert.False(t, val)
+
+	preempted = "TRUE"
+	val, err = IsPreempted()
+	require.NoError(t, err)
+	assert.True(t, val)
+}
@@ -6,9 +6,9 @@
 package util
 
 import (
+	"context"
 	"expvar"
 	"fmt"
-	"net"
 	"os"
 	"regexp"
 	"runtime"
@@ -21,6 +21,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/ec2"
 	"github.com/DataDog/datadog-agent/pkg/util/ecs"
 	"github.com/DataDog/datadog-agent/pkg/util/hostname"
+	"github.com/DataDog/datadog-agent/pkg/util/resolver"
 )
 
 const maxLength = 255
@@ -74,7 +75,7 @@ func isLocal(name string) bool {
 
 // Fqdn returns the FQDN for the host if any
 func Fqdn(hostname string) string {
-	addrs, err := net.LookupIP(hostname)
+	addrs, err := resolver.LookupIP(context.Background(), hostname)
 	if err != nil {
 		return hostname
 	}
@@ -85,7 +86,7 @@ func Fqdn(hostname string) string {
 			if err != nil {
 				return hostname
 			}
-			hosts, err := net.LookupAddr(string(ip))
+			hosts, err := resolver.LookupAddr(context.Background(), string(ip))
 			if err != nil || len(hosts) == 0 {
 				return hostname
 			}
@@ -98,6 +99,8 @@ func Fqdn(hostname string) string {
 // GetHostname retrieve the host name for the Agent, trying to query these
 // environments/api, in order:
 // * GCE
+// * Oracle Cloud, when detected from the DMI
+// * Alibaba Cloud, when detected from the DMI
 // * Docker
 // * kubernetes
 // * os
@@ -149,6 +152,28 @@ func GetHostname() (string, error) {
 		log.Debug("Unable to get hostname from GCE: ", err)
 	}
 
+	// Oracle Cloud and Alibaba Cloud metadata
+	for _, cloud := range []string{"oracle", "alibaba"} {
+		getCloudHostname, found := hostname.ProviderCatalog[cloud]
+		if !found {
+			continue
+		}
+		log.Debugf("GetHostname trying %s metadata...", cloud)
+		cloudName, err := getCloudHostname()
+		if err == nil {
+			err = ValidHostname(cloudName)
+		}
+		if err == nil {
+			cache.Cache.Set(cacheHostnameKey, cloudName, cache.NoExpiration)
+			hostnameProvider.Set(cloud)
+			return cloudName, err
+		}
+		expErr := new(expvar.String)
+		expErr.Set(err.Error())
+		hostnameErrors.Set(cloud, expErr)
+		log.Debugf("Unable to get hostname from %s: %s", cloud, err)
+	}
+
 	// FQDN
 	log.Debug("GetHostname trying FQDN/`hostname -f`...")
 	fqdn, err := getSystemFQDN()
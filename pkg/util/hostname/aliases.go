@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package hostname
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// AliasProvider is a generic function to grab the aliases of the agent host
+type AliasProvider func() ([]string, error)
+
+// AliasProviderCatalog holds all the various kinds of host alias providers
+var AliasProviderCatalog = make(map[string]AliasProvider)
+
+var (
+	// externalHostAliases maps an external host (e.g. a vSphere VM monitored
+	// by a check) to its aliases
+	externalHostAliases = make(map[string][]string)
+	externalMutex       sync.RWMutex
+)
+
+// RegisterHostAliasProvider registers a host alias provider as part of the catalog
+func RegisterHostAliasProvider(name string, p AliasProvider) {
+	AliasProviderCatalog[name] = p
+}
+
+// SingleAliasProvider adapts a function returning a single alias to an AliasProvider.
+// An empty alias is ignored.
+func SingleAliasProvider(getAlias func() (string, error)) AliasProvider {
+	return func() ([]string, error) {
+		alias, err := getAlias()
+		if err != nil || alias == "" {
+			return nil, err
+		}
+		return []string{alias}, nil
+	}
+}
+
+// GetHostAliases returns the deduplicated aliases of the agent host, from the
+// registered providers and from the `host_aliases` configuration option.
+func GetHostAliases() []string {
+	aliases := []string{}
+	seen := make(map[string]bool)
+	add := func(alias string) {
+		if alias == "" || seen[alias] {
+			return
+		}
+		seen[alias] = true
+		aliases = append(aliases, alias)
+	}
+
+	// iterate in a stable order so that the payload doesn't change between runs
+	names := make([]string, 0, len(AliasProviderCatalog))
+	for name := range AliasProviderCatalog {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		providerAliases, err := AliasProviderCatalog[name]()
+		if err != nil {
+			log.Debugf("no %s host alias: %s", name, err)
+			continue
+		}
+		for _, alias := range providerAliases {
+			add(alias)
+		}
+	}
+
+	for _, alias := range config.Datadog.GetStringSlice("host_aliases") {
+		add(alias)
+	}
+
+	return aliases
+}
+
+// SetExternalHostAliases allows checks to declare the aliases of a host they
+// submit data for (e.g. a vSphere VM), instead of the agent host. Data can then
+// be submitted with any of these aliases as the hostname. Passing an empty list
+// removes the aliases of the host.
+func SetExternalHostAliases(hostname string, aliases []string) {
+	externalMutex.Lock()
+	defer externalMutex.Unlock()
+
+	if len(aliases) == 0 {
+		delete(externalHostAliases, hostname)
+		return
+	}
+	externalHostAliases[hostname] = append([]string{}, aliases...)
+}
+
+// GetExternalHostAliases returns a copy of the aliases declared for external hosts
+func GetExternalHostAliases() map[string][]string {
+	externalMutex.RLock()
+	defer externalMutex.RUnlock()
+
+	aliases := make(map[string][]string, len(externalHostAliases))
+	for hostname, hostAliases := range externalHostAliases {
+		aliases[hostname] = append([]string{}, hostAliases...)
+	}
+	return aliases
+}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package hostname
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestGetHostAliases(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("host_aliases", []string{"from-config", "dup"})
+
+	defer func(catalog map[string]AliasProvider) { AliasProviderCatalog = catalog }(AliasProviderCatalog)
+	AliasProviderCatalog = make(map[string]AliasProvider)
+
+	RegisterHostAliasProvider("b", func() ([]string, error) { return []string{"b1", "dup"}, nil })
+	RegisterHostAliasProvider("a", SingleAliasProvider(func() (string, error) { return "a1", nil }))
+	RegisterHostAliasProvider("empty", SingleAliasProvider(func() (string, error) { return "", nil }))
+	RegisterHostAliasProvider("failing", func() ([]string, error) { return nil, errors.New("nope") })
+
+	assert.Equal(t, []string{"a1", "b1", "dup", "from-config"}, GetHostAliases())
+}
+
+func TestExternalHostAliases(t *testing.T) {
+	SetExternalHostAliases("vm-1", []string{"vm-1.local", "my-vm"})
+	SetExternalHostAliases("vm-2", []string{"other"})
+
+	aliases := GetExternalHostAliases()
+	assert.Equal(t, []string{"vm-1.local", "my-vm"}, aliases["vm-1"])
+	assert.Len(t, aliases, 2)
+
+	SetExternalHostAliases("vm-1", nil)
+	aliases = GetExternalHostAliases()
+	assert.Len(t, aliases, 1)
+	assert.Equal(t, []string{"other"}, aliases["vm-2"])
+
+	SetExternalHostAliases("vm-2", nil)
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/resolver"
 )
 
 const (
@@ -46,13 +47,15 @@ func CreateHTTPTransport() *http.Transport {
 	// timeout to our http clients.
 	transport := &http.Transport{
 		TLSClientConfig: tlsConfig,
-		DialContext: (&net.Dialer{
+		// The shared resolver caches the lookups, a broken DNS server doesn't
+		// block every transaction
+		DialContext: resolver.Get().DialContext(&net.Dialer{
 			Timeout: 30 * time.Second,
 			// Enables TCP keepalives to detect broken connections
 			KeepAlive: 30 * time.Second,
 			// Disable RFC 6555 Fast Fallback ("Happy Eyeballs")
 			FallbackDelay: -1 * time.Nanosecond,
-		}).DialContext,
+		}),
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 5,
 		// This parameter is set to avoid connections sitting idle in the pool indefinitely
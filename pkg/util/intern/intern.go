@@ -0,0 +1,246 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package intern deduplicates the strings kept in memory by the agent, such
+// as metric names and tags. The same tags are received over and over by
+// dogstatsd and stored by the tagger and the aggregator: interning them lets
+// all these modules share a single copy of each string.
+package intern
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// internerShards is the number of shards of the interner shared by the agent
+// modules, not to serialize the dogstatsd workers on a single lock
+const internerShards = 32
+
+var (
+	interner     *ShardedStringInterner
+	internerOnce sync.Once
+	internerVars = expvar.NewMap("stringInterner")
+)
+
+func init() {
+	internerVars.Set("Stats", expvar.Func(func() interface{} {
+		return getInterner().Stats()
+	}))
+}
+
+// StringInterner returns a single copy of equal strings. It is bounded by
+// generations: once the current generation holds maxSize strings, it becomes
+// the previous one and a new generation starts. Strings used during the
+// previous generation are moved to the current one, the others are dropped
+// with the previous generation on the next rotation.
+type StringInterner struct {
+	m        sync.Mutex
+	maxSize  int
+	current  map[string]string
+	previous map[string]string
+
+	hits      int64
+	misses    int64
+	rotations int64
+}
+
+// Stats holds the telemetry of a StringInterner
+type Stats struct {
+	Size      int
+	Hits      int64
+	Misses    int64
+	Rotations int64
+}
+
+// NewStringInterner returns a StringInterner keeping at most maxSize strings
+// per generation, so at most 2*maxSize strings in total
+func NewStringInterner(maxSize int) *StringInterner {
+	if maxSize < 1 {
+		maxSize = 1
+	}
+	return &StringInterner{
+		maxSize: maxSize,
+		current: make(map[string]string),
+	}
+}
+
+// LoadOrStore returns the interned copy of the string held in b, allocating
+// it only if it is not interned yet
+func (i *StringInterner) LoadOrStore(b []byte) string {
+	i.m.Lock()
+	defer i.m.Unlock()
+
+	// map lookups with a string(b) key don't allocate
+	if s, found := i.current[string(b)]; found {
+		i.hits++
+		return s
+	}
+	if s, found := i.previous[string(b)]; found {
+		i.hits++
+		i.store(s)
+		return s
+	}
+	i.misses++
+	s := string(b)
+	i.store(s)
+	return s
+}
+
+// Intern returns the interned copy of s
+func (i *StringInterner) Intern(s string) string {
+	i.m.Lock()
+	defer i.m.Unlock()
+	return i.intern(s)
+}
+
+// InternSlice interns the strings of the slice, in place
+func (i *StringInterner) InternSlice(strs []string) {
+	i.m.Lock()
+	defer i.m.Unlock()
+	for idx, s := range strs {
+		strs[idx] = i.intern(s)
+	}
+}
+
+// intern must be called with the lock held
+func (i *StringInterner) intern(s string) string {
+	if interned, found := i.current[s]; found {
+		i.hits++
+		return interned
+	}
+	if interned, found := i.previous[s]; found {
+		i.hits++
+		i.store(interned)
+		return interned
+	}
+	i.misses++
+	i.store(s)
+	return s
+}
+
+// store adds s to the current generation, rotating the generations if it is
+// full. It must be called with the lock held.
+func (i *StringInterner) store(s string) {
+	if len(i.current) >= i.maxSize {
+		i.previous = i.current
+		i.current = make(map[string]string, i.maxSize)
+		i.rotations++
+	}
+	i.current[s] = s
+}
+
+// Stats returns the telemetry of the interner
+func (i *StringInterner) Stats() Stats {
+	i.m.Lock()
+	defer i.m.Unlock()
+	return Stats{
+		Size:      len(i.current) + len(i.previous),
+		Hits:      i.hits,
+		Misses:    i.misses,
+		Rotations: i.rotations,
+	}
+}
+
+// ShardedStringInterner spreads the strings over several StringInterners by
+// hash, each with its own lock, so that concurrent callers seldom contend
+type ShardedStringInterner struct {
+	shards []*StringInterner
+}
+
+// NewShardedStringInterner returns a ShardedStringInterner keeping at most
+// maxSize strings per generation, spread over the shards
+func NewShardedStringInterner(maxSize, shards int) *ShardedStringInterner {
+	if shards < 1 {
+		shards = 1
+	}
+	i := &ShardedStringInterner{shards: make([]*StringInterner, shards)}
+	for n := range i.shards {
+		i.shards[n] = NewStringInterner(maxSize / shards)
+	}
+	return i
+}
+
+// shard returns the interner of the string held in b, the FNV-1a hash being
+// computed inline not to allocate
+func (i *ShardedStringInterner) shard(b []byte) *StringInterner {
+	hash := uint32(2166136261)
+	for _, c := range b {
+		hash ^= uint32(c)
+		hash *= 16777619
+	}
+	return i.shards[hash%uint32(len(i.shards))]
+}
+
+// shardString is shard for a string, the conversion of the argument doesn't
+// allocate
+func (i *ShardedStringInterner) shardString(s string) *StringInterner {
+	hash := uint32(2166136261)
+	for n := 0; n < len(s); n++ {
+		hash ^= uint32(s[n])
+		hash *= 16777619
+	}
+	return i.shards[hash%uint32(len(i.shards))]
+}
+
+// LoadOrStore returns the interned copy of the string held in b, allocating
+// it only if it is not interned yet
+func (i *ShardedStringInterner) LoadOrStore(b []byte) string {
+	return i.shard(b).LoadOrStore(b)
+}
+
+// Intern returns the interned copy of s
+func (i *ShardedStringInterner) Intern(s string) string {
+	return i.shardString(s).Intern(s)
+}
+
+// InternSlice interns the strings of the slice, in place
+func (i *ShardedStringInterner) InternSlice(strs []string) {
+	for idx, s := range strs {
+		strs[idx] = i.shardString(s).Intern(s)
+	}
+}
+
+// Stats returns the telemetry of the shards, summed
+func (i *ShardedStringInterner) Stats() Stats {
+	var stats Stats
+	for _, shard := range i.shards {
+		s := shard.Stats()
+		stats.Size += s.Size
+		stats.Hits += s.Hits
+		stats.Misses += s.Misses
+		stats.Rotations += s.Rotations
+	}
+	return stats
+}
+
+func getInterner() *ShardedStringInterner {
+	internerOnce.Do(func() {
+		interner = NewShardedStringInterner(config.Datadog.GetInt("string_interner_size"), internerShards)
+	})
+	return interner
+}
+
+// LoadOrStore returns the copy of the string held in b interned by the
+// interner shared by the agent modules
+func LoadOrStore(b []byte) string {
+	return getInterner().LoadOrStore(b)
+}
+
+// Intern returns the copy of s interned by the interner shared by the agent
+// modules
+func Intern(s string) string {
+	return getInterner().Intern(s)
+}
+
+// InternSlice interns the strings of the slice in place, with the interner
+// shared by the agent modules
+func InternSlice(strs []string) {
+	if len(strs) == 0 {
+		return
+	}
+	getInterner().InternSlice(strs)
+}
@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package intern
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// data returns the address of the bytes of s
+func data(s string) uintptr {
+	return *(*uintptr)(unsafe.Pointer(&s))
+}
+
+func TestLoadOrStore(t *testing.T) {
+	i := NewStringInterner(10)
+
+	s1 := i.LoadOrStore([]byte("foo:bar"))
+	s2 := i.LoadOrStore([]byte("foo:bar"))
+	assert.Equal(t, "foo:bar", s1)
+	assert.Equal(t, data(s1), data(s2))
+
+	s3 := i.Intern(fmt.Sprintf("foo:%s", "bar"))
+	assert.Equal(t, data(s1), data(s3))
+
+	assert.Equal(t, Stats{Size: 1, Hits: 2, Misses: 1}, i.Stats())
+}
+
+func TestInternSlice(t *testing.T) {
+	i := NewStringInterner(10)
+	first := i.Intern("foo")
+
+	tags := []string{fmt.Sprintf("f%s", "oo"), "bar"}
+	i.InternSlice(tags)
+	assert.Equal(t, []string{"foo", "bar"}, tags)
+	assert.Equal(t, data(first), data(tags[0]))
+}
+
+func TestGenerations(t *testing.T) {
+	i := NewStringInterner(2)
+
+	a := i.Intern(fmt.Sprintf("a"))
+	i.Intern("b")
+	// rotation, a and b are in the previous generation
+	i.Intern("c")
+	assert.Equal(t, int64(1), i.Stats().Rotations)
+	assert.Equal(t, 3, i.Stats().Size)
+
+	// a is moved to the current generation, which is full
+	assert.Equal(t, data(a), data(i.Intern(fmt.Sprintf("a"))))
+
+	// rotation, b is dropped
+	i.Intern("d")
+	assert.Equal(t, int64(2), i.Stats().Rotations)
+	assert.Equal(t, 3, i.Stats().Size)
+	_, found := i.previous["b"]
+	assert.False(t, found)
+	assert.Equal(t, data(a), data(i.Intern(fmt.Sprintf("a"))))
+}
+
+func TestConcurrentIntern(t *testing.T) {
+	i := NewStringInterner(100)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				tag := fmt.Sprintf("tag:%d", n%150)
+				assert.Equal(t, tag, i.LoadOrStore([]byte(tag)))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.True(t, i.Stats().Size <= 200)
+}
+
+func TestShardedStringInterner(t *testing.T) {
+	i := NewShardedStringInterner(64, 4)
+
+	s1 := i.LoadOrStore([]byte("foo:bar"))
+	s2 := i.Intern(fmt.Sprintf("foo:%s", "bar"))
+	assert.Equal(t, data(s1), data(s2))
+
+	tags := []string{fmt.Sprintf("foo:%s", "bar"), "baz"}
+	i.InternSlice(tags)
+	assert.Equal(t, data(s1), data(tags[0]))
+
+	assert.Equal(t, Stats{Size: 2, Hits: 2, Misses: 2}, i.Stats())
+}
+
+func TestConcurrentShardedIntern(t *testing.T) {
+	i := NewShardedStringInterner(400, 4)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				tag := fmt.Sprintf("tag:%d", n%150)
+				assert.Equal(t, tag, i.LoadOrStore([]byte(tag)))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.True(t, i.Stats().Size <= 800)
+}
+
+func BenchmarkLoadOrStore(b *testing.B) {
+	i := NewStringInterner(20000)
+	tags := make([][]byte, 1000)
+	for n := range tags {
+		tags[n] = []byte(fmt.Sprintf("tag:value-%d", n))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		i.LoadOrStore(tags[n%len(tags)])
+	}
+}
+
+func BenchmarkShardedLoadOrStoreParallel(b *testing.B) {
+	i := NewShardedStringInterner(20000, internerShards)
+	tags := make([][]byte, 1000)
+	for n := range tags {
+		tags[n] = []byte(fmt.Sprintf("tag:value-%d", n))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		n := 0
+		for pb.Next() {
+			i.LoadOrStore(tags[n%len(tags)])
+			n++
+		}
+	})
+}
@@ -8,14 +8,17 @@
 package apiserver
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -24,20 +27,27 @@ import (
 	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
-	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
+	"github.com/DataDog/datadog-agent/pkg/util/component"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/retry"
 )
 
 var (
-	globalAPIClient  *APIClient
 	ErrNotFound      = errors.New("entity not found")
 	ErrIsEmpty       = errors.New("entity is empty")
 	ErrOutdated      = errors.New("entity is outdated")
 	ErrNotLeader     = errors.New("not Leader")
 	isConnectVerbose = false
+
+	// API clients of the kubeconfig contexts, by name
+	contextAPIClients      = make(map[string]*APIClient)
+	contextAPIClientsMutex sync.Mutex
 )
 
+// ComponentName is the name of the API server client in the component
+// registries
+const ComponentName = "apiserver"
+
 const (
 	configMapDCAToken         = "datadogtoken"
 	tokenTime                 = "tokenTimestamp"
@@ -51,40 +61,154 @@ const (
 type APIClient struct {
 	// InformerFactory gives access to informers.
 	InformerFactory informers.SharedInformerFactory
+	// NamespacedInformerFactories give access to the informers of the
+	// namespaces of kubernetes_namespaces_include, nil if the agent watches
+	// the whole cluster
+	NamespacedInformerFactories map[string]informers.SharedInformerFactory
 
 	// used to setup the APIClient
 	initRetry      retry.Retrier
 	Cl             kubernetes.Interface
 	timeoutSeconds int64
+	listPageSize   int64
+	// cachedList serves the lists from the watch cache of the API server
+	// instead of etcd
+	cachedList bool
+
+	// DiscoveryCl caches the discovery of the API groups and resources for
+	// cluster_agent.discovery_cache.ttl
+	DiscoveryCl discovery.DiscoveryInterface
+
+	// DynamicCl gives access to the resources without typed client, like
+	// the custom resources
+	DynamicCl dynamic.Interface
+	// dynamicWatchCl has no timeout, to allow long watch
+	dynamicWatchCl dynamic.Interface
+
+	// starts the endpoints informer of the node agents computing the
+	// metadata mapper themselves
+	nodeMetadataOnce sync.Once
+
+	// kubeContext is the kubeconfig context of the cluster, nil for the
+	// cluster configured by kubernetes_kubeconfig_path
+	kubeContext *config.KubeconfigContext
+}
+
+func newAPIClient(kubeContext *config.KubeconfigContext) *APIClient {
+	cfg := config.Get()
+	cl := &APIClient{
+		timeoutSeconds: cfg.GetInt64("kubernetes_apiserver_client_timeout"),
+		listPageSize:   cfg.GetInt64("kubernetes_apiserver_list_page_size"),
+		cachedList:     cfg.GetBool("kubernetes_apiserver_cached_list"),
+		kubeContext:    kubeContext,
+	}
+	name := "apiserver"
+	if kubeContext != nil {
+		name = "apiserver-" + kubeContext.Name
+	}
+	cl.initRetry.SetupRetrier(&retry.Config{
+		Name:          name,
+		AttemptMethod: cl.connect,
+		Strategy:      retry.RetryCount,
+		RetryCount:    10,
+		RetryDelay:    30 * time.Second,
+	})
+	return cl
+}
+
+// NewAPIClientWithClientset returns an API client using a clientset, like a
+// fake one, for a binary embedding the agent or a test to register it as the
+// API server client component. Its informers use the same clientset.
+func NewAPIClientWithClientset(clientset kubernetes.Interface) *APIClient {
+	cl := newAPIClient(nil)
+	cl.Cl = clientset
+	cl.DiscoveryCl = clientset.Discovery()
+	cl.InformerFactory = informers.NewSharedInformerFactoryWithOptions(clientset, defaultResyncPeriod(), informerFactoryOptions(metav1.NamespaceAll)...)
+	// already connected
+	cl.initRetry.SetupRetrier(&retry.Config{
+		Name:     "apiserver",
+		Strategy: retry.JustTesting,
+	})
+	return cl
 }
 
-// GetAPIClient returns the shared ApiClient instance.
+// GetAPIClient returns the shared ApiClient instance, the API server client
+// component of the default component registry.
 func GetAPIClient() (*APIClient, error) {
-	if globalAPIClient == nil {
-		globalAPIClient = &APIClient{
-			timeoutSeconds: config.Datadog.GetInt64("kubernetes_apiserver_client_timeout"),
-		}
-		globalAPIClient.initRetry.SetupRetrier(&retry.Config{
-			Name:          "apiserver",
-			AttemptMethod: globalAPIClient.connect,
-			Strategy:      retry.RetryCount,
-			RetryCount:    10,
-			RetryDelay:    30 * time.Second,
-		})
-	}
-	err := globalAPIClient.initRetry.TriggerRetry()
+	cl, ok := component.Default().GetOrRegister(ComponentName, func() interface{} {
+		return newAPIClient(nil)
+	}).(*APIClient)
+	if !ok {
+		return nil, fmt.Errorf("the %s component is not an API client", ComponentName)
+	}
+	err := cl.initRetry.TriggerRetry()
 	if err != nil {
 		log.Debugf("API Server init error: %s", err)
 		return nil, err
 	}
-	return globalAPIClient, nil
+	return cl, nil
+}
+
+// GetAPIClientForContext returns the shared ApiClient instance of the cluster
+// of a kubeconfig context configured in kubernetes_kubeconfig_contexts
+func GetAPIClientForContext(name string) (*APIClient, error) {
+	kubeContext, err := getKubeconfigContext(name)
+	if err != nil {
+		return nil, err
+	}
+
+	contextAPIClientsMutex.Lock()
+	cl, found := contextAPIClients[name]
+	if !found {
+		cl = newAPIClient(kubeContext)
+		contextAPIClients[name] = cl
+	}
+	contextAPIClientsMutex.Unlock()
+
+	err = cl.initRetry.TriggerRetry()
+	if err != nil {
+		log.Debugf("API Server init error for context %s: %s", name, err)
+		return nil, err
+	}
+	return cl, nil
 }
 
-func getKubeClient(timeout time.Duration) (kubernetes.Interface, error) {
+// getKubeconfigContext returns the context with the given name from
+// kubernetes_kubeconfig_contexts
+func getKubeconfigContext(name string) (*config.KubeconfigContext, error) {
+	var contexts []config.KubeconfigContext
+	if err := config.Datadog.UnmarshalKey("kubernetes_kubeconfig_contexts", &contexts); err != nil {
+		return nil, fmt.Errorf("invalid kubernetes_kubeconfig_contexts: %v", err)
+	}
+	for _, c := range contexts {
+		if c.Name != name {
+			continue
+		}
+		if c.KubeconfigPath == "" {
+			c.KubeconfigPath = config.Datadog.GetString("kubernetes_kubeconfig_path")
+		}
+		if c.KubeconfigPath == "" {
+			return nil, fmt.Errorf("no kubeconfig path for the kubeconfig context %s", name)
+		}
+		return &c, nil
+	}
+	return nil, fmt.Errorf("unknown kubeconfig context %s", name)
+}
+
+func getClientConfig(kubeContext *config.KubeconfigContext, timeout time.Duration) (*rest.Config, error) {
 	var clientConfig *rest.Config
 	var err error
 	cfgPath := config.Datadog.GetString("kubernetes_kubeconfig_path")
-	if cfgPath == "" {
+	if kubeContext != nil {
+		clientConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeContext.KubeconfigPath},
+			&clientcmd.ConfigOverrides{CurrentContext: kubeContext.Context},
+		).ClientConfig()
+		if err != nil {
+			log.Debugf("Can't create a config for the official client from the kubeconfig context %s: %v", kubeContext.Name, err)
+			return nil, err
+		}
+	} else if cfgPath == "" {
 		clientConfig, err = rest.InClusterConfig()
 		if err != nil {
 			log.Debugf("Can't create a config for the official client from the service account's token: %v", err)
@@ -98,43 +222,139 @@ func getKubeClient(timeout time.Duration) (kubernetes.Interface, error) {
 			return nil, err
 		}
 	}
+	if err = setupCredentialPlugins(clientConfig); err != nil {
+		return nil, err
+	}
 	clientConfig.Timeout = timeout
+	clientConfig.QPS = float32(config.Datadog.GetFloat64("kubernetes_apiserver_client_qps"))
+	clientConfig.Burst = config.Datadog.GetInt("kubernetes_apiserver_client_burst")
+	clientConfig.RateLimiter = newTelemetryRateLimiter(clientConfig.QPS, clientConfig.Burst)
+	return clientConfig, nil
+}
 
+func getKubeClient(kubeContext *config.KubeconfigContext, timeout time.Duration) (kubernetes.Interface, error) {
+	clientConfig, err := getClientConfig(kubeContext, timeout)
+	if err != nil {
+		return nil, err
+	}
 	if config.Datadog.GetBool("kubernetes_apiserver_use_protobuf") {
-		clientConfig.ContentType = "application/vnd.kubernetes.protobuf"
+		clientConfig.ContentType = protobufContentType
+		clientConfig.AcceptContentTypes = protobufContentType + "," + jsonContentType
+		clientConfig.WrapTransport = newContentTypeNegotiator(config.Datadog.GetStringSlice("kubernetes_apiserver_protobuf_groups"))
+	}
+	if timeout == 0 {
+		// the clients without timeout are used by the informers
+		clientConfig.WrapTransport = globalWatchTracker.wrap(clientConfig.WrapTransport)
 	}
 	return kubernetes.NewForConfig(clientConfig)
 }
 
-func getInformerFactory() (informers.SharedInformerFactory, error) {
-	resyncPeriodSeconds := time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period"))
-	client, err := getKubeClient(0) // No timeout for the Informers, to allow long watch.
+// getDynamicClient returns a client for any resource, including the custom
+// resources, which are only served in JSON
+func getDynamicClient(kubeContext *config.KubeconfigContext, timeout time.Duration) (dynamic.Interface, error) {
+	clientConfig, err := getClientConfig(kubeContext, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if timeout == 0 {
+		clientConfig.WrapTransport = globalWatchTracker.wrap(clientConfig.WrapTransport)
+	}
+	return dynamic.NewForConfig(clientConfig)
+}
+
+func getInformerFactory(kubeContext *config.KubeconfigContext) (informers.SharedInformerFactory, error) {
+	client, err := getKubeClient(kubeContext, 0) // No timeout for the Informers, to allow long watch.
 	if err != nil {
 		log.Infof("Could not get apiserver client: %v", err)
 		return nil, err
 	}
-	return informers.NewSharedInformerFactory(client, resyncPeriodSeconds*time.Second), nil
+	return informers.NewSharedInformerFactoryWithOptions(client, defaultResyncPeriod(), informerFactoryOptions(metav1.NamespaceAll)...), nil
+}
+
+// getNamespacedInformerFactories returns an informer factory scoped to each
+// namespace, for the deployments not granting cluster-wide rights
+func getNamespacedInformerFactories(kubeContext *config.KubeconfigContext, namespaces []string) (map[string]informers.SharedInformerFactory, error) {
+	client, err := getKubeClient(kubeContext, 0) // No timeout for the Informers, to allow long watch.
+	if err != nil {
+		log.Infof("Could not get apiserver client: %v", err)
+		return nil, err
+	}
+	factories := make(map[string]informers.SharedInformerFactory, len(namespaces))
+	for _, ns := range namespaces {
+		factories[ns] = informers.NewSharedInformerFactoryWithOptions(client, defaultResyncPeriod(), informerFactoryOptions(ns)...)
+	}
+	return factories, nil
+}
+
+// watchedNamespaces returns the namespaces of kubernetes_namespaces_include,
+// or metav1.NamespaceAll if the agent watches the whole cluster
+func (c *APIClient) watchedNamespaces() []string {
+	if c.kubeContext == nil {
+		if namespaces := config.Datadog.GetStringSlice("kubernetes_namespaces_include"); len(namespaces) > 0 {
+			return namespaces
+		}
+	}
+	return []string{metav1.NamespaceAll}
+}
+
+// InformerFactoriesByNamespace returns the informer factories to watch the
+// namespaced resources with: the factories of the namespaces of
+// kubernetes_namespaces_include, or InformerFactory for metav1.NamespaceAll
+func (c *APIClient) InformerFactoriesByNamespace() map[string]informers.SharedInformerFactory {
+	if c.NamespacedInformerFactories != nil {
+		return c.NamespacedInformerFactories
+	}
+	return map[string]informers.SharedInformerFactory{metav1.NamespaceAll: c.InformerFactory}
 }
 
 func (c *APIClient) connect() error {
 	var err error
-	c.Cl, err = getKubeClient(time.Duration(c.timeoutSeconds) * time.Second)
+	c.Cl, err = getKubeClient(c.kubeContext, time.Duration(c.timeoutSeconds)*time.Second)
 	if err != nil {
 		log.Infof("Could not get apiserver client: %v", err)
 		return err
 	}
+	if ttl := config.Datadog.GetInt64("cluster_agent.discovery_cache.ttl"); ttl > 0 {
+		c.DiscoveryCl = newCachedDiscovery(c.Cl.Discovery(), discoveryCacheDir(c.kubeContext), time.Duration(ttl)*time.Second)
+	} else {
+		c.DiscoveryCl = c.Cl.Discovery()
+	}
 	// informer factory uses its own clientset with a larger timeout
-	c.InformerFactory, err = getInformerFactory()
+	c.InformerFactory, err = getInformerFactory(c.kubeContext)
+	if err != nil {
+		return err
+	}
+	if namespaces := c.watchedNamespaces(); namespaces[0] != metav1.NamespaceAll {
+		log.Infof("Watching the namespaced resources of the namespaces %s only", strings.Join(namespaces, ", "))
+		c.NamespacedInformerFactories, err = getNamespacedInformerFactories(c.kubeContext, namespaces)
+		if err != nil {
+			return err
+		}
+	}
+	c.DynamicCl, err = getDynamicClient(c.kubeContext, time.Duration(c.timeoutSeconds)*time.Second)
 	if err != nil {
+		log.Infof("Could not get apiserver dynamic client: %v", err)
+		return err
+	}
+	c.dynamicWatchCl, err = getDynamicClient(c.kubeContext, 0)
+	if err != nil {
+		log.Infof("Could not get apiserver dynamic client: %v", err)
 		return err
 	}
 
-	// Try to get apiserver version to confim connectivity
-	APIversion := c.Cl.Discovery().RESTClient().APIVersion()
-	if APIversion.Empty() {
-		return fmt.Errorf("cannot retrieve the version of the API server at the moment")
+	// Try to get the API groups to confirm connectivity, through the cached
+	// discovery so that the retries and the restarts don't query /apis again
+	groups, err := c.DiscoveryCl.ServerGroups()
+	if err != nil {
+		return fmt.Errorf("cannot retrieve the API groups of the API server at the moment: %v", err)
+	}
+	log.Debugf("Connected to kubernetes apiserver, %d API groups served", len(groups.Groups))
+
+	// The RBAC of the other clusters is set up for the components targeting
+	// them, which don't need the resources of the local cluster.
+	if c.kubeContext != nil {
+		return nil
 	}
-	log.Debugf("Connected to kubernetes apiserver, version %s", APIversion.Version)
 
 	err = c.checkResourcesAuth()
 	if err != nil {
@@ -147,12 +367,14 @@ func (c *APIClient) connect() error {
 // metadataMapperBundle maps pod names to associated metadata.
 type metadataMapperBundle struct {
 	Services apiv1.NamespacesPodsStringsSet
-	mapOnIP  bool // temporary opt-out of the new mapping logic
+	Pods     apiv1.NamespacesPodsStringsSet // tags of the pods, set by the PodMetadataController
+	mapOnIP  bool                           // temporary opt-out of the new mapping logic
 }
 
 func newMetadataMapperBundle() *metadataMapperBundle {
 	return &metadataMapperBundle{
 		Services: apiv1.NewNamespacesPodsStringsSet(),
+		Pods:     apiv1.NewNamespacesPodsStringsSet(),
 		mapOnIP:  config.Datadog.GetBool("kubernetes_map_services_on_ip"),
 	}
 }
@@ -161,43 +383,59 @@ func aggregateCheckResourcesErrors(errorMessages []string) error {
 	if len(errorMessages) == 0 {
 		return nil
 	}
-	return fmt.Errorf("check resources failed: %s", strings.Join(errorMessages, ", "))
+	return fmt.Errorf("check resources failed: %s, run the `diagnose kube-rbac` command to list the missing permissions", strings.Join(errorMessages, ", "))
 }
 
 // checkResourcesAuth is meant to check that we can query resources from the API server.
 // Depending on the user's config we only trigger an error if necessary.
 // The Event check requires getting Events data.
 // The MetadataMapper case, requires access to Services, Nodes and Pods.
+// The namespaced resources are checked in each namespace of kubernetes_namespaces_include.
 func (c *APIClient) checkResourcesAuth() error {
 	var errorMessages []string
+	namespaces := c.watchedNamespaces()
+
+	// checkNamespaced returns false if the check must stop at the first error
+	checkNamespaced := func(resource string, list func(ns string) error) bool {
+		for _, ns := range namespaces {
+			if err := list(ns); err != nil {
+				name := resource
+				if ns != metav1.NamespaceAll {
+					name = fmt.Sprintf("%s in namespace %s", resource, ns)
+				}
+				errorMessages = append(errorMessages, fmt.Sprintf("%s: %q", name, err.Error()))
+				if !isConnectVerbose {
+					return false
+				}
+			}
+		}
+		return true
+	}
 
 	// We always want to collect events
-	_, err := c.Cl.CoreV1().Events("").List(metav1.ListOptions{Limit: 1, TimeoutSeconds: &c.timeoutSeconds})
-	if err != nil {
-		errorMessages = append(errorMessages, fmt.Sprintf("event collection: %q", err.Error()))
-		if !isConnectVerbose {
-			return aggregateCheckResourcesErrors(errorMessages)
-		}
+	if !checkNamespaced("event collection", func(ns string) error {
+		_, err := c.Cl.CoreV1().Events(ns).List(metav1.ListOptions{Limit: 1, TimeoutSeconds: &c.timeoutSeconds})
+		return err
+	}) {
+		return aggregateCheckResourcesErrors(errorMessages)
 	}
 
 	if config.Datadog.GetBool("kubernetes_collect_metadata_tags") == false {
 		return aggregateCheckResourcesErrors(errorMessages)
 	}
-	_, err = c.Cl.CoreV1().Services("").List(metav1.ListOptions{Limit: 1, TimeoutSeconds: &c.timeoutSeconds})
-	if err != nil {
-		errorMessages = append(errorMessages, fmt.Sprintf("service collection: %q", err.Error()))
-		if !isConnectVerbose {
-			return aggregateCheckResourcesErrors(errorMessages)
-		}
+	if !checkNamespaced("service collection", func(ns string) error {
+		_, err := c.Cl.CoreV1().Services(ns).List(metav1.ListOptions{Limit: 1, TimeoutSeconds: &c.timeoutSeconds})
+		return err
+	}) {
+		return aggregateCheckResourcesErrors(errorMessages)
 	}
-	_, err = c.Cl.CoreV1().Pods("").List(metav1.ListOptions{Limit: 1, TimeoutSeconds: &c.timeoutSeconds})
-	if err != nil {
-		errorMessages = append(errorMessages, fmt.Sprintf("pod collection: %q", err.Error()))
-		if !isConnectVerbose {
-			return aggregateCheckResourcesErrors(errorMessages)
-		}
+	if !checkNamespaced("pod collection", func(ns string) error {
+		_, err := c.Cl.CoreV1().Pods(ns).List(metav1.ListOptions{Limit: 1, TimeoutSeconds: &c.timeoutSeconds})
+		return err
+	}) {
+		return aggregateCheckResourcesErrors(errorMessages)
 	}
-	_, err = c.Cl.CoreV1().Nodes().List(metav1.ListOptions{Limit: 1, TimeoutSeconds: &c.timeoutSeconds})
+	_, err := c.Cl.CoreV1().Nodes().List(metav1.ListOptions{Limit: 1, TimeoutSeconds: &c.timeoutSeconds})
 
 	if err != nil {
 		errorMessages = append(errorMessages, fmt.Sprintf("node collection: %q", err.Error()))
@@ -207,77 +445,59 @@ func (c *APIClient) checkResourcesAuth() error {
 
 // ComponentStatuses returns the component status list from the APIServer
 func (c *APIClient) ComponentStatuses() (*v1.ComponentStatusList, error) {
-	return c.Cl.CoreV1().ComponentStatuses().List(metav1.ListOptions{TimeoutSeconds: &c.timeoutSeconds})
+	return c.ComponentStatusesWithContext(context.Background())
 }
 
-// GetTokenFromConfigmap returns the value of the `tokenValue` from the `tokenKey` in the ConfigMap `configMapDCAToken` if its timestamp is less than tokenTimeout old.
-func (c *APIClient) GetTokenFromConfigmap(token string, tokenTimeout int64) (string, bool, error) {
-	namespace := common.GetResourcesNamespace()
-	tokenConfigMap, err := c.Cl.CoreV1().ConfigMaps(namespace).Get(configMapDCAToken, metav1.GetOptions{})
-	if err != nil {
-		log.Debugf("Could not find the ConfigMap %s: %s", configMapDCAToken, err.Error())
-		return "", false, ErrNotFound
-	}
-	log.Infof("Found the ConfigMap %s", configMapDCAToken)
-
-	eventTokenKey := fmt.Sprintf("%s.%s", token, tokenKey)
-	tokenValue, found := tokenConfigMap.Data[eventTokenKey]
-	if !found {
-		log.Errorf("%s was not found in the ConfigMap %s", eventTokenKey, configMapDCAToken)
-		return "", found, ErrNotFound
-	}
-	log.Infof("%s is %q", token, tokenValue)
-
-	eventTokenTS := fmt.Sprintf("%s.%s", token, tokenTime)
-	tokenTimeStr, set := tokenConfigMap.Data[eventTokenTS] // This is so we can have one timestamp per token
+// ComponentStatusesWithContext returns the component status list from the APIServer,
+// the request is canceled with ctx
+func (c *APIClient) ComponentStatusesWithContext(ctx context.Context) (*v1.ComponentStatusList, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
 
-	if !set {
-		log.Debugf("Could not find timestamp associated with %s in the ConfigMap %s. Refreshing.", eventTokenTS, configMapDCAToken)
-		// We return ErrOutdated to reset the tokenValue and its timestamp as token's timestamp was not found.
-		return tokenValue, found, ErrOutdated
-	}
+	var result *v1.ComponentStatusList
+	err := doWithContext(ctx, func() (err error) {
+		result, err = c.Cl.CoreV1().ComponentStatuses().List(metav1.ListOptions{TimeoutSeconds: &c.timeoutSeconds})
+		return err
+	})
+	return result, err
+}
 
-	tokenTime, err := time.Parse(time.RFC822, tokenTimeStr)
-	if err != nil {
-		return "", found, log.Errorf("could not convert the timestamp associated with %s from the ConfigMap %s", token, configMapDCAToken)
-	}
-	tokenAge := time.Now().Unix() - tokenTime.Unix()
+// GetTokenFromConfigmap returns the value of the `tokenValue` from the `tokenKey` in the ConfigMap `configMapDCAToken` if its timestamp is less than tokenTimeout old.
+func (c *APIClient) GetTokenFromConfigmap(token string, tokenTimeout int64) (string, bool, error) {
+	return c.GetTokenFromConfigmapWithContext(context.Background(), token, tokenTimeout)
+}
 
-	if tokenAge > tokenTimeout {
-		log.Debugf("The tokenValue %s is outdated, refreshing the state", token)
-		return tokenValue, found, ErrOutdated
-	}
-	log.Debugf("Token %s was updated recently, using value to collect newer events.", token)
-	return tokenValue, found, nil
+// GetTokenFromConfigmapWithContext is GetTokenFromConfigmap, the request is canceled with ctx
+func (c *APIClient) GetTokenFromConfigmapWithContext(ctx context.Context, token string, tokenTimeout int64) (string, bool, error) {
+	return (&configMapTokenStore{c}).GetToken(ctx, token, tokenTimeout)
 }
 
 // UpdateTokenInConfigmap updates the value of the `tokenValue` from the `tokenKey` and
 // sets its collected timestamp in the ConfigMap `configmaptokendca`
 func (c *APIClient) UpdateTokenInConfigmap(token, tokenValue string) error {
-	namespace := common.GetResourcesNamespace()
-	tokenConfigMap, err := c.Cl.CoreV1().ConfigMaps(namespace).Get(configMapDCAToken, metav1.GetOptions{})
-	if err != nil {
-		return err
-	}
-
-	eventTokenKey := fmt.Sprintf("%s.%s", token, tokenKey)
-	tokenConfigMap.Data[eventTokenKey] = tokenValue
-
-	now := time.Now()
-	eventTokenTS := fmt.Sprintf("%s.%s", token, tokenTime)
-	tokenConfigMap.Data[eventTokenTS] = now.Format(time.RFC822) // Timestamps in the ConfigMap should all use the type int.
+	return c.UpdateTokenInConfigmapWithContext(context.Background(), token, tokenValue)
+}
 
-	_, err = c.Cl.CoreV1().ConfigMaps(namespace).Update(tokenConfigMap)
-	if err != nil {
-		return err
-	}
-	log.Debugf("Updated %s to %s in the ConfigMap %s", eventTokenKey, tokenValue, configMapDCAToken)
-	return nil
+// UpdateTokenInConfigmapWithContext is UpdateTokenInConfigmap, the requests are canceled with ctx
+func (c *APIClient) UpdateTokenInConfigmapWithContext(ctx context.Context, token, tokenValue string) error {
+	return (&configMapTokenStore{c}).UpdateToken(ctx, token, tokenValue)
 }
 
 // NodeLabels is used to fetch the labels attached to a given node.
 func (c *APIClient) NodeLabels(nodeName string) (map[string]string, error) {
-	node, err := c.Cl.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+	return c.NodeLabelsWithContext(context.Background(), nodeName)
+}
+
+// NodeLabelsWithContext is NodeLabels, the request is canceled with ctx
+func (c *APIClient) NodeLabelsWithContext(ctx context.Context, nodeName string) (map[string]string, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	var node *v1.Node
+	err := doWithContext(ctx, func() (err error) {
+		node, err = c.Cl.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -286,37 +506,87 @@ func (c *APIClient) NodeLabels(nodeName string) (map[string]string, error) {
 
 // GetNodeForPod retrieves a pod and returns the name of the node it is scheduled on
 func (c *APIClient) GetNodeForPod(namespace, pod_name string) (string, error) {
-	pod, err := c.Cl.CoreV1().Pods(namespace).Get(pod_name, metav1.GetOptions{})
+	return c.GetNodeForPodWithContext(context.Background(), namespace, pod_name)
+}
+
+// GetNodeForPodWithContext is GetNodeForPod, the request is canceled with ctx
+func (c *APIClient) GetNodeForPodWithContext(ctx context.Context, namespace, podName string) (string, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	var pod *v1.Pod
+	err := doWithContext(ctx, func() (err error) {
+		pod, err = c.Cl.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
 	return pod.Spec.NodeName, nil
 }
 
+// getConfigMap retrieves a ConfigMap, the request is canceled with ctx
+func (c *APIClient) getConfigMap(ctx context.Context, namespace, name string) (*v1.ConfigMap, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	var configMap *v1.ConfigMap
+	err := doWithContext(ctx, func() (err error) {
+		configMap, err = c.Cl.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+		return err
+	})
+	return configMap, err
+}
+
+// requestContext bounds ctx with the `kubernetes_apiserver_client_timeout`
+// if it doesn't have a deadline already
+func (c *APIClient) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, hasDeadline := ctx.Deadline(); hasDeadline || c.timeoutSeconds <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(c.timeoutSeconds)*time.Second)
+}
+
+// doWithContext runs a request of the clientset, which doesn't take a context
+// in this client-go version, and returns the error of ctx if it is done first.
+// The request itself is still bounded by the timeout of the client.
+func doWithContext(ctx context.Context, request func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- request()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // GetMetadataMapBundleOnAllNodes is used for the CLI svcmap command to run fetch the metadata map of all nodes.
 func GetMetadataMapBundleOnAllNodes(cl *APIClient) (*apiv1.MetadataResponse, error) {
 	stats := apiv1.NewMetadataResponse()
-	var err error
 
-	nodes, err := getNodeList(cl)
+	err := cl.ListNodesPaginated(ListSelectors{}, func(nodes []v1.Node) error {
+		for _, node := range nodes {
+			if node.GetObjectMeta() == nil {
+				log.Error("Incorrect payload when evaluating a node for the service mapper") // This will be removed as we move to the client-go
+				continue
+			}
+			bundle, err := getMetadataMapBundle(node.Name)
+			if err != nil {
+				warn := fmt.Sprintf("Node %s could not be added to the service map bundle: %s", node.Name, err.Error())
+				stats.Warnings = append(stats.Warnings, warn)
+			}
+			stats.Nodes[node.Name] = convertmetadataMapperBundleToAPI(bundle)
+		}
+		return nil
+	})
 	if err != nil {
+		log.Errorf("Can't list nodes from the API server: %s", err.Error())
 		stats.Errors = fmt.Sprintf("Failed to get nodes from the API server: %s", err.Error())
 		return stats, err
 	}
-
-	for _, node := range nodes {
-		if node.GetObjectMeta() == nil {
-			log.Error("Incorrect payload when evaluating a node for the service mapper") // This will be removed as we move to the client-go
-			continue
-		}
-		var bundle *metadataMapperBundle
-		bundle, err = getMetadataMapBundle(node.Name)
-		if err != nil {
-			warn := fmt.Sprintf("Node %s could not be added to the service map bundle: %s", node.Name, err.Error())
-			stats.Warnings = append(stats.Warnings, warn)
-		}
-		stats.Nodes[node.Name] = convertmetadataMapperBundleToAPI(bundle)
-	}
 	return stats, nil
 }
 
@@ -342,29 +612,13 @@ func getMetadataMapBundle(nodeName string) (*metadataMapperBundle, error) {
 	return metaBundle.(*metadataMapperBundle), nil
 }
 
-func getNodeList(cl *APIClient) ([]v1.Node, error) {
-	nodes, err := cl.Cl.CoreV1().Nodes().List(metav1.ListOptions{TimeoutSeconds: &cl.timeoutSeconds})
-	if err != nil {
-		log.Errorf("Can't list nodes from the API server: %s", err.Error())
-		return nil, err
-	}
-	return nodes.Items, nil
-}
-
-// GetRESTObject allows to retrive a custom resource from the APIserver
-func (c *APIClient) GetRESTObject(path string, output runtime.Object) error {
-	result := c.Cl.CoreV1().RESTClient().Get().AbsPath(path).Do()
-	if result.Error() != nil {
-		return result.Error()
-	}
-
-	return result.Into(output)
-}
-
 func convertmetadataMapperBundleToAPI(input *metadataMapperBundle) *apiv1.MetadataResponseBundle {
 	output := apiv1.NewMetadataResponseBundle()
 	for key, val := range input.Services {
 		output.Services[key] = val
 	}
+	for key, val := range input.Pods {
+		output.Pods[key] = val
+	}
 	return output
 }
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: local
+clusters:
+- name: local
+  cluster:
+    server: https://local.example.com
+- name: management
+  cluster:
+    server: https://management.example.com
+users:
+- name: agent
+  user:
+    token: foo
+contexts:
+- name: local
+  context:
+    cluster: local
+    user: agent
+- name: admin@management
+  context:
+    cluster: management
+    user: agent
+`
+
+func TestGetKubeconfigContext(t *testing.T) {
+	f, err := ioutil.TempFile("", "kubeconfig")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(testKubeconfig)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	mockConfig := config.Mock()
+	mockConfig.Set("kubernetes_kubeconfig_contexts", []map[string]interface{}{
+		{"name": "management", "kubeconfig_path": f.Name(), "context": "admin@management"},
+		{"name": "default", "kubeconfig_path": f.Name()},
+		{"name": "nopath"},
+	})
+
+	kubeContext, err := getKubeconfigContext("management")
+	require.NoError(t, err)
+	clientConfig, err := getClientConfig(kubeContext, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "https://management.example.com", clientConfig.Host)
+	assert.Equal(t, 5*time.Second, clientConfig.Timeout)
+
+	// The current context of the kubeconfig is used by default
+	kubeContext, err = getKubeconfigContext("default")
+	require.NoError(t, err)
+	clientConfig, err = getClientConfig(kubeContext, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "https://local.example.com", clientConfig.Host)
+
+	_, err = getKubeconfigContext("nopath")
+	assert.Error(t, err)
+
+	// The path defaults to kubernetes_kubeconfig_path
+	mockConfig.Set("kubernetes_kubeconfig_path", f.Name())
+	kubeContext, err = getKubeconfigContext("nopath")
+	require.NoError(t, err)
+	assert.Equal(t, f.Name(), kubeContext.KubeconfigPath)
+
+	_, err = getKubeconfigContext("unknown")
+	assert.Error(t, err)
+	_, err = GetAPIClientForContext("unknown")
+	assert.Error(t, err)
+}
@@ -8,28 +8,85 @@
 package apiserver
 
 import (
+	"context"
+	"fmt"
+
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	toolscache "k8s.io/client-go/tools/cache"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-// NodeMetadataMapping only fetch the endpoints from Kubernetes apiserver and add the metadataMapper of the
-// node to the cache
+// NodeMetadataMapping adds the metadataMapper of the node to the cache.
 // Only called when the node agent computes the metadata mapper locally and does not rely on the DCA.
 func (c *APIClient) NodeMetadataMapping(nodeName string, pods []*kubelet.Pod) error {
-	endpointList, err := c.Cl.CoreV1().Endpoints("").List(metav1.ListOptions{TimeoutSeconds: &c.timeoutSeconds})
-	if err != nil {
-		log.Errorf("Could not collect endpoints from the API Server: %q", err.Error())
-		return err
+	return c.NodeMetadataMappingWithContext(context.Background(), nodeName, pods)
+}
+
+// NodeMetadataMappingWithContext is NodeMetadataMapping, waiting for the
+// endpoints to be synced is canceled with ctx.
+//
+// The endpoints are watched by shared informers, one per watched namespace,
+// started on the first call: their event handlers update the services of the
+// pods of the node incrementally, instead of listing all the endpoints of the
+// cluster on every call. Mapping the services on the pods IPs needs the pods of
+// the node, this mapping is computed on every call from the endpoints cached by
+// the informers.
+func (c *APIClient) NodeMetadataMappingWithContext(ctx context.Context, nodeName string, pods []*kubelet.Pod) error {
+	if nodeName == "" {
+		return fmt.Errorf("empty node name, cannot map the services of the pods")
+	}
+	factories := c.InformerFactoriesByNamespace()
+	mapOnIP := config.Datadog.GetBool("kubernetes_map_services_on_ip")
+
+	c.nodeMetadataOnce.Do(func() {
+		// the informers run as long as the agent
+		stopCh := make(chan struct{})
+		for ns, factory := range factories {
+			endpointsInformer := factory.Core().V1().Endpoints()
+			if !mapOnIP {
+				go newNodeMetadataController(nodeName, endpointsInformer).Run(stopCh)
+			}
+			RegisterInformerTelemetry(InformerName("endpoints", ns), endpointsInformer.Informer())
+			factory.Start(stopCh)
+		}
+		log.Debugf("Started watching endpoints to map the services of node %s", nodeName)
+	})
+
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+	var endpoints []*v1.Endpoints
+	for _, factory := range factories {
+		endpointsInformer := factory.Core().V1().Endpoints()
+		if !toolscache.WaitForCacheSync(ctx.Done(), endpointsInformer.Informer().HasSynced) {
+			return fmt.Errorf("endpoints not synced from the API Server yet")
+		}
+		if !mapOnIP {
+			continue
+		}
+		list, err := endpointsInformer.Lister().List(labels.Everything())
+		if err != nil {
+			log.Errorf("Could not list endpoints from the informer cache: %q", err.Error())
+			return err
+		}
+		endpoints = append(endpoints, list...)
 	}
-	if endpointList.Items == nil {
+	if !mapOnIP {
+		return nil
+	}
+
+	if len(endpoints) == 0 {
 		log.Debug("No endpoints collected from the API server")
 		return nil
 	}
-	log.Debugf("Successfully collected endpoints")
+	endpointList := &v1.EndpointsList{Items: make([]v1.Endpoints, 0, len(endpoints))}
+	for _, e := range endpoints {
+		endpointList.Items = append(endpointList.Items, *e)
+	}
 
 	var node v1.Node
 	var nodeList v1.NodeList
@@ -34,6 +34,12 @@ func GetAPIClient() (*APIClient, error) {
 	return &APIClient{}, nil
 }
 
+// GetAPIClientForContext returns the shared ApiClient instance of a kubeconfig context.
+func GetAPIClientForContext(name string) (*APIClient, error) {
+	log.Errorf("GetAPIClientForContext not implemented %s", ErrNotCompiled.Error())
+	return &APIClient{}, nil
+}
+
 // GetPodMetadataNames is used when the API endpoint of the DCA to get the services of a pod is hit.
 func GetPodMetadataNames(nodeName, ns, podName string) ([]string, error) {
 	log.Errorf("GetPodMetadataNames not implemented %s", ErrNotCompiled.Error())
@@ -52,8 +58,30 @@ func GetMetadataMapBundleOnAllNodes(_ *APIClient) (*apiv1.MetadataResponse, erro
 	return nil, nil
 }
 
+// GetPodMetadataByIP is used when the API endpoint of the DCA to get the pod of an IP is hit.
+func GetPodMetadataByIP(ip string) (*apiv1.PodMetadataByIPResponse, error) {
+	log.Errorf("GetPodMetadataByIP not implemented %s", ErrNotCompiled.Error())
+	return nil, nil
+}
+
+// GetServicesByClusterIP is used when the API endpoint of the DCA to map the ClusterIPs to their services is hit.
+func GetServicesByClusterIP() (*apiv1.ServicesByClusterIPResponse, error) {
+	log.Errorf("GetServicesByClusterIP not implemented %s", ErrNotCompiled.Error())
+	return nil, nil
+}
+
 // GetNodeLabels retrieves the labels of the queried node from the cache of the shared informer.
 func GetNodeLabels(nodeName string) (map[string]string, error) {
 	log.Errorf("GetNodeLabels not implemented %s", ErrNotCompiled.Error())
 	return nil, nil
 }
+
+// NewServiceAccountTokenValidator is used by the DCA to validate the service account tokens.
+func NewServiceAccountTokenValidator() (func(token string) error, error) {
+	return nil, ErrNotCompiled
+}
+
+// RefreshDiscoveryCache is used by the DCA API to drop the cached API discovery.
+func RefreshDiscoveryCache() int {
+	return 0
+}
@@ -0,0 +1,191 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/component"
+)
+
+func newTestAPIClient(t *testing.T, handler http.HandlerFunc) (*APIClient, func()) {
+	server := httptest.NewServer(handler)
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	return &APIClient{Cl: client, timeoutSeconds: 5}, server.Close
+}
+
+func TestNodeLabelsWithContext(t *testing.T) {
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/nodes/node1", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "Node", "apiVersion": "v1", "metadata": {"name": "node1", "labels": {"foo": "bar"}}}`))
+	})
+	defer cleanup()
+
+	labels, err := cl.NodeLabelsWithContext(context.Background(), "node1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo": "bar"}, labels)
+}
+
+func TestNodeLabelsFakeClientset(t *testing.T) {
+	cl := NewAPIClientWithClientset(fake.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"foo": "bar"}},
+	}))
+
+	labels, err := cl.NodeLabels("node1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo": "bar"}, labels)
+
+	_, err = cl.NodeLabels("node2")
+	assert.Error(t, err)
+}
+
+func TestGetNodeForPodWithContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	defer cleanup()
+	// unblock the handler before closing the server, which waits for it
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := cl.GetNodeForPodWithContext(ctx, "default", "pod1")
+	assert.Error(t, err)
+	// the deadline of the caller is used instead of the 5 seconds client timeout
+	assert.True(t, time.Since(start) < 2*time.Second)
+}
+
+func TestRequestContext(t *testing.T) {
+	cl := &APIClient{timeoutSeconds: 5}
+
+	ctx, cancel := cl.requestContext(context.Background())
+	defer cancel()
+	deadline, found := ctx.Deadline()
+	require.True(t, found)
+	assert.True(t, time.Until(deadline) <= 5*time.Second)
+
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer parentCancel()
+	ctx, cancel = cl.requestContext(parent)
+	defer cancel()
+	deadline, _ = ctx.Deadline()
+	parentDeadline, _ := parent.Deadline()
+	assert.Equal(t, parentDeadline, deadline)
+}
+
+func TestCheckResourcesAuthNamespaces(t *testing.T) {
+	var paths []string
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.HasPrefix(r.URL.Path, "/api/v1/namespaces/forbidden/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "List", "apiVersion": "v1", "items": []}`))
+	})
+	defer cleanup()
+
+	mockConfig := config.Mock()
+	mockConfig.Set("kubernetes_collect_metadata_tags", true)
+
+	// cluster-wide
+	require.NoError(t, cl.checkResourcesAuth())
+	assert.Equal(t, []string{"/api/v1/events", "/api/v1/services", "/api/v1/pods", "/api/v1/nodes"}, paths)
+	assert.Equal(t, []string{""}, cl.watchedNamespaces())
+
+	// restricted to some namespaces
+	mockConfig.Set("kubernetes_namespaces_include", []string{"default", "kube-system"})
+	defer mockConfig.Set("kubernetes_namespaces_include", []string{})
+	paths = nil
+	require.NoError(t, cl.checkResourcesAuth())
+	assert.Equal(t, []string{
+		"/api/v1/namespaces/default/events",
+		"/api/v1/namespaces/kube-system/events",
+		"/api/v1/namespaces/default/services",
+		"/api/v1/namespaces/kube-system/services",
+		"/api/v1/namespaces/default/pods",
+		"/api/v1/namespaces/kube-system/pods",
+		"/api/v1/nodes",
+	}, paths)
+
+	mockConfig.Set("kubernetes_namespaces_include", []string{"default", "forbidden"})
+	err := cl.checkResourcesAuth()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "event collection in namespace forbidden")
+
+	// every error names its own namespace
+	isConnectVerbose = true
+	defer func() { isConnectVerbose = false }()
+	mockConfig.Set("kubernetes_namespaces_include", []string{"forbidden", "default", "forbidden"})
+	err = cl.checkResourcesAuth()
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "in namespace forbidden in namespace")
+	assert.Equal(t, 2, strings.Count(err.Error(), "service collection in namespace forbidden:"))
+}
+
+func TestGetAPIClientFromRegistry(t *testing.T) {
+	registry := component.NewRegistry()
+	restore := component.SetDefault(registry)
+	defer restore()
+
+	clientset := fake.NewSimpleClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"foo": "bar"}}})
+	registry.Register(ComponentName, NewAPIClientWithClientset(clientset))
+
+	cl, err := GetAPIClient()
+	require.NoError(t, err)
+	assert.True(t, cl.Cl == clientset)
+	node, err := cl.Cl.CoreV1().Nodes().Get("node1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo": "bar"}, node.Labels)
+	assert.NotNil(t, cl.InformerFactory)
+}
+
+func TestGetAPIClientCreatedByRegistry(t *testing.T) {
+	registry := component.NewRegistry()
+	restore := component.SetDefault(registry)
+	defer restore()
+	mockConfig := config.Mock()
+	mockConfig.Set("kubernetes_apiserver_client_timeout", 42)
+	registry.Register(config.ComponentName, mockConfig)
+
+	// outside of a cluster, the client is created and registered but can't
+	// connect
+	done := make(chan error)
+	go func() {
+		_, err := GetAPIClient()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("GetAPIClient deadlocked")
+	}
+	cl, ok := registry.Get(ComponentName).(*APIClient)
+	require.True(t, ok)
+	assert.Equal(t, int64(42), cl.timeoutSeconds)
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+	"os/exec"
+
+	"k8s.io/client-go/rest"
+	// registers the oidc auth provider, refreshing the ID token with the refresh token
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// setupCredentialPlugins checks the credential plugins of a kubeconfig user.
+// client-go runs the exec plugins (aws-iam-authenticator, gke-gcloud-auth-plugin...)
+// again when their credentials expire or are rejected, and the auth providers
+// refresh their tokens, so the long-running informers keep authenticating.
+func setupCredentialPlugins(clientConfig *rest.Config) error {
+	if clientConfig.ExecProvider != nil {
+		command := clientConfig.ExecProvider.Command
+		if _, err := exec.LookPath(command); err != nil {
+			return fmt.Errorf("the credential plugin %s of the kubeconfig can't be run: %v", command, err)
+		}
+		log.Infof("Authenticating to the API server with the credential plugin %s", command)
+	}
+
+	if clientConfig.AuthProvider != nil {
+		log.Infof("Authenticating to the API server with the %s auth provider", clientConfig.AuthProvider.Name)
+		if clientConfig.AuthConfigPersister != nil {
+			clientConfig.AuthConfigPersister = &inMemoryFallbackPersister{clientConfig.AuthConfigPersister}
+		}
+	}
+	return nil
+}
+
+// inMemoryFallbackPersister persists the refreshed tokens of the auth providers
+// to the kubeconfig, like kubectl. The kubeconfig is usually mounted read-only
+// from a secret though, and the auth providers fail the requests when the new
+// tokens can't be written: the failure is logged instead, the tokens staying
+// in memory until the next refresh.
+type inMemoryFallbackPersister struct {
+	persister rest.AuthProviderConfigPersister
+}
+
+// Persist implements rest.AuthProviderConfigPersister
+func (p *inMemoryFallbackPersister) Persist(authConfig map[string]string) error {
+	if err := p.persister.Persist(authConfig); err != nil {
+		log.Debugf("Could not write the refreshed tokens to the kubeconfig, keeping them in memory: %v", err)
+	}
+	return nil
+}
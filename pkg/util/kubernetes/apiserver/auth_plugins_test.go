@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const testPluginsKubeconfig = `apiVersion: v1
+kind: Config
+current-context: eks
+clusters:
+- name: cluster
+  cluster:
+    server: https://cluster.example.com
+users:
+- name: eks
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1alpha1
+      command: %s
+      args: ["token", "-i", "cluster"]
+- name: oidc
+  user:
+    auth-provider:
+      name: oidc
+      config:
+        client-id: agent
+        idp-issuer-url: https://issuer.example.com
+        refresh-token: foo
+contexts:
+- name: eks
+  context:
+    cluster: cluster
+    user: eks
+- name: oidc
+  context:
+    cluster: cluster
+    user: oidc
+`
+
+func writeTestPluginsKubeconfig(t *testing.T, command string) string {
+	f, err := ioutil.TempFile("", "kubeconfig")
+	require.NoError(t, err)
+	_, err = f.WriteString(fmt.Sprintf(testPluginsKubeconfig, command))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return f.Name()
+}
+
+func TestGetClientConfigCredentialPlugins(t *testing.T) {
+	path := writeTestPluginsKubeconfig(t, "sh")
+	defer os.Remove(path)
+
+	clientConfig, err := getClientConfig(&config.KubeconfigContext{KubeconfigPath: path, Context: "eks"}, 0)
+	require.NoError(t, err)
+	require.NotNil(t, clientConfig.ExecProvider)
+	assert.Equal(t, "sh", clientConfig.ExecProvider.Command)
+
+	clientConfig, err = getClientConfig(&config.KubeconfigContext{KubeconfigPath: path, Context: "oidc"}, 0)
+	require.NoError(t, err)
+	require.NotNil(t, clientConfig.AuthProvider)
+	assert.Equal(t, "oidc", clientConfig.AuthProvider.Name)
+	assert.IsType(t, &inMemoryFallbackPersister{}, clientConfig.AuthConfigPersister)
+
+	missingPath := writeTestPluginsKubeconfig(t, "datadog-missing-credential-plugin")
+	defer os.Remove(missingPath)
+	_, err = getClientConfig(&config.KubeconfigContext{KubeconfigPath: missingPath, Context: "eks"}, 0)
+	assert.Error(t, err)
+}
+
+type failingPersister struct {
+	calls int
+}
+
+func (p *failingPersister) Persist(map[string]string) error {
+	p.calls++
+	return errors.New("read-only file system")
+}
+
+func TestInMemoryFallbackPersister(t *testing.T) {
+	base := &failingPersister{}
+	p := &inMemoryFallbackPersister{base}
+	assert.NoError(t, p.Persist(map[string]string{"id-token": "bar"}))
+	assert.Equal(t, 1, base.calls)
+}
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/tools/metrics"
+)
+
+// The requests of the API server clients are instrumented like the ones of
+// the kubernetes components, the metrics are served on the `/metrics`
+// endpoint of the cluster agent.
+var (
+	clientRequestLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "rest_client",
+			Name:      "request_latency_seconds",
+			Help:      "Latency of the requests to the API server, by verb and resource.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		},
+		[]string{"verb", "resource"},
+	)
+	clientRequestResults = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "rest_client",
+			Name:      "requests_total",
+			Help:      "Number of requests to the API server, by status code, method and host.",
+		},
+		[]string{"code", "method", "host"},
+	)
+	clientRateLimiterWait = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Subsystem: "rest_client",
+			Name:      "rate_limiter_wait_seconds",
+			Help:      "How long the requests to the API server wait for the client-side rate limiter.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(clientRequestLatency)
+	prometheus.MustRegister(clientRequestResults)
+	prometheus.MustRegister(clientRateLimiterWait)
+	metrics.Register(clientLatencyMetric{}, clientResultMetric{})
+}
+
+// clientLatencyMetric implements metrics.LatencyMetric
+type clientLatencyMetric struct{}
+
+func (clientLatencyMetric) Observe(verb string, u url.URL, latency time.Duration) {
+	clientRequestLatency.WithLabelValues(verb, requestResource(u.Path)).Observe(latency.Seconds())
+}
+
+// clientResultMetric implements metrics.ResultMetric
+type clientResultMetric struct{}
+
+func (clientResultMetric) Increment(code string, method string, host string) {
+	clientRequestResults.WithLabelValues(code, method, host).Inc()
+}
+
+// requestResource returns the resource of a request path, prefixed with its
+// API group outside of the core group, to keep the cardinality of the latency
+// metric low: the names and the namespaces of the objects are dropped.
+func requestResource(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var group string
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		group = parts[1]
+		parts = parts[3:]
+	default:
+		return "other"
+	}
+
+	if len(parts) >= 2 && parts[0] == "namespaces" {
+		if len(parts) == 2 {
+			// a namespace itself
+			parts = parts[:1]
+		} else {
+			parts = parts[2:]
+		}
+	}
+	if len(parts) == 0 {
+		return "discovery"
+	}
+
+	resource := parts[0]
+	// the subresources, like pods/log or deployments/scale
+	if len(parts) >= 3 {
+		resource += "/" + parts[2]
+	}
+	if group != "" {
+		return group + "/" + resource
+	}
+	return resource
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/metrics"
+)
+
+func TestRequestResource(t *testing.T) {
+	for path, resource := range map[string]string{
+		"/api/v1/nodes":                                          "nodes",
+		"/api/v1/nodes/node1":                                    "nodes",
+		"/api/v1/namespaces":                                     "namespaces",
+		"/api/v1/namespaces/default":                             "namespaces",
+		"/api/v1/namespaces/default/pods":                        "pods",
+		"/api/v1/namespaces/default/pods/redis/log":              "pods/log",
+		"/apis/apps/v1/namespaces/default/deployments/web":       "apps/deployments",
+		"/apis/apps/v1/namespaces/default/deployments/web/scale": "apps/deployments/scale",
+		"/apis/autoscaling/v2beta1/horizontalpodautoscalers":     "autoscaling/horizontalpodautoscalers",
+		"/api/v1":       "discovery",
+		"/apis/apps/v1": "discovery",
+		"/version":      "other",
+	} {
+		assert.Equal(t, resource, requestResource(path), path)
+	}
+}
+
+func TestClientMetrics(t *testing.T) {
+	u, _ := url.Parse("https://10.0.0.1/api/v1/namespaces/default/pods/redis")
+	metrics.RequestLatency.Observe("GET", *u, 20*time.Millisecond)
+	metrics.RequestResult.Increment("200", "GET", "10.0.0.1")
+
+	assert.Equal(t, uint64(1), readMetric(t, clientRequestLatency.WithLabelValues("GET", "pods")).GetHistogram().GetSampleCount())
+	assert.Equal(t, 1.0, readMetric(t, clientRequestResults.WithLabelValues("200", "GET", "10.0.0.1")).GetCounter().GetValue())
+
+	waits := readMetric(t, clientRateLimiterWait).GetHistogram().GetSampleCount()
+	limiter := newTelemetryRateLimiter(1, 1)
+	limiter.Accept()
+	assert.Equal(t, waits+1, readMetric(t, clientRateLimiterWait).GetHistogram().GetSampleCount())
+}
@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	protobufContentType = "application/vnd.kubernetes.protobuf"
+	jsonContentType     = "application/json"
+
+	// coreAPIGroup is the name given to the legacy core group, served under /api
+	coreAPIGroup = "core"
+)
+
+// contentTypeNegotiator uses protobuf for the API groups known to support it,
+// and JSON for the others. A group answering a protobuf request with a 406 or
+// a 415, like the aggregated APIs only speaking JSON, is switched to JSON for
+// the lifetime of the client.
+type contentTypeNegotiator struct {
+	rt             http.RoundTripper
+	protobufGroups map[string]bool
+	jsonOnlyGroups sync.Map
+}
+
+func newContentTypeNegotiator(protobufGroups []string) func(http.RoundTripper) http.RoundTripper {
+	groups := make(map[string]bool, len(protobufGroups))
+	for _, group := range protobufGroups {
+		groups[group] = true
+	}
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &contentTypeNegotiator{rt: rt, protobufGroups: groups}
+	}
+}
+
+// apiGroup returns the API group of a request path, or an empty string for
+// the paths outside of the API groups
+func apiGroup(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 3)
+	switch {
+	case parts[0] == "api":
+		return coreAPIGroup
+	case parts[0] == "apis" && len(parts) > 1:
+		return parts[1]
+	}
+	return ""
+}
+
+func (n *contentTypeNegotiator) useProtobuf(group string) bool {
+	if !n.protobufGroups[group] {
+		return false
+	}
+	_, jsonOnly := n.jsonOnlyGroups.Load(group)
+	return !jsonOnly
+}
+
+// RoundTrip implements http.RoundTripper
+func (n *contentTypeNegotiator) RoundTrip(req *http.Request) (*http.Response, error) {
+	group := apiGroup(req.URL.Path)
+	if !n.useProtobuf(group) {
+		return n.roundTripJSON(req)
+	}
+
+	// keep the body around to send the request again in JSON
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = withBody(req, body, req.Header.Get("Content-Type"))
+	}
+
+	resp, err := n.rt.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusNotAcceptable && resp.StatusCode != http.StatusUnsupportedMediaType) {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	if _, loaded := n.jsonOnlyGroups.LoadOrStore(group, struct{}{}); !loaded {
+		log.Infof("The API group %s doesn't support protobuf (%s), falling back to JSON", group, resp.Status)
+	}
+	if body != nil {
+		req = withBody(req, body, req.Header.Get("Content-Type"))
+	}
+	return n.roundTripJSON(req)
+}
+
+// roundTripJSON sends the request in JSON, converting its protobuf body
+func (n *contentTypeNegotiator) roundTripJSON(req *http.Request) (*http.Response, error) {
+	accept := req.Header.Get("Accept")
+	contentType := req.Header.Get("Content-Type")
+	if !strings.Contains(accept, protobufContentType) && !strings.HasPrefix(contentType, protobufContentType) {
+		return n.rt.RoundTrip(req)
+	}
+
+	// the round trippers must not modify the request
+	req = cloneRequest(req)
+	if strings.Contains(accept, protobufContentType) {
+		req.Header.Set("Accept", jsonContentType)
+	}
+	if strings.HasPrefix(contentType, protobufContentType) && req.Body != nil && req.Body != http.NoBody {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if body, err = protobufToJSON(body); err != nil {
+			return nil, err
+		}
+		req = withBody(req, body, jsonContentType)
+	}
+	return n.rt.RoundTrip(req)
+}
+
+// protobufToJSON converts a protobuf encoded object of the client-go scheme to JSON
+func protobufToJSON(body []byte) ([]byte, error) {
+	protobufInfo, ok := runtime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), protobufContentType)
+	if !ok {
+		return nil, fmt.Errorf("no serializer for %s", protobufContentType)
+	}
+	jsonInfo, ok := runtime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), jsonContentType)
+	if !ok {
+		return nil, fmt.Errorf("no serializer for %s", jsonContentType)
+	}
+
+	obj, gvk, err := protobufInfo.Serializer.Decode(body, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the protobuf request body: %v", err)
+	}
+	obj.GetObjectKind().SetGroupVersionKind(*gvk)
+	return runtime.Encode(jsonInfo.Serializer, obj)
+}
+
+func cloneRequest(req *http.Request) *http.Request {
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	return r
+}
+
+// withBody returns a copy of the request with a new body
+func withBody(req *http.Request, body []byte, contentType string) *http.Request {
+	r := cloneRequest(req)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Type", contentType)
+	return r
+}
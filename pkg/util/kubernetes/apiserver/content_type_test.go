@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestAPIGroup(t *testing.T) {
+	for path, group := range map[string]string{
+		"/api/v1/namespaces/default/pods":       "core",
+		"/apis/apps/v1/deployments":             "apps",
+		"/apis/metrics.k8s.io/v1beta1/pods":     "metrics.k8s.io",
+		"/apis":                                 "",
+		"/version":                              "",
+		"/apis/external.metrics.k8s.io/v1beta1": "external.metrics.k8s.io",
+	} {
+		assert.Equal(t, group, apiGroup(path), path)
+	}
+}
+
+func TestContentTypeNegotiator(t *testing.T) {
+	type received struct {
+		path, accept, contentType string
+		body                      []byte
+	}
+	var requests []received
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, received{r.URL.Path, r.Header.Get("Accept"), r.Header.Get("Content-Type"), body})
+		// the core group speaks JSON only
+		if strings.HasPrefix(r.URL.Path, "/api/") && strings.HasPrefix(r.Header.Get("Content-Type"), protobufContentType) {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newContentTypeNegotiator([]string{"core", "apps"})(http.DefaultTransport)}
+
+	cm := &v1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Data:       map[string]string{"foo": "bar"},
+	}
+	protobufInfo, ok := runtime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), protobufContentType)
+	require.True(t, ok)
+	body, err := runtime.Encode(protobufInfo.Serializer, cm)
+	require.NoError(t, err)
+
+	newRequest := func(method, path string, body []byte) *http.Request {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequest(method, server.URL+path, reader)
+		require.NoError(t, err)
+		req.Header.Set("Accept", protobufContentType+","+jsonContentType)
+		if body != nil {
+			req.Header.Set("Content-Type", protobufContentType)
+		}
+		return req
+	}
+
+	// protobuf for the apps group
+	resp, err := client.Do(newRequest("GET", "/apis/apps/v1/deployments", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// JSON for the other groups
+	resp, err = client.Do(newRequest("GET", "/apis/batch/v1/jobs", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// protobuf rejected by the core group, sent again in JSON
+	resp, err = client.Do(newRequest("POST", "/api/v1/namespaces/default/configmaps", body))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// JSON straight away afterwards
+	resp, err = client.Do(newRequest("GET", "/api/v1/namespaces/default/configmaps", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.Len(t, requests, 5)
+	assert.Equal(t, protobufContentType+","+jsonContentType, requests[0].accept)
+	assert.Equal(t, jsonContentType, requests[1].accept)
+	assert.Equal(t, protobufContentType, requests[2].contentType)
+	assert.Equal(t, jsonContentType, requests[3].contentType)
+	assert.Equal(t, jsonContentType, requests[3].accept)
+	assert.Equal(t, jsonContentType, requests[4].accept)
+
+	var decoded v1.ConfigMap
+	require.NoError(t, json.Unmarshal(requests[3].body, &decoded))
+	assert.Equal(t, "ConfigMap", decoded.Kind)
+	assert.Equal(t, "foo", decoded.Name)
+	assert.Equal(t, map[string]string{"foo": "bar"}, decoded.Data)
+}
@@ -8,14 +8,41 @@
 package apiserver
 
 import (
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/hpa"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/informers"
+	autoscalersinformer "k8s.io/client-go/informers/autoscaling/v2beta1"
 	"k8s.io/client-go/kubernetes"
 )
 
+// controllerGoroutines tracks the goroutines of the controllers and their
+// informers, reported by the health status
+var controllerGoroutines = health.NewWaitGroup("cluster-agent-controllers")
+
+// goController runs f in a goroutine accounted to the controllers, unless
+// they reached their goroutine limit
+func goController(name string, f func()) {
+	if err := controllerGoroutines.Go(f); err != nil {
+		log.Errorf("Could not start the %s: %s", name, err)
+	}
+}
+
+// startInformerFactory starts the informers requested from a factory. The
+// factory doesn't expose the goroutines of its informers, so it's accounted
+// to the controllers as a single unit until stopCh is closed.
+func startInformerFactory(factory informers.SharedInformerFactory, stopCh chan struct{}) {
+	factory.Start(stopCh)
+	if err := controllerGoroutines.Track(1, stopCh); err != nil {
+		log.Errorf("The informer factory started went over the controllers limit: %s", err)
+	}
+}
+
 type controllerFuncs struct {
 	enabled func() bool
 	start   func(ControllerContext) error
@@ -26,26 +53,67 @@ var controllerCatalog = map[string]controllerFuncs{
 		func() bool { return config.Datadog.GetBool("kubernetes_collect_metadata_tags") },
 		startMetadataController,
 	},
+	"podmetadata": {
+		func() bool {
+			// the nodes are tracked by the metadata controller
+			return config.Datadog.GetBool("kubernetes_collect_metadata_tags") &&
+				config.Datadog.GetBool("kubernetes_collect_pod_metadata_tags")
+		},
+		startPodMetadataController,
+	},
 	"autoscalers": {
 		func() bool { return config.Datadog.GetBool("external_metrics_provider.enabled") },
 		startAutoscalersController,
 	},
+	"podipindex": {
+		func() bool { return config.Datadog.GetBool("kubernetes_pod_ip_index") },
+		startPodIPIndex,
+	},
 	"services": {
-		func() bool { return config.Datadog.GetBool("cluster_checks.enabled") },
+		func() bool {
+			return config.Datadog.GetBool("cluster_checks.enabled") || config.Datadog.GetBool("kubernetes_service_ip_map")
+		},
 		startServicesInformer,
 	},
+	"admission": {
+		func() bool { return config.Datadog.GetBool("admission_controller.enabled") },
+		startAdmissionController,
+	},
 }
 
 type ControllerContext struct {
 	InformerFactory informers.SharedInformerFactory
-	Client          kubernetes.Interface
-	LeaderElector   LeaderElectorInterface
-	StopCh          chan struct{}
+	// NamespacedInformerFactories watch the namespaced resources, by
+	// namespace, see APIClient.InformerFactoriesByNamespace
+	NamespacedInformerFactories map[string]informers.SharedInformerFactory
+	Client                      kubernetes.Interface
+	DiscoveryClient             discovery.DiscoveryInterface
+	LeaderElector               LeaderElectorInterface
+	StopCh                      chan struct{}
+}
+
+// discovery returns the cached discovery client, or the one of the client
+func (ctx ControllerContext) discovery() discovery.DiscoveryInterface {
+	if ctx.DiscoveryClient != nil {
+		return ctx.DiscoveryClient
+	}
+	return ctx.Client.Discovery()
+}
+
+// InformerName returns the name of an informer of a namespace in the
+// informers telemetry
+func InformerName(resource, namespace string) string {
+	if namespace == metav1.NamespaceAll {
+		return resource
+	}
+	return resource + "/" + namespace
 }
 
 // StartControllers runs the enabled Kubernetes controllers for the Datadog Cluster Agent. This is
 // only called once, when we have confirmed we could correctly connect to the API server.
 func StartControllers(ctx ControllerContext) error {
+	controllerGoroutines.SetLimit(config.Datadog.GetInt64("cluster_agent.controllers_max_goroutines"))
+
 	for name, cntrlFuncs := range controllerCatalog {
 		if !cntrlFuncs.enabled() {
 			log.Infof("%q is disabled", name)
@@ -60,17 +128,83 @@ func StartControllers(ctx ControllerContext) error {
 	// we must start the informer factory after starting the controllers because the informer
 	// factory uses lazy initialization (delays the creation of an informer until the first
 	// time it's needed).
-	ctx.InformerFactory.Start(ctx.StopCh)
+	startInformerFactory(ctx.InformerFactory, ctx.StopCh)
+	for _, factory := range ctx.NamespacedInformerFactories {
+		if factory != ctx.InformerFactory {
+			startInformerFactory(factory, ctx.StopCh)
+		}
+	}
+	startStuckWatchDetector(ctx.StopCh)
 
 	return nil
 }
 
 func startMetadataController(ctx ControllerContext) error {
-	metaController := NewMetadataController(
-		ctx.InformerFactory.Core().V1().Nodes(),
-		ctx.InformerFactory.Core().V1().Endpoints(),
-	)
-	go metaController.Run(ctx.StopCh)
+	nodeInformer := ctx.InformerFactory.Core().V1().Nodes()
+	RegisterInformerTelemetry("nodes", nodeInformer.Informer())
+
+	var controllers []*MetadataController
+	defer func() {
+		if len(controllers) > 0 && config.Datadog.GetBool("cluster_agent.metadata_snapshot.enabled") {
+			startMetadataSnapshots(globalMetaBundleStore, controllers, ctx.StopCh)
+		}
+	}()
+
+	if config.Datadog.GetBool("kubernetes_use_endpoint_slices") {
+		if gvr, found := discoverEndpointSlices(ctx.discovery()); found {
+			apiCl, err := GetAPIClient()
+			if err != nil {
+				return err
+			}
+			log.Infof("Mapping the services of the pods from the %s EndpointSlices", gvr.GroupVersion())
+			for ns := range ctx.NamespacedInformerFactories {
+				endpointSlicesInformer := newEndpointSlicesInformer(apiCl.NewUnstructuredListWatch(gvr, ns))
+				metaController := NewMetadataControllerWithEndpointSlices(nodeInformer, endpointSlicesInformer)
+				RegisterInformerTelemetry(InformerName("endpointslices", ns), endpointSlicesInformer)
+				controllers = append(controllers, metaController)
+				goController("endpointslices informer", func() { endpointSlicesInformer.Run(ctx.StopCh) })
+				goController("metadata controller", func() { metaController.Run(ctx.StopCh) })
+			}
+			return nil
+		}
+		log.Infof("EndpointSlices not served by the API server, mapping the services of the pods from the Endpoints")
+	}
+
+	for ns, factory := range ctx.NamespacedInformerFactories {
+		endpointsInformer := factory.Core().V1().Endpoints()
+		metaController := NewMetadataController(nodeInformer, endpointsInformer)
+		RegisterInformerTelemetry(InformerName("endpoints", ns), endpointsInformer.Informer())
+		controllers = append(controllers, metaController)
+		goController("metadata controller", func() { metaController.Run(ctx.StopCh) })
+	}
+
+	return nil
+}
+
+func startPodMetadataController(ctx ControllerContext) error {
+	for ns, factory := range ctx.NamespacedInformerFactories {
+		podInformer := factory.Core().V1().Pods()
+		replicaSetInformer := factory.Apps().V1().ReplicaSets()
+		jobInformer := factory.Batch().V1().Jobs()
+		podMetaController := NewPodMetadataController(podInformer, replicaSetInformer, jobInformer)
+		RegisterInformerTelemetry(InformerName("pods", ns), podInformer.Informer())
+		RegisterInformerTelemetry(InformerName("replicasets", ns), replicaSetInformer.Informer())
+		RegisterInformerTelemetry(InformerName("jobs", ns), jobInformer.Informer())
+		goController("pod metadata controller", func() { podMetaController.Run(ctx.StopCh) })
+	}
+
+	return nil
+}
+
+func startPodIPIndex(ctx ControllerContext) error {
+	for ns, factory := range ctx.NamespacedInformerFactories {
+		// the pod informers are shared with the pod metadata controller
+		podInformer := factory.Core().V1().Pods().Informer()
+		if err := globalPodIPIndex.addInformer(podInformer); err != nil {
+			return err
+		}
+		RegisterInformerTelemetry(InformerName("pods", ns), podInformer)
+	}
 
 	return nil
 }
@@ -80,24 +214,49 @@ func startAutoscalersController(ctx ControllerContext) error {
 	if err != nil {
 		return err
 	}
+	hpaInformers := make(map[string]autoscalersinformer.HorizontalPodAutoscalerInformer, len(ctx.NamespacedInformerFactories))
+	for ns, factory := range ctx.NamespacedInformerFactories {
+		hpaInformer := factory.Autoscaling().V2beta1().HorizontalPodAutoscalers()
+		RegisterInformerTelemetry(InformerName("horizontalpodautoscalers", ns), hpaInformer.Informer())
+		hpaInformers[ns] = hpaInformer
+	}
 	autoscalersController, err := NewAutoscalersController(
 		ctx.Client,
 		ctx.LeaderElector,
 		dogCl,
-		ctx.InformerFactory.Autoscaling().V2beta1().HorizontalPodAutoscalers(),
+		hpaInformers,
 	)
 	if err != nil {
 		return err
 	}
-	go autoscalersController.Run(ctx.StopCh)
+	goController("autoscalers controller", func() { autoscalersController.Run(ctx.StopCh) })
 
 	return nil
 }
 
 func startServicesInformer(ctx ControllerContext) error {
-	// Just start the shared informer, the autodiscovery
-	// components will access it when needed.
-	go ctx.InformerFactory.Core().V1().Services().Informer().Run(ctx.StopCh)
+	// Just start the shared informers, the autodiscovery
+	// components will access them when needed.
+	for ns, factory := range ctx.NamespacedInformerFactories {
+		informer := factory.Core().V1().Services().Informer()
+		RegisterInformerTelemetry(InformerName("services", ns), informer)
+		if config.Datadog.GetBool("kubernetes_service_ip_map") {
+			globalServiceIPMap.addInformer(informer)
+		}
+		goController("services informer", func() { informer.Run(ctx.StopCh) })
+	}
+
+	return nil
+}
+
+func startAdmissionController(ctx ControllerContext) error {
+	namespaceInformer := ctx.InformerFactory.Core().V1().Namespaces()
+	server, err := admission.NewServer(namespaceInformer.Lister(), namespaceInformer.Informer().HasSynced)
+	if err != nil {
+		return err
+	}
+	RegisterInformerTelemetry("namespaces", namespaceInformer.Informer())
+	goController("admission controller", func() { server.Run(ctx.StopCh) })
 
 	return nil
 }
@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// discoveryErrorTTL bounds how long a discovery error is cached, so that an
+// aggregated API coming back is noticed quickly
+const discoveryErrorTTL = 30 * time.Second
+
+var (
+	// the cached discovery clients of the API clients, invalidated together
+	// by RefreshDiscoveryCache
+	discoveryCaches   []*cachedDiscovery
+	discoveryCachesMu sync.Mutex
+)
+
+// cachedDiscovery serves the API groups and the resources of the group
+// versions from memory, then from disk, for the TTL. When the API server
+// fails to answer, e.g. while an aggregated API like metrics.k8s.io is
+// flapping, the expired entries keep being served. The other discovery
+// requests go to the API server.
+type cachedDiscovery struct {
+	discovery.DiscoveryInterface
+
+	ttl time.Duration
+	// dir is the directory of the disk cache, empty to only cache in memory
+	dir string
+
+	m       sync.Mutex
+	entries map[string]*discoveryEntry
+}
+
+type discoveryEntry struct {
+	value   interface{}
+	err     error
+	fetched time.Time
+}
+
+func (e *discoveryEntry) fresh(now time.Time, ttl time.Duration) bool {
+	if e.err != nil && ttl > discoveryErrorTTL {
+		ttl = discoveryErrorTTL
+	}
+	return now.Sub(e.fetched) < ttl
+}
+
+// newCachedDiscovery returns a discovery client caching the answers of
+// delegate, registered to be invalidated by RefreshDiscoveryCache
+func newCachedDiscovery(delegate discovery.DiscoveryInterface, dir string, ttl time.Duration) *cachedDiscovery {
+	d := &cachedDiscovery{
+		DiscoveryInterface: delegate,
+		ttl:                ttl,
+		dir:                dir,
+		entries:            make(map[string]*discoveryEntry),
+	}
+	discoveryCachesMu.Lock()
+	defer discoveryCachesMu.Unlock()
+	discoveryCaches = append(discoveryCaches, d)
+	return d
+}
+
+// discoveryCacheDir returns the directory of the disk cache of a cluster,
+// given by its kubeconfig context
+func discoveryCacheDir(kubeContext *config.KubeconfigContext) string {
+	root := config.Datadog.GetString("cluster_agent.discovery_cache.path")
+	if root == "" {
+		return ""
+	}
+	if kubeContext == nil {
+		return filepath.Join(root, "default")
+	}
+	return filepath.Join(root, "context-"+kubeContext.Name)
+}
+
+// ServerGroups returns the API groups served by the API server
+func (d *cachedDiscovery) ServerGroups() (*metav1.APIGroupList, error) {
+	value, err := d.get("groups", func() interface{} { return &metav1.APIGroupList{} }, func() (interface{}, error) {
+		return d.DiscoveryInterface.ServerGroups()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*metav1.APIGroupList), nil
+}
+
+// ServerResourcesForGroupVersion returns the resources of a group version
+func (d *cachedDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	key := "resources_" + strings.Replace(groupVersion, "/", "_", -1)
+	value, err := d.get(key, func() interface{} { return &metav1.APIResourceList{} }, func() (interface{}, error) {
+		return d.DiscoveryInterface.ServerResourcesForGroupVersion(groupVersion)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*metav1.APIResourceList), nil
+}
+
+// Invalidate drops the memory and the disk caches
+func (d *cachedDiscovery) Invalidate() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.entries = make(map[string]*discoveryEntry)
+	if d.dir != "" {
+		if err := os.RemoveAll(d.dir); err != nil {
+			log.Warnf("Could not remove the discovery cache %s: %v", d.dir, err)
+		}
+	}
+}
+
+func (d *cachedDiscovery) get(key string, newValue func() interface{}, fetch func() (interface{}, error)) (interface{}, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	now := time.Now()
+	entry, found := d.entries[key]
+	if !found {
+		entry = d.readDisk(key, newValue())
+	}
+	if entry != nil && entry.fresh(now, d.ttl) {
+		d.entries[key] = entry
+		return entry.value, entry.err
+	}
+
+	value, err := fetch()
+	if err != nil {
+		if entry != nil && entry.err == nil {
+			log.Debugf("Serving the expired discovery of %s: %v", key, err)
+			return entry.value, nil
+		}
+		d.entries[key] = &discoveryEntry{err: err, fetched: now}
+		return nil, err
+	}
+	d.entries[key] = &discoveryEntry{value: value, fetched: now}
+	d.writeDisk(key, value)
+	return value, nil
+}
+
+// readDisk returns the entry cached on disk, nil if there's none
+func (d *cachedDiscovery) readDisk(key string, value interface{}) *discoveryEntry {
+	if d.dir == "" {
+		return nil
+	}
+	path := filepath.Join(d.dir, key+".json")
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		log.Debugf("Ignoring the invalid discovery cache %s: %v", path, err)
+		return nil
+	}
+	return &discoveryEntry{value: value, fetched: info.ModTime()}
+}
+
+func (d *cachedDiscovery) writeDisk(key string, value interface{}) {
+	if d.dir == "" {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		log.Debugf("Could not create the discovery cache %s: %v", d.dir, err)
+		return
+	}
+	// write then rename so that a concurrent reader never sees a partial file
+	path := filepath.Join(d.dir, key+".json")
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		log.Debugf("Could not write the discovery cache %s: %v", path, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Debugf("Could not write the discovery cache %s: %v", path, err)
+	}
+}
+
+// RefreshDiscoveryCache drops the discovery caches of the API clients, the
+// next discovery requests are sent to the API servers
+func RefreshDiscoveryCache() int {
+	discoveryCachesMu.Lock()
+	defer discoveryCachesMu.Unlock()
+	for _, d := range discoveryCaches {
+		d.Invalidate()
+	}
+	return len(discoveryCaches)
+}
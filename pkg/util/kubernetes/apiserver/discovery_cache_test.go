@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+)
+
+type countingDiscovery struct {
+	discovery.DiscoveryInterface
+	calls int
+	err   error
+}
+
+func (d *countingDiscovery) ServerGroups() (*metav1.APIGroupList, error) {
+	d.calls++
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &metav1.APIGroupList{Groups: []metav1.APIGroup{{Name: "discovery.k8s.io"}}}, nil
+}
+
+func (d *countingDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	d.calls++
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &metav1.APIResourceList{
+		GroupVersion: groupVersion,
+		APIResources: []metav1.APIResource{{Name: "endpointslices", Namespaced: true, Kind: "EndpointSlice"}},
+	}, nil
+}
+
+func TestCachedDiscovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cacheDir := filepath.Join(dir, "default")
+
+	delegate := &countingDiscovery{}
+	d := newCachedDiscovery(delegate, cacheDir, time.Minute)
+
+	resources, err := d.ServerResourcesForGroupVersion("discovery.k8s.io/v1beta1")
+	require.NoError(t, err)
+	assert.Equal(t, "endpointslices", resources.APIResources[0].Name)
+	_, err = d.ServerResourcesForGroupVersion("discovery.k8s.io/v1beta1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, delegate.calls)
+	assert.FileExists(t, filepath.Join(cacheDir, "resources_discovery.k8s.io_v1beta1.json"))
+
+	// restored from disk by a new client, e.g. after a restart
+	restarted := &countingDiscovery{}
+	resources, err = newCachedDiscovery(restarted, cacheDir, time.Minute).ServerResourcesForGroupVersion("discovery.k8s.io/v1beta1")
+	require.NoError(t, err)
+	assert.Equal(t, "endpointslices", resources.APIResources[0].Name)
+	assert.Equal(t, 0, restarted.calls)
+
+	// the expired entries are served while the API server fails
+	d.entries["resources_discovery.k8s.io_v1beta1"].fetched = time.Now().Add(-time.Hour)
+	delegate.err = errors.New("service unavailable")
+	resources, err = d.ServerResourcesForGroupVersion("discovery.k8s.io/v1beta1")
+	require.NoError(t, err)
+	assert.NotNil(t, resources)
+	assert.Equal(t, 2, delegate.calls)
+
+	// the errors are cached too
+	_, err = d.ServerResourcesForGroupVersion("metrics.k8s.io/v1beta1")
+	assert.Error(t, err)
+	_, err = d.ServerResourcesForGroupVersion("metrics.k8s.io/v1beta1")
+	assert.Error(t, err)
+	assert.Equal(t, 3, delegate.calls)
+
+	// refreshed from the API server once invalidated
+	assert.True(t, RefreshDiscoveryCache() >= 2)
+	_, err = os.Stat(cacheDir)
+	assert.True(t, os.IsNotExist(err))
+	delegate.err = nil
+	_, err = d.ServerResourcesForGroupVersion("discovery.k8s.io/v1beta1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, delegate.calls)
+}
+
+func TestCachedDiscoveryServerGroups(t *testing.T) {
+	delegate := &countingDiscovery{}
+	d := newCachedDiscovery(delegate, "", time.Minute)
+
+	// the connect probe of the API clients goes through the cache
+	for i := 0; i < 3; i++ {
+		groups, err := d.ServerGroups()
+		require.NoError(t, err)
+		assert.Equal(t, "discovery.k8s.io", groups.Groups[0].Name)
+	}
+	assert.Equal(t, 1, delegate.calls)
+
+	d.Invalidate()
+	_, err := d.ServerGroups()
+	require.NoError(t, err)
+	assert.Equal(t, 2, delegate.calls)
+}
+
+func TestDiscoveryEntryFresh(t *testing.T) {
+	now := time.Now()
+	entry := &discoveryEntry{fetched: now.Add(-time.Minute)}
+	assert.True(t, entry.fresh(now, 10*time.Minute))
+	assert.False(t, entry.fresh(now, 30*time.Second))
+
+	entry.err = errors.New("unavailable")
+	assert.False(t, entry.fresh(now, 10*time.Minute))
+}
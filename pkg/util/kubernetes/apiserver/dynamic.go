@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+// resourceClient returns the client of the resource in the namespace, of all
+// the namespaces if empty or for cluster-scoped resources
+func resourceClient(cl dynamic.Interface, gvr schema.GroupVersionResource, namespace string) (dynamic.ResourceInterface, error) {
+	if cl == nil {
+		return nil, fmt.Errorf("dynamic client not initialized")
+	}
+	if namespace == "" {
+		return cl.Resource(gvr), nil
+	}
+	return cl.Resource(gvr).Namespace(namespace), nil
+}
+
+// ListUnstructured lists the objects of any resource, like a custom
+// resource, by its group, version and resource name. The namespace is
+// empty for all the namespaces or for cluster-scoped resources.
+func (c *APIClient) ListUnstructured(gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	rc, err := resourceClient(c.DynamicCl, gvr, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TimeoutSeconds == nil {
+		opts.TimeoutSeconds = &c.timeoutSeconds
+	}
+	return rc.List(opts)
+}
+
+// GetUnstructured gets an object of any resource by its group, version and
+// resource name. The namespace is empty for cluster-scoped resources.
+func (c *APIClient) GetUnstructured(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	rc, err := resourceClient(c.DynamicCl, gvr, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return rc.Get(name, metav1.GetOptions{})
+}
+
+// WatchUnstructured watches the objects of any resource by its group,
+// version and resource name. The watch isn't bound by the client timeout,
+// it lasts until it's stopped or until opts.TimeoutSeconds.
+func (c *APIClient) WatchUnstructured(gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	rc, err := resourceClient(c.dynamicWatchCl, gvr, namespace)
+	if err != nil {
+		return nil, err
+	}
+	opts.Watch = true
+	return rc.Watch(opts)
+}
+
+// NewUnstructuredListWatch returns a ListWatch of any resource, to build an
+// informer of *unstructured.Unstructured objects with cache.NewSharedIndexInformer
+func (c *APIClient) NewUnstructuredListWatch(gvr schema.GroupVersionResource, namespace string) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return c.ListUnstructured(gvr, namespace, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return c.WatchUnstructured(gvr, namespace, opts)
+		},
+	}
+}
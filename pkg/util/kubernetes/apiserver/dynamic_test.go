@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+var crontabs = schema.GroupVersionResource{Group: "stable.example.com", Version: "v1", Resource: "crontabs"}
+
+func newTestDynamicAPIClient(t *testing.T, handler http.HandlerFunc) (*APIClient, func()) {
+	server := httptest.NewServer(handler)
+	client, err := dynamic.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	return &APIClient{DynamicCl: client, dynamicWatchCl: client, timeoutSeconds: 5}, server.Close
+}
+
+func TestListUnstructured(t *testing.T) {
+	cl, cleanup := newTestDynamicAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apis/stable.example.com/v1/namespaces/default/crontabs", r.URL.Path)
+		assert.Equal(t, "5", r.URL.Query().Get("timeoutSeconds"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "CronTabList", "apiVersion": "stable.example.com/v1", "metadata": {}, "items": [
+			{"kind": "CronTab", "apiVersion": "stable.example.com/v1", "metadata": {"name": "tab1", "namespace": "default"}, "spec": {"cronSpec": "* * * * */5"}}
+		]}`))
+	})
+	defer cleanup()
+
+	list, err := cl.ListUnstructured(crontabs, "default", metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "tab1", list.Items[0].GetName())
+	assert.Equal(t, "* * * * */5", list.Items[0].Object["spec"].(map[string]interface{})["cronSpec"])
+}
+
+func TestGetUnstructuredAllNamespaces(t *testing.T) {
+	cl, cleanup := newTestDynamicAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apis/stable.example.com/v1/crontabs/tab1", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "CronTab", "apiVersion": "stable.example.com/v1", "metadata": {"name": "tab1"}}`))
+	})
+	defer cleanup()
+
+	obj, err := cl.GetUnstructured(crontabs, "", "tab1")
+	require.NoError(t, err)
+	assert.Equal(t, "CronTab", obj.GetKind())
+}
+
+func TestWatchUnstructured(t *testing.T) {
+	cl, cleanup := newTestDynamicAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("watch"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type": "ADDED", "object": {"kind": "CronTab", "apiVersion": "stable.example.com/v1", "metadata": {"name": "tab1"}}}`))
+	})
+	defer cleanup()
+
+	watcher, err := cl.WatchUnstructured(crontabs, "default", metav1.ListOptions{})
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	select {
+	case event := <-watcher.ResultChan():
+		assert.Equal(t, watch.Added, event.Type)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no watch event received")
+	}
+}
+
+func TestDynamicClientNotInitialized(t *testing.T) {
+	cl := &APIClient{}
+	_, err := cl.ListUnstructured(crontabs, "", metav1.ListOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	endpointSlicesResource = "endpointslices"
+	// serviceNameLabel is the label of the EndpointSlices holding the name of their service
+	serviceNameLabel = "kubernetes.io/service-name"
+	// serviceIndex indexes the EndpointSlices by `<namespace>/<service>`
+	serviceIndex = "service"
+)
+
+// endpointSlicesGroupVersions are the versions of the EndpointSlices API the
+// mapper supports, by order of preference
+var endpointSlicesGroupVersions = []schema.GroupVersion{
+	{Group: "discovery.k8s.io", Version: "v1"},
+	{Group: "discovery.k8s.io", Version: "v1beta1"},
+}
+
+// endpointSlice holds the fields of the discovery.k8s.io EndpointSlices the
+// mapper uses. The Kubernetes API release the agent is built with predates
+// the EndpointSlices, they are read from unstructured objects.
+type endpointSlice struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Endpoints         []sliceEndpoint `json:"endpoints"`
+}
+
+type sliceEndpoint struct {
+	Conditions struct {
+		Ready *bool `json:"ready,omitempty"`
+	} `json:"conditions"`
+	// NodeName is set by discovery.k8s.io/v1, and by v1beta1 since Kubernetes 1.20
+	NodeName *string `json:"nodeName,omitempty"`
+	// Topology is the v1beta1 way of setting the node of the endpoint
+	Topology  map[string]string       `json:"topology,omitempty"`
+	TargetRef *corev1.ObjectReference `json:"targetRef,omitempty"`
+}
+
+// nodeName returns the node of the endpoint, empty if unknown
+func (e sliceEndpoint) nodeName() string {
+	if e.NodeName != nil {
+		return *e.NodeName
+	}
+	return e.Topology["kubernetes.io/hostname"]
+}
+
+// ready returns false for the endpoints explicitly not ready, nil means ready
+func (e sliceEndpoint) ready() bool {
+	return e.Conditions.Ready == nil || *e.Conditions.Ready
+}
+
+// discoverEndpointSlices returns the EndpointSlices resource of the preferred
+// version served by the API server, false if none is served
+func discoverEndpointSlices(cl discovery.DiscoveryInterface) (schema.GroupVersionResource, bool) {
+	for _, gv := range endpointSlicesGroupVersions {
+		resources, err := cl.ServerResourcesForGroupVersion(gv.String())
+		if err != nil || resources == nil {
+			log.Debugf("EndpointSlices %s not served: %v", gv, err)
+			continue
+		}
+		for _, r := range resources.APIResources {
+			if r.Name == endpointSlicesResource {
+				return gv.WithResource(endpointSlicesResource), true
+			}
+		}
+	}
+	return schema.GroupVersionResource{}, false
+}
+
+// newEndpointSlicesInformer returns an informer of the EndpointSlices as
+// *unstructured.Unstructured objects, indexed by service
+func newEndpointSlicesInformer(lw cache.ListerWatcher) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		lw,
+		&unstructured.Unstructured{},
+		ResyncPeriod("endpointslices"),
+		cache.Indexers{serviceIndex: endpointSliceServiceIndexFunc},
+	)
+}
+
+func endpointSliceServiceIndexFunc(obj interface{}) ([]string, error) {
+	key, ok := endpointSliceServiceKey(obj)
+	if !ok {
+		return nil, nil
+	}
+	return []string{key}, nil
+}
+
+// endpointSliceServiceKey returns the `<namespace>/<service>` key of the
+// service of the EndpointSlice, false if it doesn't belong to a service
+func endpointSliceServiceKey(obj interface{}) (string, bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	slice, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return "", false
+	}
+	svc := slice.GetLabels()[serviceNameLabel]
+	if svc == "" {
+		return "", false
+	}
+	return slice.GetNamespace() + "/" + svc, true
+}
+
+// endpointSliceFromUnstructured converts an object of the informer
+func endpointSliceFromUnstructured(obj interface{}) (*endpointSlice, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected EndpointSlice type %T", obj)
+	}
+	slice := &endpointSlice{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), slice); err != nil {
+		return nil, err
+	}
+	return slice, nil
+}
@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+)
+
+// newFakeEndpointSlice returns an EndpointSlice of the service, the endpoints
+// being `[node, pod, ready]` tuples
+func newFakeEndpointSlice(name, svc string, endpoints ...[3]string) *unstructured.Unstructured {
+	var items []interface{}
+	for _, e := range endpoints {
+		items = append(items, map[string]interface{}{
+			"addresses":  []interface{}{"10.0.0.1"},
+			"conditions": map[string]interface{}{"ready": e[2] == "true"},
+			"nodeName":   e[0],
+			"targetRef": map[string]interface{}{
+				"kind":      "Pod",
+				"namespace": "default",
+				"name":      e[1],
+			},
+		})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "discovery.k8s.io/v1",
+		"kind":       "EndpointSlice",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+			"labels":    map[string]interface{}{serviceNameLabel: svc},
+		},
+		"endpoints": items,
+	}}
+}
+
+func TestMetadataControllerSyncEndpointSlices(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{serviceIndex: endpointSliceServiceIndexFunc})
+	metaController := &MetadataController{
+		endpointSlicesIndexer: indexer,
+		nodeName:              "node1",
+		store: &metaBundleStore{
+			cache: gocache.New(gocache.NoExpiration, 5*time.Second),
+		},
+	}
+
+	slice1 := newFakeEndpointSlice("svc1-abc", "svc1", [3]string{"node1", "pod1", "true"}, [3]string{"node2", "pod2", "true"})
+	slice2 := newFakeEndpointSlice("svc1-def", "svc1", [3]string{"node1", "pod3", "true"}, [3]string{"node1", "pod4", "false"})
+	require.NoError(t, indexer.Add(slice1))
+	require.NoError(t, indexer.Add(slice2))
+
+	// the slices of the service are mapped together
+	require.NoError(t, metaController.syncEndpointSlices("default/svc1"))
+	bundle, found := metaController.store.get("node1")
+	require.True(t, found)
+	assert.Equal(t, apiv1.NamespacesPodsStringsSet{
+		"default": {
+			"pod1": sets.NewString("svc1"),
+			"pod3": sets.NewString("svc1"),
+		},
+	}, bundle.Services)
+
+	// a deleted slice unmaps its pods
+	require.NoError(t, indexer.Delete(slice2))
+	require.NoError(t, metaController.syncEndpointSlices("default/svc1"))
+	bundle, _ = metaController.store.get("node1")
+	assert.Equal(t, apiv1.NamespacesPodsStringsSet{
+		"default": {"pod1": sets.NewString("svc1")},
+	}, bundle.Services)
+
+	// no slice left, the service is unmapped
+	require.NoError(t, indexer.Delete(slice1))
+	require.NoError(t, metaController.syncEndpointSlices("default/svc1"))
+	bundle, _ = metaController.store.get("node1")
+	assert.Empty(t, bundle.Services["default"])
+}
+
+func TestEndpointSliceServiceKey(t *testing.T) {
+	key, ok := endpointSliceServiceKey(newFakeEndpointSlice("svc1-abc", "svc1"))
+	assert.True(t, ok)
+	assert.Equal(t, "default/svc1", key)
+
+	key, ok = endpointSliceServiceKey(cache.DeletedFinalStateUnknown{Obj: newFakeEndpointSlice("svc1-abc", "svc1")})
+	assert.True(t, ok)
+	assert.Equal(t, "default/svc1", key)
+
+	_, ok = endpointSliceServiceKey(newFakeEndpointSlice("orphan", ""))
+	assert.False(t, ok)
+}
+
+func TestDiscoverEndpointSlices(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	_, found := discoverEndpointSlices(client.Discovery())
+	assert.False(t, found)
+
+	client.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "discovery.k8s.io/v1beta1",
+			APIResources: []metav1.APIResource{{Name: "endpointslices"}},
+		},
+	}
+	gvr, found := discoverEndpointSlices(client.Discovery())
+	assert.True(t, found)
+	assert.Equal(t, "v1beta1", gvr.Version)
+	assert.Equal(t, "endpointslices", gvr.Resource)
+}
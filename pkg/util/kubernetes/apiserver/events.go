@@ -10,6 +10,7 @@ package apiserver
 //// Covered by test/integration/util/kube_apiserver/events_test.go
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"time"
@@ -28,6 +29,25 @@ import (
 // overloading it.
 // https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.9/#watch-list-289
 func (c *APIClient) LatestEvents(since string, eventReadTimeout time.Duration) ([]*v1.Event, []*v1.Event, string, error) {
+	return c.LatestFilteredEventsWithContext(context.Background(), since, "", eventReadTimeout)
+}
+
+// LatestEventsWithContext is LatestEvents, the watch is stopped when ctx is
+// canceled, returning the events received so far and the error of ctx.
+func (c *APIClient) LatestEventsWithContext(ctx context.Context, since string, eventReadTimeout time.Duration) ([]*v1.Event, []*v1.Event, string, error) {
+	return c.LatestFilteredEventsWithContext(ctx, since, "", eventReadTimeout)
+}
+
+// LatestFilteredEvents is LatestEvents, only watching the events matching
+// the field selector, see EventFilter.
+func (c *APIClient) LatestFilteredEvents(since, fieldSelector string, eventReadTimeout time.Duration) ([]*v1.Event, []*v1.Event, string, error) {
+	return c.LatestFilteredEventsWithContext(context.Background(), since, fieldSelector, eventReadTimeout)
+}
+
+// LatestFilteredEventsWithContext is LatestFilteredEvents, the watch is
+// stopped when ctx is canceled, returning the events received so far and the
+// error of ctx.
+func (c *APIClient) LatestFilteredEventsWithContext(ctx context.Context, since, fieldSelector string, eventReadTimeout time.Duration) ([]*v1.Event, []*v1.Event, string, error) {
 	var added, modified []*v1.Event
 
 	// If `since` is "" strconv.Atoi(*latestResVersion) below will panic as we evaluate the error.
@@ -39,9 +59,9 @@ func (c *APIClient) LatestEvents(since string, eventReadTimeout time.Duration) (
 		since = "0"
 	}
 
-	log.Tracef("Starting watch of events with resourceVersion %s", since)
+	log.Tracef("Starting watch of events with resourceVersion %s and field selector %q", since, fieldSelector)
 
-	eventWatcher, err := c.Cl.CoreV1().Events(metav1.NamespaceAll).Watch(metav1.ListOptions{Watch: true, ResourceVersion: since})
+	eventWatcher, err := c.Cl.CoreV1().Events(metav1.NamespaceAll).Watch(metav1.ListOptions{Watch: true, ResourceVersion: since, FieldSelector: fieldSelector})
 	if err != nil {
 		return nil, nil, "0", fmt.Errorf("Failed to watch events: %v", err)
 	}
@@ -99,6 +119,9 @@ func (c *APIClient) LatestEvents(since string, eventReadTimeout time.Duration) (
 			}
 			watcherTimeout.Reset(eventReadTimeout)
 
+		case <-ctx.Done():
+			return added, modified, strconv.Itoa(resVersionInt), ctx.Err()
+
 		case <-watcherTimeout.C:
 			log.Debug("Timeout reached while collecting events")
 			// No more events to read or the watch lasted more than `eventReadTimeout`.
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// eventFilterFields maps the keys of the event filters to the fields of the
+// Events supported by the field selectors of the apiserver
+var eventFilterFields = map[string]string{
+	"type":      "type",
+	"reason":    "reason",
+	"kind":      "involvedObject.kind",
+	"namespace": "involvedObject.namespace",
+}
+
+// EventFilter holds the include and exclude rules of the events collection,
+// by type, reason, and kind and namespace of the involved object. They are
+// translated into a field selector on the events watch, so that the filtered
+// events are not sent by the apiserver. The field selectors only support
+// equalities, a single value can be included by key while several values can
+// be excluded.
+type EventFilter struct {
+	Include map[string]string   `yaml:"include"`
+	Exclude map[string][]string `yaml:"exclude"`
+}
+
+// FieldSelector returns the field selector of the events watch, empty when
+// the filter has no rule
+func (f EventFilter) FieldSelector() (string, error) {
+	var selectors []fields.Selector
+
+	for _, key := range sortedKeys(f.Include) {
+		field, found := eventFilterFields[key]
+		if !found {
+			return "", fmt.Errorf("unknown event filter key %q, supported keys are type, reason, kind and namespace", key)
+		}
+		if f.Include[key] == "" {
+			continue
+		}
+		selectors = append(selectors, fields.OneTermEqualSelector(field, f.Include[key]))
+	}
+
+	excludeKeys := make([]string, 0, len(f.Exclude))
+	for key := range f.Exclude {
+		excludeKeys = append(excludeKeys, key)
+	}
+	sort.Strings(excludeKeys)
+	for _, key := range excludeKeys {
+		field, found := eventFilterFields[key]
+		if !found {
+			return "", fmt.Errorf("unknown event filter key %q, supported keys are type, reason, kind and namespace", key)
+		}
+		for _, value := range f.Exclude[key] {
+			if value == "" {
+				continue
+			}
+			if value == f.Include[key] {
+				return "", fmt.Errorf("the event filter both includes and excludes %s %q", key, value)
+			}
+			selectors = append(selectors, fields.OneTermNotEqualSelector(field, value))
+		}
+	}
+
+	if len(selectors) == 0 {
+		return "", nil
+	}
+	return fields.AndSelectors(selectors...).String(), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventFilterFieldSelector(t *testing.T) {
+	for name, tc := range map[string]struct {
+		filter   EventFilter
+		selector string
+		err      bool
+	}{
+		"empty": {
+			filter:   EventFilter{},
+			selector: "",
+		},
+		"include": {
+			filter:   EventFilter{Include: map[string]string{"type": "Warning", "namespace": "default"}},
+			selector: "involvedObject.namespace=default,type=Warning",
+		},
+		"exclude": {
+			filter:   EventFilter{Exclude: map[string][]string{"reason": {"Scheduled", "Pulled"}, "kind": {"Node"}}},
+			selector: "involvedObject.kind!=Node,reason!=Scheduled,reason!=Pulled",
+		},
+		"include and exclude": {
+			filter: EventFilter{
+				Include: map[string]string{"kind": "Pod"},
+				Exclude: map[string][]string{"reason": {"Pulled"}},
+			},
+			selector: "involvedObject.kind=Pod,reason!=Pulled",
+		},
+		"unknown key": {
+			filter: EventFilter{Exclude: map[string][]string{"source": {"kubelet"}}},
+			err:    true,
+		},
+		"conflict": {
+			filter: EventFilter{
+				Include: map[string]string{"type": "Warning"},
+				Exclude: map[string][]string{"type": {"Warning"}},
+			},
+			err: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			selector, err := tc.filter.FieldSelector()
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.selector, selector)
+		})
+	}
+}
@@ -71,8 +71,9 @@ type AutoscalersController struct {
 	mu        sync.Mutex
 }
 
-// NewAutoscalersController returns a new AutoscalersController
-func NewAutoscalersController(client kubernetes.Interface, le LeaderElectorInterface, dogCl hpa.DatadogClient, autoscalingInformer autoscalersinformer.HorizontalPodAutoscalerInformer) (*AutoscalersController, error) {
+// NewAutoscalersController returns a new AutoscalersController watching the
+// autoscalers of the informers, by namespace
+func NewAutoscalersController(client kubernetes.Interface, le LeaderElectorInterface, dogCl hpa.DatadogClient, autoscalingInformers map[string]autoscalersinformer.HorizontalPodAutoscalerInformer) (*AutoscalersController, error) {
 	var err error
 
 	h := &AutoscalersController{
@@ -110,18 +111,61 @@ func NewAutoscalersController(client kubernetes.Interface, le LeaderElectorInter
 		return nil, err
 	}
 
-	autoscalingInformer.Informer().AddEventHandler(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc:    h.addAutoscaler,
-			UpdateFunc: h.updateAutoscaler,
-			DeleteFunc: h.deleteAutoscaler,
-		},
-	)
-	h.autoscalersLister = autoscalingInformer.Lister()
-	h.autoscalersListerSynced = autoscalingInformer.Informer().HasSynced
+	listers := make(namespacedAutoscalersLister, len(autoscalingInformers))
+	synced := make([]cache.InformerSynced, 0, len(autoscalingInformers))
+	for ns, autoscalingInformer := range autoscalingInformers {
+		autoscalingInformer.Informer().AddEventHandler(
+			cache.ResourceEventHandlerFuncs{
+				AddFunc:    h.addAutoscaler,
+				UpdateFunc: h.updateAutoscaler,
+				DeleteFunc: h.deleteAutoscaler,
+			},
+		)
+		listers[ns] = autoscalingInformer.Lister()
+		synced = append(synced, autoscalingInformer.Informer().HasSynced)
+	}
+	h.autoscalersLister = listers
+	h.autoscalersListerSynced = func() bool {
+		for _, hasSynced := range synced {
+			if !hasSynced() {
+				return false
+			}
+		}
+		return true
+	}
 	return h, nil
 }
 
+// namespacedAutoscalersLister lists the autoscalers from the listers of the
+// informers of each watched namespace, see kubernetes_namespaces_include
+type namespacedAutoscalersLister map[string]autoscalerslister.HorizontalPodAutoscalerLister
+
+// List lists the autoscalers of all the watched namespaces
+func (l namespacedAutoscalersLister) List(selector labels.Selector) ([]*autoscalingv2.HorizontalPodAutoscaler, error) {
+	var autoscalers []*autoscalingv2.HorizontalPodAutoscaler
+	for _, lister := range l {
+		list, err := lister.List(selector)
+		if err != nil {
+			return nil, err
+		}
+		autoscalers = append(autoscalers, list...)
+	}
+	return autoscalers, nil
+}
+
+// HorizontalPodAutoscalers returns the lister of the autoscalers of a
+// namespace, empty if the namespace is not watched
+func (l namespacedAutoscalersLister) HorizontalPodAutoscalers(namespace string) autoscalerslister.HorizontalPodAutoscalerNamespaceLister {
+	if lister, found := l[namespace]; found {
+		return lister.HorizontalPodAutoscalers(namespace)
+	}
+	if lister, found := l[metav1.NamespaceAll]; found {
+		return lister.HorizontalPodAutoscalers(namespace)
+	}
+	empty := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	return autoscalerslister.NewHorizontalPodAutoscalerLister(empty).HorizontalPodAutoscalers(namespace)
+}
+
 func (h *AutoscalersController) Run(stopCh <-chan struct{}) {
 	defer h.queue.ShutDown()
 
@@ -134,7 +178,7 @@ func (h *AutoscalersController) Run(stopCh <-chan struct{}) {
 
 	h.processingLoop()
 
-	go wait.Until(h.worker, time.Second, stopCh)
+	goController("HPA controller worker", func() { wait.Until(h.worker, time.Second, stopCh) })
 	<-stopCh
 }
 
@@ -210,7 +254,7 @@ func (h *AutoscalersController) gc() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	list, err := h.autoscalersLister.HorizontalPodAutoscalers(metav1.NamespaceAll).List(labels.Everything())
+	list, err := h.autoscalersLister.List(labels.Everything())
 	if err != nil {
 		log.Errorf("Could not list hpas: %v", err)
 		return
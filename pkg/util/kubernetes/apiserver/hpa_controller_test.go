@@ -27,6 +27,7 @@ import (
 	autoscalingv2 "k8s.io/api/autoscaling/v2beta1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/informers"
+	autoscalersinformer "k8s.io/client-go/informers/autoscaling/v2beta1"
 )
 
 func newFakeConfigMapStore(t *testing.T, ns, name string, metrics map[string]custommetrics.ExternalMetricValue) (custommetrics.Store, kubernetes.Interface) {
@@ -68,7 +69,9 @@ func newFakeAutoscalerController(client kubernetes.Interface, itf LeaderElectorI
 		client,
 		itf,
 		dcl,
-		informerFactory.Autoscaling().V2beta1().HorizontalPodAutoscalers(),
+		map[string]autoscalersinformer.HorizontalPodAutoscalerInformer{
+			metav1.NamespaceAll: informerFactory.Autoscaling().V2beta1().HorizontalPodAutoscalers(),
+		},
 	)
 
 	autoscalerController.autoscalersListerSynced = func() bool { return true }
@@ -0,0 +1,231 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	informersExpvars = expvar.NewMap("informers")
+
+	informers   = make(map[string]*informerTelemetry)
+	informersMu sync.RWMutex
+
+	// client-go doesn't tell which informer a reflector belongs to, the
+	// reflectors are only tracked as a whole
+	reflectorsExpvars     = expvar.NewMap("reflectors")
+	reflectorCount        = expvar.Int{}
+	reflectorLists        = expvar.Int{}
+	reflectorWatches      = expvar.Int{}
+	reflectorShortWatches = expvar.Int{}
+)
+
+func init() {
+	informersExpvars.Set("Informers", expvar.Func(func() interface{} {
+		return GetInformersStats()
+	}))
+	reflectorsExpvars.Set("Lists", &reflectorLists)
+	reflectorsExpvars.Set("Watches", &reflectorWatches)
+	reflectorsExpvars.Set("ShortWatches", &reflectorShortWatches)
+	// every watch but the first one of each reflector is a restart
+	reflectorsExpvars.Set("WatchRestarts", expvar.Func(func() interface{} {
+		if restarts := reflectorWatches.Value() - reflectorCount.Value(); restarts > 0 {
+			return restarts
+		}
+		return 0
+	}))
+	cache.SetReflectorMetricsProvider(reflectorMetricsProvider{})
+}
+
+// InformerStats holds the telemetry of an informer
+type InformerStats struct {
+	Synced                  bool   `json:"synced"`
+	LastSyncResourceVersion string `json:"last_sync_resource_version"`
+	LastEvent               string `json:"last_event,omitempty"`
+	CacheSize               int    `json:"cache_size"`
+	Adds                    int64  `json:"adds"`
+	Updates                 int64  `json:"updates"`
+	Deletes                 int64  `json:"deletes"`
+	// Resyncs counts the updates without changes, sent on the periodic
+	// resyncs and after the relists
+	Resyncs int64 `json:"resyncs"`
+	// LastWatchEvent is the last add, update or delete, the resyncs
+	// coming from the cache and not from the watch
+	LastWatchEvent string `json:"last_watch_event,omitempty"`
+	// Stuck is set by the stuck-watch detector until the next watch event
+	Stuck              bool  `json:"stuck"`
+	StuckWatchRestarts int64 `json:"stuck_watch_restarts"`
+}
+
+type informerTelemetry struct {
+	informer cache.SharedIndexInformer
+
+	adds      int64
+	updates   int64
+	deletes   int64
+	resyncs   int64
+	lastEvent int64 // unix nano
+
+	// the fields below are unix nano timestamps
+	registered     int64
+	lastWatchEvent int64
+	// lastRestart is the last time the stuck-watch detector restarted the
+	// watches, 0 if the informer isn't stuck
+	lastRestart int64
+	restarts    int64
+}
+
+// RegisterInformerTelemetry tracks the events and the cache of an informer,
+// reported in the `informers` expvar and in the status of the cluster agent.
+// Registering a name twice replaces the previous informer, registering the
+// same informer again, e.g. a shared informer used by several controllers, is a no-op.
+func RegisterInformerTelemetry(name string, informer cache.SharedIndexInformer) {
+	informersMu.Lock()
+	if current, found := informers[name]; found && current.informer == informer {
+		informersMu.Unlock()
+		return
+	}
+	informersMu.Unlock()
+
+	t := &informerTelemetry{informer: informer, registered: time.Now().UnixNano()}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			atomic.AddInt64(&t.adds, 1)
+			t.touchWatch()
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if sameResourceVersion(oldObj, newObj) {
+				atomic.AddInt64(&t.resyncs, 1)
+				t.touch()
+			} else {
+				atomic.AddInt64(&t.updates, 1)
+				t.touchWatch()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			atomic.AddInt64(&t.deletes, 1)
+			t.touchWatch()
+		},
+	})
+
+	informersMu.Lock()
+	defer informersMu.Unlock()
+	informers[name] = t
+}
+
+func (t *informerTelemetry) touch() {
+	atomic.StoreInt64(&t.lastEvent, time.Now().UnixNano())
+}
+
+// touchWatch records an event coming from the watch, which unsticks the informer
+func (t *informerTelemetry) touchWatch() {
+	now := time.Now().UnixNano()
+	atomic.StoreInt64(&t.lastEvent, now)
+	atomic.StoreInt64(&t.lastWatchEvent, now)
+	atomic.StoreInt64(&t.lastRestart, 0)
+}
+
+func (t *informerTelemetry) stats() InformerStats {
+	stats := InformerStats{
+		Synced:                  t.informer.HasSynced(),
+		LastSyncResourceVersion: t.informer.LastSyncResourceVersion(),
+		CacheSize:               len(t.informer.GetStore().ListKeys()),
+		Adds:                    atomic.LoadInt64(&t.adds),
+		Updates:                 atomic.LoadInt64(&t.updates),
+		Deletes:                 atomic.LoadInt64(&t.deletes),
+		Resyncs:                 atomic.LoadInt64(&t.resyncs),
+		Stuck:                   atomic.LoadInt64(&t.lastRestart) > 0,
+		StuckWatchRestarts:      atomic.LoadInt64(&t.restarts),
+	}
+	if last := atomic.LoadInt64(&t.lastEvent); last > 0 {
+		stats.LastEvent = time.Unix(0, last).Format(time.RFC3339)
+	}
+	if last := atomic.LoadInt64(&t.lastWatchEvent); last > 0 {
+		stats.LastWatchEvent = time.Unix(0, last).Format(time.RFC3339)
+	}
+	return stats
+}
+
+// GetInformersStats returns the telemetry of the registered informers, by name
+func GetInformersStats() map[string]InformerStats {
+	informersMu.RLock()
+	defer informersMu.RUnlock()
+	stats := make(map[string]InformerStats, len(informers))
+	for name, t := range informers {
+		stats[name] = t.stats()
+	}
+	return stats
+}
+
+func sameResourceVersion(oldObj, newObj interface{}) bool {
+	oldMeta, err := meta.Accessor(oldObj)
+	if err != nil {
+		return false
+	}
+	newMeta, err := meta.Accessor(newObj)
+	if err != nil {
+		return false
+	}
+	return oldMeta.GetResourceVersion() == newMeta.GetResourceVersion()
+}
+
+// reflectorMetricsProvider counts the lists and watches of the reflectors of
+// all the informers
+type reflectorMetricsProvider struct{}
+
+type counterMetric struct {
+	v *expvar.Int
+}
+
+func (c counterMetric) Inc() { c.v.Add(1) }
+
+type noopMetric struct{}
+
+func (noopMetric) Observe(float64) {}
+func (noopMetric) Set(float64)     {}
+
+func (reflectorMetricsProvider) NewListsMetric(name string) cache.CounterMetric {
+	return counterMetric{&reflectorLists}
+}
+
+func (reflectorMetricsProvider) NewListDurationMetric(name string) cache.SummaryMetric {
+	return noopMetric{}
+}
+
+func (reflectorMetricsProvider) NewItemsInListMetric(name string) cache.SummaryMetric {
+	return noopMetric{}
+}
+
+// NewWatchesMetric is called once per reflector
+func (reflectorMetricsProvider) NewWatchesMetric(name string) cache.CounterMetric {
+	reflectorCount.Add(1)
+	return counterMetric{&reflectorWatches}
+}
+
+func (reflectorMetricsProvider) NewShortWatchesMetric(name string) cache.CounterMetric {
+	return counterMetric{&reflectorShortWatches}
+}
+
+func (reflectorMetricsProvider) NewWatchDurationMetric(name string) cache.SummaryMetric {
+	return noopMetric{}
+}
+
+func (reflectorMetricsProvider) NewItemsInWatchMetric(name string) cache.SummaryMetric {
+	return noopMetric{}
+}
+
+func (reflectorMetricsProvider) NewLastResourceVersionMetric(name string) cache.GaugeMetric {
+	return noopMetric{}
+}
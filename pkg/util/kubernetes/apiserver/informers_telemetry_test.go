@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestInformerTelemetry(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", ResourceVersion: "1"},
+	})
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	informer := informerFactory.Core().V1().Nodes().Informer()
+	RegisterInformerTelemetry("test-nodes", informer)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	informerFactory.Start(stop)
+	require.True(t, cache.WaitForCacheSync(stop, informer.HasSynced))
+
+	node2 := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2", ResourceVersion: "2"}}
+	_, err := client.CoreV1().Nodes().Create(node2)
+	require.NoError(t, err)
+	node2Updated := node2.DeepCopy()
+	node2Updated.ResourceVersion = "3"
+	_, err = client.CoreV1().Nodes().Update(node2Updated)
+	require.NoError(t, err)
+	require.NoError(t, client.CoreV1().Nodes().Delete("node1", nil))
+
+	var stats InformerStats
+	for i := 0; i < 100; i++ {
+		stats = GetInformersStats()["test-nodes"]
+		if stats.Deletes == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, stats.Synced)
+	assert.Equal(t, 1, stats.CacheSize)
+	assert.Equal(t, int64(2), stats.Adds)
+	assert.Equal(t, int64(1), stats.Updates)
+	assert.Equal(t, int64(1), stats.Deletes)
+	assert.Equal(t, int64(0), stats.Resyncs)
+	assert.NotEmpty(t, stats.LastEvent)
+}
+
+func TestSameResourceVersion(t *testing.T) {
+	a := &v1.Node{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"}}
+	b := &v1.Node{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "2"}}
+	assert.True(t, sameResourceVersion(a, a.DeepCopy()))
+	assert.False(t, sameResourceVersion(a, b))
+	assert.False(t, sameResourceVersion("foo", a))
+}
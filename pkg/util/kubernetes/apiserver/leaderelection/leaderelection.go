@@ -49,7 +49,9 @@ type LeaderEngine struct {
 
 	HolderIdentity      string
 	LeaseDuration       time.Duration
+	RenewDeadline       time.Duration
 	LeaseName           string
+	Resource            string
 	LeaderNamespace     string
 	coreClient          corev1.CoreV1Interface
 	ServiceName         string
@@ -63,6 +65,7 @@ type LeaderEngine struct {
 func newLeaderEngine() *LeaderEngine {
 	return &LeaderEngine{
 		LeaseName:       defaultLeaseName,
+		Resource:        config.Datadog.GetString("leader_election_resource"),
 		LeaderNamespace: common.GetResourcesNamespace(),
 		ServiceName:     config.Datadog.GetString("cluster_agent.kubernetes_service_name"),
 	}
@@ -121,6 +124,16 @@ func (le *LeaderEngine) init() error {
 	}
 	log.Debugf("LeaderLeaseDuration: %s", le.LeaseDuration.String())
 
+	if renewDeadline := config.Datadog.GetInt("leader_election_renew_deadline"); renewDeadline > 0 {
+		le.RenewDeadline = time.Duration(renewDeadline) * time.Second
+	}
+
+	switch le.Resource {
+	case configMapResource, leaseResource:
+	default:
+		return fmt.Errorf("unknown leader_election_resource %q, must be %q or %q", le.Resource, configMapResource, leaseResource)
+	}
+
 	apiClient, err := apiserver.GetAPIClient()
 	if err != nil {
 		log.Errorf("Not Able to set up a client for the Leader Election: %s", err)
@@ -136,6 +149,15 @@ func (le *LeaderEngine) init() error {
 		return err
 	}
 
+	// check if we can get the Lease.
+	if le.Resource == leaseResource {
+		_, err = getLease(le.coreClient.RESTClient(), le.LeaderNamespace, defaultLeaseName)
+		if err != nil && errors.IsNotFound(err) == false {
+			log.Errorf("Cannot retrieve Lease from the %s namespace: %s", le.LeaderNamespace, err)
+			return err
+		}
+	}
+
 	le.leaderElector, err = le.newElection()
 	if err != nil {
 		log.Errorf("Could not initialize the Leader Election process: %s", err)
@@ -236,6 +258,14 @@ func GetLeaderElectionRecord() (leaderDetails rl.LeaderElectionRecord, err error
 	c := client.Cl.CoreV1()
 
 	leaderNamespace := common.GetResourcesNamespace()
+	if config.Datadog.GetString("leader_election_resource") == leaseResource {
+		l, err := getLease(c.RESTClient(), leaderNamespace, defaultLeaseName)
+		if err != nil {
+			return led, err
+		}
+		return *leaseToRecord(l), nil
+	}
+
 	leaderElectionCM, err := c.ConfigMaps(leaderNamespace).Get(defaultLeaseName, metav1.GetOptions{})
 	if err != nil {
 		return led, err
@@ -75,6 +75,7 @@ func (le *LeaderEngine) newElection() (*ld.LeaderElector, error) {
 			le.leaderIdentity = identity
 			le.leaderIdentityMutex.Unlock()
 
+			leaderTransitions.Inc()
 			log.Infof("New leader %q", identity)
 		},
 		OnStartedLeading: func(stop <-chan struct{}) {
@@ -82,6 +83,7 @@ func (le *LeaderEngine) newElection() (*ld.LeaderElector, error) {
 			le.leaderIdentity = le.HolderIdentity
 			le.leaderIdentityMutex.Unlock()
 
+			isLeader.Set(1)
 			log.Infof("Started leading as %q...", le.HolderIdentity)
 		},
 		// OnStoppedLeading shouldn't be called unless the election is lost. This could happen if
@@ -91,6 +93,7 @@ func (le *LeaderEngine) newElection() (*ld.LeaderElector, error) {
 			le.leaderIdentity = ""
 			le.leaderIdentityMutex.Unlock()
 
+			isLeader.Set(0)
 			log.Infof("Stopped leading %q", le.HolderIdentity)
 		},
 	}
@@ -115,11 +118,27 @@ func (le *LeaderEngine) newElection() (*ld.LeaderElector, error) {
 	if err != nil {
 		return nil, err
 	}
+	if le.Resource == leaseResource {
+		// the ConfigMap lock is kept up to date for the agents that don't support
+		// the Lease lock yet
+		leaderElectorInterface = &leaseLock{
+			client:     le.coreClient.RESTClient(),
+			namespace:  configMap.ObjectMeta.Namespace,
+			name:       configMap.ObjectMeta.Name,
+			lockConfig: resourceLockConfig,
+			legacy:     leaderElectorInterface,
+		}
+	}
+
+	renewDeadline := le.RenewDeadline
+	if renewDeadline == 0 {
+		renewDeadline = le.LeaseDuration / 2
+	}
 
 	electionConfig := ld.LeaderElectionConfig{
 		Lock:          leaderElectorInterface,
 		LeaseDuration: le.LeaseDuration,
-		RenewDeadline: le.LeaseDuration / 2,
+		RenewDeadline: renewDeadline,
 		RetryPeriod:   le.LeaseDuration / 4,
 		Callbacks:     callbacks,
 	}
@@ -0,0 +1,208 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package leaderelection
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	rl "k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Resources the leader election can be based on, selected with
+// `leader_election_resource`
+const (
+	configMapResource = "configmap"
+	leaseResource     = "lease"
+)
+
+const leasesAPIPath = "/apis/coordination.k8s.io/v1"
+
+// lease is a coordination.k8s.io/v1 Lease. The Kubernetes API release the
+// agent is built with predates the coordination API group, so the Lease type
+// and its lock are implemented here.
+type lease struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              leaseSpec `json:"spec,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       *string           `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds *int32            `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *metav1.MicroTime `json:"acquireTime,omitempty"`
+	RenewTime            *metav1.MicroTime `json:"renewTime,omitempty"`
+	LeaseTransitions     *int32            `json:"leaseTransitions,omitempty"`
+}
+
+func leaseToRecord(l *lease) *rl.LeaderElectionRecord {
+	record := &rl.LeaderElectionRecord{}
+	if l.Spec.HolderIdentity != nil {
+		record.HolderIdentity = *l.Spec.HolderIdentity
+	}
+	if l.Spec.LeaseDurationSeconds != nil {
+		record.LeaseDurationSeconds = int(*l.Spec.LeaseDurationSeconds)
+	}
+	if l.Spec.AcquireTime != nil {
+		record.AcquireTime = metav1.NewTime(l.Spec.AcquireTime.Time)
+	}
+	if l.Spec.RenewTime != nil {
+		record.RenewTime = metav1.NewTime(l.Spec.RenewTime.Time)
+	}
+	if l.Spec.LeaseTransitions != nil {
+		record.LeaderTransitions = int(*l.Spec.LeaseTransitions)
+	}
+	return record
+}
+
+func recordToLeaseSpec(record rl.LeaderElectionRecord) leaseSpec {
+	holderIdentity := record.HolderIdentity
+	leaseDurationSeconds := int32(record.LeaseDurationSeconds)
+	leaseTransitions := int32(record.LeaderTransitions)
+	return leaseSpec{
+		HolderIdentity:       &holderIdentity,
+		LeaseDurationSeconds: &leaseDurationSeconds,
+		AcquireTime:          &metav1.MicroTime{Time: record.AcquireTime.Time},
+		RenewTime:            &metav1.MicroTime{Time: record.RenewTime.Time},
+		LeaseTransitions:     &leaseTransitions,
+	}
+}
+
+// getLease returns the Lease `namespace`/`name`
+func getLease(client rest.Interface, namespace, name string) (*lease, error) {
+	raw, err := client.Get().AbsPath(leasesAPIPath, "namespaces", namespace, "leases", name).DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	l := &lease{}
+	if err := json.Unmarshal(raw, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// leaseLock implements the resourcelock.Interface with a Lease.
+// The agents of previous versions only know the ConfigMap lock: to migrate
+// without electing two leaders, the leader election record is mirrored to the
+// legacy lock, and the Lease is created from the record of the legacy lock so
+// that its holder keeps the leadership.
+type leaseLock struct {
+	client     rest.Interface
+	namespace  string
+	name       string
+	lockConfig rl.ResourceLockConfig
+	// legacy is the ConfigMap lock mirroring the record, nil if none
+	legacy rl.Interface
+
+	lease *lease
+}
+
+// Get returns the election record from the Lease, or the one of the legacy
+// lock if the Lease doesn't exist yet.
+func (l *leaseLock) Get() (*rl.LeaderElectionRecord, error) {
+	var legacyRecord *rl.LeaderElectionRecord
+	if l.legacy != nil {
+		// load the legacy lock for the updates to mirror the record
+		legacyRecord, _ = l.legacy.Get()
+	}
+
+	var err error
+	l.lease, err = getLease(l.client, l.namespace, l.name)
+	if err == nil {
+		return leaseToRecord(l.lease), nil
+	}
+	if !errors.IsNotFound(err) || legacyRecord == nil || legacyRecord.HolderIdentity == "" {
+		return nil, err
+	}
+
+	log.Infof("Migrating the leader election of %q to the Lease %s", legacyRecord.HolderIdentity, l.Describe())
+	if err = l.create(*legacyRecord); err != nil {
+		return nil, err
+	}
+	return legacyRecord, nil
+}
+
+// Create creates the Lease with the election record
+func (l *leaseLock) Create(record rl.LeaderElectionRecord) error {
+	if err := l.create(record); err != nil {
+		return err
+	}
+	l.mirror(record)
+	return nil
+}
+
+func (l *leaseLock) create(record rl.LeaderElectionRecord) error {
+	body, err := json.Marshal(&lease{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "coordination.k8s.io/v1", Kind: "Lease"},
+		ObjectMeta: metav1.ObjectMeta{Name: l.name, Namespace: l.namespace},
+		Spec:       recordToLeaseSpec(record),
+	})
+	if err != nil {
+		return err
+	}
+	raw, err := l.client.Post().AbsPath(leasesAPIPath, "namespaces", l.namespace, "leases").Body(body).DoRaw()
+	if err != nil {
+		return err
+	}
+	l.lease = &lease{}
+	return json.Unmarshal(raw, l.lease)
+}
+
+// Update updates the election record of the Lease
+func (l *leaseLock) Update(record rl.LeaderElectionRecord) error {
+	if l.lease == nil {
+		return fmt.Errorf("lease not initialized, call get or create first")
+	}
+	l.lease.Spec = recordToLeaseSpec(record)
+	body, err := json.Marshal(l.lease)
+	if err != nil {
+		return err
+	}
+	raw, err := l.client.Put().AbsPath(leasesAPIPath, "namespaces", l.namespace, "leases", l.name).Body(body).DoRaw()
+	if err != nil {
+		return err
+	}
+	l.lease = &lease{}
+	if err := json.Unmarshal(raw, l.lease); err != nil {
+		return err
+	}
+	l.mirror(record)
+	return nil
+}
+
+// mirror copies the election record to the legacy lock, so that the agents
+// of previous versions follow the leader of the Lease
+func (l *leaseLock) mirror(record rl.LeaderElectionRecord) {
+	if l.legacy == nil {
+		return
+	}
+	if err := l.legacy.Update(record); err != nil {
+		log.Debugf("Cannot mirror the leader election record to %s: %s", l.legacy.Describe(), err)
+	}
+}
+
+// RecordEvent logs the events of the lock, the Lease being unknown to the
+// event recorder
+func (l *leaseLock) RecordEvent(s string) {
+	log.Debugf("Leader election event on %s: %s %s", l.Describe(), l.lockConfig.Identity, s)
+}
+
+// Identity returns the identity of the candidate
+func (l *leaseLock) Identity() string {
+	return l.lockConfig.Identity
+}
+
+// Describe returns the name of the Lease
+func (l *leaseLock) Describe() string {
+	return fmt.Sprintf("%v/%v", l.namespace, l.name)
+}
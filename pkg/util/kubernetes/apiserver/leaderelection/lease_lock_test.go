@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package leaderelection
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	rl "k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+)
+
+const leasePath = "/apis/coordination.k8s.io/v1/namespaces/default/leases"
+
+// newLeaseServer serves a single Lease, stored as is
+func newLeaseServer(t *testing.T) (*httptest.Server, rest.Interface) {
+	var stored []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "POST" && r.URL.Path == leasePath:
+			stored, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "PUT" && r.URL.Path == leasePath+"/"+defaultLeaseName:
+			stored, _ = ioutil.ReadAll(r.Body)
+		case r.Method == "GET" && r.URL.Path == leasePath+"/"+defaultLeaseName && stored != nil:
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "NotFound", "code": 404}`))
+			return
+		}
+		w.Write(stored)
+	}))
+	client, err := corev1.NewForConfig(&rest.Config{Host: ts.URL})
+	require.NoError(t, err)
+	return ts, client.RESTClient()
+}
+
+func TestLeaseLock(t *testing.T) {
+	ts, client := newLeaseServer(t)
+	defer ts.Close()
+
+	lock := &leaseLock{
+		client:     client,
+		namespace:  "default",
+		name:       defaultLeaseName,
+		lockConfig: rl.ResourceLockConfig{Identity: "foo"},
+	}
+	_, err := lock.Get()
+	require.True(t, errors.IsNotFound(err))
+	assert.Error(t, lock.Update(rl.LeaderElectionRecord{HolderIdentity: "foo"}))
+
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	require.NoError(t, lock.Create(rl.LeaderElectionRecord{
+		HolderIdentity:       "foo",
+		LeaseDurationSeconds: 60,
+		AcquireTime:          now,
+		RenewTime:            now,
+	}))
+
+	got, err := lock.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "foo", got.HolderIdentity)
+	assert.Equal(t, 60, got.LeaseDurationSeconds)
+	assert.True(t, now.Equal(&got.RenewTime))
+
+	got.LeaderTransitions = 2
+	require.NoError(t, lock.Update(*got))
+	got, err = lock.Get()
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.LeaderTransitions)
+}
+
+func TestLeaseLockMigration(t *testing.T) {
+	ts, client := newLeaseServer(t)
+	defer ts.Close()
+
+	// bar holds the ConfigMap lock of a previous version
+	fakeClient := fake.NewSimpleClientset()
+	_, err := fakeClient.CoreV1().ConfigMaps("default").Create(makeLeaderCM(defaultLeaseName, "default", "bar", 120))
+	require.NoError(t, err)
+	legacy, err := rl.New(rl.ConfigMapsResourceLock, "default", defaultLeaseName, fakeClient.CoreV1(), rl.ResourceLockConfig{
+		Identity:      "foo",
+		EventRecorder: &record.FakeRecorder{},
+	})
+	require.NoError(t, err)
+
+	lock := &leaseLock{
+		client:     client,
+		namespace:  "default",
+		name:       defaultLeaseName,
+		lockConfig: rl.ResourceLockConfig{Identity: "foo"},
+		legacy:     legacy,
+	}
+
+	// the Lease is created from the ConfigMap, bar keeps the leadership
+	got, err := lock.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", got.HolderIdentity)
+	lease, err := getLease(client, "default", defaultLeaseName)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", leaseToRecord(lease).HolderIdentity)
+
+	// the updates are mirrored to the ConfigMap
+	got.HolderIdentity = "foo"
+	require.NoError(t, lock.Update(*got))
+	cm, err := fakeClient.CoreV1().ConfigMaps("default").Get(defaultLeaseName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, cm.Annotations[rl.LeaderElectionRecordAnnotationKey], `"holderIdentity":"foo"`)
+}
@@ -40,10 +40,19 @@ type MetadataController struct {
 	endpointsLister       corelisters.EndpointsLister
 	endpointsListerSynced cache.InformerSynced
 
+	// endpointSlicesIndexer replaces the endpoints lister when the services
+	// are mapped from the EndpointSlices, it is nil otherwise
+	endpointSlicesIndexer cache.Indexer
+
 	store *metaBundleStore
 
-	// Endpoints that need to be added to services mapping.
+	// Endpoints, or services of the EndpointSlices, that need to be added to
+	// services mapping.
 	queue workqueue.RateLimitingInterface
+
+	// nodeName restricts the mapping to the pods of a node, when the controller
+	// is run by a node agent. Nodes are not watched in that case.
+	nodeName string
 }
 
 func NewMetadataController(nodeInformer coreinformers.NodeInformer, endpointsInformer coreinformers.EndpointsInformer) *MetadataController {
@@ -70,21 +79,82 @@ func NewMetadataController(nodeInformer coreinformers.NodeInformer, endpointsInf
 	return m
 }
 
+// NewMetadataControllerWithEndpointSlices returns a MetadataController mapping
+// the services from the EndpointSlices, built by newEndpointSlicesInformer,
+// instead of the Endpoints.
+func NewMetadataControllerWithEndpointSlices(nodeInformer coreinformers.NodeInformer, endpointSlicesInformer cache.SharedIndexInformer) *MetadataController {
+	m := &MetadataController{
+		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "endpointslices"),
+	}
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    m.addNode,
+		DeleteFunc: m.deleteNode,
+	})
+	m.nodeLister = nodeInformer.Lister()
+	m.nodeListerSynced = nodeInformer.Informer().HasSynced
+
+	endpointSlicesInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    m.enqueueEndpointSlice,
+		UpdateFunc: func(old, cur interface{}) { m.enqueueEndpointSlice(cur) },
+		DeleteFunc: m.enqueueEndpointSlice,
+	})
+	m.endpointSlicesIndexer = endpointSlicesInformer.GetIndexer()
+	m.endpointsListerSynced = endpointSlicesInformer.HasSynced
+
+	m.store = globalMetaBundleStore // default to global store
+
+	return m
+}
+
+// newNodeMetadataController returns a MetadataController mapping the services of
+// the pods of a single node. It is used by the node agents computing the metadata
+// mapper themselves, when they don't rely on the cluster agent.
+func newNodeMetadataController(nodeName string, endpointsInformer coreinformers.EndpointsInformer) *MetadataController {
+	m := &MetadataController{
+		queue:    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "endpoints"),
+		nodeName: nodeName,
+	}
+	endpointsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    m.addEndpoints,
+		UpdateFunc: m.updateEndpoints,
+		DeleteFunc: m.deleteEndpoints,
+	})
+	m.endpointsLister = endpointsInformer.Lister()
+	m.endpointsListerSynced = endpointsInformer.Informer().HasSynced
+
+	m.store = globalMetaBundleStore
+
+	return m
+}
+
 func (m *MetadataController) Run(stopCh <-chan struct{}) {
 	defer m.queue.ShutDown()
 
 	log.Infof("Starting metadata controller")
 	defer log.Infof("Stopping metadata controller")
 
-	if !cache.WaitForCacheSync(stopCh, m.nodeListerSynced, m.endpointsListerSynced) {
+	synced := []cache.InformerSynced{m.endpointsListerSynced}
+	if m.nodeListerSynced != nil {
+		synced = append(synced, m.nodeListerSynced)
+	}
+	if !cache.WaitForCacheSync(stopCh, synced...) {
 		return
 	}
 
-	go wait.Until(m.worker, time.Second, stopCh)
+	goController("metadata controller worker", func() { wait.Until(m.worker, time.Second, stopCh) })
 
 	<-stopCh
 }
 
+// initialSyncDone returns whether the controller has mapped the services of the
+// initial list of the informers
+func (m *MetadataController) initialSyncDone() bool {
+	if !m.endpointsListerSynced() || (m.nodeListerSynced != nil && !m.nodeListerSynced()) {
+		return false
+	}
+	return m.queue.Len() == 0
+}
+
 func (m *MetadataController) worker() {
 	for m.processNextWorkItem() {
 	}
@@ -97,7 +167,12 @@ func (m *MetadataController) processNextWorkItem() bool {
 	}
 	defer m.queue.Done(key)
 
-	err := m.syncEndpoints(key.(string))
+	var err error
+	if m.endpointSlicesIndexer != nil {
+		err = m.syncEndpointSlices(key.(string))
+	} else {
+		err = m.syncEndpoints(key.(string))
+	}
 	if err != nil {
 		log.Debugf("Error syncing endpoints %v: %v", key, err)
 	}
@@ -111,8 +186,7 @@ func (m *MetadataController) addNode(obj interface{}) {
 		return
 	}
 
-	bundle := m.store.getCopyOrNew(node.Name)
-	m.store.set(node.Name, bundle)
+	m.store.update(node.Name, func(*metadataMapperBundle) {})
 
 	log.Debugf("Detected node %s", node.Name)
 }
@@ -182,6 +256,18 @@ func (m *MetadataController) enqueue(obj interface{}) {
 	m.queue.Add(key)
 }
 
+// enqueueEndpointSlice enqueues the service of the EndpointSlice, all the
+// slices of a service are mapped together
+func (m *MetadataController) enqueueEndpointSlice(obj interface{}) {
+	key, ok := endpointSliceServiceKey(obj)
+	if !ok {
+		log.Tracef("Ignoring EndpointSlice without service %v", obj)
+		return
+	}
+	log.Tracef("Enqueuing the EndpointSlices of the service %s", key)
+	m.queue.Add(key)
+}
+
 func (m *MetadataController) syncEndpoints(key string) error {
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -215,69 +301,146 @@ func (m *MetadataController) mapEndpoints(endpoints *corev1.Endpoints) error {
 				log.Tracef("No TargetRef for endpoints %s/%s, skipping", endpoints.Namespace, endpoints.Name)
 				continue
 			}
-			if address.TargetRef.Kind != "Pod" {
-				continue
-			}
-			namespace := address.TargetRef.Namespace
-			podName := address.TargetRef.Name
-			if podName == "" || namespace == "" {
-				log.Tracef("Incomplete reference for object %s on service %s/%s, skipping",
-					address.TargetRef.UID, endpoints.Namespace, endpoints.Name)
-				continue
-			}
 
 			// TODO: Kubernetes 1.3.x does not include `NodeName`
 			if address.NodeName == nil {
 				continue
 			}
 
-			nodeName := *address.NodeName
+			m.addPodToNode(nodeToPods, *address.NodeName, address.TargetRef, endpoints.Namespace, endpoints.Name)
+		}
+	}
+
+	m.mapServicePods(endpoints.Namespace, endpoints.Name, nodeToPods)
+	return nil
+}
+
+// syncEndpointSlices maps the pods of all the EndpointSlices of the service
+// `key` to the service
+func (m *MetadataController) syncEndpointSlices(key string) error {
+	namespace, svc, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
 
-			if _, ok := nodeToPods[nodeName]; !ok {
-				nodeToPods[nodeName] = make(map[string]sets.String)
+	objs, err := m.endpointSlicesIndexer.ByIndex(serviceIndex, key)
+	if err != nil {
+		return err
+	}
+	if len(objs) == 0 {
+		// No EndpointSlice left means watcher caught the deletion of the service, ensure metadata map is cleaned.
+		log.Tracef("EndpointSlices of %v have been deleted. Attempting to cleanup metadata map", key)
+		return m.deleteMappedEndpoints(namespace, svc)
+	}
+
+	nodeToPods := make(map[string]map[string]sets.String)
+	for _, obj := range objs {
+		slice, err := endpointSliceFromUnstructured(obj)
+		if err != nil {
+			log.Debugf("Cannot decode an EndpointSlice of %v: %v", key, err)
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			// Only the ready endpoints are listed in the addresses of the Endpoints
+			if endpoint.TargetRef == nil || !endpoint.ready() {
+				continue
 			}
-			if _, ok := nodeToPods[nodeName][namespace]; !ok {
-				nodeToPods[nodeName][namespace] = sets.NewString()
+			nodeName := endpoint.nodeName()
+			if nodeName == "" {
+				continue
 			}
-			nodeToPods[nodeName][namespace].Insert(podName)
+			m.addPodToNode(nodeToPods, nodeName, endpoint.TargetRef, namespace, svc)
 		}
 	}
 
-	svc := endpoints.Name
-	namespace := endpoints.Namespace
+	m.mapServicePods(namespace, svc, nodeToPods)
+	return nil
+}
+
+// addPodToNode adds the pod targeted by an endpoint of the service to the pods
+// running on the node
+func (m *MetadataController) addPodToNode(nodeToPods map[string]map[string]sets.String, nodeName string, targetRef *corev1.ObjectReference, svcNamespace, svc string) {
+	if targetRef.Kind != "Pod" {
+		return
+	}
+	namespace := targetRef.Namespace
+	podName := targetRef.Name
+	if podName == "" || namespace == "" {
+		log.Tracef("Incomplete reference for object %s on service %s/%s, skipping",
+			targetRef.UID, svcNamespace, svc)
+		return
+	}
+
+	if m.nodeName != "" && nodeName != m.nodeName {
+		return
+	}
+
+	if _, ok := nodeToPods[nodeName]; !ok {
+		nodeToPods[nodeName] = make(map[string]sets.String)
+	}
+	if _, ok := nodeToPods[nodeName][namespace]; !ok {
+		nodeToPods[nodeName][namespace] = sets.NewString()
+	}
+	nodeToPods[nodeName][namespace].Insert(podName)
+}
+
+// mapServicePods replaces the pods of the service in the metadata bundles of
+// the nodes
+func (m *MetadataController) mapServicePods(namespace, svc string, nodeToPods map[string]map[string]sets.String) {
+	if m.nodeName != "" && len(nodeToPods[m.nodeName]) == 0 {
+		// The service has no pod left on the node, its previous pods must be
+		// cleaned up. Most services of the cluster were never on the node.
+		bundle, found := m.store.get(m.nodeName)
+		if !found || !bundleHasService(bundle, namespace, svc) {
+			return
+		}
+		nodeToPods[m.nodeName] = make(map[string]sets.String)
+	}
 	for nodeName, ns := range nodeToPods {
-		metaBundle := m.store.getCopyOrNew(nodeName)
-		metaBundle.Services.Delete(namespace, svc) // cleanup pods deleted from the service
-		for _, pods := range ns {
-			for podName := range pods {
-				metaBundle.Services.Set(namespace, podName, svc)
+		m.store.update(nodeName, func(metaBundle *metadataMapperBundle) {
+			metaBundle.Services.Delete(namespace, svc) // cleanup pods deleted from the service
+			for _, pods := range ns {
+				for podName := range pods {
+					metaBundle.Services.Set(namespace, podName, svc)
+				}
 			}
-		}
-
-		m.store.set(nodeName, metaBundle)
+		})
 	}
+}
 
-	return nil
+// bundleHasService returns true if a pod of the namespace is mapped to the service
+func bundleHasService(bundle *metadataMapperBundle, namespace, svc string) bool {
+	for _, svcs := range bundle.Services[namespace] {
+		if svcs.Has(svc) {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *MetadataController) deleteMappedEndpoints(namespace, svc string) error {
-	nodes, err := m.nodeLister.List(labels.Everything()) // list all nodes
-	if err != nil {
-		return err
+	var nodeNames []string
+	if m.nodeName != "" {
+		nodeNames = []string{m.nodeName}
+	} else {
+		nodes, err := m.nodeLister.List(labels.Everything()) // list all nodes
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			nodeNames = append(nodeNames, node.Name)
+		}
 	}
 
 	// Delete the service from the metadata bundle for each node.
-	for _, node := range nodes {
-		oldBundle, ok := m.store.get(node.Name)
-		if !ok {
+	for _, nodeName := range nodeNames {
+		if _, ok := m.store.get(nodeName); !ok {
 			// Nothing to delete.
 			continue
 		}
-		newMetaBundle := newMetadataMapperBundle()
-		newMetaBundle.DeepCopy(oldBundle)
-		newMetaBundle.Services.Delete(namespace, svc)
-
-		m.store.set(node.Name, newMetaBundle)
+		m.store.update(nodeName, func(metaBundle *metadataMapperBundle) {
+			metaBundle.Services.Delete(namespace, svc)
+		})
 	}
 	return nil
 }
@@ -287,8 +450,7 @@ func GetPodMetadataNames(nodeName, ns, podName string) ([]string, error) {
 	cacheKey := agentcache.BuildAgentKey(metadataMapperCachePrefix, nodeName)
 	metaBundleInterface, found := agentcache.Cache.Get(cacheKey)
 	if !found {
-		log.Tracef("no metadata was found for the pod %s on node %s", podName, nodeName)
-		return nil, nil
+		metaBundleInterface = newMetadataMapperBundle()
 	}
 
 	metaBundle, ok := metaBundleInterface.(*metadataMapperBundle)
@@ -298,15 +460,24 @@ func GetPodMetadataNames(nodeName, ns, podName string) ([]string, error) {
 	// The list of metadata collected in the metaBundle is extensible and is handled here.
 	// If new cluster level tags need to be collected by the agent, only this needs to be modified.
 	serviceList, foundServices := metaBundle.ServicesForPod(ns, podName)
-	if !foundServices {
-		log.Tracef("no cached services list found for the pod %s on the node %s", podName, nodeName)
+	podTags, foundTags := metaBundle.TagsForPod(ns, podName)
+	if !foundServices && !foundTags {
+		// fall back on the snapshot restored at startup, until the pod is mapped again
+		if restored, found := globalMetaBundleStore.getRestored(nodeName); found {
+			serviceList, foundServices = restored.ServicesForPod(ns, podName)
+			podTags, foundTags = restored.TagsForPod(ns, podName)
+		}
+	}
+	if !foundServices && !foundTags {
+		log.Tracef("no cached metadata found for the pod %s on the node %s", podName, nodeName)
 		return nil, nil
 	}
-	log.Tracef("CacheKey: %s, with %d services", cacheKey, len(serviceList))
+	log.Tracef("CacheKey: %s, with %d services and %d pod tags", cacheKey, len(serviceList), len(podTags))
 	var metaList []string
 	for _, s := range serviceList {
 		metaList = append(metaList, fmt.Sprintf("kube_service:%s", s))
 	}
+	metaList = append(metaList, podTags...)
 	return metaList, nil
 }
 
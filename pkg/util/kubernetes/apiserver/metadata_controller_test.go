@@ -287,6 +287,116 @@ func TestMetadataControllerSyncEndpoints(t *testing.T) {
 	}
 }
 
+func TestNodeMetadataControllerSyncEndpoints(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 1*time.Second)
+	metaController := newNodeMetadataController("node1", informerFactory.Core().V1().Endpoints())
+	metaController.store = &metaBundleStore{
+		cache: gocache.New(gocache.NoExpiration, 5*time.Second),
+	}
+
+	pod1 := newFakePod("default", "pod1_name", "1111", "1.1.1.1")
+	pod2 := newFakePod("default", "pod2_name", "2222", "2.2.2.2")
+
+	tests := []struct {
+		desc           string
+		delete         bool // whether to add or delete endpoints
+		endpoints      *v1.Endpoints
+		expectedFound  bool
+		expectedMapper apiv1.NamespacesPodsStringsSet
+	}{
+		{
+			"service on another node",
+			false,
+			&v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc1"},
+				Subsets: []v1.EndpointSubset{
+					{Addresses: []v1.EndpointAddress{newFakeEndpointAddress("node2", pod2)}},
+				},
+			},
+			false,
+			nil,
+		},
+		{
+			"service on the node and another node",
+			false,
+			&v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc2"},
+				Subsets: []v1.EndpointSubset{
+					{Addresses: []v1.EndpointAddress{
+						newFakeEndpointAddress("node1", pod1),
+						newFakeEndpointAddress("node2", pod2),
+					}},
+				},
+			},
+			true,
+			apiv1.NamespacesPodsStringsSet{
+				"default": {"pod1_name": sets.NewString("svc2")},
+			},
+		},
+		{
+			"service without pod left on the node",
+			false,
+			&v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc2"},
+				Subsets: []v1.EndpointSubset{
+					{Addresses: []v1.EndpointAddress{newFakeEndpointAddress("node2", pod2)}},
+				},
+			},
+			true,
+			apiv1.NamespacesPodsStringsSet{},
+		},
+		{
+			"service back on the node",
+			false,
+			&v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc2"},
+				Subsets: []v1.EndpointSubset{
+					{Addresses: []v1.EndpointAddress{newFakeEndpointAddress("node1", pod1)}},
+				},
+			},
+			true,
+			apiv1.NamespacesPodsStringsSet{
+				"default": {"pod1_name": sets.NewString("svc2")},
+			},
+		},
+		{
+			"delete service",
+			true,
+			&v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc2"},
+			},
+			true,
+			apiv1.NamespacesPodsStringsSet{},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("Running step %d %s", i, tt.desc)
+
+		store := informerFactory.Core().V1().Endpoints().Informer().GetStore()
+		var err error
+		if tt.delete {
+			err = store.Delete(tt.endpoints)
+		} else {
+			err = store.Add(tt.endpoints)
+		}
+		require.NoError(t, err)
+
+		key, err := cache.MetaNamespaceKeyFunc(tt.endpoints)
+		require.NoError(t, err)
+		require.NoError(t, metaController.syncEndpoints(key))
+
+		metaBundle, found := metaController.store.get("node1")
+		require.Equal(t, tt.expectedFound, found)
+		if found {
+			assert.Equal(t, tt.expectedMapper, metaBundle.Services)
+		}
+		_, found = metaController.store.get("node2")
+		assert.False(t, found)
+	}
+}
+
 func TestMetadataController(t *testing.T) {
 	// FIXME: Updating to k8s.io/client-go v0.9+ should allow revert this PR https://github.com/DataDog/datadog-agent/pull/2524
 	// that allows a more fine-grain testing on the controller lifecycle (affected by bug https://github.com/kubernetes/kubernetes/pull/66078)
@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// metadataSnapshotVersion is bumped when the format of the snapshot changes,
+// the snapshots of other versions are ignored
+const metadataSnapshotVersion = 1
+
+// metadataSnapshot is the metadata mapper of all the nodes, persisted on disk so
+// that a restarted cluster agent keeps tagging the pods while its metadata
+// controllers map the services again
+type metadataSnapshot struct {
+	Version   int                              `json:"version"`
+	Timestamp int64                            `json:"timestamp"`
+	Nodes     map[string]*metadataMapperBundle `json:"nodes"`
+}
+
+// restoreMetadataSnapshot loads the snapshot in the store, unless it's older
+// than maxAge. The restored metaBundles are only looked up for the pods the
+// metadata controllers haven't mapped yet.
+func restoreMetadataSnapshot(store *metaBundleStore, path string, maxAge time.Duration) error {
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snapshot metadataSnapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return fmt.Errorf("unable to decode the metadata snapshot %s: %v", path, err)
+	}
+	if snapshot.Version != metadataSnapshotVersion {
+		log.Infof("Ignoring the metadata snapshot %s of version %d, expected %d", path, snapshot.Version, metadataSnapshotVersion)
+		return nil
+	}
+	if age := time.Since(time.Unix(snapshot.Timestamp, 0)); age > maxAge {
+		log.Infof("Ignoring the metadata snapshot %s taken %s ago", path, age)
+		return nil
+	}
+
+	for _, metaBundle := range snapshot.Nodes {
+		// the settings aren't persisted
+		metaBundle.mapOnIP = config.Datadog.GetBool("kubernetes_map_services_on_ip")
+	}
+	store.setRestored(snapshot.Nodes)
+	log.Infof("Restored the metadata of %d nodes from the snapshot %s", len(snapshot.Nodes), path)
+	return nil
+}
+
+// writeMetadataSnapshot writes the snapshot of the store, through a temporary
+// file not to leave a truncated snapshot behind
+func writeMetadataSnapshot(store *metaBundleStore, path string) error {
+	raw, err := json.Marshal(metadataSnapshot{
+		Version:   metadataSnapshotVersion,
+		Timestamp: time.Now().Unix(),
+		Nodes:     store.all(),
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// startMetadataSnapshots restores the last snapshot, it must be called before
+// the informers of the metadata controllers are started. A new snapshot is
+// then written every interval, once the controllers are synced.
+func startMetadataSnapshots(store *metaBundleStore, controllers []*MetadataController, stopCh <-chan struct{}) {
+	path := config.Datadog.GetString("cluster_agent.metadata_snapshot.path")
+	maxAge := time.Duration(config.Datadog.GetInt("cluster_agent.metadata_snapshot.max_age")) * time.Second
+	interval := time.Duration(config.Datadog.GetInt("cluster_agent.metadata_snapshot.interval")) * time.Second
+
+	if err := restoreMetadataSnapshot(store, path, maxAge); err != nil {
+		log.Warnf("Could not restore the metadata snapshot: %v", err)
+	}
+
+	go func() {
+		dropRestoredMetadataOnSync(store, controllers, stopCh)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				if err := writeMetadataSnapshot(store, path); err != nil {
+					log.Warnf("Could not write the metadata snapshot: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// dropRestoredMetadataOnSync drops the restored metaBundles once all the
+// metadata controllers have mapped the services of the initial list
+func dropRestoredMetadataOnSync(store *metaBundleStore, controllers []*MetadataController, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			synced := true
+			for _, m := range controllers {
+				synced = synced && m.initialSyncDone()
+			}
+			if synced {
+				store.setRestored(nil)
+				log.Debugf("The metadata controllers are synced, dropping the restored metadata")
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metadata_mapper_snapshot.json")
+
+	store := &metaBundleStore{cache: gocache.New(gocache.NoExpiration, 5*time.Second)}
+	store.update("node1", func(metaBundle *metadataMapperBundle) {
+		metaBundle.Services.Set("default", "redis-0", "redis")
+		metaBundle.Pods.Set("default", "redis-0", "kube_statefulset:redis")
+	})
+	store.update("node2", func(metaBundle *metadataMapperBundle) {
+		metaBundle.Services.Set("default", "web-1", "web")
+	})
+	require.NoError(t, writeMetadataSnapshot(store, path))
+
+	// no snapshot
+	restoredStore := &metaBundleStore{cache: gocache.New(gocache.NoExpiration, 5*time.Second)}
+	require.NoError(t, restoreMetadataSnapshot(restoredStore, filepath.Join(dir, "missing.json"), time.Hour))
+	_, found := restoredStore.getRestored("node1")
+	assert.False(t, found)
+
+	require.NoError(t, restoreMetadataSnapshot(restoredStore, path, time.Hour))
+	metaBundle, found := restoredStore.getRestored("node1")
+	require.True(t, found)
+	services, _ := metaBundle.ServicesForPod("default", "redis-0")
+	assert.Equal(t, []string{"redis"}, services)
+	tags, _ := metaBundle.TagsForPod("default", "redis-0")
+	assert.Equal(t, []string{"kube_statefulset:redis"}, tags)
+	metaBundle, found = restoredStore.getRestored("node2")
+	require.True(t, found)
+	services, _ = metaBundle.ServicesForPod("default", "web-1")
+	assert.Equal(t, []string{"web"}, services)
+	// the live store is left untouched
+	_, found = restoredStore.get("node1")
+	assert.False(t, found)
+
+	// stale snapshot
+	restoredStore.setRestored(nil)
+	require.NoError(t, restoreMetadataSnapshot(restoredStore, path, -time.Second))
+	_, found = restoredStore.getRestored("node1")
+	assert.False(t, found)
+
+	// snapshot of another version
+	raw, err := json.Marshal(metadataSnapshot{Version: metadataSnapshotVersion + 1, Timestamp: time.Now().Unix(), Nodes: store.all()})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, raw, 0600))
+	require.NoError(t, restoreMetadataSnapshot(restoredStore, path, time.Hour))
+	_, found = restoredStore.getRestored("node1")
+	assert.False(t, found)
+}
+
+func TestGetPodMetadataNamesRestored(t *testing.T) {
+	restored := newMetadataMapperBundle()
+	restored.Services.Set("default", "redis-0", "redis")
+	globalMetaBundleStore.setRestored(map[string]*metadataMapperBundle{"restored-node": restored})
+	defer globalMetaBundleStore.setRestored(nil)
+
+	names, err := GetPodMetadataNames("restored-node", "default", "redis-0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kube_service:redis"}, names)
+
+	// the pods mapped again are looked up in the live store
+	globalMetaBundleStore.update("restored-node", func(metaBundle *metadataMapperBundle) {
+		metaBundle.Services.Set("default", "redis-0", "redis-headless")
+	})
+	defer globalMetaBundleStore.delete("restored-node")
+	names, err = GetPodMetadataNames("restored-node", "default", "redis-0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kube_service:redis-headless"}, names)
+}
@@ -0,0 +1,171 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
+)
+
+const (
+	// NodeHealthAnnotation is the annotation of the health leases the node agents publish their health in
+	NodeHealthAnnotation = "agent.datadoghq.com/health"
+	// nodeHealthLabel selects the health leases among the leases of the namespace
+	nodeHealthLabel = "agent.datadoghq.com/node-health"
+	// nodeHealthLeasePrefix prefixes the node name in the name of its health lease
+	nodeHealthLeasePrefix = "datadog-agent-health-"
+
+	leasesAPIPath = "/apis/coordination.k8s.io/v1"
+)
+
+// NodeAgentHealth is the health of the agent of a node, as published in the
+// NodeHealthAnnotation of the health lease of the node
+type NodeAgentHealth struct {
+	Healthy   bool     `json:"healthy"`
+	Unhealthy []string `json:"unhealthy,omitempty"`
+	// Timestamp is the unix time of the publication, the health is stale
+	// when the agent stops publishing it
+	Timestamp int64 `json:"timestamp"`
+}
+
+// IsStale returns whether the health was published before maxAge
+func (h *NodeAgentHealth) IsStale(now time.Time, maxAge time.Duration) bool {
+	return now.Sub(time.Unix(h.Timestamp, 0)) > maxAge
+}
+
+// healthLease is a coordination.k8s.io/v1 Lease, the Kubernetes API release
+// the agent is built with predating the coordination API group
+type healthLease struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              healthLeaseSpec `json:"spec,omitempty"`
+}
+
+type healthLeaseSpec struct {
+	HolderIdentity *string           `json:"holderIdentity,omitempty"`
+	RenewTime      *metav1.MicroTime `json:"renewTime,omitempty"`
+}
+
+type healthLeaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []healthLease `json:"items"`
+}
+
+// NodeHealthLeaseName returns the name of the lease the agent of a node
+// publishes its health in
+func NodeHealthLeaseName(nodeName string) string {
+	return nodeHealthLeasePrefix + nodeName
+}
+
+// PublishNodeHealth writes the agent health in the health lease of the node
+func (c *APIClient) PublishNodeHealth(nodeName string, health NodeAgentHealth) error {
+	return c.PublishNodeHealthWithContext(context.Background(), nodeName, health)
+}
+
+// PublishNodeHealthWithContext is PublishNodeHealth, the request is canceled with ctx.
+// Each node has its own lease in the namespace of the agent, so that
+// publishing the health neither needs the rights to update the nodes nor
+// wakes up the watchers of the nodes.
+func (c *APIClient) PublishNodeHealthWithContext(ctx context.Context, nodeName string, health NodeAgentHealth) error {
+	value, err := json.Marshal(health)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	namespace := common.GetResourcesNamespace()
+	name := NodeHealthLeaseName(nodeName)
+	restClient := c.Cl.CoreV1().RESTClient()
+
+	lease := &healthLease{}
+	raw, err := restClient.Get().Context(ctx).AbsPath(leasesAPIPath, "namespaces", namespace, "leases", name).DoRaw()
+	switch {
+	case errors.IsNotFound(err):
+		lease.TypeMeta = metav1.TypeMeta{APIVersion: "coordination.k8s.io/v1", Kind: "Lease"}
+		lease.ObjectMeta = metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{nodeHealthLabel: "true"},
+		}
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(raw, lease); err != nil {
+			return err
+		}
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string)
+	}
+	lease.Annotations[NodeHealthAnnotation] = string(value)
+	holderIdentity := nodeName
+	renewTime := metav1.NewMicroTime(time.Unix(health.Timestamp, 0))
+	lease.Spec = healthLeaseSpec{HolderIdentity: &holderIdentity, RenewTime: &renewTime}
+
+	body, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+	if lease.ResourceVersion == "" {
+		return restClient.Post().Context(ctx).AbsPath(leasesAPIPath, "namespaces", namespace, "leases").Body(body).Do().Error()
+	}
+	return restClient.Put().Context(ctx).AbsPath(leasesAPIPath, "namespaces", namespace, "leases", name).Body(body).Do().Error()
+}
+
+// ListNodeAgentHealth returns the agent health published in the health
+// leases, by node name, as the raw NodeHealthAnnotation to parse with
+// ParseNodeAgentHealth
+func (c *APIClient) ListNodeAgentHealth() (map[string]string, error) {
+	ctx, cancel := c.requestContext(context.Background())
+	defer cancel()
+
+	raw, err := c.Cl.CoreV1().RESTClient().Get().
+		Context(ctx).
+		AbsPath(leasesAPIPath, "namespaces", common.GetResourcesNamespace(), "leases").
+		Param("labelSelector", fmt.Sprintf("%s=true", nodeHealthLabel)).
+		DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	leases := &healthLeaseList{}
+	if err := json.Unmarshal(raw, leases); err != nil {
+		return nil, err
+	}
+
+	healths := make(map[string]string, len(leases.Items))
+	for _, lease := range leases.Items {
+		if lease.Spec.HolderIdentity == nil {
+			continue
+		}
+		if value, found := lease.Annotations[NodeHealthAnnotation]; found {
+			healths[*lease.Spec.HolderIdentity] = value
+		}
+	}
+	return healths, nil
+}
+
+// ParseNodeAgentHealth parses the agent health published in the annotation
+// of a health lease
+func ParseNodeAgentHealth(value string) (*NodeAgentHealth, error) {
+	health := &NodeAgentHealth{}
+	if err := json.Unmarshal([]byte(value), health); err != nil {
+		return nil, err
+	}
+	return health, nil
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestPublishNodeHealth(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("kube_resources_namespace", "datadog")
+	defer mockConfig.Set("kube_resources_namespace", "")
+
+	leasePath := "/apis/coordination.k8s.io/v1/namespaces/datadog/leases"
+	var lease string
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "GET" && r.URL.Path == leasePath+"/datadog-agent-health-node1":
+			if lease == "" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "NotFound", "code": 404}`))
+				return
+			}
+			w.Write([]byte(lease))
+		case r.Method == "POST" && r.URL.Path == leasePath:
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{
+				"kind": "Lease",
+				"apiVersion": "coordination.k8s.io/v1",
+				"metadata": {
+					"name": "datadog-agent-health-node1",
+					"namespace": "datadog",
+					"labels": {"agent.datadoghq.com/node-health": "true"},
+					"annotations": {"agent.datadoghq.com/health": "{\"healthy\":false,\"unhealthy\":[\"forwarder\"],\"timestamp\":1500000000}"},
+					"creationTimestamp": null
+				},
+				"spec": {"holderIdentity": "node1", "renewTime": "2017-07-14T02:40:00.000000Z"}
+			}`, string(body))
+			lease = `{"kind": "Lease", "apiVersion": "coordination.k8s.io/v1", "metadata": {"name": "datadog-agent-health-node1", "namespace": "datadog", "resourceVersion": "1"}}`
+			w.Write([]byte(lease))
+		case r.Method == "PUT" && r.URL.Path == leasePath+"/datadog-agent-health-node1":
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), `"resourceVersion":"1"`)
+			assert.Contains(t, string(body), `{\"healthy\":true,\"timestamp\":1500000060}`)
+			w.Write(body)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer cleanup()
+
+	err := cl.PublishNodeHealthWithContext(context.Background(), "node1", NodeAgentHealth{
+		Healthy:   false,
+		Unhealthy: []string{"forwarder"},
+		Timestamp: 1500000000,
+	})
+	require.NoError(t, err)
+
+	// the existing lease is updated
+	err = cl.PublishNodeHealthWithContext(context.Background(), "node1", NodeAgentHealth{
+		Healthy:   true,
+		Timestamp: 1500000060,
+	})
+	require.NoError(t, err)
+}
+
+func TestListNodeAgentHealth(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("kube_resources_namespace", "datadog")
+	defer mockConfig.Set("kube_resources_namespace", "")
+
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apis/coordination.k8s.io/v1/namespaces/datadog/leases", r.URL.Path)
+		assert.Equal(t, "agent.datadoghq.com/node-health=true", r.URL.Query().Get("labelSelector"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "LeaseList", "apiVersion": "coordination.k8s.io/v1", "items": [
+			{"metadata": {"name": "datadog-agent-health-node1", "annotations": {"agent.datadoghq.com/health": "{\"healthy\":true,\"timestamp\":1500000000}"}}, "spec": {"holderIdentity": "node1"}},
+			{"metadata": {"name": "datadog-agent-health-node2"}, "spec": {"holderIdentity": "node2"}},
+			{"metadata": {"name": "datadog-agent-health-node3", "annotations": {"agent.datadoghq.com/health": "{}"}}}
+		]}`))
+	})
+	defer cleanup()
+
+	healths, err := cl.ListNodeAgentHealth()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"node1": `{"healthy":true,"timestamp":1500000000}`}, healths)
+}
+
+func TestParseNodeAgentHealth(t *testing.T) {
+	health, err := ParseNodeAgentHealth(`{"healthy":true,"timestamp":1500000000}`)
+	require.NoError(t, err)
+	assert.Equal(t, &NodeAgentHealth{Healthy: true, Timestamp: 1500000000}, health)
+	assert.False(t, health.IsStale(time.Unix(1500000060, 0), 3*time.Minute))
+	assert.True(t, health.IsStale(time.Unix(1500000200, 0), 3*time.Minute))
+
+	_, err = ParseNodeAgentHealth("not json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// topologyLabels maps the tag names to the node labels they're read from, the
+// first label found is used so the GA labels take precedence over the beta ones
+var topologyLabels = []struct {
+	tag    string
+	labels []string
+}{
+	{"kube_region", []string{"topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region"}},
+	{"kube_zone", []string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"}},
+	{"kube_instance_type", []string{"node.kubernetes.io/instance-type", "beta.kubernetes.io/instance-type"}},
+}
+
+// NodeInfo holds the scheduling related state of a node: its conditions,
+// taints and topology
+type NodeInfo struct {
+	Name string
+	// Conditions maps the condition types (Ready, DiskPressure...) to their status
+	Conditions    map[v1.NodeConditionType]v1.ConditionStatus
+	Taints        []v1.Taint
+	Unschedulable bool
+	// Topology maps the topology tag names (kube_zone...) to their value
+	Topology map[string]string
+}
+
+// NewNodeInfo extracts the NodeInfo of a node
+func NewNodeInfo(node *v1.Node) *NodeInfo {
+	info := &NodeInfo{
+		Name:          node.Name,
+		Conditions:    make(map[v1.NodeConditionType]v1.ConditionStatus, len(node.Status.Conditions)),
+		Taints:        node.Spec.Taints,
+		Unschedulable: node.Spec.Unschedulable,
+		Topology:      make(map[string]string),
+	}
+	for _, condition := range node.Status.Conditions {
+		info.Conditions[condition.Type] = condition.Status
+	}
+	for _, topology := range topologyLabels {
+		for _, label := range topology.labels {
+			if value, found := node.Labels[label]; found {
+				info.Topology[topology.tag] = value
+				break
+			}
+		}
+	}
+	return info
+}
+
+// IsReady returns whether the Ready condition of the node is true
+func (n *NodeInfo) IsReady() bool {
+	return n.Conditions[v1.NodeReady] == v1.ConditionTrue
+}
+
+// IsSchedulable returns whether new pods can be scheduled on the node: it must
+// be ready, not cordoned and without NoSchedule or NoExecute taints
+func (n *NodeInfo) IsSchedulable() bool {
+	if n.Unschedulable || !n.IsReady() {
+		return false
+	}
+	for _, taint := range n.Taints {
+		if taint.Effect == v1.TaintEffectNoSchedule || taint.Effect == v1.TaintEffectNoExecute {
+			return false
+		}
+	}
+	return true
+}
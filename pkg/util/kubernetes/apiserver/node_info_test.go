@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeInfo(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Labels: map[string]string{
+				"topology.kubernetes.io/zone":              "us-east-1a",
+				"failure-domain.beta.kubernetes.io/zone":   "ignored",
+				"failure-domain.beta.kubernetes.io/region": "us-east-1",
+				"beta.kubernetes.io/instance-type":         "m5.large",
+			},
+		},
+		Spec: v1.NodeSpec{
+			Taints: []v1.Taint{{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectPreferNoSchedule}},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: v1.ConditionTrue},
+				{Type: v1.NodeDiskPressure, Status: v1.ConditionTrue},
+				{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse},
+			},
+		},
+	}
+
+	info := NewNodeInfo(node)
+	assert.True(t, info.IsReady())
+	assert.True(t, info.IsSchedulable())
+	assert.Equal(t, map[string]string{
+		"kube_instance_type": "m5.large",
+		"kube_region":        "us-east-1",
+		"kube_zone":          "us-east-1a",
+	}, info.Topology)
+	assert.Equal(t, v1.ConditionTrue, info.Conditions[v1.NodeDiskPressure])
+
+	// cordoned
+	node.Spec.Unschedulable = true
+	info = NewNodeInfo(node)
+	assert.False(t, info.IsSchedulable())
+
+	// tainted
+	node.Spec.Unschedulable = false
+	node.Spec.Taints = append(node.Spec.Taints, v1.Taint{Key: "node.kubernetes.io/unreachable", Effect: v1.TaintEffectNoExecute})
+	assert.False(t, NewNodeInfo(node).IsSchedulable())
+
+	// not ready
+	node.Spec.Taints = nil
+	node.Status.Conditions[0].Status = v1.ConditionUnknown
+	info = NewNodeInfo(node)
+	assert.False(t, info.IsReady())
+	assert.False(t, info.IsSchedulable())
+}
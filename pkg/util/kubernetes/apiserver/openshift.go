@@ -8,6 +8,12 @@
 package apiserver
 
 import (
+	"context"
+
+	osq "github.com/openshift/api/quota/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -16,14 +22,23 @@ import (
 // non-standard `/oapi` URL prefix to standard api groups under the `/apis`
 // prefix in 3.6. Detecting both, with a preference for the new prefix.
 func (c *APIClient) DetectOpenShiftAPILevel() OpenShiftAPILevel {
-	err := c.Cl.CoreV1().RESTClient().Get().AbsPath("/apis/quota.openshift.io").Do().Error()
+	return c.DetectOpenShiftAPILevelWithContext(context.Background())
+}
+
+// DetectOpenShiftAPILevelWithContext is DetectOpenShiftAPILevel, the requests
+// are canceled with ctx
+func (c *APIClient) DetectOpenShiftAPILevelWithContext(ctx context.Context) OpenShiftAPILevel {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	err := c.Cl.CoreV1().RESTClient().Get().Context(ctx).AbsPath("/apis/quota.openshift.io").Do().Error()
 	if err == nil {
 		log.Debugf("Found %s", OpenShiftAPIGroup)
 		return OpenShiftAPIGroup
 	}
 	log.Debugf("Cannot access %s: %s", OpenShiftAPIGroup, err)
 
-	err = c.Cl.CoreV1().RESTClient().Get().AbsPath("/oapi").Do().Error()
+	err = c.Cl.CoreV1().RESTClient().Get().Context(ctx).AbsPath("/oapi").Do().Error()
 	if err == nil {
 		log.Debugf("Found %s", OpenShiftOAPI)
 		return OpenShiftOAPI
@@ -33,3 +48,43 @@ func (c *APIClient) DetectOpenShiftAPILevel() OpenShiftAPILevel {
 	// Fallback to NotOpenShift
 	return NotOpenShift
 }
+
+// ListOpenShiftClusterResourceQuotas lists the ClusterResourceQuotas of the
+// quota.openshift.io API group with the dynamic client. The quotas which
+// can't be converted are skipped.
+func (c *APIClient) ListOpenShiftClusterResourceQuotas() ([]osq.ClusterResourceQuota, error) {
+	list, err := c.ListUnstructured(OpenShiftClusterResourceQuotaGVR, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	quotas := make([]osq.ClusterResourceQuota, 0, len(list.Items))
+	for _, item := range list.Items {
+		var quota osq.ClusterResourceQuota
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &quota); err != nil {
+			log.Debugf("Skipping the ClusterResourceQuota %s: %s", item.GetName(), err)
+			continue
+		}
+		quotas = append(quotas, quota)
+	}
+	return quotas, nil
+}
+
+// ListOpenShiftRoutes lists the Routes of the route.openshift.io API group
+// with the dynamic client, in all the namespaces if namespace is empty. The
+// routes which can't be converted are skipped.
+func (c *APIClient) ListOpenShiftRoutes(namespace string) ([]OpenShiftRoute, error) {
+	list, err := c.ListUnstructured(OpenShiftRouteGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	routes := make([]OpenShiftRoute, 0, len(list.Items))
+	for _, item := range list.Items {
+		var route OpenShiftRoute
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &route); err != nil {
+			log.Debugf("Skipping the Route %s/%s: %s", item.GetNamespace(), item.GetName(), err)
+			continue
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOpenShiftClusterResourceQuotas(t *testing.T) {
+	cl, cleanup := newTestDynamicAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apis/quota.openshift.io/v1/clusterresourcequotas", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "ClusterResourceQuotaList", "apiVersion": "quota.openshift.io/v1", "metadata": {}, "items": [
+			{"kind": "ClusterResourceQuota", "apiVersion": "quota.openshift.io/v1", "metadata": {"name": "multiproj-test"},
+			 "spec": {"quota": {"hard": {"pods": "10"}}, "selector": {"annotations": {"openshift.io/requester": "user"}}},
+			 "status": {"total": {"hard": {"pods": "10", "cpu": "3"}, "used": {"pods": "6", "cpu": "600m"}},
+			            "namespaces": [{"namespace": "proj1", "status": {"hard": {"pods": "10"}, "used": {"pods": "2"}}}]}}
+		]}`))
+	})
+	defer cleanup()
+
+	quotas, err := cl.ListOpenShiftClusterResourceQuotas()
+	require.NoError(t, err)
+	require.Len(t, quotas, 1)
+
+	quota := quotas[0]
+	assert.Equal(t, "multiproj-test", quota.Name)
+	used := quota.Status.Total.Used["cpu"]
+	assert.Equal(t, int64(600), used.MilliValue())
+	require.Len(t, quota.Status.Namespaces, 1)
+	assert.Equal(t, "proj1", quota.Status.Namespaces[0].Namespace)
+}
+
+func TestListOpenShiftRoutes(t *testing.T) {
+	cl, cleanup := newTestDynamicAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apis/route.openshift.io/v1/namespaces/default/routes", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "RouteList", "apiVersion": "route.openshift.io/v1", "metadata": {}, "items": [
+			{"kind": "Route", "apiVersion": "route.openshift.io/v1", "metadata": {"name": "frontend", "namespace": "default"},
+			 "spec": {"host": "www.example.com", "to": {"kind": "Service", "name": "frontend"}, "tls": {"termination": "edge"}},
+			 "status": {"ingress": [{"host": "www.example.com", "routerName": "router", "conditions": [{"type": "Admitted", "status": "True"}]}]}},
+			{"kind": "Route", "apiVersion": "route.openshift.io/v1", "metadata": {"name": "backend", "namespace": "default"},
+			 "spec": {"host": "backend.example.com", "to": {"kind": "Service", "name": "backend"}}}
+		]}`))
+	})
+	defer cleanup()
+
+	routes, err := cl.ListOpenShiftRoutes("default")
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+
+	assert.Equal(t, "frontend", routes[0].Name)
+	assert.Equal(t, "www.example.com", routes[0].Spec.Host)
+	assert.Equal(t, "frontend", routes[0].Spec.To.Name)
+	require.NotNil(t, routes[0].Spec.TLS)
+	assert.Equal(t, "edge", routes[0].Spec.TLS.Termination)
+	assert.True(t, routes[0].IsAdmitted())
+
+	assert.Equal(t, "backend", routes[1].Name)
+	assert.Nil(t, routes[1].Spec.TLS)
+	assert.False(t, routes[1].IsAdmitted())
+}
@@ -7,6 +7,11 @@
 
 package apiserver
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
 // OpenShiftAPILevel describes what level of OpenShift APIs are available on the apiserver
 type OpenShiftAPILevel string
 
@@ -16,3 +21,67 @@ const (
 	OpenShiftOAPI                       = "legacy OAPI"
 	NotOpenShift                        = "no API"
 )
+
+// Resources of the OpenShift API groups, listed with the dynamic client
+var (
+	OpenShiftClusterResourceQuotaGVR = schema.GroupVersionResource{Group: "quota.openshift.io", Version: "v1", Resource: "clusterresourcequotas"}
+	OpenShiftRouteGVR                = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+)
+
+// OpenShiftRoute holds the fields of the OpenShift Route objects used by the
+// agent, the route.openshift.io types not being vendored
+type OpenShiftRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              OpenShiftRouteSpec   `json:"spec"`
+	Status            OpenShiftRouteStatus `json:"status,omitempty"`
+}
+
+// OpenShiftRouteSpec is the host and the backend of a Route
+type OpenShiftRouteSpec struct {
+	Host string                  `json:"host,omitempty"`
+	Path string                  `json:"path,omitempty"`
+	To   OpenShiftRouteReference `json:"to"`
+	TLS  *OpenShiftRouteTLS      `json:"tls,omitempty"`
+}
+
+// OpenShiftRouteReference is the backend of a Route, usually a Service
+type OpenShiftRouteReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// OpenShiftRouteTLS is the TLS configuration of a Route
+type OpenShiftRouteTLS struct {
+	Termination string `json:"termination"`
+}
+
+// OpenShiftRouteStatus lists the routers exposing a Route
+type OpenShiftRouteStatus struct {
+	Ingress []OpenShiftRouteIngress `json:"ingress,omitempty"`
+}
+
+// OpenShiftRouteIngress is the state of a Route in a router
+type OpenShiftRouteIngress struct {
+	Host       string                           `json:"host,omitempty"`
+	RouterName string                           `json:"routerName,omitempty"`
+	Conditions []OpenShiftRouteIngressCondition `json:"conditions,omitempty"`
+}
+
+// OpenShiftRouteIngressCondition is a condition of a Route in a router
+type OpenShiftRouteIngressCondition struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+// IsAdmitted returns whether a router admitted the Route
+func (r *OpenShiftRoute) IsAdmitted() bool {
+	for _, ingress := range r.Status.Ingress {
+		for _, condition := range ingress.Conditions {
+			if condition.Type == "Admitted" && condition.Status == "True" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ListSelectors restricts a listing to the objects matching the label and
+// field selectors, so that the API server only returns the objects needed.
+// Empty selectors match every object.
+type ListSelectors struct {
+	LabelSelector string
+	FieldSelector string
+}
+
+// PodsOnNodeSelectors returns the selectors matching the pods scheduled on the
+// node nodeName
+func PodsOnNodeSelectors(nodeName string) ListSelectors {
+	return ListSelectors{FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String()}
+}
+
+// validate checks the syntax of the selectors, to fail before querying the
+// API server
+func (s ListSelectors) validate() error {
+	if _, err := labels.Parse(s.LabelSelector); err != nil {
+		return fmt.Errorf("invalid label selector %q: %v", s.LabelSelector, err)
+	}
+	if _, err := fields.ParseSelector(s.FieldSelector); err != nil {
+		return fmt.Errorf("invalid field selector %q: %v", s.FieldSelector, err)
+	}
+	return nil
+}
+
+// paginate calls list with the continue token returned by the previous page,
+// until the last page. Pages hold at most `kubernetes_apiserver_list_page_size`
+// objects, so that large clusters are listed within the client timeout and
+// without holding every object in memory.
+// With `kubernetes_apiserver_cached_list`, the list is served by the watch
+// cache of the API server from resourceVersion 0: it doesn't reach etcd but
+// can be slightly stale, and it isn't paginated, as the API server rejects a
+// continue token along with a resourceVersion.
+func (c *APIClient) paginate(selectors ListSelectors, list func(opts metav1.ListOptions) (string, error)) error {
+	if err := selectors.validate(); err != nil {
+		return err
+	}
+	opts := metav1.ListOptions{
+		LabelSelector:  selectors.LabelSelector,
+		FieldSelector:  selectors.FieldSelector,
+		Limit:          c.listPageSize,
+		TimeoutSeconds: &c.timeoutSeconds,
+	}
+	if c.cachedList {
+		opts.ResourceVersion = "0"
+		opts.Limit = 0
+	}
+	for {
+		next, err := list(opts)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		// the continue token holds the resourceVersion of the first page
+		opts.ResourceVersion = ""
+		opts.Continue = next
+	}
+}
+
+// ListNodesPaginated lists the nodes matching the selectors page by page,
+// calling onPage with the nodes of each page. The listing stops at the first error, of the API server
+// or of onPage. If the continue token of a page expires, because listing took
+// longer than the API server keeps it, the listing has to be restarted.
+func (c *APIClient) ListNodesPaginated(selectors ListSelectors, onPage func(nodes []v1.Node) error) error {
+	return c.paginate(selectors, func(opts metav1.ListOptions) (string, error) {
+		nodes, err := c.Cl.CoreV1().Nodes().List(opts)
+		if err != nil {
+			return "", err
+		}
+		if err := onPage(nodes.Items); err != nil {
+			return "", err
+		}
+		return nodes.Continue, nil
+	})
+}
+
+// ListPodsPaginated lists the pods of the namespace, all namespaces if empty,
+// matching the selectors page by page. See ListNodesPaginated.
+func (c *APIClient) ListPodsPaginated(namespace string, selectors ListSelectors, onPage func(pods []v1.Pod) error) error {
+	return c.paginate(selectors, func(opts metav1.ListOptions) (string, error) {
+		pods, err := c.Cl.CoreV1().Pods(namespace).List(opts)
+		if err != nil {
+			return "", err
+		}
+		if err := onPage(pods.Items); err != nil {
+			return "", err
+		}
+		return pods.Continue, nil
+	})
+}
+
+// ListPods returns the pods of the namespace, all namespaces if empty,
+// matching the selectors. Use PodsOnNodeSelectors to only get the pods of a
+// node instead of every pod of the cluster.
+func (c *APIClient) ListPods(namespace string, selectors ListSelectors) ([]v1.Pod, error) {
+	var pods []v1.Pod
+	err := c.ListPodsPaginated(namespace, selectors, func(page []v1.Pod) error {
+		pods = append(pods, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pods, nil
+}
@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestListNodesPaginated(t *testing.T) {
+	pages := map[string]string{
+		"":      `{"kind": "NodeList", "apiVersion": "v1", "metadata": {"continue": "page2"}, "items": [{"metadata": {"name": "node1"}}, {"metadata": {"name": "node2"}}]}`,
+		"page2": `{"kind": "NodeList", "apiVersion": "v1", "metadata": {}, "items": [{"metadata": {"name": "node3"}}]}`,
+	}
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/nodes", r.URL.Path)
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+		page, found := pages[r.URL.Query().Get("continue")]
+		require.True(t, found)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(page))
+	})
+	defer cleanup()
+	cl.listPageSize = 2
+
+	var names [][]string
+	err := cl.ListNodesPaginated(ListSelectors{}, func(nodes []v1.Node) error {
+		var page []string
+		for _, node := range nodes {
+			page = append(page, node.Name)
+		}
+		names = append(names, page)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"node1", "node2"}, {"node3"}}, names)
+}
+
+func TestListPodsPaginatedStopsOnError(t *testing.T) {
+	requests := 0
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/api/v1/namespaces/default/pods", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "PodList", "apiVersion": "v1", "metadata": {"continue": "next"}, "items": [{"metadata": {"name": "pod1"}}]}`))
+	})
+	defer cleanup()
+	cl.listPageSize = 1
+
+	err := cl.ListPodsPaginated("default", ListSelectors{}, func(pods []v1.Pod) error {
+		return fmt.Errorf("stop")
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Equal(t, 1, requests)
+}
+
+func TestListPodsWithSelectors(t *testing.T) {
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/pods", r.URL.Path)
+		assert.Equal(t, "app=redis", r.URL.Query().Get("labelSelector"))
+		assert.Equal(t, "spec.nodeName=node1", r.URL.Query().Get("fieldSelector"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("continue") == "" {
+			w.Write([]byte(`{"kind": "PodList", "apiVersion": "v1", "metadata": {"continue": "next"}, "items": [{"metadata": {"name": "pod1"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"kind": "PodList", "apiVersion": "v1", "metadata": {}, "items": [{"metadata": {"name": "pod2"}}]}`))
+	})
+	defer cleanup()
+	cl.listPageSize = 1
+
+	selectors := PodsOnNodeSelectors("node1")
+	selectors.LabelSelector = "app=redis"
+	pods, err := cl.ListPods("", selectors)
+	require.NoError(t, err)
+	require.Len(t, pods, 2)
+	assert.Equal(t, "pod1", pods[0].Name)
+	assert.Equal(t, "pod2", pods[1].Name)
+}
+
+func TestListInvalidSelectors(t *testing.T) {
+	requests := 0
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	})
+	defer cleanup()
+
+	_, err := cl.ListPods("", ListSelectors{LabelSelector: "app in (redis"})
+	assert.Error(t, err)
+	err = cl.ListNodesPaginated(ListSelectors{FieldSelector: "spec.nodeName"}, func(nodes []v1.Node) error { return nil })
+	assert.Error(t, err)
+	assert.Equal(t, 0, requests)
+}
+
+func TestListNodesFromWatchCache(t *testing.T) {
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/nodes", r.URL.Path)
+		// a cached list isn't paginated
+		assert.Empty(t, r.URL.Query().Get("limit"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("continue") == "" {
+			assert.Equal(t, "0", r.URL.Query().Get("resourceVersion"))
+			w.Write([]byte(`{"kind": "NodeList", "apiVersion": "v1", "metadata": {"continue": "page2"}, "items": [{"metadata": {"name": "node1"}}]}`))
+			return
+		}
+		// the API server rejects a continue token along with a resourceVersion
+		assert.Empty(t, r.URL.Query().Get("resourceVersion"))
+		w.Write([]byte(`{"kind": "NodeList", "apiVersion": "v1", "metadata": {}, "items": [{"metadata": {"name": "node2"}}]}`))
+	})
+	defer cleanup()
+	cl.cachedList = true
+
+	var names []string
+	err := cl.ListNodesPaginated(ListSelectors{}, func(nodes []v1.Node) error {
+		for _, node := range nodes {
+			names = append(names, node.Name)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node1", "node2"}, names)
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// podIPIndexName is the name of the index of the pods by IP on the pod informers
+const podIPIndexName = "pod_ip"
+
+var (
+	globalPodIPIndex = &podIPIndex{}
+
+	errPodIPIndexDisabled = errors.New("the pods are not indexed by IP on the Cluster Agent, see kubernetes_pod_ip_index")
+)
+
+// podIPIndex looks up the pods by IP in the caches of the pod informers, one
+// per watched namespace
+type podIPIndex struct {
+	sync.RWMutex
+	indexers []cache.Indexer
+}
+
+// addInformer indexes the pods of the informer by IP, it must be called before
+// the informer is started
+func (i *podIPIndex) addInformer(informer cache.SharedIndexInformer) error {
+	if err := informer.AddIndexers(cache.Indexers{podIPIndexName: podIPIndexFunc}); err != nil {
+		return fmt.Errorf("unable to index the pods by IP: %v", err)
+	}
+	i.Lock()
+	defer i.Unlock()
+	i.indexers = append(i.indexers, informer.GetIndexer())
+	return nil
+}
+
+// podIPIndexFunc indexes the running pods by IP. The pods on the host network
+// are skipped as they share the IP of their node, and the terminated ones as
+// their IP can be given to a new pod.
+func podIPIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+	if pod.Spec.HostNetwork || pod.Status.PodIP == "" {
+		return nil, nil
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return nil, nil
+	}
+	return []string{pod.Status.PodIP}, nil
+}
+
+// getPod returns the pod with the IP, the most recent one if the IP is still
+// held by a pod being deleted
+func (i *podIPIndex) getPod(ip string) (*corev1.Pod, error) {
+	i.RLock()
+	defer i.RUnlock()
+
+	if len(i.indexers) == 0 {
+		return nil, errPodIPIndexDisabled
+	}
+
+	var found *corev1.Pod
+	for _, indexer := range i.indexers {
+		objs, err := indexer.ByIndex(podIPIndexName, ip)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				continue
+			}
+			if found == nil || found.CreationTimestamp.Before(&pod.CreationTimestamp) {
+				found = pod
+			}
+		}
+	}
+	return found, nil
+}
+
+// GetPodMetadataByIP is used when the API endpoint of the DCA to get the pod of an IP is hit.
+// It returns nil if no running pod has the IP.
+func GetPodMetadataByIP(ip string) (*apiv1.PodMetadataByIPResponse, error) {
+	pod, err := globalPodIPIndex.getPod(ip)
+	if err != nil || pod == nil {
+		return nil, err
+	}
+
+	tags, err := GetPodMetadataNames(pod.Spec.NodeName, pod.Namespace, pod.Name)
+	if err != nil {
+		log.Debugf("Could not retrieve the tags of the pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+
+	return &apiv1.PodMetadataByIPResponse{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		UID:       string(pod.UID),
+		NodeName:  pod.Spec.NodeName,
+		Tags:      tags,
+	}, nil
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newIndexedPod(namespace, name, ip string, created time.Time) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         namespace,
+			Name:              name,
+			UID:               types.UID(name + "-uid"),
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1.PodSpec{NodeName: "node1"},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			PodIP: ip,
+		},
+	}
+}
+
+func TestPodIPIndex(t *testing.T) {
+	now := time.Now()
+
+	hostNetworkPod := newIndexedPod("default", "host-network", "10.0.0.1", now)
+	hostNetworkPod.Spec.HostNetwork = true
+	terminatedPod := newIndexedPod("default", "terminated", "10.0.1.2", now.Add(time.Minute))
+	terminatedPod.Status.Phase = v1.PodSucceeded
+
+	defaultIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{podIPIndexName: podIPIndexFunc})
+	for _, pod := range []*v1.Pod{
+		hostNetworkPod,
+		terminatedPod,
+		newIndexedPod("default", "web", "10.0.1.1", now),
+		newIndexedPod("default", "old", "10.0.1.2", now),
+		newIndexedPod("default", "pending", "", now),
+	} {
+		require.NoError(t, defaultIndexer.Add(pod))
+	}
+	otherIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{podIPIndexName: podIPIndexFunc})
+	require.NoError(t, otherIndexer.Add(newIndexedPod("other", "new", "10.0.1.2", now.Add(time.Second))))
+
+	index := &podIPIndex{}
+	_, err := index.getPod("10.0.1.1")
+	assert.Equal(t, errPodIPIndexDisabled, err)
+
+	index.indexers = []cache.Indexer{defaultIndexer, otherIndexer}
+	for ip, expected := range map[string]string{
+		"10.0.1.1": "web",
+		"10.0.1.2": "new", // the most recent running pod
+		"10.0.0.1": "",    // host network
+		"10.0.9.9": "",
+	} {
+		pod, err := index.getPod(ip)
+		require.NoError(t, err)
+		if expected == "" {
+			assert.Nil(t, pod, ip)
+			continue
+		}
+		require.NotNil(t, pod, ip)
+		assert.Equal(t, expected, pod.Name)
+	}
+}
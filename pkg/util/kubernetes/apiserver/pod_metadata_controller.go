@@ -0,0 +1,272 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	batchinformers "k8s.io/client-go/informers/batch/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// maxOwnerChainLength bounds the resolution of the owners of a pod, in case of
+// a reference cycle
+const maxOwnerChainLength = 5
+
+// ownerTagNames maps the kinds of the owners of a pod to the name of their tag
+var ownerTagNames = map[string]string{
+	"CronJob":               "kube_cronjob",
+	"DaemonSet":             "kube_daemon_set",
+	"Deployment":            "kube_deployment",
+	"Job":                   "kube_job",
+	"ReplicaSet":            "kube_replica_set",
+	"ReplicationController": "kube_replication_controller",
+	"StatefulSet":           "kube_stateful_set",
+}
+
+// PodMetadataController is responsible for synchronizing the pods from the Kubernetes
+// apiserver to cache the tags of each pod in the metadata bundle of its node:
+// the chain of its owners (pod, replicaset, deployment or pod, job, cronjob),
+// its QoS class and its priority class.
+//
+// This controller is used by the Datadog Cluster Agent.
+type PodMetadataController struct {
+	podLister       corelisters.PodLister
+	podListerSynced cache.InformerSynced
+
+	replicaSetLister       appslisters.ReplicaSetLister
+	replicaSetListerSynced cache.InformerSynced
+
+	jobLister       batchlisters.JobLister
+	jobListerSynced cache.InformerSynced
+
+	store *metaBundleStore
+
+	// Pods that need their tags to be updated.
+	queue workqueue.RateLimitingInterface
+
+	// podNodes maps the pods keys to the node they are mapped on, to
+	// cleanup the deleted pods. Only used by the worker.
+	podNodes map[string]string
+}
+
+// NewPodMetadataController returns a new PodMetadataController
+func NewPodMetadataController(podInformer coreinformers.PodInformer, replicaSetInformer appsinformers.ReplicaSetInformer, jobInformer batchinformers.JobInformer) *PodMetadataController {
+	m := &PodMetadataController{
+		queue:    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "pods"),
+		podNodes: make(map[string]string),
+	}
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    m.enqueue,
+		UpdateFunc: m.updatePod,
+		DeleteFunc: m.deletePod,
+	})
+	m.podLister = podInformer.Lister()
+	m.podListerSynced = podInformer.Informer().HasSynced
+
+	m.replicaSetLister = replicaSetInformer.Lister()
+	m.replicaSetListerSynced = replicaSetInformer.Informer().HasSynced
+
+	m.jobLister = jobInformer.Lister()
+	m.jobListerSynced = jobInformer.Informer().HasSynced
+
+	m.store = globalMetaBundleStore
+
+	return m
+}
+
+func (m *PodMetadataController) Run(stopCh <-chan struct{}) {
+	defer m.queue.ShutDown()
+
+	log.Infof("Starting pod metadata controller")
+	defer log.Infof("Stopping pod metadata controller")
+
+	if !cache.WaitForCacheSync(stopCh, m.podListerSynced, m.replicaSetListerSynced, m.jobListerSynced) {
+		return
+	}
+
+	goController("pod metadata controller worker", func() { wait.Until(m.worker, time.Second, stopCh) })
+
+	<-stopCh
+}
+
+func (m *PodMetadataController) worker() {
+	for m.processNextWorkItem() {
+	}
+}
+
+func (m *PodMetadataController) processNextWorkItem() bool {
+	key, quit := m.queue.Get()
+	if quit {
+		return false
+	}
+	defer m.queue.Done(key)
+
+	err := m.syncPod(key.(string))
+	if err != nil {
+		log.Debugf("Error syncing pod %v: %v", key, err)
+	}
+
+	return true
+}
+
+func (m *PodMetadataController) updatePod(old, cur interface{}) {
+	oldPod, ok := old.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	newPod, ok := cur.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	// Most updates are status updates, not changing the tags
+	if oldPod.Spec.NodeName == newPod.Spec.NodeName &&
+		oldPod.Status.QOSClass == newPod.Status.QOSClass &&
+		reflect.DeepEqual(oldPod.OwnerReferences, newPod.OwnerReferences) {
+		return
+	}
+	m.enqueue(cur)
+}
+
+func (m *PodMetadataController) deletePod(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	m.enqueue(obj)
+}
+
+func (m *PodMetadataController) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.Debugf("Couldn't get key for object %v: %v", obj, err)
+		return
+	}
+	m.queue.Add(key)
+}
+
+func (m *PodMetadataController) syncPod(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	pod, err := m.podLister.Pods(namespace).Get(name)
+	switch {
+	case errors.IsNotFound(err):
+		// Pod absence in store means watcher caught the deletion, ensure metadata map is cleaned.
+		log.Tracef("Pod has been deleted %v. Attempting to cleanup metadata map", key)
+		m.deleteMappedPod(key, namespace, name)
+		return nil
+	case err != nil:
+		return fmt.Errorf("unable to retrieve pod %v from store: %v", key, err)
+	}
+
+	nodeName := pod.Spec.NodeName
+	if nodeName == "" {
+		// The pod isn't scheduled yet
+		return nil
+	}
+	if previousNode, found := m.podNodes[key]; found && previousNode != nodeName {
+		m.deleteMappedPod(key, namespace, name)
+	}
+
+	tags := m.podTags(pod)
+	if bundle, found := m.store.get(nodeName); found {
+		if current, found := bundle.TagsForPod(namespace, name); found && sets.NewString(current...).Equal(sets.NewString(tags...)) {
+			m.podNodes[key] = nodeName
+			return nil
+		}
+	}
+	m.store.update(nodeName, func(metaBundle *metadataMapperBundle) {
+		metaBundle.Pods.DeletePod(namespace, name)
+		if len(tags) > 0 {
+			metaBundle.Pods.Set(namespace, name, tags...)
+		}
+	})
+	m.podNodes[key] = nodeName
+
+	return nil
+}
+
+func (m *PodMetadataController) deleteMappedPod(key, namespace, name string) {
+	nodeName, found := m.podNodes[key]
+	if !found {
+		return
+	}
+	delete(m.podNodes, key)
+	if _, found := m.store.get(nodeName); !found {
+		// The node was deleted
+		return
+	}
+	m.store.update(nodeName, func(metaBundle *metadataMapperBundle) {
+		metaBundle.Pods.DeletePod(namespace, name)
+	})
+}
+
+// podTags returns the tags of the QoS class, the priority class and the owners of a pod
+func (m *PodMetadataController) podTags(pod *corev1.Pod) []string {
+	var tags []string
+	if pod.Status.QOSClass != "" {
+		tags = append(tags, fmt.Sprintf("kube_qos:%s", pod.Status.QOSClass))
+	}
+	if pod.Spec.PriorityClassName != "" {
+		tags = append(tags, fmt.Sprintf("kube_priority_class:%s", pod.Spec.PriorityClassName))
+	}
+	for _, owner := range m.ownerChain(pod.Namespace, metav1.GetControllerOf(pod)) {
+		if tagName, found := ownerTagNames[owner.Kind]; found {
+			tags = append(tags, fmt.Sprintf("%s:%s", tagName, owner.Name))
+		}
+	}
+	return tags
+}
+
+// ownerChain returns the controller of an object, then its own controller and so on
+func (m *PodMetadataController) ownerChain(namespace string, ref *metav1.OwnerReference) []metav1.OwnerReference {
+	var chain []metav1.OwnerReference
+	for ref != nil && len(chain) < maxOwnerChainLength {
+		chain = append(chain, *ref)
+		ref = m.controllerOf(namespace, *ref)
+	}
+	return chain
+}
+
+// controllerOf returns the controller of the owner of a pod, when it's cached
+func (m *PodMetadataController) controllerOf(namespace string, ref metav1.OwnerReference) *metav1.OwnerReference {
+	switch ref.Kind {
+	case "ReplicaSet":
+		rs, err := m.replicaSetLister.ReplicaSets(namespace).Get(ref.Name)
+		if err != nil {
+			log.Tracef("Unable to retrieve replicaset %s/%s from store: %v", namespace, ref.Name, err)
+			return nil
+		}
+		return metav1.GetControllerOf(rs)
+	case "Job":
+		job, err := m.jobLister.Jobs(namespace).Get(ref.Name)
+		if err != nil {
+			log.Tracef("Unable to retrieve job %s/%s from store: %v", namespace, ref.Name, err)
+			return nil
+		}
+		return metav1.GetControllerOf(job)
+	}
+	return nil
+}
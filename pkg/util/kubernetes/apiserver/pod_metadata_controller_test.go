@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	gocache "github.com/patrickmn/go-cache"
+)
+
+func newFakeControllerRef(kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+}
+
+func TestPodMetadataControllerSyncPod(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 1*time.Second)
+
+	podInformer := informerFactory.Core().V1().Pods()
+	podMetaController := NewPodMetadataController(
+		podInformer,
+		informerFactory.Apps().V1().ReplicaSets(),
+		informerFactory.Batch().V1().Jobs(),
+	)
+
+	// don't use the global store so we can can inspect the store without
+	// it being modified by other tests.
+	podMetaController.store = &metaBundleStore{
+		cache: gocache.New(gocache.NoExpiration, 5*time.Second),
+	}
+
+	// We are adding objects directly into the stores for testing purposes. Do NOT call
+	// informerFactory.Start() since the fake apiserver client doesn't actually contain our objects.
+	err := informerFactory.Apps().V1().ReplicaSets().Informer().GetStore().Add(&appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "web-5d69",
+			OwnerReferences: newFakeControllerRef("Deployment", "web"),
+		},
+	})
+	require.NoError(t, err)
+	err = informerFactory.Batch().V1().Jobs().Informer().GetStore().Add(&batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "backup-1557",
+			OwnerReferences: newFakeControllerRef("CronJob", "backup"),
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc            string
+		delete          bool // whether to add or delete the pod
+		pod             *v1.Pod
+		expectedBundles map[string]apiv1.NamespacesPodsStringsSet
+	}{
+		{
+			"pod of a deployment",
+			false,
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       "default",
+					Name:            "web-5d69-abcde",
+					OwnerReferences: newFakeControllerRef("ReplicaSet", "web-5d69"),
+				},
+				Spec: v1.PodSpec{
+					NodeName:          "node1",
+					PriorityClassName: "high",
+				},
+				Status: v1.PodStatus{QOSClass: v1.PodQOSBurstable},
+			},
+			map[string]apiv1.NamespacesPodsStringsSet{
+				"node1": {
+					"default": {
+						"web-5d69-abcde": sets.NewString(
+							"kube_qos:Burstable",
+							"kube_priority_class:high",
+							"kube_replica_set:web-5d69",
+							"kube_deployment:web",
+						),
+					},
+				},
+			},
+		},
+		{
+			"pod of a cronjob",
+			false,
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       "default",
+					Name:            "backup-1557-fghij",
+					OwnerReferences: newFakeControllerRef("Job", "backup-1557"),
+				},
+				Spec:   v1.PodSpec{NodeName: "node2"},
+				Status: v1.PodStatus{QOSClass: v1.PodQOSBestEffort},
+			},
+			map[string]apiv1.NamespacesPodsStringsSet{
+				"node2": {
+					"default": {
+						"backup-1557-fghij": sets.NewString(
+							"kube_qos:BestEffort",
+							"kube_job:backup-1557",
+							"kube_cronjob:backup",
+						),
+					},
+				},
+			},
+		},
+		{
+			"owner not in the cache",
+			false,
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       "default",
+					Name:            "api-7f8c-klmno",
+					OwnerReferences: newFakeControllerRef("ReplicaSet", "api-7f8c"),
+				},
+				Spec:   v1.PodSpec{NodeName: "node1"},
+				Status: v1.PodStatus{QOSClass: v1.PodQOSGuaranteed},
+			},
+			map[string]apiv1.NamespacesPodsStringsSet{
+				"node1": {
+					"default": {
+						"web-5d69-abcde": sets.NewString(
+							"kube_qos:Burstable",
+							"kube_priority_class:high",
+							"kube_replica_set:web-5d69",
+							"kube_deployment:web",
+						),
+						"api-7f8c-klmno": sets.NewString(
+							"kube_qos:Guaranteed",
+							"kube_replica_set:api-7f8c",
+						),
+					},
+				},
+			},
+		},
+		{
+			"delete pod",
+			true,
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-5d69-abcde"},
+			},
+			map[string]apiv1.NamespacesPodsStringsSet{
+				"node1": {
+					"default": {
+						"api-7f8c-klmno": sets.NewString(
+							"kube_qos:Guaranteed",
+							"kube_replica_set:api-7f8c",
+						),
+					},
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("Running step %d %s", i, tt.desc)
+
+		store := podInformer.Informer().GetStore()
+
+		var err error
+		if tt.delete {
+			err = store.Delete(tt.pod)
+		} else {
+			err = store.Add(tt.pod)
+		}
+		require.NoError(t, err)
+
+		key, err := cache.MetaNamespaceKeyFunc(tt.pod)
+		require.NoError(t, err)
+
+		err = podMetaController.syncPod(key)
+		require.NoError(t, err)
+
+		for nodeName, expectedMapper := range tt.expectedBundles {
+			metaBundle, ok := podMetaController.store.get(nodeName)
+			require.True(t, ok, "No meta bundle for %s", nodeName)
+			assert.Equal(t, expectedMapper, metaBundle.Pods, nodeName)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"expvar"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+var (
+	clientExpvars = expvar.NewMap("apiserverClient")
+	// requests delayed by the client-side rate limiter, and the total time
+	// they waited for, across all the clients
+	throttledRequests = expvar.Int{}
+	throttledWaitMs   = expvar.Int{}
+)
+
+func init() {
+	clientExpvars.Set("ThrottledRequests", &throttledRequests)
+	clientExpvars.Set("ThrottledWaitMs", &throttledWaitMs)
+}
+
+// telemetryRateLimiter is the token bucket rate limiter of client-go,
+// counting the requests it delays.
+type telemetryRateLimiter struct {
+	flowcontrol.RateLimiter
+}
+
+func newTelemetryRateLimiter(qps float32, burst int) flowcontrol.RateLimiter {
+	return &telemetryRateLimiter{flowcontrol.NewTokenBucketRateLimiter(qps, burst)}
+}
+
+// Accept blocks until a token is available, the request is throttled
+// when none is available right away.
+func (r *telemetryRateLimiter) Accept() {
+	if r.RateLimiter.TryAccept() {
+		clientRateLimiterWait.Observe(0)
+		return
+	}
+	start := time.Now()
+	r.RateLimiter.Accept()
+	wait := time.Since(start)
+	throttledRequests.Add(1)
+	throttledWaitMs.Add(wait.Nanoseconds() / int64(time.Millisecond))
+	clientRateLimiterWait.Observe(wait.Seconds())
+}
@@ -0,0 +1,30 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelemetryRateLimiter(t *testing.T) {
+	requests := throttledRequests.Value()
+
+	limiter := newTelemetryRateLimiter(10, 2)
+	defer limiter.Stop()
+	assert.Equal(t, float32(10), limiter.QPS())
+
+	// The burst isn't throttled
+	limiter.Accept()
+	limiter.Accept()
+	assert.Equal(t, requests, throttledRequests.Value())
+
+	limiter.Accept()
+	assert.Equal(t, requests+1, throttledRequests.Value())
+}
@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
+)
+
+// leaderElectionName is the name of the leader election configmap or lease,
+// see the leaderelection package
+const leaderElectionName = "datadog-leader-election"
+
+// RBACRequirement is a permission needed by a feature of the agent or the
+// cluster agent
+type RBACRequirement struct {
+	Feature    string
+	Attributes authorizationv1.ResourceAttributes
+}
+
+// RBACReview is the result of the SelfSubjectAccessReview of a requirement
+type RBACReview struct {
+	RBACRequirement
+	Allowed bool
+	// Reason is the reason given by the authorizer, if any
+	Reason string
+	// Err is set when the review itself failed
+	Err error
+}
+
+// Remediation returns a hint to grant a denied permission
+func (r RBACRequirement) Remediation() string {
+	a := r.Attributes
+	rule := fmt.Sprintf("apiGroups: [%q], resources: [%q], verbs: [%q]", a.Group, a.Resource, a.Verb)
+	if a.Name != "" {
+		rule += fmt.Sprintf(", resourceNames: [%q]", a.Name)
+	}
+	if a.Namespace != "" {
+		return fmt.Sprintf("add the rule {%s} to a Role of namespace %s bound to the service account", rule, a.Namespace)
+	}
+	return fmt.Sprintf("add the rule {%s} to the ClusterRole bound to the service account", rule)
+}
+
+// String returns the resource and verb of the requirement, like `list pods` or
+// `get configmaps/datadogtoken in kube-system`
+func (r RBACRequirement) String() string {
+	a := r.Attributes
+	resource := a.Resource
+	if a.Group != "" {
+		resource = resource + "." + a.Group
+	}
+	if a.Name != "" {
+		resource = resource + "/" + a.Name
+	}
+	s := a.Verb + " " + resource
+	if a.Namespace != "" {
+		s += " in " + a.Namespace
+	}
+	return s
+}
+
+// RBACRequirements returns the permissions needed by the features enabled in
+// the configuration of the agent, or of the cluster agent if clusterAgent is
+// set. The namespaced resources are required in each namespace of
+// kubernetes_namespaces_include.
+func RBACRequirements(clusterAgent bool) []RBACRequirement {
+	var reqs []RBACRequirement
+	namespaces := []string{metav1.NamespaceAll}
+	if included := config.Datadog.GetStringSlice("kubernetes_namespaces_include"); len(included) > 0 {
+		namespaces = included
+	}
+	resourcesNamespace := common.GetResourcesNamespace()
+
+	add := func(feature, group, resource, name, namespace string, verbs ...string) {
+		for _, verb := range verbs {
+			reqs = append(reqs, RBACRequirement{
+				Feature: feature,
+				Attributes: authorizationv1.ResourceAttributes{
+					Group:     group,
+					Resource:  resource,
+					Name:      name,
+					Namespace: namespace,
+					Verb:      verb,
+				},
+			})
+		}
+	}
+	addNamespaced := func(feature, group, resource string, verbs ...string) {
+		for _, ns := range namespaces {
+			add(feature, group, resource, "", ns, verbs...)
+		}
+	}
+
+	// the node agents get the metadata from the cluster agent when it's enabled
+	usesAPIServer := clusterAgent || !config.Datadog.GetBool("cluster_agent.enabled")
+	collectEvents := config.Datadog.GetBool("collect_kubernetes_events") && (clusterAgent || config.Datadog.GetBool("leader_election"))
+
+	if collectEvents {
+		addNamespaced("collect_kubernetes_events", "", "events", "list", "watch")
+		tokenResource := "configmaps"
+		if config.Datadog.GetString("kubernetes_token_store") == TokenStoreSecret {
+			tokenResource = "secrets"
+		}
+		add("collect_kubernetes_events", "", tokenResource, configMapDCAToken, resourcesNamespace, "get", "update")
+	}
+	if clusterAgent || config.Datadog.GetBool("leader_election") {
+		if config.Datadog.GetString("leader_election_resource") == "lease" {
+			add("leader_election", "coordination.k8s.io", "leases", "", resourcesNamespace, "create")
+			add("leader_election", "coordination.k8s.io", "leases", leaderElectionName, resourcesNamespace, "get", "update")
+		} else {
+			add("leader_election", "", "configmaps", "", resourcesNamespace, "create")
+			add("leader_election", "", "configmaps", leaderElectionName, resourcesNamespace, "get", "update")
+		}
+	}
+	if usesAPIServer && config.Datadog.GetBool("kubernetes_collect_metadata_tags") {
+		addNamespaced("kubernetes_collect_metadata_tags", "", "services", "list", "watch")
+		addNamespaced("kubernetes_collect_metadata_tags", "", "pods", "list", "watch")
+		addNamespaced("kubernetes_collect_metadata_tags", "", "endpoints", "list", "watch")
+		add("kubernetes_collect_metadata_tags", "", "nodes", "", "", "get", "list", "watch")
+		if clusterAgent && config.Datadog.GetBool("kubernetes_use_endpoint_slices") {
+			addNamespaced("kubernetes_use_endpoint_slices", "discovery.k8s.io", "endpointslices", "list", "watch")
+		}
+	}
+
+	if !clusterAgent {
+		if config.Datadog.GetBool("kubernetes_node_health_lease.enabled") {
+			add("kubernetes_node_health_lease", "coordination.k8s.io", "leases", "", resourcesNamespace, "get", "create", "update")
+		}
+		return reqs
+	}
+
+	add("kubernetes_apiserver check", "", "componentstatuses", "", "", "list")
+	if config.Datadog.GetBool("kubernetes_collect_pod_metadata_tags") {
+		addNamespaced("kubernetes_collect_pod_metadata_tags", "apps", "replicasets", "list", "watch")
+		addNamespaced("kubernetes_collect_pod_metadata_tags", "batch", "jobs", "list", "watch")
+	}
+	if config.Datadog.GetBool("external_metrics_provider.enabled") {
+		addNamespaced("external_metrics_provider", "autoscaling", "horizontalpodautoscalers", "list", "watch")
+	}
+	if config.Datadog.GetBool("admission_controller.enabled") {
+		add("admission_controller", "", "namespaces", "", "", "list", "watch")
+	}
+	if config.Datadog.GetBool("cluster_agent.token_review.enabled") {
+		add("cluster_agent.token_review", "authentication.k8s.io", "tokenreviews", "", "", "create")
+	}
+	return reqs
+}
+
+// ReviewRBAC asks the API server whether the service account of the agent is
+// granted each requirement, with SelfSubjectAccessReviews
+func (c *APIClient) ReviewRBAC(reqs []RBACRequirement) []RBACReview {
+	reviews := make([]RBACReview, 0, len(reqs))
+	for _, req := range reqs {
+		attributes := req.Attributes
+		review, err := c.Cl.AuthorizationV1().SelfSubjectAccessReviews().Create(&authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attributes},
+		})
+		if err != nil {
+			reviews = append(reviews, RBACReview{RBACRequirement: req, Err: err})
+			continue
+		}
+		reviews = append(reviews, RBACReview{
+			RBACRequirement: req,
+			Allowed:         review.Status.Allowed,
+			Reason:          strings.TrimSpace(review.Status.Reason),
+		})
+	}
+	return reviews
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestRBACRequirements(t *testing.T) {
+	mockConfig := config.Mock()
+	defer mockConfig.Set("kubernetes_namespaces_include", []string{})
+	mockConfig.Set("kubernetes_namespaces_include", []string{"default", "prod"})
+	defer mockConfig.Set("kube_resources_namespace", "")
+	mockConfig.Set("kube_resources_namespace", "datadog")
+
+	var agentReqs []string
+	for _, req := range RBACRequirements(false) {
+		agentReqs = append(agentReqs, req.String())
+	}
+	assert.Contains(t, agentReqs, "list pods in default")
+	assert.Contains(t, agentReqs, "watch services in prod")
+	assert.Contains(t, agentReqs, "get nodes")
+	assert.NotContains(t, agentReqs, "list componentstatuses")
+
+	var dcaReqs []string
+	for _, req := range RBACRequirements(true) {
+		dcaReqs = append(dcaReqs, req.String())
+	}
+	assert.Contains(t, dcaReqs, "list componentstatuses")
+	assert.Contains(t, dcaReqs, "create configmaps in datadog")
+	assert.Contains(t, dcaReqs, "update configmaps/datadog-leader-election in datadog")
+	assert.Contains(t, dcaReqs, "list endpointslices.discovery.k8s.io in default")
+}
+
+func TestRBACRequirementRemediation(t *testing.T) {
+	req := RBACRequirement{Attributes: authorizationv1.ResourceAttributes{Group: "apps", Resource: "replicasets", Verb: "list"}}
+	assert.Equal(t, `add the rule {apiGroups: ["apps"], resources: ["replicasets"], verbs: ["list"]} to the ClusterRole bound to the service account`, req.Remediation())
+
+	req = RBACRequirement{Attributes: authorizationv1.ResourceAttributes{Resource: "configmaps", Name: "datadogtoken", Namespace: "datadog", Verb: "get"}}
+	assert.Equal(t, `add the rule {apiGroups: [""], resources: ["configmaps"], verbs: ["get"], resourceNames: ["datadogtoken"]} to a Role of namespace datadog bound to the service account`, req.Remediation())
+}
+
+func TestReviewRBAC(t *testing.T) {
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", r.URL.Path)
+		review := &authorizationv1.SelfSubjectAccessReview{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(review))
+		if review.Spec.ResourceAttributes.Resource == "secrets" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "Forbidden", "code": 403}`))
+			return
+		}
+		review.Status.Allowed = review.Spec.ResourceAttributes.Resource == "pods"
+		if !review.Status.Allowed {
+			review.Status.Reason = "no RBAC policy matched "
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(review)
+	})
+	defer cleanup()
+
+	reviews := cl.ReviewRBAC([]RBACRequirement{
+		{Feature: "pods", Attributes: authorizationv1.ResourceAttributes{Resource: "pods", Verb: "list"}},
+		{Feature: "nodes", Attributes: authorizationv1.ResourceAttributes{Resource: "nodes", Verb: "list"}},
+		{Feature: "secrets", Attributes: authorizationv1.ResourceAttributes{Resource: "secrets", Verb: "get"}},
+	})
+	require.Len(t, reviews, 3)
+	assert.True(t, reviews[0].Allowed)
+	assert.NoError(t, reviews[0].Err)
+	assert.False(t, reviews[1].Allowed)
+	assert.Equal(t, "no RBAC policy matched", reviews[1].Reason)
+	assert.Error(t, reviews[2].Err)
+}
@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const defaultRESTObjectBackoff = time.Second
+
+// RESTObjectOptions tunes GetRESTObjectWithOptions
+type RESTObjectOptions struct {
+	// Context cancels the request and the retries, context.Background() if nil.
+	// Each attempt is also bounded by kubernetes_apiserver_client_timeout.
+	Context context.Context
+	// Retries is the number of attempts after the first one, on the server
+	// errors, the throttling and the network errors
+	Retries int
+	// Backoff is the wait before the first retry, doubled after each one,
+	// 1 second if zero
+	Backoff time.Duration
+	// AcceptContentTypes overrides the Accept header of the request, e.g.
+	// "application/json" for the APIs not serving protobuf
+	AcceptContentTypes string
+}
+
+// NotFoundError is returned by GetRESTObject when the path isn't served by
+// the API server, e.g. a custom resource whose definition isn't installed
+type NotFoundError struct {
+	Path string
+	Err  error
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s not found: %s", e.Path, e.Err)
+}
+
+// ForbiddenError is returned by GetRESTObject when the agent isn't allowed
+// to get the path, its RBAC lacking the rule
+type ForbiddenError struct {
+	Path string
+	Err  error
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("access to %s forbidden: %s", e.Path, e.Err)
+}
+
+// GetRESTObject allows to retrive a custom resource from the APIserver
+func (c *APIClient) GetRESTObject(path string, output runtime.Object) error {
+	return c.GetRESTObjectWithOptions(path, output, RESTObjectOptions{})
+}
+
+// GetRESTObjectWithContext is GetRESTObject, the request is canceled with ctx
+func (c *APIClient) GetRESTObjectWithContext(ctx context.Context, path string, output runtime.Object) error {
+	return c.GetRESTObjectWithOptions(path, output, RESTObjectOptions{Context: ctx})
+}
+
+// GetRESTObjectWithOptions is GetRESTObject, with retries. The 404 and 403
+// are returned as a *NotFoundError and a *ForbiddenError.
+func (c *APIClient) GetRESTObjectWithOptions(path string, output runtime.Object, opts RESTObjectOptions) error {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	backoff := opts.Backoff
+	if backoff <= 0 {
+		backoff = defaultRESTObjectBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		result := c.getRESTObject(ctx, path, opts.AcceptContentTypes)
+		err := result.Error()
+		if err == nil {
+			return result.Into(output)
+		}
+		if attempt >= opts.Retries || ctx.Err() != nil || !isRetriable(err) {
+			return restObjectError(path, err)
+		}
+
+		log.Debugf("Could not get %s, retrying in %s: %v", path, backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *APIClient) getRESTObject(ctx context.Context, path string, accept string) rest.Result {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	req := c.Cl.CoreV1().RESTClient().Get().Context(ctx).AbsPath(path)
+	if accept != "" {
+		req.SetHeader("Accept", accept)
+	}
+	return req.Do()
+}
+
+// isRetriable returns whether an error may go away on a retry: the server
+// errors, the throttling and the errors not returned by the API server
+func isRetriable(err error) bool {
+	status, ok := err.(apierrors.APIStatus)
+	if !ok {
+		return true
+	}
+	code := int(status.Status().Code)
+	return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
+
+func restObjectError(path string, err error) error {
+	switch {
+	case apierrors.IsNotFound(err):
+		return &NotFoundError{Path: path, Err: err}
+	case apierrors.IsForbidden(err):
+		return &ForbiddenError{Path: path, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+const configMapJSON = `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "cm1"}}`
+
+func statusJSON(code int, reason string) string {
+	return fmt.Sprintf(`{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": %q, "code": %d}`, reason, code)
+}
+
+func TestGetRESTObjectWithOptionsRetries(t *testing.T) {
+	var calls int32
+	cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(statusJSON(http.StatusServiceUnavailable, "ServiceUnavailable")))
+			return
+		}
+		w.Write([]byte(configMapJSON))
+	})
+	defer cleanup()
+
+	cm := &v1.ConfigMap{}
+	err := cl.GetRESTObjectWithOptions("/api/v1/namespaces/default/configmaps/cm1", cm, RESTObjectOptions{
+		Retries:            2,
+		Backoff:            time.Millisecond,
+		AcceptContentTypes: "application/json",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "cm1", cm.Name)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestGetRESTObjectWithOptionsTypedErrors(t *testing.T) {
+	for _, tc := range []struct {
+		code   int
+		reason string
+		check  func(error) bool
+	}{
+		{http.StatusNotFound, "NotFound", func(err error) bool {
+			_, ok := err.(*NotFoundError)
+			return ok
+		}},
+		{http.StatusForbidden, "Forbidden", func(err error) bool {
+			_, ok := err.(*ForbiddenError)
+			return ok
+		}},
+	} {
+		tc := tc
+		var calls int32
+		cl, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(tc.code)
+			w.Write([]byte(statusJSON(tc.code, tc.reason)))
+		})
+
+		err := cl.GetRESTObjectWithOptions("/apis/foo/v1/bars", &v1.ConfigMap{}, RESTObjectOptions{Retries: 2, Backoff: time.Millisecond})
+		assert.True(t, tc.check(err), "%d: %v", tc.code, err)
+		// not retried
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		cleanup()
+	}
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// typedResources maps the resources watched with the informer factories to
+// an object of their type, the factories key the custom resync periods on it
+var typedResources = map[string]metav1.Object{
+	"pods":                     &v1.Pod{},
+	"services":                 &v1.Service{},
+	"endpoints":                &v1.Endpoints{},
+	"nodes":                    &v1.Node{},
+	"namespaces":               &v1.Namespace{},
+	"replicasets":              &appsv1.ReplicaSet{},
+	"jobs":                     &batchv1.Job{},
+	"horizontalpodautoscalers": &autoscalingv2.HorizontalPodAutoscaler{},
+}
+
+// ResyncPeriod returns the resync period of the informers of a resource,
+// given by its plural lowercase name: its kubernetes_informers_resync_periods
+// override if any, kubernetes_informers_resync_period otherwise.
+func ResyncPeriod(resource string) time.Duration {
+	if period, found := resyncPeriodOverrides()[resource]; found {
+		return period
+	}
+	return defaultResyncPeriod()
+}
+
+func defaultResyncPeriod() time.Duration {
+	return time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period")) * time.Second
+}
+
+// resyncPeriodOverrides parses kubernetes_informers_resync_periods, the
+// invalid periods are ignored
+func resyncPeriodOverrides() map[string]time.Duration {
+	overrides := make(map[string]time.Duration)
+	for resource, value := range config.Datadog.GetStringMapString("kubernetes_informers_resync_periods") {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seconds < 0 {
+			log.Warnf("Ignoring the invalid resync period %q of the %s informers, it must be a number of seconds", value, resource)
+			continue
+		}
+		// viper lower-cases map keys from yaml, but not from envvars
+		overrides[strings.ToLower(resource)] = time.Duration(seconds) * time.Second
+	}
+	return overrides
+}
+
+// informerFactoryOptions returns the options setting the resync period of
+// the resources overridden in kubernetes_informers_resync_periods
+func informerFactoryOptions(namespace string) []informers.SharedInformerOption {
+	var options []informers.SharedInformerOption
+	if namespace != metav1.NamespaceAll {
+		options = append(options, informers.WithNamespace(namespace))
+	}
+
+	custom := make(map[metav1.Object]time.Duration)
+	for resource, period := range resyncPeriodOverrides() {
+		if obj, found := typedResources[resource]; found {
+			custom[obj] = period
+		}
+	}
+	if len(custom) > 0 {
+		options = append(options, informers.WithCustomResyncConfig(custom))
+	}
+	return options
+}
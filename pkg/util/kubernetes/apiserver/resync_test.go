@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestResyncPeriod(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("kubernetes_informers_resync_period", 300)
+	mockConfig.Set("kubernetes_informers_resync_periods", map[string]string{
+		"Pods":           "30",
+		"endpointslices": "0",
+		"nodes":          "invalid",
+		"services":       "-1",
+	})
+	defer mockConfig.Set("kubernetes_informers_resync_periods", map[string]string{})
+
+	assert.Equal(t, 30*time.Second, ResyncPeriod("pods"))
+	assert.Equal(t, time.Duration(0), ResyncPeriod("endpointslices"))
+	assert.Equal(t, 300*time.Second, ResyncPeriod("nodes"))
+	assert.Equal(t, 300*time.Second, ResyncPeriod("services"))
+	assert.Equal(t, 300*time.Second, ResyncPeriod("datadogchecks"))
+
+	// custom resync for the pods, and the endpointslices aren't a typed resource
+	assert.Len(t, informerFactoryOptions(metav1.NamespaceAll), 1)
+	assert.Len(t, informerFactoryOptions("default"), 2)
+
+	mockConfig.Set("kubernetes_informers_resync_periods", map[string]string{})
+	assert.Len(t, informerFactoryOptions(metav1.NamespaceAll), 0)
+	assert.Equal(t, 300*time.Second, ResyncPeriod("pods"))
+}
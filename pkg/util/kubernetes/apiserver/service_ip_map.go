@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"errors"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+)
+
+var (
+	globalServiceIPMap = &serviceIPMap{}
+
+	errServiceIPMapDisabled = errors.New("the services are not mapped by ClusterIP on the Cluster Agent, see kubernetes_service_ip_map")
+)
+
+// serviceIPMap maps the ClusterIPs to their services from the cache of the
+// service informers, one per watched namespace, kept up to date by the informers
+type serviceIPMap struct {
+	sync.RWMutex
+	stores []cache.Store
+}
+
+// addInformer maps the services of the informer
+func (m *serviceIPMap) addInformer(informer cache.SharedIndexInformer) {
+	m.Lock()
+	defer m.Unlock()
+	m.stores = append(m.stores, informer.GetStore())
+}
+
+// services returns the services by ClusterIP. The headless services and the
+// ExternalName ones have no ClusterIP and are skipped.
+func (m *serviceIPMap) services() (map[string]apiv1.KubeService, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if len(m.stores) == 0 {
+		return nil, errServiceIPMapDisabled
+	}
+
+	services := make(map[string]apiv1.KubeService)
+	for _, store := range m.stores {
+		for _, obj := range store.List() {
+			svc, ok := obj.(*corev1.Service)
+			if !ok {
+				continue
+			}
+			if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == corev1.ClusterIPNone {
+				continue
+			}
+			services[svc.Spec.ClusterIP] = apiv1.KubeService{Name: svc.Name, Namespace: svc.Namespace}
+		}
+	}
+	return services, nil
+}
+
+// GetServicesByClusterIP is used when the API endpoint of the DCA to map the
+// ClusterIPs to their services is hit.
+func GetServicesByClusterIP() (*apiv1.ServicesByClusterIPResponse, error) {
+	services, err := globalServiceIPMap.services()
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.ServicesByClusterIPResponse{Services: services}, nil
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+)
+
+func newMappedService(namespace, name, clusterIP string) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       v1.ServiceSpec{ClusterIP: clusterIP},
+	}
+}
+
+func TestServiceIPMap(t *testing.T) {
+	m := &serviceIPMap{}
+	_, err := m.services()
+	assert.Equal(t, errServiceIPMapDisabled, err)
+
+	// one store per watched namespace
+	defaultStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	for _, svc := range []*v1.Service{
+		newMappedService("default", "redis", "10.96.0.12"),
+		newMappedService("default", "headless", v1.ClusterIPNone),
+		newMappedService("default", "external", ""),
+	} {
+		require.NoError(t, defaultStore.Add(svc))
+	}
+	kubeSystemStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	require.NoError(t, kubeSystemStore.Add(newMappedService("kube-system", "kube-dns", "10.96.0.10")))
+	m.stores = []cache.Store{defaultStore, kubeSystemStore}
+
+	services, err := m.services()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]apiv1.KubeService{
+		"10.96.0.12": {Name: "redis", Namespace: "default"},
+		"10.96.0.10": {Name: "kube-dns", Namespace: "kube-system"},
+	}, services)
+}
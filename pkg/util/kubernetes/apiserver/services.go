@@ -21,12 +21,19 @@ func (metaBundle *metadataMapperBundle) ServicesForPod(ns, podName string) ([]st
 	return metaBundle.Services.Get(ns, podName)
 }
 
+// TagsForPod returns the tags of a given pod and namespace, like its owners.
+// If nothing is found, the boolean is false. This call is thread-safe.
+func (metaBundle *metadataMapperBundle) TagsForPod(ns, podName string) ([]string, bool) {
+	return metaBundle.Pods.Get(ns, podName)
+}
+
 // DeepCopy used to copy data between two metadataMapperBundle
 func (metaBundle *metadataMapperBundle) DeepCopy(old *metadataMapperBundle) *metadataMapperBundle {
 	if metaBundle == nil || old == nil {
 		return metaBundle
 	}
 	metaBundle.Services = metaBundle.Services.DeepCopy(&old.Services)
+	metaBundle.Pods = metaBundle.Pods.DeepCopy(&old.Pods)
 	metaBundle.mapOnIP = old.mapOnIP
 	return metaBundle
 }
@@ -8,6 +8,7 @@
 package apiserver
 
 import (
+	"strings"
 	"sync"
 
 	agentcache "github.com/DataDog/datadog-agent/pkg/util/cache"
@@ -31,6 +32,10 @@ type metaBundleStore struct {
 	// to delete items for nodes that were deleted in the apiserver to prevent data
 	// from going missing until the next resync period.
 	cache *cache.Cache
+
+	// restored are the metaBundles of the last snapshot, looked up until the
+	// metadata controllers have mapped the services again
+	restored map[string]*metadataMapperBundle
 }
 
 func (m *metaBundleStore) get(nodeName string) (*metadataMapperBundle, bool) {
@@ -55,7 +60,10 @@ func (m *metaBundleStore) get(nodeName string) (*metadataMapperBundle, bool) {
 	return metaBundle, true
 }
 
-func (m *metaBundleStore) getCopyOrNew(nodeName string) *metadataMapperBundle {
+// update applies f to a copy of the metaBundle of a node, or to a new one, and
+// stores it. The controllers sharing the store update it this way not to
+// overwrite the changes of one another.
+func (m *metaBundleStore) update(nodeName string, f func(*metadataMapperBundle)) {
 	cacheKey := agentcache.BuildAgentKey(metadataMapperCachePrefix, nodeName)
 
 	metaBundle := newMetadataMapperBundle()
@@ -72,24 +80,51 @@ func (m *metaBundleStore) getCopyOrNew(nodeName string) *metadataMapperBundle {
 			metaBundle.DeepCopy(oldMetaBundle)
 		}
 	}
+	f(metaBundle)
 
-	return metaBundle
+	m.cache.Set(cacheKey, metaBundle, cache.NoExpiration)
 }
 
-func (m *metaBundleStore) set(nodeName string, metaBundle *metadataMapperBundle) {
+func (m *metaBundleStore) delete(nodeName string) {
 	cacheKey := agentcache.BuildAgentKey(metadataMapperCachePrefix, nodeName)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.cache.Set(cacheKey, metaBundle, cache.NoExpiration)
+	m.cache.Delete(cacheKey)
 }
 
-func (m *metaBundleStore) delete(nodeName string) {
-	cacheKey := agentcache.BuildAgentKey(metadataMapperCachePrefix, nodeName)
+// getRestored returns the metaBundle of a node restored from a snapshot, if any
+func (m *metaBundleStore) getRestored(nodeName string) (*metadataMapperBundle, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	metaBundle, ok := m.restored[nodeName]
+	return metaBundle, ok
+}
 
+func (m *metaBundleStore) setRestored(metaBundles map[string]*metadataMapperBundle) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.cache.Delete(cacheKey)
+	m.restored = metaBundles
+}
+
+// all returns the metaBundles of all the nodes, keyed by node name
+func (m *metaBundleStore) all() map[string]*metadataMapperBundle {
+	prefix := agentcache.BuildAgentKey(metadataMapperCachePrefix) + "/"
+	metaBundles := make(map[string]*metadataMapperBundle)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for key, item := range m.cache.Items() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if metaBundle, ok := item.Object.(*metadataMapperBundle); ok {
+			metaBundles[strings.TrimPrefix(key, prefix)] = metaBundle
+		}
+	}
+	return metaBundles
 }
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// startStuckWatchDetector periodically restarts the watches of the informers
+// silent for longer than kubernetes_informers_stuck_watch_threshold while the
+// other informers keep receiving events. A watch can stay open without
+// delivering anything, e.g. behind a load balancer dropping the connection
+// silently, and the informer then serves an outdated cache until its process
+// restarts.
+func startStuckWatchDetector(stopCh <-chan struct{}) {
+	threshold := time.Duration(config.Datadog.GetInt64("kubernetes_informers_stuck_watch_threshold")) * time.Second
+	if threshold <= 0 {
+		log.Debug("The stuck-watch detector is disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(threshold / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case now := <-ticker.C:
+				detectStuckWatches(now, threshold, globalWatchTracker.restart)
+			}
+		}
+	}()
+}
+
+// detectStuckWatches restarts the watches of the stuck informers with restart,
+// and returns their names
+func detectStuckWatches(now time.Time, threshold time.Duration, restart func(name string) int) []string {
+	informersMu.RLock()
+	tracked := make(map[string]*informerTelemetry, len(informers))
+	for name, t := range informers {
+		tracked[name] = t
+	}
+	informersMu.RUnlock()
+
+	since := now.Add(-threshold).UnixNano()
+	clusterActive := false
+	for _, t := range tracked {
+		if atomic.LoadInt64(&t.lastWatchEvent) >= since {
+			clusterActive = true
+			break
+		}
+	}
+	if !clusterActive {
+		// nothing happens in the cluster, the silence is expected
+		return nil
+	}
+
+	var stuck []string
+	for name, t := range tracked {
+		// the informers still listing are slow, not stuck
+		if !t.informer.HasSynced() {
+			continue
+		}
+		silentSince := atomic.LoadInt64(&t.registered)
+		for _, ts := range []int64{atomic.LoadInt64(&t.lastWatchEvent), atomic.LoadInt64(&t.lastRestart)} {
+			if ts > silentSince {
+				silentSince = ts
+			}
+		}
+		if silentSince >= since {
+			continue
+		}
+
+		stuck = append(stuck, name)
+		atomic.StoreInt64(&t.lastRestart, now.UnixNano())
+		if closed := restart(name); closed > 0 {
+			atomic.AddInt64(&t.restarts, 1)
+			log.Warnf("The %s informer received no event for %s while the cluster is active, restarted its watch", name, threshold)
+		} else {
+			log.Warnf("The %s informer received no event for %s while the cluster is active, but it has no open watch to restart", name, threshold)
+		}
+	}
+	sort.Strings(stuck)
+	return stuck
+}
@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/cache"
+)
+
+type syncedInformer struct {
+	cache.SharedIndexInformer
+	synced bool
+}
+
+func (i *syncedInformer) HasSynced() bool { return i.synced }
+
+func TestDetectStuckWatches(t *testing.T) {
+	informersMu.Lock()
+	saved := informers
+	informersMu.Unlock()
+	defer func() {
+		informersMu.Lock()
+		informers = saved
+		informersMu.Unlock()
+	}()
+
+	now := time.Now()
+	threshold := 10 * time.Minute
+	ago := func(d time.Duration) int64 { return now.Add(-d).UnixNano() }
+	active := &informerTelemetry{informer: &syncedInformer{synced: true}, registered: ago(time.Hour), lastWatchEvent: ago(time.Minute)}
+	silent := &informerTelemetry{informer: &syncedInformer{synced: true}, registered: ago(time.Hour), lastWatchEvent: ago(20 * time.Minute)}
+	syncing := &informerTelemetry{informer: &syncedInformer{synced: false}, registered: ago(time.Hour)}
+	fresh := &informerTelemetry{informer: &syncedInformer{synced: true}, registered: ago(time.Minute)}
+
+	var restarted []string
+	restart := func(name string) int {
+		restarted = append(restarted, name)
+		return 1
+	}
+
+	// no activity in the cluster
+	informersMu.Lock()
+	informers = map[string]*informerTelemetry{"silent": silent, "syncing": syncing, "fresh": fresh}
+	informersMu.Unlock()
+	assert.Empty(t, detectStuckWatches(now, threshold, restart))
+
+	informersMu.Lock()
+	informers["active"] = active
+	informersMu.Unlock()
+	assert.Equal(t, []string{"silent"}, detectStuckWatches(now, threshold, restart))
+	assert.Equal(t, []string{"silent"}, restarted)
+	stats := GetInformersStats()
+	assert.True(t, stats["silent"].Stuck)
+	assert.Equal(t, int64(1), stats["silent"].StuckWatchRestarts)
+	assert.False(t, stats["active"].Stuck)
+
+	// not restarted again before another threshold
+	assert.Empty(t, detectStuckWatches(now.Add(time.Minute), threshold, restart))
+
+	// unstuck by the next watch event
+	silent.touchWatch()
+	assert.False(t, GetInformersStats()["silent"].Stuck)
+}
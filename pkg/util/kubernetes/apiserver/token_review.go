@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
+)
+
+const (
+	// tokenReviewCacheDuration is how long a reviewed token is trusted
+	tokenReviewCacheDuration = time.Minute
+	// tokenReviewFailureCacheDuration is how long a rejected token is
+	// rejected without another review
+	tokenReviewFailureCacheDuration = 10 * time.Second
+	// tokenReviewQPS and tokenReviewBurst limit the TokenReviews created for
+	// the tokens missing from the cache
+	tokenReviewQPS       = 5
+	tokenReviewBurst     = 20
+	serviceAccountPrefix = "system:serviceaccount:"
+)
+
+var errTokenReviewThrottled = errors.New("too many tokens to review, retry later")
+
+// tokenReview is the authentication.k8s.io/v1 TokenReview, with the audiences
+// added in kubernetes 1.13 missing from the vendored API
+type tokenReview struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Spec       tokenReviewSpec   `json:"spec"`
+	Status     tokenReviewStatus `json:"status,omitempty"`
+}
+
+type tokenReviewSpec struct {
+	Token     string   `json:"token"`
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+type tokenReviewStatus struct {
+	Authenticated bool     `json:"authenticated,omitempty"`
+	User          userInfo `json:"user,omitempty"`
+	Audiences     []string `json:"audiences,omitempty"`
+	Error         string   `json:"error,omitempty"`
+}
+
+type userInfo struct {
+	Username string `json:"username,omitempty"`
+}
+
+// serviceAccountTokenValidator validates the projected service account tokens
+// of the cluster check runners and the node agents with TokenReviews
+type serviceAccountTokenValidator struct {
+	client          rest.Interface
+	limiter         flowcontrol.RateLimiter
+	audience        string
+	allowedAccounts map[string]bool
+	// allowedNamespace allows all the service accounts of a namespace when
+	// no service account is listed
+	allowedNamespace string
+}
+
+// NewServiceAccountTokenValidator returns the validator of the service account
+// tokens configured with cluster_agent.token_review, for util.SetDCATokenValidator
+func NewServiceAccountTokenValidator() (func(token string) error, error) {
+	apiCl, err := GetAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &serviceAccountTokenValidator{
+		client:          apiCl.Cl.AuthenticationV1().RESTClient(),
+		limiter:         flowcontrol.NewTokenBucketRateLimiter(tokenReviewQPS, tokenReviewBurst),
+		audience:        config.Datadog.GetString("cluster_agent.token_review.audience"),
+		allowedAccounts: make(map[string]bool),
+	}
+	for _, account := range config.Datadog.GetStringSlice("cluster_agent.token_review.allowed_service_accounts") {
+		if strings.Count(account, ":") != 1 {
+			return nil, fmt.Errorf("invalid service account %q, expected <namespace>:<name>", account)
+		}
+		v.allowedAccounts[serviceAccountPrefix+account] = true
+	}
+	if len(v.allowedAccounts) == 0 {
+		v.allowedNamespace = common.GetResourcesNamespace()
+	}
+	return v.validate, nil
+}
+
+// validate reviews a token. The tokens reviewed successfully are cached, the
+// rejected ones too, briefly, and the reviews are rate limited, not to create
+// a TokenReview for every request of a client sending an invalid token.
+func (v *serviceAccountTokenValidator) validate(token string) error {
+	hash := sha256.Sum256([]byte(token))
+	cacheKey := cache.BuildAgentKey("tokenreview", hex.EncodeToString(hash[:]))
+	if cached, found := cache.Cache.Get(cacheKey); found {
+		if err, ok := cached.(error); ok {
+			return err
+		}
+		return nil
+	}
+
+	if !v.limiter.TryAccept() {
+		return errTokenReviewThrottled
+	}
+	status, err := v.review(token)
+	if err != nil {
+		return err
+	}
+	if err := v.checkStatus(status); err != nil {
+		cache.Cache.Set(cacheKey, err, tokenReviewFailureCacheDuration)
+		return err
+	}
+
+	cache.Cache.Set(cacheKey, struct{}{}, tokenReviewCacheDuration)
+	return nil
+}
+
+func (v *serviceAccountTokenValidator) checkStatus(status tokenReviewStatus) error {
+	if !status.Authenticated {
+		return fmt.Errorf("the token isn't authenticated: %s", status.Error)
+	}
+	if !containsString(status.Audiences, v.audience) {
+		return fmt.Errorf("the token isn't valid for the audience %s", v.audience)
+	}
+	return v.checkAccount(status.User.Username)
+}
+
+func (v *serviceAccountTokenValidator) checkAccount(username string) error {
+	if !strings.HasPrefix(username, serviceAccountPrefix) {
+		return fmt.Errorf("%s isn't a service account", username)
+	}
+	if v.allowedAccounts[username] {
+		return nil
+	}
+	if v.allowedNamespace != "" && strings.HasPrefix(username, serviceAccountPrefix+v.allowedNamespace+":") {
+		return nil
+	}
+	return fmt.Errorf("the service account %s isn't allowed", strings.TrimPrefix(username, serviceAccountPrefix))
+}
+
+// review creates a TokenReview, sent in JSON as the vendored API doesn't know
+// about the audiences
+func (v *serviceAccountTokenValidator) review(token string) (tokenReviewStatus, error) {
+	body, err := json.Marshal(tokenReview{
+		APIVersion: "authentication.k8s.io/v1",
+		Kind:       "TokenReview",
+		Spec:       tokenReviewSpec{Token: token, Audiences: []string{v.audience}},
+	})
+	if err != nil {
+		return tokenReviewStatus{}, err
+	}
+
+	raw, err := v.client.Post().
+		Resource("tokenreviews").
+		SetHeader("Content-Type", jsonContentType).
+		SetHeader("Accept", jsonContentType).
+		Body(body).
+		Do().
+		Raw()
+	if err != nil {
+		return tokenReviewStatus{}, fmt.Errorf("unable to review the token: %v", err)
+	}
+
+	var review tokenReview
+	if err := json.Unmarshal(raw, &review); err != nil {
+		return tokenReviewStatus{}, fmt.Errorf("unable to decode the token review: %v", err)
+	}
+	return review.Status, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authentication/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func TestServiceAccountTokenValidator(t *testing.T) {
+	reviews := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reviews++
+		require.Equal(t, "/apis/authentication.k8s.io/v1/tokenreviews", r.URL.Path)
+		var review tokenReview
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&review))
+		require.Equal(t, []string{"datadog-cluster-agent"}, review.Spec.Audiences)
+
+		switch review.Spec.Token {
+		case "runner":
+			review.Status = tokenReviewStatus{Authenticated: true, User: userInfo{Username: "system:serviceaccount:datadog:clc-runner"}, Audiences: review.Spec.Audiences}
+		case "other-namespace", "allowed-account":
+			review.Status = tokenReviewStatus{Authenticated: true, User: userInfo{Username: "system:serviceaccount:default:clc-runner"}, Audiences: review.Spec.Audiences}
+		case "apiserver-audience":
+			review.Status = tokenReviewStatus{Authenticated: true, User: userInfo{Username: "system:serviceaccount:datadog:clc-runner"}, Audiences: []string{"https://kubernetes.default.svc"}}
+		default:
+			review.Status = tokenReviewStatus{Error: "invalid bearer token"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(review)
+	}))
+	defer server.Close()
+
+	client, err := rest.RESTClientFor(&rest.Config{
+		Host:    server.URL,
+		APIPath: "/apis",
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &authv1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs,
+		},
+	})
+	require.NoError(t, err)
+
+	v := &serviceAccountTokenValidator{
+		client:           client,
+		limiter:          flowcontrol.NewTokenBucketRateLimiter(1, 10),
+		audience:         "datadog-cluster-agent",
+		allowedAccounts:  map[string]bool{},
+		allowedNamespace: "datadog",
+	}
+	assert.NoError(t, v.validate("runner"))
+	assert.Error(t, v.validate("other-namespace"))
+	assert.Error(t, v.validate("apiserver-audience"))
+	assert.Error(t, v.validate("invalid"))
+	assert.Equal(t, 4, reviews)
+
+	// the reviewed tokens are cached, the rejected ones too
+	assert.NoError(t, v.validate("runner"))
+	assert.Error(t, v.validate("invalid"))
+	assert.Equal(t, 4, reviews)
+
+	v.allowedNamespace = ""
+	v.allowedAccounts["system:serviceaccount:default:clc-runner"] = true
+	assert.NoError(t, v.validate("allowed-account"))
+	assert.Equal(t, 5, reviews)
+
+	// the reviews are rate limited
+	v.limiter = flowcontrol.NewTokenBucketRateLimiter(0.001, 1)
+	assert.Error(t, v.validate("throttled-1"))
+	assert.Equal(t, errTokenReviewThrottled, v.validate("throttled-2"))
+	assert.Equal(t, 6, reviews)
+}
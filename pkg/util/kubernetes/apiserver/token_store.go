@@ -0,0 +1,197 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Storage backends of the tokens, selected with `kubernetes_token_store`
+const (
+	TokenStoreConfigMap = "configmap"
+	TokenStoreSecret    = "secret"
+)
+
+// TokenStore persists the tokens of the Agent, like the version of the latest
+// event collected, in the `datadogtoken` object of the resources namespace.
+type TokenStore interface {
+	// GetToken returns the value of a token. The error is ErrNotFound if the token
+	// or the object storing it doesn't exist, ErrOutdated if the token was updated
+	// more than tokenTimeout seconds ago.
+	GetToken(ctx context.Context, token string, tokenTimeout int64) (string, bool, error)
+	// UpdateToken sets the value of a token and its update timestamp
+	UpdateToken(ctx context.Context, token, tokenValue string) error
+}
+
+// GetTokenStore returns the TokenStore configured with `kubernetes_token_store`
+func (c *APIClient) GetTokenStore() (TokenStore, error) {
+	switch backend := config.Datadog.GetString("kubernetes_token_store"); backend {
+	case TokenStoreConfigMap:
+		return &configMapTokenStore{c}, nil
+	case TokenStoreSecret:
+		return &secretTokenStore{c}, nil
+	default:
+		return nil, fmt.Errorf("unknown kubernetes_token_store %q, must be %q or %q", backend, TokenStoreConfigMap, TokenStoreSecret)
+	}
+}
+
+// configMapTokenStore stores the tokens in the ConfigMap `configMapDCAToken`
+type configMapTokenStore struct {
+	c *APIClient
+}
+
+func (s *configMapTokenStore) GetToken(ctx context.Context, token string, tokenTimeout int64) (string, bool, error) {
+	namespace := common.GetResourcesNamespace()
+	tokenConfigMap, err := s.c.getConfigMap(ctx, namespace, configMapDCAToken)
+	if err != nil {
+		log.Debugf("Could not find the ConfigMap %s: %s", configMapDCAToken, err.Error())
+		return "", false, ErrNotFound
+	}
+	log.Infof("Found the ConfigMap %s", configMapDCAToken)
+
+	return getTokenFromData(tokenConfigMap.Data, "ConfigMap", token, tokenTimeout)
+}
+
+func (s *configMapTokenStore) UpdateToken(ctx context.Context, token, tokenValue string) error {
+	namespace := common.GetResourcesNamespace()
+	tokenConfigMap, err := s.c.getConfigMap(ctx, namespace, configMapDCAToken)
+	if err != nil {
+		return err
+	}
+	if tokenConfigMap.Data == nil {
+		tokenConfigMap.Data = make(map[string]string)
+	}
+	setTokenInData(tokenConfigMap.Data, token, tokenValue)
+
+	ctx, cancel := s.c.requestContext(ctx)
+	defer cancel()
+	err = doWithContext(ctx, func() error {
+		_, err := s.c.Cl.CoreV1().ConfigMaps(namespace).Update(tokenConfigMap)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	log.Debugf("Updated %s to %s in the ConfigMap %s", token, tokenValue, configMapDCAToken)
+	return nil
+}
+
+// secretTokenStore stores the tokens in the Secret `configMapDCAToken`, for the
+// clusters where the ConfigMaps are readable by too many users
+type secretTokenStore struct {
+	c *APIClient
+}
+
+func (s *secretTokenStore) GetToken(ctx context.Context, token string, tokenTimeout int64) (string, bool, error) {
+	namespace := common.GetResourcesNamespace()
+	tokenSecret, err := s.c.getSecret(ctx, namespace, configMapDCAToken)
+	if err != nil {
+		log.Debugf("Could not find the Secret %s: %s", configMapDCAToken, err.Error())
+		return "", false, ErrNotFound
+	}
+	log.Infof("Found the Secret %s", configMapDCAToken)
+
+	data := make(map[string]string, len(tokenSecret.Data))
+	for k, v := range tokenSecret.Data {
+		data[k] = string(v)
+	}
+	return getTokenFromData(data, "Secret", token, tokenTimeout)
+}
+
+func (s *secretTokenStore) UpdateToken(ctx context.Context, token, tokenValue string) error {
+	namespace := common.GetResourcesNamespace()
+	tokenSecret, err := s.c.getSecret(ctx, namespace, configMapDCAToken)
+	if err != nil {
+		return err
+	}
+	data := make(map[string]string)
+	setTokenInData(data, token, tokenValue)
+	if tokenSecret.Data == nil {
+		tokenSecret.Data = make(map[string][]byte)
+	}
+	for k, v := range data {
+		tokenSecret.Data[k] = []byte(v)
+	}
+
+	ctx, cancel := s.c.requestContext(ctx)
+	defer cancel()
+	err = doWithContext(ctx, func() error {
+		_, err := s.c.Cl.CoreV1().Secrets(namespace).Update(tokenSecret)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	log.Debugf("Updated %s in the Secret %s", token, configMapDCAToken)
+	return nil
+}
+
+func (c *APIClient) getSecret(ctx context.Context, namespace, name string) (*v1.Secret, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	var secret *v1.Secret
+	err := doWithContext(ctx, func() (err error) {
+		secret, err = c.Cl.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+		return err
+	})
+	return secret, err
+}
+
+// getTokenFromData returns the value of a token from the data of the object
+// storing it, if its timestamp is less than tokenTimeout old.
+func getTokenFromData(data map[string]string, kind, token string, tokenTimeout int64) (string, bool, error) {
+	eventTokenKey := fmt.Sprintf("%s.%s", token, tokenKey)
+	tokenValue, found := data[eventTokenKey]
+	if !found {
+		log.Errorf("%s was not found in the %s %s", eventTokenKey, kind, configMapDCAToken)
+		return "", found, ErrNotFound
+	}
+	log.Infof("%s is %q", token, tokenValue)
+
+	eventTokenTS := fmt.Sprintf("%s.%s", token, tokenTime)
+	tokenTimeStr, set := data[eventTokenTS] // This is so we can have one timestamp per token
+
+	if !set {
+		log.Debugf("Could not find timestamp associated with %s in the %s %s. Refreshing.", eventTokenTS, kind, configMapDCAToken)
+		// We return ErrOutdated to reset the tokenValue and its timestamp as token's timestamp was not found.
+		return tokenValue, found, ErrOutdated
+	}
+
+	tokenTime, err := time.Parse(time.RFC822, tokenTimeStr)
+	if err != nil {
+		return "", found, log.Errorf("could not convert the timestamp associated with %s from the %s %s", token, kind, configMapDCAToken)
+	}
+	tokenAge := time.Now().Unix() - tokenTime.Unix()
+
+	if tokenAge > tokenTimeout {
+		log.Debugf("The tokenValue %s is outdated, refreshing the state", token)
+		return tokenValue, found, ErrOutdated
+	}
+	log.Debugf("Token %s was updated recently, using value to collect newer events.", token)
+	return tokenValue, found, nil
+}
+
+// setTokenInData sets the value of a token and its collected timestamp in the
+// data of the object storing it
+func setTokenInData(data map[string]string, token, tokenValue string) {
+	eventTokenKey := fmt.Sprintf("%s.%s", token, tokenKey)
+	data[eventTokenKey] = tokenValue
+
+	eventTokenTS := fmt.Sprintf("%s.%s", token, tokenTime)
+	data[eventTokenTS] = time.Now().Format(time.RFC822) // Timestamps in the ConfigMap should all use the type int.
+}
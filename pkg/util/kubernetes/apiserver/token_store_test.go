@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestGetTokenFromData(t *testing.T) {
+	recent := time.Now().Format(time.RFC822)
+	old := time.Now().Add(-2 * time.Hour).Format(time.RFC822)
+
+	_, found, err := getTokenFromData(map[string]string{}, "Secret", "event", 3600)
+	assert.Equal(t, ErrNotFound, err)
+	assert.False(t, found)
+
+	value, found, err := getTokenFromData(map[string]string{"event.tokenKey": "42"}, "Secret", "event", 3600)
+	assert.Equal(t, ErrOutdated, err)
+	assert.True(t, found)
+	assert.Equal(t, "42", value)
+
+	_, found, err = getTokenFromData(map[string]string{"event.tokenKey": "42", "event.tokenTimestamp": old}, "Secret", "event", 3600)
+	assert.Equal(t, ErrOutdated, err)
+	assert.True(t, found)
+
+	value, found, err = getTokenFromData(map[string]string{"event.tokenKey": "42", "event.tokenTimestamp": recent}, "Secret", "event", 3600)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "42", value)
+
+	data := map[string]string{}
+	setTokenInData(data, "event", "43")
+	value, _, err = getTokenFromData(data, "Secret", "event", 3600)
+	assert.NoError(t, err)
+	assert.Equal(t, "43", value)
+}
+
+func TestSecretTokenStore(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("kubernetes_token_store", "secret")
+	mockConfig.Set("kube_resources_namespace", "default")
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "datadogtoken", Namespace: "default"},
+		Data:       map[string][]byte{"event.tokenKey": []byte("42")},
+	}
+	cl, stop := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/default/secrets/datadogtoken" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == "PUT" {
+			secret = &v1.Secret{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(secret))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(secret)
+	})
+	defer stop()
+
+	store, err := cl.GetTokenStore()
+	require.NoError(t, err)
+	require.IsType(t, &secretTokenStore{}, store)
+
+	// No timestamp yet
+	value, found, err := store.GetToken(context.Background(), "event", 3600)
+	assert.Equal(t, ErrOutdated, err)
+	assert.True(t, found)
+	assert.Equal(t, "42", value)
+
+	err = store.UpdateToken(context.Background(), "event", "43")
+	require.NoError(t, err)
+	assert.Equal(t, "43", string(secret.Data["event.tokenKey"]))
+
+	value, found, err = store.GetToken(context.Background(), "event", 3600)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "43", value)
+
+	mockConfig.Set("kubernetes_token_store", "vault")
+	_, err = cl.GetTokenStore()
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// globalWatchTracker tracks the watches of the clients without timeout, used
+// by the informers
+var globalWatchTracker = newWatchTracker()
+
+// watchTracker keeps the response bodies of the open watch requests, by
+// informer name (see InformerName), so that a stuck watch can be
+// closed: its reflector then watches again from the last resource version.
+type watchTracker struct {
+	m       sync.Mutex
+	watches map[string]map[*trackedWatch]struct{}
+}
+
+func newWatchTracker() *watchTracker {
+	return &watchTracker{watches: make(map[string]map[*trackedWatch]struct{})}
+}
+
+// wrap returns a transport wrapper tracking the watches, chained after the
+// given one if any
+func (w *watchTracker) wrap(next func(http.RoundTripper) http.RoundTripper) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		if next != nil {
+			rt = next(rt)
+		}
+		return &watchTrackingRoundTripper{rt: rt, tracker: w}
+	}
+}
+
+// restart closes the open watches of an informer, and returns how many were
+func (w *watchTracker) restart(name string) int {
+	w.m.Lock()
+	watches := make([]*trackedWatch, 0, len(w.watches[name]))
+	for watch := range w.watches[name] {
+		watches = append(watches, watch)
+	}
+	w.m.Unlock()
+
+	for _, watch := range watches {
+		watch.Close()
+	}
+	return len(watches)
+}
+
+func (w *watchTracker) add(watch *trackedWatch) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.watches[watch.name] == nil {
+		w.watches[watch.name] = make(map[*trackedWatch]struct{})
+	}
+	w.watches[watch.name][watch] = struct{}{}
+}
+
+func (w *watchTracker) remove(watch *trackedWatch) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	delete(w.watches[watch.name], watch)
+	if len(w.watches[watch.name]) == 0 {
+		delete(w.watches, watch.name)
+	}
+}
+
+type watchTrackingRoundTripper struct {
+	rt      http.RoundTripper
+	tracker *watchTracker
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *watchTrackingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !isWatch(req) {
+		return resp, err
+	}
+	watch := &trackedWatch{ReadCloser: resp.Body, name: watchedInformerName(req.URL.Path), tracker: t.tracker}
+	t.tracker.add(watch)
+	resp.Body = watch
+	return resp, nil
+}
+
+// trackedWatch is the response body of a watch request, untracked when closed
+type trackedWatch struct {
+	io.ReadCloser
+	name    string
+	tracker *watchTracker
+	once    sync.Once
+}
+
+// Close implements io.Closer
+func (w *trackedWatch) Close() error {
+	w.once.Do(func() { w.tracker.remove(w) })
+	return w.ReadCloser.Close()
+}
+
+func isWatch(req *http.Request) bool {
+	watch := req.URL.Query().Get("watch")
+	return watch == "true" || watch == "1"
+}
+
+// watchedInformerName returns the name of the informers of a watched path:
+// the resource, followed by the namespace for the namespaced watches
+func watchedInformerName(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	// strip /api/v1 or /apis/<group>/<version>
+	switch {
+	case len(parts) > 2 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) > 3 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return path
+	}
+	if len(parts) == 3 && parts[0] == "namespaces" {
+		return InformerName(parts[2], parts[1])
+	}
+	return InformerName(parts[0], metav1.NamespaceAll)
+}
@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchedInformerName(t *testing.T) {
+	for path, name := range map[string]string{
+		"/api/v1/pods":                                                "pods",
+		"/api/v1/namespaces":                                          "namespaces",
+		"/api/v1/namespaces/default/pods":                             "pods/default",
+		"/apis/discovery.k8s.io/v1beta1/endpointslices":               "endpointslices",
+		"/apis/discovery.k8s.io/v1beta1/namespaces/ns/endpointslices": "endpointslices/ns",
+		"/version": "/version",
+	} {
+		assert.Equal(t, name, watchedInformerName(path), path)
+	}
+}
+
+func TestWatchTracker(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		if r.URL.Query().Get("watch") == "true" {
+			// stuck watch, never sends anything
+			<-r.Context().Done()
+		}
+	}))
+	defer ts.Close()
+
+	tracker := newWatchTracker()
+	client := &http.Client{Transport: tracker.wrap(nil)(http.DefaultTransport)}
+
+	resp, err := client.Get(ts.URL + "/api/v1/pods")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 0, tracker.restart("pods"))
+
+	resp, err = client.Get(ts.URL + "/api/v1/namespaces/default/pods?watch=true")
+	require.NoError(t, err)
+	done := make(chan error)
+	go func() {
+		_, err := ioutil.ReadAll(resp.Body)
+		done <- err
+	}()
+
+	assert.Equal(t, 0, tracker.restart("pods"))
+	assert.Equal(t, 1, tracker.restart("pods/default"))
+	<-done
+	assert.Empty(t, tracker.watches)
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// The work queues of the controllers are instrumented like the ones of
+// controller-runtime, the metrics are served on the `/metrics` endpoint of the
+// cluster agent and labelled with the name of the queue.
+var (
+	workqueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "workqueue",
+			Name:      "depth",
+			Help:      "Current depth of the work queue.",
+		},
+		[]string{"name"},
+	)
+	workqueueAdds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "workqueue",
+			Name:      "adds_total",
+			Help:      "Total number of items added to the work queue.",
+		},
+		[]string{"name"},
+	)
+	workqueueQueueDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "workqueue",
+			Name:      "queue_duration_seconds",
+			Help:      "How long an item stays in the work queue before being processed.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+		[]string{"name"},
+	)
+	workqueueWorkDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "workqueue",
+			Name:      "work_duration_seconds",
+			Help:      "How long processing an item of the work queue takes.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+		[]string{"name"},
+	)
+	workqueueRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "workqueue",
+			Name:      "retries_total",
+			Help:      "Total number of items requeued after a failed processing.",
+		},
+		[]string{"name"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(workqueueDepth)
+	prometheus.MustRegister(workqueueAdds)
+	prometheus.MustRegister(workqueueQueueDuration)
+	prometheus.MustRegister(workqueueWorkDuration)
+	prometheus.MustRegister(workqueueRetries)
+	// Only the queues created with a name report metrics
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
+
+// workqueueMetricsProvider implements workqueue.MetricsProvider
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.SummaryMetric {
+	return microsecondsObserver{workqueueQueueDuration.WithLabelValues(name)}
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.SummaryMetric {
+	return microsecondsObserver{workqueueWorkDuration.WithLabelValues(name)}
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.WithLabelValues(name)
+}
+
+// microsecondsObserver converts the durations observed by the work queues,
+// in microseconds, to seconds
+type microsecondsObserver struct {
+	h prometheus.Histogram
+}
+
+func (m microsecondsObserver) Observe(v float64) {
+	m.h.Observe(v / 1e6)
+}
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func readMetric(t *testing.T, m prometheus.Metric) *dto.Metric {
+	out := &dto.Metric{}
+	require.NoError(t, m.Write(out))
+	return out
+}
+
+func TestWorkqueueMetrics(t *testing.T) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test_queue")
+	defer queue.ShutDown()
+
+	queue.Add("default/redis")
+	assert.Equal(t, 1.0, readMetric(t, workqueueDepth.WithLabelValues("test_queue")).GetGauge().GetValue())
+	assert.Equal(t, 1.0, readMetric(t, workqueueAdds.WithLabelValues("test_queue")).GetCounter().GetValue())
+
+	key, _ := queue.Get()
+	assert.Equal(t, 0.0, readMetric(t, workqueueDepth.WithLabelValues("test_queue")).GetGauge().GetValue())
+	assert.Equal(t, uint64(1), readMetric(t, workqueueQueueDuration.WithLabelValues("test_queue")).GetHistogram().GetSampleCount())
+
+	queue.AddRateLimited(key)
+	queue.Done(key)
+	assert.Equal(t, uint64(1), readMetric(t, workqueueWorkDuration.WithLabelValues("test_queue")).GetHistogram().GetSampleCount())
+	assert.Equal(t, 1.0, readMetric(t, workqueueRetries.WithLabelValues("test_queue")).GetCounter().GetValue())
+}
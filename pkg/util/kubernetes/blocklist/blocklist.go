@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package blocklist applies the workload blocklist served by the Cluster
+// Agent: the node agents skip the logs and/or the container metrics of the
+// pods matching its rules.
+package blocklist
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+)
+
+// Kind is the kind of data excluded by a rule
+type Kind int
+
+const (
+	// Logs excludes the logs collection
+	Logs Kind = iota
+	// Metrics excludes the container metrics and the autodiscovered checks
+	Metrics
+)
+
+type rule struct {
+	namespace string
+	selector  labels.Selector
+	logs      bool
+	metrics   bool
+}
+
+// Blocklist holds the parsed rules of the workload blocklist
+type Blocklist struct {
+	rules []rule
+	// source holds the rules as served by the Cluster Agent
+	source []apiv1.BlocklistRule
+}
+
+// New parses the rules, it fails on invalid label selectors
+func New(rules []apiv1.BlocklistRule) (*Blocklist, error) {
+	b := &Blocklist{source: rules}
+	for _, r := range rules {
+		selector := labels.Everything()
+		if r.LabelSelector != "" {
+			var err error
+			selector, err = labels.Parse(r.LabelSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid label selector %q: %v", r.LabelSelector, err)
+			}
+		}
+		if r.Namespace == "" && selector.Empty() {
+			return nil, fmt.Errorf("a rule must have a namespace or a label selector")
+		}
+		b.rules = append(b.rules, rule{
+			namespace: r.Namespace,
+			selector:  selector,
+			logs:      r.Logs,
+			metrics:   r.Metrics,
+		})
+	}
+	return b, nil
+}
+
+// IsEmpty returns whether the blocklist has no rules
+func (b *Blocklist) IsEmpty() bool {
+	return b == nil || len(b.rules) == 0
+}
+
+// IsExcluded returns whether the kind of data of the pod in namespace with
+// podLabels is excluded
+func (b *Blocklist) IsExcluded(namespace string, podLabels map[string]string, kind Kind) bool {
+	if b.IsEmpty() {
+		return false
+	}
+	set := labels.Set(podLabels)
+	for _, r := range b.rules {
+		if (kind == Logs && !r.logs) || (kind == Metrics && !r.metrics) {
+			continue
+		}
+		if r.namespace != "" && r.namespace != namespace {
+			continue
+		}
+		if r.selector.Matches(set) {
+			return true
+		}
+	}
+	return false
+}
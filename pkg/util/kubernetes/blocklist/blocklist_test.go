@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package blocklist
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+)
+
+func TestIsExcluded(t *testing.T) {
+	b, err := New([]apiv1.BlocklistRule{
+		{Namespace: "kube-system", Logs: true},
+		{LabelSelector: "app in (noisy),tier!=frontend", Logs: true, Metrics: true},
+		{Namespace: "load-testing", LabelSelector: "app=bench", Metrics: true},
+	})
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		namespace string
+		labels    map[string]string
+		logs      bool
+		metrics   bool
+	}{
+		{"kube-system", nil, true, false},
+		{"default", nil, false, false},
+		{"default", map[string]string{"app": "noisy"}, true, true},
+		{"default", map[string]string{"app": "noisy", "tier": "frontend"}, false, false},
+		{"load-testing", map[string]string{"app": "bench"}, false, true},
+		{"default", map[string]string{"app": "bench"}, false, false},
+	} {
+		t.Run(fmt.Sprintf("case %d", i), func(t *testing.T) {
+			assert.Equal(t, tc.logs, b.IsExcluded(tc.namespace, tc.labels, Logs))
+			assert.Equal(t, tc.metrics, b.IsExcluded(tc.namespace, tc.labels, Metrics))
+		})
+	}
+}
+
+func TestNewInvalidRules(t *testing.T) {
+	_, err := New([]apiv1.BlocklistRule{{LabelSelector: "app in (", Logs: true}})
+	assert.Error(t, err)
+
+	// a rule without namespace nor selector would match every pod
+	_, err = New([]apiv1.BlocklistRule{{Logs: true}})
+	assert.Error(t, err)
+}
+
+func TestEmptyBlocklist(t *testing.T) {
+	var b *Blocklist
+	assert.True(t, b.IsEmpty())
+	assert.False(t, b.IsExcluded("default", nil, Logs))
+}
+
+type fakeRulesGetter struct {
+	rules []apiv1.BlocklistRule
+	err   error
+}
+
+func (f *fakeRulesGetter) GetWorkloadBlocklist() ([]apiv1.BlocklistRule, error) {
+	return f.rules, f.err
+}
+
+func TestRefresh(t *testing.T) {
+	defer set(nil)
+
+	getter := &fakeRulesGetter{rules: []apiv1.BlocklistRule{{Namespace: "kube-system", Logs: true}}}
+	refresh(getter)
+	assert.True(t, Get().IsExcluded("kube-system", nil, Logs))
+
+	// the blocklist is only replaced when its rules change
+	first := Get()
+	getter.rules = []apiv1.BlocklistRule{{Namespace: "kube-system", Logs: true}}
+	refresh(getter)
+	assert.True(t, first == Get())
+
+	// the previous blocklist is kept on errors and invalid rules
+	getter.rules, getter.err = nil, fmt.Errorf("unreachable")
+	refresh(getter)
+	assert.True(t, Get().IsExcluded("kube-system", nil, Logs))
+
+	getter.rules, getter.err = []apiv1.BlocklistRule{{LabelSelector: "app in ("}}, nil
+	refresh(getter)
+	assert.True(t, Get().IsExcluded("kube-system", nil, Logs))
+
+	getter.rules = nil
+	refresh(getter)
+	assert.True(t, Get().IsEmpty())
+}
@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package blocklist
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// initialFetchTimeout bounds the wait for the first blocklist at startup
+const initialFetchTimeout = 5 * time.Second
+
+var (
+	current   *Blocklist
+	currentM  sync.RWMutex
+	startOnce sync.Once
+)
+
+// rulesGetter is the part of the Cluster Agent client used to fetch the rules
+type rulesGetter interface {
+	GetWorkloadBlocklist() ([]apiv1.BlocklistRule, error)
+}
+
+// Start periodically fetches the blocklist from the Cluster Agent, it's a
+// noop if the Cluster Agent is disabled. It returns once the first blocklist
+// is fetched, or after initialFetchTimeout, so that the workloads scheduled
+// next are filtered.
+func Start() {
+	if !config.Datadog.GetBool("cluster_agent.enabled") {
+		return
+	}
+	startOnce.Do(func() {
+		interval := time.Duration(config.Datadog.GetInt("cluster_agent.workload_blocklist_refresh_interval")) * time.Second
+		fetched := make(chan struct{})
+		go func() {
+			fetch()
+			close(fetched)
+			for {
+				time.Sleep(interval)
+				fetch()
+			}
+		}()
+		select {
+		case <-fetched:
+		case <-time.After(initialFetchTimeout):
+			log.Warnf("Cannot get the workload blocklist from the cluster agent in %s, the workloads are filtered once it is fetched", initialFetchTimeout)
+		}
+	})
+}
+
+func fetch() {
+	dcaClient, err := clusteragent.GetClusterAgentClient()
+	if err != nil {
+		log.Debugf("Cannot get the workload blocklist, the cluster agent is unreachable: %v", err)
+		return
+	}
+	refresh(dcaClient)
+}
+
+// refresh replaces the current blocklist, the previous one is kept on errors.
+// The current blocklist is only replaced when its rules change, the users of
+// Get compare it to the one they applied.
+func refresh(client rulesGetter) {
+	rules, err := client.GetWorkloadBlocklist()
+	if err != nil {
+		log.Debugf("Cannot get the workload blocklist from the cluster agent: %v", err)
+		return
+	}
+	if previous := Get(); previous != nil && reflect.DeepEqual(previous.source, rules) {
+		return
+	}
+	b, err := New(rules)
+	if err != nil {
+		log.Warnf("Ignoring the invalid workload blocklist of the cluster agent: %v", err)
+		return
+	}
+	log.Infof("Got a new workload blocklist of %d rules from the cluster agent", len(rules))
+	set(b)
+}
+
+// Get returns the current blocklist, nil if it was never fetched
+func Get() *Blocklist {
+	currentM.RLock()
+	defer currentM.RUnlock()
+	return current
+}
+
+func set(b *Blocklist) {
+	currentM.Lock()
+	defer currentM.Unlock()
+	current = b
+}
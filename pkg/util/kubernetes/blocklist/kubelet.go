@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubelet
+
+package blocklist
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// IsPodExcluded returns whether the kind of data of the pod is excluded by
+// the current blocklist
+func IsPodExcluded(pod *kubelet.Pod, kind Kind) bool {
+	return Get().IsPodExcluded(pod, kind)
+}
+
+// IsEntityExcluded returns whether the kind of data of the container or pod
+// entity is excluded by the current blocklist
+func IsEntityExcluded(entityID string, kind Kind) bool {
+	return Get().IsEntityExcluded(entityID, kind)
+}
+
+// IsPodExcluded returns whether the kind of data of the pod is excluded by
+// the blocklist
+func (b *Blocklist) IsPodExcluded(pod *kubelet.Pod, kind Kind) bool {
+	if b.IsEmpty() || pod == nil {
+		return false
+	}
+	return b.IsExcluded(pod.Metadata.Namespace, pod.Metadata.Labels, kind)
+}
+
+// IsEntityExcluded returns whether the kind of data of the container or pod
+// entity is excluded by the blocklist
+func (b *Blocklist) IsEntityExcluded(entityID string, kind Kind) bool {
+	if entityID == "" || b.IsEmpty() {
+		return false
+	}
+	ku, err := kubelet.GetKubeUtil()
+	if err != nil {
+		return false
+	}
+	pod, err := ku.GetPodForEntityID(entityID)
+	if err != nil {
+		log.Tracef("Cannot find the pod of %s: %v", entityID, err)
+		return false
+	}
+	return b.IsPodExcluded(pod, kind)
+}
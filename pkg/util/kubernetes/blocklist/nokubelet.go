@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build !kubelet
+
+package blocklist
+
+// IsEntityExcluded is a noop, pods are only known with kubelet support
+func IsEntityExcluded(entityID string, kind Kind) bool {
+	return false
+}
+
+// IsEntityExcluded is a noop, pods are only known with kubelet support
+func (b *Blocklist) IsEntityExcluded(entityID string, kind Kind) bool {
+	return false
+}
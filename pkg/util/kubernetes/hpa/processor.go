@@ -35,17 +35,25 @@ type ProcessorInterface interface {
 
 // Processor embeds the configuration to refresh metrics from Datadog and process HPA structs to ExternalMetrics.
 type Processor struct {
-	externalMaxAge time.Duration
-	datadogClient  DatadogClient
+	externalMaxAge    time.Duration
+	datadogClient     DatadogClient
+	maxQueriesPerCall int
+	// cache is nil unless `external_metrics_provider.cache_staleness` is set
+	cache *queryCache
 }
 
 // NewProcessor returns a new Processor
 func NewProcessor(datadogCl DatadogClient) (*Processor, error) {
 	externalMaxAge := math.Max(config.Datadog.GetFloat64("external_metrics_provider.max_age"), 3*config.Datadog.GetFloat64("external_metrics_provider.rollup"))
-	return &Processor{
-		externalMaxAge: time.Duration(externalMaxAge) * time.Second,
-		datadogClient:  datadogCl,
-	}, nil
+	p := &Processor{
+		externalMaxAge:    time.Duration(externalMaxAge) * time.Second,
+		datadogClient:     datadogCl,
+		maxQueriesPerCall: config.Datadog.GetInt("external_metrics_provider.max_queries_per_call"),
+	}
+	if staleness := config.Datadog.GetInt("external_metrics_provider.cache_staleness"); staleness > 0 {
+		p.cache = newQueryCache(time.Duration(staleness)*time.Second, p.queryDatadogExternalBatched)
+	}
+	return p, nil
 }
 
 // UpdateExternalMetrics does the validation and processing of the ExternalMetrics
@@ -107,7 +115,36 @@ func (p *Processor) validateExternalMetric(emList map[string]custommetrics.Exter
 		q := getKey(e.MetricName, e.Labels)
 		batch = append(batch, q)
 	}
-	return p.queryDatadogExternal(batch)
+	if p.cache != nil {
+		return p.cache.get(batch)
+	}
+	return p.queryDatadogExternalBatched(batch)
+}
+
+// queryDatadogExternalBatched queries the metrics with one call to Datadog per
+// `external_metrics_provider.max_queries_per_call` metrics. The error is the
+// one of the last failed call, the points of the other calls are returned.
+func (p *Processor) queryDatadogExternalBatched(metricNames []string) (map[string]Point, error) {
+	if p.maxQueriesPerCall <= 0 || len(metricNames) <= p.maxQueriesPerCall {
+		return p.queryDatadogExternal(metricNames)
+	}
+
+	var err error
+	processed := make(map[string]Point, len(metricNames))
+	for start := 0; start < len(metricNames); start += p.maxQueriesPerCall {
+		end := start + p.maxQueriesPerCall
+		if end > len(metricNames) {
+			end = len(metricNames)
+		}
+		points, batchErr := p.queryDatadogExternal(metricNames[start:end])
+		if batchErr != nil {
+			err = batchErr
+		}
+		for name, point := range points {
+			processed[name] = point
+		}
+	}
+	return processed, err
 }
 
 func invalidate(emList map[string]custommetrics.ExternalMetricValue) (invList map[string]custommetrics.ExternalMetricValue) {
@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2017 Datadog, Inc.
+
+// +build kubeapiserver
+
+package hpa
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var cacheRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "external_metrics_cache_requests",
+		Help: "Counter of external metrics requested from the query cache, by result (hit or miss)",
+	},
+	[]string{"result"},
+)
+
+func init() {
+	prometheus.MustRegister(cacheRequests)
+}
+
+// queriesCacheEvictionFactor is the number of staleness windows after which
+// a query that is not requested anymore, for instance because the HPA was
+// deleted or the Cluster Agent is not the leader anymore, stops being refreshed
+const queriesCacheEvictionFactor = 3
+
+type cacheEntry struct {
+	point      Point
+	fetched    time.Time
+	lastAccess time.Time
+}
+
+// queryCache caches the points of the external metrics queries. The cached
+// points are served for a staleness window after being fetched, and refreshed
+// in the background by batches before expiring, so that the processing of the
+// HPAs doesn't wait for Datadog.
+type queryCache struct {
+	m         sync.Mutex
+	entries   map[string]*cacheEntry
+	staleness time.Duration
+	// query fetches the points of the queries from Datadog, by batches
+	query  func(queries []string) (map[string]Point, error)
+	stopCh chan struct{}
+}
+
+// newQueryCache returns a running queryCache
+func newQueryCache(staleness time.Duration, query func(queries []string) (map[string]Point, error)) *queryCache {
+	c := &queryCache{
+		entries:   make(map[string]*cacheEntry),
+		staleness: staleness,
+		query:     query,
+		stopCh:    make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// get returns the points of the queries, from the cache if they were fetched
+// within the staleness window, from Datadog otherwise
+func (c *queryCache) get(queries []string) (map[string]Point, error) {
+	now := time.Now()
+	points := make(map[string]Point, len(queries))
+	var misses []string
+
+	c.m.Lock()
+	for _, q := range queries {
+		e, found := c.entries[q]
+		if found && now.Sub(e.fetched) < c.staleness {
+			e.lastAccess = now
+			points[q] = e.point
+			cacheRequests.WithLabelValues("hit").Inc()
+			continue
+		}
+		misses = append(misses, q)
+		cacheRequests.WithLabelValues("miss").Inc()
+	}
+	c.m.Unlock()
+
+	if len(misses) == 0 {
+		return points, nil
+	}
+	fetched, err := c.query(misses)
+	c.store(fetched, now, true)
+	for q, point := range fetched {
+		points[q] = point
+	}
+	return points, err
+}
+
+// store caches the valid points. The invalid ones are queried again on the
+// next request.
+func (c *queryCache) store(points map[string]Point, fetched time.Time, accessed bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for q, point := range points {
+		if !point.valid {
+			continue
+		}
+		e, found := c.entries[q]
+		if !found {
+			e = &cacheEntry{lastAccess: fetched}
+			c.entries[q] = e
+		}
+		if accessed {
+			e.lastAccess = fetched
+		}
+		e.point = point
+		e.fetched = fetched
+	}
+}
+
+func (c *queryCache) run() {
+	ticker := time.NewTicker(c.staleness / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.refresh()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// refresh queries again the points fetched more than half a staleness window
+// ago, and evicts the queries not requested anymore
+func (c *queryCache) refresh() {
+	now := time.Now()
+	var queries []string
+
+	c.m.Lock()
+	for q, e := range c.entries {
+		if now.Sub(e.lastAccess) > queriesCacheEvictionFactor*c.staleness {
+			delete(c.entries, q)
+			continue
+		}
+		if now.Sub(e.fetched) >= c.staleness/2 {
+			queries = append(queries, q)
+		}
+	}
+	c.m.Unlock()
+
+	if len(queries) == 0 {
+		return
+	}
+	log.Debugf("Refreshing %d cached external metrics queries", len(queries))
+	fetched, err := c.query(queries)
+	if err != nil {
+		log.Debugf("Could not refresh all the cached external metrics: %v", err)
+	}
+	c.store(fetched, now, false)
+}
+
+func (c *queryCache) stop() {
+	close(c.stopCh)
+}
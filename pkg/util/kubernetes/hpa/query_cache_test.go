@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2017 Datadog, Inc.
+
+// +build kubeapiserver
+
+package hpa
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/zorkian/go-datadog-api.v2"
+)
+
+// fakeQuery returns valid points for the queries, but the ones starting with
+// "nodata"
+type fakeQuery struct {
+	calls [][]string
+}
+
+func (f *fakeQuery) query(queries []string) (map[string]Point, error) {
+	f.calls = append(f.calls, queries)
+	points := make(map[string]Point)
+	for _, q := range queries {
+		points[q] = Point{value: 1, timestamp: time.Now().Unix(), valid: !strings.HasPrefix(q, "nodata")}
+	}
+	return points, nil
+}
+
+func TestQueryCacheGet(t *testing.T) {
+	f := &fakeQuery{}
+	c := newQueryCache(time.Hour, f.query)
+	defer c.stop()
+
+	points, err := c.get([]string{"a{*}", "nodata{*}"})
+	require.NoError(t, err)
+	assert.Len(t, points, 2)
+	assert.True(t, points["a{*}"].valid)
+	assert.Equal(t, [][]string{{"a{*}", "nodata{*}"}}, f.calls)
+
+	// a{*} is cached, nodata{*} is queried again
+	points, err = c.get([]string{"a{*}", "nodata{*}", "b{*}"})
+	require.NoError(t, err)
+	assert.Len(t, points, 3)
+	assert.Equal(t, []string{"nodata{*}", "b{*}"}, f.calls[1])
+
+	// stale points are queried again
+	c.entries["a{*}"].fetched = time.Now().Add(-2 * time.Hour)
+	_, err = c.get([]string{"a{*}"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a{*}"}, f.calls[2])
+}
+
+func TestQueryCacheRefresh(t *testing.T) {
+	f := &fakeQuery{}
+	c := newQueryCache(time.Hour, f.query)
+	defer c.stop()
+
+	_, err := c.get([]string{"fresh{*}", "old{*}", "unused{*}"})
+	require.NoError(t, err)
+	c.entries["old{*}"].fetched = time.Now().Add(-40 * time.Minute)
+	c.entries["unused{*}"].lastAccess = time.Now().Add(-4 * time.Hour)
+
+	c.refresh()
+	require.Len(t, f.calls, 2)
+	assert.Equal(t, []string{"old{*}"}, f.calls[1])
+	assert.WithinDuration(t, time.Now(), c.entries["old{*}"].fetched, time.Minute)
+	assert.NotContains(t, c.entries, "unused{*}")
+	assert.Contains(t, c.entries, "fresh{*}")
+}
+
+func TestQueryDatadogExternalBatched(t *testing.T) {
+	var queries []string
+	p := &Processor{
+		maxQueriesPerCall: 2,
+		datadogClient: &fakeDatadogClient{
+			queryMetricsFunc: func(from, to int64, query string) ([]datadog.Series, error) {
+				queries = append(queries, query)
+				return nil, nil
+			},
+		},
+	}
+
+	points, err := p.queryDatadogExternalBatched([]string{"a{*}", "b{*}", "c{*}", "d{*}", "e{*}"})
+	assert.Error(t, err) // no series returned
+	assert.Len(t, points, 5)
+	assert.Len(t, queries, 3)
+	assert.Contains(t, queries[2], "e{*}")
+}
@@ -10,6 +10,7 @@ package kubelet
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"expvar"
 	"fmt"
 	"io/ioutil"
@@ -28,12 +29,14 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/clustername"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/resolver"
 	"github.com/DataDog/datadog-agent/pkg/util/retry"
 )
 
 const (
 	kubeletPodPath         = "/pods"
 	kubeletMetricsPath     = "/metrics"
+	kubeletStatsPath       = "/stats/summary"
 	authorizationHeaderKey = "Authorization"
 	podListCacheKey        = "KubeletPodListCacheKey"
 	unreadyAnnotation      = "ad.datadoghq.com/tolerate-unready"
@@ -500,6 +503,23 @@ func (ku *KubeUtil) GetRawMetrics() ([]byte, error) {
 	return data, nil
 }
 
+// GetStatsSummary returns the pod stats of the kubelet /stats/summary endpoint
+func (ku *KubeUtil) GetStatsSummary() (*StatsSummary, error) {
+	data, code, err := ku.QueryKubelet(kubeletStatsPath)
+	if err != nil {
+		return nil, fmt.Errorf("error performing kubelet query %s%s: %s", ku.kubeletApiEndpoint, kubeletStatsPath, err)
+	}
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d on %s%s: %s", code, ku.kubeletApiEndpoint, kubeletStatsPath, string(data))
+	}
+
+	var summary StatsSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
 func (ku *KubeUtil) setupKubeletApiEndpoint() error {
 	// HTTPS
 	ku.kubeletApiEndpoint = fmt.Sprintf("https://%s:%d", ku.kubeletHost, config.Datadog.GetInt("kubernetes_https_kubelet_port"))
@@ -603,19 +623,19 @@ func getKubeletHostFromConfig(kubeletHost string, ctx context.Context) ([]string
 	if kubeletIp == nil {
 		log.Debugf("Parsing kubernetes_kubelet_host: %s is a hostname, cached, trying to resolve it to ip...", kubeletHost)
 		hostnames = append(hostnames, kubeletHost)
-		ipAddrs, err := net.DefaultResolver.LookupIPAddr(ctx, kubeletHost)
+		ipAddrs, err := resolver.LookupIP(ctx, kubeletHost)
 		if err != nil {
 			log.Debugf("Cannot LookupIP hostname %s: %v", kubeletHost, err)
 		} else {
 			log.Debugf("kubernetes_kubelet_host: %s is resolved to: %v", kubeletHost, ipAddrs)
 			for _, ipAddr := range ipAddrs {
-				ips = append(ips, ipAddr.IP.String())
+				ips = append(ips, ipAddr.String())
 			}
 		}
 	} else {
 		log.Debugf("Parsed kubernetes_kubelet_host: %s is an address: %v, cached, trying to resolve it to hostname", kubeletHost, kubeletIp)
 		ips = append(ips, kubeletIp.String())
-		addrs, err := net.DefaultResolver.LookupAddr(ctx, kubeletHost)
+		addrs, err := resolver.LookupAddr(ctx, kubeletHost)
 		if err != nil {
 			log.Debugf("Cannot LookupHost ip %s: %v", kubeletHost, err)
 		} else {
@@ -640,13 +660,13 @@ func getKubeletHostFromDocker(ctx context.Context) ([]string, []string) {
 
 	log.Debugf("Trying to resolve host name %s provided by docker to ip...", dockerHost)
 	hostnames = append(hostnames, dockerHost)
-	ipAddrs, err := net.DefaultResolver.LookupIPAddr(ctx, dockerHost)
+	ipAddrs, err := resolver.LookupIP(ctx, dockerHost)
 	if err != nil {
 		log.Debugf("Cannot resolve host name %s, cached, provided by docker to ip: %s", dockerHost, err)
 	} else {
 		log.Debugf("Resolved host name %s provided by docker to %v", dockerHost, ipAddrs)
 		for _, ipAddr := range ipAddrs {
-			ips = append(ips, ipAddr.IP.String())
+			ips = append(ips, ipAddr.String())
 		}
 	}
 
@@ -870,6 +890,12 @@ func IsPodReady(pod *Pod) bool {
 	return false
 }
 
+// IsPodTerminated returns whether all the containers of the pod terminated,
+// and won't be restarted
+func IsPodTerminated(pod *Pod) bool {
+	return pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed"
+}
+
 // isPodStatic identifies whether a pod is static or not based on an annotation
 // Static pods can be sent to the kubelet from files or an http endpoint.
 func isPodStatic(pod *Pod) bool {
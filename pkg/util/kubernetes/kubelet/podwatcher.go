@@ -28,6 +28,13 @@ type PodWatcher struct {
 	lastSeen       map[string]time.Time
 	lastSeenReady  map[string]time.Time
 	tagsDigest     map[string]string
+	// terminated holds the terminated pods still in the pod list, they are
+	// reported once and then ignored until they leave the pod list
+	terminated map[string]struct{}
+	// lastPull is the time of the last pod list, the entities seen before
+	// weren't listed in it
+	lastPull         time.Time
+	purgedGeneration uint64
 }
 
 // NewPodWatcher creates a new watcher given an expiry duration
@@ -42,7 +49,10 @@ func NewPodWatcher(expiryDuration time.Duration, isWatchingTags bool) (*PodWatch
 		kubeUtil:       kubeutil,
 		lastSeen:       make(map[string]time.Time),
 		lastSeenReady:  make(map[string]time.Time),
+		terminated:     make(map[string]struct{}),
 		expiryDuration: expiryDuration,
+		// only the purges requested after its creation apply to the watcher
+		purgedGeneration: currentPurgeGeneration(),
 	}
 	if isWatchingTags {
 		watcher.tagsDigest = make(map[string]string)
@@ -75,11 +85,27 @@ func (w *PodWatcher) computeChanges(podList []*Pod) ([]*Pod, error) {
 
 	w.Lock()
 	defer w.Unlock()
+	w.lastPull = now
+	listedPods := make(map[string]struct{}, len(podList))
 	for _, pod := range podList {
 		podEntity := PodUIDToEntityName(pod.Metadata.UID)
+		listedPods[podEntity] = struct{}{}
 		newStaticPod := false
 		_, foundPod := w.lastSeen[podEntity]
 
+		// terminated pods stay in the pod list until they are deleted, they
+		// are not refreshed once seen so that they expire like deleted pods,
+		// and are not reported again after they expired
+		if _, found := w.terminated[podEntity]; found {
+			continue
+		}
+		if IsPodTerminated(pod) {
+			w.terminated[podEntity] = struct{}{}
+			if foundPod {
+				continue
+			}
+		}
+
 		if w.isWatchingTags() && !foundPod {
 			w.tagsDigest[podEntity] = digestPodMeta(pod.Metadata)
 		}
@@ -136,12 +162,19 @@ func (w *PodWatcher) computeChanges(podList []*Pod) ([]*Pod, error) {
 			updatedPods = append(updatedPods, pod)
 		}
 	}
+	for podEntity := range w.terminated {
+		if _, found := listedPods[podEntity]; !found {
+			delete(w.terminated, podEntity)
+		}
+	}
 	log.Debugf("Found %d changed pods out of %d", len(updatedPods), len(podList))
 	return updatedPods, nil
 }
 
 // Expire returns a list of entities (containers and pods)
-// that are not listed in the podlist anymore. It must be called
+// that are not listed in the podlist anymore, or terminated, since
+// the expiry duration. When PurgeDeletedPods was called, the entities
+// missing from the last podlist expire right away. It must be called
 // immediately after a PullChanges.
 // For containers, string is kubernetes container ID (with runtime name)
 // For pods, string is "kubernetes_pod://uid" format
@@ -151,9 +184,15 @@ func (w *PodWatcher) Expire() ([]string, error) {
 	defer w.Unlock()
 	var expiredContainers []string
 
+	purge := w.purgedGeneration != currentPurgeGeneration()
+	if purge {
+		log.Infof("Purging the deleted pods")
+		w.purgedGeneration = currentPurgeGeneration()
+	}
+
 	for id, lastSeen := range w.lastSeen {
 		// pod was removed from the pod list, we can safely cleanup everything
-		if now.Sub(lastSeen) > w.expiryDuration {
+		if now.Sub(lastSeen) > w.expiryDuration || (purge && lastSeen.Before(w.lastPull)) {
 			delete(w.lastSeen, id)
 			delete(w.lastSeenReady, id)
 			if w.isWatchingTags() {
@@ -173,6 +212,14 @@ func (w *PodWatcher) Expire() ([]string, error) {
 	return expiredContainers, nil
 }
 
+// PurgeRequested returns whether PurgeDeletedPods was called since the last
+// expiry of the watcher
+func (w *PodWatcher) PurgeRequested() bool {
+	w.Lock()
+	defer w.Unlock()
+	return w.purgedGeneration != currentPurgeGeneration()
+}
+
 // GetPodForEntityID finds the pod corresponding to an entity.
 // EntityIDs can be Docker container IDs or pod UIDs (prefixed).
 // Returns a nil pointer if not found.
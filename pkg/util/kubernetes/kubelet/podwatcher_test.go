@@ -9,6 +9,7 @@ package kubelet
 
 import (
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -417,6 +418,103 @@ func (suite *PodwatcherTestSuite) TestPodWatcherExpireWholePod() {
 	require.Len(suite.T(), watcher.tagsDigest, 5)
 }
 
+func (suite *PodwatcherTestSuite) TestPodWatcherExpireTerminatedPod() {
+	sourcePods, err := loadPodsFixture("./testdata/podlist_1.8-2.json")
+	require.Nil(suite.T(), err)
+	require.Len(suite.T(), sourcePods, 7)
+
+	watcher := &PodWatcher{
+		lastSeen:       make(map[string]time.Time),
+		lastSeenReady:  make(map[string]time.Time),
+		terminated:     make(map[string]struct{}),
+		expiryDuration: time.Minute,
+	}
+
+	_, err = watcher.computeChanges(sourcePods)
+	require.Nil(suite.T(), err)
+	require.Len(suite.T(), watcher.lastSeen, 12)
+
+	// Make everything old
+	for k := range watcher.lastSeen {
+		watcher.lastSeen[k] = watcher.lastSeen[k].Add(-2 * time.Minute)
+	}
+
+	// The terminated pod stays in the list but is not refreshed
+	terminatedPod := sourcePods[5]
+	require.Contains(suite.T(), terminatedPod.Metadata.UID, "d91aa43c-0769-11e8-afcc-000c29dea4f6")
+	terminatedPod.Status.Phase = "Succeeded"
+	require.True(suite.T(), IsPodTerminated(terminatedPod))
+
+	_, err = watcher.computeChanges(sourcePods)
+	require.Nil(suite.T(), err)
+
+	expire, err := watcher.Expire()
+	require.Nil(suite.T(), err)
+	assert.ElementsMatch(suite.T(), []string{
+		"kubernetes_pod://d91aa43c-0769-11e8-afcc-000c29dea4f6",
+		"docker://3e13513f94b41d23429804243820438fb9a214238bf2d4f384741a48b575670a",
+	}, expire)
+	require.Len(suite.T(), watcher.lastSeen, 10)
+
+	// The expired terminated pod is not reported again while it stays in the list
+	changes, err := watcher.computeChanges(sourcePods)
+	require.Nil(suite.T(), err)
+	assert.NotContains(suite.T(), changes, terminatedPod)
+	require.Len(suite.T(), watcher.lastSeen, 10)
+	require.Len(suite.T(), watcher.terminated, 1)
+
+	// It is forgotten once deleted from the list
+	_, err = watcher.computeChanges(append(sourcePods[0:5:5], sourcePods[6]))
+	require.Nil(suite.T(), err)
+	require.Len(suite.T(), watcher.terminated, 0)
+}
+
+func (suite *PodwatcherTestSuite) TestPodWatcherPurgeDeletedPods() {
+	// the purge requests are global, restore them for the other tests
+	defer atomic.StoreUint64(&purgeGeneration, currentPurgeGeneration())
+
+	sourcePods, err := loadPodsFixture("./testdata/podlist_1.8-2.json")
+	require.Nil(suite.T(), err)
+	require.Len(suite.T(), sourcePods, 7)
+
+	watcher := &PodWatcher{
+		lastSeen:         make(map[string]time.Time),
+		lastSeenReady:    make(map[string]time.Time),
+		expiryDuration:   5 * time.Minute,
+		purgedGeneration: currentPurgeGeneration(),
+	}
+
+	_, err = watcher.computeChanges(sourcePods)
+	require.Nil(suite.T(), err)
+
+	// Remove the last pods from the list, they are kept for the grace period
+	_, err = watcher.computeChanges(sourcePods[0:5])
+	require.Nil(suite.T(), err)
+	expire, err := watcher.Expire()
+	require.Nil(suite.T(), err)
+	require.Len(suite.T(), expire, 0)
+
+	// Purge them right away
+	PurgeDeletedPods()
+	require.True(suite.T(), watcher.PurgeRequested())
+	expire, err = watcher.Expire()
+	require.Nil(suite.T(), err)
+	assert.ElementsMatch(suite.T(), []string{
+		"kubernetes_pod://d91aa43c-0769-11e8-afcc-000c29dea4f6",
+		"docker://3e13513f94b41d23429804243820438fb9a214238bf2d4f384741a48b575670a",
+		"kubernetes_pod://260c2b1d43b094af6d6b4ccba082c2db",
+	}, expire)
+	require.False(suite.T(), watcher.PurgeRequested())
+	require.Len(suite.T(), watcher.lastSeen, 9)
+
+	// The purge only applies once
+	_, err = watcher.computeChanges(sourcePods[0:4])
+	require.Nil(suite.T(), err)
+	expire, err = watcher.Expire()
+	require.Nil(suite.T(), err)
+	require.Len(suite.T(), expire, 0)
+}
+
 func (suite *PodwatcherTestSuite) TestPullChanges() {
 	mockConfig := config.Mock()
 
@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package kubelet
+
+import (
+	"sync/atomic"
+)
+
+// purgeGeneration is bumped by every purge request, each PodWatcher compares
+// it to the last generation it purged
+var purgeGeneration uint64
+
+// PurgeDeletedPods makes the pod watchers expire the deleted pods at their
+// next expiry, without waiting for the end of the grace period
+func PurgeDeletedPods() {
+	atomic.AddUint64(&purgeGeneration, 1)
+}
+
+func currentPurgeGeneration() uint64 {
+	return atomic.LoadUint64(&purgeGeneration)
+}
@@ -53,6 +53,7 @@ type ContainerSpec struct {
 	Image          string              `json:"image,omitempty"`
 	Ports          []ContainerPortSpec `json:"ports,omitempty"`
 	ReadinessProbe *ContainerProbe     `json:"readinessProbe,omitempty"`
+	Resources      *ContainerResources `json:"resources,omitempty"`
 }
 
 // ContainerSpec contains fields for unmarshalling a Pod.Spec.Containers.Ports
@@ -63,6 +64,12 @@ type ContainerPortSpec struct {
 	Protocol      string `json:"protocol"`
 }
 
+// ContainerResources contains fields for unmarshalling a Pod.Spec.Containers.Resources
+type ContainerResources struct {
+	Limits   map[string]string `json:"limits,omitempty"`
+	Requests map[string]string `json:"requests,omitempty"`
+}
+
 // ContainerProbe contains fields for unmarshalling a Pod.Spec.Containers.ReadinessProbe
 type ContainerProbe struct {
 	InitialDelaySeconds int `json:"initialDelaySeconds"`
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubelet
+
+package kubelet
+
+// StatsSummary contains fields for unmarshalling the kubelet /stats/summary
+// response, only the pod level stats are used
+type StatsSummary struct {
+	Pods []PodStats `json:"pods,omitempty"`
+}
+
+// PodStats contains fields for unmarshalling a StatsSummary.Pods
+type PodStats struct {
+	PodRef           PodReference    `json:"podRef"`
+	Network          *NetworkStats   `json:"network,omitempty"`
+	VolumeStats      []VolumeStats   `json:"volume,omitempty"`
+	EphemeralStorage *FsStats        `json:"ephemeral-storage,omitempty"`
+	Containers       []ContainerStat `json:"containers,omitempty"`
+}
+
+// PodReference contains fields for unmarshalling a PodStats.PodRef
+type PodReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	UID       string `json:"uid"`
+}
+
+// ContainerStat contains fields for unmarshalling a PodStats.Containers
+type ContainerStat struct {
+	Name string `json:"name"`
+}
+
+// NetworkStats contains fields for unmarshalling a PodStats.Network
+type NetworkStats struct {
+	InterfaceStats
+	Interfaces []InterfaceStats `json:"interfaces,omitempty"`
+}
+
+// InterfaceStats contains fields for unmarshalling a NetworkStats.Interfaces,
+// the counters are cumulative
+type InterfaceStats struct {
+	Name     string  `json:"name"`
+	RxBytes  *uint64 `json:"rxBytes,omitempty"`
+	RxErrors *uint64 `json:"rxErrors,omitempty"`
+	TxBytes  *uint64 `json:"txBytes,omitempty"`
+	TxErrors *uint64 `json:"txErrors,omitempty"`
+}
+
+// FsStats contains fields for unmarshalling a filesystem usage
+type FsStats struct {
+	AvailableBytes *uint64 `json:"availableBytes,omitempty"`
+	CapacityBytes  *uint64 `json:"capacityBytes,omitempty"`
+	UsedBytes      *uint64 `json:"usedBytes,omitempty"`
+	InodesFree     *uint64 `json:"inodesFree,omitempty"`
+	Inodes         *uint64 `json:"inodes,omitempty"`
+	InodesUsed     *uint64 `json:"inodesUsed,omitempty"`
+}
+
+// VolumeStats contains fields for unmarshalling a PodStats.VolumeStats
+type VolumeStats struct {
+	FsStats
+	Name   string        `json:"name,omitempty"`
+	PVCRef *PVCReference `json:"pvcRef,omitempty"`
+}
+
+// PVCReference contains fields for unmarshalling a VolumeStats.PVCRef
+type PVCReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
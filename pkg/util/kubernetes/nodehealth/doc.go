@@ -0,0 +1,13 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+/*
+Package nodehealth publishes the health of the node agent in a
+coordination.k8s.io Lease per Kubernetes node, in the namespace of the agent.
+The cluster agent aggregates the leases of every node in the
+kubernetes_apiserver check, to alert on the agents that are unhealthy or that
+stopped publishing, without relying on the Datadog backend.
+*/
+package nodehealth
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver,kubelet
+
+package nodehealth
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// healthPublisher is the part of the API server client used to publish the health
+type healthPublisher interface {
+	PublishNodeHealthWithContext(ctx context.Context, nodeName string, health apiserver.NodeAgentHealth) error
+}
+
+// Start periodically publishes the agent health in the health lease of its
+// node, for the cluster agent to report the silent agent failures. It's a noop
+// if `kubernetes_node_health_lease.enabled` is false.
+func Start(ctx context.Context) {
+	if !config.Datadog.GetBool("kubernetes_node_health_lease.enabled") {
+		return
+	}
+	interval := time.Duration(config.Datadog.GetInt("kubernetes_node_health_lease.interval")) * time.Second
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := publishOnce(ctx); err != nil {
+				log.Debugf("Cannot publish the agent health on the node: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func publishOnce(ctx context.Context) error {
+	ku, err := kubelet.GetKubeUtil()
+	if err != nil {
+		return err
+	}
+	nodeName, err := ku.GetNodename()
+	if err != nil {
+		return err
+	}
+	cl, err := apiserver.GetAPIClient()
+	if err != nil {
+		return err
+	}
+	return publish(ctx, cl, nodeName, time.Now())
+}
+
+// publish sends the current health, an agent whose health can't be queried
+// in time is reported unhealthy
+func publish(ctx context.Context, cl healthPublisher, nodeName string, now time.Time) error {
+	nodeHealth := apiserver.NodeAgentHealth{Timestamp: now.Unix()}
+	status, err := health.GetStatusNonBlocking()
+	if err != nil {
+		nodeHealth.Unhealthy = []string{"healthcheck"}
+	} else {
+		nodeHealth.Healthy = len(status.Unhealthy) == 0
+		nodeHealth.Unhealthy = status.Unhealthy
+		sort.Strings(nodeHealth.Unhealthy)
+	}
+	return cl.PublishNodeHealthWithContext(ctx, nodeName, nodeHealth)
+}
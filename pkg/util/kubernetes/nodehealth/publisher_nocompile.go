@@ -0,0 +1,15 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build !kubeapiserver !kubelet
+
+package nodehealth
+
+import (
+	"context"
+)
+
+// Start is a noop, publishing the health needs both the kubelet and the API server support
+func Start(ctx context.Context) {}
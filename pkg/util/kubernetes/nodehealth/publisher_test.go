@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build kubeapiserver,kubelet
+
+package nodehealth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+type fakePublisher struct {
+	nodeName string
+	health   apiserver.NodeAgentHealth
+}
+
+func (f *fakePublisher) PublishNodeHealthWithContext(ctx context.Context, nodeName string, health apiserver.NodeAgentHealth) error {
+	f.nodeName = nodeName
+	f.health = health
+	return nil
+}
+
+func TestPublish(t *testing.T) {
+	handle := health.Register("nodehealth-test")
+	defer handle.Deregister()
+
+	pub := &fakePublisher{}
+	now := time.Unix(1500000000, 0)
+	require.NoError(t, publish(context.Background(), pub, "node1", now))
+
+	assert.Equal(t, "node1", pub.nodeName)
+	assert.Equal(t, int64(1500000000), pub.health.Timestamp)
+	// a component is unhealthy until its first ping
+	assert.False(t, pub.health.Healthy)
+	assert.Contains(t, pub.health.Unhealthy, "nodehealth-test")
+}
@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package oracle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// declare these as vars not const to ease testing
+var (
+	metadataURL         = "http://169.254.169.254/opc"
+	timeout             = 300 * time.Millisecond
+	chassisAssetTagPath = "/sys/class/dmi/id/chassis_asset_tag"
+)
+
+// chassisAssetTag is the DMI chassis asset tag of the Oracle Cloud instances
+const chassisAssetTag = "OracleCloud.com"
+
+var errNotOracle = fmt.Errorf("not running on Oracle Cloud")
+
+// IsRunningOn returns whether the agent runs on an Oracle Cloud instance,
+// from its DMI chassis asset tag, so that the metadata api, whose address is
+// shared with other clouds, is only queried there
+func IsRunningOn() bool {
+	tag, err := ioutil.ReadFile(chassisAssetTagPath)
+	return err == nil && strings.TrimSpace(string(tag)) == chassisAssetTag
+}
+
+// instanceMetadata is the document served at /opc/v2/instance/
+type instanceMetadata struct {
+	ID                 string                       `json:"id"`
+	DisplayName        string                       `json:"displayName"`
+	Hostname           string                       `json:"hostname"`
+	Region             string                       `json:"region"`
+	CanonicalRegion    string                       `json:"canonicalRegionName"`
+	AvailabilityDomain string                       `json:"availabilityDomain"`
+	FaultDomain        string                       `json:"faultDomain"`
+	Shape              string                       `json:"shape"`
+	FreeformTags       map[string]string            `json:"freeformTags"`
+	DefinedTags        map[string]map[string]string `json:"definedTags"`
+}
+
+// GetHostname returns the hostname of the instance from the OCI metadata api
+func GetHostname() (string, error) {
+	if !IsRunningOn() {
+		return "", errNotOracle
+	}
+	hostname, err := getResponseWithMaxLength("/instance/hostname",
+		config.Datadog.GetInt("metadata_endpoints_max_hostname_size"))
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve hostname from Oracle Cloud: %s", err)
+	}
+	return hostname, nil
+}
+
+// GetHostAlias returns the OCID of the instance from the OCI metadata api
+func GetHostAlias() (string, error) {
+	if !IsRunningOn() {
+		return "", errNotOracle
+	}
+	id, err := getResponseWithMaxLength("/instance/id",
+		config.Datadog.GetInt("metadata_endpoints_max_hostname_size"))
+	if err != nil {
+		return "", fmt.Errorf("Oracle Cloud HostAliases: unable to query metadata endpoint: %s", err)
+	}
+	return id, nil
+}
+
+// GetTags returns the location, the shape, the freeform and the defined
+// tags of the instance from the OCI metadata api
+func GetTags() ([]string, error) {
+	if !IsRunningOn() {
+		return nil, errNotOracle
+	}
+	res, err := getResponse("/instance/")
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the instance metadata from Oracle Cloud: %s", err)
+	}
+	metadata := instanceMetadata{}
+	if err := json.Unmarshal([]byte(res), &metadata); err != nil {
+		return nil, fmt.Errorf("unable to parse the instance metadata from Oracle Cloud: %s", err)
+	}
+
+	var tags []string
+	for _, tag := range []struct{ name, value string }{
+		{"region", metadata.CanonicalRegion},
+		{"availability-domain", metadata.AvailabilityDomain},
+		{"fault-domain", metadata.FaultDomain},
+		{"instance-type", metadata.Shape},
+		{"instance-id", metadata.ID},
+		{"display-name", metadata.DisplayName},
+	} {
+		if tag.value != "" {
+			tags = append(tags, fmt.Sprintf("%s:%s", tag.name, tag.value))
+		}
+	}
+	if metadata.CanonicalRegion == "" && metadata.Region != "" {
+		tags = append(tags, fmt.Sprintf("region:%s", metadata.Region))
+	}
+
+	var userTags []string
+	for k, v := range metadata.FreeformTags {
+		userTags = append(userTags, fmt.Sprintf("%s:%s", k, v))
+	}
+	// the defined tags are prefixed with their namespace, like in the console
+	for namespace, defined := range metadata.DefinedTags {
+		for k, v := range defined {
+			userTags = append(userTags, fmt.Sprintf("%s.%s:%s", namespace, k, v))
+		}
+	}
+	sort.Strings(userTags)
+
+	return append(tags, userTags...), nil
+}
+
+func getResponseWithMaxLength(endpoint string, maxLength int) (string, error) {
+	result, err := getResponse(endpoint)
+	if err != nil {
+		return result, err
+	}
+	if len(result) > maxLength {
+		return "", fmt.Errorf("%v gave a response with length > to %v", endpoint, maxLength)
+	}
+	return result, err
+}
+
+// getResponse queries the v2 metadata endpoint, which requires the
+// "Authorization: Bearer Oracle" header, and falls back to the legacy v1
+// endpoint, without header, on the instances not serving the v2 yet
+func getResponse(endpoint string) (string, error) {
+	res, status, err := doGet(metadataURL+"/v2"+endpoint, true)
+	if err == nil && status == http.StatusNotFound {
+		res, status, err = doGet(metadataURL+"/v1"+endpoint, false)
+	}
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("status code %d trying to GET %s", status, endpoint)
+	}
+	// Some cloud platforms will respond with an empty body, causing the agent to assume a faulty hostname
+	if len(res) == 0 {
+		return "", fmt.Errorf("empty response body")
+	}
+	return res, nil
+}
+
+func doGet(url string, v2 bool) (string, int, error) {
+	client := http.Client{
+		Timeout: timeout,
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	if v2 {
+		req.Header.Add("Authorization", "Bearer Oracle")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer res.Body.Close()
+
+	all, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("error while reading response from Oracle Cloud metadata endpoint: %s", err)
+	}
+	return string(all), res.StatusCode, nil
+}
+
+// HostnameProvider Oracle Cloud implementation of the HostnameProvider
+func HostnameProvider() (string, error) {
+	return GetHostname()
+}
@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package oracle
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain runs the tests as on an Oracle Cloud instance, with a mocked DMI
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "oracle-dmi")
+	if err != nil {
+		panic(err)
+	}
+	chassisAssetTagPath = filepath.Join(dir, "dmi")
+	if err := ioutil.WriteFile(chassisAssetTagPath, []byte("OracleCloud.com\n"), 0644); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestNotRunningOn(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+	defer func(path string) { chassisAssetTagPath = path }(chassisAssetTagPath)
+
+	for _, path := range []string{"other", "missing"} {
+		chassisAssetTagPath = filepath.Join(filepath.Dir(chassisAssetTagPath), path)
+		if path == "other" {
+			require.NoError(t, ioutil.WriteFile(chassisAssetTagPath, []byte("Amazon EC2\n"), 0644))
+		}
+		assert.False(t, IsRunningOn())
+		_, err := GetHostname()
+		assert.Equal(t, errNotOracle, err)
+		_, err = GetTags()
+		assert.Equal(t, errNotOracle, err)
+	}
+}
+
+func TestGetHostnameV2(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer Oracle" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "/opc/v2/instance/hostname", r.URL.Path)
+		io.WriteString(w, "instance-20200101")
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL + "/opc"
+
+	val, err := GetHostname()
+	require.NoError(t, err)
+	assert.Equal(t, "instance-20200101", val)
+}
+
+func TestGetHostAliasV1Fallback(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path != "/opc/v1/instance/id" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Empty(t, r.Header.Get("Authorization"))
+		io.WriteString(w, "ocid1.instance.oc1.iad.abc")
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL + "/opc"
+
+	val, err := GetHostAlias()
+	require.NoError(t, err)
+	assert.Equal(t, "ocid1.instance.oc1.iad.abc", val)
+	assert.Equal(t, []string{"/opc/v2/instance/id", "/opc/v1/instance/id"}, paths)
+}
+
+func TestGetTags(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/opc/v2/instance/", r.URL.Path)
+		io.WriteString(w, `{
+  "id": "ocid1.instance.oc1.iad.abc",
+  "displayName": "web-1",
+  "hostname": "web-1",
+  "region": "iad",
+  "canonicalRegionName": "us-ashburn-1",
+  "availabilityDomain": "Uocm:US-ASHBURN-AD-1",
+  "faultDomain": "FAULT-DOMAIN-2",
+  "shape": "VM.Standard2.1",
+  "freeformTags": {"team": "web"},
+  "definedTags": {"Operations": {"CostCenter": "42"}}
+}`)
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL + "/opc"
+
+	tags, err := GetTags()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"region:us-ashburn-1",
+		"availability-domain:Uocm:US-ASHBURN-AD-1",
+		"fault-domain:FAULT-DOMAIN-2",
+		"instance-type:VM.Standard2.1",
+		"instance-id:ocid1.instance.oc1.iad.abc",
+		"display-name:web-1",
+		"Operations.CostCenter:42",
+		"team:web",
+	}, tags)
+}
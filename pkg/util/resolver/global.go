@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package resolver
+
+import (
+	"context"
+	"expvar"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+var (
+	globalResolver *Resolver
+	globalOnce     sync.Once
+
+	stats = expvar.NewMap("dnsResolver")
+)
+
+// Get returns the resolver shared by the agent components, configured by the
+// `dns_resolver` settings
+func Get() *Resolver {
+	globalOnce.Do(func() {
+		globalResolver = NewResolver(
+			config.Datadog.GetInt("dns_resolver.max_concurrent_lookups"),
+			config.Datadog.GetDuration("dns_resolver.cache_ttl")*time.Second,
+			config.Datadog.GetDuration("dns_resolver.negative_cache_ttl")*time.Second,
+		)
+	})
+	return globalResolver
+}
+
+// LookupHost returns the addresses of host with the shared resolver
+func LookupHost(ctx context.Context, host string) ([]string, error) {
+	return Get().LookupHost(ctx, host)
+}
+
+// LookupIP returns the IP addresses of host with the shared resolver
+func LookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	return Get().LookupIP(ctx, host)
+}
+
+// LookupAddr returns the names mapping to addr with the shared resolver
+func LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	return Get().LookupAddr(ctx, addr)
+}
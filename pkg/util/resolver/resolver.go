@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// Package resolver provides a shared DNS resolver with a bounded number of
+// concurrent lookups and a cache of both the successful and failed lookups,
+// so that a broken DNS server doesn't block every component of the agent.
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// maximum duration of a lookup, whatever the context of the callers
+	lookupTimeout = 10 * time.Second
+	// the expired entries are purged once the cache reaches this size
+	purgeThreshold = 1024
+)
+
+// entry is a cached lookup, done is closed once the lookup is complete
+type entry struct {
+	done    chan struct{}
+	results []string
+	err     error
+	expire  time.Time
+}
+
+// Resolver resolves hostnames and addresses. Concurrent lookups of the same
+// name are merged and their results cached for ttl, or negativeTTL if the
+// lookup failed. The Go resolver doesn't expose the records TTLs, ttl is an
+// upper bound of the time the agent keeps using a record after it changed.
+type Resolver struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+	sem         chan struct{}
+
+	m     sync.Mutex
+	cache map[string]*entry
+
+	// for testing purpose
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	lookupAddr func(ctx context.Context, addr string) ([]string, error)
+	now        func() time.Time
+}
+
+// NewResolver returns a new Resolver running at most maxConcurrent lookups at a time
+func NewResolver(maxConcurrent int, ttl, negativeTTL time.Duration) *Resolver {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &Resolver{
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		sem:         make(chan struct{}, maxConcurrent),
+		cache:       make(map[string]*entry),
+		lookupHost:  net.DefaultResolver.LookupHost,
+		lookupAddr:  net.DefaultResolver.LookupAddr,
+		now:         time.Now,
+	}
+}
+
+// LookupHost returns the addresses of host
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{host}, nil
+	}
+	return r.lookup(ctx, "host:"+host, func(ctx context.Context) ([]string, error) {
+		return r.lookupHost(ctx, host)
+	})
+}
+
+// LookupIP returns the IP addresses of host
+func (r *Resolver) LookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		// zoned IPv6 addresses can't be parsed, skip them
+		if ip := net.ParseIP(addr); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips, nil
+}
+
+// LookupAddr returns the names mapping to addr, a reverse lookup
+func (r *Resolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	return r.lookup(ctx, "addr:"+addr, func(ctx context.Context) ([]string, error) {
+		return r.lookupAddr(ctx, addr)
+	})
+}
+
+// DialContext returns a dial function resolving the hostnames with the
+// resolver, suitable for a http.Transport. The addresses are tried in order
+// until a connection succeeds.
+func (r *Resolver) DialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := r.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			var conn net.Conn
+			conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		if err == nil {
+			err = fmt.Errorf("no address found for %s", host)
+		}
+		return nil, err
+	}
+}
+
+// lookup returns the cached results of key, or runs fn once for all the
+// concurrent callers. The lookup isn't bound to the context of the callers,
+// a canceled caller doesn't fail the other ones and still fills the cache.
+func (r *Resolver) lookup(ctx context.Context, key string, fn func(ctx context.Context) ([]string, error)) ([]string, error) {
+	r.m.Lock()
+	e, found := r.cache[key]
+	if found {
+		select {
+		case <-e.done:
+			if r.now().Before(e.expire) {
+				r.m.Unlock()
+				stats.Add("Hits", 1)
+				return e.results, e.err
+			}
+			found = false
+		default:
+			// lookup in progress
+		}
+	}
+	if !found {
+		stats.Add("Misses", 1)
+		e = &entry{done: make(chan struct{})}
+		r.purge()
+		r.cache[key] = e
+		go r.resolve(e, fn)
+	}
+	r.m.Unlock()
+
+	select {
+	case <-e.done:
+		return e.results, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolve runs the lookup of the entry once a slot is available
+func (r *Resolver) resolve(e *entry, fn func(ctx context.Context) ([]string, error)) {
+	r.sem <- struct{}{}
+	defer func() { <-r.sem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	results, err := fn(ctx)
+
+	ttl := r.ttl
+	if err != nil {
+		stats.Add("Errors", 1)
+		ttl = r.negativeTTL
+	}
+	r.m.Lock()
+	e.results = results
+	e.err = err
+	e.expire = r.now().Add(ttl)
+	close(e.done)
+	r.m.Unlock()
+}
+
+// purge removes the expired entries once the cache grows too big, it must be
+// called with the lock held
+func (r *Resolver) purge() {
+	if len(r.cache) < purgeThreshold {
+		return
+	}
+	now := r.now()
+	for key, e := range r.cache {
+		select {
+		case <-e.done:
+			if !now.Before(e.expire) {
+				delete(r.cache, key)
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package resolver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	m   sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestResolver(maxConcurrent int, lookupHost func(ctx context.Context, host string) ([]string, error)) (*Resolver, *fakeClock) {
+	clock := &fakeClock{now: time.Now()}
+	r := NewResolver(maxConcurrent, time.Minute, 10*time.Second)
+	r.lookupHost = lookupHost
+	r.now = clock.Now
+	return r, clock
+}
+
+func TestLookupHostCache(t *testing.T) {
+	var calls int32
+	r, clock := newTestResolver(1, func(ctx context.Context, host string) ([]string, error) {
+		atomic.AddInt32(&calls, 1)
+		return []string{"10.0.0.1"}, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		addrs, err := r.LookupHost(context.Background(), "example.com")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	clock.Add(2 * time.Minute)
+	_, err := r.LookupHost(context.Background(), "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestLookupHostNegativeCache(t *testing.T) {
+	var calls int32
+	r, clock := newTestResolver(1, func(ctx context.Context, host string) ([]string, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.New("no such host")
+	})
+
+	_, err := r.LookupHost(context.Background(), "broken.example.com")
+	assert.Error(t, err)
+	_, err = r.LookupHost(context.Background(), "broken.example.com")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// failures are cached for a shorter time
+	clock.Add(15 * time.Second)
+	_, err = r.LookupHost(context.Background(), "broken.example.com")
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestLookupHostIP(t *testing.T) {
+	r, _ := newTestResolver(1, func(ctx context.Context, host string) ([]string, error) {
+		return nil, errors.New("unexpected lookup")
+	})
+	addrs, err := r.LookupHost(context.Background(), "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1"}, addrs)
+}
+
+func TestLookupHostCancellation(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+	r, _ := newTestResolver(1, func(ctx context.Context, host string) ([]string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []string{"10.0.0.1"}, nil
+	})
+
+	// the caller gives up while the lookup hangs
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := r.LookupHost(ctx, "slow.example.com")
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// a concurrent caller joins the lookup in progress instead of starting a new one
+	done := make(chan []string)
+	go func() {
+		addrs, _ := r.LookupHost(context.Background(), "slow.example.com")
+		done <- addrs
+	}()
+	close(release)
+	assert.Equal(t, []string{"10.0.0.1"}, <-done)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestLookupConcurrencyLimit(t *testing.T) {
+	var running, maxRunning int32
+	r, _ := newTestResolver(2, func(ctx context.Context, host string) ([]string, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return []string{"10.0.0.1"}, nil
+	})
+
+	var wg sync.WaitGroup
+	for _, host := range []string{"a.com", "b.com", "c.com", "d.com", "e.com"} {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			r.LookupHost(context.Background(), host)
+		}(host)
+	}
+	wg.Wait()
+	assert.True(t, atomic.LoadInt32(&maxRunning) <= 2)
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package supervisor
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	globalSupervisor = NewSupervisor()
+
+	supervisorExpvars = expvar.NewMap("supervisor")
+	panicsCount       = expvar.Map{}
+	restartsCount     = expvar.Map{}
+)
+
+func init() {
+	panicsCount.Init()
+	restartsCount.Init()
+	supervisorExpvars.Set("Panics", &panicsCount)
+	supervisorExpvars.Set("Restarts", &restartsCount)
+}
+
+// DefaultPolicy returns the restart policy configured in the agent configuration
+func DefaultPolicy() Policy {
+	return Policy{
+		Restart:     RestartOnPanic,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Duration(config.Datadog.GetInt("supervisor.max_backoff")) * time.Second,
+		MaxRestarts: config.Datadog.GetInt("supervisor.max_restarts"),
+		Window:      time.Duration(config.Datadog.GetInt("supervisor.restart_window")) * time.Second,
+	}
+}
+
+// Go starts a component with the default policy on the global supervisor
+func Go(name string, fn func()) {
+	GoWithPolicy(name, DefaultPolicy(), fn)
+}
+
+// GoWithPolicy starts a component with the given policy on the global supervisor
+func GoWithPolicy(name string, policy Policy, fn func()) {
+	if !config.Datadog.GetBool("supervisor.enabled") {
+		go fn()
+		return
+	}
+	if err := globalSupervisor.Go(name, policy, fn); err != nil {
+		log.Warnf("Could not supervise %s, running it unsupervised: %s", name, err)
+		go fn()
+	}
+}
+
+// Stop prevents the global supervisor from restarting components
+func Stop() {
+	globalSupervisor.Stop()
+}
+
+// GetStatus returns the status of the components of the global supervisor
+func GetStatus() []ComponentStatus {
+	return globalSupervisor.GetStatus()
+}
@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package supervisor
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RestartPolicy defines when a supervised component is restarted
+type RestartPolicy int
+
+const (
+	// RestartNever only recovers from the panic, the component is not restarted
+	RestartNever RestartPolicy = iota
+	// RestartOnPanic restarts the component if it panicked, a clean return stops it
+	RestartOnPanic
+	// RestartAlways restarts the component whenever it returns
+	RestartAlways
+)
+
+// Policy holds the restart configuration of a supervised component
+type Policy struct {
+	Restart RestartPolicy
+	// Backoff is the delay before the first restart, it doubles on every
+	// restart up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// A component restarting more than MaxRestarts times within Window
+	// is considered crash-looping and is not restarted anymore
+	MaxRestarts int
+	Window      time.Duration
+}
+
+// ComponentStatus exposes the state of a supervised component
+type ComponentStatus struct {
+	Name          string    `json:"name"`
+	Running       bool      `json:"running"`
+	CrashLooping  bool      `json:"crash_looping"`
+	Restarts      int       `json:"restarts"`
+	LastPanic     string    `json:"last_panic,omitempty"`
+	LastPanicTime time.Time `json:"last_panic_time,omitempty"`
+}
+
+type component struct {
+	status   ComponentStatus
+	policy   Policy
+	fn       func()
+	restarts []time.Time
+}
+
+// Supervisor runs long-running components in their own goroutine, recovers
+// from their panics and restarts them according to their Policy, so that a
+// single faulty component does not bring down the whole process.
+type Supervisor struct {
+	sync.RWMutex
+	components map[string]*component
+	stop       chan struct{}
+	stopped    bool
+}
+
+// NewSupervisor returns a new Supervisor
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		components: make(map[string]*component),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Go starts fn in a supervised goroutine. The name must be unique, starting
+// a component with the name of a running one returns an error.
+func (s *Supervisor) Go(name string, policy Policy, fn func()) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.stopped {
+		return fmt.Errorf("supervisor is stopped, cannot start %s", name)
+	}
+	if c, found := s.components[name]; found && c.status.Running {
+		return fmt.Errorf("component %s is already running", name)
+	}
+
+	c := &component{
+		status: ComponentStatus{Name: name, Running: true},
+		policy: policy,
+		fn:     fn,
+	}
+	s.components[name] = c
+	go s.supervise(c)
+	return nil
+}
+
+// Stop prevents any further restart. Components are expected to be stopped
+// through their own mechanism.
+func (s *Supervisor) Stop() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	close(s.stop)
+}
+
+// GetStatus returns the status of all supervised components
+func (s *Supervisor) GetStatus() []ComponentStatus {
+	s.RLock()
+	defer s.RUnlock()
+
+	statuses := make([]ComponentStatus, 0, len(s.components))
+	for _, c := range s.components {
+		statuses = append(statuses, c.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// supervise is the goroutine running the component until it should not be
+// restarted anymore
+func (s *Supervisor) supervise(c *component) {
+	backoff := c.policy.Backoff
+	defer s.setRunning(c, false)
+
+	for {
+		panicked := s.runOnce(c)
+		if !s.shouldRestart(c, panicked) {
+			return
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > c.policy.MaxBackoff {
+			backoff = c.policy.MaxBackoff
+		}
+	}
+}
+
+// runOnce runs the component and returns true if it panicked
+func (s *Supervisor) runOnce(c *component) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			log.Errorf("Component %s panicked: %v\n%s", c.status.Name, r, debug.Stack())
+
+			s.Lock()
+			c.status.LastPanic = fmt.Sprintf("%v", r)
+			c.status.LastPanicTime = time.Now()
+			s.Unlock()
+			panicsCount.Add(c.status.Name, 1)
+		}
+	}()
+	c.fn()
+	return false
+}
+
+// shouldRestart applies the restart policy and the crash-loop detection
+func (s *Supervisor) shouldRestart(c *component, panicked bool) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.stopped {
+		return false
+	}
+
+	switch c.policy.Restart {
+	case RestartNever:
+		return false
+	case RestartOnPanic:
+		if !panicked {
+			return false
+		}
+	}
+
+	now := time.Now()
+	recent := c.restarts[:0]
+	for _, t := range c.restarts {
+		if now.Sub(t) < c.policy.Window {
+			recent = append(recent, t)
+		}
+	}
+	c.restarts = recent
+
+	if len(c.restarts) >= c.policy.MaxRestarts {
+		log.Errorf("Component %s restarted %d times in %s, it is crash-looping and will not be restarted", c.status.Name, len(c.restarts), c.policy.Window)
+		c.status.CrashLooping = true
+		return false
+	}
+
+	c.restarts = append(c.restarts, now)
+	c.status.Restarts++
+	restartsCount.Add(c.status.Name, 1)
+	log.Warnf("Restarting component %s (restart %d)", c.status.Name, c.status.Restarts)
+	return true
+}
+
+func (s *Supervisor) setRunning(c *component, running bool) {
+	s.Lock()
+	defer s.Unlock()
+	c.status.Running = running
+}
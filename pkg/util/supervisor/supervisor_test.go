@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package supervisor
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPolicy(restart RestartPolicy) Policy {
+	return Policy{
+		Restart:     restart,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+		MaxRestarts: 3,
+		Window:      time.Minute,
+	}
+}
+
+func waitStopped(t *testing.T, s *Supervisor) ComponentStatus {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		statuses := s.GetStatus()
+		if len(statuses) == 1 && !statuses[0].Running {
+			return statuses[0]
+		}
+		time.Sleep(time.Millisecond)
+	}
+	require.FailNow(t, "component did not stop in time")
+	return ComponentStatus{}
+}
+
+func TestRecoverNoRestart(t *testing.T) {
+	s := NewSupervisor()
+	var runs int32
+	err := s.Go("test", testPolicy(RestartNever), func() {
+		atomic.AddInt32(&runs, 1)
+		panic("boom")
+	})
+	require.NoError(t, err)
+
+	status := waitStopped(t, s)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	assert.Equal(t, 0, status.Restarts)
+	assert.Equal(t, "boom", status.LastPanic)
+	assert.False(t, status.CrashLooping)
+}
+
+func TestRestartOnPanic(t *testing.T) {
+	s := NewSupervisor()
+	var runs int32
+	err := s.Go("test", testPolicy(RestartOnPanic), func() {
+		if atomic.AddInt32(&runs, 1) < 3 {
+			panic("boom")
+		}
+	})
+	require.NoError(t, err)
+
+	status := waitStopped(t, s)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+	assert.Equal(t, 2, status.Restarts)
+	assert.False(t, status.CrashLooping)
+}
+
+func TestCrashLoop(t *testing.T) {
+	s := NewSupervisor()
+	var runs int32
+	err := s.Go("test", testPolicy(RestartAlways), func() {
+		atomic.AddInt32(&runs, 1)
+		panic("boom")
+	})
+	require.NoError(t, err)
+
+	status := waitStopped(t, s)
+	// first run + MaxRestarts restarts
+	assert.Equal(t, int32(4), atomic.LoadInt32(&runs))
+	assert.Equal(t, 3, status.Restarts)
+	assert.True(t, status.CrashLooping)
+}
+
+func TestStopPreventsRestart(t *testing.T) {
+	s := NewSupervisor()
+	s.Stop()
+	err := s.Go("test", testPolicy(RestartAlways), func() {})
+	assert.Error(t, err)
+}
+
+func TestDuplicateName(t *testing.T) {
+	s := NewSupervisor()
+	block := make(chan struct{})
+	defer close(block)
+
+	require.NoError(t, s.Go("test", testPolicy(RestartNever), func() { <-block }))
+	assert.Error(t, s.Go("test", testPolicy(RestartNever), func() {}))
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build !windows
+
+package watchdog
+
+import (
+	"os"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// processSampler samples the usage of the agent process, the CPU being
+// averaged since the previous sample
+type processSampler struct {
+	lastCPU  float64
+	lastTime time.Time
+}
+
+func newProcessSampler() *processSampler {
+	return &processSampler{}
+}
+
+func (s *processSampler) sample() (Usage, error) {
+	p, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return Usage{}, err
+	}
+	times, err := p.Times()
+	if err != nil {
+		return Usage{}, err
+	}
+	mem, err := p.MemoryInfo()
+	if err != nil {
+		return Usage{}, err
+	}
+
+	now := time.Now()
+	cpu := times.User + times.System
+	usage := Usage{RSS: mem.RSS}
+	if !s.lastTime.IsZero() {
+		usage.CPU = cpuPercent(cpu-s.lastCPU, now.Sub(s.lastTime))
+	}
+	s.lastCPU, s.lastTime = cpu, now
+	return usage, nil
+}
+
+// cpuPercent returns the percent of a core used by cpuSeconds of CPU time
+// over elapsed
+func cpuPercent(cpuSeconds float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return cpuSeconds / elapsed.Seconds() * 100
+}
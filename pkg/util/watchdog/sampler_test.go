@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+// +build !windows
+
+package watchdog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPUPercent(t *testing.T) {
+	assert.Equal(t, 50.0, cpuPercent(5, 10*time.Second))
+	assert.Equal(t, 200.0, cpuPercent(20, 10*time.Second))
+	assert.Equal(t, 0.0, cpuPercent(1, 0))
+}
+
+func TestProcessSampler(t *testing.T) {
+	s := newProcessSampler()
+	usage, err := s.sample()
+	require.NoError(t, err)
+	assert.NotZero(t, usage.RSS)
+	// the first sample has no CPU reference
+	assert.Zero(t, usage.CPU)
+}
@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package watchdog
+
+import "errors"
+
+// processSampler isn't implemented on Windows, where gopsutil/process can't be
+// imported
+type processSampler struct{}
+
+func newProcessSampler() *processSampler {
+	return &processSampler{}
+}
+
+func (s *processSampler) sample() (Usage, error) {
+	return Usage{}, errors.New("the resource usage of the agent isn't sampled on Windows")
+}
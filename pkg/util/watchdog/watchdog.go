@@ -0,0 +1,232 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package watchdog
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// the usage must exceed a budget for shedSamples samples in a row to
+	// shed an action, not to react to a single spike before a GC
+	shedSamples = 2
+	// the usage must stay under restoreRatio of the budgets for
+	// restoreSamples samples in a row to restore the last shed action
+	restoreRatio   = 0.8
+	restoreSamples = 6
+
+	eventType = "Agent Resource Budget"
+	// senderID identifies the sender of the events, not to commit the
+	// default sender shared with the other components
+	senderID check.ID = "resource_budget"
+)
+
+var expvars = expvar.NewMap("resource_budget")
+
+// Action sheds some load of the agent, undone when its usage is back under
+// the budgets
+type Action struct {
+	// Name identifies the action in the events and the expvars
+	Name string
+	// Description completes "The agent ...", e.g. "reduced the frequency of
+	// the container checks"
+	Description string
+	Shed        func()
+	Restore     func()
+}
+
+// Usage is the resource usage of the agent process
+type Usage struct {
+	// CPU is in percent of a core, over the last sampling interval
+	CPU float64 `json:"cpu_percent"`
+	// RSS is in bytes
+	RSS uint64 `json:"rss"`
+}
+
+// Status exposes the state of the watchdog
+type Status struct {
+	Usage     Usage    `json:"usage"`
+	MaxCPU    float64  `json:"max_cpu_percent"`
+	MaxMemory uint64   `json:"max_memory"`
+	Shed      []string `json:"shed_actions"`
+}
+
+// Watchdog samples the CPU and the RSS of the agent against the budgets set
+// by resource_budget.max_cpu_percent and resource_budget.max_memory. While
+// one is exceeded for shedSamples samples in a row, it sheds the load with
+// its actions, one more per sample in their order. Once the usage is back
+// under the budgets, the actions are restored one by one in the reverse
+// order. Every action is reported as an event.
+type Watchdog struct {
+	maxCPU   float64
+	maxMem   uint64
+	interval time.Duration
+	actions  []Action
+	sample   func() (Usage, error)
+	report   func(metrics.Event)
+
+	m     sync.RWMutex
+	usage Usage
+	// level is the number of actions in effect
+	level int
+	// hot is the number of samples in a row over the budgets
+	hot int
+	// calm is the number of samples in a row under restoreRatio of the budgets
+	calm int
+}
+
+// NewWatchdog returns a watchdog shedding the load with the actions, in their
+// order
+func NewWatchdog(actions []Action) *Watchdog {
+	return &Watchdog{
+		maxCPU:   config.Datadog.GetFloat64("resource_budget.max_cpu_percent"),
+		maxMem:   uint64(config.Datadog.GetInt64("resource_budget.max_memory")),
+		interval: time.Duration(config.Datadog.GetInt64("resource_budget.check_interval")) * time.Second,
+		actions:  actions,
+		sample:   newProcessSampler().sample,
+		report:   sendEvent,
+	}
+}
+
+// Enabled returns whether a budget is set
+func (w *Watchdog) Enabled() bool {
+	return w.maxCPU > 0 || w.maxMem > 0
+}
+
+// Start samples the agent usage until ctx is done
+func (w *Watchdog) Start(ctx context.Context) {
+	if !w.Enabled() {
+		log.Debug("No resource budget set, the watchdog is disabled")
+		return
+	}
+	if w.interval <= 0 {
+		log.Warnf("Invalid resource_budget.check_interval %s, the watchdog is disabled", w.interval)
+		return
+	}
+	expvars.Set("Status", expvar.Func(func() interface{} { return w.Status() }))
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				usage, err := w.sample()
+				if err != nil {
+					log.Debugf("Could not sample the agent resource usage: %v", err)
+					continue
+				}
+				w.update(usage)
+			}
+		}
+	}()
+}
+
+// Status returns the last usage and the actions in effect
+func (w *Watchdog) Status() Status {
+	w.m.RLock()
+	defer w.m.RUnlock()
+	status := Status{Usage: w.usage, MaxCPU: w.maxCPU, MaxMemory: w.maxMem, Shed: []string{}}
+	for _, action := range w.actions[:w.level] {
+		status.Shed = append(status.Shed, action.Name)
+	}
+	return status
+}
+
+// update sheds or restores an action depending on the usage
+func (w *Watchdog) update(usage Usage) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.usage = usage
+
+	switch {
+	case w.over(usage, 1):
+		w.calm = 0
+		w.hot++
+		if w.hot < shedSamples {
+			return
+		}
+		if w.level == len(w.actions) {
+			log.Debugf("The agent is over its resource budget (%s), with no action left to shed", w.describe(usage))
+			return
+		}
+		action := w.actions[w.level]
+		w.level++
+		action.Shed()
+		log.Warnf("The agent is over its resource budget (%s), it %s", w.describe(usage), action.Description)
+		w.report(metrics.Event{
+			Title:          fmt.Sprintf("Datadog agent over its resource budget: %s", action.Name),
+			Text:           fmt.Sprintf("The agent is over its resource budget (%s), it %s.", w.describe(usage), action.Description),
+			AlertType:      metrics.EventAlertTypeWarning,
+			SourceTypeName: "System",
+			EventType:      eventType,
+			Tags:           []string{"action:" + action.Name, "shed:true"},
+		})
+	case w.over(usage, restoreRatio):
+		w.hot, w.calm = 0, 0
+	default:
+		w.hot = 0
+		w.calm++
+		if w.calm < restoreSamples || w.level == 0 {
+			return
+		}
+		w.calm = 0
+		w.level--
+		action := w.actions[w.level]
+		action.Restore()
+		log.Infof("The agent is back under its resource budget (%s), it no longer %s", w.describe(usage), action.Description)
+		w.report(metrics.Event{
+			Title:          fmt.Sprintf("Datadog agent back under its resource budget: %s", action.Name),
+			Text:           fmt.Sprintf("The agent is back under its resource budget (%s), it no longer %s.", w.describe(usage), action.Description),
+			AlertType:      metrics.EventAlertTypeSuccess,
+			SourceTypeName: "System",
+			EventType:      eventType,
+			Tags:           []string{"action:" + action.Name, "shed:false"},
+		})
+	}
+}
+
+// over returns whether the usage exceeds ratio of a budget
+func (w *Watchdog) over(usage Usage, ratio float64) bool {
+	if w.maxCPU > 0 && usage.CPU > w.maxCPU*ratio {
+		return true
+	}
+	return w.maxMem > 0 && float64(usage.RSS) > float64(w.maxMem)*ratio
+}
+
+func (w *Watchdog) describe(usage Usage) string {
+	cpu := fmt.Sprintf("CPU %.1f%%", usage.CPU)
+	if w.maxCPU > 0 {
+		cpu += fmt.Sprintf(" of %.1f%%", w.maxCPU)
+	}
+	mem := fmt.Sprintf("RSS %d MB", usage.RSS/1e6)
+	if w.maxMem > 0 {
+		mem += fmt.Sprintf(" of %d MB", w.maxMem/1e6)
+	}
+	return cpu + ", " + mem
+}
+
+func sendEvent(e metrics.Event) {
+	sender, err := aggregator.GetSender(senderID)
+	if err != nil {
+		log.Errorf("Error getting the resource budget sender: %v. Not sending the resource budget event", err)
+		return
+	}
+	sender.Event(e)
+	sender.Commit()
+}
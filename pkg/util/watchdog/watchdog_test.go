@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2019 Datadog, Inc.
+
+package watchdog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+type testActions struct {
+	calls  []string
+	events []metrics.Event
+}
+
+func (a *testActions) action(name string) Action {
+	return Action{
+		Name:        name,
+		Description: "did " + name,
+		Shed:        func() { a.calls = append(a.calls, "shed "+name) },
+		Restore:     func() { a.calls = append(a.calls, "restore "+name) },
+	}
+}
+
+func newTestWatchdog(a *testActions, maxCPU float64, maxMem uint64) *Watchdog {
+	return &Watchdog{
+		maxCPU:  maxCPU,
+		maxMem:  maxMem,
+		actions: []Action{a.action("first"), a.action("second")},
+		report:  func(e metrics.Event) { a.events = append(a.events, e) },
+	}
+}
+
+func TestWatchdogShedsInOrder(t *testing.T) {
+	a := &testActions{}
+	w := newTestWatchdog(a, 50, 0)
+
+	w.update(Usage{CPU: 10})
+	assert.Empty(t, a.calls)
+
+	// a single sample over the budget sheds nothing
+	w.update(Usage{CPU: 60})
+	w.update(Usage{CPU: 10})
+	w.update(Usage{CPU: 60})
+	assert.Empty(t, a.calls)
+
+	w.update(Usage{CPU: 60})
+	w.update(Usage{CPU: 70})
+	// no action left
+	w.update(Usage{CPU: 70})
+	assert.Equal(t, []string{"shed first", "shed second"}, a.calls)
+	assert.Equal(t, []string{"first", "second"}, w.Status().Shed)
+
+	require.Len(t, a.events, 2)
+	assert.Equal(t, "Datadog agent over its resource budget: first", a.events[0].Title)
+	assert.Equal(t, metrics.EventAlertTypeWarning, a.events[0].AlertType)
+	assert.Equal(t, "Agent Resource Budget", a.events[0].EventType)
+	assert.Contains(t, a.events[0].Text, "CPU 60.0% of 50.0%")
+	assert.Contains(t, a.events[1].Tags, "action:second")
+}
+
+func TestWatchdogRestoresInReverseOrder(t *testing.T) {
+	a := &testActions{}
+	w := newTestWatchdog(a, 0, 100e6)
+
+	for i := 0; i < shedSamples+1; i++ {
+		w.update(Usage{RSS: 150e6})
+	}
+	a.calls = nil
+
+	// between the restore ratio and the budget, nothing is restored
+	for i := 0; i < 2*restoreSamples; i++ {
+		w.update(Usage{RSS: 90e6})
+	}
+	assert.Empty(t, a.calls)
+
+	for i := 0; i < restoreSamples-1; i++ {
+		w.update(Usage{RSS: 50e6})
+	}
+	assert.Empty(t, a.calls)
+	w.update(Usage{RSS: 50e6})
+	assert.Equal(t, []string{"restore second"}, a.calls)
+
+	// a sample over the ratio starts the count again
+	w.update(Usage{RSS: 90e6})
+	for i := 0; i < restoreSamples; i++ {
+		w.update(Usage{RSS: 50e6})
+	}
+	assert.Equal(t, []string{"restore second", "restore first"}, a.calls)
+	assert.Empty(t, w.Status().Shed)
+
+	require.Len(t, a.events, 4)
+	assert.Equal(t, "Datadog agent back under its resource budget: first", a.events[3].Title)
+	assert.Equal(t, metrics.EventAlertTypeSuccess, a.events[3].AlertType)
+	assert.Contains(t, a.events[3].Text, "RSS 50 MB of 100 MB")
+}
+
+func TestWatchdogEnabled(t *testing.T) {
+	assert.False(t, (&Watchdog{}).Enabled())
+	assert.True(t, (&Watchdog{maxCPU: 50}).Enabled())
+	assert.True(t, (&Watchdog{maxMem: 1e9}).Enabled())
+}
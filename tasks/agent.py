@@ -41,6 +41,7 @@ DEFAULT_BUILD_TAGS = [
     "netcgo",
     "systemd",
     "process",
+    "vsphere",
     "zk",
     "zlib",
     "secrets",
@@ -53,6 +54,7 @@ AGENT_CORECHECKS = [
     "docker",
     "file_handle",
     "go_expvar",
+    "http_json",
     "io",
     "jmx",
     "kubernetes_apiserver",
@@ -61,6 +63,8 @@ AGENT_CORECHECKS = [
     "ntp",
     "systemd",
     "uptime",
+    "vsphere",
+    "windows_iis",
     "winproc",
 ]
 
@@ -24,6 +24,7 @@ ALL_TAGS = set([
     "netcgo", # Force the use of the CGO resolver. This will also have the effect of making the binary non-static
     "process",
     "systemd",
+    "vsphere",
     "zk",
     "zlib",
     "secrets",